@@ -45,6 +45,7 @@
     .Values.sync.fromHost.secrets.enabled
     .Values.integrations.istio.enabled
     .Values.sync.toHost.namespaces.enabled
+    .Values.snapshots.customResources.enabled
     (include "vcluster.enableVolumeSnapshotRules" .)
      -}}
 {{- true -}}
@@ -27,5 +27,6 @@ func NewRestoreCommand() *cobra.Command {
 
 	cmd.Flags().BoolVar(&restoreClient.NewVCluster, "new-vcluster", false, "Restore a new vCluster from snapshot instead of restoring into an existing vCluster")
 	cmd.Flags().BoolVar(&restoreClient.RestoreVolumes, "restore-volumes", false, "Restore volumes from volume snapshots")
+	snapshot.AddSelectiveRestoreFlags(cmd.Flags(), &restoreClient.Selective)
 	return cmd
 }
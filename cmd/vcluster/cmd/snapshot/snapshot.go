@@ -28,6 +28,7 @@ func NewSnapshotCommand() *cobra.Command {
 	cmd.AddCommand(NewGetCmd())
 	cmd.AddCommand(NewListCmd())
 	cmd.AddCommand(NewDeleteCmd())
+	cmd.AddCommand(NewVolumeCmd())
 
 	return cmd
 }
@@ -0,0 +1,75 @@
+package snapshot
+
+import (
+	"fmt"
+
+	"github.com/loft-sh/vcluster/pkg/snapshot"
+	"github.com/spf13/cobra"
+)
+
+type volumeOptions struct {
+	URL  string
+	Path string
+}
+
+// NewVolumeCmd returns the commands that are run by the file level volume backup helper pods.
+func NewVolumeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "volume",
+		Short:  "back up and restore the files of a volume",
+		Args:   cobra.NoArgs,
+		Hidden: true,
+	}
+
+	cmd.AddCommand(newVolumeBackupCmd())
+	cmd.AddCommand(newVolumeRestoreCmd())
+
+	return cmd
+}
+
+func newVolumeBackupCmd() *cobra.Command {
+	options := &volumeOptions{}
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "back up the files of a volume",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			envOptions, err := snapshot.ParseOptionsFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to parse options from environment: %w", err)
+			}
+
+			return snapshot.BackupVolume(cmd.Context(), envOptions, options.URL, options.Path)
+		},
+	}
+
+	addVolumeFlags(cmd, options)
+	return cmd
+}
+
+func newVolumeRestoreCmd() *cobra.Command {
+	options := &volumeOptions{}
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "restore the files of a volume",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			envOptions, err := snapshot.ParseOptionsFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to parse options from environment: %w", err)
+			}
+
+			return snapshot.RestoreVolume(cmd.Context(), envOptions, options.URL, options.Path)
+		},
+	}
+
+	addVolumeFlags(cmd, options)
+	return cmd
+}
+
+func addVolumeFlags(cmd *cobra.Command, options *volumeOptions) {
+	cmd.Flags().StringVar(&options.URL, "url", "", "The url of the volume backup")
+	cmd.Flags().StringVar(&options.Path, "path", "", "The path the volume is mounted at")
+	_ = cmd.MarkFlagRequired("url")
+	_ = cmd.MarkFlagRequired("path")
+}
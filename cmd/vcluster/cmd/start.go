@@ -19,6 +19,7 @@ import (
 	setupconfig "github.com/loft-sh/vcluster/pkg/setup/config"
 	"github.com/loft-sh/vcluster/pkg/syncer/synccontext"
 	"github.com/loft-sh/vcluster/pkg/telemetry"
+	"github.com/loft-sh/vcluster/pkg/tracing"
 	"github.com/loft-sh/vcluster/pkg/util/osutil"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -85,6 +86,18 @@ func StartInCluster(ctx context.Context, options *StartOptions) error {
 		return err
 	}
 
+	// start tracing, this needs to happen before any client or plugin is created
+	shutdownTracing, err := tracing.Init(ctx, vClusterName, vConfig.ControlPlane.Advanced.Tracing)
+	if err != nil {
+		return fmt.Errorf("init tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			klog.Errorf("error shutting down tracing: %v", err)
+		}
+	}()
+	tracing.WrapConfig(vConfig.HostConfig)
+
 	// start telemetry
 	telemetry.StartControlPlane(vConfig)
 	defer telemetry.CollectorControlPlane.Flush()
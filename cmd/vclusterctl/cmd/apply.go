@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"github.com/loft-sh/log"
+	vclusterconfig "github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/pkg/cli"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/platform"
+	"github.com/loft-sh/vcluster/pkg/upgrade"
+	"github.com/spf13/cobra"
+)
+
+// ApplyCmd holds the apply cmd flags
+type ApplyCmd struct {
+	*flags.GlobalFlags
+	cli.ApplyOptions
+
+	log log.Logger
+}
+
+// NewApplyCmd creates a new command
+func NewApplyCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &ApplyCmd{
+		GlobalFlags: globalFlags,
+		log:         log.GetInstance(),
+	}
+
+	cobraCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Creates, upgrades and deletes virtual clusters from a fleet file",
+		Long: `#######################################################
+#################### vcluster apply ###################
+#######################################################
+Applies a fleet file that lists virtual clusters. Virtual
+clusters that don't exist yet are created, virtual clusters
+whose fleet entry has changed are upgraded and, with --prune,
+virtual clusters of the fleet that are no longer listed are
+deleted.
+
+Example fleet file:
+name: my-fleet
+vclusters:
+- name: team-a
+  namespace: team-a
+  chartVersion: 0.29.0
+  values:
+  - team-a.yaml
+  config:
+    sync:
+      toHost:
+        ingresses:
+          enabled: true
+  connector: my-database-connector
+  sleepMode:
+    enabled: true
+    autoSleep:
+      afterInactivity: 1h
+- name: team-b
+
+Example:
+vcluster apply -f fleet.yaml
+vcluster apply -f fleet.yaml --dry-run
+vcluster apply -f fleet.yaml --prune --concurrency 5
+#######################################################
+	`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, _ []string) error {
+			// Check for newer version
+			upgrade.PrintNewerVersionWarning()
+
+			return cmd.Run(cobraCmd)
+		},
+	}
+
+	cobraCmd.Flags().StringArrayVarP(&cmd.Files, "file", "f", []string{}, "Path of the fleet file to apply")
+	cobraCmd.Flags().BoolVar(&cmd.Prune, "prune", false, "If true, deletes the virtual clusters of the fleet that are not listed in the fleet file anymore")
+	cobraCmd.Flags().BoolVar(&cmd.DryRun, "dry-run", false, "If true, only prints which virtual clusters would be created, upgraded or deleted")
+	cobraCmd.Flags().IntVar(&cmd.Concurrency, "concurrency", 3, "How many virtual clusters are applied at the same time")
+	cobraCmd.Flags().StringVar(&cmd.ChartVersion, "chart-version", upgrade.GetVersion(), "The virtual cluster chart version to use if the fleet file doesn't specify one")
+	cobraCmd.Flags().StringVar(&cmd.ChartName, "chart-name", "vcluster", "The virtual cluster chart name to use")
+	cobraCmd.Flags().StringVar(&cmd.ChartRepo, "chart-repo", "", "The virtual cluster chart repo to use (empty = use embedded chart)")
+	cobraCmd.Flags().StringVar(&cmd.LocalChartDir, "local-chart-dir", "", "The virtual cluster local chart dir to use")
+	cobraCmd.Flags().BoolVar(&cmd.CreateNamespace, "create-namespace", true, "If true the namespaces will be created if they do not exist")
+	_ = cobraCmd.Flags().MarkHidden("local-chart-dir")
+	_ = cobraCmd.MarkFlagRequired("file")
+
+	return cobraCmd
+}
+
+// Run executes the functionality
+func (cmd *ApplyCmd) Run(cobraCmd *cobra.Command) error {
+	ctx := cobraCmd.Context()
+	cmd.Distro = vclusterconfig.K8SDistro
+	cmd.ExposeLocal = true
+
+	// the platform client is only used to clean up deleted virtual clusters in the platform
+	platformClient, err := platform.InitClientFromConfig(ctx, cmd.LoadedConfig(cmd.log))
+	if err != nil {
+		platformClient = nil
+	}
+
+	return cli.ApplyHelm(ctx, platformClient, &cmd.ApplyOptions, cmd.GlobalFlags, cmd.log)
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli"
+	"github.com/loft-sh/vcluster/pkg/cli/completion"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/cli/flags/create"
+	"github.com/loft-sh/vcluster/pkg/cli/util"
+	"github.com/loft-sh/vcluster/pkg/snapshot"
+	"github.com/loft-sh/vcluster/pkg/upgrade"
+	"github.com/spf13/cobra"
+)
+
+// CloneCmd holds the clone cmd flags
+type CloneCmd struct {
+	*flags.GlobalFlags
+	cli.CloneOptions
+
+	log log.Logger
+}
+
+// NewCloneCmd creates a new command
+func NewCloneCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &CloneCmd{
+		GlobalFlags: globalFlags,
+		log:         log.GetInstance(),
+	}
+
+	encryptionOptions := &snapshot.Options{}
+	useLine, nameValidator := util.NamedPositionalArgsValidator(true, false, "SOURCE_VCLUSTER_NAME", "VCLUSTER_NAME")
+	cobraCmd := &cobra.Command{
+		Use:   "clone" + useLine,
+		Short: "Clone a virtual cluster from a snapshot",
+		Long: `#######################################################
+#################### vcluster clone ###################
+#######################################################
+Clones a virtual cluster under a new name and namespace. The
+source virtual cluster is snapshotted, and the new virtual
+cluster is created from the Helm values stored in the
+snapshot and restored from it, including its volumes.
+
+The name and namespace of the source virtual cluster are
+replaced in the values under --rewrite-paths, further values
+can be changed with --patch. An encrypted snapshot is
+decrypted with the same --encryption-* flags, the secret of
+--encryption-key-secret is copied into the new namespace.
+
+A source virtual cluster with an external database or etcd
+can only be cloned if the clone is pointed to another
+datastore with --patch, as the restore wipes its data.
+
+Example:
+vcluster clone my-vcluster my-clone --namespace my-clone --snapshot s3://my-bucket/my-clone.tar.gz
+vcluster clone my-vcluster my-clone --snapshot s3://my-bucket/existing.tar.gz --use-existing-snapshot
+vcluster clone my-vcluster my-clone --snapshot s3://my-bucket/my-clone.tar.gz --encryption-key-secret my-snapshot-key
+vcluster clone my-vcluster my-clone --snapshot s3://my-bucket/my-clone.tar.gz --patch controlPlane.ingress.host=my-clone.example.com --patch sync.toHost.ingresses-
+vcluster clone my-vcluster my-clone --snapshot s3://my-bucket/my-clone.tar.gz --patch controlPlane.backingStore.database.external.dataSource=postgres://user:password@db:5432/my-clone
+#######################################################
+	`,
+		Args:              nameValidator,
+		ValidArgsFunction: completion.NewValidVClusterNameFunc(globalFlags),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			// Check for newer version
+			upgrade.PrintNewerVersionWarning()
+
+			cmd.Encryption = encryptionOptions.Encryption
+			return cli.CloneHelm(cobraCmd.Context(), &cmd.CloneOptions, cmd.GlobalFlags, args[0], args[1], cmd.log)
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&cmd.SourceNamespace, "source-namespace", "", "The namespace of the virtual cluster to clone")
+	cobraCmd.Flags().StringVar(&cmd.Snapshot, "snapshot", "", "The snapshot url to store the snapshot at or to clone from. E.g. --snapshot s3://my-bucket/my-key")
+	cobraCmd.Flags().BoolVar(&cmd.UseExistingSnapshot, "use-existing-snapshot", false, "If true, clones from the existing snapshot at --snapshot instead of taking a new one")
+	cobraCmd.Flags().BoolVar(&cmd.IncludeVolumes, "include-volumes", true, "If true, the volumes are snapshotted and restored as well")
+	cobraCmd.Flags().StringArrayVar(&cmd.Patches, "patch", []string{}, "Patch the values of the source virtual cluster. E.g. --patch controlPlane.ingress.host=my-clone.example.com or --patch sync.toHost.ingresses- to remove a value")
+	cobraCmd.Flags().StringSliceVar(&cmd.RewritePaths, "rewrite-paths", cli.DefaultCloneRewritePaths, "Values paths in which the name and namespace of the source virtual cluster are replaced with the new ones")
+	snapshot.AddEncryptionFlags(cobraCmd.Flags(), encryptionOptions, false)
+	cobraCmd.Flags().StringVar(&encryptionOptions.Encryption.AgeIdentity, "age-identity", "", "Age identity (AGE-SECRET-KEY-1...) used to decrypt a snapshot that is encrypted for --age-recipient")
+	create.AddCommonFlags(cobraCmd, &cmd.CreateOptions)
+	create.AddHelmFlags(cobraCmd, &cmd.CreateOptions)
+	_ = cobraCmd.Flags().MarkHidden("restore")
+	_ = cobraCmd.MarkFlagRequired("snapshot")
+
+	return cobraCmd
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/spf13/cobra"
+)
+
+func NewConfigCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect virtual cluster config values",
+		Long: `#######################################################
+################### vcluster config ###################
+#######################################################
+	`,
+		Args: cobra.NoArgs,
+	}
+
+	configCmd.AddCommand(diff(globalFlags))
+	configCmd.AddCommand(lint(globalFlags))
+	return configCmd
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli"
+	"github.com/loft-sh/vcluster/pkg/cli/completion"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/cli/util"
+	"github.com/spf13/cobra"
+)
+
+type diffCmd struct {
+	*flags.GlobalFlags
+	cli.ConfigDiffOptions
+
+	log log.Logger
+}
+
+func diff(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &diffCmd{
+		GlobalFlags: globalFlags,
+		log:         log.GetInstance(),
+	}
+
+	useLine, nameValidator := util.NamedPositionalArgsValidator(true, false, "VCLUSTER_NAME")
+	cobraCmd := &cobra.Command{
+		Use:   "diff" + useLine,
+		Short: "Shows the differences between the config of a running virtual cluster and the desired config",
+		Long: `##############################################################
+#################### vcluster config diff ####################
+##############################################################
+Shows the differences between the config of a running virtual
+cluster and the given values files. Without "-f" the running
+config is compared to the chart defaults.
+
+Both sides are merged with the chart defaults of this CLI
+version before they are compared. Every change is flagged
+if it restarts the control plane, is destructive (e.g.
+switching the distro or backing store) or is ignored because
+the feature is not available in this edition.
+
+Examples:
+vcluster config diff test -f vcluster.yaml
+vcluster config diff test -f vcluster.yaml --set sync.toHost.ingresses.enabled=true
+vcluster config diff test -f vcluster.yaml -o json --exit-code
+##############################################################
+	`,
+		Args:              nameValidator,
+		ValidArgsFunction: completion.NewValidVClusterNameFunc(globalFlags),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cli.ConfigDiffHelm(cobraCmd.Context(), cmd.GlobalFlags, args[0], &cmd.ConfigDiffOptions, cmd.log)
+		}}
+
+	cobraCmd.Flags().StringArrayVarP(&cmd.Values, "values", "f", []string{}, "Path to the desired vcluster.yaml, can be specified multiple times")
+	cobraCmd.Flags().StringArrayVar(&cmd.SetValues, "set", []string{}, "Set desired values. E.g. --set 'sync.toHost.ingresses.enabled=true'")
+	cobraCmd.Flags().StringVarP(&cmd.Output, "output", "o", "table", "Choose the format of the output. [table|json]")
+	cobraCmd.Flags().BoolVar(&cmd.ExitCode, "exit-code", false, "Exit with an error if there are differences")
+	flags.AddContextFlags(cobraCmd.Flags(), &cmd.Contexts)
+
+	return cobraCmd
+}
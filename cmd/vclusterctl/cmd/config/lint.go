@@ -0,0 +1,65 @@
+package config
+
+import (
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/spf13/cobra"
+)
+
+type lintCmd struct {
+	*flags.GlobalFlags
+	cli.ConfigLintOptions
+
+	log log.Logger
+}
+
+func lint(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &lintCmd{
+		GlobalFlags: globalFlags,
+		log:         log.GetInstance(),
+	}
+
+	cobraCmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Checks virtual cluster config values for risky or contradictory settings",
+		Long: `##############################################################
+#################### vcluster config lint ####################
+##############################################################
+Checks the given virtual cluster config values for risky or
+contradictory settings, e.g. sleep mode without a trigger,
+conflicting backing stores, patches that are not available
+in this edition, deprecated fields or RBAC rules with
+wildcards. The values are merged with the chart defaults
+before they are checked.
+
+With "--check-host" the current kube context is used to
+check that the host namespaces referenced by fromHost
+mappings exist. Use "--pro" if the virtual cluster runs with
+the pro features, e.g. because it is created by the platform.
+
+Use "-o sarif" or "-o json" to process the findings in a CI
+pipeline. The command fails if there are findings with the
+severity given by "--fail-on" or higher.
+
+Examples:
+vcluster config lint -f vcluster.yaml
+vcluster config lint -f vcluster.yaml --check-host --fail-on warning
+vcluster config lint -f vcluster.yaml -o sarif > vcluster.sarif
+##############################################################
+	`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, _ []string) error {
+			return cli.ConfigLint(cobraCmd.Context(), cmd.GlobalFlags, &cmd.ConfigLintOptions, cmd.log)
+		}}
+
+	cobraCmd.Flags().StringArrayVarP(&cmd.Values, "values", "f", []string{}, "Path to the vcluster.yaml to check, can be specified multiple times")
+	cobraCmd.Flags().StringArrayVar(&cmd.SetValues, "set", []string{}, "Set additional values. E.g. --set 'sync.toHost.ingresses.enabled=true'")
+	cobraCmd.Flags().StringVarP(&cmd.Output, "output", "o", "text", "Choose the format of the output. [text|json|sarif]")
+	cobraCmd.Flags().StringVar(&cmd.FailOn, "fail-on", "error", "Fail if there are findings with this severity or higher. [info|warning|error|never]")
+	cobraCmd.Flags().StringSliceVar(&cmd.DisabledRules, "disable-rule", []string{}, "Rules to skip, can be specified multiple times")
+	cobraCmd.Flags().BoolVar(&cmd.CheckHost, "check-host", false, "Check the host namespaces referenced by fromHost mappings in the current kube context")
+	cobraCmd.Flags().BoolVar(&cmd.ProFeatures, "pro", false, "The virtual cluster runs with the pro features, so values that require them are not flagged")
+
+	return cobraCmd
+}
@@ -46,6 +46,13 @@ vcluster connect test --namespace test
 # Open a new bash with the vcluster KUBECONFIG defined
 vcluster connect test -n test -- bash
 vcluster connect test -n test -- kubectl get ns
+# Find the virtual cluster in any context of the kube config
+vcluster connect test --all-contexts
+# Keep the port-forwarding running in the background
+vcluster connect test -n test --background
+# Expose the virtual cluster via a load balancer or an ingress instead of port-forwarding
+vcluster connect test -n test --expose=loadbalancer
+vcluster connect test -n test --expose=ingress --expose-host=vcluster.example.com
 #######################################################
 	`,
 		Args:              nameValidator,
@@ -68,6 +75,7 @@ vcluster connect test -n test -- kubectl get ns
 	cobraCmd.Flags().StringVar(&cmd.Driver, "driver", "", "The driver to use for managing the virtual cluster, can be either helm or platform.")
 
 	connect.AddCommonFlags(cobraCmd, &cmd.ConnectOptions)
+	flags.AddContextFlags(cobraCmd.Flags(), &cmd.Contexts)
 	connect.AddPlatformFlags(cobraCmd, &cmd.ConnectOptions, "[PLATFORM] ")
 
 	return cobraCmd
@@ -96,6 +104,10 @@ func (cmd *ConnectCmd) Run(ctx context.Context, args []string) error {
 	}
 
 	if driverType == config.PlatformDriver {
+		if cmd.Expose != "" || cmd.Background {
+			return fmt.Errorf("--expose and --background are only supported with driver type %s", config.HelmDriver)
+		}
+
 		return cli.ConnectPlatform(ctx, &cmd.ConnectOptions, cmd.GlobalFlags, vClusterName, args[1:], cmd.Log)
 	}
 
@@ -120,6 +132,14 @@ func (cmd *ConnectCmd) validateFlags() error {
 		return fmt.Errorf("expected --service-account to be defined as well")
 	}
 
+	if err := cli.ValidateExposeOptions(&cmd.ConnectOptions); err != nil {
+		return err
+	}
+
+	if cmd.Background && (cmd.Server != "" || cmd.Expose != "") {
+		return fmt.Errorf("--background cannot be used together with --server or --expose")
+	}
+
 	return nil
 }
 
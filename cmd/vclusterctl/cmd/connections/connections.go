@@ -0,0 +1,27 @@
+package connections
+
+import (
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/spf13/cobra"
+)
+
+func NewConnectionsCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	connectionsCmd := &cobra.Command{
+		Use:   "connections",
+		Short: "Manage background connections to virtual clusters",
+		Long: `#######################################################
+################ vcluster connections #################
+#######################################################
+Background connections are started with
+"vcluster connect --background". A user-level daemon keeps
+their port-forwardings running and reconnects them after
+pod restarts or network changes.
+#######################################################
+	`,
+		Args: cobra.NoArgs,
+	}
+
+	connectionsCmd.AddCommand(list(globalFlags))
+	connectionsCmd.AddCommand(daemon())
+	return connectionsCmd
+}
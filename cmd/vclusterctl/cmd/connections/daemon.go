@@ -0,0 +1,33 @@
+package connections
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli/connections"
+	"github.com/spf13/cobra"
+)
+
+func daemon() *cobra.Command {
+	return &cobra.Command{
+		Use:    "daemon",
+		Short:  "Runs the connection daemon",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, _ []string) error {
+			ctx, cancel := signal.NotifyContext(cobraCmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+
+			dir, err := connections.Dir()
+			if err != nil {
+				return err
+			}
+
+			daemon := connections.NewDaemon(filepath.Join(dir, connections.StateFileName), log.GetInstance())
+			return daemon.Run(ctx, filepath.Join(dir, connections.SocketFileName))
+		},
+	}
+}
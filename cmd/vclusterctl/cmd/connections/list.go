@@ -0,0 +1,47 @@
+package connections
+
+import (
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli/connections"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/spf13/cobra"
+)
+
+type listCmd struct {
+	*flags.GlobalFlags
+	connections.ListOptions
+
+	log log.Logger
+}
+
+func list(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &listCmd{
+		GlobalFlags: globalFlags,
+		log:         log.GetInstance(),
+	}
+
+	cobraCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "Lists the background connections to virtual clusters",
+		Long: `#######################################################
+############# vcluster connections list ###############
+#######################################################
+Lists the background connections of the connection daemon
+together with their status, the pod they forward to and
+how often they were reconnected.
+
+Example:
+vcluster connections list
+vcluster connections list --output json
+#######################################################
+	`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, _ []string) error {
+			return connections.List(cobraCmd.Context(), &cmd.ListOptions, cmd.log)
+		},
+	}
+
+	cobraCmd.Flags().StringVarP(&cmd.Output, "output", "o", "table", "Choose the format of the output. [table|json]")
+	return cobraCmd
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/loft-sh/log"
+	"github.com/loft-sh/log/terminal"
 	"github.com/loft-sh/vcluster/pkg/cli"
 	"github.com/loft-sh/vcluster/pkg/cli/config"
 	"github.com/loft-sh/vcluster/pkg/cli/flags"
@@ -42,6 +43,7 @@ Creates a new virtual cluster
 
 Example:
 vcluster create test --namespace test
+vcluster create test --namespace test --interactive
 #######################################################
 	`,
 		RunE: func(cobraCmd *cobra.Command, args []string) error {
@@ -89,6 +91,27 @@ func (cmd *CreateCmd) Run(cobraCmd *cobra.Command, args []string) error {
 
 	ctx := cobraCmd.Context()
 
+	// run the wizard and pass the resulting values file to the create
+	if cmd.Interactive {
+		if !terminal.IsTerminalIn {
+			return errors.New("--interactive requires an interactive terminal")
+		}
+
+		result, err := cli.CreateWizard(args[0], &cli.CreateWizardOptions{
+			Values:      cmd.Values,
+			SetValues:   cmd.SetValues,
+			ProFeatures: driver == config.PlatformDriver,
+		}, cmd.log)
+		if err != nil {
+			return err
+		} else if !result.Create {
+			cmd.log.Infof("Run %q to create the virtual cluster later", fmt.Sprintf("vcluster create %s -f %s", args[0], result.ValuesFile))
+			return nil
+		}
+
+		cmd.Values = append(cmd.Values, result.ValuesFile)
+	}
+
 	// check if there is a platform client or we skip the info message
 	_, err = platform.InitClientFromConfig(ctx, cfg)
 	if err == nil {
@@ -41,6 +41,7 @@ Deletes a virtual cluster
 
 Example:
 vcluster delete test --namespace test
+vcluster delete test --context-selector "staging-*"
 #######################################################
 	`,
 		Args:              util.VClusterNameOnlyValidator,
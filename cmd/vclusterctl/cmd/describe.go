@@ -8,6 +8,7 @@ import (
 	"github.com/loft-sh/log"
 	"github.com/loft-sh/vcluster/pkg/cli"
 	"github.com/loft-sh/vcluster/pkg/cli/config"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
 	"github.com/loft-sh/vcluster/pkg/cli/flags"
 	pdefaults "github.com/loft-sh/vcluster/pkg/platform/defaults"
 	"github.com/spf13/cobra"
@@ -21,6 +22,7 @@ type DescribeCmd struct {
 	log        log.Logger
 	project    string
 	configOnly bool
+	contexts   find.ContextOptions
 }
 
 // NewDescribeCmd creates a new command
@@ -44,6 +46,7 @@ describes a virtual cluster
 Example:
 vcluster describe test
 vcluster describe -o json test
+vcluster describe test --all-contexts
 #######################################################
 	`,
 		Args: cobra.ExactArgs(1),
@@ -57,6 +60,7 @@ vcluster describe -o json test
 	cobraCmd.Flags().StringVarP(&cmd.output, "output", "o", "", "The format to use to display the information, can either be json or yaml")
 	cobraCmd.Flags().StringVarP(&cmd.project, "project", "p", p, "The project to use")
 	cobraCmd.Flags().BoolVar(&cmd.configOnly, "config-only", false, "Return only the vcluster.yaml configuration")
+	flags.AddContextFlags(cobraCmd.Flags(), &cmd.contexts)
 
 	return cobraCmd
 }
@@ -84,5 +88,5 @@ func (cmd *DescribeCmd) Run(cobraCmd *cobra.Command, driver, name string) error
 		return cli.DescribePlatform(cobraCmd.Context(), cmd.GlobalFlags, os.Stdout, cmd.log, name, cmd.project, cmd.configOnly, cmd.output)
 	}
 
-	return cli.DescribeHelm(cobraCmd.Context(), cmd.GlobalFlags, os.Stdout, cmd.log, name, cmd.configOnly, cmd.output)
+	return cli.DescribeHelm(cobraCmd.Context(), cmd.GlobalFlags, &cmd.contexts, os.Stdout, cmd.log, name, cmd.configOnly, cmd.output)
 }
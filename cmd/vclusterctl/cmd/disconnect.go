@@ -1,11 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
 	"github.com/loft-sh/log"
 	"github.com/loft-sh/log/survey"
+	"github.com/loft-sh/vcluster/pkg/cli"
 	"github.com/loft-sh/vcluster/pkg/cli/find"
 	"github.com/loft-sh/vcluster/pkg/cli/flags"
 	"github.com/spf13/cobra"
@@ -35,7 +37,10 @@ func NewDisconnectCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
 #######################################################
 Disconnect switches back the kube context if
 "vcluster connect --update-current" or "vcluster platform
-connect" was used
+connect" was used. If the vCluster was connected with
+"vcluster connect --background", the background connection
+is stopped and its kube context removed, this also works
+for custom context names.
 
 Example:
 vcluster connect --update-current
@@ -43,8 +48,8 @@ vcluster disconnect
 #######################################################
 	`,
 		Args: cobra.NoArgs,
-		RunE: func(_ *cobra.Command, _ []string) error {
-			return cmd.Run()
+		RunE: func(cobraCmd *cobra.Command, _ []string) error {
+			return cmd.Run(cobraCmd.Context())
 		},
 	}
 
@@ -52,7 +57,7 @@ vcluster disconnect
 }
 
 // Run executes the functionality
-func (cmd *DisconnectCmd) Run() error {
+func (cmd *DisconnectCmd) Run(ctx context.Context) error {
 	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{
 		CurrentContext: cmd.Context,
 	})
@@ -75,9 +80,17 @@ func (cmd *DisconnectCmd) Run() error {
 	if vClusterName == "" {
 		// get vCluster platform info from context
 		vClusterName, _, otherContext = find.VClusterPlatformFromContext(cmd.Context)
-		if vClusterName == "" {
-			return fmt.Errorf("current selected context %q is not a virtual cluster context. If you've used a custom context name you will need to switch manually using kubectl", otherContext)
+	}
+	if vClusterName == "" {
+		// background connections can use a custom context name, so ask the connection daemon
+		connection, err := cli.BackgroundConnection(ctx, cmd.Context)
+		if err != nil {
+			return err
+		} else if connection == nil {
+			return fmt.Errorf("current selected context %q is not a virtual cluster context. If you've used a custom context name you will need to switch manually using kubectl", cmd.Context)
 		}
+
+		otherContext = connection.HostContext
 	}
 
 	if cfg.PreviousContext != "" {
@@ -103,6 +116,13 @@ func (cmd *DisconnectCmd) Run() error {
 		}
 	}
 
+	stopped, err := cli.DisconnectBackground(ctx, cmd.Context, otherContext)
+	if err != nil {
+		return err
+	} else if stopped {
+		cmd.log.Infof("Stopped the background connection and removed kube context %s", cmd.Context)
+	}
+
 	cmd.log.Infof("Successfully disconnected and switched back to the original context: %s", otherContext)
 	return nil
 }
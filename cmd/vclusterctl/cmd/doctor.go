@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli/completion"
+	"github.com/loft-sh/vcluster/pkg/cli/doctor"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/cli/util"
+	"github.com/spf13/cobra"
+)
+
+// DoctorCmd holds the doctor cmd flags
+type DoctorCmd struct {
+	*flags.GlobalFlags
+	doctor.Options
+
+	log log.Logger
+}
+
+// NewDoctorCmd creates a new command
+func NewDoctorCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &DoctorCmd{
+		GlobalFlags: globalFlags,
+		log:         log.GetInstance(),
+	}
+
+	useLine, nameValidator := util.NamedPositionalArgsValidator(true, true, "VCLUSTER_NAME")
+	cobraCmd := &cobra.Command{
+		Use:   "doctor" + useLine,
+		Short: "Runs health checks against a virtual cluster",
+		Long: `#######################################################
+################### vcluster doctor ###################
+#######################################################
+Runs health checks against the host and the virtual
+cluster and prints pass, warn or fail for each check
+together with a hint how to fix it:
+
+control-plane   control plane pods are ready and not restarting
+backing-store   etcd or the external database is reachable
+certificates    certificates are not expired or expiring soon
+syncer-errors   no sync errors in the virtual cluster events
+mappings        synced host objects match the virtual cluster
+coredns         coredns pods are ready
+pending-pods    pending pods and their host side reasons
+
+The command exits with an error if a check fails.
+
+Example:
+vcluster doctor my-vcluster
+vcluster doctor my-vcluster -n my-namespace --output json
+#######################################################
+	`,
+		Args:              nameValidator,
+		ValidArgsFunction: completion.NewValidVClusterNameFunc(globalFlags),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return doctor.Run(cobraCmd.Context(), args[0], cmd.GlobalFlags, &cmd.Options, cmd.log)
+		},
+	}
+
+	cobraCmd.Flags().StringVarP(&cmd.Output, "output", "o", "table", "Choose the format of the output. [table|json]")
+	return cobraCmd
+}
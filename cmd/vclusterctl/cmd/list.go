@@ -38,6 +38,8 @@ Example:
 vcluster list
 vcluster list --output json
 vcluster list --namespace test
+vcluster list --all-contexts
+vcluster list --context-selector "prod-*" --output json
 #######################################################
 	`,
 		Args:    cobra.NoArgs,
@@ -49,6 +51,7 @@ vcluster list --namespace test
 
 	cobraCmd.Flags().StringVar(&cmd.Driver, "driver", "", "The driver to use for managing the virtual cluster, can be either helm or platform.")
 	cobraCmd.Flags().StringVar(&cmd.Output, "output", "table", "Choose the format of the output. [table|json]")
+	flags.AddContextFlags(cobraCmd.Flags(), &cmd.Contexts)
 
 	return cobraCmd
 }
@@ -0,0 +1,53 @@
+package rbac
+
+import (
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli/completion"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/cli/rbac"
+	"github.com/loft-sh/vcluster/pkg/cli/util"
+	"github.com/spf13/cobra"
+)
+
+type previewCmd struct {
+	*flags.GlobalFlags
+	rbac.PreviewOptions
+
+	log log.Logger
+}
+
+func preview(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &previewCmd{
+		GlobalFlags: globalFlags,
+		log:         log.GetInstance(),
+	}
+
+	useLine, nameValidator := util.NamedPositionalArgsValidator(true, false, "VCLUSTER_NAME")
+	previewCmd := &cobra.Command{
+		Use:   "preview" + useLine,
+		Short: "Preview the virtual permissions of a host user",
+		Long: `##############################################################
+################### vcluster rbac preview ####################
+##############################################################
+Shows the virtual roles a host user and its groups receive
+through rbac.identityMappings.
+
+Examples:
+vcluster rbac preview test --user alice --group team-a-devs
+vcluster rbac preview test --group team-a-devs --rules
+vcluster rbac preview test --user oidc:alice
+##############################################################
+	`,
+		Args:              nameValidator,
+		ValidArgsFunction: completion.NewValidVClusterNameFunc(globalFlags),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return rbac.Preview(cobraCmd.Context(), args[0], cmd.GlobalFlags, cmd.PreviewOptions, cmd.log)
+		}}
+
+	previewCmd.Flags().StringVar(&cmd.User, "user", "", "The host user name as authenticated by the host api server, including a prefix like oidc:")
+	previewCmd.Flags().StringSliceVar(&cmd.Groups, "group", []string{}, "The host groups of the user as authenticated by the host api server. Can be specified multiple times")
+	previewCmd.Flags().BoolVar(&cmd.Rules, "rules", false, "If enabled, resolves the rules of the bound roles within the virtual cluster")
+	previewCmd.Flags().StringVar(&cmd.Output, "output", "table", "Choose the format of the output. [table|json]")
+
+	return previewCmd
+}
@@ -0,0 +1,21 @@
+package rbac
+
+import (
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/spf13/cobra"
+)
+
+func NewRBACCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	rbacCmd := &cobra.Command{
+		Use:   "rbac",
+		Short: "vCluster rbac subcommands",
+		Long: `#######################################################
+#################### vcluster rbac ####################
+#######################################################
+	`,
+		Args: cobra.NoArgs,
+	}
+
+	rbacCmd.AddCommand(preview(globalFlags))
+	return rbacCmd
+}
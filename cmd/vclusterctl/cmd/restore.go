@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"errors"
+
 	"github.com/loft-sh/log"
 	"github.com/loft-sh/vcluster/pkg/cli"
 	"github.com/loft-sh/vcluster/pkg/cli/completion"
@@ -17,6 +19,7 @@ type RestoreCmd struct {
 	Snapshot       snapshot.Options
 	Pod            pod.Options
 	RestoreVolumes bool
+	Selective      snapshot.SelectiveRestoreOptions
 
 	Log log.Logger
 }
@@ -44,17 +47,37 @@ vcluster restore my-vcluster oci://ghcr.io/my-user/my-repo:my-tag
 vcluster restore my-vcluster s3://my-bucket/my-bucket-key
 # Restore from vCluster container filesystem
 vcluster restore my-vcluster container:///data/my-local-snapshot.tar.gz
+# Restore a single namespace into the running vCluster
+vcluster restore my-vcluster s3://my-bucket/my-bucket-key --include-namespaces my-namespace
+# Show which config maps and secrets would be restored
+vcluster restore my-vcluster s3://my-bucket/my-bucket-key --include-kinds configmaps,secrets --dry-run
 #######################################################
 	`,
 		Args:              nameValidator,
 		ValidArgsFunction: completion.NewValidVClusterNameFunc(globalFlags),
 		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			if cmd.Selective.Enabled() {
+				if cmd.RestoreVolumes {
+					return errors.New("--restore-volumes cannot be used with a selective restore")
+				}
+
+				return cli.RestoreSelective(cobraCmd.Context(), args, cmd.GlobalFlags, &cmd.Snapshot, &cmd.Selective, cmd.Log)
+			}
+
+			err := cmd.Selective.Validate()
+			if err != nil {
+				return err
+			}
+
 			return cli.Restore(cobraCmd.Context(), args, cmd.GlobalFlags, &cmd.Snapshot, &cmd.Pod, false, cmd.RestoreVolumes, cmd.Log)
 		},
 	}
 
 	// add storage flags
 	pod.AddFlags(cobraCmd.Flags(), &cmd.Pod, true)
+	snapshot.AddEncryptionFlags(cobraCmd.Flags(), &cmd.Snapshot, true)
 	cobraCmd.Flags().BoolVar(&cmd.RestoreVolumes, "restore-volumes", false, "Restore volumes from volume snapshots")
+	cobraCmd.Flags().BoolVar(&cmd.Snapshot.SkipVerify, "skip-verify", false, "Skip verifying the snapshot against its manifest before restoring. Incremental snapshots always need to be verified")
+	snapshot.AddSelectiveRestoreFlags(cobraCmd.Flags(), &cmd.Selective)
 	return cobraCmd
 }
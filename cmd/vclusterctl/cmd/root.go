@@ -9,12 +9,15 @@ import (
 
 	"github.com/loft-sh/log"
 	"github.com/loft-sh/vcluster/cmd/vclusterctl/cmd/certs"
+	cmdconfig "github.com/loft-sh/vcluster/cmd/vclusterctl/cmd/config"
+	"github.com/loft-sh/vcluster/cmd/vclusterctl/cmd/connections"
 	"github.com/loft-sh/vcluster/cmd/vclusterctl/cmd/convert"
 	"github.com/loft-sh/vcluster/cmd/vclusterctl/cmd/credits"
 	"github.com/loft-sh/vcluster/cmd/vclusterctl/cmd/debug"
 	"github.com/loft-sh/vcluster/cmd/vclusterctl/cmd/node"
 	cmdplatform "github.com/loft-sh/vcluster/cmd/vclusterctl/cmd/platform"
 	"github.com/loft-sh/vcluster/cmd/vclusterctl/cmd/platform/set"
+	"github.com/loft-sh/vcluster/cmd/vclusterctl/cmd/rbac"
 	"github.com/loft-sh/vcluster/cmd/vclusterctl/cmd/registry"
 	"github.com/loft-sh/vcluster/cmd/vclusterctl/cmd/snapshot"
 	cmdtelemetry "github.com/loft-sh/vcluster/cmd/vclusterctl/cmd/telemetry"
@@ -120,18 +123,25 @@ func BuildRoot(log log.Logger) (*cobra.Command, *flags.GlobalFlags, error) {
 	// add top level commands
 	rootCmd.AddCommand(NewConnectCmd(globalFlags))
 	rootCmd.AddCommand(NewCreateCmd(globalFlags))
+	rootCmd.AddCommand(NewCloneCmd(globalFlags))
+	rootCmd.AddCommand(NewApplyCmd(globalFlags))
 	rootCmd.AddCommand(NewListCmd(globalFlags))
 	rootCmd.AddCommand(NewDescribeCmd(globalFlags, defaults))
 	rootCmd.AddCommand(NewDeleteCmd(globalFlags))
 	rootCmd.AddCommand(NewPauseCmd(globalFlags))
 	rootCmd.AddCommand(NewResumeCmd(globalFlags))
 	rootCmd.AddCommand(NewDisconnectCmd(globalFlags))
+	rootCmd.AddCommand(connections.NewConnectionsCmd(globalFlags))
 	rootCmd.AddCommand(NewUpgradeCmd())
+	rootCmd.AddCommand(NewUpgradeClusterCmd(globalFlags))
 	rootCmd.AddCommand(snapshot.NewSnapshot(globalFlags))
 	rootCmd.AddCommand(NewRestore(globalFlags))
 	rootCmd.AddCommand(use.NewUseCmd(globalFlags))
 	rootCmd.AddCommand(debug.NewDebugCommand(globalFlags))
+	rootCmd.AddCommand(NewDoctorCmd(globalFlags))
+	rootCmd.AddCommand(NewTopCmd(globalFlags))
 	rootCmd.AddCommand(convert.NewConvertCmd(globalFlags))
+	rootCmd.AddCommand(cmdconfig.NewConfigCmd(globalFlags))
 	rootCmd.AddCommand(cmdtelemetry.NewTelemetryCmd(globalFlags))
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(NewInfoCmd(globalFlags))
@@ -140,6 +150,7 @@ func BuildRoot(log log.Logger) (*cobra.Command, *flags.GlobalFlags, error) {
 	rootCmd.AddCommand(node.NewNodeCmd(globalFlags))
 	rootCmd.AddCommand(registry.NewRegistryCmd(globalFlags))
 	rootCmd.AddCommand(certs.NewCertsCmd(globalFlags))
+	rootCmd.AddCommand(rbac.NewRBACCmd(globalFlags))
 
 	// add platform commands
 	platformCmd, err := cmdplatform.NewPlatformCmd(globalFlags)
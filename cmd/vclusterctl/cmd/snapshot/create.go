@@ -41,6 +41,14 @@ vcluster snapshot create my-vcluster oci://ghcr.io/my-user/my-repo:my-tag
 vcluster snapshot create my-vcluster s3://my-bucket/my-bucket-key
 # Snapshot to vCluster container filesystem
 vcluster snapshot create my-vcluster container:///data/my-local-snapshot.tar.gz
+# Snapshot to a filesystem mounted into the vCluster, e.g. a shared nfs volume
+vcluster snapshot create my-vcluster file:///mnt/snapshots/my-snapshot.tar.gz
+# Snapshot to google cloud storage bucket
+vcluster snapshot create my-vcluster gs://my-bucket/my-bucket-key
+# Snapshot to azure blob storage container
+vcluster snapshot create my-vcluster azblob://my-container/my-blob?account=my-account
+# Incremental snapshot that only contains the changes since a previous snapshot
+vcluster snapshot create my-vcluster s3://my-bucket/snapshot-2.tar.gz --incremental-base s3://my-bucket/snapshot-1.tar.gz
 ##############################################################
 	`,
 		Args:              nameValidator,
@@ -0,0 +1,50 @@
+package snapshot
+
+import (
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli"
+	"github.com/loft-sh/vcluster/pkg/cli/completion"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/spf13/cobra"
+)
+
+type DescribeCmd struct {
+	*flags.GlobalFlags
+	cli.SnapshotResourcesOptions
+
+	Log log.Logger
+}
+
+func NewDescribeCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &DescribeCmd{
+		GlobalFlags: globalFlags,
+		Log:         log.GetInstance(),
+	}
+
+	describeCmd := &cobra.Command{
+		Use:   "describe VCLUSTER_NAME NAME",
+		Short: "Describe a snapshot or restore of a virtual cluster",
+		Long: `##############################################################
+################# vcluster snapshot describe #################
+##############################################################
+Describe a VirtualClusterSnapshot or VirtualClusterRestore of a
+virtual cluster, including the status of its volumes and its
+conditions. Requires snapshots.customResources.enabled in the
+vCluster config.
+
+Example:
+vcluster snapshot describe my-vcluster my-snapshot
+vcluster snapshot describe my-vcluster my-restore --restore
+##############################################################
+	`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completion.NewValidVClusterNameFunc(globalFlags),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cli.DescribeSnapshotResource(cobraCmd.Context(), args[0], args[1], cmd.GlobalFlags, &cmd.SnapshotResourcesOptions, cmd.Log)
+		},
+	}
+
+	describeCmd.Flags().BoolVar(&cmd.Restores, "restore", false, "If enabled, describes a VirtualClusterRestore instead of a VirtualClusterSnapshot")
+	describeCmd.Flags().StringVarP(&cmd.Output, "output", "o", "", "Print the resource as json or yaml instead of a description")
+	return describeCmd
+}
@@ -0,0 +1,56 @@
+package snapshot
+
+import (
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/snapshot"
+	"github.com/spf13/cobra"
+)
+
+type DiffCmd struct {
+	*flags.GlobalFlags
+
+	Snapshot snapshot.Options
+	VCluster string
+	Output   string
+
+	Log log.Logger
+}
+
+func NewDiffCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &DiffCmd{
+		GlobalFlags: globalFlags,
+		Log:         log.GetInstance(),
+	}
+
+	diffCmd := &cobra.Command{
+		Use:   "diff SNAPSHOT_URL [SNAPSHOT_URL]",
+		Short: "Compare a virtual cluster snapshot with another snapshot or a running virtual cluster",
+		Long: `##############################################################
+################## vcluster snapshot diff ####################
+##############################################################
+Compare the objects of a virtual cluster snapshot with the
+objects of another snapshot or a running virtual cluster.
+Added, removed and changed objects are printed as YAML diffs.
+Objects managed by the control plane or owned by other
+objects are not compared.
+
+Example:
+# Compare two snapshots
+vcluster snapshot diff s3://my-bucket/monday s3://my-bucket/tuesday
+# Compare a snapshot with the running vCluster
+vcluster snapshot diff oci://ghcr.io/my-user/my-repo:my-tag --vcluster my-vcluster
+##############################################################
+	`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cli.DiffSnapshots(cobraCmd.Context(), args, cmd.VCluster, cmd.GlobalFlags, &cmd.Snapshot, cmd.Output, cobraCmd.OutOrStdout(), cmd.Log)
+		},
+	}
+
+	diffCmd.Flags().StringVar(&cmd.VCluster, "vcluster", "", "Compare the snapshot with this running vCluster")
+	diffCmd.Flags().StringVarP(&cmd.Output, "output", "o", "", "The format to use to display the differences, can be json")
+	snapshot.AddEncryptionFlags(diffCmd.Flags(), &cmd.Snapshot, true)
+	return diffCmd
+}
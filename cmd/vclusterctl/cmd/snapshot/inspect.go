@@ -0,0 +1,49 @@
+package snapshot
+
+import (
+	"github.com/loft-sh/vcluster/pkg/cli"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/snapshot"
+	"github.com/spf13/cobra"
+)
+
+type InspectCmd struct {
+	*flags.GlobalFlags
+
+	Snapshot snapshot.Options
+	Output   string
+}
+
+func NewInspectCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &InspectCmd{
+		GlobalFlags: globalFlags,
+	}
+
+	inspectCmd := &cobra.Command{
+		Use:   "inspect SNAPSHOT_URL",
+		Short: "Inspect the contents of a virtual cluster snapshot",
+		Long: `##############################################################
+################# vcluster snapshot inspect ##################
+##############################################################
+Inspect the contents of a virtual cluster snapshot without
+restoring it. Prints the contained namespaces, the number of
+objects per kind, the vCluster release and config as well as
+the included volume snapshots.
+
+Example:
+# Inspect snapshot from oci image
+vcluster snapshot inspect oci://ghcr.io/my-user/my-repo:my-tag
+# Inspect snapshot from s3 bucket as json
+vcluster snapshot inspect s3://my-bucket/my-bucket-key -o json
+##############################################################
+	`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cli.InspectSnapshot(cobraCmd.Context(), args[0], &cmd.Snapshot, cmd.Output, cobraCmd.OutOrStdout())
+		},
+	}
+
+	inspectCmd.Flags().StringVarP(&cmd.Output, "output", "o", "", "The format to use to display the information, can either be json or yaml")
+	snapshot.AddEncryptionFlags(inspectCmd.Flags(), &cmd.Snapshot, true)
+	return inspectCmd
+}
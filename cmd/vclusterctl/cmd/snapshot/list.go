@@ -0,0 +1,51 @@
+package snapshot
+
+import (
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli"
+	"github.com/loft-sh/vcluster/pkg/cli/completion"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/spf13/cobra"
+)
+
+type ListCmd struct {
+	*flags.GlobalFlags
+	cli.SnapshotResourcesOptions
+
+	Log log.Logger
+}
+
+func NewListCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &ListCmd{
+		GlobalFlags: globalFlags,
+		Log:         log.GetInstance(),
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list VCLUSTER_NAME",
+		Short: "List the snapshots or restores of a virtual cluster",
+		Long: `##############################################################
+################### vcluster snapshot list ###################
+##############################################################
+List the VirtualClusterSnapshots or VirtualClusterRestores of a
+virtual cluster. Requires snapshots.customResources.enabled in
+the vCluster config.
+
+Example:
+vcluster snapshot list my-vcluster
+vcluster snapshot list my-vcluster --restores
+vcluster snapshot list my-vcluster -o yaml
+##############################################################
+	`,
+		Aliases:           []string{"ls"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.NewValidVClusterNameFunc(globalFlags),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cli.ListSnapshotResources(cobraCmd.Context(), args[0], cmd.GlobalFlags, &cmd.SnapshotResourcesOptions, cmd.Log)
+		},
+	}
+
+	listCmd.Flags().BoolVar(&cmd.Restores, "restores", false, "If enabled, lists the VirtualClusterRestores instead of the VirtualClusterSnapshots")
+	listCmd.Flags().StringVarP(&cmd.Output, "output", "o", "table", "Choose the format of the output. [table|json|yaml]")
+	return listCmd
+}
@@ -43,6 +43,12 @@ vcluster snapshot my-vcluster oci://ghcr.io/my-user/my-repo:my-tag
 vcluster snapshot my-vcluster s3://my-bucket/my-bucket-key
 # Snapshot to vCluster container filesystem
 vcluster snapshot my-vcluster container:///data/my-local-snapshot.tar.gz
+# Snapshot to a filesystem mounted into the vCluster, e.g. a shared nfs volume
+vcluster snapshot my-vcluster file:///mnt/snapshots/my-snapshot.tar.gz
+# Snapshot to google cloud storage bucket
+vcluster snapshot my-vcluster gs://my-bucket/my-bucket-key
+# Snapshot to azure blob storage container
+vcluster snapshot my-vcluster azblob://my-container/my-blob?account=my-account
 #######################################################
 	`,
 		Args:              nameValidator,
@@ -59,6 +65,11 @@ vcluster snapshot my-vcluster container:///data/my-local-snapshot.tar.gz
 	// add subcommands
 	cobraCmd.AddCommand(NewCreateCmd(globalFlags))
 	cobraCmd.AddCommand(NewGetCmd(globalFlags))
+	cobraCmd.AddCommand(NewInspectCmd(globalFlags))
+	cobraCmd.AddCommand(NewDiffCmd(globalFlags))
+	cobraCmd.AddCommand(NewVerifyCmd(globalFlags))
+	cobraCmd.AddCommand(NewListCmd(globalFlags))
+	cobraCmd.AddCommand(NewDescribeCmd(globalFlags))
 
 	return cobraCmd
 }
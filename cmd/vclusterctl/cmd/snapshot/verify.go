@@ -0,0 +1,52 @@
+package snapshot
+
+import (
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/snapshot"
+	"github.com/spf13/cobra"
+)
+
+type VerifyCmd struct {
+	*flags.GlobalFlags
+
+	Snapshot snapshot.Options
+	Output   string
+
+	Log log.Logger
+}
+
+func NewVerifyCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &VerifyCmd{
+		GlobalFlags: globalFlags,
+		Log:         log.GetInstance(),
+	}
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify SNAPSHOT_URL",
+		Short: "Verify the integrity of a virtual cluster snapshot",
+		Long: `##############################################################
+################## vcluster snapshot verify ##################
+##############################################################
+Verify the integrity of a virtual cluster snapshot. Reads the
+whole snapshot and compares the number of keys and the checksum
+with the manifest that was written when the snapshot was taken.
+
+Example:
+# Verify snapshot from oci image
+vcluster snapshot verify oci://ghcr.io/my-user/my-repo:my-tag
+# Verify snapshot from s3 bucket and print the manifest as json
+vcluster snapshot verify s3://my-bucket/my-bucket-key -o json
+##############################################################
+	`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cli.VerifySnapshot(cobraCmd.Context(), args[0], &cmd.Snapshot, cmd.Output, cobraCmd.OutOrStdout(), cmd.Log)
+		},
+	}
+
+	verifyCmd.Flags().StringVarP(&cmd.Output, "output", "o", "", "The format to use to display the manifest, can only be json")
+	snapshot.AddEncryptionFlags(verifyCmd.Flags(), &cmd.Snapshot, true)
+	return verifyCmd
+}
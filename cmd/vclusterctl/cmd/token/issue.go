@@ -0,0 +1,203 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli"
+	"github.com/loft-sh/vcluster/pkg/cli/completion"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/cli/util"
+	"github.com/loft-sh/vcluster/pkg/credentials"
+	"github.com/loft-sh/vcluster/pkg/util/clihelper"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+type IssueCmd struct {
+	*flags.GlobalFlags
+
+	Type         string
+	Namespaces   []string
+	Roles        []string
+	ClusterRoles []string
+	Expires      time.Duration
+	Description  string
+	Server       string
+	KubeConfig   string
+
+	Log log.Logger
+}
+
+func NewIssueCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &IssueCmd{
+		GlobalFlags: globalFlags,
+		Log:         log.GetInstance().ErrorStreamOnly(),
+	}
+
+	description := `########################################################
+################# vcluster token issue #################
+########################################################
+Issue a short-lived kubeconfig for a virtual cluster that
+is scoped to the given namespaces and roles. The issuance
+is recorded in the host namespace of the vCluster and can
+be listed via 'vcluster token issued' and revoked via
+'vcluster token revoke'.
+
+Example:
+vcluster token issue my-vcluster --namespaces dev --role edit --expires 2h > dev.yaml
+vcluster token issue my-vcluster --cluster-role view --type certificate --kube-config view.yaml
+#######################################################
+	`
+
+	useLine, nameValidator := util.NamedPositionalArgsValidator(true, true, "VCLUSTER_NAME")
+	issueCmd := &cobra.Command{
+		Use:               "issue" + useLine,
+		Short:             "Issue a short-lived, scoped kubeconfig for a virtual cluster.",
+		Long:              description,
+		Args:              nameValidator,
+		ValidArgsFunction: completion.NewValidVClusterNameFunc(globalFlags),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cmd.Run(cobraCmd.Context(), args[0])
+		},
+	}
+
+	issueCmd.Flags().StringVar(&cmd.Type, "type", string(credentials.TypeToken), "The credential type to issue. [token|certificate]")
+	issueCmd.Flags().StringSliceVar(&cmd.Namespaces, "namespaces", []string{}, "The virtual namespaces the roles are bound in")
+	issueCmd.Flags().StringSliceVar(&cmd.Roles, "role", []string{}, "Cluster role to bind in each of the given namespaces. Can be specified multiple times")
+	issueCmd.Flags().StringSliceVar(&cmd.ClusterRoles, "cluster-role", []string{}, "Cluster role to bind cluster wide. Can be specified multiple times")
+	issueCmd.Flags().DurationVar(&cmd.Expires, "expires", time.Hour, "The duration the credential will be valid for, at most controlPlane.proxy.credentials.maxExpiration of the vCluster")
+	issueCmd.Flags().StringVar(&cmd.Description, "description", "", "An optional description stored with the issuance record")
+	issueCmd.Flags().StringVar(&cmd.Server, "server", "", "The server address to use in the kubeconfig. Defaults to the server of the vCluster kubeconfig secret")
+	issueCmd.Flags().StringVar(&cmd.KubeConfig, "kube-config", "", "If specified, the kubeconfig is written to this file instead of stdout")
+	return issueCmd
+}
+
+func (cmd *IssueCmd) Run(ctx context.Context, vClusterName string) error {
+	vCluster, hostClient, vClient, err := getVClusterClients(ctx, cmd.GlobalFlags, vClusterName, cmd.Log)
+	if err != nil {
+		return err
+	}
+
+	// get the vCluster kube config for the server address and ca
+	vKubeConfig, err := clihelper.GetKubeConfig(ctx, hostClient, vCluster.Name, vCluster.Namespace, cmd.Log)
+	if err != nil {
+		return fmt.Errorf("get vCluster kube config: %w", err)
+	}
+	kubeContext := vKubeConfig.Contexts[vKubeConfig.CurrentContext]
+	if kubeContext == nil || vKubeConfig.Clusters[kubeContext.Cluster] == nil {
+		return fmt.Errorf("vCluster kube config has no cluster for context %s", vKubeConfig.CurrentContext)
+	}
+	cluster := vKubeConfig.Clusters[kubeContext.Cluster]
+
+	// the maximum expiration of the vCluster applies to credentials issued via the cli as well
+	maxExpiration := credentials.DefaultMaxExpiration
+	vClusterConfig, err := cli.RunningVClusterConfig(ctx, hostClient, vCluster.Name, vCluster.Namespace)
+	if err != nil {
+		cmd.Log.Debugf("Error retrieving vCluster config, using the default maximum expiration: %v", err)
+	} else {
+		maxExpiration, err = credentials.MaxExpiration(vClusterConfig.ControlPlane.Proxy)
+		if err != nil {
+			return err
+		}
+	}
+
+	credential, err := credentials.Issue(ctx, hostClient, vClient, vCluster.Name, vCluster.Namespace, &credentials.Request{
+		Type:         credentials.Type(cmd.Type),
+		Namespaces:   cmd.Namespaces,
+		Roles:        cmd.Roles,
+		ClusterRoles: cmd.ClusterRoles,
+		Expiration:   cmd.Expires,
+		Description:  cmd.Description,
+	}, maxExpiration)
+	if err != nil {
+		return fmt.Errorf("issue credential: %w", err)
+	}
+
+	// build the kube config
+	contextName := "vcluster_" + vCluster.Name + "_" + credential.Record.ID
+	server := cluster.Server
+	if cmd.Server != "" {
+		server = cmd.Server
+	}
+	kubeConfig := clientcmdapi.NewConfig()
+	kubeConfig.Clusters[contextName] = &clientcmdapi.Cluster{
+		Server:                   server,
+		CertificateAuthorityData: cluster.CertificateAuthorityData,
+		InsecureSkipTLSVerify:    cluster.InsecureSkipTLSVerify,
+	}
+	kubeConfig.AuthInfos[contextName] = &clientcmdapi.AuthInfo{
+		Token:                 credential.Token,
+		ClientCertificateData: credential.ClientCertificate,
+		ClientKeyData:         credential.ClientKey,
+	}
+	kubeConfig.Contexts[contextName] = &clientcmdapi.Context{
+		Cluster:   contextName,
+		AuthInfo:  contextName,
+		Namespace: firstOrDefault(cmd.Namespaces),
+	}
+	kubeConfig.CurrentContext = contextName
+	out, err := clientcmd.Write(*kubeConfig)
+	if err != nil {
+		return err
+	}
+
+	if cmd.KubeConfig == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+
+	err = os.WriteFile(cmd.KubeConfig, out, 0600)
+	if err != nil {
+		return fmt.Errorf("write kube config: %w", err)
+	}
+
+	cmd.Log.Donef("Issued credential %s for %s, valid until %s. Kubeconfig written to %s", credential.Record.ID, credential.Record.Subject, credential.Record.ExpiresAt.Format(time.RFC3339), cmd.KubeConfig)
+	return nil
+}
+
+func firstOrDefault(namespaces []string) string {
+	if len(namespaces) == 0 {
+		return ""
+	}
+
+	return namespaces[0]
+}
+
+// getVClusterClients returns clients for the host namespace of the vCluster and for the virtual cluster itself
+func getVClusterClients(ctx context.Context, globalFlags *flags.GlobalFlags, vClusterName string, log log.Logger) (*find.VCluster, *kubernetes.Clientset, *kubernetes.Clientset, error) {
+	vCluster, err := find.GetVCluster(ctx, globalFlags.Context, vClusterName, globalFlags.Namespace, log)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	kubeConfig, err := vCluster.ClientFactory.ClientConfig()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("load kube config: %w", err)
+	}
+	hostClient, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	vKubeConfig, err := clihelper.GetVClusterKubeConfig(ctx, kubeConfig, hostClient, vCluster, log, clihelper.PortForwardingOptions{
+		StdOut: io.Discard,
+		StdErr: os.Stderr,
+	})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("get virtual cluster config: %w", err)
+	}
+	vClient, err := kubernetes.NewForConfig(vKubeConfig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return vCluster, hostClient, vClient, nil
+}
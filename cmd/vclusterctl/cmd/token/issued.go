@@ -0,0 +1,100 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/log/table"
+	"github.com/loft-sh/vcluster/pkg/cli/completion"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/cli/util"
+	"github.com/loft-sh/vcluster/pkg/credentials"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+)
+
+type IssuedCmd struct {
+	*flags.GlobalFlags
+
+	Output string
+
+	Log log.Logger
+}
+
+func NewIssuedCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &IssuedCmd{
+		GlobalFlags: globalFlags,
+		Log:         log.GetInstance(),
+	}
+
+	description := `########################################################
+################# vcluster token issued #################
+########################################################
+List all credentials issued via 'vcluster token issue'.
+#######################################################
+	`
+
+	useLine, nameValidator := util.NamedPositionalArgsValidator(true, true, "VCLUSTER_NAME")
+	issuedCmd := &cobra.Command{
+		Use:               "issued" + useLine,
+		Short:             "List all credentials issued for a virtual cluster.",
+		Long:              description,
+		Args:              nameValidator,
+		ValidArgsFunction: completion.NewValidVClusterNameFunc(globalFlags),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cmd.Run(cobraCmd.Context(), args[0])
+		},
+	}
+
+	issuedCmd.Flags().StringVar(&cmd.Output, "output", "table", "Choose the format of the output. [table|json]")
+	return issuedCmd
+}
+
+func (cmd *IssuedCmd) Run(ctx context.Context, vClusterName string) error {
+	// the records live in the host namespace, so there is no need to connect to the virtual cluster
+	vCluster, err := find.GetVCluster(ctx, cmd.Context, vClusterName, cmd.Namespace, cmd.Log)
+	if err != nil {
+		return err
+	}
+	kubeConfig, err := vCluster.ClientFactory.ClientConfig()
+	if err != nil {
+		return fmt.Errorf("load kube config: %w", err)
+	}
+	hostClient, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		return err
+	}
+
+	records, err := credentials.List(ctx, hostClient, vCluster.Name, vCluster.Namespace)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Output == "json" {
+		bytes, err := json.MarshalIndent(records, "", "    ")
+		if err != nil {
+			return fmt.Errorf("json marshal credentials: %w", err)
+		}
+
+		cmd.Log.WriteString(logrus.InfoLevel, string(bytes)+"\n")
+	} else {
+		header := []string{"ID", "TYPE", "SUBJECT", "SCOPE", "EXPIRES", "ISSUED"}
+		var values [][]string
+		for _, record := range records {
+			expires := record.ExpiresAt.Format(time.RFC3339)
+			if record.Expired() {
+				expires += " (expired)"
+			}
+
+			values = append(values, []string{record.ID, string(record.Type), record.Subject, record.Scope(), expires, record.IssuedAt.Format(time.RFC3339)})
+		}
+		table.PrintTable(cmd.Log, header, values)
+	}
+
+	return nil
+}
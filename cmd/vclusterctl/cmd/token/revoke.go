@@ -0,0 +1,91 @@
+package token
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli/completion"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/credentials"
+	"github.com/spf13/cobra"
+)
+
+type RevokeCmd struct {
+	*flags.GlobalFlags
+
+	Expired bool
+
+	Log log.Logger
+}
+
+func NewRevokeCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &RevokeCmd{
+		GlobalFlags: globalFlags,
+		Log:         log.GetInstance(),
+	}
+
+	description := `########################################################
+################# vcluster token revoke #################
+########################################################
+Revoke credentials issued via 'vcluster token issue'. This
+removes the service account and role bindings of the
+credential in the virtual cluster. Client certificates are
+added to the revocation list of the vCluster and rejected
+by the vCluster proxy.
+
+Example:
+vcluster token revoke my-vcluster abcd1234
+vcluster token revoke my-vcluster --expired
+#######################################################
+	`
+
+	revokeCmd := &cobra.Command{
+		Use:               "revoke VCLUSTER_NAME [ID...]",
+		Short:             "Revoke issued credentials of a virtual cluster.",
+		Long:              description,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completion.NewValidVClusterNameFunc(globalFlags),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cmd.Run(cobraCmd.Context(), args[0], args[1:])
+		},
+	}
+
+	revokeCmd.Flags().BoolVar(&cmd.Expired, "expired", false, "If enabled, revokes all expired credentials")
+	return revokeCmd
+}
+
+func (cmd *RevokeCmd) Run(ctx context.Context, vClusterName string, ids []string) error {
+	if len(ids) == 0 && !cmd.Expired {
+		return fmt.Errorf("please specify the credential ids to revoke or use --expired")
+	}
+
+	vCluster, hostClient, vClient, err := getVClusterClients(ctx, cmd.GlobalFlags, vClusterName, cmd.Log)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Expired {
+		records, err := credentials.List(ctx, hostClient, vCluster.Name, vCluster.Namespace)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if record.Expired() {
+				ids = append(ids, record.ID)
+			}
+		}
+	}
+
+	for _, id := range ids {
+		err = credentials.Revoke(ctx, hostClient, vClient, vCluster.Name, vCluster.Namespace, id)
+		if err != nil {
+			return fmt.Errorf("revoke credential %s: %w", id, err)
+		}
+
+		cmd.Log.Donef("Revoked credential %s", id)
+	}
+
+	return nil
+}
@@ -19,5 +19,8 @@ func NewTokenCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
 	tokenCmd.AddCommand(NewCreateCmd(globalFlags))
 	tokenCmd.AddCommand(NewListCmd(globalFlags))
 	tokenCmd.AddCommand(NewDeleteCmd(globalFlags))
+	tokenCmd.AddCommand(NewIssueCmd(globalFlags))
+	tokenCmd.AddCommand(NewIssuedCmd(globalFlags))
+	tokenCmd.AddCommand(NewRevokeCmd(globalFlags))
 	return tokenCmd
 }
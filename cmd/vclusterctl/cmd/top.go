@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli/completion"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/cli/top"
+	"github.com/loft-sh/vcluster/pkg/cli/util"
+	"github.com/spf13/cobra"
+)
+
+// TopCmd holds the top cmd flags
+type TopCmd struct {
+	*flags.GlobalFlags
+	top.Options
+
+	log log.Logger
+}
+
+// NewTopCmd creates a new command
+func NewTopCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &TopCmd{
+		GlobalFlags: globalFlags,
+		log:         log.GetInstance(),
+	}
+
+	useLine, nameValidator := util.NamedPositionalArgsValidator(false, true, "VCLUSTER_NAME")
+	cobraCmd := &cobra.Command{
+		Use:   "top" + useLine,
+		Short: "Shows the resource usage of virtual clusters",
+		Long: `#######################################################
+#################### vcluster top #####################
+#######################################################
+Shows the cpu, memory, pod count and persistent volume
+storage of virtual clusters. The host pods are grouped
+by the virtual cluster that synced them, the usage is
+read from metrics.k8s.io. If metrics-server is not
+installed, the resource requests are shown instead.
+
+If a virtual cluster name is given, or --by-namespace
+is set, the usage is broken down by virtual namespace.
+
+Example:
+vcluster top
+vcluster top --sort-by memory --watch
+vcluster top my-vcluster -n my-namespace
+vcluster top --by-namespace --output json
+#######################################################
+	`,
+		Args:              nameValidator,
+		ValidArgsFunction: completion.NewValidVClusterNameFunc(globalFlags),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			vClusterName := ""
+			if len(args) > 0 {
+				vClusterName = args[0]
+			}
+
+			return top.Run(cobraCmd.Context(), vClusterName, cmd.GlobalFlags, &cmd.Options, cmd.log)
+		},
+	}
+
+	cobraCmd.Flags().StringVarP(&cmd.Output, "output", "o", "table", "Choose the format of the output. [table|json]")
+	cobraCmd.Flags().StringVar(&cmd.SortBy, "sort-by", top.SortByCPU, "Sort by cpu, memory, pods, storage or name")
+	cobraCmd.Flags().BoolVar(&cmd.ByNamespace, "by-namespace", false, "Break down the usage by virtual namespace")
+	cobraCmd.Flags().BoolVarP(&cmd.Watch, "watch", "w", false, "Keep refreshing the usage")
+	cobraCmd.Flags().DurationVar(&cmd.Interval, "interval", 5*time.Second, "The refresh interval in watch mode")
+	return cobraCmd
+}
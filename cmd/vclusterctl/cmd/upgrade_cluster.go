@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli"
+	"github.com/loft-sh/vcluster/pkg/cli/completion"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/cli/util"
+	"github.com/loft-sh/vcluster/pkg/upgrade"
+	"github.com/spf13/cobra"
+)
+
+// UpgradeClusterCmd holds the upgrade-cluster cmd flags
+type UpgradeClusterCmd struct {
+	*flags.GlobalFlags
+	cli.UpgradeClusterOptions
+
+	log log.Logger
+}
+
+// NewUpgradeClusterCmd creates a new command
+func NewUpgradeClusterCmd(globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &UpgradeClusterCmd{
+		GlobalFlags: globalFlags,
+		log:         log.GetInstance(),
+	}
+
+	useLine, nameValidator := util.NamedPositionalArgsValidator(true, true, "VCLUSTER_NAME")
+	cobraCmd := &cobra.Command{
+		Use:   "upgrade-cluster" + useLine,
+		Short: "Upgrades the chart of a virtual cluster after preflight checks",
+		Long: `#######################################################
+############## vcluster upgrade-cluster ###############
+#######################################################
+Upgrades the helm chart of a virtual cluster. Before the
+upgrade the new values are rendered and checked:
+
+chart-version        no downgrade and no big version jumps
+config               the new vcluster.yaml is valid
+deprecated-values    no deprecated values are used
+distro               the distro doesn't change
+backing-store        the backing store change is supported
+immutable-settings   no settings are changed that are fixed
+                     after creation
+kubernetes-version   kubernetes is upgraded at most one
+                     minor version
+
+The current values are kept, additional values can be
+passed via --values and --set. Optionally a snapshot is
+taken before the upgrade. If the virtual cluster doesn't
+become ready, it is rolled back to the previous release.
+
+Example:
+vcluster upgrade-cluster test --chart-version 0.30.0
+vcluster upgrade-cluster test --chart-version 0.30.0 --dry-run
+vcluster upgrade-cluster test --chart-version 0.30.0 -f values.yaml --snapshot s3://my-bucket/test-before-upgrade
+#######################################################
+	`,
+		Args:              nameValidator,
+		ValidArgsFunction: completion.NewValidVClusterNameFunc(globalFlags),
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			// Check for newer version
+			upgrade.PrintNewerVersionWarning()
+
+			cmd.ExposeLocal = true
+			return cli.UpgradeClusterHelm(cobraCmd.Context(), &cmd.UpgradeClusterOptions, cmd.GlobalFlags, args[0], cmd.log)
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&cmd.ChartVersion, "chart-version", upgrade.GetVersion(), "The virtual cluster chart version to upgrade to")
+	cobraCmd.Flags().StringVar(&cmd.ChartName, "chart-name", "vcluster", "The virtual cluster chart name to use")
+	cobraCmd.Flags().StringVar(&cmd.ChartRepo, "chart-repo", "", "The virtual cluster chart repo to use (empty = use embedded chart for the cli version and the vCluster chart repo otherwise)")
+	cobraCmd.Flags().StringVar(&cmd.LocalChartDir, "local-chart-dir", "", "The virtual cluster local chart dir to use")
+	cobraCmd.Flags().StringArrayVarP(&cmd.Values, "values", "f", []string{}, "Path where to load extra helm values from")
+	cobraCmd.Flags().StringArrayVar(&cmd.SetValues, "set", []string{}, "Set values for helm. E.g. --set 'persistence.enabled=true'")
+	cobraCmd.Flags().BoolVar(&cmd.ResetValues, "reset-values", false, "If true, the current values of the virtual cluster are not reused")
+	cobraCmd.Flags().StringVar(&cmd.Snapshot, "snapshot", "", "If set, a snapshot is taken to this url via a snapshot request before the upgrade, e.g. s3://my-bucket/my-key")
+	cobraCmd.Flags().BoolVar(&cmd.IncludeVolumes, "include-volumes", false, "If true, the snapshot taken before the upgrade includes the volumes")
+	cobraCmd.Flags().BoolVar(&cmd.DryRun, "dry-run", false, "If true, only runs the preflight checks")
+	cobraCmd.Flags().BoolVar(&cmd.Force, "force", false, "If true, upgrades even if preflight checks have failed")
+	cobraCmd.Flags().BoolVar(&cmd.RollbackOnFailure, "rollback-on-failure", true, "If true, rolls back to the previous release if the upgrade fails or the virtual cluster doesn't become ready")
+	cobraCmd.Flags().DurationVar(&cmd.Timeout, "timeout", 10*time.Minute, "How long to wait for the virtual cluster to become ready after the upgrade")
+	_ = cobraCmd.Flags().MarkHidden("local-chart-dir")
+
+	return cobraCmd
+}
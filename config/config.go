@@ -22,6 +22,11 @@ import (
 //go:embed values.yaml
 var Values string
 
+// Schema is the JSON schema of the vcluster.yaml, it is generated by hack/schema/main.go.
+//
+//go:embed values.schema.json
+var Schema string
+
 var ErrInvalidConfig = errors.New("invalid config")
 
 // NewDefaultConfig creates a new config based on the values.yaml, including all default values.
@@ -93,6 +98,9 @@ type Config struct {
 
 	// Logging provides structured logging options
 	Logging *Logging `json:"logging,omitempty"`
+
+	// Snapshots holds configuration for snapshots that are created by the vCluster control plane.
+	Snapshots Snapshots `json:"snapshots,omitempty"`
 }
 
 // PrivateNodes enables private nodes for vCluster. When turned on, vCluster will not sync resources to the host cluster
@@ -2299,6 +2307,48 @@ type ControlPlaneProxy struct {
 
 	// ExtraSANs are extra hostnames to sign the vCluster proxy certificate for.
 	ExtraSANs []string `json:"extraSANs,omitempty"`
+
+	// Impersonation restricts which identities can be impersonated through the vCluster proxy.
+	Impersonation ControlPlaneProxyImpersonation `json:"impersonation,omitempty"`
+
+	// SessionRecording records interactive exec and attach sessions in the asciicast format.
+	SessionRecording ControlPlaneProxySessionRecording `json:"sessionRecording,omitempty"`
+
+	// Credentials configures the scoped, short-lived credentials issued via vcluster token issue.
+	Credentials ControlPlaneProxyCredentials `json:"credentials,omitempty"`
+}
+
+type ControlPlaneProxyCredentials struct {
+	// MaxExpiration is the longest duration an issued credential can be valid for, e.g. 24h. Defaults to 24h.
+	MaxExpiration Duration `json:"maxExpiration,omitempty"`
+}
+
+type ControlPlaneProxySessionRecording struct {
+	// Enabled defines if interactive exec and attach sessions should be recorded. Recorded sessions require websocket
+	// streaming (kubectl v1.30 or newer), interactive SPDY sessions are rejected as they can't be recorded. Sessions with
+	// a tty or stdin are interactive.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Path is the local directory recordings are written to. Defaults to /data/session-recordings.
+	Path string `json:"path,omitempty"`
+
+	// URL is an optional s3 url (s3://BUCKET/PREFIX) recordings are uploaded to once a session ended. Uses the same
+	// options as snapshot urls. Local recordings are removed after a successful upload.
+	URL string `json:"url,omitempty"`
+}
+
+type ControlPlaneProxyImpersonation struct {
+	// AllowedUserPrefixes restricts impersonated user names to the given prefixes. If empty, all user names are allowed.
+	AllowedUserPrefixes []string `json:"allowedUserPrefixes,omitempty"`
+
+	// DeniedUsers are user names that can never be impersonated.
+	DeniedUsers []string `json:"deniedUsers,omitempty"`
+
+	// DeniedGroups are groups that can never be impersonated, e.g. system:masters.
+	DeniedGroups []string `json:"deniedGroups,omitempty"`
+
+	// DenyHostServiceAccounts denies impersonating service accounts of the host namespace vCluster is running in.
+	DenyHostServiceAccounts bool `json:"denyHostServiceAccounts,omitempty"`
 }
 
 type ControlPlaneService struct {
@@ -2384,6 +2434,21 @@ type ControlPlaneAdvanced struct {
 	// PodDisruptionBudget limits how many pods of an application can be voluntarily disrupted at once
 	// to ensure availability during maintenance or scaling operations.
 	PodDisruptionBudget PodDisruptionBudget `json:"podDisruptionBudget,omitempty"`
+
+	// Tracing holds OpenTelemetry tracing configuration for the vCluster control plane.
+	Tracing Tracing `json:"tracing,omitempty"`
+}
+
+type Tracing struct {
+	// Enabled defines if OpenTelemetry tracing should be enabled for the proxy, the syncers, plugin calls and host cluster requests.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Endpoint is the OTLP gRPC endpoint spans are exported to, e.g. otel-collector.observability:4317. Defaults to localhost:4317.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// SamplingRatePerMillion is the number of samples to collect per million spans. Requests that already carry a sampled
+	// trace context are always traced. Defaults to 0.
+	SamplingRatePerMillion int32 `json:"samplingRatePerMillion,omitempty"`
 }
 
 type Registry struct {
@@ -2951,6 +3016,30 @@ type RBAC struct {
 	// EnableVolumeSnapshotRules enables all required volume snapshot rules in the Role and
 	// ClusterRole.
 	EnableVolumeSnapshotRules EnableAutoSwitch `json:"enableVolumeSnapshotRules,omitempty"`
+
+	// IdentityMappings map host cluster users and groups to roles within the virtual cluster. vCluster
+	// continuously reconciles the resulting virtual ClusterRoleBindings and RoleBindings.
+	IdentityMappings []RBACIdentityMapping `json:"identityMappings,omitempty"`
+}
+
+type RBACIdentityMapping struct {
+	// Users are the host cluster user names this mapping applies to.
+	Users []string `json:"users,omitempty"`
+
+	// Groups are the host cluster group names this mapping applies to.
+	Groups []string `json:"groups,omitempty"`
+
+	// Prefix is prepended to the user and group names, e.g. oidc: if the host api server uses a username prefix.
+	Prefix string `json:"prefix,omitempty"`
+
+	// ClusterRoles are virtual cluster roles that are bound cluster wide.
+	ClusterRoles []string `json:"clusterRoles,omitempty"`
+
+	// Roles are virtual cluster roles that are bound in each of the given namespaces.
+	Roles []string `json:"roles,omitempty"`
+
+	// Namespaces are the virtual namespaces the roles are bound in.
+	Namespaces []string `json:"namespaces,omitempty"`
 }
 
 type RBACClusterRole struct {
@@ -3229,6 +3318,50 @@ func addProToJSONSchema(base *jsonschema.Schema, t reflect.Type) {
 	}
 }
 
+// ProFeaturePaths returns the dot separated paths of all config fields with the `product:"pro"` tag.
+func ProFeaturePaths() []string {
+	return proFeaturePaths("", reflect.TypeOf(Config{}), map[reflect.Type]bool{})
+}
+
+func proFeaturePaths(prefix string, t reflect.Type, visited map[reflect.Type]bool) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return nil
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	paths := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if jsonName == "-" || !field.IsExported() {
+			continue
+		}
+
+		path := prefix
+		if jsonName != "" {
+			if path != "" {
+				path += "."
+			}
+			path += jsonName
+		} else if !field.Anonymous {
+			continue
+		}
+
+		if field.Tag.Get("product") != "" {
+			paths = append(paths, path)
+			continue
+		}
+
+		paths = append(paths, proFeaturePaths(path, field.Type, visited)...)
+	}
+
+	return paths
+}
+
 // SleepMode holds configuration for native/workload only sleep mode
 type SleepMode struct {
 	// Enabled toggles the sleep mode functionality, allowing for disabling sleep mode without removing other config
@@ -3296,6 +3429,86 @@ type AutoSleepExclusion struct {
 	Selector LabelSelector `json:"selector,omitempty"`
 }
 
+// Snapshots holds configuration for snapshots that are created by the vCluster control plane.
+type Snapshots struct {
+	// Schedules create snapshots periodically and prune old snapshots according to their retention. The result
+	// of the last run of each schedule is reported in the vc-snapshot-schedules-VCLUSTER_NAME ConfigMap.
+	Schedules []SnapshotSchedule `json:"schedules,omitempty"`
+
+	// Volumes configures how the data of persistent volumes is backed up when volumes are included in a snapshot.
+	Volumes SnapshotVolumes `json:"volumes,omitempty"`
+
+	// CustomResources configures the VirtualClusterSnapshot and VirtualClusterRestore resources.
+	CustomResources SnapshotCustomResources `json:"customResources,omitempty"`
+}
+
+// SnapshotCustomResources configures the VirtualClusterSnapshot and VirtualClusterRestore resources.
+type SnapshotCustomResources struct {
+	// Enabled installs the VirtualClusterSnapshot and VirtualClusterRestore CRDs in the host cluster. Creating a
+	// VirtualClusterSnapshot in the vCluster namespace takes a snapshot, and all snapshot and restore requests are
+	// reported as VirtualClusterSnapshots and VirtualClusterRestores.
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// SnapshotVolumes configures how the data of persistent volumes is backed up.
+type SnapshotVolumes struct {
+	// FileBackup backs up volumes without CSI snapshot support on file level. A helper pod mounts the
+	// PersistentVolumeClaim and streams its files into the same storage as the etcd snapshot.
+	FileBackup SnapshotFileBackup `json:"fileBackup,omitempty"`
+}
+
+// SnapshotFileBackup configures file level volume backups.
+type SnapshotFileBackup struct {
+	// StorageClasses are the storage classes whose volumes are backed up on file level instead of with CSI
+	// volume snapshots, e.g. local-path or nfs-client. Only s3, gs and azblob snapshot urls are supported.
+	StorageClasses []string `json:"storageClasses,omitempty"`
+
+	// Image is the image of the helper pods. If empty defaults to the image of the vCluster control plane.
+	Image string `json:"image,omitempty"`
+
+	// RunAsUser runs the helper pods as the given non-root user. The user needs to be able to read all files of the
+	// backed up volumes and restored files are owned by it. If 0, the helper pods run as root with only the
+	// capabilities required to read all files and to restore their ownership.
+	RunAsUser int64 `json:"runAsUser,omitempty"`
+}
+
+// SnapshotSchedule defines a periodically created snapshot.
+type SnapshotSchedule struct {
+	// Name of the schedule. Must be unique across all schedules.
+	Name string `json:"name" jsonschema:"required"`
+
+	// Schedule is the cron expression (minute, hour, day of month, month, day of week) in UTC that defines when the
+	// snapshot should be created, e.g. "0 3 * * *" or "@daily".
+	Schedule string `json:"schedule" jsonschema:"required"`
+
+	// URL is a template for the snapshot url. The fields {{.Name}}, {{.Namespace}}, {{.Schedule}} and {{.Timestamp}}
+	// can be used, e.g. s3://my-bucket/{{.Name}}/{{.Timestamp}}.tar.gz. Retention requires {{.Timestamp}} in a file
+	// name ending with .tar.gz and is not supported for oci:// urls.
+	URL string `json:"url" jsonschema:"required"`
+
+	// IncludeVolumes defines if CSI volume snapshots should be created as well.
+	IncludeVolumes bool `json:"includeVolumes,omitempty"`
+
+	// Retention defines which snapshots created by this schedule are kept. Snapshots are kept if any of the
+	// rules selects them. If no rule is set, all snapshots are kept.
+	Retention SnapshotRetention `json:"retention,omitempty"`
+}
+
+// SnapshotRetention defines which snapshots of a schedule are kept.
+type SnapshotRetention struct {
+	// KeepLast keeps the last n snapshots.
+	KeepLast int `json:"keepLast,omitempty"`
+
+	// KeepDaily keeps the last snapshot of each of the last n days.
+	KeepDaily int `json:"keepDaily,omitempty"`
+
+	// KeepWeekly keeps the last snapshot of each of the last n weeks.
+	KeepWeekly int `json:"keepWeekly,omitempty"`
+
+	// KeepMonthly keeps the last snapshot of each of the last n months.
+	KeepMonthly int `json:"keepMonthly,omitempty"`
+}
+
 // Logging holds the log encoding details
 type Logging struct {
 	// Encoding specifies the format of vCluster logs, it can either be json or console.
@@ -491,3 +491,41 @@ func TestImage_String(t *testing.T) {
 		})
 	}
 }
+
+func TestProFeaturePaths(t *testing.T) {
+	paths := ProFeaturePaths()
+	for _, path := range []string{
+		"networking.resolveDNS",
+		"controlPlane.hostPathMapper",
+		"controlPlane.backingStore.etcd.embedded",
+		"controlPlane.coredns.embedded",
+		"policies.centralAdmission",
+		"experimental.denyProxyRequests",
+	} {
+		assert.Assert(t, cmp.Contains(paths, path))
+	}
+}
+
+func TestChanges(t *testing.T) {
+	fromConfig, err := NewDefaultConfig()
+	assert.NilError(t, err)
+	toConfig, err := NewDefaultConfig()
+	assert.NilError(t, err)
+
+	changes, err := Changes(fromConfig, toConfig)
+	assert.NilError(t, err)
+	assert.Equal(t, len(changes), 0)
+
+	toConfig.Sync.ToHost.Ingresses.Enabled = true
+	toConfig.Sync.ToHost.Services.Enabled = false
+	toConfig.ControlPlane.Distro.K8S.Image.Tag = "v1.33.0"
+	toConfig.Experimental.DenyProxyRequests = []DenyRule{{Name: "deny"}}
+	changes, err = Changes(fromConfig, toConfig)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, changes, []Change{
+		{Path: "controlPlane.distro.k8s.image.tag", From: fromConfig.ControlPlane.Distro.K8S.Image.Tag, To: "v1.33.0"},
+		{Path: "experimental.denyProxyRequests", To: []interface{}{map[string]interface{}{"name": "deny"}}},
+		{Path: "sync.toHost.ingresses.enabled", From: false, To: true},
+		{Path: "sync.toHost.services.enabled", From: true, To: false},
+	})
+}
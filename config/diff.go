@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -40,6 +41,94 @@ func Diff(fromConfig *Config, toConfig *Config) (string, error) {
 	return string(out), nil
 }
 
+// Change is a single value that differs between two configs.
+type Change struct {
+	// Path is the dot separated path of the value, e.g. controlPlane.distro.k8s.enabled
+	Path string `json:"path"`
+
+	// From is the value in the first config, nil if it was not set
+	From interface{} `json:"from,omitempty"`
+
+	// To is the value in the second config, nil if it was not set
+	To interface{} `json:"to,omitempty"`
+}
+
+// Changes returns all values that differ between the two configs sorted by path. Lists are compared as a whole
+// and empty values are treated as not set.
+func Changes(fromConfig *Config, toConfig *Config) ([]Change, error) {
+	fromRaw := map[string]interface{}{}
+	err := convert(fromConfig, &fromRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	toRaw := map[string]interface{}{}
+	err = convert(toConfig, &toRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	fromValues := map[string]interface{}{}
+	flatten("", fromRaw, fromValues)
+	toValues := map[string]interface{}{}
+	flatten("", toRaw, toValues)
+
+	changes := []Change{}
+	for path, fromValue := range fromValues {
+		toValue, ok := toValues[path]
+		if !ok {
+			changes = append(changes, Change{Path: path, From: fromValue, To: zeroValue(fromValue)})
+		} else if !reflect.DeepEqual(fromValue, toValue) {
+			changes = append(changes, Change{Path: path, From: fromValue, To: toValue})
+		}
+	}
+	for path, toValue := range toValues {
+		if _, ok := fromValues[path]; !ok {
+			changes = append(changes, Change{Path: path, From: zeroValue(toValue), To: toValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+func flatten(prefix string, in interface{}, out map[string]interface{}) {
+	switch inType := in.(type) {
+	case map[string]interface{}:
+		for k, v := range inType {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+
+			flatten(path, v, out)
+		}
+	case []interface{}:
+		if len(inType) > 0 {
+			out[prefix] = in
+		}
+	case nil:
+	default:
+		out[prefix] = in
+	}
+}
+
+// zeroValue returns the value an omitted field has, nil for lists and objects
+func zeroValue(value interface{}) interface{} {
+	switch value.(type) {
+	case bool:
+		return false
+	case string:
+		return ""
+	case float64:
+		return float64(0)
+	}
+
+	return nil
+}
+
 func diff(from, to any) any {
 	if reflect.DeepEqual(from, to) {
 		return nil
@@ -17,6 +17,7 @@ import (
 
 const (
 	OutFile       = "chart/values.schema.json"
+	ConfigOutFile = "config/values.schema.json"
 	ValuesOutFile = "chart/values.yaml"
 )
 const (
@@ -60,6 +61,10 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	err = writeSchema(generatedSchema, ConfigOutFile)
+	if err != nil {
+		panic(err)
+	}
 
 	err = writeValues(generatedSchema)
 	if err != nil {
@@ -0,0 +1,16 @@
+#!/usr/bin/env bash
+
+# This script generates the deepcopy functions and CRDs of the snapshot.vcluster.loft.sh api
+
+set -eu
+
+VCLUSTER_ROOT="$(dirname ${0})/.."
+CONTROLLER_GEN_VERSION="${CONTROLLER_GEN_VERSION:-v0.18.0}"
+CONTROLLER_GEN="${CONTROLLER_GEN:-go run sigs.k8s.io/controller-tools/cmd/controller-gen@${CONTROLLER_GEN_VERSION}}"
+
+cd "${VCLUSTER_ROOT}"
+${CONTROLLER_GEN} \
+  object \
+  crd:crdVersions=v1 \
+  paths=./pkg/apis/snapshot/... \
+  output:crd:artifacts:config=pkg/snapshot/crds
@@ -0,0 +1,5 @@
+// Package v1alpha1 contains the VirtualClusterSnapshot and VirtualClusterRestore resources. The deepcopy
+// functions and the CRDs in pkg/snapshot/crds are generated with hack/snapshot-apis.sh.
+// +kubebuilder:object:generate=true
+// +groupName=snapshot.vcluster.loft.sh
+package v1alpha1
@@ -0,0 +1,175 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionReady is true once the snapshot or restore has finished successfully, it is false while it is
+	// in progress or when it has failed.
+	ConditionReady = "Ready"
+
+	// ConditionReconciling is true while the snapshot or restore is in progress.
+	ConditionReconciling = "Reconciling"
+
+	// VolumeMethodCSISnapshot marks volumes that are snapshotted with CSI volume snapshots.
+	VolumeMethodCSISnapshot = "CSISnapshot"
+
+	// VolumeMethodFileBackup marks volumes that are backed up on file level.
+	VolumeMethodFileBackup = "FileBackup"
+)
+
+// VirtualClusterSnapshot is a snapshot of a virtual cluster. Creating a VirtualClusterSnapshot in the host
+// namespace of the virtual cluster takes a new snapshot. Snapshots that are taken with vcluster snapshot
+// create or by a snapshot schedule show up as VirtualClusterSnapshots as well.
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=vcsnap
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.url`
+// +kubebuilder:printcolumn:name="Volumes",type=boolean,JSONPath=`.spec.includeVolumes`
+// +kubebuilder:printcolumn:name="Completed",type=date,JSONPath=`.status.completionTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type VirtualClusterSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VirtualClusterSnapshotSpec   `json:"spec,omitempty"`
+	Status VirtualClusterSnapshotStatus `json:"status,omitempty"`
+}
+
+// VirtualClusterSnapshotSpec defines where the snapshot is stored.
+// +kubebuilder:validation:XValidation:rule="has(self.url) != has(self.urlFrom)",message="exactly one of url or urlFrom needs to be set"
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type VirtualClusterSnapshotSpec struct {
+	// URL is the snapshot url, e.g. s3://my-bucket/my-snapshot.tar.gz.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// URLFrom reads the snapshot url from a key of a Secret in the same namespace. Use this instead of url if
+	// the url contains credentials.
+	// +optional
+	URLFrom *corev1.SecretKeySelector `json:"urlFrom,omitempty"`
+
+	// IncludeVolumes defines if the persistent volumes should be snapshotted as well.
+	// +optional
+	IncludeVolumes bool `json:"includeVolumes,omitempty"`
+}
+
+// VirtualClusterSnapshotStatus is the observed state of the snapshot.
+type VirtualClusterSnapshotStatus struct {
+	// Phase of the snapshot, e.g. CreatingVolumeSnapshots, CreatingEtcdBackup, Completed or Failed.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// URL is the snapshot url without credentials.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// CompletionTime is the time the snapshot has finished.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Volumes is the status of the snapshotted persistent volumes.
+	// +optional
+	Volumes []VolumeStatus `json:"volumes,omitempty"`
+
+	// Error is set if the snapshot has failed or has partially failed.
+	// +optional
+	Error string `json:"error,omitempty"`
+
+	// Conditions of the snapshot.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// VirtualClusterSnapshotList is a list of virtual cluster snapshots.
+// +kubebuilder:object:root=true
+type VirtualClusterSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []VirtualClusterSnapshot `json:"items"`
+}
+
+// VirtualClusterRestore reports the progress of restoring the volumes of a virtual cluster. VirtualClusterRestores
+// are created when vcluster restore restores a snapshot with volumes.
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=vcrestore
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`
+// +kubebuilder:printcolumn:name="Completed",type=date,JSONPath=`.status.completionTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type VirtualClusterRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VirtualClusterRestoreSpec   `json:"spec,omitempty"`
+	Status VirtualClusterRestoreStatus `json:"status,omitempty"`
+}
+
+// VirtualClusterRestoreSpec defines the snapshot that is restored.
+type VirtualClusterRestoreSpec struct {
+	// URL is the url of the restored snapshot without credentials.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// IncludeVolumes defines if the persistent volumes are restored as well.
+	// +optional
+	IncludeVolumes bool `json:"includeVolumes,omitempty"`
+}
+
+// VirtualClusterRestoreStatus is the observed state of the restore.
+type VirtualClusterRestoreStatus struct {
+	// Phase of the restore, e.g. RestoringVolumes, Completed or Failed.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// CompletionTime is the time the restore has finished.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Volumes is the status of the restored persistent volumes.
+	// +optional
+	Volumes []VolumeStatus `json:"volumes,omitempty"`
+
+	// Error is set if the restore has failed or has partially failed.
+	// +optional
+	Error string `json:"error,omitempty"`
+
+	// Conditions of the restore.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// VirtualClusterRestoreList is a list of virtual cluster restores.
+// +kubebuilder:object:root=true
+type VirtualClusterRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []VirtualClusterRestore `json:"items"`
+}
+
+// VolumeStatus is the snapshot or restore status of a single persistent volume.
+type VolumeStatus struct {
+	// PersistentVolumeClaim is the namespace and name of the persistent volume claim.
+	PersistentVolumeClaim string `json:"persistentVolumeClaim"`
+
+	// Method is either CSISnapshot or FileBackup.
+	Method string `json:"method"`
+
+	// Phase of the volume snapshot or restore.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// Error is set if the volume snapshot or restore has failed.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
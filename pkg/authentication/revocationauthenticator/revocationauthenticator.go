@@ -0,0 +1,81 @@
+package revocationauthenticator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loft-sh/vcluster/pkg/credentials"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+)
+
+var (
+	cacheTime = 5 * time.Second
+)
+
+// RevokedSerialsFunc returns the serials of all revoked client certificates
+type RevokedSerialsFunc func(ctx context.Context) (sets.Set[string], error)
+
+// New wraps the client certificate authenticator and rejects certificates of issued credentials that were revoked
+func New(delegate authenticator.Request, revokedSerials RevokedSerialsFunc) authenticator.Request {
+	return &revocationAuthenticator{
+		delegate:       delegate,
+		revokedSerials: revokedSerials,
+	}
+}
+
+type revocationAuthenticator struct {
+	delegate       authenticator.Request
+	revokedSerials RevokedSerialsFunc
+
+	m       sync.Mutex
+	serials sets.Set[string]
+	exp     time.Time
+}
+
+func (r *revocationAuthenticator) AuthenticateRequest(req *http.Request) (*authenticator.Response, bool, error) {
+	response, ok, err := r.delegate.AuthenticateRequest(req)
+	if err != nil || !ok || req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+		return response, ok, err
+	}
+
+	// only issued credentials can be revoked
+	if response.User == nil || !strings.HasPrefix(response.User.GetName(), credentials.UserPrefix) {
+		return response, ok, nil
+	}
+
+	serials, err := r.getRevokedSerials(req.Context())
+	if err != nil {
+		return nil, false, err
+	}
+
+	serial := credentials.SerialOf(req.TLS.PeerCertificates[0])
+	if serials.Has(serial) {
+		return nil, false, fmt.Errorf("client certificate of %s has been revoked", response.User.GetName())
+	}
+
+	return response, ok, nil
+}
+
+func (r *revocationAuthenticator) getRevokedSerials(ctx context.Context) (sets.Set[string], error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	now := time.Now()
+	if r.serials != nil && r.exp.After(now) {
+		return r.serials, nil
+	}
+
+	serials, err := r.revokedSerials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get revoked credentials: %w", err)
+	}
+
+	r.serials = serials
+	r.exp = now.Add(cacheTime)
+	return serials, nil
+}
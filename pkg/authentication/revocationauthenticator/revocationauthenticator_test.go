@@ -0,0 +1,94 @@
+package revocationauthenticator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/vcluster/pkg/certs"
+	"github.com/loft-sh/vcluster/pkg/credentials"
+	"github.com/loft-sh/vcluster/pkg/util/certhelper"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	x509request "k8s.io/apiserver/pkg/authentication/request/x509"
+	"k8s.io/client-go/kubernetes/fake"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+func TestIssueUseAndRevoke(t *testing.T) {
+	defer func(duration time.Duration) { cacheTime = duration }(cacheTime)
+	cacheTime = 0
+
+	ctx := context.Background()
+	caCert, caSecret := newCASecret(t)
+	hostClient := fake.NewSimpleClientset(caSecret)
+	vClient := fake.NewSimpleClientset()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	auth := New(x509request.New(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, x509request.CommonNameUserConversion), func(ctx context.Context) (sets.Set[string], error) {
+		return credentials.RevokedSerials(ctx, hostClient, "vcluster", "test")
+	})
+
+	// issue a client certificate
+	credential, err := credentials.Issue(ctx, hostClient, vClient, "vcluster", "test", &credentials.Request{
+		Type:         credentials.TypeCertificate,
+		ClusterRoles: []string{"view"},
+		Expiration:   time.Hour,
+	}, credentials.DefaultMaxExpiration)
+	assert.NilError(t, err)
+	clientCerts, err := certhelper.ParseCertsPEM(credential.ClientCertificate)
+	assert.NilError(t, err)
+	assert.Equal(t, credential.Record.Serial, credentials.SerialOf(clientCerts[0]))
+
+	// use it
+	req := &http.Request{TLS: &tls.ConnectionState{PeerCertificates: clientCerts}}
+	response, ok, err := auth.AuthenticateRequest(req)
+	assert.NilError(t, err)
+	assert.Assert(t, ok)
+	assert.Equal(t, response.User.GetName(), credentials.UserPrefix+credential.Record.ID)
+
+	// revoke it
+	assert.NilError(t, credentials.Revoke(ctx, hostClient, vClient, "vcluster", "test", credential.Record.ID))
+	_, ok, err = auth.AuthenticateRequest(req)
+	assert.ErrorContains(t, err, "has been revoked")
+	assert.Assert(t, !ok)
+
+	revocationList, err := hostClient.CoreV1().ConfigMaps("test").Get(ctx, credentials.RevocationListName("vcluster"), metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(revocationList.Data), 1)
+}
+
+func newCASecret(t *testing.T) (*x509.Certificate, *corev1.Secret) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	caCert, err := certutil.NewSelfSignedCACert(certutil.Config{CommonName: "vcluster-client-ca"}, key)
+	assert.NilError(t, err)
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	assert.NilError(t, err)
+
+	return caCert, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      certs.CertSecretName("vcluster"),
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			certs.CACertName: certhelper.EncodeCertPEM(caCert),
+			certs.CAKeyName:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}),
+		},
+	}
+}
@@ -2,19 +2,28 @@ package impersonationauthorizer
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
 
+	vclusterconfig "github.com/loft-sh/vcluster/config"
 	delegatingauthorizer "github.com/loft-sh/vcluster/pkg/authorization/delegatingauthorizer"
 	"github.com/loft-sh/vcluster/pkg/util/clienthelper"
 	authorizationv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func New(client client.Client) authorizer.Authorizer {
+func New(client client.Client, restrictions vclusterconfig.ControlPlaneProxyImpersonation, hostNamespace string) authorizer.Authorizer {
 	return &impersonationAuthorizer{
 		client: client,
 
+		restrictions:  restrictions,
+		hostNamespace: hostNamespace,
+
 		cache: delegatingauthorizer.NewCache(),
 	}
 }
@@ -22,6 +31,9 @@ func New(client client.Client) authorizer.Authorizer {
 type impersonationAuthorizer struct {
 	client client.Client
 
+	restrictions  vclusterconfig.ControlPlaneProxyImpersonation
+	hostNamespace string
+
 	cache *delegatingauthorizer.Cache
 }
 
@@ -30,6 +42,12 @@ func (i *impersonationAuthorizer) Authorize(ctx context.Context, a authorizer.At
 		return authorizer.DecisionNoOpinion, "", nil
 	}
 
+	// check if the impersonated identity is restricted
+	if reason := i.restricted(a); reason != "" {
+		klog.FromContext(ctx).Info("Denied impersonation", "user", a.GetUser().GetName(), "resource", a.GetResource(), "namespace", a.GetNamespace(), "name", a.GetName(), "reason", reason)
+		return authorizer.DecisionDeny, reason, nil
+	}
+
 	// check if in cache
 	authorized, reason, exists := i.cache.Get(a)
 	if exists {
@@ -65,3 +83,44 @@ func (i *impersonationAuthorizer) Authorize(ctx context.Context, a authorizer.At
 
 	return authorizer.DecisionDeny, accessReview.Status.Reason, nil
 }
+
+// restricted returns the reason why the impersonated identity is not allowed or an empty string
+func (i *impersonationAuthorizer) restricted(a authorizer.Attributes) string {
+	switch a.GetResource() {
+	case "users":
+		return i.restrictedUser(a.GetName())
+	case "serviceaccounts":
+		if i.restrictions.DenyHostServiceAccounts && a.GetNamespace() == i.hostNamespace {
+			return fmt.Sprintf("impersonating service accounts of the host namespace %s is not allowed", i.hostNamespace)
+		}
+
+		return i.restrictedUser(serviceaccount.MakeUsername(a.GetNamespace(), a.GetName()))
+	case "groups":
+		if slices.Contains(i.restrictions.DeniedGroups, a.GetName()) {
+			return fmt.Sprintf("impersonating group %s is not allowed", a.GetName())
+		}
+	}
+
+	return ""
+}
+
+func (i *impersonationAuthorizer) restrictedUser(name string) string {
+	if slices.Contains(i.restrictions.DeniedUsers, name) {
+		return fmt.Sprintf("impersonating user %s is not allowed", name)
+	}
+
+	if i.restrictions.DenyHostServiceAccounts {
+		namespace, _, err := serviceaccount.SplitUsername(name)
+		if err == nil && namespace == i.hostNamespace {
+			return fmt.Sprintf("impersonating service accounts of the host namespace %s is not allowed", i.hostNamespace)
+		}
+	}
+
+	if len(i.restrictions.AllowedUserPrefixes) > 0 && !slices.ContainsFunc(i.restrictions.AllowedUserPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(name, prefix)
+	}) {
+		return fmt.Sprintf("impersonated user %s doesn't match any of the allowed prefixes %s", name, strings.Join(i.restrictions.AllowedUserPrefixes, ", "))
+	}
+
+	return ""
+}
@@ -0,0 +1,60 @@
+package impersonationauthorizer
+
+import (
+	"context"
+	"testing"
+
+	vclusterconfig "github.com/loft-sh/vcluster/config"
+	"gotest.tools/assert"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+func TestRestrictions(t *testing.T) {
+	impersonationAuthorizer := New(nil, vclusterconfig.ControlPlaneProxyImpersonation{
+		AllowedUserPrefixes:     []string{"team-a:", "system:serviceaccount:"},
+		DeniedUsers:             []string{"team-a:admin"},
+		DeniedGroups:            []string{"system:masters"},
+		DenyHostServiceAccounts: true,
+	}, "vcluster-host").(*impersonationAuthorizer)
+
+	testCases := []struct {
+		name       string
+		resource   string
+		namespace  string
+		target     string
+		restricted bool
+	}{
+		{name: "allowed prefix", resource: "users", target: "team-a:alice"},
+		{name: "other prefix", resource: "users", target: "team-b:bob", restricted: true},
+		{name: "denied user", resource: "users", target: "team-a:admin", restricted: true},
+		{name: "denied group", resource: "groups", target: "system:masters", restricted: true},
+		{name: "other group", resource: "groups", target: "team-a"},
+		{name: "service account", resource: "serviceaccounts", namespace: "default", target: "default"},
+		{name: "host service account", resource: "serviceaccounts", namespace: "vcluster-host", target: "vc-vcluster", restricted: true},
+		{name: "host service account user", resource: "users", target: "system:serviceaccount:vcluster-host:vc-vcluster", restricted: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			attributes := authorizer.AttributesRecord{
+				User:            &user.DefaultInfo{Name: "tenant"},
+				Verb:            "impersonate",
+				Resource:        testCase.resource,
+				Namespace:       testCase.namespace,
+				Name:            testCase.target,
+				ResourceRequest: true,
+			}
+			if !testCase.restricted {
+				assert.Equal(t, impersonationAuthorizer.restricted(attributes), "")
+				return
+			}
+
+			// restricted identities are denied without asking the virtual cluster
+			decision, reason, err := impersonationAuthorizer.Authorize(context.Background(), attributes)
+			assert.NilError(t, err)
+			assert.Equal(t, decision, authorizer.DecisionDeny)
+			assert.Assert(t, reason != "")
+		})
+	}
+}
@@ -0,0 +1,419 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/log/table"
+	"github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/platform"
+	"github.com/loft-sh/vcluster/pkg/upgrade"
+	"golang.org/x/sync/errgroup"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// FleetLabel is set on the control plane of vClusters that are managed by a fleet file and holds the fleet name.
+	FleetLabel = "vcluster.loft.sh/fleet"
+
+	// FleetHashAnnotation holds the hash of the fleet entry the vCluster was last applied with.
+	FleetHashAnnotation = "vcluster.loft.sh/fleet-hash"
+)
+
+// Fleet is a declarative list of vClusters that is applied with vcluster apply.
+type Fleet struct {
+	// Name of the fleet. vClusters created by the fleet are labeled with it, so --prune only deletes
+	// vClusters of this fleet.
+	Name string `json:"name"`
+
+	// VClusters of the fleet.
+	VClusters []FleetVCluster `json:"vclusters,omitempty"`
+}
+
+// FleetVCluster is a single vCluster of a fleet.
+type FleetVCluster struct {
+	// Name of the vCluster.
+	Name string `json:"name"`
+
+	// Namespace of the vCluster, defaults to vcluster-NAME.
+	Namespace string `json:"namespace,omitempty"`
+
+	// ChartVersion of the vCluster, defaults to the version of the cli.
+	ChartVersion string `json:"chartVersion,omitempty"`
+
+	// Values are paths of values files, relative to the fleet file.
+	Values []string `json:"values,omitempty"`
+
+	// Config holds inline values that are applied after the values files.
+	Config map[string]interface{} `json:"config,omitempty"`
+
+	// Connector is the platform secret with the database connection information, see
+	// controlPlane.backingStore.database.external.connector.
+	Connector string `json:"connector,omitempty"`
+
+	// SleepMode configures the sleep mode of the vCluster.
+	SleepMode *config.SleepMode `json:"sleepMode,omitempty"`
+}
+
+// ApplyOptions holds the apply cmd options
+type ApplyOptions struct {
+	CreateOptions
+
+	Files       []string
+	Prune       bool
+	DryRun      bool
+	Concurrency int
+}
+
+type fleetAction string
+
+const (
+	fleetActionCreate    fleetAction = "create"
+	fleetActionUpgrade   fleetAction = "upgrade"
+	fleetActionDelete    fleetAction = "delete"
+	fleetActionUnchanged fleetAction = "unchanged"
+)
+
+// fleetPlanItem is a planned change of a single vCluster.
+type fleetPlanItem struct {
+	Name      string
+	Namespace string
+	Action    fleetAction
+	Reason    string
+
+	// values and files that are passed to CreateHelm
+	chartVersion string
+	values       []byte
+	valuesFiles  []string
+
+	result   string
+	err      error
+	duration time.Duration
+	output   *bytes.Buffer
+}
+
+// ApplyHelm creates, upgrades and optionally deletes the vClusters of the fleet files.
+func ApplyHelm(ctx context.Context, platformClient platform.Client, options *ApplyOptions, globalFlags *flags.GlobalFlags, log log.Logger) error {
+	if len(options.Files) == 0 {
+		return errors.New("please specify at least one fleet file with -f")
+	}
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+
+	fleets := make([]*Fleet, 0, len(options.Files))
+	for _, file := range options.Files {
+		fleet, err := readFleet(file)
+		if err != nil {
+			return err
+		}
+		fleets = append(fleets, fleet)
+	}
+
+	existing, err := find.ListVClusters(ctx, globalFlags.Context, "", "", log)
+	if err != nil {
+		return err
+	}
+
+	var plan []*fleetPlanItem
+	for i, fleet := range fleets {
+		fleetPlan, err := planFleet(fleet, filepath.Dir(options.Files[i]), existing, options.ChartVersion, options.Prune)
+		if err != nil {
+			return err
+		}
+		plan = append(plan, fleetPlan...)
+	}
+
+	if options.DryRun {
+		values := make([][]string, 0, len(plan))
+		for _, item := range plan {
+			values = append(values, []string{item.Name, item.Namespace, string(item.Action), item.Reason})
+		}
+		table.PrintTable(log, []string{"NAME", "NAMESPACE", "ACTION", "REASON"}, values)
+		return nil
+	}
+
+	// apply the plan with bounded concurrency
+	var outputLock sync.Mutex
+	group := errgroup.Group{}
+	group.SetLimit(options.Concurrency)
+	for _, item := range plan {
+		if item.Action == fleetActionUnchanged {
+			item.result = "Unchanged"
+			continue
+		}
+
+		group.Go(func() error {
+			start := time.Now()
+			item.err = applyFleetItem(ctx, platformClient, options, globalFlags, item, log)
+			item.duration = time.Since(start).Round(time.Second)
+			if item.err != nil {
+				item.result = "Failed: " + item.err.Error()
+			} else {
+				item.result = "Done"
+			}
+
+			// print the output of the vCluster at once, so the logs of different vClusters don't interleave
+			outputLock.Lock()
+			defer outputLock.Unlock()
+			if item.err != nil {
+				log.Errorf("Failed to %s vCluster %s/%s: %v", item.Action, item.Namespace, item.Name, item.err)
+				log.WriteString(log.GetLevel(), item.output.String())
+			} else {
+				log.Donef("Applied %s of vCluster %s/%s in %s", item.Action, item.Namespace, item.Name, item.duration)
+			}
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	failed := 0
+	values := make([][]string, 0, len(plan))
+	for _, item := range plan {
+		if item.err != nil {
+			failed++
+		}
+		duration := ""
+		if item.duration > 0 {
+			duration = item.duration.String()
+		}
+		values = append(values, []string{item.Name, item.Namespace, string(item.Action), item.result, duration})
+	}
+	table.PrintTable(log, []string{"NAME", "NAMESPACE", "ACTION", "RESULT", "DURATION"}, values)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d vClusters failed to apply", failed, len(plan))
+	}
+
+	return nil
+}
+
+func applyFleetItem(ctx context.Context, platformClient platform.Client, options *ApplyOptions, globalFlags *flags.GlobalFlags, item *fleetPlanItem, parentLog log.Logger) error {
+	item.output = &bytes.Buffer{}
+	itemLog := log.NewStreamLogger(item.output, item.output, parentLog.GetLevel())
+	itemFlags := *globalFlags
+	itemFlags.Namespace = item.Namespace
+
+	if item.Action == fleetActionDelete {
+		return DeleteHelm(ctx, platformClient, &DeleteOptions{
+			Wait:                true,
+			DeleteConfigMap:     true,
+			AutoDeleteNamespace: true,
+			IgnoreNotFound:      true,
+		}, &itemFlags, item.Name, itemLog)
+	}
+
+	valuesFile, err := writeTempFile(item.values)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(valuesFile)
+
+	createOptions := options.CreateOptions
+	createOptions.ChartVersion = item.chartVersion
+	createOptions.Values = append(slices.Clone(item.valuesFiles), valuesFile)
+	createOptions.Upgrade = true
+	createOptions.Connect = false
+	createOptions.UpdateCurrent = false
+	createOptions.Print = false
+	return CreateHelm(ctx, &createOptions, &itemFlags, item.Name, itemLog)
+}
+
+// readFleet reads and validates a fleet file.
+func readFleet(file string) (*Fleet, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read fleet file: %w", err)
+	}
+
+	fleet := &Fleet{}
+	err = yaml.UnmarshalStrict(raw, fleet)
+	if err != nil {
+		return nil, fmt.Errorf("parse fleet file %s: %w", file, err)
+	}
+
+	if fleet.Name == "" {
+		return nil, fmt.Errorf("fleet file %s: name is required", file)
+	} else if errs := validation.IsValidLabelValue(fleet.Name); len(errs) > 0 {
+		return nil, fmt.Errorf("fleet file %s: invalid name %q: %s", file, fleet.Name, strings.Join(errs, ", "))
+	}
+
+	seen := map[string]bool{}
+	for i := range fleet.VClusters {
+		vCluster := &fleet.VClusters[i]
+		if vCluster.Name == "" {
+			return nil, fmt.Errorf("fleet file %s: vclusters[%d].name is required", file, i)
+		}
+		if vCluster.Namespace == "" {
+			vCluster.Namespace = "vcluster-" + vCluster.Name
+		}
+		if seen[vCluster.Namespace] {
+			return nil, fmt.Errorf("fleet file %s: there is more than one vCluster in namespace %s", file, vCluster.Namespace)
+		}
+		seen[vCluster.Namespace] = true
+	}
+
+	return fleet, nil
+}
+
+// planFleet compares the fleet with the existing vClusters and decides which vClusters need to be created,
+// upgraded or deleted.
+func planFleet(fleet *Fleet, baseDir string, existing []find.VCluster, defaultChartVersion string, prune bool) ([]*fleetPlanItem, error) {
+	if defaultChartVersion == "" || defaultChartVersion == upgrade.DevelopmentVersion {
+		defaultChartVersion = upgrade.GetVersion()
+	}
+
+	var plan []*fleetPlanItem
+	desired := map[string]bool{}
+	for _, vCluster := range fleet.VClusters {
+		desired[vCluster.Namespace+"/"+vCluster.Name] = true
+		item, err := planFleetVCluster(fleet.Name, vCluster, baseDir, existing, defaultChartVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		plan = append(plan, item)
+	}
+
+	if prune {
+		for _, vCluster := range existing {
+			if vCluster.Labels[FleetLabel] != fleet.Name || desired[vCluster.Namespace+"/"+vCluster.Name] {
+				continue
+			}
+
+			plan = append(plan, &fleetPlanItem{
+				Name:      vCluster.Name,
+				Namespace: vCluster.Namespace,
+				Action:    fleetActionDelete,
+				Reason:    "not in fleet " + fleet.Name,
+			})
+		}
+	}
+
+	return plan, nil
+}
+
+func planFleetVCluster(fleetName string, vCluster FleetVCluster, baseDir string, existing []find.VCluster, defaultChartVersion string) (*fleetPlanItem, error) {
+	item := &fleetPlanItem{
+		Name:         vCluster.Name,
+		Namespace:    vCluster.Namespace,
+		chartVersion: vCluster.ChartVersion,
+	}
+	if item.chartVersion == "" {
+		item.chartVersion = defaultChartVersion
+	}
+
+	// the hash covers everything that ends up in the helm release, so unchanged vClusters can be skipped
+	hash := sha256.New()
+	hash.Write([]byte(item.chartVersion + "\n"))
+	for _, valuesFile := range vCluster.Values {
+		if !filepath.IsAbs(valuesFile) {
+			valuesFile = filepath.Join(baseDir, valuesFile)
+		}
+		raw, err := os.ReadFile(valuesFile)
+		if err != nil {
+			return nil, fmt.Errorf("read values of vCluster %s: %w", vCluster.Name, err)
+		}
+		hash.Write(raw)
+		item.valuesFiles = append(item.valuesFiles, valuesFile)
+	}
+
+	values, err := fleetValues(vCluster)
+	if err != nil {
+		return nil, fmt.Errorf("build values of vCluster %s: %w", vCluster.Name, err)
+	}
+	raw, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	hash.Write(raw)
+	specHash := hex.EncodeToString(hash.Sum(nil))[:16]
+
+	// label the control plane, so the vCluster can be found by --prune and the next apply
+	err = unstructured.SetNestedField(values, fleetName, "controlPlane", "statefulSet", "labels", FleetLabel)
+	if err != nil {
+		return nil, fmt.Errorf("build values of vCluster %s: %w", vCluster.Name, err)
+	}
+	err = unstructured.SetNestedField(values, specHash, "controlPlane", "statefulSet", "annotations", FleetHashAnnotation)
+	if err != nil {
+		return nil, fmt.Errorf("build values of vCluster %s: %w", vCluster.Name, err)
+	}
+	item.values, err = yaml.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	var current *find.VCluster
+	for i := range existing {
+		if existing[i].Name == vCluster.Name && existing[i].Namespace == vCluster.Namespace {
+			current = &existing[i]
+			break
+		}
+	}
+
+	switch {
+	case current == nil:
+		item.Action = fleetActionCreate
+		item.Reason = "not found"
+	case current.Annotations[FleetHashAnnotation] == specHash:
+		item.Action = fleetActionUnchanged
+		item.Reason = "up to date"
+	case current.Annotations[FleetHashAnnotation] == "":
+		item.Action = fleetActionUpgrade
+		item.Reason = "not applied by a fleet yet"
+	default:
+		item.Action = fleetActionUpgrade
+		item.Reason = "fleet entry changed"
+	}
+
+	return item, nil
+}
+
+// fleetValues converts the inline config, connector and sleep mode of the fleet entry into helm values.
+func fleetValues(vCluster FleetVCluster) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if len(vCluster.Config) > 0 {
+		values = runtime.DeepCopyJSON(vCluster.Config)
+	}
+
+	if vCluster.Connector != "" {
+		err := unstructured.SetNestedField(values, true, "controlPlane", "backingStore", "database", "external", "enabled")
+		if err != nil {
+			return nil, err
+		}
+		err = unstructured.SetNestedField(values, vCluster.Connector, "controlPlane", "backingStore", "database", "external", "connector")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if vCluster.SleepMode != nil {
+		raw, err := yaml.Marshal(vCluster.SleepMode)
+		if err != nil {
+			return nil, err
+		}
+		sleepMode := map[string]interface{}{}
+		err = yaml.Unmarshal(raw, &sleepMode)
+		if err != nil {
+			return nil, err
+		}
+		values["sleepMode"] = sleepMode
+	}
+
+	return values, nil
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	"gotest.tools/v3/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestApplyFleet(t *testing.T) {
+	dir := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "team-a.yaml"), []byte("sync:\n  toHost:\n    ingresses:\n      enabled: true\n"), 0o644))
+	fleetFile := filepath.Join(dir, "fleet.yaml")
+	assert.NilError(t, os.WriteFile(fleetFile, []byte(`name: my-fleet
+vclusters:
+- name: team-a
+  namespace: team-a
+  chartVersion: 0.29.0
+  values:
+  - team-a.yaml
+  connector: my-connector
+  sleepMode:
+    enabled: true
+    autoSleep:
+      afterInactivity: 1h
+- name: team-b
+`), 0o644))
+
+	fleet, err := readFleet(fleetFile)
+	assert.NilError(t, err)
+	assert.Equal(t, len(fleet.VClusters), 2)
+	assert.Equal(t, fleet.VClusters[1].Namespace, "vcluster-team-b")
+
+	// nothing exists yet
+	plan, err := planFleet(fleet, dir, nil, "0.30.0", true)
+	assert.NilError(t, err)
+	assert.Equal(t, len(plan), 2)
+	assert.Equal(t, plan[0].Action, fleetActionCreate)
+	assert.Equal(t, plan[0].chartVersion, "0.29.0")
+	assert.DeepEqual(t, plan[0].valuesFiles, []string{filepath.Join(dir, "team-a.yaml")})
+	assert.Equal(t, plan[1].chartVersion, "0.30.0")
+
+	values := map[string]interface{}{}
+	assert.NilError(t, yaml.Unmarshal(plan[0].values, &values))
+	controlPlane := values["controlPlane"].(map[string]interface{})
+	external := controlPlane["backingStore"].(map[string]interface{})["database"].(map[string]interface{})["external"].(map[string]interface{})
+	assert.Equal(t, external["connector"], "my-connector")
+	assert.Equal(t, external["enabled"], true)
+	assert.Equal(t, values["sleepMode"].(map[string]interface{})["autoSleep"].(map[string]interface{})["afterInactivity"], "1h0m0s")
+	statefulSet := controlPlane["statefulSet"].(map[string]interface{})
+	assert.Equal(t, statefulSet["labels"].(map[string]interface{})[FleetLabel], "my-fleet")
+	hash := statefulSet["annotations"].(map[string]interface{})[FleetHashAnnotation].(string)
+
+	// team-a is up to date, team-b was created by hand and the third vCluster was removed from the fleet
+	existing := []find.VCluster{
+		{Name: "team-a", Namespace: "team-a", Annotations: map[string]string{FleetHashAnnotation: hash}, Labels: map[string]string{FleetLabel: "my-fleet"}},
+		{Name: "team-b", Namespace: "vcluster-team-b"},
+		{Name: "team-c", Namespace: "vcluster-team-c", Labels: map[string]string{FleetLabel: "my-fleet"}},
+		{Name: "other", Namespace: "vcluster-other", Labels: map[string]string{FleetLabel: "other-fleet"}},
+	}
+	plan, err = planFleet(fleet, dir, existing, "0.30.0", true)
+	assert.NilError(t, err)
+	actions := map[string]fleetAction{}
+	for _, item := range plan {
+		actions[item.Name] = item.Action
+	}
+	assert.DeepEqual(t, actions, map[string]fleetAction{
+		"team-a": fleetActionUnchanged,
+		"team-b": fleetActionUpgrade,
+		"team-c": fleetActionDelete,
+	})
+
+	// without --prune nothing is deleted
+	plan, err = planFleet(fleet, dir, existing, "0.30.0", false)
+	assert.NilError(t, err)
+	assert.Equal(t, len(plan), 2)
+
+	// a changed values file changes the hash
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "team-a.yaml"), []byte("sync: {}\n"), 0o644))
+	plan, err = planFleet(fleet, dir, existing, "0.30.0", false)
+	assert.NilError(t, err)
+	assert.Equal(t, plan[0].Action, fleetActionUpgrade)
+}
+
+func TestReadFleetErrors(t *testing.T) {
+	tests := map[string]string{
+		"missing name":       "vclusters:\n- name: a\n",
+		"unknown field":      "name: fleet\nvclusters:\n- name: a\n  namspace: a\n",
+		"duplicate":          "name: fleet\nvclusters:\n- name: a\n  namespace: ns\n- name: b\n  namespace: ns\n",
+		"missing vcluster":   "name: fleet\nvclusters:\n- namespace: ns\n",
+		"invalid fleet name": "name: my fleet\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "fleet.yaml")
+			assert.NilError(t, os.WriteFile(file, []byte(content), 0o644))
+			_, err := readFleet(file)
+			assert.Assert(t, err != nil)
+		})
+	}
+}
@@ -26,18 +26,44 @@ func Check(ctx context.Context, vClusterName string, globalFlags *flags.GlobalFl
 		return err
 	}
 
+	certificateInfos, err := Infos(ctx, vCluster)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		bytes, err := json.MarshalIndent(certificateInfos, "", "    ")
+		if err != nil {
+			return fmt.Errorf("json marshal vClusters: %w", err)
+		}
+
+		log.WriteString(logrus.InfoLevel, string(bytes)+"\n")
+	} else {
+		header := []string{"FILENAME", "SUBJECT", "ISSUER", "EXPIRES ON", "STATUS"}
+		var values [][]string
+		for _, certInfo := range certificateInfos {
+			values = append(values, []string{certInfo.Filename, certInfo.Subject, certInfo.Issuer, certInfo.ExpiryTime.Format("Jan 02, 2006 15:04 MST"), certInfo.Status})
+		}
+		table.PrintTable(log, header, values)
+	}
+
+	return nil
+}
+
+// Infos runs the check command in the control plane of the vCluster and returns the information of its certificates.
+func Infos(ctx context.Context, vCluster *find.VCluster) ([]certs.Info, error) {
 	// check if check command is supported
 	version, err := semver.Parse(strings.TrimPrefix(vCluster.Version, "v"))
 	if err == nil {
 		// only check if version matches if vCluster actually has a parsable version
 		if version.LT(semver.MustParse(minVersion)) {
-			return fmt.Errorf("cert check is not supported in vCluster version %s", vCluster.Version)
+			return nil, fmt.Errorf("cert check is not supported in vCluster version %s", vCluster.Version)
 		}
 	}
 
 	// abort in case the virtual cluster has a non-running status.
 	if vCluster.Status != find.StatusRunning {
-		return fmt.Errorf("aborting operation because virtual cluster %q has status %q", vCluster.Name, vCluster.Status)
+		return nil, fmt.Errorf("aborting operation because virtual cluster %q has status %q", vCluster.Name, vCluster.Status)
 	}
 
 	var targetPod *corev1.Pod
@@ -51,12 +77,12 @@ func Check(ctx context.Context, vClusterName string, globalFlags *flags.GlobalFl
 		}
 	}
 	if targetPod == nil {
-		return fmt.Errorf("couldn't find a running pod for vCluster %s", vCluster.Name)
+		return nil, fmt.Errorf("couldn't find a running pod for vCluster %s", vCluster.Name)
 	}
 
 	kubeConfig, err := vCluster.ClientFactory.ClientConfig()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	reader, writer := io.Pipe()
@@ -88,25 +114,9 @@ func Check(ctx context.Context, vClusterName string, globalFlags *flags.GlobalFl
 			if err == io.EOF {
 				break
 			}
-			return fmt.Errorf("decoding: %w", err)
-		}
-	}
-
-	if output == "json" {
-		bytes, err := json.MarshalIndent(certificateInfos, "", "    ")
-		if err != nil {
-			return fmt.Errorf("json marshal vClusters: %w", err)
+			return nil, fmt.Errorf("decoding: %w", err)
 		}
-
-		log.WriteString(logrus.InfoLevel, string(bytes)+"\n")
-	} else {
-		header := []string{"FILENAME", "SUBJECT", "ISSUER", "EXPIRES ON", "STATUS"}
-		var values [][]string
-		for _, certInfo := range certificateInfos {
-			values = append(values, []string{certInfo.Filename, certInfo.Subject, certInfo.Issuer, certInfo.ExpiryTime.Format("Jan 02, 2006 15:04 MST"), certInfo.Status})
-		}
-		table.PrintTable(log, header, values)
 	}
 
-	return nil
+	return certificateInfos, nil
 }
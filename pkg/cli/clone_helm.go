@@ -0,0 +1,372 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/snapshot"
+	"github.com/loft-sh/vcluster/pkg/snapshot/encryption"
+	"github.com/loft-sh/vcluster/pkg/snapshot/pod"
+	"github.com/loft-sh/vcluster/pkg/upgrade"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	snapshotRequestInterval = 5 * time.Second
+	snapshotRequestTimeout  = 2 * time.Hour
+)
+
+// DefaultCloneRewritePaths are the config paths that usually contain the name or namespace of the vCluster.
+var DefaultCloneRewritePaths = []string{
+	"controlPlane.ingress.host",
+	"controlPlane.ingress.spec",
+	"controlPlane.proxy.extraSANs",
+	"exportKubeConfig",
+}
+
+// externalDatastorePaths are the config paths that point to a datastore outside of the vCluster. The restore wipes
+// the datastore of the clone, so these need to be changed for the clone.
+var externalDatastorePaths = []string{
+	"controlPlane.backingStore.database.external.dataSource",
+	"controlPlane.backingStore.etcd.external.endpoint",
+}
+
+// CloneOptions holds the clone cmd options
+type CloneOptions struct {
+	CreateOptions
+
+	SourceNamespace     string
+	Snapshot            string
+	UseExistingSnapshot bool
+	IncludeVolumes      bool
+	Patches             []string
+	RewritePaths        []string
+
+	// Encryption is used to encrypt the snapshot of the source vCluster and to decrypt it again for the clone
+	Encryption encryption.Options
+}
+
+// CloneHelm creates a new vCluster from a snapshot of an existing vCluster.
+func CloneHelm(ctx context.Context, options *CloneOptions, globalFlags *flags.GlobalFlags, sourceName, vClusterName string, log log.Logger) error {
+	if options.Snapshot == "" {
+		return errors.New("please specify the snapshot url with --snapshot")
+	} else if options.Restore != "" {
+		return errors.New("--restore cannot be used with vcluster clone, please use --snapshot instead")
+	}
+	if globalFlags.Namespace == "" {
+		globalFlags.Namespace = "vcluster-" + vClusterName
+	}
+
+	// find the source vCluster
+	source, err := find.GetVCluster(ctx, globalFlags.Context, sourceName, options.SourceNamespace, log)
+	if err != nil {
+		return err
+	} else if source.Namespace == globalFlags.Namespace {
+		return fmt.Errorf("vCluster %s is already in namespace %s, please choose another namespace for the clone", source.Name, source.Namespace)
+	}
+
+	// take the snapshot of the source vCluster
+	if !options.UseExistingSnapshot {
+		err = cloneSnapshot(ctx, globalFlags, source, options, log)
+		if err != nil {
+			return fmt.Errorf("snapshot vCluster %s: %w", source.Name, err)
+		}
+	}
+
+	// read the release of the source vCluster from the snapshot
+	sourceRestConfig, err := source.ClientFactory.ClientConfig()
+	if err != nil {
+		return err
+	}
+	sourceKubeClient, err := kubernetes.NewForConfig(sourceRestConfig)
+	if err != nil {
+		return err
+	}
+	snapshotOptions := &snapshot.Options{Encryption: options.Encryption}
+	err = fillSnapshotOptions(options.Snapshot, snapshotOptions)
+	if err != nil {
+		return err
+	}
+	release, err := snapshot.ReadRelease(ctx, snapshotOptions, snapshotSecretLoader(ctx, sourceKubeClient, source.Namespace))
+	if err != nil {
+		return fmt.Errorf("read vCluster release from snapshot: %w", err)
+	} else if release == nil {
+		return fmt.Errorf("snapshot %s doesn't contain the vCluster release, please take a new snapshot", options.Snapshot)
+	}
+
+	// rewrite the values for the new vCluster
+	values, err := cloneValues(release.Values, source.Name, source.Namespace, vClusterName, globalFlags.Namespace, options.RewritePaths, options.Patches)
+	if err != nil {
+		return err
+	}
+	valuesFile, err := writeTempFile(values)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(valuesFile)
+
+	// the restore pod loads the encryption key secret from the namespace of the clone
+	if snapshotOptions.Encryption.KeySecret != "" {
+		err = copyEncryptionKeySecret(ctx, sourceKubeClient, snapshotOptions.Encryption.KeySecret, source.Namespace, globalFlags.Namespace, log)
+		if err != nil {
+			return err
+		}
+	}
+
+	// create the new vCluster and restore the snapshot into it
+	createOptions := options.CreateOptions
+	createOptions.Values = append([]string{valuesFile}, createOptions.Values...)
+	createOptions.Restore = options.Snapshot
+	createOptions.RestoreVolumes = options.IncludeVolumes
+	createOptions.RestoreEncryption = options.Encryption
+	if release.ChartVersion != "" && (createOptions.ChartVersion == "" || createOptions.ChartVersion == upgrade.GetVersion()) {
+		createOptions.ChartVersion = release.ChartVersion
+	}
+
+	log.Infof("Clone vCluster %s/%s into %s/%s...", source.Namespace, source.Name, globalFlags.Namespace, vClusterName)
+	return CreateHelm(ctx, &createOptions, globalFlags, vClusterName, log)
+}
+
+// cloneSnapshot takes a snapshot of the source vCluster. Volumes can only be backed up by a snapshot request,
+// so in this case the snapshot request is created and awaited.
+func cloneSnapshot(ctx context.Context, globalFlags *flags.GlobalFlags, source *find.VCluster, options *CloneOptions, log log.Logger) error {
+	sourceFlags := *globalFlags
+	sourceFlags.Namespace = source.Namespace
+	snapshotOptions := &snapshot.Options{
+		IncludeVolumes: options.IncludeVolumes,
+		Encryption:     options.Encryption,
+	}
+	vCluster, kubeClient, restConfig, err := initSnapshotCommand(ctx, []string{source.Name, options.Snapshot}, &sourceFlags, snapshotOptions, log)
+	if err != nil {
+		return err
+	}
+	err = setSnapshotRelease(ctx, vCluster, kubeClient, snapshotOptions)
+	if err != nil {
+		return err
+	}
+
+	if !options.IncludeVolumes {
+		log.Infof("Taking snapshot of vCluster %s...", vCluster.Name)
+		return pod.RunSnapshotPod(ctx, restConfig, kubeClient, []string{"/vcluster", "snapshot"}, vCluster, &pod.Options{}, snapshotOptions, log)
+	}
+
+	request, err := createSnapshotRequest(ctx, vCluster, kubeClient, snapshotOptions, log)
+	if err != nil {
+		return err
+	}
+
+	return waitForSnapshotRequest(ctx, kubeClient, vCluster.Namespace, request.Name, log)
+}
+
+// copyEncryptionKeySecret copies the encryption key secret of the snapshot into the namespace of the clone, which
+// is created if it doesn't exist yet. An existing secret in the namespace of the clone is kept.
+func copyEncryptionKeySecret(ctx context.Context, kubeClient kubernetes.Interface, name, sourceNamespace, namespace string, log log.Logger) error {
+	_, err := kubeClient.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		log.Infof("Creating namespace %s", namespace)
+		_, err = kubeClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: namespace,
+				Annotations: map[string]string{
+					CreatedByVClusterAnnotation: "true",
+				},
+			},
+		}, metav1.CreateOptions{})
+	}
+	if err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("ensure namespace %s: %w", namespace, err)
+	}
+
+	secret, err := kubeClient.CoreV1().Secrets(sourceNamespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get encryption key secret %s/%s: %w", sourceNamespace, name, err)
+	}
+
+	_, err = kubeClient.CoreV1().Secrets(namespace).Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Type: secret.Type,
+		Data: secret.Data,
+	}, metav1.CreateOptions{})
+	if kerrors.IsAlreadyExists(err) {
+		log.Infof("Using existing encryption key secret %s/%s", namespace, name)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("copy encryption key secret to namespace %s: %w", namespace, err)
+	}
+
+	return nil
+}
+
+// waitForSnapshotRequest waits until the snapshot request has finished.
+func waitForSnapshotRequest(ctx context.Context, kubeClient kubernetes.Interface, namespace, name string, log log.Logger) error {
+	var request *snapshot.Request
+	err := wait.PollUntilContextTimeout(ctx, snapshotRequestInterval, snapshotRequestTimeout, true, func(ctx context.Context) (bool, error) {
+		configMap, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("get snapshot request %s/%s: %w", namespace, name, err)
+		}
+
+		request, err = snapshot.UnmarshalSnapshotRequest(configMap)
+		if err != nil {
+			return false, err
+		}
+
+		log.Debugf("Snapshot request %s is in phase %s", name, request.Status.Phase)
+		return request.Done(), nil
+	})
+	if err != nil {
+		return fmt.Errorf("wait for snapshot request %s/%s: %w", namespace, name, err)
+	}
+
+	switch request.Status.Phase {
+	case snapshot.RequestPhaseFailed, snapshot.RequestPhaseCanceled:
+		return fmt.Errorf("snapshot request %s has %s: %s", name, strings.ToLower(string(request.Status.Phase)), request.Status.Error.Message)
+	case snapshot.RequestPhasePartiallyFailed:
+		log.Warnf("Snapshot request %s has partially failed, not all volumes will be restored: %s", name, request.Status.Error.Message)
+	default:
+		log.Donef("Snapshot request %s has completed", name)
+	}
+
+	return nil
+}
+
+// cloneValues rewrites the source vCluster name and namespace in the values at the given paths and applies
+// the patches afterwards. A patch has the form path=value, where the value is parsed as yaml, or path- to
+// remove the path from the values.
+func cloneValues(values []byte, sourceName, sourceNamespace, name, namespace string, rewritePaths, patches []string) ([]byte, error) {
+	config := map[string]interface{}{}
+	if len(values) > 0 {
+		err := yaml.Unmarshal(values, &config)
+		if err != nil {
+			return nil, fmt.Errorf("parse vCluster values: %w", err)
+		}
+	}
+
+	// remember the external datastores of the source vCluster
+	datastores := map[string]string{}
+	for _, path := range externalDatastorePaths {
+		value, _, _ := unstructured.NestedString(config, strings.Split(path, ".")...)
+		if value != "" {
+			datastores[path] = value
+		}
+	}
+
+	rewriter := &nameRewriter{
+		sourceName:      sourceName,
+		sourceNamespace: sourceNamespace,
+		name:            name,
+		namespace:       namespace,
+	}
+	for _, path := range rewritePaths {
+		fields := strings.Split(path, ".")
+		value, found, err := unstructured.NestedFieldNoCopy(config, fields...)
+		if err != nil || !found {
+			continue
+		}
+
+		err = unstructured.SetNestedField(config, rewriter.rewrite(fields[len(fields)-1], value), fields...)
+		if err != nil {
+			return nil, fmt.Errorf("rewrite %s: %w", path, err)
+		}
+	}
+
+	for _, patch := range patches {
+		if path, ok := strings.CutSuffix(patch, "-"); ok && !strings.Contains(patch, "=") {
+			unstructured.RemoveNestedField(config, strings.Split(path, ".")...)
+			continue
+		}
+
+		path, rawValue, ok := strings.Cut(patch, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid patch %q, expected path=value or path-", patch)
+		}
+
+		var value interface{}
+		err := yaml.Unmarshal([]byte(rawValue), &value)
+		if err != nil {
+			return nil, fmt.Errorf("parse value of patch %q: %w", patch, err)
+		}
+
+		err = unstructured.SetNestedField(config, value, strings.Split(path, ".")...)
+		if err != nil {
+			return nil, fmt.Errorf("apply patch %q: %w", patch, err)
+		}
+	}
+
+	// the restore deletes the existing data of the clone, so it must not use the datastore of the source vCluster
+	for _, path := range externalDatastorePaths {
+		value, _, _ := unstructured.NestedString(config, strings.Split(path, ".")...)
+		if datastores[path] != "" && value == datastores[path] {
+			return nil, fmt.Errorf("the source vCluster uses the external datastore %s, restoring the clone would wipe its data. Please point the clone to another datastore with --patch %s=...", path, path)
+		}
+	}
+
+	return yaml.Marshal(config)
+}
+
+// labelRegEx matches the dns labels and other name like tokens of a value, e.g. the labels of a host or url
+var labelRegEx = regexp.MustCompile(`[A-Za-z0-9_-]+`)
+
+// nameRewriter replaces the source vCluster name and namespace with the new ones. Only whole names are replaced,
+// so a value that merely contains the name, such as my-vcluster-data for my-vcluster, is kept as is.
+type nameRewriter struct {
+	sourceName      string
+	sourceNamespace string
+	name            string
+	namespace       string
+}
+
+// rewrite rewrites the value of the given field. Fields that hold a namespace are only rewritten if they are exactly
+// the source namespace, all other strings are rewritten per dns label.
+func (r *nameRewriter) rewrite(field string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if field == "namespace" {
+			if v == r.sourceNamespace {
+				return r.namespace
+			}
+
+			return v
+		}
+
+		return labelRegEx.ReplaceAllStringFunc(v, r.rewriteLabel)
+	case []interface{}:
+		for i := range v {
+			v[i] = r.rewrite(field, v[i])
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = r.rewrite(key, v[key])
+		}
+	}
+
+	return value
+}
+
+func (r *nameRewriter) rewriteLabel(label string) string {
+	switch label {
+	case r.sourceNamespace:
+		return r.namespace
+	case r.sourceName:
+		return r.name
+	}
+
+	return label
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCloneValues(t *testing.T) {
+	values := `controlPlane:
+  ingress:
+    enabled: true
+    host: my-vcluster.vcluster-my-vcluster.example.com
+  proxy:
+    extraSANs:
+    - my-vcluster.vcluster-my-vcluster.svc
+    - my-vcluster-data.example.com
+sync:
+  toHost:
+    ingresses:
+      enabled: true
+exportKubeConfig:
+  server: https://my-vcluster.vcluster-my-vcluster:443
+  secret:
+    name: vc-my-vcluster
+    namespace: vcluster-my-vcluster
+`
+	patches := []string{
+		"controlPlane.ingress.host=clone.example.com",
+		"controlPlane.statefulSet.resources.limits={memory: 2Gi}",
+		"sync.toHost.ingresses-",
+	}
+	cloned, err := cloneValues([]byte(values), "my-vcluster", "vcluster-my-vcluster", "clone", "vcluster-clone", DefaultCloneRewritePaths, patches)
+	assert.NilError(t, err)
+	assert.Equal(t, string(cloned), `controlPlane:
+  ingress:
+    enabled: true
+    host: clone.example.com
+  proxy:
+    extraSANs:
+    - clone.vcluster-clone.svc
+    - my-vcluster-data.example.com
+  statefulSet:
+    resources:
+      limits:
+        memory: 2Gi
+exportKubeConfig:
+  secret:
+    name: vc-my-vcluster
+    namespace: vcluster-clone
+  server: https://clone.vcluster-clone:443
+sync:
+  toHost: {}
+`)
+
+	_, err = cloneValues(nil, "my-vcluster", "vcluster-my-vcluster", "clone", "vcluster-clone", nil, []string{"controlPlane.ingress.host"})
+	assert.ErrorContains(t, err, "invalid patch")
+
+	// the clone must not restore into the external datastore of the source vCluster
+	values = `controlPlane:
+  backingStore:
+    database:
+      external:
+        enabled: true
+        dataSource: postgres://vcluster:password@db:5432/my-vcluster
+    etcd:
+      external:
+        endpoint: etcd.vcluster-my-vcluster:2379
+`
+	_, err = cloneValues([]byte(values), "my-vcluster", "vcluster-my-vcluster", "clone", "vcluster-clone", DefaultCloneRewritePaths, nil)
+	assert.ErrorContains(t, err, "external datastore controlPlane.backingStore.database.external.dataSource")
+	_, err = cloneValues([]byte(values), "my-vcluster", "vcluster-my-vcluster", "clone", "vcluster-clone", DefaultCloneRewritePaths, []string{
+		"controlPlane.backingStore.database.external.dataSource=postgres://vcluster:password@db:5432/clone",
+	})
+	assert.ErrorContains(t, err, "external datastore controlPlane.backingStore.etcd.external.endpoint")
+	cloned, err = cloneValues([]byte(values), "my-vcluster", "vcluster-my-vcluster", "clone", "vcluster-clone", DefaultCloneRewritePaths, []string{
+		"controlPlane.backingStore.database.external.dataSource=postgres://vcluster:password@db:5432/clone",
+		"controlPlane.backingStore.etcd-",
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, string(cloned), `controlPlane:
+  backingStore:
+    database:
+      external:
+        dataSource: postgres://vcluster:password@db:5432/clone
+        enabled: true
+`)
+}
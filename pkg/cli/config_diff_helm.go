@@ -0,0 +1,354 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/log/table"
+	"github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ErrConfigDrift is returned by ConfigDiffHelm if differences were found and ConfigDiffOptions.ExitCode is set
+var ErrConfigDrift = errors.New("the running vcluster config differs from the desired config")
+
+type ConfigDiffOptions struct {
+	Values    []string
+	SetValues []string
+	Output    string
+	ExitCode  bool
+	Contexts  find.ContextOptions
+}
+
+// ConfigDiff is the result of comparing the config of a running vCluster with the desired config.
+type ConfigDiff struct {
+	Name      string             `json:"name"`
+	Namespace string             `json:"namespace"`
+	Context   string             `json:"context,omitempty"`
+	Changes   []ConfigDiffChange `json:"changes"`
+}
+
+// ConfigDiffChange is a single changed value from the running to the desired config.
+type ConfigDiffChange struct {
+	config.Change
+
+	// RequiresRestart is true if the change restarts the vCluster control plane
+	RequiresRestart bool `json:"requiresRestart,omitempty"`
+
+	// Destructive explains why applying the change is destructive, e.g. because the backing store is switched
+	Destructive string `json:"destructive,omitempty"`
+
+	// Ignored explains why the value has no effect in this edition
+	Ignored string `json:"ignored,omitempty"`
+}
+
+// cliManagedConfigPaths are set by the cli on create and are never part of a values file
+var cliManagedConfigPaths = []string{
+	"telemetry.instanceCreator",
+	"telemetry.machineID",
+	"telemetry.platformInstanceID",
+	"telemetry.platformUserID",
+}
+
+// unavailableConfigPaths are features that are stubbed in pkg/pro and fail the vCluster start if enabled
+var unavailableConfigPaths = map[string]string{
+	"privateNodes.enabled":                  "private nodes",
+	"sync.toHost.namespaces.enabled":        "namespace toHost syncing",
+	"sync.*.*.patches":                      "translate patches",
+	"controlPlane.advanced.kubeVip.enabled": "embedded kube-vip",
+	"experimental.virtualClusterKubeConfig": "removed in v0.29.0",
+}
+
+// noRestartConfigPaths are excluded from the vcluster.vClusterConfigHash pod annotation in the chart
+var noRestartConfigPaths = []string{
+	"privateNodes.autoNodes",
+}
+
+func ConfigDiffHelm(ctx context.Context, globalFlags *flags.GlobalFlags, vClusterName string, options *ConfigDiffOptions, log log.Logger) error {
+	var (
+		vCluster *find.VCluster
+		err      error
+	)
+	if options.Contexts.Enabled() {
+		vCluster, err = find.GetVClusterInContexts(ctx, &options.Contexts, vClusterName, globalFlags.Namespace, log)
+	} else {
+		vCluster, err = find.GetVCluster(ctx, globalFlags.Context, vClusterName, globalFlags.Namespace, log)
+	}
+	if err != nil {
+		return err
+	}
+
+	restConfig, err := vCluster.ClientFactory.ClientConfig()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
+	runningConfig, err := RunningVClusterConfig(ctx, kubeClient, vCluster.Name, vCluster.Namespace)
+	if err != nil {
+		return fmt.Errorf("load running config: %w", err)
+	}
+
+	desiredConfig, err := desiredVClusterConfig(options.Values, options.SetValues)
+	if err != nil {
+		return fmt.Errorf("load desired config: %w", err)
+	}
+
+	changes, err := diffVClusterConfigs(runningConfig, desiredConfig)
+	if err != nil {
+		return err
+	}
+
+	configDiff := &ConfigDiff{
+		Name:      vCluster.Name,
+		Namespace: vCluster.Namespace,
+		Changes:   changes,
+	}
+	if options.Contexts.Enabled() {
+		configDiff.Context = vCluster.Context
+	}
+
+	if options.Output == "json" {
+		out, err := json.MarshalIndent(configDiff, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		log.WriteString(logrus.InfoLevel, string(out)+"\n")
+	} else {
+		printConfigDiff(configDiff, log)
+	}
+
+	if options.ExitCode && len(changes) > 0 {
+		return ErrConfigDrift
+	}
+
+	return nil
+}
+
+// RunningVClusterConfig loads the config of the running vCluster on top of the default values, so values that were
+// added in later chart versions don't show up as changes.
+func RunningVClusterConfig(ctx context.Context, kubeClient kubernetes.Interface, name, namespace string) (*config.Config, error) {
+	secretName := "vc-config-" + name
+	secret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	configBytes, ok := secret.Data["config.yaml"]
+	if !ok {
+		return nil, fmt.Errorf("secret %s in namespace %s does not contain the expected 'config.yaml' field", secretName, namespace)
+	}
+
+	vClusterConfig, err := config.NewDefaultConfig()
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(configBytes, vClusterConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return vClusterConfig, nil
+}
+
+// desiredVClusterConfig merges the given values files and --set values into the default values.
+func desiredVClusterConfig(valueFiles, setValues []string) (*config.Config, error) {
+	finalValues, err := mergeAllValues(setValues, valueFiles, config.Values)
+	if err != nil {
+		return nil, fmt.Errorf("merge values: %w", err)
+	}
+
+	vClusterConfig := &config.Config{}
+	err = vClusterConfig.UnmarshalYAMLStrict([]byte(finalValues))
+	if err != nil {
+		return nil, err
+	}
+
+	return vClusterConfig, nil
+}
+
+func diffVClusterConfigs(runningConfig, desiredConfig *config.Config) ([]ConfigDiffChange, error) {
+	changes, err := config.Changes(runningConfig, desiredConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	destructive := destructiveConfigPaths(runningConfig, desiredConfig)
+	proPaths := config.ProFeaturePaths()
+	diffChanges := []ConfigDiffChange{}
+	for _, change := range changes {
+		if matchConfigPath(cliManagedConfigPaths, change.Path) != "" {
+			continue
+		}
+
+		diffChange := ConfigDiffChange{
+			Change:          change,
+			RequiresRestart: matchConfigPath(noRestartConfigPaths, change.Path) == "",
+		}
+		for _, path := range slices.Sorted(maps.Keys(destructive)) {
+			if matchConfigPath([]string{path}, change.Path) != "" {
+				diffChange.Destructive = destructive[path]
+				break
+			}
+		}
+
+		// only values that enable something are ignored, disabling a feature is fine
+		if !isZeroConfigValue(change.To) {
+			if matchConfigPath(proPaths, change.Path) != "" {
+				diffChange.Ignored = "pro feature, not available in this edition"
+			} else if path := matchConfigPath(slices.Sorted(maps.Keys(unavailableConfigPaths)), change.Path); path != "" {
+				diffChange.Ignored = "not implemented in this edition: " + unavailableConfigPaths[path]
+			}
+		}
+
+		diffChanges = append(diffChanges, diffChange)
+	}
+
+	return diffChanges, nil
+}
+
+// destructiveConfigPaths returns the config paths that can't be changed without losing data or breaking the vCluster
+// mapped to the reason.
+func destructiveConfigPaths(runningConfig, desiredConfig *config.Config) map[string]string {
+	paths := map[string]string{}
+	if runningConfig.Distro() != desiredConfig.Distro() {
+		reason := fmt.Sprintf("switches the distro from %s to %s", runningConfig.Distro(), desiredConfig.Distro())
+		if err := config.ValidateDistroChanges(desiredConfig.Distro(), runningConfig.Distro()); err != nil {
+			reason = err.Error()
+		}
+		paths["controlPlane.distro.*.enabled"] = reason
+	}
+	if runningConfig.BackingStoreType() != desiredConfig.BackingStoreType() {
+		reason := fmt.Sprintf("switches the backing store from %s to %s", runningConfig.BackingStoreType(), desiredConfig.BackingStoreType())
+		if err := config.ValidateStoreChanges(desiredConfig.BackingStoreType(), runningConfig.BackingStoreType()); err != nil {
+			reason = err.Error()
+		}
+		paths["controlPlane.backingStore.*.*.enabled"] = reason
+	}
+	if err := config.ValidateNamespaceSyncChanges(runningConfig, desiredConfig); err != nil {
+		paths["sync.toHost.namespaces"] = err.Error()
+	}
+	if err := config.ValidateVPNChanges(runningConfig, desiredConfig); err != nil {
+		paths["privateNodes.vpn"] = err.Error()
+	}
+
+	return paths
+}
+
+// matchConfigPath returns the first pattern that matches the path or one of its parents. A * matches a single
+// path segment.
+func matchConfigPath(patterns []string, path string) string {
+	segments := strings.Split(path, ".")
+	for _, pattern := range patterns {
+		patternSegments := strings.Split(pattern, ".")
+		if len(patternSegments) > len(segments) {
+			continue
+		}
+
+		matches := true
+		for i, patternSegment := range patternSegments {
+			if patternSegment != "*" && patternSegment != segments[i] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return pattern
+		}
+	}
+
+	return ""
+}
+
+func isZeroConfigValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	}
+
+	return false
+}
+
+func printConfigDiff(configDiff *ConfigDiff, log log.Logger) {
+	if len(configDiff.Changes) == 0 {
+		log.Donef("The config of vcluster %s in namespace %s matches the desired config", configDiff.Name, configDiff.Namespace)
+		return
+	}
+
+	restarts := 0
+	values := [][]string{}
+	for _, change := range configDiff.Changes {
+		notes := []string{}
+		if change.RequiresRestart {
+			notes = append(notes, "restart")
+			restarts++
+		}
+		if change.Destructive != "" {
+			notes = append(notes, "destructive")
+		}
+		if change.Ignored != "" {
+			notes = append(notes, "ignored")
+		}
+
+		values = append(values, []string{
+			change.Path,
+			formatConfigValue(change.From),
+			formatConfigValue(change.To),
+			strings.Join(notes, ", "),
+		})
+	}
+	table.PrintTable(log, []string{"PATH", "RUNNING", "DESIRED", "NOTES"}, values)
+
+	for _, change := range configDiff.Changes {
+		if change.Destructive != "" {
+			log.Warnf("%s is destructive: %s", change.Path, change.Destructive)
+		}
+	}
+	for _, change := range configDiff.Changes {
+		if change.Ignored != "" {
+			log.Warnf("%s is ignored: %s", change.Path, change.Ignored)
+		}
+	}
+	if restarts > 0 {
+		log.Infof("%d of %d changes restart the vcluster control plane when applied", restarts, len(configDiff.Changes))
+	}
+}
+
+func formatConfigValue(value interface{}) string {
+	if value == nil {
+		return "<unset>"
+	}
+
+	out, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	formatted := strings.Trim(string(out), "\"")
+	if len(formatted) > 50 {
+		formatted = formatted[:47] + "..."
+	}
+
+	return formatted
+}
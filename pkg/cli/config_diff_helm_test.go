@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/loft-sh/vcluster/config"
+	"gotest.tools/v3/assert"
+)
+
+func TestDiffVClusterConfigs(t *testing.T) {
+	runningConfig, err := config.NewDefaultConfig()
+	assert.NilError(t, err)
+	runningConfig.Telemetry.MachineID = "machine"
+
+	changes, err := diffVClusterConfigs(runningConfig, runningConfig)
+	assert.NilError(t, err)
+	assert.Equal(t, len(changes), 0)
+
+	desiredConfig, err := config.NewDefaultConfig()
+	assert.NilError(t, err)
+	desiredConfig.Sync.ToHost.Ingresses.Enabled = true
+	desiredConfig.ControlPlane.BackingStore.Etcd.Embedded.Enabled = true
+	desiredConfig.ControlPlane.CoreDNS.Embedded = true
+	desiredConfig.Sync.ToHost.Pods.Patches = []config.TranslatePatch{{Path: "metadata.labels.test"}}
+	desiredConfig.PrivateNodes.AutoNodes = []config.PrivateNodesAutoNodes{{Provider: "test"}}
+
+	changes, err = diffVClusterConfigs(runningConfig, desiredConfig)
+	assert.NilError(t, err)
+	byPath := map[string]ConfigDiffChange{}
+	for _, change := range changes {
+		byPath[change.Path] = change
+	}
+	assert.Equal(t, len(byPath), 5)
+
+	ingresses := byPath["sync.toHost.ingresses.enabled"]
+	assert.Assert(t, ingresses.RequiresRestart)
+	assert.Equal(t, ingresses.Destructive, "")
+	assert.Equal(t, ingresses.Ignored, "")
+
+	etcd := byPath["controlPlane.backingStore.etcd.embedded.enabled"]
+	assert.Equal(t, etcd.Destructive, "switches the backing store from embedded-database to embedded-etcd")
+	assert.Equal(t, etcd.Ignored, "pro feature, not available in this edition")
+
+	assert.Equal(t, byPath["controlPlane.coredns.embedded"].Ignored, "pro feature, not available in this edition")
+	assert.Equal(t, byPath["sync.toHost.pods.patches"].Ignored, "not implemented in this edition: translate patches")
+	assert.Assert(t, !byPath["privateNodes.autoNodes"].RequiresRestart)
+
+	// disabling a feature is never ignored
+	changes, err = diffVClusterConfigs(desiredConfig, runningConfig)
+	assert.NilError(t, err)
+	for _, change := range changes {
+		assert.Equal(t, change.Ignored, "", change.Path)
+	}
+}
+
+func TestDestructiveConfigPaths(t *testing.T) {
+	runningConfig := &config.Config{}
+	runningConfig.ControlPlane.Distro.K3S.Enabled = true
+	runningConfig.ControlPlane.BackingStore.Etcd.Deploy.Enabled = true
+
+	desiredConfig := &config.Config{}
+	desiredConfig.ControlPlane.Distro.K8S.Enabled = true
+	assert.DeepEqual(t, destructiveConfigPaths(runningConfig, desiredConfig), map[string]string{
+		"controlPlane.distro.*.enabled":         "switches the distro from k3s to k8s",
+		"controlPlane.backingStore.*.*.enabled": "seems like you were using deployed-etcd as a store before and now have switched to embedded-database, please make sure to not switch between vCluster stores",
+	})
+	assert.Equal(t, len(destructiveConfigPaths(runningConfig, runningConfig)), 0)
+}
+
+func TestMatchConfigPath(t *testing.T) {
+	patterns := []string{"sync.*.*.patches", "privateNodes.autoNodes"}
+	assert.Equal(t, matchConfigPath(patterns, "sync.toHost.pods.patches"), "sync.*.*.patches")
+	assert.Equal(t, matchConfigPath(patterns, "privateNodes.autoNodes"), "privateNodes.autoNodes")
+	assert.Equal(t, matchConfigPath(patterns, "privateNodes.autoNodes.0.provider"), "privateNodes.autoNodes")
+	assert.Equal(t, matchConfigPath(patterns, "sync.toHost.pods.enabled"), "")
+	assert.Equal(t, matchConfigPath(patterns, "privateNodes"), "")
+}
+
+func TestDesiredVClusterConfig(t *testing.T) {
+	valuesFile := filepath.Join(t.TempDir(), "values.yaml")
+	assert.NilError(t, os.WriteFile(valuesFile, []byte("sync:\n  toHost:\n    ingresses:\n      enabled: true\n"), 0o600))
+
+	desiredConfig, err := desiredVClusterConfig([]string{valuesFile}, []string{"sync.fromHost.nodes.enabled=true"})
+	assert.NilError(t, err)
+	assert.Assert(t, desiredConfig.Sync.ToHost.Ingresses.Enabled)
+	assert.Assert(t, desiredConfig.Sync.FromHost.Nodes.Enabled)
+	// defaults are kept
+	assert.Assert(t, desiredConfig.Sync.ToHost.Services.Enabled)
+
+	assert.NilError(t, os.WriteFile(valuesFile, []byte("sync:\n  toHost:\n    unknown: true\n"), 0o600))
+	_, err = desiredVClusterConfig([]string{valuesFile}, nil)
+	assert.ErrorContains(t, err, "unknown")
+}
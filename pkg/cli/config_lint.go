@@ -0,0 +1,559 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/config/legacyconfig"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	pkgconfig "github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/upgrade"
+	"github.com/sirupsen/logrus"
+	yamlv3 "gopkg.in/yaml.v3"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+// invalidConfigRuleID is used for values that can't be parsed at all
+const invalidConfigRuleID = "invalid-config"
+
+type ConfigLintOptions struct {
+	Values        []string
+	SetValues     []string
+	Output        string
+	FailOn        string
+	DisabledRules []string
+	CheckHost     bool
+	ProFeatures   bool
+}
+
+// ConfigLintFinding is a lint finding together with its location in the values files.
+type ConfigLintFinding struct {
+	pkgconfig.LintFinding
+
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// ConfigLintReport holds all findings of a lint run.
+type ConfigLintReport struct {
+	Findings []ConfigLintFinding `json:"findings"`
+	Summary  ConfigLintSummary   `json:"summary"`
+}
+
+// ConfigLintSummary counts the findings by severity.
+type ConfigLintSummary struct {
+	Error   int `json:"error"`
+	Warning int `json:"warning"`
+	Info    int `json:"info"`
+}
+
+func ConfigLint(ctx context.Context, globalFlags *flags.GlobalFlags, options *ConfigLintOptions, log log.Logger) error {
+	if len(options.Values) == 0 {
+		return fmt.Errorf("please specify at least one values file via -f")
+	}
+	severities := []string{string(pkgconfig.LintSeverityInfo), string(pkgconfig.LintSeverityWarning), string(pkgconfig.LintSeverityError), "never"}
+	if !slices.Contains(severities, options.FailOn) {
+		return fmt.Errorf("invalid --fail-on %q, allowed values: %s", options.FailOn, strings.Join(severities, ", "))
+	}
+
+	files := map[string][]byte{}
+	for _, valuesFile := range options.Values {
+		out, err := os.ReadFile(valuesFile)
+		if err != nil {
+			return fmt.Errorf("reading values file %s: %w", valuesFile, err)
+		}
+
+		files[valuesFile] = out
+	}
+
+	lintInput := pkgconfig.LintInput{ProFeatures: options.ProFeatures}
+	if options.CheckHost {
+		kubeClient, err := hostKubeClient(globalFlags.Context)
+		if err != nil {
+			return err
+		}
+
+		lintInput.HostNamespaceExists = func(name string) (bool, error) {
+			_, err := kubeClient.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+			if kerrors.IsNotFound(err) {
+				return false, nil
+			} else if err != nil {
+				return false, err
+			}
+
+			return true, nil
+		}
+	}
+
+	report, err := lintValues(options.Values, files, options.SetValues, lintInput, options.DisabledRules)
+	if err != nil {
+		return err
+	}
+
+	switch options.Output {
+	case "json":
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		log.WriteString(logrus.InfoLevel, string(out)+"\n")
+	case "sarif":
+		out, err := json.MarshalIndent(toSARIF(report, options.DisabledRules), "", "  ")
+		if err != nil {
+			return err
+		}
+
+		log.WriteString(logrus.InfoLevel, string(out)+"\n")
+	default:
+		printConfigLintReport(report, log)
+	}
+
+	if failed := failingFindings(report, options.FailOn); failed > 0 {
+		return fmt.Errorf("found %d findings with severity %s or higher", failed, options.FailOn)
+	}
+
+	return nil
+}
+
+// lintValues lints the values files merged with the default values and the --set values. Fields from the
+// pre-v0.20 config format are reported and removed before the remaining values are parsed. The parsed config is
+// set as the config of the lint input.
+func lintValues(valueFiles []string, files map[string][]byte, setValues []string, lintInput pkgconfig.LintInput, disabledRules []string) (*ConfigLintReport, error) {
+	findings := []pkgconfig.LintFinding{}
+	cleanedFiles := []string{}
+	defer func() {
+		for _, cleanedFile := range cleanedFiles {
+			_ = os.Remove(cleanedFile)
+		}
+	}()
+	parsed := true
+	for _, valuesFile := range valueFiles {
+		values := map[string]interface{}{}
+		err := yaml.Unmarshal(files[valuesFile], &values)
+		if err != nil {
+			findings = append(findings, pkgconfig.LintFinding{
+				RuleID:   invalidConfigRuleID,
+				Severity: pkgconfig.LintSeverityError,
+				Message:  fmt.Sprintf("parse values file %s: %v", valuesFile, err),
+			})
+			parsed = false
+			continue
+		}
+
+		if !slices.Contains(disabledRules, pkgconfig.DeprecatedFieldRuleID) {
+			for _, key := range legacyConfigFields(values) {
+				findings = append(findings, pkgconfig.LintFinding{
+					RuleID:   pkgconfig.DeprecatedFieldRuleID,
+					Severity: pkgconfig.LintSeverityError,
+					Path:     key,
+					Message:  fmt.Sprintf("%s is a field of the pre-v0.20 config format and is not supported anymore, convert the values via \"vcluster convert config\"", key),
+				})
+				delete(values, key)
+			}
+		}
+
+		cleanedFile, err := writeTempValues(values)
+		if err != nil {
+			return nil, err
+		}
+		cleanedFiles = append(cleanedFiles, cleanedFile)
+	}
+
+	if parsed {
+		finalValues, err := mergeAllValues(setValues, cleanedFiles, config.Values)
+		if err != nil {
+			return nil, fmt.Errorf("merge values: %w", err)
+		}
+
+		vClusterConfig := &config.Config{}
+		err = vClusterConfig.UnmarshalYAMLStrict([]byte(finalValues))
+		if err != nil {
+			findings = append(findings, pkgconfig.LintFinding{
+				RuleID:   invalidConfigRuleID,
+				Severity: pkgconfig.LintSeverityError,
+				Message:  err.Error(),
+			})
+		} else {
+			lintInput.Config = vClusterConfig
+			findings = append(findings, pkgconfig.LintConfig(&lintInput, disabledRules...)...)
+		}
+	}
+
+	report := &ConfigLintReport{Findings: []ConfigLintFinding{}}
+	for _, finding := range findings {
+		configLintFinding := ConfigLintFinding{LintFinding: finding}
+		configLintFinding.File, configLintFinding.Line = locateConfigPath(valueFiles, files, finding.Path)
+		report.Findings = append(report.Findings, configLintFinding)
+
+		switch finding.Severity {
+		case pkgconfig.LintSeverityError:
+			report.Summary.Error++
+		case pkgconfig.LintSeverityWarning:
+			report.Summary.Warning++
+		default:
+			report.Summary.Info++
+		}
+	}
+
+	return report, nil
+}
+
+// legacyConfigFields returns the top level fields of the values that don't exist in the config, but in the
+// pre-v0.20 config format.
+func legacyConfigFields(values map[string]interface{}) []string {
+	configFields := jsonFieldNames(reflect.TypeOf(config.Config{}))
+	legacyFields := jsonFieldNames(reflect.TypeOf(legacyconfig.LegacyK8s{}))
+	for name := range jsonFieldNames(reflect.TypeOf(legacyconfig.LegacyK3s{})) {
+		legacyFields[name] = true
+	}
+
+	fields := []string{}
+	for key := range values {
+		if !configFields[key] && legacyFields[key] {
+			fields = append(fields, key)
+		}
+	}
+
+	slices.Sort(fields)
+	return fields
+}
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for embeddedName := range jsonFieldNames(field.Type) {
+				names[embeddedName] = true
+			}
+		} else if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+
+	return names
+}
+
+func writeTempValues(values map[string]interface{}) (string, error) {
+	out, err := yaml.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return writeTempFile(out)
+}
+
+// locateConfigPath returns the last values file that sets the path and the line of the path within it.
+// If only a parent of the path is set, the line of the parent is returned.
+func locateConfigPath(valueFiles []string, files map[string][]byte, path string) (string, int) {
+	if path == "" {
+		if len(valueFiles) == 1 {
+			return valueFiles[0], 0
+		}
+		return "", 0
+	}
+
+	segments := splitConfigPath(path)
+	for i := len(valueFiles) - 1; i >= 0; i-- {
+		if line := yamlLine(files[valueFiles[i]], segments); line > 0 {
+			return valueFiles[i], line
+		}
+	}
+
+	return "", 0
+}
+
+// splitConfigPath splits a path like sync.fromHost.configMaps.mappings.byName["ns/name"] or
+// rbac.role.extraRules[0] into its segments.
+func splitConfigPath(path string) []string {
+	segments := []string{}
+	current := strings.Builder{}
+	flush := func() {
+		if current.Len() > 0 {
+			segments = append(segments, current.String())
+			current.Reset()
+		}
+	}
+
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '.':
+			flush()
+		case '[':
+			flush()
+			rest := path[i+1:]
+			if quoted, err := strconv.QuotedPrefix(rest); err == nil {
+				key, _ := strconv.Unquote(quoted)
+				segments = append(segments, key)
+				i += len(quoted) + 1
+			} else if end := strings.IndexByte(rest, ']'); end >= 0 {
+				segments = append(segments, rest[:end])
+				i += end + 1
+			} else {
+				current.WriteString(rest)
+				i = len(path)
+			}
+		default:
+			current.WriteByte(path[i])
+		}
+	}
+	flush()
+
+	return segments
+}
+
+// yamlLine returns the line of the deepest node of the path that exists in the yaml document, 0 if not even
+// the first segment exists.
+func yamlLine(data []byte, segments []string) int {
+	node := &yamlv3.Node{}
+	if err := yamlv3.Unmarshal(data, node); err != nil {
+		return 0
+	}
+	if node.Kind == yamlv3.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	line := 0
+	for _, segment := range segments {
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			found := false
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == segment {
+					line = node.Content[i].Line
+					node = node.Content[i+1]
+					found = true
+					break
+				}
+			}
+			if !found {
+				return line
+			}
+		case yamlv3.SequenceNode:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(node.Content) {
+				return line
+			}
+			node = node.Content[idx]
+			line = node.Line
+		default:
+			return line
+		}
+	}
+
+	return line
+}
+
+func failingFindings(report *ConfigLintReport, failOn string) int {
+	rank := map[pkgconfig.LintSeverity]int{
+		pkgconfig.LintSeverityInfo:    1,
+		pkgconfig.LintSeverityWarning: 2,
+		pkgconfig.LintSeverityError:   3,
+	}
+	minRank, ok := rank[pkgconfig.LintSeverity(failOn)]
+	if !ok {
+		return 0
+	}
+
+	failed := 0
+	for _, finding := range report.Findings {
+		if rank[finding.Severity] >= minRank {
+			failed++
+		}
+	}
+
+	return failed
+}
+
+func printConfigLintReport(report *ConfigLintReport, log log.Logger) {
+	for _, finding := range report.Findings {
+		location := finding.File
+		if finding.Line > 0 {
+			location += ":" + strconv.Itoa(finding.Line)
+		}
+		if location != "" {
+			location += ": "
+		}
+		if finding.Path != "" && !strings.Contains(finding.Message, finding.Path) {
+			location += finding.Path + ": "
+		}
+
+		message := fmt.Sprintf("%s%s (%s)", location, finding.Message, finding.RuleID)
+		switch finding.Severity {
+		case pkgconfig.LintSeverityError:
+			log.Error(message)
+		case pkgconfig.LintSeverityWarning:
+			log.Warn(message)
+		default:
+			log.Info(message)
+		}
+	}
+
+	if len(report.Findings) == 0 {
+		log.Done("No problems found")
+		return
+	}
+
+	log.Infof("Found %d errors, %d warnings and %d infos", report.Summary.Error, report.Summary.Warning, report.Summary.Info)
+}
+
+func hostKubeClient(kubeContext string) (kubernetes.Interface, error) {
+	kubeClientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{
+		CurrentContext: kubeContext,
+	})
+	restConfig, err := kubeClientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kube config: %w", err)
+	}
+
+	return kubernetes.NewForConfig(restConfig)
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// toSARIF converts the report into the SARIF 2.1.0 format, which is understood by most CI code scanning tools.
+func toSARIF(report *ConfigLintReport, disabledRules []string) *sarifLog {
+	rules := []sarifRule{{
+		ID:                   invalidConfigRuleID,
+		ShortDescription:     sarifMessage{Text: "The values can't be parsed as vcluster.yaml"},
+		DefaultConfiguration: sarifConfiguration{Level: sarifLevel(pkgconfig.LintSeverityError)},
+	}}
+	for _, rule := range pkgconfig.LintRules() {
+		if slices.Contains(disabledRules, rule.ID) {
+			continue
+		}
+
+		rules = append(rules, sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     sarifMessage{Text: rule.Description},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.Severity)},
+		})
+	}
+
+	results := []sarifResult{}
+	for _, finding := range report.Findings {
+		result := sarifResult{
+			RuleID:  finding.RuleID,
+			Level:   sarifLevel(finding.Severity),
+			Message: sarifMessage{Text: finding.Message},
+		}
+
+		location := sarifLocation{}
+		if finding.File != "" {
+			location.PhysicalLocation = &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: finding.File}}
+			if finding.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{StartLine: finding.Line}
+			}
+		}
+		if finding.Path != "" {
+			location.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: finding.Path}}
+		}
+		if location.PhysicalLocation != nil || len(location.LogicalLocations) > 0 {
+			result.Locations = []sarifLocation{location}
+		}
+
+		results = append(results, result)
+	}
+
+	return &sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "vcluster config lint",
+				Version:        upgrade.GetVersion(),
+				InformationURI: "https://www.vcluster.com/docs",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+}
+
+func sarifLevel(severity pkgconfig.LintSeverity) string {
+	switch severity {
+	case pkgconfig.LintSeverityError:
+		return "error"
+	case pkgconfig.LintSeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
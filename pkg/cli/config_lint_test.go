@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"testing"
+
+	pkgconfig "github.com/loft-sh/vcluster/pkg/config"
+	"gotest.tools/v3/assert"
+)
+
+func TestLintValues(t *testing.T) {
+	files := map[string][]byte{
+		"base.yaml": []byte(`sync:
+  toHost:
+    pods:
+      patches:
+        - path: metadata.labels.team
+          expression: '"a"'
+`),
+		"legacy.yaml": []byte(`syncer:
+  replicas: 2
+rbac:
+  clusterRole:
+    extraRules:
+      - apiGroups: ["*"]
+        resources: ["*"]
+        verbs: ["get"]
+`),
+	}
+
+	report, err := lintValues([]string{"base.yaml", "legacy.yaml"}, files, nil, pkgconfig.LintInput{}, nil)
+	assert.NilError(t, err)
+	type location struct {
+		RuleID string
+		Path   string
+		File   string
+		Line   int
+	}
+	locations := []location{}
+	for _, finding := range report.Findings {
+		locations = append(locations, location{finding.RuleID, finding.Path, finding.File, finding.Line})
+	}
+	assert.DeepEqual(t, locations, []location{
+		{"deprecated-field", "syncer", "legacy.yaml", 1},
+		{"broad-rbac-rule", "rbac.clusterRole.extraRules[0]", "legacy.yaml", 6},
+		{"patches-unavailable", "sync.toHost.pods.patches", "base.yaml", 4},
+	})
+	assert.Equal(t, report.Summary, ConfigLintSummary{Error: 2, Warning: 1})
+	assert.Equal(t, failingFindings(report, "error"), 2)
+	assert.Equal(t, failingFindings(report, "warning"), 3)
+	assert.Equal(t, failingFindings(report, "never"), 0)
+
+	report, err = lintValues([]string{"base.yaml"}, files, nil, pkgconfig.LintInput{}, []string{"patches-unavailable"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(report.Findings), 0)
+
+	// unknown fields make the config invalid
+	files["invalid.yaml"] = []byte("sync:\n  toHost:\n    unknown: true\n")
+	report, err = lintValues([]string{"invalid.yaml"}, files, nil, pkgconfig.LintInput{}, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(report.Findings), 1)
+	assert.Equal(t, report.Findings[0].RuleID, invalidConfigRuleID)
+	assert.Equal(t, report.Findings[0].File, "invalid.yaml")
+}
+
+func TestSplitConfigPath(t *testing.T) {
+	assert.DeepEqual(t, splitConfigPath("sync.toHost.pods.patches"), []string{"sync", "toHost", "pods", "patches"})
+	assert.DeepEqual(t, splitConfigPath("rbac.role.extraRules[2]"), []string{"rbac", "role", "extraRules", "2"})
+	assert.DeepEqual(t, splitConfigPath(`sync.fromHost.configMaps.mappings.byName["ns/a.b"]`), []string{"sync", "fromHost", "configMaps", "mappings", "byName", "ns/a.b"})
+	assert.DeepEqual(t, splitConfigPath(`sync.fromHost.customResources["a.b.c"].mappings`), []string{"sync", "fromHost", "customResources", "a.b.c", "mappings"})
+}
+
+func TestYAMLLine(t *testing.T) {
+	data := []byte(`controlPlane:
+  distro:
+    k8s:
+      enabled: true
+rbac:
+  role:
+    extraRules:
+      - verbs: ["get"]
+      - verbs: ["*"]
+`)
+	assert.Equal(t, yamlLine(data, splitConfigPath("controlPlane.distro.k8s.enabled")), 4)
+	assert.Equal(t, yamlLine(data, splitConfigPath("controlPlane.distro.k3s.enabled")), 2)
+	assert.Equal(t, yamlLine(data, splitConfigPath("rbac.role.extraRules[1]")), 9)
+	assert.Equal(t, yamlLine(data, splitConfigPath("sync.toHost")), 0)
+}
+
+func TestToSARIF(t *testing.T) {
+	report := &ConfigLintReport{Findings: []ConfigLintFinding{{
+		LintFinding: pkgconfig.LintFinding{RuleID: "broad-rbac-rule", Severity: pkgconfig.LintSeverityWarning, Path: "rbac.role.extraRules[0]", Message: "wildcard"},
+		File:        "vcluster.yaml",
+		Line:        3,
+	}, {
+		LintFinding: pkgconfig.LintFinding{RuleID: "missing-host-namespace", Severity: pkgconfig.LintSeverityInfo, Message: "forbidden"},
+	}}}
+
+	sarif := toSARIF(report, []string{"sleep-mode-without-trigger"})
+	assert.Equal(t, sarif.Version, "2.1.0")
+	assert.Equal(t, len(sarif.Runs), 1)
+	for _, rule := range sarif.Runs[0].Tool.Driver.Rules {
+		assert.Assert(t, rule.ID != "sleep-mode-without-trigger")
+	}
+
+	results := sarif.Runs[0].Results
+	assert.Equal(t, len(results), 2)
+	assert.Equal(t, results[0].Level, "warning")
+	assert.Equal(t, results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI, "vcluster.yaml")
+	assert.Equal(t, results[0].Locations[0].PhysicalLocation.Region.StartLine, 3)
+	assert.Equal(t, results[0].Locations[0].LogicalLocations[0].FullyQualifiedName, "rbac.role.extraRules[0]")
+	assert.Equal(t, results[1].Level, "note")
+	assert.Assert(t, results[1].Locations == nil)
+}
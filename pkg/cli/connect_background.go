@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/loft-sh/vcluster/pkg/cli/connections"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// startBackgroundConnection hands the port-forwarding to the vCluster over to the connection daemon and
+// waits until the daemon is connected
+func (cmd *connectHelm) startBackgroundConnection(ctx context.Context, vClusterName, remotePort string) error {
+	port, err := strconv.Atoi(remotePort)
+	if err != nil {
+		return fmt.Errorf("parse vcluster port %s: %w", remotePort, err)
+	}
+
+	hostKubeConfig, err := minifiedKubeConfig(cmd.rawConfig, cmd.Context)
+	if err != nil {
+		return err
+	}
+
+	client, err := connections.EnsureDaemon(ctx, cmd.Log)
+	if err != nil {
+		return err
+	}
+
+	connection, err := client.Add(ctx, &connections.AddRequest{
+		Connection: connections.Connection{
+			Name:        cmd.KubeConfigContextName,
+			VCluster:    vClusterName,
+			Namespace:   cmd.Namespace,
+			HostContext: cmd.Context,
+			Address:     cmd.Address,
+			LocalPort:   cmd.LocalPort,
+			RemotePort:  port,
+		},
+		HostKubeConfig: string(hostKubeConfig),
+	})
+	if err != nil {
+		return fmt.Errorf("add background connection: %w", err)
+	}
+
+	cmd.Log.Infof("Waiting for the background connection to vcluster %s...", vClusterName)
+	err = wait.PollUntilContextTimeout(ctx, time.Millisecond*500, time.Minute, true, func(ctx context.Context) (bool, error) {
+		connection, err = client.Get(ctx, connection.Name)
+		if err != nil {
+			return false, err
+		}
+
+		return connection.Status == connections.StatusConnected, nil
+	})
+	if err != nil {
+		if connection != nil && connection.LastError != "" {
+			return fmt.Errorf("wait for background connection: %w: %s", err, connection.LastError)
+		}
+		return fmt.Errorf("wait for background connection: %w", err)
+	}
+
+	cmd.Log.Donef("Port-forwarding to vcluster %s runs in the background on port %d, use `vcluster connections list` to check its status", vClusterName, cmd.LocalPort)
+	return nil
+}
+
+// minifiedKubeConfig returns a self-contained kube config that only contains the given context
+func minifiedKubeConfig(rawConfig clientcmdapi.Config, kubeContext string) ([]byte, error) {
+	config := rawConfig.DeepCopy()
+	config.CurrentContext = kubeContext
+	if err := clientcmdapi.MinifyConfig(config); err != nil {
+		return nil, fmt.Errorf("minify kube config: %w", err)
+	}
+	if err := clientcmdapi.FlattenConfig(config); err != nil {
+		return nil, fmt.Errorf("flatten kube config: %w", err)
+	}
+
+	return clientcmd.Write(*config)
+}
+
+// BackgroundConnection returns the background connection of the kube context or nil if the daemon doesn't
+// manage a connection for it
+func BackgroundConnection(ctx context.Context, kubeContext string) (*connections.Connection, error) {
+	client, err := connections.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	connection, err := client.Get(ctx, kubeContext)
+	if errors.Is(err, connections.ErrNotFound) || errors.Is(err, connections.ErrDaemonNotRunning) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("get background connection: %w", err)
+	}
+
+	return connection, nil
+}
+
+// DisconnectBackground stops the background connection of the kube context and removes the context from the
+// kube config. It returns false if there was no background connection for the context.
+func DisconnectBackground(ctx context.Context, kubeContext, otherContext string) (bool, error) {
+	client, err := connections.NewClient()
+	if err != nil {
+		return false, err
+	}
+
+	err = client.Remove(ctx, kubeContext)
+	if errors.Is(err, connections.ErrNotFound) || errors.Is(err, connections.ErrDaemonNotRunning) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("stop background connection: %w", err)
+	}
+
+	rawConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{}).RawConfig()
+	if err != nil {
+		return true, err
+	}
+
+	return true, deleteContext(&rawConfig, kubeContext, otherContext)
+}
@@ -0,0 +1,438 @@
+package cli
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	ExposeIngress      = "ingress"
+	ExposeLoadBalancer = "loadbalancer"
+	ExposeNodePort     = "nodeport"
+
+	// ExposeLabel marks the host objects created by vcluster connect --expose, the value is the vCluster name
+	ExposeLabel = "vcluster.loft.sh/connect-expose"
+)
+
+// ExposeTypes are the values vcluster connect --expose accepts
+var ExposeTypes = []string{ExposeIngress, ExposeLoadBalancer, ExposeNodePort}
+
+var (
+	exposeAddressTimeout     = time.Minute * 5
+	exposeCertificateTimeout = time.Minute * 2
+)
+
+// ValidateExposeOptions checks that the --expose flags fit together
+func ValidateExposeOptions(options *ConnectOptions) error {
+	ingressFlagsSet := options.ExposeHost != "" || options.ExposeIngressClass != ""
+	switch {
+	case options.Expose == "":
+		if ingressFlagsSet {
+			return errors.New("--expose-host and --expose-ingress-class require --expose=ingress")
+		}
+		return nil
+	case !slices.Contains(ExposeTypes, options.Expose):
+		return fmt.Errorf("unsupported --expose=%s, please select one of: %s", options.Expose, strings.Join(ExposeTypes, ", "))
+	case options.Server != "":
+		return errors.New("--expose and --server cannot be used together")
+	case options.Expose == ExposeIngress && options.ExposeHost == "":
+		return errors.New("--expose=ingress requires --expose-host")
+	case options.Expose != ExposeIngress && ingressFlagsSet:
+		return errors.New("--expose-host and --expose-ingress-class require --expose=ingress")
+	}
+
+	return nil
+}
+
+// expose creates or updates the host object that exposes the vCluster, waits for its address and makes sure
+// the proxy certificate is signed for it, so the kube config can use the address directly.
+func (cmd *connectHelm) expose(ctx context.Context, vClusterName string) error {
+	service, err := cmd.kubeClient.CoreV1().Services(cmd.Namespace).Get(ctx, vClusterName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get vcluster service: %w", err)
+	}
+
+	var address string
+	switch cmd.Expose {
+	case ExposeIngress:
+		address, err = exposeViaIngress(ctx, cmd.kubeClient, service, cmd.ExposeHost, cmd.ExposeIngressClass, cmd.Log)
+	case ExposeLoadBalancer:
+		address, err = exposeViaService(ctx, cmd.kubeClient, service, corev1.ServiceTypeLoadBalancer, cmd.Log)
+	case ExposeNodePort:
+		address, err = exposeViaService(ctx, cmd.kubeClient, service, corev1.ServiceTypeNodePort, cmd.Log)
+	default:
+		return fmt.Errorf("unsupported --expose=%s", cmd.Expose)
+	}
+	if err != nil {
+		return err
+	}
+
+	host := address
+	if h, _, err := net.SplitHostPort(address); err == nil {
+		host = h
+	}
+
+	// the cert syncer adds the host to the proxy certificate
+	err = addExtraSAN(ctx, cmd.kubeClient, service, host)
+	if err != nil {
+		return err
+	}
+
+	cmd.Log.Infof("Waiting for the vcluster certificate to be valid for %s...", host)
+	err = waitForServingCertificate(ctx, address, host, exposeCertificateTimeout)
+	if err != nil {
+		cmd.Log.Warnf("Error verifying the vcluster certificate for %s, the connection might fail until the certificate was renewed: %v", host, err)
+	}
+
+	cmd.Server = address
+	cmd.Log.Donef("Exposed vcluster %s via %s under https://%s", vClusterName, cmd.Expose, address)
+	return nil
+}
+
+func exposeObjectName(vClusterName string) string {
+	return vClusterName + "-connect"
+}
+
+// exposeObjectMeta returns the metadata of the exposure objects, they are owned by the vCluster
+// service, so they are garbage collected together with the vCluster.
+func exposeObjectMeta(service *corev1.Service) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      exposeObjectName(service.Name),
+		Namespace: service.Namespace,
+		Labels: map[string]string{
+			ExposeLabel: service.Name,
+		},
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: "v1",
+			Kind:       "Service",
+			Name:       service.Name,
+			UID:        service.UID,
+		}},
+	}
+}
+
+// newExposeService returns a service of the given type that targets the https port of the vCluster service
+func newExposeService(service *corev1.Service, serviceType corev1.ServiceType) *corev1.Service {
+	targetPort := intstr.FromInt32(8443)
+	for _, port := range service.Spec.Ports {
+		if port.Name == "https" {
+			targetPort = port.TargetPort
+		}
+	}
+
+	return &corev1.Service{
+		ObjectMeta: exposeObjectMeta(service),
+		Spec: corev1.ServiceSpec{
+			Type:     serviceType,
+			Selector: service.Spec.Selector,
+			Ports: []corev1.ServicePort{{
+				Name:       "https",
+				Port:       443,
+				TargetPort: targetPort,
+				Protocol:   corev1.ProtocolTCP,
+			}},
+		},
+	}
+}
+
+// newExposeIngress returns an ingress for the host that passes the tls connection through to the vCluster service
+func newExposeIngress(service *corev1.Service, host, ingressClass string) *networkingv1.Ingress {
+	pathType := networkingv1.PathTypeImplementationSpecific
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: exposeObjectMeta(service),
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: service.Name,
+									Port: networkingv1.ServiceBackendPort{Name: "https"},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+
+	// the vCluster terminates tls itself, same as the chart ingress
+	ingress.Annotations = map[string]string{
+		"nginx.ingress.kubernetes.io/backend-protocol": "HTTPS",
+		"nginx.ingress.kubernetes.io/ssl-passthrough":  "true",
+		"nginx.ingress.kubernetes.io/ssl-redirect":     "true",
+	}
+	if ingressClass != "" {
+		ingress.Spec.IngressClassName = &ingressClass
+	}
+
+	return ingress
+}
+
+func exposeViaService(ctx context.Context, kubeClient kubernetes.Interface, service *corev1.Service, serviceType corev1.ServiceType, log log.Logger) (string, error) {
+	desired := newExposeService(service, serviceType)
+	existing, err := kubeClient.CoreV1().Services(desired.Namespace).Get(ctx, desired.Name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		log.Infof("Create %s service %s/%s", serviceType, desired.Namespace, desired.Name)
+		_, err = kubeClient.CoreV1().Services(desired.Namespace).Create(ctx, desired, metav1.CreateOptions{})
+		if err != nil {
+			return "", fmt.Errorf("create service %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+	} else if err != nil {
+		return "", fmt.Errorf("get service %s/%s: %w", desired.Namespace, desired.Name, err)
+	} else {
+		// keep the allocated node port
+		for _, port := range existing.Spec.Ports {
+			if port.Name == "https" && serviceType == corev1.ServiceTypeNodePort {
+				desired.Spec.Ports[0].NodePort = port.NodePort
+			}
+		}
+
+		log.Infof("Update %s service %s/%s", serviceType, desired.Namespace, desired.Name)
+		existing.Labels = desired.Labels
+		existing.OwnerReferences = desired.OwnerReferences
+		existing.Spec.Type = desired.Spec.Type
+		existing.Spec.Selector = desired.Spec.Selector
+		existing.Spec.Ports = desired.Spec.Ports
+		_, err = kubeClient.CoreV1().Services(desired.Namespace).Update(ctx, existing, metav1.UpdateOptions{})
+		if err != nil {
+			return "", fmt.Errorf("update service %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+	}
+
+	address := ""
+	printedWaiting := false
+	err = wait.PollUntilContextTimeout(ctx, time.Second*2, exposeAddressTimeout, true, func(ctx context.Context) (bool, error) {
+		exposeService, err := kubeClient.CoreV1().Services(desired.Namespace).Get(ctx, desired.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		address, err = exposeServiceAddress(ctx, kubeClient, exposeService)
+		if err != nil {
+			return false, err
+		} else if address == "" && !printedWaiting {
+			log.Infof("Waiting for the address of service %s/%s...", desired.Namespace, desired.Name)
+			printedWaiting = true
+		}
+
+		return address != "", nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("wait for service %s/%s address: %w", desired.Namespace, desired.Name, err)
+	}
+
+	return address, nil
+}
+
+// exposeServiceAddress returns the address the service can be reached under or an empty string if
+// it has none yet
+func exposeServiceAddress(ctx context.Context, kubeClient kubernetes.Interface, service *corev1.Service) (string, error) {
+	if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			if ingress.Hostname != "" {
+				return ingress.Hostname, nil
+			} else if ingress.IP != "" {
+				return ingress.IP, nil
+			}
+		}
+
+		return "", nil
+	}
+
+	nodePort := int32(0)
+	for _, port := range service.Spec.Ports {
+		if port.Name == "https" {
+			nodePort = port.NodePort
+		}
+	}
+	if nodePort == 0 {
+		return "", nil
+	}
+
+	nodeAddress, err := nodeAddressOfVCluster(ctx, kubeClient, service.Namespace, service.Labels[ExposeLabel])
+	if err != nil || nodeAddress == "" {
+		return "", err
+	}
+
+	return net.JoinHostPort(nodeAddress, strconv.Itoa(int(nodePort))), nil
+}
+
+// nodeAddressOfVCluster returns the external or internal ip of the node the vCluster runs on
+func nodeAddressOfVCluster(ctx context.Context, kubeClient kubernetes.Interface, namespace, vClusterName string) (string, error) {
+	pods, err := kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=vcluster,release=" + vClusterName,
+	})
+	if err != nil {
+		return "", fmt.Errorf("list vcluster pods: %w", err)
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.Spec.NodeName == "" {
+			continue
+		}
+
+		node, err := kubeClient.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{})
+		if err != nil {
+			// nodes might not be readable with the current permissions
+			if pod.Status.HostIP != "" {
+				return pod.Status.HostIP, nil
+			}
+			return "", fmt.Errorf("get node %s: %w", pod.Spec.NodeName, err)
+		}
+
+		for _, addressType := range []corev1.NodeAddressType{corev1.NodeExternalIP, corev1.NodeInternalIP} {
+			for _, address := range node.Status.Addresses {
+				if address.Type == addressType && address.Address != "" {
+					return address.Address, nil
+				}
+			}
+		}
+
+		return pod.Status.HostIP, nil
+	}
+
+	return "", nil
+}
+
+func exposeViaIngress(ctx context.Context, kubeClient kubernetes.Interface, service *corev1.Service, host, ingressClass string, log log.Logger) (string, error) {
+	desired := newExposeIngress(service, host, ingressClass)
+	existing, err := kubeClient.NetworkingV1().Ingresses(desired.Namespace).Get(ctx, desired.Name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		log.Infof("Create ingress %s/%s for host %s", desired.Namespace, desired.Name, host)
+		_, err = kubeClient.NetworkingV1().Ingresses(desired.Namespace).Create(ctx, desired, metav1.CreateOptions{})
+		if err != nil {
+			return "", fmt.Errorf("create ingress %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+	} else if err != nil {
+		return "", fmt.Errorf("get ingress %s/%s: %w", desired.Namespace, desired.Name, err)
+	} else {
+		log.Infof("Update ingress %s/%s for host %s", desired.Namespace, desired.Name, host)
+		existing.Labels = desired.Labels
+		existing.Annotations = desired.Annotations
+		existing.OwnerReferences = desired.OwnerReferences
+		existing.Spec = desired.Spec
+		_, err = kubeClient.NetworkingV1().Ingresses(desired.Namespace).Update(ctx, existing, metav1.UpdateOptions{})
+		if err != nil {
+			return "", fmt.Errorf("update ingress %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+	}
+
+	log.Infof("Waiting for the ingress controller to admit ingress %s/%s...", desired.Namespace, desired.Name)
+	err = wait.PollUntilContextTimeout(ctx, time.Second*2, exposeAddressTimeout, true, func(ctx context.Context) (bool, error) {
+		ingress, err := kubeClient.NetworkingV1().Ingresses(desired.Namespace).Get(ctx, desired.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		return len(ingress.Status.LoadBalancer.Ingress) > 0, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("wait for ingress %s/%s address: %w", desired.Namespace, desired.Name, err)
+	}
+
+	return host, nil
+}
+
+// addExtraSAN adds the san to the extra sans annotation of the vCluster service
+func addExtraSAN(ctx context.Context, kubeClient kubernetes.Interface, service *corev1.Service, san string) error {
+	existing := service.Annotations[constants.VClusterExtraSANsAnnotation]
+	sans := mergeExtraSANs(existing, san)
+	if sans == existing {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				constants.VClusterExtraSANsAnnotation: sans,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = kubeClient.CoreV1().Services(service.Namespace).Patch(ctx, service.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("add %s to the vcluster certificate: %w", san, err)
+	}
+
+	return nil
+}
+
+func mergeExtraSANs(existing, san string) string {
+	sans := []string{}
+	if existing != "" {
+		sans = strings.Split(existing, ",")
+	}
+	if !slices.Contains(sans, san) {
+		sans = append(sans, san)
+	}
+
+	return strings.Join(sans, ",")
+}
+
+// waitForServingCertificate waits until the certificate served under address is valid for host
+func waitForServingCertificate(ctx context.Context, address, host string, timeout time.Duration) error {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "443")
+	}
+
+	tlsConfig := &tls.Config{
+		// only the names of the certificate are checked here, the kube config verifies the ca
+		InsecureSkipVerify: true,
+	}
+	if net.ParseIP(host) == nil {
+		tlsConfig.ServerName = host
+	}
+
+	var lastErr error
+	err := wait.PollUntilContextTimeout(ctx, time.Second*2, timeout, true, func(ctx context.Context) (bool, error) {
+		dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: time.Second * 5}, Config: tlsConfig}
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			lastErr = err
+			return false, nil
+		}
+		defer conn.Close()
+
+		certificates := conn.(*tls.Conn).ConnectionState().PeerCertificates
+		if len(certificates) == 0 {
+			lastErr = errors.New("no certificate served")
+			return false, nil
+		}
+
+		lastErr = certificates[0].VerifyHostname(host)
+		return lastErr == nil, nil
+	})
+	if err != nil && lastErr != nil {
+		return lastErr
+	}
+
+	return err
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/constants"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateExposeOptions(t *testing.T) {
+	assert.NilError(t, ValidateExposeOptions(&ConnectOptions{}))
+	assert.NilError(t, ValidateExposeOptions(&ConnectOptions{Expose: ExposeLoadBalancer}))
+	assert.NilError(t, ValidateExposeOptions(&ConnectOptions{Expose: ExposeIngress, ExposeHost: "vcluster.example.com"}))
+
+	assert.ErrorContains(t, ValidateExposeOptions(&ConnectOptions{Expose: "route"}), "unsupported --expose=route")
+	assert.ErrorContains(t, ValidateExposeOptions(&ConnectOptions{Expose: ExposeNodePort, Server: "https://1.2.3.4"}), "cannot be used together")
+	assert.ErrorContains(t, ValidateExposeOptions(&ConnectOptions{Expose: ExposeIngress}), "requires --expose-host")
+	assert.ErrorContains(t, ValidateExposeOptions(&ConnectOptions{Expose: ExposeNodePort, ExposeHost: "vcluster.example.com"}), "require --expose=ingress")
+	assert.ErrorContains(t, ValidateExposeOptions(&ConnectOptions{ExposeIngressClass: "nginx"}), "require --expose=ingress")
+}
+
+func testVClusterService() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-vcluster", Namespace: "vcluster-my-vcluster", UID: "1234"},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{"app": "vcluster", "release": "my-vcluster"},
+			Ports: []corev1.ServicePort{
+				{Name: "https", Port: 443, TargetPort: intstr.FromInt32(8443)},
+				{Name: "kubelet", Port: 10250, TargetPort: intstr.FromInt32(8443)},
+			},
+		},
+	}
+}
+
+func TestNewExposeObjects(t *testing.T) {
+	service := testVClusterService()
+
+	exposeService := newExposeService(service, corev1.ServiceTypeLoadBalancer)
+	assert.Equal(t, exposeService.Name, "my-vcluster-connect")
+	assert.Equal(t, exposeService.Labels[ExposeLabel], "my-vcluster")
+	assert.Equal(t, exposeService.OwnerReferences[0].UID, service.UID)
+	assert.DeepEqual(t, exposeService.Spec.Selector, service.Spec.Selector)
+	assert.Equal(t, len(exposeService.Spec.Ports), 1)
+	assert.Equal(t, exposeService.Spec.Ports[0].TargetPort, intstr.FromInt32(8443))
+
+	ingress := newExposeIngress(service, "vcluster.example.com", "nginx")
+	assert.Equal(t, ingress.Name, "my-vcluster-connect")
+	assert.Equal(t, *ingress.Spec.IngressClassName, "nginx")
+	assert.Equal(t, ingress.Annotations["nginx.ingress.kubernetes.io/ssl-passthrough"], "true")
+	assert.Equal(t, ingress.Spec.Rules[0].Host, "vcluster.example.com")
+	assert.Equal(t, ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name, "my-vcluster")
+	assert.Assert(t, newExposeIngress(service, "vcluster.example.com", "").Spec.IngressClassName == nil)
+}
+
+func TestExposeViaService(t *testing.T) {
+	ctx := context.Background()
+	service := testVClusterService()
+	kubeClient := fake.NewSimpleClientset(
+		service,
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-vcluster-0", Namespace: service.Namespace, Labels: map[string]string{"app": "vcluster", "release": "my-vcluster"}},
+			Spec:       corev1.PodSpec{NodeName: "node-1"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, HostIP: "10.0.0.1"},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+				{Type: corev1.NodeExternalIP, Address: "1.2.3.4"},
+			}},
+		},
+	)
+
+	// the fake client doesn't allocate node ports
+	exposeService := newExposeService(service, corev1.ServiceTypeNodePort)
+	exposeService.Spec.Ports[0].NodePort = 31443
+	_, err := kubeClient.CoreV1().Services(service.Namespace).Create(ctx, exposeService, metav1.CreateOptions{})
+	assert.NilError(t, err)
+
+	address, err := exposeViaService(ctx, kubeClient, service, corev1.ServiceTypeNodePort, log.Discard)
+	assert.NilError(t, err)
+	assert.Equal(t, address, "1.2.3.4:31443")
+
+	// load balancer without address yet
+	exposeService, err = kubeClient.CoreV1().Services(service.Namespace).Get(ctx, "my-vcluster-connect", metav1.GetOptions{})
+	assert.NilError(t, err)
+	exposeService.Spec.Type = corev1.ServiceTypeLoadBalancer
+	address, err = exposeServiceAddress(ctx, kubeClient, exposeService)
+	assert.NilError(t, err)
+	assert.Equal(t, address, "")
+
+	exposeService.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "5.6.7.8"}}
+	address, err = exposeServiceAddress(ctx, kubeClient, exposeService)
+	assert.NilError(t, err)
+	assert.Equal(t, address, "5.6.7.8")
+}
+
+func TestAddExtraSAN(t *testing.T) {
+	ctx := context.Background()
+	service := testVClusterService()
+	kubeClient := fake.NewSimpleClientset(service)
+
+	assert.NilError(t, addExtraSAN(ctx, kubeClient, service, "vcluster.example.com"))
+	service, err := kubeClient.CoreV1().Services(service.Namespace).Get(ctx, service.Name, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, service.Annotations[constants.VClusterExtraSANsAnnotation], "vcluster.example.com")
+
+	assert.Equal(t, mergeExtraSANs("vcluster.example.com", "1.2.3.4"), "vcluster.example.com,1.2.3.4")
+	assert.Equal(t, mergeExtraSANs("vcluster.example.com,1.2.3.4", "1.2.3.4"), "vcluster.example.com,1.2.3.4")
+}
+
+func TestWaitForServingCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	assert.NilError(t, err)
+
+	// the test certificate is only valid for 127.0.0.1 and example.com hosts
+	assert.NilError(t, waitForServingCertificate(context.Background(), serverURL.Host, "127.0.0.1", time.Second*5))
+	assert.ErrorContains(t, waitForServingCertificate(context.Background(), serverURL.Host, "vcluster.loft.sh", time.Second), "vcluster.loft.sh")
+}
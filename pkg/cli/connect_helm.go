@@ -46,13 +46,19 @@ type ConnectOptions struct {
 	KubeConfig                string
 	ServiceAccount            string
 	BackgroundProxyImage      string
+	Expose                    string
+	ExposeHost                string
+	ExposeIngressClass        string
 	LocalPort                 int
 	ServiceAccountExpiration  int
 	Print                     bool
 	UpdateCurrent             bool
 	BackgroundProxy           bool
+	Background                bool
 	Insecure                  bool
 
+	Contexts find.ContextOptions
+
 	Project string
 }
 
@@ -79,7 +85,15 @@ func ConnectHelm(ctx context.Context, options *ConnectOptions, globalFlags *flag
 	}
 
 	// retrieve the vcluster
-	vCluster, err := find.GetVCluster(ctx, cmd.Context, vClusterName, cmd.Namespace, cmd.Log)
+	var (
+		vCluster *find.VCluster
+		err      error
+	)
+	if options.Contexts.Enabled() {
+		vCluster, err = find.GetVClusterInContexts(ctx, &options.Contexts, vClusterName, cmd.Namespace, cmd.Log)
+	} else {
+		vCluster, err = find.GetVCluster(ctx, cmd.Context, vClusterName, cmd.Namespace, cmd.Log)
+	}
 	if err != nil {
 		return err
 	}
@@ -341,14 +355,19 @@ func (cmd *connectHelm) getVClusterKubeConfig(ctx context.Context, vclusterName
 
 	// check if the vcluster is exposed and set server
 	if vclusterName != "" && cmd.Server == "" && len(command) == 0 {
-		// check if local kubernetes / can be exposed
-		err = cmd.setServerIfExposed(ctx, vclusterName, kubeConfig)
+		if cmd.Expose != "" {
+			// expose the vcluster on the host
+			err = cmd.expose(ctx, vclusterName)
+		} else {
+			// check if local kubernetes / can be exposed
+			err = cmd.setServerIfExposed(ctx, vclusterName, kubeConfig)
+		}
 		if err != nil {
 			return nil, err
 		}
 
-		// check if we should start a background proxy
-		if cmd.Server == "" && cmd.BackgroundProxy {
+		// check if we should start a background proxy, the connection daemon takes care of it otherwise
+		if cmd.Server == "" && cmd.BackgroundProxy && !cmd.Background {
 			if localkubernetes.IsDockerInstalledAndUpAndRunning() {
 				// start background container
 				cmd.Server, err = localkubernetes.CreateBackgroundProxyContainer(ctx, vclusterName, cmd.Namespace, cmd.BackgroundProxyImage, cmd.kubeClientConfig, cmd.LocalPort, cmd.Log)
@@ -397,7 +416,13 @@ func (cmd *connectHelm) getVClusterKubeConfig(ctx context.Context, vclusterName
 	// * we want to have a service account token
 	// * we still don't have a server (means background proxy has failed or is disabled)
 	// * we have a command to execute
-	if cmd.ServiceAccount != "" || cmd.Server == "" || len(command) > 0 {
+	// the connection daemon forwards the port instead if a background connection is requested
+	if cmd.Background && cmd.Server == "" && len(command) == 0 {
+		err = cmd.startBackgroundConnection(ctx, vclusterName, port)
+		if err != nil {
+			return nil, err
+		}
+	} else if cmd.ServiceAccount != "" || cmd.Server == "" || len(command) > 0 {
 		cmd.portForwarding = true
 		cmd.interruptChan = make(chan struct{})
 		cmd.errorChan = make(chan error)
@@ -0,0 +1,317 @@
+package connections
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/log/table"
+	"github.com/loft-sh/vcluster/pkg/cli/config"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// SocketFileName is the unix socket in the vCluster config dir the daemon listens on
+	SocketFileName = "connections.sock"
+	// StateFileName is the file in the vCluster config dir the daemon persists the connections to
+	StateFileName = "connections.json"
+	// LogFileName is the file in the vCluster config dir the daemon logs to
+	LogFileName = "connections.log"
+)
+
+var (
+	// ErrDaemonNotRunning is returned by the client if no daemon is listening on the socket
+	ErrDaemonNotRunning = errors.New("connection daemon is not running")
+	// ErrNotFound is returned by the client if the daemon doesn't manage the connection
+	ErrNotFound = errors.New("connection not found")
+)
+
+// Status is the state of a background connection
+type Status string
+
+const (
+	StatusConnecting   Status = "Connecting"
+	StatusConnected    Status = "Connected"
+	StatusReconnecting Status = "Reconnecting"
+)
+
+// Connection is a port-forwarding to a vCluster that is kept running by the daemon
+type Connection struct {
+	// Name is the kube context of the vCluster and identifies the connection
+	Name string `json:"name"`
+
+	// VCluster and Namespace are the name and namespace of the vCluster on the host
+	VCluster  string `json:"vcluster"`
+	Namespace string `json:"namespace"`
+
+	// HostContext is the kube context of the host cluster
+	HostContext string `json:"hostContext"`
+
+	// Address, LocalPort and RemotePort are the port-forwarding parameters
+	Address    string `json:"address,omitempty"`
+	LocalPort  int    `json:"localPort"`
+	RemotePort int    `json:"remotePort"`
+
+	Status    Status    `json:"status"`
+	Pod       string    `json:"pod,omitempty"`
+	LastError string    `json:"lastError,omitempty"`
+	Restarts  int       `json:"restarts"`
+	Since     time.Time `json:"since"`
+}
+
+// AddRequest registers a connection with the daemon
+type AddRequest struct {
+	Connection Connection `json:"connection"`
+
+	// HostKubeConfig is the minified kube config to reach the host cluster, so the daemon doesn't
+	// depend on the kube config of the shell it was started from
+	HostKubeConfig string `json:"hostKubeConfig"`
+}
+
+// Dir returns the directory the socket, state and log files are in. It is created accessible only by the user,
+// as the state file contains host credentials.
+func Dir() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, config.DirName), nil
+}
+
+// Client talks to the daemon via its unix socket
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient returns a client for the daemon of the current user
+func NewClient() (*Client, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClientForSocket(filepath.Join(dir, SocketFileName)), nil
+}
+
+// NewClientForSocket returns a client for the daemon listening on socket
+func NewClientForSocket(socket string) *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Timeout: time.Second * 10,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+}
+
+// Ping returns ErrDaemonNotRunning if the daemon can't be reached
+func (c *Client) Ping(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/healthz", nil, nil)
+}
+
+// List returns all connections of the daemon
+func (c *Client) List(ctx context.Context) ([]Connection, error) {
+	connections := []Connection{}
+	err := c.do(ctx, http.MethodGet, "/connections", nil, &connections)
+	if err != nil {
+		return nil, err
+	}
+
+	return connections, nil
+}
+
+// Get returns the connection with the given name
+func (c *Client) Get(ctx context.Context, name string) (*Connection, error) {
+	connection := &Connection{}
+	err := c.do(ctx, http.MethodGet, "/connections/"+url.PathEscape(name), nil, connection)
+	if err != nil {
+		return nil, err
+	}
+
+	return connection, nil
+}
+
+// Add starts or replaces a connection
+func (c *Client) Add(ctx context.Context, request *AddRequest) (*Connection, error) {
+	connection := &Connection{}
+	err := c.do(ctx, http.MethodPost, "/connections", request, connection)
+	if err != nil {
+		return nil, err
+	}
+
+	return connection, nil
+}
+
+// Remove stops the connection with the given name
+func (c *Client) Remove(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/connections/"+url.PathEscape(name), nil, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		raw, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(raw)
+	}
+
+	// the host is ignored, the transport always dials the socket
+	req, err := http.NewRequestWithContext(ctx, method, "http://daemon"+path, body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		var netErr *net.OpError
+		if errors.As(err, &netErr) && netErr.Op == "dial" {
+			return ErrDaemonNotRunning
+		}
+		return fmt.Errorf("request connection daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode >= http.StatusBadRequest:
+		message, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("connection daemon: %s", bytes.TrimSpace(message))
+	case out == nil:
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// EnsureDaemon starts the daemon in the background if it isn't running yet and returns a client for it
+func EnsureDaemon(ctx context.Context, log log.Logger) (*Client, error) {
+	client, err := NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.Ping(ctx)
+	if err == nil {
+		return client, nil
+	} else if !errors.Is(err, ErrDaemonNotRunning) {
+		return nil, err
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create %s: %w", dir, err)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("find vcluster executable: %w", err)
+	}
+
+	logFile, err := os.OpenFile(filepath.Join(dir, LogFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open daemon log file: %w", err)
+	}
+	defer logFile.Close()
+
+	log.Infof("Starting connection daemon, logs are written to %s", logFile.Name())
+	daemon := exec.Command(executable, "connections", "daemon")
+	daemon.Stdout = logFile
+	daemon.Stderr = logFile
+	detach(daemon)
+	if err := daemon.Start(); err != nil {
+		return nil, fmt.Errorf("start connection daemon: %w", err)
+	}
+	_ = daemon.Process.Release()
+
+	err = wait.PollUntilContextTimeout(ctx, time.Millisecond*200, time.Second*10, true, func(ctx context.Context) (bool, error) {
+		return client.Ping(ctx) == nil, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("wait for connection daemon, check %s for details: %w", logFile.Name(), err)
+	}
+
+	return client, nil
+}
+
+// ListOptions holds the connections list cmd options
+type ListOptions struct {
+	Output string
+}
+
+// List prints the connections of the daemon
+func List(ctx context.Context, options *ListOptions, log log.Logger) error {
+	if options.Output != "" && options.Output != "table" && options.Output != "json" {
+		return fmt.Errorf("unsupported output format %q, expected table or json", options.Output)
+	}
+
+	client, err := NewClient()
+	if err != nil {
+		return err
+	}
+
+	connections, err := client.List(ctx)
+	if errors.Is(err, ErrDaemonNotRunning) {
+		connections = []Connection{}
+	} else if err != nil {
+		return err
+	}
+
+	if options.Output == "json" {
+		out, err := json.MarshalIndent(connections, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		log.WriteString(logrus.InfoLevel, string(out)+"\n")
+		return nil
+	}
+
+	if len(connections) == 0 {
+		log.Info("No background connections found, use vcluster connect --background to start one")
+		return nil
+	}
+
+	values := [][]string{}
+	for _, connection := range connections {
+		values = append(values, []string{
+			connection.Name,
+			connection.VCluster,
+			connection.Namespace,
+			connection.HostContext,
+			strconv.Itoa(connection.LocalPort),
+			string(connection.Status),
+			connection.Pod,
+			strconv.Itoa(connection.Restarts),
+			duration.HumanDuration(time.Since(connection.Since)),
+			connection.LastError,
+		})
+	}
+
+	table.PrintTable(log, []string{"NAME", "VCLUSTER", "NAMESPACE", "HOST CONTEXT", "LOCAL PORT", "STATUS", "POD", "RESTARTS", "SINCE", "LAST ERROR"}, values)
+	return nil
+}
@@ -0,0 +1,446 @@
+package connections
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/util/portforward"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var (
+	minBackoff = time.Second
+	maxBackoff = time.Second * 30
+)
+
+// ForwardFunc starts the port-forwarding for the connection and returns the stop channel of the
+// port-forwarding together with the name of the pod it forwards to. The channel is closed once
+// the port-forwarding stopped, closing it stops the port-forwarding.
+type ForwardFunc func(ctx context.Context, connection *Connection, hostKubeConfig []byte, log log.Logger) (chan struct{}, string, error)
+
+// Daemon keeps the port-forwardings of all connections running and reconnects them after pod
+// restarts or network errors. It serves the connections via http on a unix socket.
+type Daemon struct {
+	// StateFile is the file the connections are persisted to, so they survive daemon restarts
+	StateFile string
+
+	// Forward starts a single port-forwarding, defaults to port-forwarding to the vCluster pod
+	Forward ForwardFunc
+
+	log log.Logger
+
+	m       sync.Mutex
+	entries map[string]*entry
+
+	// empty is signaled when the last connection was removed
+	empty chan struct{}
+}
+
+type entry struct {
+	connection     Connection
+	hostKubeConfig string
+	cancel         context.CancelFunc
+}
+
+// storedConnection is a connection in the state file
+type storedConnection struct {
+	Connection     Connection `json:"connection"`
+	HostKubeConfig string     `json:"hostKubeConfig"`
+}
+
+// NewDaemon creates a new daemon that persists its connections into stateFile
+func NewDaemon(stateFile string, log log.Logger) *Daemon {
+	return &Daemon{
+		StateFile: stateFile,
+		Forward:   forwardToVCluster,
+		log:       log,
+		entries:   map[string]*entry{},
+		empty:     make(chan struct{}, 1),
+	}
+}
+
+// Run restores the persisted connections and serves the api on the socket until ctx is done
+// or the last connection was removed
+func (d *Daemon) Run(ctx context.Context, socket string) error {
+	if _, err := os.Stat(socket); err == nil {
+		if NewClientForSocket(socket).Ping(ctx) == nil {
+			return errors.New("connection daemon is already running")
+		}
+
+		// stale socket of a daemon that didn't shut down cleanly
+		if err := os.Remove(socket); err != nil {
+			return fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+
+	// the daemon reads host credentials and opens connections on behalf of whoever can reach the socket
+	if err := os.MkdirAll(filepath.Dir(socket), 0o700); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(socket), err)
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", socket, err)
+	}
+	defer os.Remove(socket)
+	if err := os.Chmod(socket, 0o600); err != nil {
+		_ = listener.Close()
+		return fmt.Errorf("restrict access to %s: %w", socket, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	err = d.restore(ctx)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: d.Handler(ctx), ReadHeaderTimeout: time.Second * 10}
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-d.empty:
+			d.log.Info("No connections left, stopping connection daemon")
+		}
+		_ = server.Close()
+	}()
+
+	d.log.Infof("Connection daemon listening on %s", socket)
+	err = server.Serve(listener)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+	for _, e := range d.entries {
+		e.cancel()
+	}
+	return nil
+}
+
+// Handler returns the http api of the daemon, connections are started with ctx
+func (d *Daemon) Handler(ctx context.Context) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("GET /connections", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, d.list())
+	})
+	mux.HandleFunc("GET /connections/{name}", func(w http.ResponseWriter, r *http.Request) {
+		connection, ok := d.get(r.PathValue("name"))
+		if !ok {
+			http.Error(w, "connection not found", http.StatusNotFound)
+			return
+		}
+
+		writeJSON(w, connection)
+	})
+	mux.HandleFunc("POST /connections", func(w http.ResponseWriter, r *http.Request) {
+		request := &AddRequest{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		connection, err := d.add(ctx, request)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, connection)
+	})
+	mux.HandleFunc("DELETE /connections/{name}", func(w http.ResponseWriter, r *http.Request) {
+		removed, err := d.remove(r.PathValue("name"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		} else if !removed {
+			http.Error(w, "connection not found", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+func (d *Daemon) list() []Connection {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	connections := []Connection{}
+	for _, e := range d.entries {
+		connections = append(connections, e.connection)
+	}
+	sort.Slice(connections, func(i, j int) bool {
+		return connections[i].Name < connections[j].Name
+	})
+	return connections
+}
+
+func (d *Daemon) get(name string) (Connection, bool) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	e, ok := d.entries[name]
+	if !ok {
+		return Connection{}, false
+	}
+
+	return e.connection, true
+}
+
+func (d *Daemon) add(ctx context.Context, request *AddRequest) (*Connection, error) {
+	connection := request.Connection
+	if connection.Name == "" || connection.VCluster == "" || connection.Namespace == "" {
+		return nil, errors.New("name, vcluster and namespace are required")
+	} else if connection.LocalPort == 0 || connection.RemotePort == 0 {
+		return nil, errors.New("local and remote port are required")
+	} else if _, err := clientcmd.Load([]byte(request.HostKubeConfig)); err != nil {
+		return nil, fmt.Errorf("parse host kube config: %w", err)
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	for name, e := range d.entries {
+		if name != connection.Name && e.connection.LocalPort == connection.LocalPort {
+			return nil, fmt.Errorf("local port %d is already used by connection %s", connection.LocalPort, name)
+		}
+	}
+
+	// replace an existing connection for the same context
+	if existing, ok := d.entries[connection.Name]; ok {
+		existing.cancel()
+	}
+
+	d.start(ctx, connection, request.HostKubeConfig)
+	if err := d.save(); err != nil {
+		return nil, err
+	}
+
+	d.log.Infof("Added connection %s to vcluster %s/%s on local port %d", connection.Name, connection.Namespace, connection.VCluster, connection.LocalPort)
+	added := d.entries[connection.Name].connection
+	return &added, nil
+}
+
+func (d *Daemon) remove(name string) (bool, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	e, ok := d.entries[name]
+	if !ok {
+		return false, nil
+	}
+
+	e.cancel()
+	delete(d.entries, name)
+	if err := d.save(); err != nil {
+		return true, err
+	}
+
+	d.log.Infof("Removed connection %s", name)
+	if len(d.entries) == 0 {
+		select {
+		case d.empty <- struct{}{}:
+		default:
+		}
+	}
+
+	return true, nil
+}
+
+// start runs the connection until it is removed, d.m needs to be held
+func (d *Daemon) start(ctx context.Context, connection Connection, hostKubeConfig string) {
+	ctx, cancel := context.WithCancel(ctx)
+	connection.Status = StatusConnecting
+	connection.Since = time.Now()
+	connection.Pod = ""
+	connection.LastError = ""
+	connection.Restarts = 0
+	d.entries[connection.Name] = &entry{
+		connection:     connection,
+		hostKubeConfig: hostKubeConfig,
+		cancel:         cancel,
+	}
+
+	go d.keepConnected(ctx, connection, []byte(hostKubeConfig))
+}
+
+// keepConnected restarts the port-forwarding with an exponential backoff whenever it stops
+func (d *Daemon) keepConnected(ctx context.Context, connection Connection, hostKubeConfig []byte) {
+	backoff := minBackoff
+	for ctx.Err() == nil {
+		stopped, pod, err := d.Forward(ctx, &connection, hostKubeConfig, d.log)
+		if err != nil {
+			d.setStatus(ctx, connection.Name, StatusReconnecting, "", err.Error(), false)
+			d.log.Infof("Error connecting %s, retrying in %s: %v", connection.Name, backoff, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, maxBackoff)
+			continue
+		}
+
+		backoff = minBackoff
+		d.setStatus(ctx, connection.Name, StatusConnected, pod, "", false)
+		select {
+		case <-ctx.Done():
+			close(stopped)
+			return
+		case <-stopped:
+			d.log.Infof("Port-forwarding of %s stopped, reconnecting", connection.Name)
+			d.setStatus(ctx, connection.Name, StatusReconnecting, pod, "port-forwarding stopped", true)
+		}
+	}
+}
+
+func (d *Daemon) setStatus(ctx context.Context, name string, status Status, pod, lastError string, restarted bool) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	// the connection might have been removed or replaced in the meantime
+	e, ok := d.entries[name]
+	if !ok || ctx.Err() != nil {
+		return
+	}
+
+	if e.connection.Status != status {
+		e.connection.Since = time.Now()
+	}
+	e.connection.Status = status
+	e.connection.Pod = pod
+	if lastError != "" {
+		e.connection.LastError = lastError
+	}
+	if restarted {
+		e.connection.Restarts++
+	}
+}
+
+func (d *Daemon) restore(ctx context.Context) error {
+	out, err := os.ReadFile(d.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("read state file: %w", err)
+	}
+
+	stored := []storedConnection{}
+	if err := json.Unmarshal(out, &stored); err != nil {
+		return fmt.Errorf("parse state file %s: %w", d.StateFile, err)
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+	for _, s := range stored {
+		d.log.Infof("Restoring connection %s", s.Connection.Name)
+		d.start(ctx, s.Connection, s.HostKubeConfig)
+	}
+
+	return nil
+}
+
+// save writes the connections into the state file, d.m needs to be held
+func (d *Daemon) save() error {
+	stored := []storedConnection{}
+	for _, name := range slices.Sorted(maps.Keys(d.entries)) {
+		stored = append(stored, storedConnection{
+			Connection:     d.entries[name].connection,
+			HostKubeConfig: d.entries[name].hostKubeConfig,
+		})
+	}
+
+	out, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// the state file contains the host credentials
+	if err := os.MkdirAll(filepath.Dir(d.StateFile), 0o700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(d.StateFile, out, 0o600); err != nil {
+		return fmt.Errorf("write state file: %w", err)
+	}
+
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// forwardToVCluster looks up the current vCluster pod and starts the port-forwarding to it
+func forwardToVCluster(ctx context.Context, connection *Connection, hostKubeConfig []byte, log log.Logger) (chan struct{}, string, error) {
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(hostKubeConfig)
+	if err != nil {
+		return nil, "", fmt.Errorf("load host kube config: %w", err)
+	}
+
+	kubeClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, "", fmt.Errorf("create kube client: %w", err)
+	}
+
+	pod, err := readyVClusterPod(ctx, kubeClient, connection.Namespace, connection.VCluster)
+	if err != nil {
+		return nil, "", err
+	}
+
+	stopChan, err := portforward.StartPortForwarding(ctx, restConfig, kubeClient, connection.Address, pod, connection.Namespace, strconv.Itoa(connection.LocalPort), strconv.Itoa(connection.RemotePort), io.Discard, io.Discard, log)
+	if err != nil {
+		return nil, "", fmt.Errorf("start port-forwarding to %s/%s: %w", connection.Namespace, pod, err)
+	}
+
+	return stopChan, pod, nil
+}
+
+// readyVClusterPod returns the newest ready pod of the vCluster
+func readyVClusterPod(ctx context.Context, kubeClient kubernetes.Interface, namespace, vClusterName string) (string, error) {
+	pods, err := kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=vcluster,release=" + vClusterName,
+	})
+	if err != nil {
+		return "", fmt.Errorf("list vcluster pods: %w", err)
+	}
+
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].CreationTimestamp.After(pods.Items[j].CreationTimestamp.Time)
+	})
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				return pod.Name, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no ready vcluster pod found in namespace %s", namespace)
+}
@@ -0,0 +1,251 @@
+package connections
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/log"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testHostKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: host
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: host
+  context:
+    cluster: host
+current-context: host
+`
+
+// startTestDaemon runs a daemon with a fake port-forwarding, every started port-forwarding is sent to forwards
+func startTestDaemon(ctx context.Context, t *testing.T, dir string) (*Client, chan chan struct{}, chan error) {
+	t.Helper()
+
+	forwards := make(chan chan struct{}, 10)
+	client, done := runTestDaemon(ctx, t, dir, func(_ context.Context, connection *Connection, _ []byte, _ log.Logger) (chan struct{}, string, error) {
+		stopped := make(chan struct{})
+		forwards <- stopped
+		return stopped, connection.VCluster + "-0", nil
+	})
+	return client, forwards, done
+}
+
+// runTestDaemon runs a daemon with the given port-forwarding in dir and waits until it is reachable
+func runTestDaemon(ctx context.Context, t *testing.T, dir string, forward ForwardFunc) (*Client, chan error) {
+	t.Helper()
+
+	daemon := NewDaemon(filepath.Join(dir, StateFileName), log.Discard)
+	daemon.Forward = forward
+
+	socket := filepath.Join(dir, SocketFileName)
+	done := make(chan error, 1)
+	go func() {
+		done <- daemon.Run(ctx, socket)
+	}()
+
+	client := NewClientForSocket(socket)
+	err := wait.PollUntilContextTimeout(ctx, time.Millisecond*10, time.Second*5, true, func(ctx context.Context) (bool, error) {
+		return client.Ping(ctx) == nil, nil
+	})
+	assert.NilError(t, err)
+	return client, done
+}
+
+func waitForStatus(ctx context.Context, t *testing.T, client *Client, name string, status Status) *Connection {
+	t.Helper()
+
+	var connection *Connection
+	err := wait.PollUntilContextTimeout(ctx, time.Millisecond*10, time.Second*5, true, func(ctx context.Context) (bool, error) {
+		var err error
+		connection, err = client.Get(ctx, name)
+		if err != nil {
+			return false, err
+		}
+
+		return connection.Status == status, nil
+	})
+	assert.NilError(t, err)
+	return connection
+}
+
+func testAddRequest(name string, localPort int) *AddRequest {
+	return &AddRequest{
+		Connection: Connection{
+			Name:        name,
+			VCluster:    "my-vcluster",
+			Namespace:   "vcluster-my-vcluster",
+			HostContext: "host",
+			LocalPort:   localPort,
+			RemotePort:  8443,
+		},
+		HostKubeConfig: testHostKubeConfig,
+	}
+}
+
+func TestDaemon(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	dir := t.TempDir()
+	client, forwards, done := startTestDaemon(ctx, t, dir)
+
+	// only the user can reach the socket
+	info, err := os.Stat(filepath.Join(dir, SocketFileName))
+	assert.NilError(t, err)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0o600))
+
+	_, err = client.Add(ctx, testAddRequest("vcluster_my-vcluster", 11000))
+	assert.NilError(t, err)
+	connection := waitForStatus(ctx, t, client, "vcluster_my-vcluster", StatusConnected)
+	assert.Equal(t, connection.Pod, "my-vcluster-0")
+	assert.Equal(t, connection.Restarts, 0)
+
+	// the local port is already taken by another connection
+	_, err = client.Add(ctx, testAddRequest("other", 11000))
+	assert.ErrorContains(t, err, "local port 11000 is already used")
+	_, err = client.Add(ctx, &AddRequest{Connection: Connection{Name: "invalid"}})
+	assert.ErrorContains(t, err, "required")
+
+	connections, err := client.List(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(connections), 1)
+
+	// a stopped port-forwarding is reconnected
+	close(<-forwards)
+	<-forwards
+	connection = waitForStatus(ctx, t, client, "vcluster_my-vcluster", StatusConnected)
+	assert.Equal(t, connection.Restarts, 1)
+
+	// the connection is persisted together with its host kube config
+	stored := []storedConnection{}
+	out, err := os.ReadFile(filepath.Join(dir, StateFileName))
+	assert.NilError(t, err)
+	assert.NilError(t, json.Unmarshal(out, &stored))
+	assert.Equal(t, len(stored), 1)
+	assert.Equal(t, stored[0].HostKubeConfig, testHostKubeConfig)
+
+	_, err = client.Add(ctx, testAddRequest("other", 11001))
+	assert.NilError(t, err)
+	assert.NilError(t, client.Remove(ctx, "vcluster_my-vcluster"))
+	assert.ErrorIs(t, client.Remove(ctx, "vcluster_my-vcluster"), ErrNotFound)
+	_, err = client.Get(ctx, "vcluster_my-vcluster")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// the daemon stops once the last connection is removed
+	assert.NilError(t, client.Remove(ctx, "other"))
+	assert.NilError(t, <-done)
+	assert.ErrorIs(t, client.Ping(ctx), ErrDaemonNotRunning)
+}
+
+func TestDaemonRestore(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	dir := t.TempDir()
+	daemonCtx, stopDaemon := context.WithCancel(ctx)
+	client, _, done := startTestDaemon(daemonCtx, t, dir)
+	_, err := client.Add(ctx, testAddRequest("vcluster_my-vcluster", 11000))
+	assert.NilError(t, err)
+	stopDaemon()
+	assert.NilError(t, <-done)
+
+	client, _, done = startTestDaemon(ctx, t, dir)
+	connection := waitForStatus(ctx, t, client, "vcluster_my-vcluster", StatusConnected)
+	assert.Equal(t, connection.LocalPort, 11000)
+	assert.NilError(t, client.Remove(ctx, "vcluster_my-vcluster"))
+	assert.NilError(t, <-done)
+}
+
+func TestKeepConnectedBackoff(t *testing.T) {
+	defer func(minDuration, maxDuration time.Duration) { minBackoff, maxBackoff = minDuration, maxDuration }(minBackoff, maxBackoff)
+	minBackoff, maxBackoff = time.Millisecond*20, time.Millisecond*40
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	// the port-forwarding fails 4 times before it connects
+	failures := 4
+	attempts := make(chan time.Time, 10)
+	forwards := make(chan chan struct{}, 10)
+	client, done := runTestDaemon(ctx, t, t.TempDir(), func(_ context.Context, connection *Connection, _ []byte, _ log.Logger) (chan struct{}, string, error) {
+		attempts <- time.Now()
+		if failures > 0 {
+			failures--
+			return nil, "", errors.New("no ready vcluster pod found")
+		}
+
+		stopped := make(chan struct{})
+		forwards <- stopped
+		return stopped, connection.VCluster + "-0", nil
+	})
+
+	_, err := client.Add(ctx, testAddRequest("vcluster_my-vcluster", 11000))
+	assert.NilError(t, err)
+	connection := waitForStatus(ctx, t, client, "vcluster_my-vcluster", StatusConnected)
+	assert.Equal(t, connection.LastError, "no ready vcluster pod found")
+	assert.Equal(t, connection.Restarts, 0)
+
+	// the retries back off exponentially up to the maximum
+	last := <-attempts
+	for _, backoff := range []time.Duration{minBackoff, minBackoff * 2, maxBackoff, maxBackoff} {
+		attempt := <-attempts
+		assert.Assert(t, attempt.Sub(last) >= backoff, "retried after %s, expected at least %s", attempt.Sub(last), backoff)
+		last = attempt
+	}
+
+	// a stopped port-forwarding is reconnected right away and the backoff is reset
+	close(<-forwards)
+	<-forwards
+	connection = waitForStatus(ctx, t, client, "vcluster_my-vcluster", StatusConnected)
+	assert.Equal(t, connection.Restarts, 1)
+	assert.Equal(t, len(attempts), 1)
+
+	assert.NilError(t, client.Remove(ctx, "vcluster_my-vcluster"))
+	assert.NilError(t, <-done)
+}
+
+func TestReadyVClusterPod(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	pod := func(name string, created time.Time, ready corev1.ConditionStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "vcluster-my-vcluster",
+				Labels:            map[string]string{"app": "vcluster", "release": "my-vcluster"},
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}}},
+		}
+	}
+
+	kubeClient := fake.NewSimpleClientset(pod("my-vcluster-old", now.Add(-time.Hour), corev1.ConditionTrue))
+	name, err := readyVClusterPod(ctx, kubeClient, "vcluster-my-vcluster", "my-vcluster")
+	assert.NilError(t, err)
+	assert.Equal(t, name, "my-vcluster-old")
+
+	kubeClient = fake.NewSimpleClientset(
+		pod("my-vcluster-old", now.Add(-time.Hour), corev1.ConditionTrue),
+		pod("my-vcluster-new", now, corev1.ConditionTrue),
+		pod("my-vcluster-starting", now.Add(time.Minute), corev1.ConditionFalse),
+	)
+	name, err = readyVClusterPod(ctx, kubeClient, "vcluster-my-vcluster", "my-vcluster")
+	assert.NilError(t, err)
+	assert.Equal(t, name, "my-vcluster-new")
+
+	_, err = readyVClusterPod(ctx, kubeClient, "vcluster-my-vcluster", "other")
+	assert.ErrorContains(t, err, "no ready vcluster pod")
+}
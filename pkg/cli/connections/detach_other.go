@@ -0,0 +1,8 @@
+//go:build !unix
+
+package connections
+
+import "os/exec"
+
+// detach is not implemented on Windows, the daemon is started as a regular child process.
+func detach(*exec.Cmd) {}
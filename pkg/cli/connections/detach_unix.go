@@ -0,0 +1,13 @@
+//go:build unix
+
+package connections
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach starts the daemon in its own session, so it keeps running after the terminal is closed
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
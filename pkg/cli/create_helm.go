@@ -1,15 +1,10 @@
 package cli
 
 import (
-	"archive/tar"
-	"bytes"
-	"compress/gzip"
 	"context"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"io/fs"
 	"os"
 	"os/exec"
@@ -48,6 +43,7 @@ import (
 	platformclihelper "github.com/loft-sh/vcluster/pkg/platform/clihelper"
 	"github.com/loft-sh/vcluster/pkg/platform/sleepmode"
 	"github.com/loft-sh/vcluster/pkg/snapshot"
+	"github.com/loft-sh/vcluster/pkg/snapshot/encryption"
 	"github.com/loft-sh/vcluster/pkg/snapshot/pod"
 	"github.com/loft-sh/vcluster/pkg/telemetry"
 	"github.com/loft-sh/vcluster/pkg/upgrade"
@@ -70,6 +66,7 @@ type CreateOptions struct {
 	Values                []string
 	SetValues             []string
 	Print                 bool
+	Interactive           bool
 
 	KubernetesVersion string
 
@@ -81,6 +78,8 @@ type CreateOptions struct {
 	Expose               bool
 	ExposeLocal          bool
 	Restore              string
+	RestoreVolumes       bool
+	RestoreEncryption    encryption.Options
 	Connect              bool
 	Upgrade              bool
 
@@ -132,17 +131,7 @@ func CreateHelm(ctx context.Context, options *CreateOptions, globalFlags *flags.
 	}
 
 	// check helm binary
-	helmBinaryPath, err := helmdownloader.GetHelmBinaryPath(ctx, cmd.log)
-	if err != nil {
-		return err
-	}
-
-	output, err := exec.Command(helmBinaryPath, "version", "--client", "--template", "{{.Version}}").Output()
-	if err != nil {
-		return err
-	}
-
-	err = clihelper.CheckHelmVersion(string(output))
+	helmBinaryPath, err := getHelmBinaryPath(ctx, cmd.log)
 	if err != nil {
 		return err
 	}
@@ -188,7 +177,7 @@ func CreateHelm(ctx context.Context, options *CreateOptions, globalFlags *flags.
 				}
 
 				log.Infof("Restore vCluster %s...", vClusterName)
-				err = Restore(ctx, []string{vClusterName, cmd.Restore}, globalFlags, &snapshot.Options{}, &pod.Options{}, false, false, log)
+				err = Restore(ctx, []string{vClusterName, cmd.Restore}, globalFlags, &snapshot.Options{Encryption: cmd.RestoreEncryption}, &pod.Options{}, false, false, log)
 				if err != nil {
 					return fmt.Errorf("restore vCluster %s: %w", vClusterName, err)
 				}
@@ -250,7 +239,7 @@ func CreateHelm(ctx context.Context, options *CreateOptions, globalFlags *flags.
 			}
 		} else {
 			// When a vCluster is not legacy, there should be a config secret and we will fetch the values from the secret
-			currentVClusterConfig, err = getConfigfileFromSecret(ctx, vClusterName, cmd.Namespace)
+			currentVClusterConfig, err = getConfigfileFromSecret(ctx, cmd.kubeClient, vClusterName, cmd.Namespace)
 			if err != nil {
 				return err
 			}
@@ -326,17 +315,7 @@ func CreateHelm(ctx context.Context, options *CreateOptions, globalFlags *flags.
 		return err
 	}
 
-	err = pkgconfig.ValidateSyncFromHostClasses(vClusterConfig.Sync.FromHost)
-	if err != nil {
-		return err
-	}
-
-	err = pkgconfig.ValidateAllSyncPatches(vClusterConfig.Sync)
-	if err != nil {
-		return err
-	}
-
-	err = pkgconfig.ValidateVolumeSnapshotController(vClusterConfig.Deploy.VolumeSnapshotController, vClusterConfig.PrivateNodes)
+	err = validateVClusterConfig(vClusterConfig, vClusterName, cmd.Namespace)
 	if err != nil {
 		return err
 	}
@@ -346,12 +325,6 @@ func CreateHelm(ctx context.Context, options *CreateOptions, globalFlags *flags.
 		cmd.log.Warnf(warning)
 	}
 
-	if vClusterConfig.Sync.ToHost.Namespaces.Enabled {
-		if err := namespaces.ValidateNamespaceSyncConfig(vClusterConfig, vClusterName, cmd.Namespace); err != nil {
-			return err
-		}
-	}
-
 	// Only require platform agent if sleep mode is configured via platform (external.platform)
 	// Non-platform sleep mode (sleepMode.enabled) doesn't require the agent
 	if vClusterConfig.IsConfiguredForSleepMode() {
@@ -369,11 +342,6 @@ func CreateHelm(ctx context.Context, options *CreateOptions, globalFlags *flags.
 		}
 	}
 
-	err = validateHABackingStoreCompatibility(vClusterConfig)
-	if err != nil {
-		return err
-	}
-
 	verb := "created"
 	if isVClusterDeployed(release) {
 		verb = "upgraded"
@@ -553,6 +521,32 @@ func isLegacyVCluster(version string) bool {
 	return semver.Compare("v"+version, "v0.20.0-alpha.0") == -1
 }
 
+// validateVClusterConfig runs the validations of the vCluster config that don't need to talk to the cluster.
+func validateVClusterConfig(vClusterConfig *config.Config, vClusterName, namespace string) error {
+	err := pkgconfig.ValidateSyncFromHostClasses(vClusterConfig.Sync.FromHost)
+	if err != nil {
+		return err
+	}
+
+	err = pkgconfig.ValidateAllSyncPatches(vClusterConfig.Sync)
+	if err != nil {
+		return err
+	}
+
+	err = pkgconfig.ValidateVolumeSnapshotController(vClusterConfig.Deploy.VolumeSnapshotController, vClusterConfig.PrivateNodes)
+	if err != nil {
+		return err
+	}
+
+	if vClusterConfig.Sync.ToHost.Namespaces.Enabled {
+		if err := namespaces.ValidateNamespaceSyncConfig(vClusterConfig, vClusterName, namespace); err != nil {
+			return err
+		}
+	}
+
+	return validateHABackingStoreCompatibility(vClusterConfig)
+}
+
 func validateHABackingStoreCompatibility(config *config.Config) error {
 	if !config.EmbeddedDatabase() {
 		return nil
@@ -591,6 +585,26 @@ func helmExtraValuesYAML(release *helm.Release) (string, error) {
 	return string(extraValues), nil
 }
 
+// getHelmBinaryPath returns the path to a helm binary with a supported version, it is downloaded if necessary.
+func getHelmBinaryPath(ctx context.Context, log log.Logger) (string, error) {
+	helmBinaryPath, err := helmdownloader.GetHelmBinaryPath(ctx, log)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := exec.Command(helmBinaryPath, "version", "--client", "--template", "{{.Version}}").Output()
+	if err != nil {
+		return "", err
+	}
+
+	err = clihelper.CheckHelmVersion(string(output))
+	if err != nil {
+		return "", err
+	}
+
+	return helmBinaryPath, nil
+}
+
 func getBase64DecodedString(values string) (string, error) {
 	strDecoded, err := base64.StdEncoding.DecodeString(values)
 	if err != nil {
@@ -716,7 +730,7 @@ func (cmd *createHelm) deployChart(ctx context.Context, vClusterName, chartValue
 	// now restore if wanted
 	if cmd.Restore != "" {
 		cmd.log.Infof("Restore vCluster %s...", vClusterName)
-		err = Restore(ctx, []string{vClusterName, cmd.Restore}, cmd.GlobalFlags, &snapshot.Options{}, &pod.Options{}, true, false, cmd.log)
+		err = Restore(ctx, []string{vClusterName, cmd.Restore}, cmd.GlobalFlags, &snapshot.Options{Encryption: cmd.RestoreEncryption}, &pod.Options{}, true, cmd.RestoreVolumes, cmd.log)
 		if err != nil {
 			// delete the vcluster if the restore failed
 			deleteErr := helmClient.Delete(vClusterName, cmd.Namespace)
@@ -929,56 +943,21 @@ func (cmd *createHelm) getVClusterConfigFromSnapshot(ctx context.Context) (strin
 		return "", nil
 	}
 
-	snapshotOptions := &snapshot.Options{}
+	snapshotOptions := &snapshot.Options{Encryption: cmd.RestoreEncryption}
 	err := snapshot.Parse(cmd.Restore, snapshotOptions)
 	if err != nil {
 		return "", fmt.Errorf("parse snapshot: %w", err)
 	}
 
-	objectStore, err := snapshot.CreateStore(ctx, snapshotOptions)
-	if err != nil {
-		return "", fmt.Errorf("create snapshot store: %w", err)
-	}
-
-	reader, err := objectStore.GetObject(ctx)
-	if err != nil {
-		return "", fmt.Errorf("get snapshot object: %w", err)
-	}
-
-	// read the first tar entry
-	gzipReader, err := gzip.NewReader(reader)
-	if err != nil {
-		return "", fmt.Errorf("create gzip reader: %w", err)
-	}
-	defer gzipReader.Close()
-
-	// create a new tar reader
-	tarReader := tar.NewReader(gzipReader)
-
-	// read the vCluster config
-	header, err := tarReader.Next()
-	if err != nil {
-		return "", err
-	}
-
-	buf := &bytes.Buffer{}
-	_, err = io.Copy(buf, tarReader)
+	// read the vCluster release, the encryption key secret is expected in the namespace of the new vCluster
+	release, err := snapshot.ReadRelease(ctx, snapshotOptions, snapshotSecretLoader(ctx, cmd.kubeClient, cmd.Namespace))
 	if err != nil {
 		return "", err
-	}
-
-	// no vCluster config in the snapshot
-	if header.Name != snapshot.SnapshotReleaseKey {
+	} else if release == nil {
+		// no vCluster config in the snapshot
 		return "", nil
 	}
 
-	// unmarshal the release
-	release := &snapshot.HelmRelease{}
-	err = json.Unmarshal(buf.Bytes(), release)
-	if err != nil {
-		return "", fmt.Errorf("unmarshal vCluster release: %w", err)
-	}
-
 	// set chart version
 	if release.ChartVersion != "" && (cmd.ChartVersion == "" || cmd.ChartVersion == upgrade.GetVersion()) {
 		cmd.ChartVersion = release.ChartVersion
@@ -992,21 +971,9 @@ func (cmd *createHelm) getVClusterConfigFromSnapshot(ctx context.Context) (strin
 	return "", nil
 }
 
-func getConfigfileFromSecret(ctx context.Context, name, namespace string) (*config.Config, error) {
+func getConfigfileFromSecret(ctx context.Context, kubeClient kubernetes.Interface, name, namespace string) (*config.Config, error) {
 	secretName := "vc-config-" + name
-
-	kConf := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{})
-	clientConfig, err := kConf.ClientConfig()
-	if err != nil {
-		return nil, err
-	}
-
-	clientset, err := kubernetes.NewForConfig(clientConfig)
-	if err != nil {
-		return nil, err
-	}
-
-	secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	secret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
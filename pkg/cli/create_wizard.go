@@ -0,0 +1,706 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/log/survey"
+	"github.com/loft-sh/vcluster/config"
+	pkgconfig "github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/strvals"
+)
+
+const (
+	wizardYes = "yes"
+	wizardNo  = "no"
+
+	// DefaultWizardValuesFile is the file the create wizard proposes to write the values to
+	DefaultWizardValuesFile = "vcluster.yaml"
+)
+
+// CreateWizardOptions holds the options for the interactive create wizard
+type CreateWizardOptions struct {
+	// Values and SetValues are used as the defaults of the wizard questions
+	Values    []string
+	SetValues []string
+
+	// ProFeatures is true if the virtual cluster runs with the pro features, otherwise pro values are not asked for
+	ProFeatures bool
+}
+
+// CreateWizardResult is the outcome of the interactive create wizard
+type CreateWizardResult struct {
+	// ValuesFile is the path of the values file that was written
+	ValuesFile string
+
+	// Create is true if the virtual cluster should be created right away
+	Create bool
+}
+
+// CreateWizard walks through the most important sections of the vcluster.yaml, validates the answers of each
+// section and writes them into a values file that can be passed to vcluster create.
+func CreateWizard(name string, options *CreateWizardOptions, log log.Logger) (*CreateWizardResult, error) {
+	defaultValues, err := mergeAllValues(options.SetValues, options.Values, config.Values)
+	if err != nil {
+		return nil, err
+	}
+
+	wizard, err := newCreateWizard(name, defaultValues, log.Question, log)
+	if err != nil {
+		return nil, err
+	}
+	wizard.schema.proFeatures = options.ProFeatures
+
+	return wizard.Run()
+}
+
+// wizardSection is a group of questions that is validated as a whole
+type wizardSection struct {
+	Name string
+	Ask  func(w *createWizard) error
+}
+
+var wizardSections = []wizardSection{
+	{Name: "distro", Ask: (*createWizard).askDistro},
+	{Name: "backing store", Ask: (*createWizard).askBackingStore},
+	{Name: "sync", Ask: (*createWizard).askSync},
+	{Name: "networking", Ask: (*createWizard).askNetworking},
+	{Name: "sleep mode", Ask: (*createWizard).askSleepMode},
+	{Name: "exposure", Ask: (*createWizard).askExposure},
+}
+
+type createWizard struct {
+	name   string
+	schema *valuesSchema
+
+	// defaults are the values the questions start with, answers only hold the values that differ from them
+	defaults map[string]interface{}
+	answers  map[string]interface{}
+
+	ask func(*survey.QuestionOptions) (string, error)
+	log log.Logger
+}
+
+func newCreateWizard(name, defaultValues string, ask func(*survey.QuestionOptions) (string, error), log log.Logger) (*createWizard, error) {
+	schema, err := parseValuesSchema(config.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("parse values schema: %w", err)
+	}
+
+	defaults, err := parseString(defaultValues)
+	if err != nil {
+		return nil, fmt.Errorf("parse default values: %w", err)
+	}
+
+	return &createWizard{
+		name:     name,
+		schema:   schema,
+		defaults: defaults,
+		answers:  map[string]interface{}{},
+		ask:      ask,
+		log:      log,
+	}, nil
+}
+
+// Run asks all sections and writes the values file
+func (w *createWizard) Run() (*CreateWizardResult, error) {
+	for _, section := range wizardSections {
+		if err := w.runSection(section); err != nil {
+			return nil, err
+		}
+	}
+
+	valuesFile, err := w.writeValuesFile()
+	if err != nil {
+		return nil, err
+	}
+
+	create, err := w.askBool(fmt.Sprintf("Create virtual cluster %s now?", w.name), true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateWizardResult{ValuesFile: valuesFile, Create: create}, nil
+}
+
+// runSection asks the section until its answers result in a valid config
+func (w *createWizard) runSection(section wizardSection) error {
+	w.log.Infof("Configure %s", section.Name)
+	for {
+		previousAnswers := copyValues(w.answers)
+		if err := section.Ask(w); err != nil {
+			return err
+		}
+
+		err := w.validate()
+		if err == nil {
+			return nil
+		}
+
+		w.log.Errorf("Invalid %s configuration: %v", section.Name, err)
+		w.answers = previousAnswers
+	}
+}
+
+// validate checks the defaults together with the current answers the same way the syncer would
+func (w *createWizard) validate() error {
+	out, err := yaml.Marshal(strvals.MergeMaps(w.defaults, w.answers))
+	if err != nil {
+		return err
+	}
+
+	vClusterConfig := &config.Config{}
+	if err := config.UnmarshalYAMLStrict(out, vClusterConfig); err != nil {
+		return err
+	}
+
+	return pkgconfig.ValidateConfigAndSetDefaults(&pkgconfig.VirtualClusterConfig{
+		Config: *vClusterConfig,
+		Name:   w.name,
+	})
+}
+
+func (w *createWizard) askDistro() error {
+	distros := w.schema.ChildrenWith("controlPlane.distro", "enabled")
+	selected := config.K8SDistro
+	for _, distro := range distros {
+		if w.boolValue("controlPlane.distro." + distro + ".enabled") {
+			selected = distro
+		}
+	}
+
+	answer, err := w.askOption("Which Kubernetes distro should the control plane run?", distros, selected, func(distro string) string {
+		if w.schema.Deprecated("controlPlane.distro." + distro) {
+			return distro + " (deprecated)"
+		}
+		return distro
+	})
+	if err != nil {
+		return err
+	}
+
+	w.enableOne("controlPlane.distro", distros, answer, config.K8SDistro)
+	return nil
+}
+
+func (w *createWizard) askBackingStore() error {
+	stores := []string{}
+	for _, kind := range w.schema.ChildrenWith("controlPlane.backingStore", "") {
+		for _, store := range w.schema.ChildrenWith("controlPlane.backingStore."+kind, "enabled") {
+			stores = append(stores, kind+"."+store)
+		}
+	}
+
+	const defaultStore = "database.embedded"
+
+	selected := defaultStore
+	for _, store := range stores {
+		if w.boolValue("controlPlane.backingStore." + store + ".enabled") {
+			selected = store
+		}
+	}
+
+	answer, err := w.askOption("Which backing store should the control plane use?", stores, selected, nil)
+	if err != nil {
+		return err
+	}
+	w.enableOne("controlPlane.backingStore", stores, answer, defaultStore)
+
+	switch answer {
+	case "database.external":
+		connector, err := w.askString("controlPlane.backingStore.database.external.connector", false)
+		if err != nil {
+			return err
+		}
+		if connector == "" {
+			if _, err := w.askString("controlPlane.backingStore.database.external.dataSource", true); err != nil {
+				return err
+			}
+		}
+	case "etcd.external":
+		if _, err := w.askString("controlPlane.backingStore.etcd.external.endpoint", true); err != nil {
+			return err
+		}
+	}
+
+	_, err = w.askInteger("controlPlane.statefulSet.highAvailability.replicas")
+	return err
+}
+
+func (w *createWizard) askSync() error {
+	for _, direction := range w.schema.ChildrenWith("sync", "") {
+		customize, err := w.askBool(fmt.Sprintf("Customize which resources are synced %s (sync.%s)?", strings.TrimSuffix(direction, "Host")+" the host", direction), false)
+		if err != nil {
+			return err
+		} else if !customize {
+			continue
+		}
+
+		for _, resource := range w.schema.ChildrenWith("sync."+direction, "enabled") {
+			if err := w.askSwitch("sync." + direction + "." + resource + ".enabled"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *createWizard) askNetworking() error {
+	_, err := w.askString("networking.advanced.clusterDomain", true)
+	return err
+}
+
+func (w *createWizard) askSleepMode() error {
+	if w.schema.Lookup("sleepMode") == nil {
+		return nil
+	}
+
+	if err := w.askSwitch("sleepMode.enabled"); err != nil {
+		return err
+	} else if !w.boolValue("sleepMode.enabled") {
+		return nil
+	}
+
+	for _, path := range []string{"sleepMode.autoSleep.afterInactivity", "sleepMode.autoSleep.schedule", "sleepMode.timeZone"} {
+		if _, err := w.askString(path, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *createWizard) askExposure() error {
+	const ingress = "Ingress"
+
+	selected := w.stringValue("controlPlane.service.spec.type")
+	if w.boolValue("controlPlane.ingress.enabled") {
+		selected = ingress
+	}
+
+	answer, err := w.askOption("How should the virtual cluster endpoint be exposed?", []string{"ClusterIP", "LoadBalancer", "NodePort", ingress}, selected, nil)
+	if err != nil {
+		return err
+	}
+
+	if answer != ingress {
+		w.setAnswer("controlPlane.ingress.enabled", false)
+		w.setAnswer("controlPlane.service.spec.type", answer)
+		return nil
+	}
+
+	w.setAnswer("controlPlane.ingress.enabled", true)
+	w.setAnswer("controlPlane.service.spec.type", "ClusterIP")
+	_, err = w.askString("controlPlane.ingress.host", true)
+	return err
+}
+
+// writeValuesFile asks for the path of the values file and writes the answers into it
+func (w *createWizard) writeValuesFile() (string, error) {
+	out, err := yaml.Marshal(w.answers)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		valuesFile, err := w.ask(&survey.QuestionOptions{
+			Question:     "Where should the values file be written to?",
+			DefaultValue: DefaultWizardValuesFile,
+			ValidationFunc: func(value string) error {
+				if strings.TrimSpace(value) == "" {
+					return errors.New("please specify a path")
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := os.Stat(valuesFile); err == nil {
+			overwrite, err := w.askBool(fmt.Sprintf("%s already exists, overwrite it?", valuesFile), false)
+			if err != nil {
+				return "", err
+			} else if !overwrite {
+				continue
+			}
+		}
+
+		if err := os.WriteFile(valuesFile, out, 0o644); err != nil {
+			return "", fmt.Errorf("write values file %s: %w", valuesFile, err)
+		}
+
+		w.log.Donef("Wrote values to %s", valuesFile)
+		return valuesFile, nil
+	}
+}
+
+// askOption asks to choose one of the options, label can be used to decorate the options
+func (w *createWizard) askOption(question string, options []string, selected string, label func(string) string) (string, error) {
+	if label == nil {
+		label = func(option string) string { return option }
+	}
+
+	labels := make([]string, 0, len(options))
+	defaultLabel := ""
+	for _, option := range options {
+		if option == selected {
+			defaultLabel = label(option)
+		}
+		labels = append(labels, label(option))
+	}
+	if defaultLabel == "" && len(labels) > 0 {
+		defaultLabel = labels[0]
+	}
+
+	answer, err := w.ask(&survey.QuestionOptions{
+		Question:     question,
+		DefaultValue: defaultLabel,
+		Options:      labels,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	index := slices.Index(labels, answer)
+	if index == -1 {
+		return "", fmt.Errorf("unknown option %q", answer)
+	}
+
+	return options[index], nil
+}
+
+func (w *createWizard) askBool(question string, defaultValue bool) (bool, error) {
+	answer, err := w.ask(&survey.QuestionOptions{
+		Question:     question,
+		DefaultValue: formatBool(defaultValue),
+		Options:      []string{wizardYes, wizardNo},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return answer == wizardYes, nil
+}
+
+// askSwitch asks for an enabled switch, which is either a boolean or "auto", "true" or "false"
+func (w *createWizard) askSwitch(path string) error {
+	property := w.schema.Lookup(path)
+	if property == nil {
+		return fmt.Errorf("unknown config path %s", path)
+	}
+
+	if !property.OneOfTypes("string", "boolean") {
+		enabled, err := w.askBool(w.question(path, property), w.boolValue(path))
+		if err != nil {
+			return err
+		}
+
+		w.setAnswer(path, enabled)
+		return nil
+	}
+
+	answer, err := w.ask(&survey.QuestionOptions{
+		Question:     w.question(path, property),
+		DefaultValue: w.stringValue(path),
+		Options:      []string{"auto", "true", "false"},
+	})
+	if err != nil {
+		return err
+	}
+
+	w.setAnswer(path, answer)
+	return nil
+}
+
+func (w *createWizard) askString(path string, required bool) (string, error) {
+	property := w.schema.Lookup(path)
+	if property == nil {
+		return "", fmt.Errorf("unknown config path %s", path)
+	}
+
+	answer, err := w.ask(&survey.QuestionOptions{
+		Question:     w.question(path, property),
+		DefaultValue: w.stringValue(path),
+		ValidationFunc: func(value string) error {
+			if required && strings.TrimSpace(value) == "" {
+				return fmt.Errorf("%s is required", path)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	answer = strings.TrimSpace(answer)
+	w.setAnswer(path, answer)
+	return answer, nil
+}
+
+func (w *createWizard) askInteger(path string) (int, error) {
+	property := w.schema.Lookup(path)
+	if property == nil {
+		return 0, fmt.Errorf("unknown config path %s", path)
+	}
+
+	answer, err := w.ask(&survey.QuestionOptions{
+		Question:     w.question(path, property),
+		DefaultValue: w.stringValue(path),
+		ValidationFunc: func(value string) error {
+			_, err := strconv.Atoi(value)
+			return err
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.Atoi(answer)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	w.setAnswer(path, value)
+	return value, nil
+}
+
+// question builds the question out of the first sentence of the schema description
+func (w *createWizard) question(path string, property *schemaProperty) string {
+	description, _, _ := strings.Cut(property.Description, "\n")
+	if description == "" {
+		return path
+	}
+
+	return fmt.Sprintf("%s (%s)", description, path)
+}
+
+// enableOne enables the option at prefix.selected.enabled and disables all other options. The fallback
+// option is used by vCluster if no option is enabled, so it is not enabled explicitly.
+func (w *createWizard) enableOne(prefix string, options []string, selected, fallback string) {
+	for _, option := range options {
+		w.setAnswer(prefix+"."+option+".enabled", option == selected && option != fallback)
+	}
+}
+
+// setAnswer sets the value at path, values that equal the defaults are removed from the answers
+func (w *createWizard) setAnswer(path string, value interface{}) {
+	defaultValue := lookupValue(w.defaults, path)
+	if fmt.Sprint(value) == fmt.Sprint(defaultValue) || (defaultValue == nil && isZeroConfigValue(value)) {
+		deleteValue(w.answers, path)
+		return
+	}
+
+	setValue(w.answers, path, value)
+}
+
+func (w *createWizard) value(path string) interface{} {
+	if value := lookupValue(w.answers, path); value != nil {
+		return value
+	}
+
+	return lookupValue(w.defaults, path)
+}
+
+func (w *createWizard) boolValue(path string) bool {
+	value, _ := w.value(path).(bool)
+	return value
+}
+
+func (w *createWizard) stringValue(path string) string {
+	value := w.value(path)
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		return strconv.FormatBool(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func formatBool(value bool) string {
+	if value {
+		return wizardYes
+	}
+
+	return wizardNo
+}
+
+func lookupValue(values map[string]interface{}, path string) interface{} {
+	var current interface{} = values
+	for _, segment := range strings.Split(path, ".") {
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = currentMap[segment]
+	}
+
+	return current
+}
+
+func setValue(values map[string]interface{}, path string, value interface{}) {
+	segments := strings.Split(path, ".")
+	for _, segment := range segments[:len(segments)-1] {
+		next, ok := values[segment].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			values[segment] = next
+		}
+		values = next
+	}
+
+	values[segments[len(segments)-1]] = value
+}
+
+// deleteValue removes the value at path and all parents that are empty afterwards
+func deleteValue(values map[string]interface{}, path string) {
+	segment, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		delete(values, segment)
+		return
+	}
+
+	child, ok := values[segment].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	deleteValue(child, rest)
+	if len(child) == 0 {
+		delete(values, segment)
+	}
+}
+
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if child, ok := value.(map[string]interface{}); ok {
+			value = copyValues(child)
+		}
+		out[key] = value
+	}
+
+	return out
+}
+
+// valuesSchema is the subset of the generated values JSON schema the create wizard needs
+type valuesSchema struct {
+	schemaProperty
+
+	Defs map[string]*schemaProperty `json:"$defs"`
+
+	// proFeatures is true if pro properties should be returned by ChildrenWith
+	proFeatures bool
+}
+
+type schemaProperty struct {
+	Ref         string                     `json:"$ref,omitempty"`
+	Type        string                     `json:"type,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Properties  map[string]*schemaProperty `json:"properties,omitempty"`
+	OneOf       []*schemaProperty          `json:"oneOf,omitempty"`
+	Pro         bool                       `json:"pro,omitempty"`
+}
+
+// OneOfTypes returns true if the property is exactly one of the given types
+func (p *schemaProperty) OneOfTypes(types ...string) bool {
+	if len(p.OneOf) != len(types) {
+		return false
+	}
+
+	for i, oneOf := range p.OneOf {
+		if oneOf.Type != types[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parseValuesSchema(schema string) (*valuesSchema, error) {
+	out := &valuesSchema{}
+	if err := json.Unmarshal([]byte(schema), out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// Lookup returns the property at the dotted path with all references resolved. The description
+// and pro flag of the referencing property are kept.
+func (s *valuesSchema) Lookup(path string) *schemaProperty {
+	current := &s.schemaProperty
+	for _, segment := range strings.Split(path, ".") {
+		property, ok := current.Properties[segment]
+		if !ok {
+			return nil
+		}
+
+		current = s.resolve(property)
+		if current == nil {
+			return nil
+		}
+	}
+
+	return current
+}
+
+// ChildrenWith returns the sorted names of the properties at path. If field is set, only properties that have
+// the field are returned. Pro properties are left out if the pro features are not available.
+func (s *valuesSchema) ChildrenWith(path, field string) []string {
+	property := s.Lookup(path)
+	if property == nil {
+		return nil
+	}
+
+	children := []string{}
+	for _, name := range slices.Sorted(maps.Keys(property.Properties)) {
+		child := s.resolve(property.Properties[name])
+		if child == nil || (field != "" && child.Properties[field] == nil) {
+			continue
+		} else if (child.Pro || (field != "" && child.Properties[field].Pro)) && !s.proFeatures {
+			continue
+		}
+
+		children = append(children, name)
+	}
+
+	return children
+}
+
+// Deprecated returns true if the description of the property at path marks it as deprecated
+func (s *valuesSchema) Deprecated(path string) bool {
+	property := s.Lookup(path)
+	return property != nil && strings.HasPrefix(property.Description, "[Deprecated]")
+}
+
+func (s *valuesSchema) resolve(property *schemaProperty) *schemaProperty {
+	if property.Ref == "" {
+		return property
+	}
+
+	definition, ok := s.Defs[strings.TrimPrefix(property.Ref, "#/$defs/")]
+	if !ok {
+		return nil
+	}
+
+	resolved := *definition
+	if property.Description != "" {
+		resolved.Description = property.Description
+	}
+	resolved.Pro = resolved.Pro || property.Pro
+	return &resolved
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/log/survey"
+	"github.com/loft-sh/vcluster/config"
+	"gotest.tools/v3/assert"
+)
+
+// scriptedAnswers answers every question that contains a key with the first value of the key,
+// all other questions are answered with their default.
+type scriptedAnswers struct {
+	answers   map[string][]string
+	questions []*survey.QuestionOptions
+}
+
+func (s *scriptedAnswers) ask(question *survey.QuestionOptions) (string, error) {
+	s.questions = append(s.questions, question)
+	for key, answers := range s.answers {
+		if !strings.Contains(question.Question, key) || len(answers) == 0 {
+			continue
+		}
+
+		s.answers[key] = answers[1:]
+		return answers[0], nil
+	}
+
+	return question.DefaultValue, nil
+}
+
+func TestCreateWizard(t *testing.T) {
+	valuesFile := filepath.Join(t.TempDir(), "vcluster.yaml")
+	script := &scriptedAnswers{answers: map[string][]string{
+		"Which Kubernetes distro":                 {"k3s (deprecated)"},
+		"Which backing store":                     {"database.embedded", "etcd.deploy"},
+		"highAvailability.replicas":               {"3", "3"},
+		"synced to the host":                      {"yes"},
+		"synced from the host":                    {"yes"},
+		"sync.toHost.ingresses.enabled":           {"yes"},
+		"sync.fromHost.csiNodes.enabled":          {"false"},
+		"sleepMode.enabled":                       {"yes"},
+		"sleepMode.autoSleep.afterInactivity":     {"1h"},
+		"How should the virtual cluster endpoint": {"Ingress"},
+		"controlPlane.ingress.host":               {"vcluster.example.com"},
+		"Where should the values file be written": {valuesFile},
+		"Create virtual cluster my-vcluster now?": {"no"},
+	}}
+
+	wizard, err := newCreateWizard("my-vcluster", config.Values, script.ask, log.Discard)
+	assert.NilError(t, err)
+
+	result, err := wizard.Run()
+	assert.NilError(t, err)
+	assert.Equal(t, result.ValuesFile, valuesFile)
+	assert.Assert(t, !result.Create)
+
+	// embedded database with 3 replicas is invalid, so the backing store is asked twice
+	backingStoreQuestions := 0
+	for _, question := range script.questions {
+		if strings.HasPrefix(question.Question, "Which backing store") {
+			backingStoreQuestions++
+			// pro stores are not offered
+			assert.Assert(t, !strings.Contains(strings.Join(question.Options, ","), "etcd.embedded"))
+		}
+	}
+	assert.Equal(t, backingStoreQuestions, 2)
+
+	out, err := os.ReadFile(valuesFile)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), `controlPlane:
+  backingStore:
+    etcd:
+      deploy:
+        enabled: true
+  distro:
+    k3s:
+      enabled: true
+  ingress:
+    enabled: true
+    host: vcluster.example.com
+  statefulSet:
+    highAvailability:
+      replicas: 3
+sleepMode:
+  autoSleep:
+    afterInactivity: 1h
+  enabled: true
+sync:
+  fromHost:
+    csiNodes:
+      enabled: "false"
+  toHost:
+    ingresses:
+      enabled: true
+`)
+
+	parsed := &config.Config{}
+	assert.NilError(t, config.UnmarshalYAMLStrict([]byte(config.Values), parsed))
+	assert.NilError(t, config.UnmarshalYAMLStrict(out, parsed))
+}
+
+func TestCreateWizardOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	existingFile := filepath.Join(dir, "existing.yaml")
+	assert.NilError(t, os.WriteFile(existingFile, []byte("keep: true\n"), 0o600))
+	newFile := filepath.Join(dir, "new.yaml")
+
+	script := &scriptedAnswers{answers: map[string][]string{
+		"Where should the values file be written": {existingFile, newFile},
+		"already exists, overwrite it?":           {"no"},
+	}}
+	wizard, err := newCreateWizard("my-vcluster", config.Values, script.ask, log.Discard)
+	assert.NilError(t, err)
+
+	result, err := wizard.Run()
+	assert.NilError(t, err)
+	assert.Equal(t, result.ValuesFile, newFile)
+	assert.Assert(t, result.Create)
+
+	out, err := os.ReadFile(existingFile)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), "keep: true\n")
+
+	// keeping all defaults results in an empty values file
+	out, err = os.ReadFile(newFile)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), "{}\n")
+}
+
+func TestValuesSchemaLookup(t *testing.T) {
+	schema, err := parseValuesSchema(config.Schema)
+	assert.NilError(t, err)
+
+	property := schema.Lookup("controlPlane.backingStore.database.external.connector")
+	assert.Assert(t, property != nil)
+	assert.Equal(t, property.Type, "string")
+	assert.Assert(t, schema.Lookup("controlPlane.backingStore.unknown") == nil)
+
+	assert.Assert(t, schema.Deprecated("controlPlane.distro.k3s"))
+	assert.Assert(t, !schema.Deprecated("controlPlane.distro.k8s"))
+	assert.DeepEqual(t, schema.ChildrenWith("controlPlane.distro", "enabled"), []string{"k3s", "k8s"})
+	assert.DeepEqual(t, schema.ChildrenWith("controlPlane.backingStore.etcd", "enabled"), []string{"deploy", "external"})
+	schema.proFeatures = true
+	assert.DeepEqual(t, schema.ChildrenWith("controlPlane.backingStore.etcd", "enabled"), []string{"deploy", "embedded", "external"})
+	schema.proFeatures = false
+	assert.Assert(t, schema.Lookup("sync.fromHost.csiNodes.enabled").OneOfTypes("string", "boolean"))
+}
+
+func TestSetAnswer(t *testing.T) {
+	wizard := &createWizard{
+		defaults: map[string]interface{}{"a": map[string]interface{}{"b": true, "c": float64(1)}},
+		answers:  map[string]interface{}{},
+	}
+
+	wizard.setAnswer("a.b", false)
+	wizard.setAnswer("a.c", 1)
+	wizard.setAnswer("d.e", "")
+	assert.DeepEqual(t, wizard.answers, map[string]interface{}{"a": map[string]interface{}{"b": false}})
+
+	wizard.setAnswer("a.b", true)
+	assert.DeepEqual(t, wizard.answers, map[string]interface{}{})
+}
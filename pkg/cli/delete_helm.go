@@ -44,6 +44,8 @@ type DeleteOptions struct {
 	AutoDeleteNamespace bool
 	IgnoreNotFound      bool
 	KeepDatabase        bool
+
+	Contexts find.ContextOptions
 }
 
 type deleteHelm struct {
@@ -65,7 +67,18 @@ func DeleteHelm(ctx context.Context, platformClient platform.Client, options *De
 	}
 
 	// find vcluster
-	vCluster, err := find.GetVCluster(ctx, cmd.Context, vClusterName, cmd.Namespace, cmd.log)
+	var (
+		vCluster *find.VCluster
+		err      error
+	)
+	if options.Contexts.Enabled() {
+		vCluster, err = find.GetVClusterInContexts(ctx, &options.Contexts, vClusterName, cmd.Namespace, cmd.log)
+		if err == nil {
+			cmd.Context = vCluster.Context
+		}
+	} else {
+		vCluster, err = find.GetVCluster(ctx, cmd.Context, vClusterName, cmd.Namespace, cmd.log)
+	}
 	if err != nil {
 		if !cmd.IgnoreNotFound {
 			return err
@@ -22,7 +22,6 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/kubectl/pkg/describe"
 	"k8s.io/utils/ptr"
 )
@@ -35,6 +34,7 @@ var (
 type DescribeOutput struct {
 	Name           string            `json:"name,omitempty"`
 	Namespace      string            `json:"namespace,omitempty"`
+	Context        string            `json:"context,omitempty"`
 	Version        string            `json:"version,omitempty"`
 	BackingStore   string            `json:"backingStore,omitempty"`
 	Distro         string            `json:"distro,omitempty"`
@@ -53,6 +53,9 @@ func (do *DescribeOutput) String() string {
 	w := describe.NewPrefixWriter(out)
 	w.Write(describe.LEVEL_0, "Name:\t%s\n", do.Name)
 	w.Write(describe.LEVEL_0, "Namespace:\t%s\n", do.Namespace)
+	if do.Context != "" {
+		w.Write(describe.LEVEL_0, "Context:\t%s\n", do.Context)
+	}
 	w.Write(describe.LEVEL_0, "Version:\t%s\n", do.Version)
 	w.Write(describe.LEVEL_0, "Backing Store:\t%s\n", do.BackingStore)
 	w.Write(describe.LEVEL_0, "Distro:\t%s\n", do.Distro)
@@ -85,23 +88,34 @@ func (do *DescribeOutput) String() string {
 	return buf.String()
 }
 
-func DescribeHelm(ctx context.Context, flags *flags.GlobalFlags, output io.Writer, l log.Logger, name string, configOnly bool, format string) error {
-	kubeClientConf := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{})
-	rawConfig, err := kubeClientConf.RawConfig()
-	if err != nil {
-		return err
+func DescribeHelm(ctx context.Context, flags *flags.GlobalFlags, contexts *find.ContextOptions, output io.Writer, l log.Logger, name string, configOnly bool, format string) error {
+	var (
+		vCluster *find.VCluster
+		err      error
+	)
+	if contexts.Enabled() {
+		vCluster, err = find.GetVClusterInContexts(ctx, contexts, name, flags.Namespace, l)
+	} else {
+		kubeContext := flags.Context
+		if kubeContext == "" {
+			kubeContext, _, err = find.CurrentContext()
+			if err != nil {
+				return err
+			}
+		}
+
+		vCluster, err = find.GetVCluster(ctx, kubeContext, name, flags.Namespace, l)
 	}
-	kubeConfig, err := kubeClientConf.ClientConfig()
 	if err != nil {
 		return err
 	}
 
-	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
+	kubeConfig, err := vCluster.ClientFactory.ClientConfig()
 	if err != nil {
 		return err
 	}
 
-	vCluster, err := find.GetVCluster(ctx, rawConfig.CurrentContext, name, flags.Namespace, l)
+	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
 		return err
 	}
@@ -172,6 +186,9 @@ func DescribeHelm(ctx context.Context, flags *flags.GlobalFlags, output io.Write
 		Images:         getImagesFromConfig(conf, vCluster.Version),
 		UserConfigYaml: userConfigYaml,
 	}
+	if contexts.Enabled() {
+		describeOutput.Context = vCluster.Context
+	}
 
 	return writeWithFormat(output, format, describeOutput)
 }
@@ -0,0 +1,553 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	vclusterconfig "github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/pkg/certs"
+	clicerts "github.com/loft-sh/vcluster/pkg/cli/certs"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	"github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/util/translate"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// restartWarningThreshold is the number of container restarts from which on a control plane pod is reported
+	restartWarningThreshold = 3
+
+	// certificateWarningPeriod is the time before the expiry of a certificate from which on it is reported
+	certificateWarningPeriod = 30 * 24 * time.Hour
+
+	// pendingPodGracePeriod is the time a pod can be pending before it is reported
+	pendingPodGracePeriod = 2 * time.Minute
+
+	// maxDetails is the maximum number of details that are printed per check
+	maxDetails = 10
+
+	// backingStoreLogLines is the number of syncer log lines that are searched for backing store errors
+	backingStoreLogLines = 500
+)
+
+// backingStoreErrors matches log lines of failed connections to etcd or the external database.
+var backingStoreErrors = regexp.MustCompile(`(?i)(etcd|kine|database|datastore|mysql|postgres).*(connection refused|no such host|i/o timeout|context deadline exceeded|access denied|authentication failed|too many connections)`)
+
+func checkControlPlane(_ context.Context, c *checkContext) Result {
+	return controlPlaneResult(c.vCluster.Status, c.vCluster.Pods)
+}
+
+func controlPlaneResult(status find.Status, pods []corev1.Pod) Result {
+	if status == find.StatusPaused {
+		return warn("vCluster is paused", "Resume the vCluster with vcluster resume")
+	} else if len(pods) == 0 {
+		return fail("no control plane pods found", "Check the events of the vCluster StatefulSet or Deployment with kubectl describe")
+	}
+
+	var notReady, restarts []string
+	for _, pod := range pods {
+		if !isPodReady(&pod) {
+			notReady = append(notReady, fmt.Sprintf("%s is %s: %s", pod.Name, pod.Status.Phase, podProblem(&pod)))
+		}
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			if containerStatus.RestartCount < restartWarningThreshold {
+				continue
+			}
+
+			detail := fmt.Sprintf("container %s of %s restarted %d times", containerStatus.Name, pod.Name, containerStatus.RestartCount)
+			if terminated := containerStatus.LastTerminationState.Terminated; terminated != nil {
+				detail += fmt.Sprintf(", last exit: %s (exit code %d)", terminated.Reason, terminated.ExitCode)
+			}
+			restarts = append(restarts, detail)
+		}
+	}
+
+	if len(notReady) > 0 {
+		return fail(fmt.Sprintf("%d of %d control plane pods are not ready", len(notReady), len(pods)), "Check the control plane logs with kubectl logs -c syncer", limitDetails(append(notReady, restarts...))...)
+	} else if len(restarts) > 0 {
+		return warn("control plane containers are restarting", "Check the logs of the previous container with kubectl logs --previous -c syncer", limitDetails(restarts)...)
+	}
+
+	return pass(fmt.Sprintf("%d control plane pods are ready", len(pods)))
+}
+
+func checkBackingStore(ctx context.Context, c *checkContext) Result {
+	if c.vConfig == nil {
+		return skip(fmt.Sprintf("couldn't read vCluster config: %v", c.vConfigError))
+	}
+
+	storeType := c.vConfig.BackingStoreType()
+	switch storeType {
+	case vclusterconfig.StoreTypeEmbeddedEtcd, vclusterconfig.StoreTypeEmbeddedDatabase:
+		if c.vCluster.Status != find.StatusRunning {
+			return skip(fmt.Sprintf("%s runs inside the control plane, which has status %s", storeType, c.vCluster.Status))
+		}
+
+		// the storage readiness check of the virtual API server covers embedded etcd as well as kine
+		if c.virtualClient != nil {
+			_, err := c.virtualClient.Discovery().RESTClient().Get().AbsPath("/readyz/etcd").DoRaw(ctx)
+			if err == nil || kerrors.IsInternalError(err) {
+				return embeddedBackingStoreResult(storeType, err)
+			}
+		}
+
+		return backingStoreLogsResult(ctx, c, storeType)
+	case vclusterconfig.StoreTypeDeployedEtcd:
+		pods, err := c.hostClient.CoreV1().Pods(c.vCluster.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: "app=vcluster-etcd,release=" + c.vCluster.Name,
+		})
+		if err != nil {
+			return fail(fmt.Sprintf("list etcd pods: %v", err), "")
+		}
+
+		return deployedEtcdResult(pods.Items)
+	default:
+		return backingStoreLogsResult(ctx, c, storeType)
+	}
+}
+
+func embeddedBackingStoreResult(storeType vclusterconfig.StoreType, readyErr error) Result {
+	if readyErr != nil {
+		return fail(fmt.Sprintf("the virtual API server reports the %s as not ready", storeType), "Check the control plane logs with kubectl logs -c syncer", readyErr.Error())
+	}
+
+	return pass(fmt.Sprintf("the virtual API server reports the %s as ready", storeType))
+}
+
+func backingStoreLogsResult(ctx context.Context, c *checkContext, storeType vclusterconfig.StoreType) Result {
+	if len(c.vCluster.Pods) == 0 {
+		return skip(fmt.Sprintf("%s can't be checked without a control plane pod", storeType))
+	}
+
+	pod := c.vCluster.Pods[0]
+	logs, err := c.hostClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: "syncer",
+		TailLines: ptr.To[int64](backingStoreLogLines),
+	}).DoRaw(ctx)
+	if err != nil {
+		return skip(fmt.Sprintf("couldn't read control plane logs: %v", err))
+	}
+
+	return backingStoreLogResult(storeType, string(logs))
+}
+
+func deployedEtcdResult(pods []corev1.Pod) Result {
+	if len(pods) == 0 {
+		return fail("no etcd pods found", "Check the events of the etcd StatefulSet with kubectl describe statefulset")
+	}
+
+	var notReady []string
+	for _, pod := range pods {
+		if !isPodReady(&pod) {
+			notReady = append(notReady, fmt.Sprintf("%s is %s: %s", pod.Name, pod.Status.Phase, podProblem(&pod)))
+		}
+	}
+	if len(notReady) > len(pods)/2 {
+		return fail(fmt.Sprintf("etcd has lost quorum, %d of %d pods are not ready", len(notReady), len(pods)), "Check the etcd logs with kubectl logs", limitDetails(notReady)...)
+	} else if len(notReady) > 0 {
+		return warn(fmt.Sprintf("%d of %d etcd pods are not ready", len(notReady), len(pods)), "Check the etcd logs with kubectl logs", limitDetails(notReady)...)
+	}
+
+	return pass(fmt.Sprintf("%d etcd pods are ready", len(pods)))
+}
+
+func backingStoreLogResult(storeType vclusterconfig.StoreType, logs string) Result {
+	var errors []string
+	for _, line := range strings.Split(logs, "\n") {
+		if backingStoreErrors.MatchString(line) {
+			errors = append(errors, strings.TrimSpace(line))
+		}
+	}
+	if len(errors) > 0 {
+		// the most recent errors are the most relevant ones
+		if len(errors) > maxDetails {
+			errors = errors[len(errors)-maxDetails:]
+		}
+
+		return fail(fmt.Sprintf("control plane logs show connection errors to the %s", storeType), "Check that the backing store endpoint is reachable from the host cluster and the credentials are valid", errors...)
+	}
+
+	return pass(fmt.Sprintf("no connection errors to the %s in the last %d log lines", storeType, backingStoreLogLines))
+}
+
+func checkCertificates(ctx context.Context, c *checkContext) Result {
+	if c.vCluster.Status != find.StatusRunning {
+		return skip(fmt.Sprintf("vCluster has status %s", c.vCluster.Status))
+	}
+
+	infos, err := clicerts.Infos(ctx, c.vCluster)
+	if err != nil {
+		return skip(fmt.Sprintf("couldn't check certificates: %v", err))
+	}
+
+	return certificatesResult(infos, c.now)
+}
+
+func certificatesResult(infos []certs.Info, now time.Time) Result {
+	var expired, expiring []string
+	for _, info := range infos {
+		switch {
+		case info.Status == "EXPIRED" || !info.ExpiryTime.After(now):
+			expired = append(expired, fmt.Sprintf("%s expired on %s", info.Filename, info.ExpiryTime.Format(time.DateOnly)))
+		case info.ExpiryTime.Sub(now) < certificateWarningPeriod:
+			expiring = append(expiring, fmt.Sprintf("%s expires on %s", info.Filename, info.ExpiryTime.Format(time.DateOnly)))
+		}
+	}
+
+	if len(expired) > 0 {
+		return fail(fmt.Sprintf("%d certificates have expired", len(expired)), "Rotate the certificates with vcluster certs rotate", limitDetails(append(expired, expiring...))...)
+	} else if len(expiring) > 0 {
+		return warn(fmt.Sprintf("%d certificates expire within %d days", len(expiring), int(certificateWarningPeriod.Hours()/24)), "Rotate the certificates with vcluster certs rotate", limitDetails(expiring)...)
+	}
+
+	return pass(fmt.Sprintf("%d certificates are valid", len(infos)))
+}
+
+func checkSyncerErrors(ctx context.Context, c *checkContext) Result {
+	if c.virtualClient == nil {
+		return skip(fmt.Sprintf("couldn't connect to the virtual cluster: %v", c.virtualClientError))
+	}
+
+	events, err := c.virtualClient.CoreV1().Events("").List(ctx, metav1.ListOptions{
+		FieldSelector: "type=" + corev1.EventTypeWarning,
+	})
+	if err != nil {
+		return fail(fmt.Sprintf("list virtual cluster events: %v", err), "")
+	}
+
+	return syncerErrorsResult(events.Items)
+}
+
+func syncerErrorsResult(events []corev1.Event) Result {
+	var errors []corev1.Event
+	for _, event := range events {
+		if event.Reason == "SyncError" || event.Reason == "SyncWarning" {
+			errors = append(errors, event)
+		}
+	}
+	if len(errors) == 0 {
+		return pass("no sync errors in the virtual cluster events")
+	}
+
+	sort.SliceStable(errors, func(i, j int) bool {
+		return eventTime(&errors[i]).After(eventTime(&errors[j]))
+	})
+	details := make([]string, 0, len(errors))
+	for _, event := range errors {
+		object := event.InvolvedObject.Kind + " " + event.InvolvedObject.Name
+		if event.InvolvedObject.Namespace != "" {
+			object = event.InvolvedObject.Kind + " " + event.InvolvedObject.Namespace + "/" + event.InvolvedObject.Name
+		}
+		details = append(details, fmt.Sprintf("%s: %s", object, event.Message))
+	}
+
+	return warn(fmt.Sprintf("%d objects couldn't be synced", len(errors)), "Fix the reported objects or the sync config, the syncer retries automatically", limitDetails(details)...)
+}
+
+// checkMappings compares the synced objects in the host namespace with the objects in the virtual cluster.
+// Host objects of deleted virtual objects and running virtual pods without a host pod mean that the name
+// mappings of the syncer are out of sync.
+func checkMappings(ctx context.Context, c *checkContext) Result {
+	if c.virtualClient == nil {
+		return skip(fmt.Sprintf("couldn't connect to the virtual cluster: %v", c.virtualClientError))
+	} else if c.vConfig != nil && c.vConfig.Sync.ToHost.Namespaces.Enabled {
+		return skip("namespace syncing is enabled, objects are synced into multiple host namespaces")
+	}
+
+	hostObjects := []hostObject{}
+	virtualObjects := map[string]map[string]bool{}
+	for kind, list := range mappingLists(c.hostClient, c.virtualClient) {
+		hostList, err := list.host(ctx, c.vCluster.Namespace)
+		if err != nil {
+			return fail(fmt.Sprintf("list host %s: %v", kind, err), "")
+		}
+		virtualList, err := list.virtual(ctx, "")
+		if err != nil {
+			return fail(fmt.Sprintf("list virtual %s: %v", kind, err), "")
+		}
+
+		virtualObjects[kind] = map[string]bool{}
+		for _, obj := range virtualList {
+			virtualObjects[kind][obj.GetNamespace()+"/"+obj.GetName()] = true
+		}
+		for _, obj := range hostList {
+			hostObjects = append(hostObjects, hostObject{kind: kind, Object: obj})
+		}
+	}
+
+	virtualPods, err := c.virtualClient.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fail(fmt.Sprintf("list virtual pods: %v", err), "")
+	}
+
+	return mappingsResult(hostObjects, virtualObjects, virtualPods.Items)
+}
+
+// hostObject is a synced object in the host namespace.
+type hostObject struct {
+	metav1.Object
+
+	kind string
+}
+
+func mappingsResult(hostObjects []hostObject, virtualObjects map[string]map[string]bool, virtualPods []corev1.Pod) Result {
+	var dangling []string
+	syncedPods := map[string]bool{}
+	for _, hostObject := range hostObjects {
+		annotations := hostObject.GetAnnotations()
+		name, namespace, kind := annotations[translate.NameAnnotation], annotations[translate.NamespaceAnnotation], hostObject.kind
+		if name == "" || annotations[translate.ImportedMarkerAnnotation] == "true" {
+			continue
+		}
+
+		key := namespace + "/" + name
+		if kind == "pods" {
+			syncedPods[key] = true
+		}
+		if !virtualObjects[kind][key] {
+			dangling = append(dangling, fmt.Sprintf("host %s %s belongs to virtual %s %s, which doesn't exist", kind, hostObject.GetName(), kind, key))
+		}
+	}
+
+	var missing []string
+	for _, pod := range virtualPods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if !syncedPods[pod.Namespace+"/"+pod.Name] {
+			missing = append(missing, fmt.Sprintf("virtual pod %s/%s is scheduled, but has no host pod", pod.Namespace, pod.Name))
+		}
+	}
+
+	if len(missing) > 0 {
+		return fail(fmt.Sprintf("%d virtual pods are missing in the host cluster", len(missing)), "Restart the vCluster control plane to rebuild the name mappings", limitDetails(append(missing, dangling...))...)
+	} else if len(dangling) > 0 {
+		return warn(fmt.Sprintf("%d host objects have no virtual object", len(dangling)), "The syncer deletes them on the next resync, restart the vCluster control plane if they remain", limitDetails(dangling)...)
+	}
+
+	return pass(fmt.Sprintf("%d synced host objects match the virtual cluster", len(hostObjects)))
+}
+
+type mappingList struct {
+	host    func(ctx context.Context, namespace string) ([]metav1.Object, error)
+	virtual func(ctx context.Context, namespace string) ([]metav1.Object, error)
+}
+
+func mappingLists(hostClient, virtualClient kubernetes.Interface) map[string]mappingList {
+	pods := func(client kubernetes.Interface) func(ctx context.Context, namespace string) ([]metav1.Object, error) {
+		return func(ctx context.Context, namespace string) ([]metav1.Object, error) {
+			list, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return toObjects(list.Items), nil
+		}
+	}
+	services := func(client kubernetes.Interface) func(ctx context.Context, namespace string) ([]metav1.Object, error) {
+		return func(ctx context.Context, namespace string) ([]metav1.Object, error) {
+			list, err := client.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return toObjects(list.Items), nil
+		}
+	}
+	pvcs := func(client kubernetes.Interface) func(ctx context.Context, namespace string) ([]metav1.Object, error) {
+		return func(ctx context.Context, namespace string) ([]metav1.Object, error) {
+			list, err := client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return toObjects(list.Items), nil
+		}
+	}
+
+	return map[string]mappingList{
+		"pods":                   {host: pods(hostClient), virtual: pods(virtualClient)},
+		"services":               {host: services(hostClient), virtual: services(virtualClient)},
+		"persistentvolumeclaims": {host: pvcs(hostClient), virtual: pvcs(virtualClient)},
+	}
+}
+
+func toObjects[T any, PT interface {
+	*T
+	metav1.Object
+}](items []T) []metav1.Object {
+	objects := make([]metav1.Object, 0, len(items))
+	for i := range items {
+		objects = append(objects, PT(&items[i]))
+	}
+	return objects
+}
+
+func checkCoreDNS(ctx context.Context, c *checkContext) Result {
+	if c.vConfig != nil && !c.vConfig.ControlPlane.CoreDNS.Enabled {
+		return skip("coredns is disabled")
+	} else if c.vConfig != nil && c.vConfig.ControlPlane.CoreDNS.Embedded {
+		return skip("coredns is embedded into the control plane")
+	} else if c.virtualClient == nil {
+		return skip(fmt.Sprintf("couldn't connect to the virtual cluster: %v", c.virtualClientError))
+	}
+
+	pods, err := c.virtualClient.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{LabelSelector: "k8s-app=kube-dns"})
+	if err != nil {
+		return fail(fmt.Sprintf("list coredns pods: %v", err), "")
+	}
+
+	return coreDNSResult(pods.Items)
+}
+
+func coreDNSResult(pods []corev1.Pod) Result {
+	if len(pods) == 0 {
+		return fail("no coredns pods found in kube-system", "Check the coredns deployment with kubectl -n kube-system describe deployment coredns inside the vCluster")
+	}
+
+	var notReady []string
+	for _, pod := range pods {
+		if !isPodReady(&pod) {
+			notReady = append(notReady, fmt.Sprintf("%s is %s: %s", pod.Name, pod.Status.Phase, podProblem(&pod)))
+		}
+	}
+	if len(notReady) == len(pods) {
+		return fail("no coredns pod is ready, DNS resolution inside the vCluster doesn't work", "Check the coredns pods and the pending-pods check", limitDetails(notReady)...)
+	} else if len(notReady) > 0 {
+		return warn(fmt.Sprintf("%d of %d coredns pods are not ready", len(notReady), len(pods)), "Check the coredns pods and the pending-pods check", limitDetails(notReady)...)
+	}
+
+	return pass(fmt.Sprintf("%d coredns pods are ready", len(pods)))
+}
+
+func checkPendingPods(ctx context.Context, c *checkContext) Result {
+	if c.virtualClient == nil {
+		return skip(fmt.Sprintf("couldn't connect to the virtual cluster: %v", c.virtualClientError))
+	}
+
+	virtualPods, err := c.virtualClient.CoreV1().Pods("").List(ctx, metav1.ListOptions{FieldSelector: "status.phase=Pending"})
+	if err != nil {
+		return fail(fmt.Sprintf("list virtual pods: %v", err), "")
+	}
+	hostPods, err := c.hostClient.CoreV1().Pods(c.vCluster.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fail(fmt.Sprintf("list host pods: %v", err), "")
+	}
+	events, err := c.virtualClient.CoreV1().Events("").List(ctx, metav1.ListOptions{FieldSelector: "involvedObject.kind=Pod,type=" + corev1.EventTypeWarning})
+	if err != nil {
+		return fail(fmt.Sprintf("list virtual cluster events: %v", err), "")
+	}
+
+	return pendingPodsResult(virtualPods.Items, hostPods.Items, events.Items, c.now)
+}
+
+func pendingPodsResult(virtualPods, hostPods []corev1.Pod, events []corev1.Event, now time.Time) Result {
+	hostPodsByVirtual := map[string]*corev1.Pod{}
+	for i := range hostPods {
+		annotations := hostPods[i].Annotations
+		if annotations[translate.NameAnnotation] != "" {
+			hostPodsByVirtual[annotations[translate.NamespaceAnnotation]+"/"+annotations[translate.NameAnnotation]] = &hostPods[i]
+		}
+	}
+	syncErrors := map[string]string{}
+	for _, event := range events {
+		if event.Reason == "SyncError" {
+			syncErrors[event.InvolvedObject.Namespace+"/"+event.InvolvedObject.Name] = event.Message
+		}
+	}
+
+	var pending []string
+	for _, pod := range virtualPods {
+		if pod.Status.Phase != corev1.PodPending || now.Sub(pod.CreationTimestamp.Time) < pendingPodGracePeriod {
+			continue
+		}
+
+		key := pod.Namespace + "/" + pod.Name
+		hostPod, ok := hostPodsByVirtual[key]
+		switch {
+		case ok:
+			pending = append(pending, fmt.Sprintf("%s (host pod %s): %s", key, hostPod.Name, podProblem(hostPod)))
+		case syncErrors[key] != "":
+			pending = append(pending, fmt.Sprintf("%s is not synced to the host: %s", key, syncErrors[key]))
+		default:
+			pending = append(pending, fmt.Sprintf("%s is not synced to the host: %s", key, podProblem(&pod)))
+		}
+	}
+
+	if len(pending) > 0 {
+		sort.Strings(pending)
+		return warn(fmt.Sprintf("%d pods are pending for more than %s", len(pending), pendingPodGracePeriod), "Check the host pod with kubectl describe pod in the vCluster host namespace", limitDetails(pending)...)
+	}
+
+	return pass("no pods are pending")
+}
+
+func getVClusterConfig(ctx context.Context, hostClient kubernetes.Interface, vCluster *find.VCluster) (*config.VirtualClusterConfig, error) {
+	configSecret, err := hostClient.CoreV1().Secrets(vCluster.Namespace).Get(ctx, "vc-config-"+vCluster.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	configBytes := configSecret.Data["config.yaml"]
+	if configBytes == nil {
+		return nil, fmt.Errorf("secret %s has no config.yaml", configSecret.Name)
+	}
+
+	return config.ParseConfigBytes(configBytes, vCluster.Name, nil)
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// podProblem returns the most specific reason why a pod is not ready.
+func podProblem(pod *corev1.Pod) string {
+	for _, containerStatus := range append(slices.Clone(pod.Status.InitContainerStatuses), pod.Status.ContainerStatuses...) {
+		if waiting := containerStatus.State.Waiting; waiting != nil && waiting.Reason != "" && waiting.Reason != "PodInitializing" {
+			return strings.TrimSuffix(fmt.Sprintf("container %s is waiting: %s %s", containerStatus.Name, waiting.Reason, waiting.Message), " ")
+		}
+		if terminated := containerStatus.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			return fmt.Sprintf("container %s terminated: %s (exit code %d)", containerStatus.Name, terminated.Reason, terminated.ExitCode)
+		}
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue && condition.Reason != "" {
+			return strings.TrimSuffix(fmt.Sprintf("%s %s", condition.Reason, condition.Message), " ")
+		}
+	}
+	if pod.Status.Reason != "" {
+		return pod.Status.Reason
+	}
+
+	return "no reason reported"
+}
+
+func eventTime(event *corev1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	} else if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+
+	return event.CreationTimestamp.Time
+}
+
+func limitDetails(details []string) []string {
+	if len(details) <= maxDetails {
+		return details
+	}
+
+	return append(details[:maxDetails:maxDetails], fmt.Sprintf("... and %d more", len(details)-maxDetails))
+}
@@ -0,0 +1,205 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	vclusterconfig "github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/pkg/certs"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	"github.com/loft-sh/vcluster/pkg/util/translate"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func readyPod(name string, ready bool) corev1.Pod {
+	status := corev1.ConditionTrue
+	if !ready {
+		status = corev1.ConditionFalse
+	}
+
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "vcluster"},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+		},
+	}
+}
+
+func TestControlPlaneResult(t *testing.T) {
+	assert.Equal(t, controlPlaneResult(find.StatusRunning, []corev1.Pod{readyPod("vcluster-0", true)}).Status, StatusPass)
+	assert.Equal(t, controlPlaneResult(find.StatusPaused, nil).Status, StatusWarn)
+	assert.Equal(t, controlPlaneResult(find.StatusUnknown, nil).Status, StatusFail)
+
+	crashing := readyPod("vcluster-0", false)
+	crashing.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:                 "syncer",
+		RestartCount:         5,
+		State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}},
+	}}
+	result := controlPlaneResult(find.StatusRunning, []corev1.Pod{crashing})
+	assert.Equal(t, result.Status, StatusFail)
+	assert.DeepEqual(t, result.Details, []string{
+		"vcluster-0 is Running: container syncer is waiting: CrashLoopBackOff",
+		"container syncer of vcluster-0 restarted 5 times, last exit: Error (exit code 1)",
+	})
+
+	restarted := readyPod("vcluster-0", true)
+	restarted.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "syncer", RestartCount: 3}}
+	assert.Equal(t, controlPlaneResult(find.StatusRunning, []corev1.Pod{restarted}).Status, StatusWarn)
+}
+
+func TestDeployedEtcdResult(t *testing.T) {
+	assert.Equal(t, deployedEtcdResult(nil).Status, StatusFail)
+	assert.Equal(t, deployedEtcdResult([]corev1.Pod{readyPod("etcd-0", true), readyPod("etcd-1", true), readyPod("etcd-2", false)}).Status, StatusWarn)
+	assert.Equal(t, deployedEtcdResult([]corev1.Pod{readyPod("etcd-0", true), readyPod("etcd-1", false), readyPod("etcd-2", false)}).Status, StatusFail)
+}
+
+func TestEmbeddedBackingStoreResult(t *testing.T) {
+	assert.Equal(t, embeddedBackingStoreResult(vclusterconfig.StoreTypeEmbeddedEtcd, nil).Status, StatusPass)
+
+	result := embeddedBackingStoreResult(vclusterconfig.StoreTypeEmbeddedDatabase, kerrors.NewInternalError(errors.New("[-]etcd failed: reason withheld")))
+	assert.Equal(t, result.Status, StatusFail)
+	assert.Equal(t, len(result.Details), 1)
+}
+
+func TestBackingStoreLogResult(t *testing.T) {
+	logs := `2025-01-01 INFO started
+2025-01-01 ERROR kine: failed to connect to database: dial tcp 10.0.0.1:5432: connect: connection refused
+`
+	result := backingStoreLogResult(vclusterconfig.StoreTypeExternalDatabase, logs)
+	assert.Equal(t, result.Status, StatusFail)
+	assert.Equal(t, len(result.Details), 1)
+	assert.Equal(t, backingStoreLogResult(vclusterconfig.StoreTypeExternalEtcd, "INFO all good\n").Status, StatusPass)
+}
+
+func TestCertificatesResult(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, certificatesResult([]certs.Info{{Filename: "apiserver.crt", ExpiryTime: now.AddDate(1, 0, 0)}}, now).Status, StatusPass)
+
+	result := certificatesResult([]certs.Info{{Filename: "apiserver.crt", ExpiryTime: now.AddDate(0, 0, 10)}}, now)
+	assert.Equal(t, result.Status, StatusWarn)
+	assert.DeepEqual(t, result.Details, []string{"apiserver.crt expires on 2025-01-11"})
+
+	assert.Equal(t, certificatesResult([]certs.Info{{Filename: "ca.crt", ExpiryTime: now.AddDate(0, 0, -1), Status: "EXPIRED"}}, now).Status, StatusFail)
+}
+
+func TestSyncerErrorsResult(t *testing.T) {
+	assert.Equal(t, syncerErrorsResult([]corev1.Event{{Reason: "BackOff"}}).Status, StatusPass)
+
+	result := syncerErrorsResult([]corev1.Event{{
+		Reason:         "SyncError",
+		Message:        "Error syncing: admission webhook denied the request",
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "nginx"},
+	}})
+	assert.Equal(t, result.Status, StatusWarn)
+	assert.DeepEqual(t, result.Details, []string{"Pod default/nginx: Error syncing: admission webhook denied the request"})
+}
+
+func TestMappingsResult(t *testing.T) {
+	synced := func(kind, name, namespace string) hostObject {
+		return hostObject{kind: kind, Object: &metav1.ObjectMeta{
+			Name:        name + "-x-" + namespace + "-x-vcluster",
+			Annotations: map[string]string{translate.NameAnnotation: name, translate.NamespaceAnnotation: namespace},
+		}}
+	}
+	scheduledPod := func(name string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: "node-1"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	virtualObjects := map[string]map[string]bool{
+		"pods":     {"default/nginx": true},
+		"services": {"default/nginx": true},
+	}
+	hostObjects := []hostObject{synced("pods", "nginx", "default"), synced("services", "nginx", "default")}
+	assert.Equal(t, mappingsResult(hostObjects, virtualObjects, []corev1.Pod{scheduledPod("nginx")}).Status, StatusPass)
+
+	result := mappingsResult(append(hostObjects, synced("services", "deleted", "default")), virtualObjects, []corev1.Pod{scheduledPod("nginx")})
+	assert.Equal(t, result.Status, StatusWarn)
+	assert.DeepEqual(t, result.Details, []string{"host services deleted-x-default-x-vcluster belongs to virtual services default/deleted, which doesn't exist"})
+
+	result = mappingsResult(hostObjects, virtualObjects, []corev1.Pod{scheduledPod("nginx"), scheduledPod("missing")})
+	assert.Equal(t, result.Status, StatusFail)
+	assert.DeepEqual(t, result.Details, []string{"virtual pod default/missing is scheduled, but has no host pod"})
+}
+
+func TestCoreDNSResult(t *testing.T) {
+	assert.Equal(t, coreDNSResult(nil).Status, StatusFail)
+	assert.Equal(t, coreDNSResult([]corev1.Pod{readyPod("coredns-1", true)}).Status, StatusPass)
+	assert.Equal(t, coreDNSResult([]corev1.Pod{readyPod("coredns-1", true), readyPod("coredns-2", false)}).Status, StatusWarn)
+	assert.Equal(t, coreDNSResult([]corev1.Pod{readyPod("coredns-1", false)}).Status, StatusFail)
+}
+
+func TestPendingPodsResult(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	pending := func(name string, age time.Duration) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: metav1.NewTime(now.Add(-age))},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		}
+	}
+	hostPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "unschedulable-x-default-x-vcluster",
+			Annotations: map[string]string{translate.NameAnnotation: "unschedulable", translate.NamespaceAnnotation: "default"},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Reason:  "Unschedulable",
+				Message: "0/3 nodes are available: 3 Insufficient cpu.",
+			}},
+		},
+	}
+	events := []corev1.Event{{
+		Reason:         "SyncError",
+		Message:        "Error syncing: pods is forbidden",
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "forbidden"},
+	}}
+
+	result := pendingPodsResult([]corev1.Pod{
+		pending("new", time.Second),
+		pending("unschedulable", time.Hour),
+		pending("forbidden", time.Hour),
+	}, []corev1.Pod{hostPod}, events, now)
+	assert.Equal(t, result.Status, StatusWarn)
+	assert.DeepEqual(t, result.Details, []string{
+		"default/forbidden is not synced to the host: Error syncing: pods is forbidden",
+		"default/unschedulable (host pod unschedulable-x-default-x-vcluster): Unschedulable 0/3 nodes are available: 3 Insufficient cpu.",
+	})
+
+	assert.Equal(t, pendingPodsResult([]corev1.Pod{pending("new", time.Second)}, nil, nil, now).Status, StatusPass)
+}
+
+func TestRunChecks(t *testing.T) {
+	hostClient := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "vcluster-etcd-0", Namespace: "vcluster", Labels: map[string]string{"app": "vcluster-etcd", "release": "vcluster"}},
+		Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}},
+	})
+	c := &checkContext{
+		vCluster:           &find.VCluster{Name: "vcluster", Namespace: "vcluster", Status: find.StatusUnknown},
+		hostClient:         hostClient,
+		now:                time.Now(),
+		vConfigError:       context.DeadlineExceeded,
+		virtualClientError: context.DeadlineExceeded,
+	}
+
+	report := runChecks(context.Background(), c, checks)
+	assert.Equal(t, len(report.Results), len(checks))
+	assert.Equal(t, report.Results[0].Check, "control-plane")
+	assert.Equal(t, report.Results[0].Status, StatusFail)
+	assert.DeepEqual(t, report.Summary, Summary{Fail: 1, Skip: len(checks) - 1})
+}
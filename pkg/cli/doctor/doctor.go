@@ -0,0 +1,229 @@
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/log/table"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/util/clihelper"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Status is the outcome of a single check.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// Result is the result of a single check.
+type Result struct {
+	Check   string   `json:"check"`
+	Status  Status   `json:"status"`
+	Message string   `json:"message"`
+	Details []string `json:"details,omitempty"`
+	Hint    string   `json:"hint,omitempty"`
+}
+
+// Report holds the results of all checks of a vCluster.
+type Report struct {
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	Results   []Result `json:"results"`
+	Summary   Summary  `json:"summary"`
+}
+
+// Summary counts the results by status.
+type Summary struct {
+	Pass int `json:"pass"`
+	Warn int `json:"warn"`
+	Fail int `json:"fail"`
+	Skip int `json:"skip"`
+}
+
+// Options holds the doctor cmd options
+type Options struct {
+	Output string
+}
+
+// checkContext holds the clients and configuration that are shared by all checks. The virtual client and config
+// are nil if they couldn't be loaded, checks that need them are skipped in this case.
+type checkContext struct {
+	vCluster      *find.VCluster
+	hostClient    kubernetes.Interface
+	hostConfig    *rest.Config
+	virtualClient kubernetes.Interface
+	vConfig       *config.VirtualClusterConfig
+	now           time.Time
+
+	// virtualClientError and vConfigError explain why the virtual client or config are missing
+	virtualClientError error
+	vConfigError       error
+}
+
+type check struct {
+	name string
+	run  func(ctx context.Context, c *checkContext) Result
+}
+
+var checks = []check{
+	{name: "control-plane", run: checkControlPlane},
+	{name: "backing-store", run: checkBackingStore},
+	{name: "certificates", run: checkCertificates},
+	{name: "syncer-errors", run: checkSyncerErrors},
+	{name: "mappings", run: checkMappings},
+	{name: "coredns", run: checkCoreDNS},
+	{name: "pending-pods", run: checkPendingPods},
+}
+
+// Run runs all checks against the vCluster and prints the results.
+func Run(ctx context.Context, vClusterName string, globalFlags *flags.GlobalFlags, options *Options, log log.Logger) error {
+	if options.Output != "" && options.Output != "table" && options.Output != "json" {
+		return fmt.Errorf("unsupported output format %q, expected table or json", options.Output)
+	}
+
+	vCluster, err := find.GetVCluster(ctx, globalFlags.Context, vClusterName, globalFlags.Namespace, log)
+	if err != nil {
+		return err
+	}
+
+	c, err := newCheckContext(ctx, vCluster, log)
+	if err != nil {
+		return err
+	}
+
+	report := runChecks(ctx, c, checks)
+	if options.Output == "json" {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("json marshal report: %w", err)
+		}
+
+		log.WriteString(logrus.InfoLevel, string(out)+"\n")
+	} else {
+		printReport(report, log)
+	}
+
+	if report.Summary.Fail > 0 {
+		return fmt.Errorf("%d of %d checks failed", report.Summary.Fail, len(report.Results))
+	}
+
+	return nil
+}
+
+func newCheckContext(ctx context.Context, vCluster *find.VCluster, log log.Logger) (*checkContext, error) {
+	hostConfig, err := vCluster.ClientFactory.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	hostClient, err := kubernetes.NewForConfig(hostConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checkContext{
+		vCluster:   vCluster,
+		hostClient: hostClient,
+		hostConfig: hostConfig,
+		now:        time.Now(),
+	}
+
+	c.vConfig, c.vConfigError = getVClusterConfig(ctx, hostClient, vCluster)
+	if vCluster.Status == find.StatusRunning {
+		// the port forwarding output would break the json output
+		portForwardingOptions := clihelper.PortForwardingOptions{
+			StdOut: io.Discard,
+			StdErr: os.Stderr,
+		}
+		virtualConfig, err := clihelper.GetVClusterKubeConfig(ctx, hostConfig, hostClient, vCluster, log.ErrorStreamOnly(), portForwardingOptions)
+		if err == nil {
+			c.virtualClient, err = kubernetes.NewForConfig(virtualConfig)
+		}
+		c.virtualClientError = err
+	} else {
+		c.virtualClientError = fmt.Errorf("vCluster has status %s", vCluster.Status)
+	}
+
+	return c, nil
+}
+
+func runChecks(ctx context.Context, c *checkContext, checks []check) *Report {
+	report := &Report{
+		Name:      c.vCluster.Name,
+		Namespace: c.vCluster.Namespace,
+	}
+	for _, check := range checks {
+		result := check.run(ctx, c)
+		result.Check = check.name
+		report.Results = append(report.Results, result)
+
+		switch result.Status {
+		case StatusPass:
+			report.Summary.Pass++
+		case StatusWarn:
+			report.Summary.Warn++
+		case StatusFail:
+			report.Summary.Fail++
+		default:
+			report.Summary.Skip++
+		}
+	}
+
+	return report
+}
+
+func printReport(report *Report, log log.Logger) {
+	values := make([][]string, 0, len(report.Results))
+	for _, result := range report.Results {
+		values = append(values, []string{strings.ToUpper(string(result.Status)), result.Check, result.Message})
+	}
+	table.PrintTable(log, []string{"STATUS", "CHECK", "MESSAGE"}, values)
+
+	for _, result := range report.Results {
+		if result.Status != StatusWarn && result.Status != StatusFail {
+			continue
+		}
+
+		out := &strings.Builder{}
+		fmt.Fprintf(out, "%s (%s): %s\n", result.Check, result.Status, result.Message)
+		for _, detail := range result.Details {
+			fmt.Fprintf(out, "  - %s\n", detail)
+		}
+		if result.Hint != "" {
+			fmt.Fprintf(out, "  Hint: %s\n", result.Hint)
+		}
+		log.WriteString(logrus.InfoLevel, "\n"+out.String())
+	}
+
+	log.WriteString(logrus.InfoLevel, fmt.Sprintf("\n%d passed, %d warnings, %d failed, %d skipped\n", report.Summary.Pass, report.Summary.Warn, report.Summary.Fail, report.Summary.Skip))
+}
+
+func pass(message string) Result {
+	return Result{Status: StatusPass, Message: message}
+}
+
+func skip(message string) Result {
+	return Result{Status: StatusSkip, Message: message}
+}
+
+func warn(message, hint string, details ...string) Result {
+	return Result{Status: StatusWarn, Message: message, Hint: hint, Details: details}
+}
+
+func fail(message, hint string, details ...string) Result {
+	return Result{Status: StatusFail, Message: message, Hint: hint, Details: details}
+}
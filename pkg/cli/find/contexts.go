@@ -0,0 +1,212 @@
+package find
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loft-sh/log"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// DefaultContextTimeout is the time a single host context may take to list its vClusters
+const DefaultContextTimeout = 10 * time.Second
+
+// ContextOptions selects the host kube contexts that are searched for vClusters
+type ContextOptions struct {
+	// AllContexts searches all contexts of the kube config
+	AllContexts bool
+	// ContextSelector is a comma separated list of glob patterns, only contexts matching one of them are searched
+	ContextSelector string
+	// ContextTimeout is the timeout per context
+	ContextTimeout time.Duration
+}
+
+// Enabled returns true if more than the current context should be searched
+func (o *ContextOptions) Enabled() bool {
+	return o != nil && (o.AllContexts || o.ContextSelector != "")
+}
+
+// SelectContexts returns the sorted host contexts of the kube config that match the options. Contexts created by
+// vcluster connect are skipped, as they point to virtual clusters instead of host clusters.
+func SelectContexts(kubeConfig *clientcmdapi.Config, options *ContextOptions) ([]string, error) {
+	if kubeConfig == nil {
+		return nil, errors.New("nil kubeconfig")
+	}
+
+	var patterns []string
+	for _, pattern := range strings.Split(options.ContextSelector, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid context selector %q: %w", pattern, err)
+		}
+
+		patterns = append(patterns, pattern)
+	}
+
+	contexts := []string{}
+	for name := range kubeConfig.Contexts {
+		if strings.HasPrefix(name, "vcluster_") || strings.HasPrefix(name, "vcluster-platform_") {
+			continue
+		}
+		if len(patterns) > 0 && !slices.ContainsFunc(patterns, func(pattern string) bool {
+			matched, _ := path.Match(pattern, name)
+			return matched
+		}) {
+			continue
+		}
+
+		contexts = append(contexts, name)
+	}
+	if len(contexts) == 0 {
+		if options.ContextSelector != "" {
+			return nil, fmt.Errorf("no kube context matches the selector %q", options.ContextSelector)
+		}
+
+		return nil, errors.New("no kube contexts found")
+	}
+
+	slices.Sort(contexts)
+	return contexts, nil
+}
+
+// ListVClustersInContexts lists the vClusters of all selected host contexts in parallel. Contexts that fail or
+// don't answer within the context timeout are skipped with a warning, an error is only returned if all of them failed.
+func ListVClustersInContexts(ctx context.Context, options *ContextOptions, name, namespace string, log log.Logger) ([]VCluster, error) {
+	vClusters, failed, err := listVClustersInContexts(ctx, options, name, namespace, log)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, kubeContext := range slices.Sorted(maps.Keys(failed)) {
+		log.Warnf("Skipping context %s: %v", kubeContext, failed[kubeContext])
+	}
+	return vClusters, nil
+}
+
+// listVClustersInContexts lists the vClusters of all selected host contexts in parallel and returns the errors of the
+// contexts that failed by context name. An error is only returned if all of them failed.
+func listVClustersInContexts(ctx context.Context, options *ContextOptions, name, namespace string, log log.Logger) ([]VCluster, map[string]error, error) {
+	_, kubeConfig, err := CurrentContext()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contexts, err := SelectContexts(kubeConfig, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	timeout := options.ContextTimeout
+	if timeout <= 0 {
+		timeout = DefaultContextTimeout
+	}
+
+	results := make([][]VCluster, len(contexts))
+	errs := make([]error, len(contexts))
+	wg := sync.WaitGroup{}
+	for i, kubeContext := range contexts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			results[i], errs[i] = listVClustersWithTimeout(ctx, kubeContext, name, namespace, timeout, log)
+		}()
+	}
+	wg.Wait()
+
+	vClusters := []VCluster{}
+	failed := map[string]error{}
+	for i, kubeContext := range contexts {
+		if errs[i] != nil {
+			failed[kubeContext] = errs[i]
+			continue
+		}
+
+		vClusters = append(vClusters, results[i]...)
+	}
+	if len(failed) == len(contexts) {
+		return nil, nil, fmt.Errorf("couldn't list vclusters in any of the contexts %s", strings.Join(contexts, ", "))
+	}
+
+	slices.SortStableFunc(vClusters, func(a, b VCluster) int {
+		return strings.Compare(a.Context+"/"+a.Namespace+"/"+a.Name, b.Context+"/"+b.Namespace+"/"+b.Name)
+	})
+	return vClusters, failed, nil
+}
+
+// GetVClusterInContexts searches the selected host contexts for a vCluster with the given name. It errors if the
+// name isn't unique or if any of the contexts failed, as prompting between clusters or picking the one that was
+// found would make it too easy to pick the wrong one.
+func GetVClusterInContexts(ctx context.Context, options *ContextOptions, name, namespace string, log log.Logger) (*VCluster, error) {
+	if name == "" {
+		return nil, fmt.Errorf("please specify a name")
+	}
+
+	vClusters, failed, err := listVClustersInContexts(ctx, options, name, namespace, log)
+	if err != nil {
+		return nil, err
+	}
+
+	return uniqueVCluster(vClusters, failed, name)
+}
+
+func uniqueVCluster(vClusters []VCluster, failed map[string]error, name string) (*VCluster, error) {
+	// a vCluster with the same name might exist in a context that failed
+	if len(failed) > 0 {
+		errs := make([]error, 0, len(failed))
+		for _, kubeContext := range slices.Sorted(maps.Keys(failed)) {
+			errs = append(errs, fmt.Errorf("context %s: %w", kubeContext, failed[kubeContext]))
+		}
+
+		return nil, fmt.Errorf("couldn't search all selected contexts for vcluster %s, please fix or exclude them via --context-selector: %w", name, errors.Join(errs...))
+	} else if len(vClusters) == 0 {
+		return nil, &VClusterNotFoundError{Name: name}
+	} else if len(vClusters) > 1 {
+		locations := make([]string, 0, len(vClusters))
+		for _, vCluster := range vClusters {
+			locations = append(locations, fmt.Sprintf("%s (namespace %s)", vCluster.Context, vCluster.Namespace))
+		}
+
+		return nil, fmt.Errorf("vcluster %s is ambiguous, it was found in %s; please select one via --context and --namespace", name, strings.Join(locations, ", "))
+	}
+
+	return &vClusters[0], nil
+}
+
+// listVClustersWithTimeout doesn't wait for ListVClusters to return after the timeout, as not all client calls
+// respect the context and an unreachable cluster would otherwise block the whole listing.
+func listVClustersWithTimeout(ctx context.Context, kubeContext, name, namespace string, timeout time.Duration, log log.Logger) ([]VCluster, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		vClusters []VCluster
+		err       error
+	}
+	resultChan := make(chan result, 1)
+	go func() {
+		vClusters, err := ListVClusters(ctx, kubeContext, name, namespace, log)
+		resultChan <- result{vClusters: vClusters, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("timed out after %s", timeout)
+		}
+
+		return nil, ctx.Err()
+	case r := <-resultChan:
+		return r.vClusters, r.err
+	}
+}
@@ -0,0 +1,58 @@
+package find
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestSelectContexts(t *testing.T) {
+	kubeConfig := &clientcmdapi.Config{
+		Contexts: map[string]*clientcmdapi.Context{
+			"prod-eu":                         {},
+			"prod-us":                         {},
+			"staging":                         {},
+			"kind-dev":                        {},
+			"vcluster_test_vcluster-test_dev": {},
+			"vcluster-platform_test_p_dev":    {},
+		},
+	}
+
+	contexts, err := SelectContexts(kubeConfig, &ContextOptions{AllContexts: true})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, contexts, []string{"kind-dev", "prod-eu", "prod-us", "staging"})
+
+	contexts, err = SelectContexts(kubeConfig, &ContextOptions{ContextSelector: "prod-*, staging"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, contexts, []string{"prod-eu", "prod-us", "staging"})
+
+	_, err = SelectContexts(kubeConfig, &ContextOptions{ContextSelector: "qa-*"})
+	assert.ErrorContains(t, err, "no kube context matches")
+
+	_, err = SelectContexts(kubeConfig, &ContextOptions{ContextSelector: "prod-["})
+	assert.ErrorContains(t, err, "invalid context selector")
+}
+
+func TestUniqueVCluster(t *testing.T) {
+	_, err := uniqueVCluster(nil, nil, "test")
+	var notFound *VClusterNotFoundError
+	assert.Assert(t, errors.As(err, &notFound))
+
+	vCluster, err := uniqueVCluster([]VCluster{{Name: "test", Namespace: "team-a", Context: "prod-eu"}}, nil, "test")
+	assert.NilError(t, err)
+	assert.Equal(t, vCluster.Context, "prod-eu")
+
+	_, err = uniqueVCluster([]VCluster{
+		{Name: "test", Namespace: "team-a", Context: "prod-eu"},
+		{Name: "test", Namespace: "team-a", Context: "prod-us"},
+	}, nil, "test")
+	assert.ErrorContains(t, err, "vcluster test is ambiguous, it was found in prod-eu (namespace team-a), prod-us (namespace team-a)")
+
+	// the vCluster might exist in a context that failed as well
+	_, err = uniqueVCluster([]VCluster{{Name: "test", Namespace: "team-a", Context: "prod-eu"}}, map[string]error{
+		"prod-us": errors.New("timed out after 10s"),
+	}, "test")
+	assert.ErrorContains(t, err, "couldn't search all selected contexts for vcluster test, please fix or exclude them via --context-selector: context prod-us: timed out after 10s")
+}
@@ -30,6 +30,10 @@ func AddCommonFlags(cmd *cobra.Command, options *cli.ConnectOptions) {
 	cmd.Flags().BoolVar(&options.Insecure, "insecure", false, "If specified, vCluster will create the kube config with insecure-skip-tls-verify")
 	cmd.Flags().BoolVar(&options.BackgroundProxy, "background-proxy", true, "Try to use a background-proxy to access the vCluster. Only works if docker is installed and reachable")
 	cmd.Flags().StringVar(&options.BackgroundProxyImage, "background-proxy-image", constants.DefaultBackgroundProxyImage(upgrade.GetVersion()), "The image to use for the background proxy. Only used if --background-proxy is enabled.")
+	cmd.Flags().BoolVar(&options.Background, "background", false, "If true, a user-level connection daemon keeps the port-forwarding to the virtual cluster running and reconnects it automatically. Use \"vcluster connections list\" to see the status")
+	cmd.Flags().StringVar(&options.Expose, "expose", "", fmt.Sprintf("If set, exposes the virtual cluster on the host cluster and connects through it instead of port-forwarding. Allowed values: %s", strings.Join(cli.ExposeTypes, ", ")))
+	cmd.Flags().StringVar(&options.ExposeHost, "expose-host", "", "The host name to expose the virtual cluster under. Required for --expose=ingress")
+	cmd.Flags().StringVar(&options.ExposeIngressClass, "expose-ingress-class", "", "The ingress class to use for --expose=ingress")
 
 	// deprecated
 	_ = cmd.Flags().MarkDeprecated("kube-config", fmt.Sprintf("please use %q to write the kubeconfig of the virtual cluster to stdout.", "vcluster connect --print"))
@@ -0,0 +1,13 @@
+package flags
+
+import (
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	flag "github.com/spf13/pflag"
+)
+
+// AddContextFlags adds the flags to search several host contexts for virtual clusters
+func AddContextFlags(flags *flag.FlagSet, options *find.ContextOptions) {
+	flags.BoolVar(&options.AllContexts, "all-contexts", false, "Search the virtual clusters in all contexts of the kube config")
+	flags.StringVar(&options.ContextSelector, "context-selector", "", "Search the virtual clusters in all contexts of the kube config matching one of these comma separated glob patterns, e.g. prod-*,staging")
+	flags.DurationVar(&options.ContextTimeout, "context-timeout", find.DefaultContextTimeout, "The time after which a context that doesn't respond fails when searching several contexts. Listing skips failed contexts, commands that select a single vCluster by name fail")
+}
@@ -25,6 +25,7 @@ func AddHelmFlags(cmd *cobra.Command, options *cli.DeleteOptions) {
 	cmd.Flags().BoolVar(&options.AutoDeleteNamespace, "auto-delete-namespace", true, "If enabled, vcluster will delete the namespace of the vcluster if it was created by vclusterctl. In the case of multi-namespace mode, will also delete all other namespaces created by vcluster")
 	cmd.Flags().BoolVar(&options.IgnoreNotFound, "ignore-not-found", false, "If enabled, vcluster will not error out in case the target vcluster does not exist")
 	cmd.Flags().BoolVar(&options.KeepDatabase, "keep-database", false, "If enabled, vcluster will keep the external database and user created by the connector (default: auto-cleanup)")
+	flags.AddContextFlags(cmd.Flags(), &options.Contexts)
 }
 
 func AddPlatformFlags(cmd *cobra.Command, options *cli.DeleteOptions, prefixes ...string) {
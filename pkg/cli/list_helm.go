@@ -27,6 +27,7 @@ type ListVCluster struct {
 	Status     string
 	AgeSeconds int
 	Connected  bool
+	Context    string `json:",omitempty"`
 }
 
 // ListProVCluster holds information about a vCluster along with the associated project name
@@ -39,6 +40,8 @@ type ListOptions struct {
 	Driver string
 
 	Output string
+
+	Contexts find.ContextOptions
 }
 
 func ListHelm(ctx context.Context, options *ListOptions, globalFlags *flags.GlobalFlags, log log.Logger) error {
@@ -57,7 +60,12 @@ func ListHelm(ctx context.Context, options *ListOptions, globalFlags *flags.Glob
 		namespace = globalFlags.Namespace
 	}
 
-	vClusters, err := find.ListVClusters(ctx, globalFlags.Context, "", namespace, log.ErrorStreamOnly())
+	var vClusters []find.VCluster
+	if options.Contexts.Enabled() {
+		vClusters, err = find.ListVClustersInContexts(ctx, &options.Contexts, "", namespace, log.ErrorStreamOnly())
+	} else {
+		vClusters, err = find.ListVClusters(ctx, globalFlags.Context, "", namespace, log.ErrorStreamOnly())
+	}
 	if err != nil {
 		return err
 	}
@@ -81,6 +89,12 @@ func printVClusters(ctx context.Context, options *ListOptions, output []ListVClu
 	} else {
 		header := []string{"NAME", "NAMESPACE", "STATUS", "VERSION", "CONNECTED", "AGE"}
 		values := toValues(output)
+		if options.Contexts.Enabled() {
+			header = append([]string{"CONTEXT"}, header...)
+			for i := range values {
+				values[i] = append([]string{output[i].Context}, values[i]...)
+			}
+		}
 		table.PrintTable(logger, header, values)
 
 		platformClient, err := platform.InitClientFromConfig(ctx, globalFlags.LoadedConfig(logger))
@@ -114,6 +128,7 @@ func ossToVClusters(vClusters []find.VCluster, currentContext string) []ListVClu
 			Version:    vCluster.Version,
 			AgeSeconds: int(time.Since(vCluster.Created.Time).Round(time.Second).Seconds()),
 			Status:     string(vCluster.Status),
+			Context:    vCluster.Context,
 		}
 		vClusterOutput.Connected = currentContext == find.VClusterContextName(
 			vCluster.Name,
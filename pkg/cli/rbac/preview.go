@@ -0,0 +1,169 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/log/table"
+	"github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/controllers/rbacmapping"
+	"github.com/loft-sh/vcluster/pkg/util/clihelper"
+	"github.com/sirupsen/logrus"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type PreviewOptions struct {
+	User   string
+	Groups []string
+
+	// Rules resolves the rules of the bound roles within the virtual cluster
+	Rules  bool
+	Output string
+}
+
+// Permission is a virtual role a host identity receives through rbac.identityMappings
+type Permission struct {
+	Subject   string              `json:"subject"`
+	Role      string              `json:"role"`
+	Namespace string              `json:"namespace,omitempty"`
+	Binding   string              `json:"binding"`
+	Rules     []rbacv1.PolicyRule `json:"rules,omitempty"`
+}
+
+// Preview prints the virtual roles the given host user and groups are mapped to
+func Preview(ctx context.Context, vClusterName string, globalFlags *flags.GlobalFlags, options PreviewOptions, log log.Logger) error {
+	if options.User == "" && len(options.Groups) == 0 {
+		return fmt.Errorf("please specify either --user or --group")
+	}
+
+	vCluster, err := find.GetVCluster(ctx, globalFlags.Context, vClusterName, globalFlags.Namespace, log)
+	if err != nil {
+		return err
+	}
+	kubeConfig, err := vCluster.ClientFactory.ClientConfig()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		return err
+	}
+
+	// load the vCluster config
+	configSecret, err := kubeClient.CoreV1().Secrets(vCluster.Namespace).Get(ctx, "vc-config-"+vCluster.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to load the vcluster config: %w", err)
+	}
+	vClusterConfig := &config.Config{}
+	err = yaml.Unmarshal(configSecret.Data["config.yaml"], vClusterConfig)
+	if err != nil {
+		return fmt.Errorf("parse vcluster config: %w", err)
+	}
+
+	mappings := vClusterConfig.RBAC.IdentityMappings
+	permissions := []Permission{}
+	for _, binding := range rbacmapping.DesiredBindings(mappings) {
+		mapping := mappings[binding.Mapping]
+		if !rbacmapping.Matches(mapping, options.User, options.Groups) {
+			continue
+		}
+
+		// only show the subjects that match the given identity
+		subjects := []string{}
+		for _, subject := range binding.Subjects {
+			if (subject.Kind == rbacv1.UserKind && subject.Name == options.User) || (subject.Kind == rbacv1.GroupKind && slices.Contains(options.Groups, subject.Name)) {
+				subjects = append(subjects, subject.Kind+":"+subject.Name)
+			}
+		}
+
+		permissions = append(permissions, Permission{
+			Subject:   strings.Join(subjects, ","),
+			Role:      binding.ClusterRole,
+			Namespace: binding.Namespace,
+			Binding:   binding.Name,
+		})
+	}
+	if len(permissions) == 0 {
+		log.Infof("No identity mapping of vCluster %s matches the given user or groups", vCluster.Name)
+		return nil
+	}
+
+	if options.Rules {
+		vKubeConfig, err := clihelper.GetVClusterKubeConfig(ctx, kubeConfig, kubeClient, vCluster, log, clihelper.PortForwardingOptions{
+			StdOut: io.Discard,
+			StdErr: os.Stderr,
+		})
+		if err != nil {
+			return fmt.Errorf("get virtual cluster config: %w", err)
+		}
+		vKubeClient, err := kubernetes.NewForConfig(vKubeConfig)
+		if err != nil {
+			return err
+		}
+
+		for i := range permissions {
+			clusterRole, err := vKubeClient.RbacV1().ClusterRoles().Get(ctx, permissions[i].Role, metav1.GetOptions{})
+			if err != nil {
+				log.Warnf("Error retrieving cluster role %s: %v", permissions[i].Role, err)
+				continue
+			}
+
+			permissions[i].Rules = clusterRole.Rules
+		}
+	}
+
+	if options.Output == "json" {
+		bytes, err := json.MarshalIndent(permissions, "", "    ")
+		if err != nil {
+			return fmt.Errorf("json marshal permissions: %w", err)
+		}
+
+		log.WriteString(logrus.InfoLevel, string(bytes)+"\n")
+		return nil
+	}
+
+	header := []string{"SUBJECT", "ROLE", "NAMESPACE", "BINDING"}
+	if options.Rules {
+		header = append(header, "RULES")
+	}
+	var values [][]string
+	for _, permission := range permissions {
+		namespace := permission.Namespace
+		if namespace == "" {
+			namespace = "*"
+		}
+
+		row := []string{permission.Subject, permission.Role, namespace, permission.Binding}
+		if options.Rules {
+			row = append(row, formatRules(permission.Rules))
+		}
+		values = append(values, row)
+	}
+	table.PrintTable(log, header, values)
+	return nil
+}
+
+func formatRules(rules []rbacv1.PolicyRule) string {
+	formatted := []string{}
+	for _, rule := range rules {
+		resources := rule.Resources
+		if len(rule.NonResourceURLs) > 0 {
+			resources = rule.NonResourceURLs
+		}
+
+		formatted = append(formatted, strings.Join(rule.Verbs, ",")+" "+strings.Join(resources, ","))
+	}
+
+	return strings.Join(formatted, "; ")
+}
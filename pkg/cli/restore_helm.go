@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/kballard/go-shellquote"
 	"github.com/loft-sh/log"
 	"github.com/loft-sh/vcluster/pkg/cli/find"
 	"github.com/loft-sh/vcluster/pkg/cli/flags"
@@ -31,6 +32,30 @@ func Restore(ctx context.Context, args []string, globalFlags *flags.GlobalFlags,
 	return restoreVCluster(ctx, kubeClient, restConfig, vCluster, snapshot, pod, newVCluster, restoreVolumes, log)
 }
 
+// RestoreSelective restores only the selected objects from a snapshot into the running vCluster
+func RestoreSelective(ctx context.Context, args []string, globalFlags *flags.GlobalFlags, snapshotOpts *snapshot.Options, selective *snapshot.SelectiveRestoreOptions, log log.Logger) error {
+	err := selective.Validate()
+	if err != nil {
+		return err
+	}
+
+	// init kube client and vCluster
+	vCluster, _, restConfig, err := initSnapshotCommand(ctx, args, globalFlags, snapshotOpts, log)
+	if err != nil {
+		return err
+	} else if vCluster.Status != find.StatusRunning {
+		return fmt.Errorf("vCluster %s needs to be running for a selective restore, but has status %s", vCluster.Name, vCluster.Status)
+	}
+
+	// the objects are restored through the api server, so there is no need to pause the vCluster
+	command := []string{"/vcluster", "restore"}
+	for _, arg := range selective.Args() {
+		command = append(command, shellquote.Join(arg))
+	}
+
+	return pod.SnapshotExec(ctx, restConfig, command, vCluster, &pod.Options{}, snapshotOpts)
+}
+
 func restoreVCluster(ctx context.Context, kubeClient *kubernetes.Clientset, restConfig *rest.Config, vCluster *find.VCluster, snapshot *snapshot.Options, podOptions *pod.Options, newVCluster bool, restoreVolumes bool, log log.Logger) error {
 	// pause vCluster
 	log.Infof("Pausing vCluster %s", vCluster.Name)
@@ -13,6 +13,7 @@ import (
 	vclusterconfig "github.com/loft-sh/vcluster/pkg/config"
 	"github.com/loft-sh/vcluster/pkg/helm"
 	"github.com/loft-sh/vcluster/pkg/snapshot"
+	"github.com/loft-sh/vcluster/pkg/snapshot/encryption"
 	"github.com/loft-sh/vcluster/pkg/snapshot/pod"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -32,13 +33,33 @@ func CreateSnapshot(ctx context.Context, args []string, globalFlags *flags.Globa
 		return err
 	}
 
+	// set helm release
+	err = setSnapshotRelease(ctx, vCluster, kubeClient, snapshotOpts)
+	if err != nil {
+		return err
+	}
+
+	if !async {
+		// run snapshot pod
+		return pod.RunSnapshotPod(ctx, restConfig, kubeClient, []string{"/vcluster", "snapshot"}, vCluster, podOptions, snapshotOpts, log)
+	}
+
+	// creating snapshot request with 'vcluster snapshot create' command
+	_, err = createSnapshotRequest(ctx, vCluster, kubeClient, snapshotOpts, log)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// setSnapshotRelease embeds the Helm release of the vCluster into the snapshot, so it can be recreated from it.
+func setSnapshotRelease(ctx context.Context, vCluster *find.VCluster, kubeClient kubernetes.Interface, snapshotOpts *snapshot.Options) error {
 	// get vCluster release
 	vClusterRelease, err := helm.NewSecrets(kubeClient).Get(ctx, vCluster.Name, vCluster.Namespace)
 	if err != nil {
 		return fmt.Errorf("failed to get vCluster release: %w", err)
 	}
 
-	// set helm release
 	if vClusterRelease != nil && vClusterRelease.Chart != nil && vClusterRelease.Chart.Metadata != nil {
 		values, _ := yaml.Marshal(vClusterRelease.Config)
 		snapshotOpts.Release = &snapshot.HelmRelease{
@@ -50,16 +71,6 @@ func CreateSnapshot(ctx context.Context, args []string, globalFlags *flags.Globa
 		}
 	}
 
-	if !async {
-		// run snapshot pod
-		return pod.RunSnapshotPod(ctx, restConfig, kubeClient, []string{"/vcluster", "snapshot"}, vCluster, podOptions, snapshotOpts, log)
-	}
-
-	// creating snapshot request with 'vcluster snapshot create' command
-	err = createSnapshotRequest(ctx, vCluster, kubeClient, snapshotOpts, log)
-	if err != nil {
-		return err
-	}
 	return nil
 }
 
@@ -70,7 +81,8 @@ func GetSnapshots(ctx context.Context, args []string, globalFlags *flags.GlobalF
 		return fmt.Errorf("failed to init snapshot command: %w", err)
 	}
 
-	if snapshotOpts.Type == "container" {
+	// snapshots on a mounted filesystem need to be listed from within the vCluster
+	if snapshotOpts.Type == "container" || snapshotOpts.Type == "file" {
 		podOptions := &pod.Options{
 			Exec: true,
 		}
@@ -104,6 +116,8 @@ func fillSnapshotOptions(snapshotURL string, snapshotOptions *snapshot.Options)
 	// try to fill in oci options
 	snapshotOptions.OCI.FillCredentials(true)
 	snapshotOptions.S3.FillCredentials(true)
+	snapshotOptions.GCS.FillCredentials(true)
+	snapshotOptions.AzBlob.FillCredentials(true)
 	return nil
 }
 
@@ -153,22 +167,22 @@ func initSnapshotCommand(
 	return vCluster, kubeClient, restClient, nil
 }
 
-func createSnapshotRequest(ctx context.Context, vCluster *find.VCluster, kubeClient *kubernetes.Clientset, snapshotOpts *snapshot.Options, log log.Logger) error {
+func createSnapshotRequest(ctx context.Context, vCluster *find.VCluster, kubeClient *kubernetes.Clientset, snapshotOpts *snapshot.Options, log log.Logger) (*snapshot.Request, error) {
 	err := checkIfVClusterSupportsSnapshotRequests(vCluster, log)
 	if err != nil {
-		return fmt.Errorf("vCluster version check failed: %w", err)
+		return nil, fmt.Errorf("vCluster version check failed: %w", err)
 	}
 	vClusterConfig, err := getVClusterConfig(ctx, vCluster, kubeClient, snapshotOpts)
 	if err != nil {
-		return fmt.Errorf("failed to get vcluster config: %w", err)
+		return nil, fmt.Errorf("failed to get vcluster config: %w", err)
 	}
 	// Create snapshot request resources
-	_, err = snapshot.CreateSnapshotRequestResources(ctx, vCluster.Namespace, vCluster.Name, vClusterConfig, snapshotOpts, kubeClient)
+	request, err := snapshot.CreateSnapshotRequestResources(ctx, vCluster.Namespace, vCluster.Name, vClusterConfig, snapshotOpts, kubeClient)
 	if err != nil {
-		return fmt.Errorf("failed to create snapshot request resources: %w", err)
+		return nil, fmt.Errorf("failed to create snapshot request resources: %w", err)
 	}
 	log.Infof("Beginning snapshot creation... Check the snapshot status by running `vcluster snapshot get %s %s`", vCluster.Name, snapshotOpts.GetURL())
-	return nil
+	return request, nil
 }
 
 func checkIfVClusterSupportsSnapshotRequests(vCluster *find.VCluster, log log.Logger) error {
@@ -226,3 +240,20 @@ func getVClusterConfig(ctx context.Context, vCluster *find.VCluster, kubeClient
 
 	return vClusterConfig, nil
 }
+
+// snapshotSecretLoader loads the snapshot encryption key secrets from the given host namespace
+func snapshotSecretLoader(ctx context.Context, kubeClient kubernetes.Interface, namespace string) encryption.SecretLoader {
+	return func(name, key string) ([]byte, error) {
+		secret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("get encryption key secret %s/%s: %w", namespace, name, err)
+		}
+
+		value, ok := secret.Data[key]
+		if !ok {
+			return nil, fmt.Errorf("key %s not found in secret %s/%s", key, namespace, name)
+		}
+
+		return value, nil
+	}
+}
@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/snapshot"
+	"github.com/loft-sh/vcluster/pkg/util/clihelper"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubectl/pkg/describe"
+)
+
+// InspectSnapshot prints a summary of the snapshot contents without restoring it
+func InspectSnapshot(ctx context.Context, snapshotURL string, snapshotOpts *snapshot.Options, output string, out io.Writer) error {
+	err := fillInspectSnapshotOptions(snapshotURL, snapshotOpts)
+	if err != nil {
+		return err
+	}
+
+	summary, err := snapshot.Inspect(ctx, snapshotOpts, nil)
+	if err != nil {
+		return fmt.Errorf("inspect snapshot: %w", err)
+	}
+
+	switch output {
+	case "json":
+		raw, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(raw))
+		return err
+	case "yaml":
+		raw, err := yaml.Marshal(summary)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(out, string(raw))
+		return err
+	case "":
+		_, err = fmt.Fprint(out, snapshotSummaryString(summary))
+		return err
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+}
+
+// DiffSnapshots compares a snapshot with another snapshot or the running vCluster
+func DiffSnapshots(ctx context.Context, args []string, vClusterName string, globalFlags *flags.GlobalFlags, snapshotOpts *snapshot.Options, output string, out io.Writer, log log.Logger) error {
+	if output != "" && output != "json" {
+		return fmt.Errorf("unsupported output format: %s", output)
+	} else if len(args) == 1 && vClusterName == "" {
+		return errors.New("either specify a second snapshot url or a vCluster to compare against with --vcluster")
+	} else if len(args) == 2 && vClusterName != "" {
+		return errors.New("--vcluster cannot be used when comparing two snapshots")
+	}
+
+	// read the objects from the first snapshot
+	fromOptions := *snapshotOpts
+	err := fillInspectSnapshotOptions(args[0], &fromOptions)
+	if err != nil {
+		return err
+	}
+	fromObjects, err := snapshot.ReadObjects(ctx, &fromOptions, nil)
+	if err != nil {
+		return fmt.Errorf("read snapshot %s: %w", args[0], err)
+	}
+
+	// read the objects from the second snapshot or the running vCluster
+	var toObjects map[string]*unstructured.Unstructured
+	if len(args) == 2 {
+		toOptions := *snapshotOpts
+		err = fillInspectSnapshotOptions(args[1], &toOptions)
+		if err != nil {
+			return err
+		}
+		toObjects, err = snapshot.ReadObjects(ctx, &toOptions, nil)
+		if err != nil {
+			return fmt.Errorf("read snapshot %s: %w", args[1], err)
+		}
+	} else {
+		toObjects, err = readLiveVClusterObjects(ctx, globalFlags, vClusterName, log)
+		if err != nil {
+			return err
+		}
+	}
+
+	diffs, err := snapshot.DiffObjects(fromObjects, toObjects)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		raw, err := json.MarshalIndent(diffs, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(raw))
+		return err
+	}
+
+	changes := map[snapshot.ObjectChange]int{}
+	for _, diff := range diffs {
+		changes[diff.Change]++
+		_, err = fmt.Fprint(out, diff.Diff)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Infof("%d added, %d removed, %d changed", changes[snapshot.ObjectAdded], changes[snapshot.ObjectRemoved], changes[snapshot.ObjectChanged])
+	return nil
+}
+
+// VerifySnapshot reads the whole snapshot and verifies it against its manifest
+func VerifySnapshot(ctx context.Context, snapshotURL string, snapshotOpts *snapshot.Options, output string, out io.Writer, log log.Logger) error {
+	if output != "" && output != "json" {
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+
+	err := fillInspectSnapshotOptions(snapshotURL, snapshotOpts)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := snapshot.VerifySnapshot(ctx, snapshotOpts, nil)
+	if errors.Is(err, snapshot.ErrNoManifest) {
+		return fmt.Errorf("snapshot %s was created by an older vCluster version and cannot be verified: %w", snapshotURL, err)
+	} else if err != nil {
+		return fmt.Errorf("verify snapshot %s: %w", snapshotURL, err)
+	}
+
+	if output == "json" {
+		raw, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(raw))
+		return err
+	}
+
+	log.Donef("Snapshot %s is valid: %d keys, checksum %s", snapshotURL, manifest.Keys, manifest.Checksum)
+	return nil
+}
+
+func readLiveVClusterObjects(ctx context.Context, globalFlags *flags.GlobalFlags, vClusterName string, log log.Logger) (map[string]*unstructured.Unstructured, error) {
+	vCluster, err := find.GetVCluster(ctx, globalFlags.Context, vClusterName, globalFlags.Namespace, log)
+	if err != nil {
+		return nil, err
+	}
+
+	kubeConfig, err := vCluster.ClientFactory.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kube config: %w", err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	vKubeConfig, err := clihelper.GetVClusterKubeConfig(ctx, kubeConfig, kubeClient, vCluster, log, clihelper.PortForwardingOptions{
+		StdOut: io.Discard,
+		StdErr: os.Stderr,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get virtual cluster config: %w", err)
+	}
+
+	objects, err := snapshot.ReadLiveObjects(ctx, vKubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("read objects from vCluster %s: %w", vCluster.Name, err)
+	}
+
+	return objects, nil
+}
+
+func fillInspectSnapshotOptions(snapshotURL string, snapshotOpts *snapshot.Options) error {
+	err := fillSnapshotOptions(snapshotURL, snapshotOpts)
+	if err != nil {
+		return err
+	} else if snapshotOpts.Type == "container" {
+		return errors.New("snapshots stored in the vCluster container cannot be read locally, please use a snapshot stored in s3, gcs, azure blob storage or an oci registry")
+	}
+
+	return nil
+}
+
+func snapshotSummaryString(summary *snapshot.Summary) string {
+	out := &tabwriter.Writer{}
+	buf := &bytes.Buffer{}
+	out.Init(buf, 0, 8, 2, ' ', 0)
+
+	w := describe.NewPrefixWriter(out)
+	w.Write(describe.LEVEL_0, "Target:\t%s\n", summary.Target)
+	w.Write(describe.LEVEL_0, "Encrypted:\t%t\n", summary.Encrypted)
+	w.Write(describe.LEVEL_0, "Keys:\t%d\n", summary.Keys)
+	w.Write(describe.LEVEL_0, "Objects:\t%d\n", summary.Objects)
+	w.Write(describe.LEVEL_0, "Mappings:\t%d\n", summary.Mappings)
+	if summary.Manifest != nil {
+		w.Write(describe.LEVEL_0, "Manifest:\t%s\n", summary.Manifest.Checksum)
+		w.Write(describe.LEVEL_1, "Created:\t%s\n", summary.Manifest.CreationTimestamp.String())
+		w.Write(describe.LEVEL_1, "vCluster Version:\t%s\n", summary.Manifest.VClusterVersion)
+		w.Write(describe.LEVEL_1, "Kubernetes Version:\t%s\n", summary.Manifest.KubernetesVersion)
+		w.Write(describe.LEVEL_1, "Backing Store:\t%s\n", summary.Manifest.BackingStore)
+		w.Write(describe.LEVEL_1, "Revision:\t%d\n", summary.Manifest.Revision)
+		if summary.Manifest.Base != nil {
+			w.Write(describe.LEVEL_1, "Incremental Base:\t%s (revision %d)\n", summary.Manifest.Base.URL, summary.Manifest.Base.Revision)
+		}
+	}
+	if summary.Request != nil {
+		w.Write(describe.LEVEL_0, "Request:\t%s\n", summary.Request.Name)
+		w.Write(describe.LEVEL_1, "Created:\t%s\n", summary.Request.CreationTimestamp.String())
+		w.Write(describe.LEVEL_1, "Include Volumes:\t%t\n", summary.Request.Spec.IncludeVolumes)
+	}
+	if summary.Release != nil {
+		w.Write(describe.LEVEL_0, "Release:\t%s/%s\n", summary.Release.ReleaseNamespace, summary.Release.ReleaseName)
+		w.Write(describe.LEVEL_1, "Chart:\t%s\n", summary.Release.ChartName)
+		w.Write(describe.LEVEL_1, "Version:\t%s\n", summary.Release.ChartVersion)
+	}
+
+	if len(summary.Namespaces) > 0 {
+		w.Write(describe.LEVEL_0, "Namespaces:\t%s\n", strings.Join(summary.Namespaces, ", "))
+	}
+	if len(summary.Kinds) > 0 {
+		w.Write(describe.LEVEL_0, "Kinds:\n")
+		for _, kind := range slices.Sorted(maps.Keys(summary.Kinds)) {
+			w.Write(describe.LEVEL_1, "%s:\t%d\n", kind, summary.Kinds[kind])
+		}
+	}
+	if len(summary.VolumeSnapshots) > 0 {
+		w.Write(describe.LEVEL_0, "Volume Snapshots:\n")
+		for _, volumeSnapshot := range summary.VolumeSnapshots {
+			w.Write(describe.LEVEL_1, "%s:\t%s\t%s\n", volumeSnapshot.PersistentVolumeClaim, volumeSnapshot.Phase, volumeSnapshot.Error)
+		}
+	}
+
+	if summary.Release != nil && len(summary.Release.Values) > 0 {
+		w.Write(describe.LEVEL_0, "\n------------------- vcluster.yaml -------------------\n")
+		w.Write(describe.LEVEL_0, "%s\n", strings.TrimSuffix(string(summary.Release.Values), "\n"))
+		w.Write(describe.LEVEL_0, "-----------------------------------------------------\n")
+	}
+
+	out.Flush()
+	return buf.String()
+}
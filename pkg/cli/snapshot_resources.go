@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/log/table"
+	snapshotv1alpha1 "github.com/loft-sh/vcluster/pkg/apis/snapshot/v1alpha1"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SnapshotResourcesOptions holds the options of the snapshot list and describe commands
+type SnapshotResourcesOptions struct {
+	Restores bool
+	Output   string
+}
+
+// ListSnapshotResources lists the VirtualClusterSnapshots or VirtualClusterRestores of a vCluster.
+func ListSnapshotResources(ctx context.Context, vClusterName string, globalFlags *flags.GlobalFlags, options *SnapshotResourcesOptions, log log.Logger) error {
+	vCluster, kubeClient, err := initSnapshotResourcesCommand(ctx, vClusterName, globalFlags, log)
+	if err != nil {
+		return err
+	}
+
+	var list client.ObjectList = &snapshotv1alpha1.VirtualClusterSnapshotList{}
+	if options.Restores {
+		list = &snapshotv1alpha1.VirtualClusterRestoreList{}
+	}
+	err = kubeClient.List(ctx, list, client.InNamespace(vCluster.Namespace))
+	if err != nil {
+		return snapshotResourcesError(err, options.Restores)
+	}
+
+	if options.Output != "" && options.Output != "table" {
+		return printSnapshotResource(list, options.Output, log)
+	}
+
+	now := time.Now()
+	var values [][]string
+	switch list := list.(type) {
+	case *snapshotv1alpha1.VirtualClusterSnapshotList:
+		sort.Slice(list.Items, func(i, j int) bool {
+			return list.Items[i].CreationTimestamp.After(list.Items[j].CreationTimestamp.Time)
+		})
+		for _, item := range list.Items {
+			values = append(values, []string{item.Name, phaseOrPending(item.Status.Phase), item.Status.URL, fmt.Sprintf("%t", item.Spec.IncludeVolumes), snapshotResourceAge(item.CreationTimestamp, now)})
+		}
+		table.PrintTable(log, []string{"NAME", "PHASE", "URL", "VOLUMES", "AGE"}, values)
+	case *snapshotv1alpha1.VirtualClusterRestoreList:
+		sort.Slice(list.Items, func(i, j int) bool {
+			return list.Items[i].CreationTimestamp.After(list.Items[j].CreationTimestamp.Time)
+		})
+		for _, item := range list.Items {
+			values = append(values, []string{item.Name, phaseOrPending(item.Status.Phase), item.Spec.URL, fmt.Sprintf("%t", item.Spec.IncludeVolumes), snapshotResourceAge(item.CreationTimestamp, now)})
+		}
+		table.PrintTable(log, []string{"NAME", "PHASE", "URL", "VOLUMES", "AGE"}, values)
+	}
+
+	return nil
+}
+
+// DescribeSnapshotResource prints the details of a VirtualClusterSnapshot or VirtualClusterRestore of a vCluster.
+func DescribeSnapshotResource(ctx context.Context, vClusterName, name string, globalFlags *flags.GlobalFlags, options *SnapshotResourcesOptions, log log.Logger) error {
+	vCluster, kubeClient, err := initSnapshotResourcesCommand(ctx, vClusterName, globalFlags, log)
+	if err != nil {
+		return err
+	}
+
+	var obj client.Object = &snapshotv1alpha1.VirtualClusterSnapshot{}
+	if options.Restores {
+		obj = &snapshotv1alpha1.VirtualClusterRestore{}
+	}
+	err = kubeClient.Get(ctx, client.ObjectKey{Namespace: vCluster.Namespace, Name: name}, obj)
+	if err != nil {
+		return snapshotResourcesError(err, options.Restores)
+	}
+
+	if options.Output != "" {
+		return printSnapshotResource(obj, options.Output, log)
+	}
+
+	log.WriteString(logrus.InfoLevel, describeSnapshotResource(obj))
+	return nil
+}
+
+func describeSnapshotResource(obj client.Object) string {
+	var (
+		kind, phase, url, errorMessage string
+		includeVolumes                 bool
+		completionTime                 *metav1.Time
+		volumes                        []snapshotv1alpha1.VolumeStatus
+		conditions                     []metav1.Condition
+	)
+	switch obj := obj.(type) {
+	case *snapshotv1alpha1.VirtualClusterSnapshot:
+		kind, phase, url, errorMessage = "VirtualClusterSnapshot", obj.Status.Phase, obj.Status.URL, obj.Status.Error
+		includeVolumes, completionTime, volumes, conditions = obj.Spec.IncludeVolumes, obj.Status.CompletionTime, obj.Status.Volumes, obj.Status.Conditions
+		if url == "" {
+			url = obj.Spec.URL
+		}
+	case *snapshotv1alpha1.VirtualClusterRestore:
+		kind, phase, url, errorMessage = "VirtualClusterRestore", obj.Status.Phase, obj.Spec.URL, obj.Status.Error
+		includeVolumes, completionTime, volumes, conditions = obj.Spec.IncludeVolumes, obj.Status.CompletionTime, obj.Status.Volumes, obj.Status.Conditions
+	}
+
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "Name:             %s\n", obj.GetName())
+	fmt.Fprintf(out, "Namespace:        %s\n", obj.GetNamespace())
+	fmt.Fprintf(out, "Kind:             %s\n", kind)
+	fmt.Fprintf(out, "Created:          %s\n", obj.GetCreationTimestamp().Format(time.RFC3339))
+	fmt.Fprintf(out, "URL:              %s\n", url)
+	fmt.Fprintf(out, "Include Volumes:  %t\n", includeVolumes)
+	fmt.Fprintf(out, "Phase:            %s\n", phaseOrPending(phase))
+	if completionTime != nil {
+		fmt.Fprintf(out, "Completed:        %s\n", completionTime.Format(time.RFC3339))
+	}
+	if errorMessage != "" {
+		fmt.Fprintf(out, "Error:            %s\n", errorMessage)
+	}
+
+	if len(volumes) > 0 {
+		fmt.Fprintf(out, "Volumes:\n")
+		for _, volume := range volumes {
+			fmt.Fprintf(out, "  %s (%s): %s", volume.PersistentVolumeClaim, volume.Method, phaseOrPending(volume.Phase))
+			if volume.Error != "" {
+				fmt.Fprintf(out, " - %s", volume.Error)
+			}
+			fmt.Fprintf(out, "\n")
+		}
+	}
+
+	if len(conditions) > 0 {
+		fmt.Fprintf(out, "Conditions:\n")
+		for _, condition := range conditions {
+			fmt.Fprintf(out, "  %s=%s", condition.Type, condition.Status)
+			if condition.Reason != "" {
+				fmt.Fprintf(out, " (%s)", condition.Reason)
+			}
+			if condition.Message != "" {
+				fmt.Fprintf(out, ": %s", condition.Message)
+			}
+			fmt.Fprintf(out, "\n")
+		}
+	}
+
+	return out.String()
+}
+
+func printSnapshotResource(obj runtime.Object, output string, log log.Logger) error {
+	var (
+		out []byte
+		err error
+	)
+	switch output {
+	case "json":
+		out, err = json.MarshalIndent(obj, "", "  ")
+	case "yaml":
+		out, err = yaml.Marshal(obj)
+	default:
+		return fmt.Errorf("unsupported output format %q, expected table, json or yaml", output)
+	}
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", output, err)
+	}
+
+	log.WriteString(logrus.InfoLevel, strings.TrimSuffix(string(out), "\n")+"\n")
+	return nil
+}
+
+func initSnapshotResourcesCommand(ctx context.Context, vClusterName string, globalFlags *flags.GlobalFlags, log log.Logger) (*find.VCluster, client.Client, error) {
+	vCluster, err := find.GetVCluster(ctx, globalFlags.Context, vClusterName, globalFlags.Namespace, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	restConfig, err := vCluster.ClientFactory.ClientConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scheme := runtime.NewScheme()
+	err = snapshotv1alpha1.AddToScheme(scheme)
+	if err != nil {
+		return nil, nil, err
+	}
+	kubeClient, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return vCluster, kubeClient, nil
+}
+
+func snapshotResourcesError(err error, restores bool) error {
+	kind := "snapshots"
+	if restores {
+		kind = "restores"
+	}
+	if meta.IsNoMatchError(err) {
+		return fmt.Errorf("the %s resource is not installed, please enable snapshots.customResources.enabled in the vCluster config", kind)
+	}
+
+	return fmt.Errorf("get %s: %w", kind, err)
+}
+
+func phaseOrPending(phase string) string {
+	if phase == "" {
+		return "Pending"
+	}
+
+	return phase
+}
+
+func snapshotResourceAge(timestamp metav1.Time, now time.Time) string {
+	if timestamp.IsZero() {
+		return "<unknown>"
+	}
+
+	return duration.HumanDuration(now.Sub(timestamp.Time))
+}
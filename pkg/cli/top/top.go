@@ -0,0 +1,508 @@
+package top
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/log/table"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	"github.com/loft-sh/vcluster/pkg/util/translate"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	metricsv1beta1client "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
+)
+
+const (
+	SortByCPU     = "cpu"
+	SortByMemory  = "memory"
+	SortByPods    = "pods"
+	SortByStorage = "storage"
+	SortByName    = "name"
+)
+
+// Source describes where the cpu and memory numbers come from.
+type Source string
+
+const (
+	// SourceMetrics means the numbers are the current usage reported by metrics.k8s.io
+	SourceMetrics Source = "metrics"
+	// SourceRequests means metrics.k8s.io wasn't available and the numbers are the resource requests of the pods
+	SourceRequests Source = "requests"
+)
+
+// Options holds the top cmd options
+type Options struct {
+	Output      string
+	SortBy      string
+	ByNamespace bool
+	Watch       bool
+	Interval    time.Duration
+}
+
+// Usage is the resource usage of a vCluster or of a single virtual namespace of a vCluster.
+type Usage struct {
+	VCluster         string `json:"vcluster"`
+	Namespace        string `json:"namespace"`
+	VirtualNamespace string `json:"virtualNamespace,omitempty"`
+	ControlPlane     bool   `json:"controlPlane,omitempty"`
+	Pods             int    `json:"pods"`
+	CPUMillis        int64  `json:"cpuMillis"`
+	MemoryBytes      int64  `json:"memoryBytes"`
+	StorageBytes     int64  `json:"storageBytes"`
+}
+
+// Report is a single snapshot of the resource usage.
+type Report struct {
+	Source Source  `json:"source"`
+	Usage  []Usage `json:"usage"`
+}
+
+// hostResources are the host objects that belong to vClusters
+type hostResources struct {
+	pods  []corev1.Pod
+	pvcs  []corev1.PersistentVolumeClaim
+	usage map[string]corev1.ResourceList
+}
+
+// Run prints the resource usage of the vClusters, and keeps refreshing it in watch mode.
+func Run(ctx context.Context, vClusterName string, globalFlags *flags.GlobalFlags, options *Options, log log.Logger) error {
+	if options.Output != "" && options.Output != "table" && options.Output != "json" {
+		return fmt.Errorf("unsupported output format %q, expected table or json", options.Output)
+	}
+	switch options.SortBy {
+	case "", SortByCPU, SortByMemory, SortByPods, SortByStorage, SortByName:
+	default:
+		return fmt.Errorf("unsupported sort %q, expected one of %s", options.SortBy, strings.Join([]string{SortByCPU, SortByMemory, SortByPods, SortByStorage, SortByName}, ", "))
+	}
+	if options.Watch && options.Interval <= 0 {
+		return fmt.Errorf("interval needs to be positive, got %s", options.Interval)
+	}
+
+	namespace := metav1.NamespaceAll
+	if globalFlags.Namespace != "" {
+		namespace = globalFlags.Namespace
+	}
+
+	vClusters, err := find.ListVClusters(ctx, globalFlags.Context, vClusterName, namespace, log.ErrorStreamOnly())
+	if err != nil {
+		return err
+	}
+	if len(vClusters) == 0 {
+		if vClusterName != "" {
+			return &find.VClusterNotFoundError{Name: vClusterName}
+		}
+
+		log.Info("No virtual clusters found")
+		return nil
+	}
+
+	restConfig, err := vClusters[0].ClientFactory.ClientConfig()
+	if err != nil {
+		return err
+	}
+	hostClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	metricsClient, err := metricsv1beta1client.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
+	// a single vCluster is always broken down by virtual namespace
+	byNamespace := options.ByNamespace || vClusterName != ""
+	warned := false
+	for {
+		resources, metricsErr := collect(ctx, hostClient, metricsClient, vClusters)
+		if resources == nil {
+			return metricsErr
+		}
+
+		source := SourceMetrics
+		if metricsErr != nil {
+			source = SourceRequests
+			if !warned {
+				log.Warnf("Resource metrics are not available, showing resource requests instead of the usage. Is metrics-server installed? (%v)", metricsErr)
+				warned = true
+			}
+		}
+
+		report := &Report{
+			Source: source,
+			Usage:  aggregate(vClusters, resources, byNamespace),
+		}
+		sortUsage(report.Usage, options.SortBy)
+
+		if options.Watch && options.Output != "json" {
+			// clear the screen, so the table stays on top
+			log.WriteString(logrus.InfoLevel, "\033[H\033[2J")
+		}
+		err = printReport(report, byNamespace, options.Output, log)
+		if err != nil {
+			return err
+		}
+
+		if !options.Watch {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(options.Interval):
+		}
+	}
+}
+
+// collect lists the pods and persistent volume claims that belong to the vClusters together with their metrics.
+// If the metrics are not available, the returned error describes why and the resources are still returned.
+func collect(ctx context.Context, hostClient kubernetes.Interface, metricsClient metricsv1beta1client.MetricsV1beta1Interface, vClusters []find.VCluster) (*hostResources, error) {
+	namespaces := []string{}
+	releases := map[string][]string{}
+	for _, vCluster := range vClusters {
+		if !slices.Contains(namespaces, vCluster.Namespace) {
+			namespaces = append(namespaces, vCluster.Namespace)
+		}
+		releases[vCluster.Namespace] = append(releases[vCluster.Namespace], vCluster.Name)
+	}
+
+	resources := &hostResources{}
+	seenPods := map[string]bool{}
+	addPods := func(pods []corev1.Pod) {
+		for _, pod := range pods {
+			if key := pod.Namespace + "/" + pod.Name; !seenPods[key] {
+				seenPods[key] = true
+				resources.pods = append(resources.pods, pod)
+			}
+		}
+	}
+	seenPVCs := map[string]bool{}
+	addPVCs := func(pvcs []corev1.PersistentVolumeClaim) {
+		for _, pvc := range pvcs {
+			if key := pvc.Namespace + "/" + pvc.Name; !seenPVCs[key] {
+				seenPVCs[key] = true
+				resources.pvcs = append(resources.pvcs, pvc)
+			}
+		}
+	}
+
+	// synced objects carry the marker label, these are listed cluster wide so vClusters that sync to several host
+	// namespaces are covered as well. Without permissions to do so, only the namespaces of the vClusters are searched.
+	markerOptions := metav1.ListOptions{LabelSelector: translate.MarkerLabel}
+	clusterWide := true
+	podList, err := hostClient.CoreV1().Pods(metav1.NamespaceAll).List(ctx, markerOptions)
+	if kerrors.IsForbidden(err) {
+		clusterWide = false
+	} else if err != nil {
+		return nil, fmt.Errorf("list pods: %w", err)
+	} else {
+		addPods(podList.Items)
+	}
+	pvcList, err := hostClient.CoreV1().PersistentVolumeClaims(metav1.NamespaceAll).List(ctx, markerOptions)
+	if kerrors.IsForbidden(err) {
+		clusterWide = false
+	} else if err != nil {
+		return nil, fmt.Errorf("list persistent volume claims: %w", err)
+	} else {
+		addPVCs(pvcList.Items)
+	}
+
+	for _, namespace := range namespaces {
+		namespaceOptions := []metav1.ListOptions{
+			// control plane objects carry the release label
+			{LabelSelector: fmt.Sprintf("%s in (%s)", translate.VClusterReleaseLabel, strings.Join(releases[namespace], ","))},
+		}
+		if !clusterWide {
+			namespaceOptions = append(namespaceOptions, markerOptions)
+		}
+
+		for _, opts := range namespaceOptions {
+			podList, err := hostClient.CoreV1().Pods(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, fmt.Errorf("list pods: %w", err)
+			}
+			addPods(podList.Items)
+
+			pvcList, err := hostClient.CoreV1().PersistentVolumeClaims(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, fmt.Errorf("list persistent volume claims: %w", err)
+			}
+			addPVCs(pvcList.Items)
+		}
+	}
+
+	usage, err := podUsage(ctx, metricsClient, namespaces)
+	if err != nil {
+		return resources, err
+	}
+
+	resources.usage = usage
+	return resources, nil
+}
+
+func podUsage(ctx context.Context, metricsClient metricsv1beta1client.MetricsV1beta1Interface, namespaces []string) (map[string]corev1.ResourceList, error) {
+	usage := map[string]corev1.ResourceList{}
+	metricsList, err := metricsClient.PodMetricses(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if kerrors.IsForbidden(err) {
+		for _, namespace := range namespaces {
+			namespaceMetrics, err := metricsClient.PodMetricses(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			if metricsList == nil {
+				metricsList = namespaceMetrics
+			} else {
+				metricsList.Items = append(metricsList.Items, namespaceMetrics.Items...)
+			}
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, podMetrics := range metricsList.Items {
+		total := corev1.ResourceList{}
+		for _, container := range podMetrics.Containers {
+			addResources(total, container.Usage)
+		}
+
+		usage[podMetrics.Namespace+"/"+podMetrics.Name] = total
+	}
+
+	return usage, nil
+}
+
+// aggregate sums up the host resources per vCluster, or per virtual namespace if byNamespace is set. If there is no
+// usage from metrics.k8s.io, the resource requests of the pods are used instead.
+func aggregate(vClusters []find.VCluster, resources *hostResources, byNamespace bool) []Usage {
+	usages := map[string]*Usage{}
+	get := func(vCluster *find.VCluster, object metav1.Object) *Usage {
+		key := vCluster.Namespace + "/" + vCluster.Name
+		virtualNamespace, controlPlane := "", false
+		if byNamespace {
+			controlPlane = object.GetLabels()[translate.MarkerLabel] == ""
+			if !controlPlane {
+				virtualNamespace = object.GetAnnotations()[translate.NamespaceAnnotation]
+			}
+
+			key += "/" + virtualNamespace + "/" + strconv.FormatBool(controlPlane)
+		}
+		if usages[key] == nil {
+			usages[key] = &Usage{
+				VCluster:         vCluster.Name,
+				Namespace:        vCluster.Namespace,
+				VirtualNamespace: virtualNamespace,
+				ControlPlane:     controlPlane,
+			}
+		}
+
+		return usages[key]
+	}
+
+	// vClusters without any pods should still show up in the overview
+	if !byNamespace {
+		for i := range vClusters {
+			get(&vClusters[i], nil)
+		}
+	}
+
+	for i := range resources.pods {
+		pod := &resources.pods[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		vCluster := owner(vClusters, pod)
+		if vCluster == nil {
+			continue
+		}
+
+		podResources := resources.usage[pod.Namespace+"/"+pod.Name]
+		if resources.usage == nil {
+			podResources = podRequests(pod)
+		}
+
+		usage := get(vCluster, pod)
+		usage.Pods++
+		usage.CPUMillis += podResources.Cpu().MilliValue()
+		usage.MemoryBytes += podResources.Memory().Value()
+	}
+
+	for i := range resources.pvcs {
+		pvc := &resources.pvcs[i]
+		vCluster := owner(vClusters, pvc)
+		if vCluster == nil {
+			continue
+		}
+
+		storage, ok := pvc.Status.Capacity[corev1.ResourceStorage]
+		if !ok {
+			storage = pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		}
+
+		get(vCluster, pvc).StorageBytes += storage.Value()
+	}
+
+	ret := make([]Usage, 0, len(usages))
+	for _, usage := range usages {
+		ret = append(ret, *usage)
+	}
+
+	sortUsage(ret, SortByName)
+	return ret
+}
+
+// owner returns the vCluster a host object belongs to. Synced objects are matched by the marker label and
+// preferably by the namespace, as vClusters with the same name can exist in several namespaces. Control plane
+// objects are matched by the release label within the namespace of the vCluster.
+func owner(vClusters []find.VCluster, object metav1.Object) *find.VCluster {
+	if name := object.GetLabels()[translate.MarkerLabel]; name != "" {
+		var candidates []*find.VCluster
+		for i := range vClusters {
+			if vClusters[i].Name != name {
+				continue
+			} else if vClusters[i].Namespace == object.GetNamespace() {
+				return &vClusters[i]
+			}
+
+			candidates = append(candidates, &vClusters[i])
+		}
+
+		// with several candidates in other namespaces we can't tell which one the object belongs to
+		if len(candidates) == 1 {
+			return candidates[0]
+		}
+
+		return nil
+	}
+
+	release := object.GetLabels()[translate.VClusterReleaseLabel]
+	for i := range vClusters {
+		if vClusters[i].Name == release && vClusters[i].Namespace == object.GetNamespace() {
+			return &vClusters[i]
+		}
+	}
+
+	return nil
+}
+
+func podRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		addResources(requests, container.Resources.Requests)
+	}
+
+	return requests
+}
+
+func addResources(total, add corev1.ResourceList) {
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		quantity, ok := add[name]
+		if !ok {
+			continue
+		}
+
+		current := total[name]
+		current.Add(quantity)
+		total[name] = current
+	}
+}
+
+func sortUsage(usages []Usage, sortBy string) {
+	slices.SortStableFunc(usages, func(a, b Usage) int {
+		var diff int64
+		switch sortBy {
+		case SortByMemory:
+			diff = b.MemoryBytes - a.MemoryBytes
+		case SortByPods:
+			diff = int64(b.Pods - a.Pods)
+		case SortByStorage:
+			diff = b.StorageBytes - a.StorageBytes
+		case SortByName:
+		default:
+			diff = b.CPUMillis - a.CPUMillis
+		}
+		if diff != 0 {
+			if diff < 0 {
+				return -1
+			}
+			return 1
+		}
+
+		return strings.Compare(sortKey(a), sortKey(b))
+	})
+}
+
+func sortKey(usage Usage) string {
+	// the control plane comes first within a vCluster
+	controlPlane := "1"
+	if usage.ControlPlane {
+		controlPlane = "0"
+	}
+
+	return usage.Namespace + "/" + usage.VCluster + "/" + controlPlane + "/" + usage.VirtualNamespace
+}
+
+func printReport(report *Report, byNamespace bool, output string, log log.Logger) error {
+	if output == "json" {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("json marshal report: %w", err)
+		}
+
+		log.WriteString(logrus.InfoLevel, string(out)+"\n")
+		return nil
+	}
+
+	cpuHeader, memoryHeader := "CPU", "MEMORY"
+	if report.Source == SourceRequests {
+		cpuHeader, memoryHeader = "CPU (REQUESTS)", "MEMORY (REQUESTS)"
+	}
+
+	header := []string{"NAME", "NAMESPACE", "PODS", cpuHeader, memoryHeader, "STORAGE"}
+	if byNamespace {
+		header = []string{"NAME", "NAMESPACE", "VIRTUAL NAMESPACE", "PODS", cpuHeader, memoryHeader, "STORAGE"}
+	}
+
+	values := make([][]string, 0, len(report.Usage))
+	for _, usage := range report.Usage {
+		row := []string{usage.VCluster, usage.Namespace}
+		if byNamespace {
+			virtualNamespace := usage.VirtualNamespace
+			if usage.ControlPlane {
+				virtualNamespace = "(control plane)"
+			}
+
+			row = append(row, virtualNamespace)
+		}
+
+		values = append(values, append(row,
+			strconv.Itoa(usage.Pods),
+			fmt.Sprintf("%dm", usage.CPUMillis),
+			formatBytes(usage.MemoryBytes),
+			formatBytes(usage.StorageBytes),
+		))
+	}
+
+	table.PrintTable(log, header, values)
+	return nil
+}
+
+func formatBytes(bytes int64) string {
+	const mi = 1024 * 1024
+	if bytes >= 1024*mi {
+		return fmt.Sprintf("%.1fGi", float64(bytes)/(1024*mi))
+	}
+
+	return fmt.Sprintf("%dMi", bytes/mi)
+}
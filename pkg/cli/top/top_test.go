@@ -0,0 +1,135 @@
+package top
+
+import (
+	"testing"
+
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	"github.com/loft-sh/vcluster/pkg/util/translate"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func syncedPod(vCluster, hostNamespace, virtualNamespace, name, cpu, memory string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name + "-x-" + virtualNamespace + "-x-" + vCluster,
+			Namespace:   hostNamespace,
+			Labels:      map[string]string{translate.MarkerLabel: vCluster},
+			Annotations: map[string]string{translate.NamespaceAnnotation: virtualNamespace, translate.NameAnnotation: name},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			}},
+		}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	vClusters := []find.VCluster{
+		{Name: "team-a", Namespace: "team-a"},
+		{Name: "team-b", Namespace: "team-b"},
+		{Name: "idle", Namespace: "idle"},
+	}
+
+	controlPlane := syncedPod("team-a", "team-a", "", "team-a-0", "200m", "256Mi")
+	controlPlane.Name = "team-a-0"
+	controlPlane.Labels = map[string]string{translate.VClusterReleaseLabel: "team-a", "app": "vcluster"}
+	controlPlane.Annotations = nil
+
+	completed := syncedPod("team-a", "team-a", "default", "job", "1", "1Gi")
+	completed.Status.Phase = corev1.PodSucceeded
+
+	resources := &hostResources{
+		pods: []corev1.Pod{
+			controlPlane,
+			completed,
+			syncedPod("team-a", "team-a", "default", "nginx", "100m", "128Mi"),
+			syncedPod("team-a", "team-a", "backend", "api", "500m", "512Mi"),
+			syncedPod("team-b", "team-b", "default", "nginx", "50m", "64Mi"),
+			// belongs to a vCluster that wasn't listed
+			syncedPod("other", "other", "default", "nginx", "1", "1Gi"),
+		},
+		pvcs: []corev1.PersistentVolumeClaim{{
+			ObjectMeta: metav1.ObjectMeta{Name: "data-team-a-0", Namespace: "team-a", Labels: map[string]string{translate.VClusterReleaseLabel: "team-a"}},
+			Status:     corev1.PersistentVolumeClaimStatus{Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("5Gi")}},
+		}, {
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "data-x-backend-x-team-a",
+				Namespace:   "team-a",
+				Labels:      map[string]string{translate.MarkerLabel: "team-a"},
+				Annotations: map[string]string{translate.NamespaceAnnotation: "backend"},
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{Resources: corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}}},
+		}},
+	}
+
+	// without metrics the requests are used
+	usages := aggregate(vClusters, resources, false)
+	sortUsage(usages, SortByCPU)
+	assert.DeepEqual(t, usages, []Usage{
+		{VCluster: "team-a", Namespace: "team-a", Pods: 3, CPUMillis: 800, MemoryBytes: 896 * 1024 * 1024, StorageBytes: 6 * 1024 * 1024 * 1024},
+		{VCluster: "team-b", Namespace: "team-b", Pods: 1, CPUMillis: 50, MemoryBytes: 64 * 1024 * 1024},
+		{VCluster: "idle", Namespace: "idle"},
+	})
+
+	usages = aggregate(vClusters[:1], resources, true)
+	assert.DeepEqual(t, usages, []Usage{
+		{VCluster: "team-a", Namespace: "team-a", ControlPlane: true, Pods: 1, CPUMillis: 200, MemoryBytes: 256 * 1024 * 1024, StorageBytes: 5 * 1024 * 1024 * 1024},
+		{VCluster: "team-a", Namespace: "team-a", VirtualNamespace: "backend", Pods: 1, CPUMillis: 500, MemoryBytes: 512 * 1024 * 1024, StorageBytes: 1024 * 1024 * 1024},
+		{VCluster: "team-a", Namespace: "team-a", VirtualNamespace: "default", Pods: 1, CPUMillis: 100, MemoryBytes: 128 * 1024 * 1024},
+	})
+
+	// with metrics the usage is used
+	resources.usage = map[string]corev1.ResourceList{
+		"team-b/nginx-x-default-x-team-b": {corev1.ResourceCPU: resource.MustParse("5m"), corev1.ResourceMemory: resource.MustParse("10Mi")},
+	}
+	usages = aggregate(vClusters[1:2], resources, false)
+	assert.DeepEqual(t, usages, []Usage{{VCluster: "team-b", Namespace: "team-b", Pods: 1, CPUMillis: 5, MemoryBytes: 10 * 1024 * 1024}})
+}
+
+func TestOwner(t *testing.T) {
+	vClusters := []find.VCluster{
+		{Name: "test", Namespace: "ns-a"},
+		{Name: "test", Namespace: "ns-b"},
+		{Name: "multi", Namespace: "ns-c"},
+	}
+	object := func(namespace string, labels map[string]string) metav1.Object {
+		return &metav1.ObjectMeta{Namespace: namespace, Labels: labels}
+	}
+
+	assert.Equal(t, owner(vClusters, object("ns-b", map[string]string{translate.MarkerLabel: "test"})), &vClusters[1])
+	assert.Equal(t, owner(vClusters, object("synced-ns", map[string]string{translate.MarkerLabel: "multi"})), &vClusters[2])
+	assert.Assert(t, owner(vClusters, object("synced-ns", map[string]string{translate.MarkerLabel: "test"})) == nil)
+	assert.Equal(t, owner(vClusters, object("ns-a", map[string]string{translate.VClusterReleaseLabel: "test"})), &vClusters[0])
+	assert.Assert(t, owner(vClusters, object("ns-c", map[string]string{translate.VClusterReleaseLabel: "test"})) == nil)
+}
+
+func TestSortUsage(t *testing.T) {
+	usages := []Usage{
+		{VCluster: "a", Pods: 1, MemoryBytes: 3, StorageBytes: 1},
+		{VCluster: "b", Pods: 3, MemoryBytes: 1, StorageBytes: 2},
+		{VCluster: "c", Pods: 2, MemoryBytes: 2, StorageBytes: 2},
+	}
+	names := func() []string {
+		ret := []string{}
+		for _, usage := range usages {
+			ret = append(ret, usage.VCluster)
+		}
+		return ret
+	}
+
+	sortUsage(usages, SortByMemory)
+	assert.DeepEqual(t, names(), []string{"a", "c", "b"})
+	sortUsage(usages, SortByPods)
+	assert.DeepEqual(t, names(), []string{"b", "c", "a"})
+	sortUsage(usages, SortByStorage)
+	assert.DeepEqual(t, names(), []string{"b", "c", "a"})
+	sortUsage(usages, SortByName)
+	assert.DeepEqual(t, names(), []string{"a", "b", "c"})
+}
@@ -0,0 +1,401 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/loft-sh/log"
+	"github.com/loft-sh/log/table"
+	"github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/pkg/cli/find"
+	"github.com/loft-sh/vcluster/pkg/cli/flags"
+	pkgconfig "github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/constants"
+	"github.com/loft-sh/vcluster/pkg/helm"
+	"github.com/loft-sh/vcluster/pkg/snapshot"
+	"github.com/loft-sh/vcluster/pkg/upgrade"
+	"golang.org/x/mod/semver"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// UpgradeClusterOptions holds the upgrade-cluster cmd options
+type UpgradeClusterOptions struct {
+	CreateOptions
+
+	ResetValues       bool
+	Snapshot          string
+	IncludeVolumes    bool
+	DryRun            bool
+	Force             bool
+	RollbackOnFailure bool
+	Timeout           time.Duration
+}
+
+// PreflightStatus is the outcome of a single preflight check.
+type PreflightStatus string
+
+const (
+	PreflightPass PreflightStatus = "pass"
+	PreflightWarn PreflightStatus = "warn"
+	PreflightFail PreflightStatus = "fail"
+	PreflightSkip PreflightStatus = "skip"
+)
+
+// PreflightCheck is the result of a single check that runs before a vCluster is upgraded.
+type PreflightCheck struct {
+	Name    string
+	Status  PreflightStatus
+	Message string
+}
+
+// UpgradeClusterHelm upgrades the chart of an existing vCluster after checking that the upgrade is safe. The vCluster
+// is optionally snapshotted first and rolled back to the previous release if it doesn't become ready again.
+func UpgradeClusterHelm(ctx context.Context, options *UpgradeClusterOptions, globalFlags *flags.GlobalFlags, vClusterName string, log log.Logger) error {
+	vCluster, err := find.GetVCluster(ctx, globalFlags.Context, vClusterName, globalFlags.Namespace, log)
+	if err != nil {
+		return err
+	}
+	globalFlags.Context = vCluster.Context
+	globalFlags.Namespace = vCluster.Namespace
+
+	// the embedded chart only exists for the version of the cli
+	if options.ChartVersion == upgrade.DevelopmentVersion {
+		options.ChartVersion = ""
+	}
+	if options.ChartRepo == "" && options.LocalChartDir == "" && options.ChartVersion != upgrade.GetVersion() {
+		options.ChartRepo = constants.LoftChartRepo
+	}
+
+	cmd := &createHelm{
+		GlobalFlags:   globalFlags,
+		CreateOptions: &options.CreateOptions,
+		log:           log,
+	}
+	cmd.Upgrade = true
+	err = cmd.prepare(ctx, vClusterName)
+	if err != nil {
+		return err
+	}
+
+	release, err := helm.NewSecrets(cmd.kubeClient).Get(ctx, vClusterName, cmd.Namespace)
+	if err != nil {
+		return fmt.Errorf("get current helm release: %w", err)
+	} else if !isVClusterDeployed(release) {
+		return fmt.Errorf("helm release of vcluster %s is not deployed, please fix it with helm first", vClusterName)
+	}
+	currentChartVersion := release.Chart.Metadata.Version
+
+	// TODO Delete after vCluster 0.19.x resp. the old config format is out of support.
+	if isLegacyVCluster(currentChartVersion) {
+		return fmt.Errorf("vcluster %s runs chart version %s, which uses the pre-v0.20 configuration format. Please convert the values via %q and upgrade with %q first", vClusterName, currentChartVersion, "vcluster convert config", "vcluster create --upgrade")
+	}
+	// TODO end
+
+	currentConfig, err := getConfigfileFromSecret(ctx, cmd.kubeClient, vClusterName, cmd.Namespace)
+	if err != nil {
+		return fmt.Errorf("get current vcluster config: %w", err)
+	}
+	cmd.Distro = currentConfig.Distro()
+
+	// helm doesn't keep the values of a release on upgrade, so the current values are passed along
+	if !options.ResetValues {
+		currentValues, err := helmExtraValuesYAML(release)
+		if err != nil {
+			return err
+		}
+		if currentValues != "" {
+			currentValuesFile, err := writeTempFile([]byte(currentValues))
+			if err != nil {
+				return fmt.Errorf("write current values: %w", err)
+			}
+			defer os.Remove(currentValuesFile)
+
+			cmd.Values = append([]string{currentValuesFile}, cmd.Values...)
+		}
+	}
+
+	// render the new values
+	kubernetesVersion, err := cmd.getKubernetesVersion()
+	if err != nil {
+		return err
+	}
+	chartOptions, err := cmd.ToChartOptions(kubernetesVersion, log)
+	if err != nil {
+		return err
+	}
+	chartValues, err := config.GetExtraValues(chartOptions)
+	if err != nil {
+		return err
+	}
+	newConfig, err := cmd.parseVClusterYAML(chartValues)
+	if err != nil {
+		return fmt.Errorf("parse new vcluster config: %w", err)
+	}
+
+	// run the preflight checks
+	checks := upgradePreflightChecks(currentChartVersion, options.ChartVersion, currentConfig, newConfig, validateVClusterConfig(newConfig, vClusterName, cmd.Namespace))
+	printPreflightChecks(checks, log)
+	failed := 0
+	for _, check := range checks {
+		if check.Status == PreflightFail {
+			failed++
+		}
+	}
+	if failed > 0 && !options.Force {
+		return fmt.Errorf("%d preflight checks failed, fix them or use --force to upgrade anyway", failed)
+	} else if options.DryRun {
+		log.Donef("Preflight checks for vcluster %s finished, skipping the upgrade because of --dry-run", vClusterName)
+		return nil
+	}
+
+	// take a snapshot to go back to if anything goes wrong
+	if options.Snapshot != "" {
+		err = upgradeSnapshot(ctx, globalFlags, vCluster, options, log)
+		if err != nil {
+			return fmt.Errorf("snapshot vcluster %s: %w", vClusterName, err)
+		}
+	}
+
+	helmBinaryPath, err := getHelmBinaryPath(ctx, log)
+	if err != nil {
+		return err
+	}
+	err = cmd.deployChart(ctx, vClusterName, chartValues, helmBinaryPath)
+	if err == nil {
+		log.Infof("Waiting for vcluster %s to become ready...", vClusterName)
+		err = waitForVClusterRollout(ctx, cmd.kubeClient, vCluster, options.Timeout)
+	}
+	if err != nil {
+		if !options.RollbackOnFailure {
+			return fmt.Errorf("upgrade vcluster %s: %w\n- Use `helm rollback %s -n %s` to roll back to the previous release", vClusterName, err, vClusterName, cmd.Namespace)
+		}
+
+		log.Errorf("Upgrade of vcluster %s failed: %v", vClusterName, err)
+		log.Infof("Rolling back vcluster %s to chart version %s...", vClusterName, currentChartVersion)
+		rollbackErr := helm.NewClient(&cmd.rawConfig, log, helmBinaryPath).Rollback(ctx, vClusterName, cmd.Namespace)
+		if rollbackErr != nil {
+			return fmt.Errorf("upgrade vcluster %s: %w, rollback failed as well: %w", vClusterName, err, rollbackErr)
+		}
+
+		return fmt.Errorf("upgrade vcluster %s failed and was rolled back to chart version %s: %w", vClusterName, currentChartVersion, err)
+	}
+
+	log.Donef("Successfully upgraded virtual cluster %s in namespace %s from chart version %s to %s", vClusterName, cmd.Namespace, currentChartVersion, cmp.Or(options.ChartVersion, "latest"))
+	return nil
+}
+
+// upgradePreflightChecks compares the current and the new vCluster config and chart version.
+func upgradePreflightChecks(currentChartVersion, newChartVersion string, currentConfig, newConfig *config.Config, validationErr error) []PreflightCheck {
+	checks := []PreflightCheck{chartVersionCheck(currentChartVersion, newChartVersion)}
+
+	if validationErr != nil {
+		checks = append(checks, PreflightCheck{Name: "config", Status: PreflightFail, Message: validationErr.Error()})
+	} else {
+		checks = append(checks, PreflightCheck{Name: "config", Status: PreflightPass, Message: "vcluster.yaml is valid"})
+	}
+
+	if deprecations := deprecatedValues(newConfig); len(deprecations) > 0 {
+		checks = append(checks, PreflightCheck{Name: "deprecated-values", Status: PreflightWarn, Message: strings.Join(deprecations, "; ")})
+	} else {
+		checks = append(checks, PreflightCheck{Name: "deprecated-values", Status: PreflightPass, Message: "no deprecated values are used"})
+	}
+
+	if err := config.ValidateDistroChanges(newConfig.Distro(), currentConfig.Distro()); err != nil {
+		checks = append(checks, PreflightCheck{Name: "distro", Status: PreflightFail, Message: err.Error()})
+	} else {
+		checks = append(checks, PreflightCheck{Name: "distro", Status: PreflightPass, Message: "distro stays " + newConfig.Distro()})
+	}
+
+	currentStore, newStore := currentConfig.BackingStoreType(), newConfig.BackingStoreType()
+	if err := config.ValidateStoreChanges(newStore, currentStore); err != nil {
+		checks = append(checks, PreflightCheck{Name: "backing-store", Status: PreflightFail, Message: err.Error()})
+	} else if currentStore != newStore {
+		checks = append(checks, PreflightCheck{Name: "backing-store", Status: PreflightWarn, Message: fmt.Sprintf("backing store will be migrated from %s to %s, taking a snapshot first is recommended", currentStore, newStore)})
+	} else {
+		checks = append(checks, PreflightCheck{Name: "backing-store", Status: PreflightPass, Message: "backing store stays " + string(newStore)})
+	}
+
+	immutableErr := errors.Join(config.ValidateNamespaceSyncChanges(currentConfig, newConfig), config.ValidateVPNChanges(currentConfig, newConfig))
+	if immutableErr != nil {
+		checks = append(checks, PreflightCheck{Name: "immutable-settings", Status: PreflightFail, Message: immutableErr.Error()})
+	} else {
+		checks = append(checks, PreflightCheck{Name: "immutable-settings", Status: PreflightPass, Message: "no settings that can't be changed after creation are changed"})
+	}
+
+	return append(checks, kubernetesVersionCheck(virtualKubernetesVersion(currentConfig), virtualKubernetesVersion(newConfig)))
+}
+
+// deprecatedValues returns the findings of the deprecated field lint rule for the config.
+func deprecatedValues(vClusterConfig *config.Config) []string {
+	deprecations := []string{}
+	for _, finding := range pkgconfig.LintConfig(&pkgconfig.LintInput{Config: vClusterConfig}) {
+		if finding.RuleID == pkgconfig.DeprecatedFieldRuleID {
+			deprecations = append(deprecations, finding.Message)
+		}
+	}
+
+	return deprecations
+}
+
+func chartVersionCheck(currentVersion, newVersion string) PreflightCheck {
+	check := PreflightCheck{Name: "chart-version"}
+	current, target := "v"+strings.TrimPrefix(currentVersion, "v"), "v"+strings.TrimPrefix(newVersion, "v")
+	switch {
+	case newVersion == "":
+		check.Status, check.Message = PreflightSkip, "no chart version specified, helm will use the latest version"
+	case !semver.IsValid(current) || !semver.IsValid(target):
+		check.Status, check.Message = PreflightSkip, fmt.Sprintf("cannot compare chart versions %s and %s", currentVersion, newVersion)
+	case semver.Compare(target, current) < 0:
+		check.Status, check.Message = PreflightFail, fmt.Sprintf("downgrading from %s to %s is not supported", currentVersion, newVersion)
+	case semver.Compare(target, current) == 0:
+		check.Status, check.Message = PreflightPass, fmt.Sprintf("already on chart version %s, only the values will change", currentVersion)
+	default:
+		check.Status, check.Message = PreflightPass, fmt.Sprintf("upgrade from %s to %s", currentVersion, newVersion)
+		currentMajor, currentMinor, _ := parseMinorVersion(current)
+		targetMajor, targetMinor, _ := parseMinorVersion(target)
+		if currentMajor == targetMajor && targetMinor-currentMinor > 1 {
+			check.Status, check.Message = PreflightWarn, fmt.Sprintf("upgrade from %s to %s skips %d minor versions, make sure to read the release notes of each of them", currentVersion, newVersion, targetMinor-currentMinor-1)
+		}
+	}
+
+	return check
+}
+
+func kubernetesVersionCheck(currentVersion, newVersion string) PreflightCheck {
+	check := PreflightCheck{Name: "kubernetes-version"}
+	currentMajor, currentMinor, currentOK := parseMinorVersion(currentVersion)
+	newMajor, newMinor, newOK := parseMinorVersion(newVersion)
+	switch {
+	case newVersion == "":
+		check.Status, check.Message = PreflightSkip, "the default kubernetes version of the chart is used"
+	case !currentOK || !newOK:
+		check.Status, check.Message = PreflightSkip, fmt.Sprintf("cannot compare kubernetes versions %q and %q", currentVersion, newVersion)
+	case newMajor != currentMajor || newMinor < currentMinor:
+		check.Status, check.Message = PreflightFail, fmt.Sprintf("downgrading kubernetes from %s to %s is not supported", currentVersion, newVersion)
+	case newMinor-currentMinor > 1:
+		check.Status, check.Message = PreflightFail, fmt.Sprintf("kubernetes can only be upgraded one minor version at a time, but the upgrade goes from %s to %s", currentVersion, newVersion)
+	default:
+		check.Status, check.Message = PreflightPass, fmt.Sprintf("kubernetes version changes from %s to %s", currentVersion, newVersion)
+	}
+
+	return check
+}
+
+// virtualKubernetesVersion returns the kubernetes version the config pins, if any.
+func virtualKubernetesVersion(vConfig *config.Config) string {
+	if vConfig.ControlPlane.Distro.K3S.Enabled {
+		return vConfig.ControlPlane.Distro.K3S.Image.Tag
+	} else if vConfig.ControlPlane.Distro.K8S.Version != "" {
+		return vConfig.ControlPlane.Distro.K8S.Version
+	}
+
+	return vConfig.ControlPlane.Distro.K8S.Image.Tag
+}
+
+// parseMinorVersion parses the major and minor version of versions like v1.33.1 or v1.32.0-k3s1.
+func parseMinorVersion(version string) (int, int, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(strings.SplitN(parts[1], "-", 2)[0])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return major, minor, true
+}
+
+func printPreflightChecks(checks []PreflightCheck, log log.Logger) {
+	values := make([][]string, 0, len(checks))
+	for _, check := range checks {
+		values = append(values, []string{strings.ToUpper(string(check.Status)), check.Name, check.Message})
+	}
+
+	table.PrintTable(log, []string{"STATUS", "CHECK", "MESSAGE"}, values)
+}
+
+// upgradeSnapshot takes a snapshot of the vCluster via a snapshot request and waits until it has finished.
+func upgradeSnapshot(ctx context.Context, globalFlags *flags.GlobalFlags, vCluster *find.VCluster, options *UpgradeClusterOptions, log log.Logger) error {
+	snapshotOptions := &snapshot.Options{
+		IncludeVolumes: options.IncludeVolumes,
+	}
+	vCluster, kubeClient, _, err := initSnapshotCommand(ctx, []string{vCluster.Name, options.Snapshot}, globalFlags, snapshotOptions, log)
+	if err != nil {
+		return err
+	}
+	err = setSnapshotRelease(ctx, vCluster, kubeClient, snapshotOptions)
+	if err != nil {
+		return err
+	}
+
+	request, err := createSnapshotRequest(ctx, vCluster, kubeClient, snapshotOptions, log)
+	if err != nil {
+		return err
+	}
+
+	return waitForSnapshotRequest(ctx, kubeClient, vCluster.Namespace, request.Name, log)
+}
+
+// waitForVClusterRollout waits until the statefulset or deployment of the vCluster has rolled out all replicas.
+func waitForVClusterRollout(ctx context.Context, kubeClient kubernetes.Interface, vCluster *find.VCluster, timeout time.Duration) error {
+	return wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		if vCluster.StatefulSet != nil {
+			statefulSet, err := kubeClient.AppsV1().StatefulSets(vCluster.Namespace).Get(ctx, vCluster.StatefulSet.Name, metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+
+			return statefulSetRolledOut(statefulSet), nil
+		} else if vCluster.Deployment != nil {
+			deployment, err := kubeClient.AppsV1().Deployments(vCluster.Namespace).Get(ctx, vCluster.Deployment.Name, metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+
+			return deploymentRolledOut(deployment), nil
+		}
+
+		return true, nil
+	})
+}
+
+func statefulSetRolledOut(statefulSet *appsv1.StatefulSet) bool {
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+
+	status := statefulSet.Status
+	return status.ObservedGeneration >= statefulSet.Generation &&
+		status.UpdatedReplicas == replicas &&
+		status.ReadyReplicas == replicas &&
+		(status.UpdateRevision == "" || status.CurrentRevision == status.UpdateRevision)
+}
+
+func deploymentRolledOut(deployment *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	status := deployment.Status
+	return status.ObservedGeneration >= deployment.Generation &&
+		status.UpdatedReplicas == replicas &&
+		status.ReadyReplicas == replicas &&
+		status.Replicas == replicas
+}
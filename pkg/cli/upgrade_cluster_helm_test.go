@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/loft-sh/vcluster/config"
+	"gotest.tools/v3/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestUpgradePreflightChecks(t *testing.T) {
+	newConfig := func(mutate func(c *config.Config)) *config.Config {
+		c := &config.Config{}
+		c.ControlPlane.Distro.K8S.Enabled = true
+		c.ControlPlane.Distro.K8S.Image.Tag = "v1.32.1"
+		if mutate != nil {
+			mutate(c)
+		}
+		return c
+	}
+	statuses := func(checks []PreflightCheck) map[string]PreflightStatus {
+		ret := map[string]PreflightStatus{}
+		for _, check := range checks {
+			ret[check.Name] = check.Status
+		}
+		return ret
+	}
+
+	checks := upgradePreflightChecks("0.29.0", "0.30.0", newConfig(nil), newConfig(func(c *config.Config) {
+		c.ControlPlane.Distro.K8S.Image.Tag = "v1.33.0"
+	}), nil)
+	assert.DeepEqual(t, statuses(checks), map[string]PreflightStatus{
+		"chart-version":      PreflightPass,
+		"config":             PreflightPass,
+		"deprecated-values":  PreflightPass,
+		"distro":             PreflightPass,
+		"backing-store":      PreflightPass,
+		"immutable-settings": PreflightPass,
+		"kubernetes-version": PreflightPass,
+	})
+
+	checks = upgradePreflightChecks("0.29.0", "0.30.0", newConfig(nil), newConfig(func(c *config.Config) {
+		c.ControlPlane.Distro.K8S.Enabled = false
+		c.ControlPlane.Distro.K3S.Enabled = true
+		c.ControlPlane.Distro.K3S.Image.Tag = "v1.32.1-k3s1"
+		c.ControlPlane.BackingStore.Etcd.Deploy.Enabled = true
+	}), errors.New("invalid sync patches"))
+	status := statuses(checks)
+	assert.Equal(t, status["config"], PreflightFail)
+	assert.Equal(t, status["deprecated-values"], PreflightWarn)
+	assert.Equal(t, status["distro"], PreflightFail)
+	assert.Equal(t, status["backing-store"], PreflightWarn)
+	assert.Equal(t, status["kubernetes-version"], PreflightPass)
+
+	checks = upgradePreflightChecks("0.29.0", "0.30.0", newConfig(func(c *config.Config) {
+		c.ControlPlane.BackingStore.Etcd.Deploy.Enabled = true
+	}), newConfig(nil), nil)
+	assert.Equal(t, statuses(checks)["backing-store"], PreflightFail)
+
+	// only deprecated values are reported, not the findings of other lint rules
+	checks = upgradePreflightChecks("0.29.0", "0.30.0", newConfig(nil), newConfig(func(c *config.Config) {
+		c.SleepMode = &config.SleepMode{Enabled: true}
+		c.ExportKubeConfig.Secret.Name = "my-kubeconfig"
+	}), nil)
+	for _, check := range checks {
+		if check.Name == "deprecated-values" {
+			assert.Equal(t, check.Status, PreflightWarn)
+			assert.Equal(t, check.Message, "exportKubeConfig.secret is deprecated: Use AdditionalSecrets instead.")
+		}
+	}
+}
+
+func TestChartVersionCheck(t *testing.T) {
+	assert.Equal(t, chartVersionCheck("0.29.0", "0.30.1").Status, PreflightPass)
+	assert.Equal(t, chartVersionCheck("0.29.0", "v0.29.0").Status, PreflightPass)
+	assert.Equal(t, chartVersionCheck("0.29.0", "0.28.0").Status, PreflightFail)
+	assert.Equal(t, chartVersionCheck("0.26.0", "0.29.0").Status, PreflightWarn)
+	assert.Equal(t, chartVersionCheck("0.29.0", "").Status, PreflightSkip)
+	assert.Equal(t, chartVersionCheck("0.29.0", "latest").Status, PreflightSkip)
+}
+
+func TestKubernetesVersionCheck(t *testing.T) {
+	assert.Equal(t, kubernetesVersionCheck("v1.32.1", "v1.33.0").Status, PreflightPass)
+	assert.Equal(t, kubernetesVersionCheck("v1.32.1", "v1.32.4").Status, PreflightPass)
+	assert.Equal(t, kubernetesVersionCheck("v1.31.1", "v1.33.0").Status, PreflightFail)
+	assert.Equal(t, kubernetesVersionCheck("v1.33.1", "v1.32.0").Status, PreflightFail)
+	assert.Equal(t, kubernetesVersionCheck("v1.33.1", "").Status, PreflightSkip)
+	assert.Equal(t, kubernetesVersionCheck("", "v1.33.0").Status, PreflightSkip)
+}
+
+func TestRolledOut(t *testing.T) {
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Generation: 2},
+		Spec:       appsv1.StatefulSetSpec{Replicas: ptr.To[int32](3)},
+		Status: appsv1.StatefulSetStatus{
+			ObservedGeneration: 2,
+			UpdatedReplicas:    3,
+			ReadyReplicas:      3,
+			CurrentRevision:    "vcluster-2",
+			UpdateRevision:     "vcluster-2",
+		},
+	}
+	assert.Assert(t, statefulSetRolledOut(statefulSet))
+
+	statefulSet.Status.CurrentRevision = "vcluster-1"
+	assert.Assert(t, !statefulSetRolledOut(statefulSet))
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Generation: 3},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, UpdatedReplicas: 1, ReadyReplicas: 1, Replicas: 1},
+	}
+	assert.Assert(t, !deploymentRolledOut(deployment))
+
+	deployment.Status.ObservedGeneration = 3
+	assert.Assert(t, deploymentRolledOut(deployment))
+}
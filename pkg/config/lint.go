@@ -1,6 +1,15 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
 	"github.com/loft-sh/vcluster/config"
 )
 
@@ -14,15 +23,410 @@ const (
 		"scheduler to sync.toHost.pods.hybridScheduling.hostSchedulers, or disable the hybrid scheduling."
 )
 
+// DeprecatedFieldRuleID is the id of the lint rule that flags deprecated config fields.
+const DeprecatedFieldRuleID = "deprecated-field"
+
+// LintSeverity is the severity of a lint finding.
+type LintSeverity string
+
+const (
+	LintSeverityError   LintSeverity = "error"
+	LintSeverityWarning LintSeverity = "warning"
+	LintSeverityInfo    LintSeverity = "info"
+)
+
+// LintFinding is a single problem found by a lint rule.
+type LintFinding struct {
+	RuleID   string       `json:"ruleId"`
+	Severity LintSeverity `json:"severity"`
+	// Path is the path of the offending value within the config, e.g. sync.toHost.pods.patches[0]
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// LintInput is passed to every lint rule.
+type LintInput struct {
+	Config *config.Config
+
+	// HostNamespaceExists checks if a namespace exists in the host cluster. It is nil if the host cluster
+	// is not available, rules that need it are skipped in this case.
+	HostNamespaceExists func(name string) (bool, error)
+
+	// ProFeatures is true if the vCluster runs with the pro features, e.g. because it is created by the platform.
+	// Values that only work with pro features are flagged otherwise.
+	ProFeatures bool
+}
+
+// LintRule checks the config for a single kind of problem. Findings that don't set a severity get
+// the default severity of the rule.
+type LintRule struct {
+	ID          string
+	Severity    LintSeverity
+	Description string
+	Check       func(input *LintInput) []LintFinding
+}
+
+var lintRules = []LintRule{
+	{
+		ID:          "hybrid-scheduling-no-effect",
+		Severity:    LintSeverityWarning,
+		Description: "Hybrid scheduling is enabled together with the virtual scheduler, but without host schedulers",
+		Check:       lintHybridScheduling,
+	},
+	{
+		ID:          "sleep-mode-without-trigger",
+		Severity:    LintSeverityWarning,
+		Description: "Sleep mode is enabled without afterInactivity or a schedule, so the vCluster never sleeps",
+		Check:       lintSleepMode,
+	},
+	{
+		ID:          "conflicting-backing-stores",
+		Severity:    LintSeverityError,
+		Description: "An external database and embedded etcd are configured as backing store at the same time",
+		Check:       lintBackingStores,
+	},
+	{
+		ID:          "missing-host-namespace",
+		Severity:    LintSeverityError,
+		Description: "A fromHost mapping references a host namespace that doesn't exist",
+		Check:       lintFromHostNamespaces,
+	},
+	{
+		ID:          "patches-unavailable",
+		Severity:    LintSeverityError,
+		Description: "Translate patches are configured, but are not available in this edition",
+		Check:       lintPatches,
+	},
+	{
+		ID:          DeprecatedFieldRuleID,
+		Severity:    LintSeverityWarning,
+		Description: "A deprecated config field is used",
+		Check:       lintDeprecatedFields,
+	},
+	{
+		ID:          "broad-rbac-rule",
+		Severity:    LintSeverityWarning,
+		Description: "An extra RBAC rule grants access to all verbs, resources or api groups",
+		Check:       lintRBACRules,
+	},
+}
+
+// RegisterLintRule adds a rule to the rules that are checked by LintConfig.
+func RegisterLintRule(rule LintRule) {
+	lintRules = append(lintRules, rule)
+}
+
+// LintRules returns all registered lint rules.
+func LintRules() []LintRule {
+	return slices.Clone(lintRules)
+}
+
+// LintConfig runs all registered rules except the disabled ones against the config and returns the findings
+// sorted by path.
+func LintConfig(input *LintInput, disabledRules ...string) []LintFinding {
+	findings := []LintFinding{}
+	for _, rule := range lintRules {
+		if slices.Contains(disabledRules, rule.ID) {
+			continue
+		}
+
+		for _, finding := range rule.Check(input) {
+			finding.RuleID = rule.ID
+			if finding.Severity == "" {
+				finding.Severity = rule.Severity
+			}
+
+			findings = append(findings, finding)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Path < findings[j].Path
+	})
+	return findings
+}
+
 // Lint checks the virtual cluster config and returns warnings for the parts of the config
 // that should be probably corrected, but are not breaking any functionality in the cluster.
+// The lint rules of LintConfig are not part of these warnings.
 func Lint(config config.Config) []string {
 	var warnings []string
-	if config.IsVirtualSchedulerEnabled() &&
-		config.Sync.ToHost.Pods.HybridScheduling.Enabled &&
-		len(config.Sync.ToHost.Pods.HybridScheduling.HostSchedulers) == 0 {
-		warnings = append(warnings, HybridSchedulingNoEffectWarning)
+	for _, finding := range lintHybridScheduling(&LintInput{Config: &config}) {
+		warnings = append(warnings, finding.Message)
 	}
 
 	return warnings
 }
+
+func lintHybridScheduling(input *LintInput) []LintFinding {
+	if input.Config.IsVirtualSchedulerEnabled() &&
+		input.Config.Sync.ToHost.Pods.HybridScheduling.Enabled &&
+		len(input.Config.Sync.ToHost.Pods.HybridScheduling.HostSchedulers) == 0 {
+		return []LintFinding{{Path: "sync.toHost.pods.hybridScheduling", Message: HybridSchedulingNoEffectWarning}}
+	}
+
+	return nil
+}
+
+func lintSleepMode(input *LintInput) []LintFinding {
+	sleepMode := input.Config.SleepMode
+	if sleepMode == nil || !sleepMode.Enabled {
+		return nil
+	}
+	if sleepMode.AutoSleep.AfterInactivity == "" && sleepMode.AutoSleep.Schedule == "" {
+		return []LintFinding{{
+			Path:    "sleepMode.autoSleep",
+			Message: "sleepMode is enabled, but neither sleepMode.autoSleep.afterInactivity nor sleepMode.autoSleep.schedule is set, so the vCluster never goes to sleep",
+		}}
+	}
+
+	return nil
+}
+
+func lintBackingStores(input *LintInput) []LintFinding {
+	backingStore := input.Config.ControlPlane.BackingStore
+	if !backingStore.Etcd.Embedded.Enabled {
+		return nil
+	}
+
+	findings := []LintFinding{}
+	if backingStore.Database.External.Enabled || backingStore.Database.External.Connector != "" {
+		message := "controlPlane.backingStore.database.external and controlPlane.backingStore.etcd.embedded are both configured, only one backing store can be used"
+		if input.Config.ControlPlane.StatefulSet.HighAvailability.Replicas > 1 {
+			message += ". With multiple replicas every replica would run its own embedded etcd member next to the external database"
+		}
+		findings = append(findings, LintFinding{Path: "controlPlane.backingStore.database.external", Message: message})
+	}
+	if backingStore.Etcd.External.Enabled || backingStore.Etcd.Deploy.Enabled {
+		findings = append(findings, LintFinding{
+			Path:    "controlPlane.backingStore.etcd",
+			Message: "controlPlane.backingStore.etcd.embedded is enabled together with another etcd backing store, only one backing store can be used",
+		})
+	}
+
+	return findings
+}
+
+func lintFromHostNamespaces(input *LintInput) []LintFinding {
+	if input.HostNamespaceExists == nil {
+		return nil
+	}
+
+	type byNameMappings struct {
+		path   string
+		byName map[string]string
+	}
+	mappings := []byNameMappings{
+		{"sync.fromHost.configMaps.mappings.byName", input.Config.Sync.FromHost.ConfigMaps.Mappings.ByName},
+		{"sync.fromHost.secrets.mappings.byName", input.Config.Sync.FromHost.Secrets.Mappings.ByName},
+	}
+	for _, name := range slices.Sorted(maps.Keys(input.Config.Sync.FromHost.CustomResources)) {
+		mappings = append(mappings, byNameMappings{
+			path:   fmt.Sprintf("sync.fromHost.customResources[%q].mappings.byName", name),
+			byName: input.Config.Sync.FromHost.CustomResources[name].Mappings.ByName,
+		})
+	}
+
+	findings := []LintFinding{}
+	checked := map[string]bool{}
+	for _, mapping := range mappings {
+		for _, hostRef := range slices.Sorted(maps.Keys(mapping.byName)) {
+			// an empty namespace refers to the vCluster namespace
+			namespace, _, _ := strings.Cut(hostRef, "/")
+			if namespace == "" || namespace == "*" {
+				continue
+			}
+
+			exists, ok := checked[namespace]
+			if !ok {
+				var err error
+				exists, err = input.HostNamespaceExists(namespace)
+				if err != nil {
+					findings = append(findings, LintFinding{
+						Severity: LintSeverityInfo,
+						Path:     fmt.Sprintf("%s[%q]", mapping.path, hostRef),
+						Message:  fmt.Sprintf("couldn't check if host namespace %s exists: %v", namespace, err),
+					})
+					continue
+				}
+				checked[namespace] = exists
+			}
+			if !exists {
+				findings = append(findings, LintFinding{
+					Path:    fmt.Sprintf("%s[%q]", mapping.path, hostRef),
+					Message: fmt.Sprintf("host namespace %s doesn't exist, nothing will be synced from it", namespace),
+				})
+			}
+		}
+	}
+
+	return findings
+}
+
+func lintPatches(input *LintInput) []LintFinding {
+	if input.ProFeatures {
+		return nil
+	}
+
+	findings := []LintFinding{}
+	for _, p := range allSyncPatches(input.Config.Sync) {
+		if len(p.patches) > 0 {
+			findings = append(findings, LintFinding{
+				Path:    p.basePath + ".patches",
+				Message: fmt.Sprintf("%s.patches are configured, but translate patches are not available in this edition and the syncer will fail to sync these resources", p.basePath),
+			})
+		}
+	}
+
+	return findings
+}
+
+func lintDeprecatedFields(input *LintInput) []LintFinding {
+	defaultConfig, err := config.NewDefaultConfig()
+	if err != nil {
+		return nil
+	}
+	changes, err := config.Changes(defaultConfig, input.Config)
+	if err != nil {
+		return nil
+	}
+
+	findings := []LintFinding{}
+	for _, field := range deprecatedFields() {
+		// only values that are set count, not defaults that are missing from the config
+		if !slices.ContainsFunc(changes, func(change config.Change) bool {
+			return (change.Path == field.path || strings.HasPrefix(change.Path, field.path+".")) &&
+				change.To != nil && !reflect.ValueOf(change.To).IsZero()
+		}) {
+			continue
+		}
+
+		message := field.path + " is deprecated"
+		if field.notice != "" {
+			message += ": " + field.notice
+		}
+		findings = append(findings, LintFinding{
+			Path:    field.path,
+			Message: message,
+		})
+	}
+
+	return findings
+}
+
+type deprecatedField struct {
+	path   string
+	notice string
+}
+
+// deprecatedFields returns the paths of all config fields that are marked as deprecated in config/config.go sorted
+// by path. The markers end up in the descriptions of the generated json schema.
+var deprecatedFields = sync.OnceValue(func() []deprecatedField {
+	schema := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(config.Schema), &schema); err != nil {
+		return nil
+	}
+
+	defs, _ := schema["$defs"].(map[string]interface{})
+	fields := []deprecatedField{}
+	var walk func(prefix string, node map[string]interface{}, visited map[string]bool)
+	walk = func(prefix string, node map[string]interface{}, visited map[string]bool) {
+		properties, _ := node["properties"].(map[string]interface{})
+		for name, rawProperty := range properties {
+			property, _ := rawProperty.(map[string]interface{})
+			path := name
+			if prefix != "" {
+				path = prefix + "." + name
+			}
+
+			ref, _ := property["$ref"].(string)
+			ref = strings.TrimPrefix(ref, "#/$defs/")
+			def, _ := defs[ref].(map[string]interface{})
+			if notice, ok := deprecationNotice(property); ok {
+				fields = append(fields, deprecatedField{path: path, notice: notice})
+				continue
+			} else if notice, ok := deprecationNotice(def); ok {
+				fields = append(fields, deprecatedField{path: path, notice: notice})
+				continue
+			}
+
+			if def != nil && !visited[ref] {
+				visited[ref] = true
+				walk(path, def, visited)
+				delete(visited, ref)
+			}
+		}
+	}
+	walk("", schema, map[string]bool{})
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].path < fields[j].path
+	})
+	return fields
+})
+
+// deprecationNotice returns the text after a "Deprecated:" line or if the description starts with "[Deprecated]"
+func deprecationNotice(node map[string]interface{}) (string, bool) {
+	description, _ := node["description"].(string)
+	if strings.HasPrefix(description, "[Deprecated]") {
+		return "", true
+	}
+	for _, line := range strings.Split(description, "\n") {
+		if notice, ok := strings.CutPrefix(strings.TrimSpace(line), "Deprecated:"); ok {
+			return strings.TrimSpace(notice), true
+		}
+	}
+
+	return "", false
+}
+
+func lintRBACRules(input *LintInput) []LintFinding {
+	rules := []struct {
+		path  string
+		rules []map[string]interface{}
+	}{
+		{"rbac.role.extraRules", input.Config.RBAC.Role.ExtraRules},
+		{"rbac.role.overwriteRules", input.Config.RBAC.Role.OverwriteRules},
+		{"rbac.clusterRole.extraRules", input.Config.RBAC.ClusterRole.ExtraRules},
+		{"rbac.clusterRole.overwriteRules", input.Config.RBAC.ClusterRole.OverwriteRules},
+	}
+
+	findings := []LintFinding{}
+	for _, r := range rules {
+		for idx, rule := range r.rules {
+			wildcards := []string{}
+			for _, field := range []string{"verbs", "resources", "apiGroups", "nonResourceURLs"} {
+				if slices.Contains(ruleStrings(rule[field]), "*") {
+					wildcards = append(wildcards, field)
+				}
+			}
+			if len(wildcards) == 0 {
+				continue
+			}
+
+			findings = append(findings, LintFinding{
+				Path:    fmt.Sprintf("%s[%d]", r.path, idx),
+				Message: fmt.Sprintf("%s[%d] uses a wildcard for %s, grant only the access the vCluster needs", r.path, idx, strings.Join(wildcards, ", ")),
+			})
+		}
+	}
+
+	return findings
+}
+
+func ruleStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		ret := []string{}
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				ret = append(ret, str)
+			}
+		}
+		return ret
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/loft-sh/vcluster/config"
+	"gotest.tools/v3/assert"
+)
+
+func TestLintConfig(t *testing.T) {
+	defaultConfig, err := config.NewDefaultConfig()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, LintConfig(&LintInput{Config: defaultConfig}), []LintFinding{})
+
+	cases := []struct {
+		name        string
+		config      func(c *config.Config)
+		proFeatures bool
+		expected    []LintFinding
+	}{
+		{
+			name: "sleep mode without trigger",
+			config: func(c *config.Config) {
+				c.SleepMode = &config.SleepMode{Enabled: true}
+			},
+			expected: []LintFinding{{RuleID: "sleep-mode-without-trigger", Severity: LintSeverityWarning, Path: "sleepMode.autoSleep"}},
+		},
+		{
+			name: "sleep mode with schedule",
+			config: func(c *config.Config) {
+				c.SleepMode = &config.SleepMode{Enabled: true, AutoSleep: config.SleepModeAutoSleep{Schedule: "0 20 * * *"}}
+			},
+		},
+		{
+			name: "external database and embedded etcd",
+			config: func(c *config.Config) {
+				c.ControlPlane.BackingStore.Database.External.Connector = "db"
+				c.ControlPlane.BackingStore.Etcd.Embedded.Enabled = true
+				c.ControlPlane.StatefulSet.HighAvailability.Replicas = 3
+			},
+			expected: []LintFinding{{RuleID: "conflicting-backing-stores", Severity: LintSeverityError, Path: "controlPlane.backingStore.database.external"}},
+		},
+		{
+			name: "patches",
+			config: func(c *config.Config) {
+				c.Sync.ToHost.Pods.Patches = []config.TranslatePatch{{Path: "metadata.labels.a", Expression: "value"}}
+			},
+			expected: []LintFinding{{RuleID: "patches-unavailable", Severity: LintSeverityError, Path: "sync.toHost.pods.patches"}},
+		},
+		{
+			name: "patches with pro features",
+			config: func(c *config.Config) {
+				c.Sync.ToHost.Pods.Patches = []config.TranslatePatch{{Path: "metadata.labels.a", Expression: "value"}}
+			},
+			proFeatures: true,
+		},
+		{
+			name: "deprecated fields",
+			config: func(c *config.Config) {
+				c.ExportKubeConfig.Secret.Name = "my-kubeconfig"
+				c.ControlPlane.Advanced.VirtualScheduler.Enabled = true
+				c.ControlPlane.Distro.K3S.Enabled = true
+				c.Experimental.VirtualClusterKubeConfig.ServerCAKey = "/data/server-ca.key"
+			},
+			expected: []LintFinding{
+				{RuleID: "deprecated-field", Severity: LintSeverityWarning, Path: "controlPlane.advanced.virtualScheduler"},
+				{RuleID: "deprecated-field", Severity: LintSeverityWarning, Path: "controlPlane.distro.k3s"},
+				{RuleID: "deprecated-field", Severity: LintSeverityWarning, Path: "experimental.virtualClusterKubeConfig"},
+				{RuleID: "deprecated-field", Severity: LintSeverityWarning, Path: "exportKubeConfig.secret"},
+			},
+		},
+		{
+			name: "broad rbac rules",
+			config: func(c *config.Config) {
+				c.RBAC.ClusterRole.ExtraRules = []map[string]interface{}{
+					{"apiGroups": []interface{}{""}, "resources": []interface{}{"pods"}, "verbs": []interface{}{"get"}},
+					{"apiGroups": []interface{}{"*"}, "resources": []interface{}{"*"}, "verbs": []interface{}{"get"}},
+				}
+			},
+			expected: []LintFinding{{RuleID: "broad-rbac-rule", Severity: LintSeverityWarning, Path: "rbac.clusterRole.extraRules[1]"}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := config.NewDefaultConfig()
+			assert.NilError(t, err)
+			tc.config(c)
+
+			findings := LintConfig(&LintInput{Config: c, ProFeatures: tc.proFeatures})
+			for i := range findings {
+				assert.Assert(t, findings[i].Message != "")
+				findings[i].Message = ""
+			}
+			if tc.expected == nil {
+				tc.expected = []LintFinding{}
+			}
+			assert.DeepEqual(t, findings, tc.expected)
+		})
+	}
+}
+
+func TestLintFromHostNamespaces(t *testing.T) {
+	c, err := config.NewDefaultConfig()
+	assert.NilError(t, err)
+	c.Sync.FromHost.ConfigMaps.Mappings.ByName = map[string]string{
+		"":             "default/*",
+		"existing/*":   "existing/*",
+		"missing/my":   "default/my",
+		"forbidden/my": "default/my2",
+	}
+
+	// skipped without host access
+	assert.Equal(t, len(LintConfig(&LintInput{Config: c})), 0)
+
+	findings := LintConfig(&LintInput{
+		Config: c,
+		HostNamespaceExists: func(name string) (bool, error) {
+			if name == "forbidden" {
+				return false, errors.New("forbidden")
+			}
+			return name == "existing", nil
+		},
+	})
+	assert.Equal(t, len(findings), 2)
+	assert.Equal(t, findings[0].Path, `sync.fromHost.configMaps.mappings.byName["forbidden/my"]`)
+	assert.Equal(t, findings[0].Severity, LintSeverityInfo)
+	assert.Equal(t, findings[1].Path, `sync.fromHost.configMaps.mappings.byName["missing/my"]`)
+	assert.Equal(t, findings[1].Severity, LintSeverityError)
+
+	// disabled rules are skipped
+	assert.Equal(t, len(LintConfig(&LintInput{Config: c, HostNamespaceExists: func(string) (bool, error) { return false, nil }}, "missing-host-namespace")), 0)
+}
+
+func TestLint(t *testing.T) {
+	c, err := config.NewDefaultConfig()
+	assert.NilError(t, err)
+	c.ControlPlane.Distro.K8S.Scheduler.Enabled = true
+	c.Sync.ToHost.Pods.HybridScheduling.Enabled = true
+	assert.DeepEqual(t, Lint(*c), []string{HybridSchedulingNoEffectWarning})
+
+	// the lint rules are only checked by LintConfig
+	c.SleepMode = &config.SleepMode{Enabled: true}
+	c.ExportKubeConfig.Secret.Name = "my-kubeconfig"
+	assert.DeepEqual(t, Lint(*c), []string{HybridSchedulingNoEffectWarning})
+	assert.Equal(t, len(LintConfig(&LintInput{Config: c})), 3)
+}
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"net"
 	"net/url"
+	"path"
 	"slices"
 	"strings"
+	"text/template"
 
 	"github.com/ghodss/yaml"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
@@ -20,6 +22,7 @@ import (
 	cliconfig "github.com/loft-sh/vcluster/pkg/cli/config"
 	"github.com/loft-sh/vcluster/pkg/constants"
 	"github.com/loft-sh/vcluster/pkg/platform"
+	"github.com/loft-sh/vcluster/pkg/util/cron"
 	"github.com/loft-sh/vcluster/pkg/util/namespaces"
 	"github.com/loft-sh/vcluster/pkg/util/toleration"
 )
@@ -192,6 +195,12 @@ func ValidateConfigAndSetDefaults(vConfig *VirtualClusterConfig) error {
 		return err
 	}
 
+	// check snapshot schedules
+	err = validateSnapshotSchedules(vConfig.Snapshots.Schedules)
+	if err != nil {
+		return err
+	}
+
 	// pro validate config
 	err = ProValidateConfig(vConfig)
 	if err != nil {
@@ -230,37 +239,39 @@ type patchesValidation struct {
 
 // ValidateAllSyncPatches validates all sync patches
 func ValidateAllSyncPatches(sync config.Sync) error {
-	return validatePatches(
-		[]patchesValidation{
-			{"sync.toHost.configMaps", sync.ToHost.ConfigMaps.Patches},
-			{"sync.toHost.secrets", sync.ToHost.Secrets.Patches},
-			{"sync.toHost.endpoints", sync.ToHost.Endpoints.Patches},
-			{"sync.toHost.services", sync.ToHost.Services.Patches},
-			{"sync.toHost.pods", sync.ToHost.Pods.Patches},
-			{"sync.toHost.serviceAccounts", sync.ToHost.ServiceAccounts.Patches},
-			{"sync.toHost.ingresses", sync.ToHost.Ingresses.Patches},
-			{"sync.toHost.namespaces", sync.ToHost.Namespaces.Patches},
-			{"sync.toHost.networkPolicies", sync.ToHost.NetworkPolicies.Patches},
-			{"sync.toHost.persistentVolumeClaims", sync.ToHost.PersistentVolumeClaims.Patches},
-			{"sync.toHost.persistentVolumes", sync.ToHost.PersistentVolumes.Patches},
-			{"sync.toHost.podDisruptionBudgets", sync.ToHost.PodDisruptionBudgets.Patches},
-			{"sync.toHost.priorityClasses", sync.ToHost.PriorityClasses.Patches},
-			{"sync.toHost.storageClasses", sync.ToHost.StorageClasses.Patches},
-			{"sync.toHost.volumeSnapshots", sync.ToHost.VolumeSnapshots.Patches},
-			{"sync.toHost.volumeSnapshotContents", sync.ToHost.VolumeSnapshotContents.Patches},
-			{"sync.fromHost.nodes", sync.FromHost.Nodes.Patches},
-			{"sync.fromHost.storageClasses", sync.FromHost.StorageClasses.Patches},
-			{"sync.fromHost.priorityClasses", sync.FromHost.PriorityClasses.Patches},
-			{"sync.fromHost.ingressClasses", sync.FromHost.IngressClasses.Patches},
-			{"sync.fromHost.csiDrivers", sync.FromHost.CSIDrivers.Patches},
-			{"sync.fromHost.runtimeClasses", sync.FromHost.RuntimeClasses.Patches},
-			{"sync.fromHost.csiNodes", sync.FromHost.CSINodes.Patches},
-			{"sync.fromHost.csiStorageCapacities", sync.FromHost.CSIStorageCapacities.Patches},
-			{"sync.fromHost.events", sync.FromHost.Events.Patches},
-			{"sync.fromHost.volumeSnapshotClasses", sync.FromHost.VolumeSnapshotClasses.Patches},
-			{"sync.fromHost.configMaps", sync.FromHost.ConfigMaps.Patches},
-		}...,
-	)
+	return validatePatches(allSyncPatches(sync)...)
+}
+
+func allSyncPatches(sync config.Sync) []patchesValidation {
+	return []patchesValidation{
+		{"sync.toHost.configMaps", sync.ToHost.ConfigMaps.Patches},
+		{"sync.toHost.secrets", sync.ToHost.Secrets.Patches},
+		{"sync.toHost.endpoints", sync.ToHost.Endpoints.Patches},
+		{"sync.toHost.services", sync.ToHost.Services.Patches},
+		{"sync.toHost.pods", sync.ToHost.Pods.Patches},
+		{"sync.toHost.serviceAccounts", sync.ToHost.ServiceAccounts.Patches},
+		{"sync.toHost.ingresses", sync.ToHost.Ingresses.Patches},
+		{"sync.toHost.namespaces", sync.ToHost.Namespaces.Patches},
+		{"sync.toHost.networkPolicies", sync.ToHost.NetworkPolicies.Patches},
+		{"sync.toHost.persistentVolumeClaims", sync.ToHost.PersistentVolumeClaims.Patches},
+		{"sync.toHost.persistentVolumes", sync.ToHost.PersistentVolumes.Patches},
+		{"sync.toHost.podDisruptionBudgets", sync.ToHost.PodDisruptionBudgets.Patches},
+		{"sync.toHost.priorityClasses", sync.ToHost.PriorityClasses.Patches},
+		{"sync.toHost.storageClasses", sync.ToHost.StorageClasses.Patches},
+		{"sync.toHost.volumeSnapshots", sync.ToHost.VolumeSnapshots.Patches},
+		{"sync.toHost.volumeSnapshotContents", sync.ToHost.VolumeSnapshotContents.Patches},
+		{"sync.fromHost.nodes", sync.FromHost.Nodes.Patches},
+		{"sync.fromHost.storageClasses", sync.FromHost.StorageClasses.Patches},
+		{"sync.fromHost.priorityClasses", sync.FromHost.PriorityClasses.Patches},
+		{"sync.fromHost.ingressClasses", sync.FromHost.IngressClasses.Patches},
+		{"sync.fromHost.csiDrivers", sync.FromHost.CSIDrivers.Patches},
+		{"sync.fromHost.runtimeClasses", sync.FromHost.RuntimeClasses.Patches},
+		{"sync.fromHost.csiNodes", sync.FromHost.CSINodes.Patches},
+		{"sync.fromHost.csiStorageCapacities", sync.FromHost.CSIStorageCapacities.Patches},
+		{"sync.fromHost.events", sync.FromHost.Events.Patches},
+		{"sync.fromHost.volumeSnapshotClasses", sync.FromHost.VolumeSnapshotClasses.Patches},
+		{"sync.fromHost.configMaps", sync.FromHost.ConfigMaps.Patches},
+	}
 }
 
 func validatePatches(patchesValidation ...patchesValidation) error {
@@ -602,6 +613,84 @@ func validateFromHostSyncMappingObjectName(objRef []string, resourceNamePlural s
 	return nil
 }
 
+func validateSnapshotSchedules(schedules []config.SnapshotSchedule) error {
+	names := map[string]bool{}
+	for idx, schedule := range schedules {
+		if schedule.Name == "" {
+			return fmt.Errorf("snapshots.schedules[%d].name is required", idx)
+		} else if names[schedule.Name] {
+			return fmt.Errorf("snapshots.schedules[%d].name %s is used by multiple schedules", idx, schedule.Name)
+		}
+		names[schedule.Name] = true
+
+		_, err := cron.Parse(schedule.Schedule)
+		if err != nil {
+			return fmt.Errorf("snapshots.schedules[%d].schedule: %w", idx, err)
+		}
+
+		if schedule.URL == "" {
+			return fmt.Errorf("snapshots.schedules[%d].url is required", idx)
+		} else if !slices.ContainsFunc(snapshotScheduleSchemes, func(scheme string) bool { return strings.HasPrefix(schedule.URL, scheme+"://") }) {
+			return fmt.Errorf("snapshots.schedules[%d].url needs to start with one of '%s'", idx, strings.Join(snapshotScheduleSchemes, "://', '")+"://")
+		}
+		_, err = template.New("url").Parse(schedule.URL)
+		if err != nil {
+			return fmt.Errorf("snapshots.schedules[%d].url: %w", idx, err)
+		}
+
+		retention := schedule.Retention
+		if retention.KeepLast < 0 || retention.KeepDaily < 0 || retention.KeepWeekly < 0 || retention.KeepMonthly < 0 {
+			return fmt.Errorf("snapshots.schedules[%d].retention values must not be negative", idx)
+		}
+		if retention.KeepLast > 0 || retention.KeepDaily > 0 || retention.KeepWeekly > 0 || retention.KeepMonthly > 0 {
+			err = validateSnapshotRetentionURL(schedule.URL)
+			if err != nil {
+				return fmt.Errorf("snapshots.schedules[%d].url: %w", idx, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// snapshotScheduleSchemes are the snapshot url schemes that can be used in snapshot schedules
+var snapshotScheduleSchemes = []string{"s3", "container", "oci", "file", "gs", "azblob"}
+
+// validateSnapshotRetentionURL checks that the snapshots of a schedule can be listed and told apart by their
+// file name, which is what the retention of a schedule relies on
+func validateSnapshotRetentionURL(urlTemplate string) error {
+	if strings.HasPrefix(urlTemplate, "oci://") {
+		return errors.New("retention is not supported for oci snapshots")
+	}
+
+	// render the template with a marker for the timestamp, the other fields are fixed per schedule
+	const marker = "TIMESTAMP"
+	t, err := template.New("url").Option("missingkey=error").Parse(urlTemplate)
+	if err != nil {
+		return err
+	}
+	rendered := &strings.Builder{}
+	err = t.Execute(rendered, map[string]string{
+		"Name":      "name",
+		"Namespace": "namespace",
+		"Schedule":  "schedule",
+		"Timestamp": marker,
+	})
+	if err != nil {
+		return err
+	}
+
+	parsedURL, err := url.Parse(rendered.String())
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(path.Base(parsedURL.Path), marker) || strings.Contains(path.Dir(parsedURL.Path), marker) || !strings.HasSuffix(parsedURL.Path, ".tar.gz") {
+		return errors.New("retention requires {{.Timestamp}} in a file name ending with .tar.gz, e.g. s3://bucket/{{.Name}}/{{.Timestamp}}.tar.gz")
+	}
+
+	return nil
+}
+
 func validateExportKubeConfig(exportKubeConfig config.ExportKubeConfig) error {
 	// You cannot set both Secret and AdditionalSecrets at the same time.
 	if exportKubeConfig.Secret.IsSet() && len(exportKubeConfig.AdditionalSecrets) > 0 {
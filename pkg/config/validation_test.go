@@ -1819,3 +1819,103 @@ func noErrExpected(t *testing.T, err error) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestValidateSnapshotSchedules(t *testing.T) {
+	cases := []struct {
+		name          string
+		schedule      config.SnapshotSchedule
+		expectedError string
+	}{
+		{
+			name:     "s3 url is valid",
+			schedule: config.SnapshotSchedule{URL: "s3://my-bucket/{{.Name}}/{{.Timestamp}}.tar.gz"},
+		},
+		{
+			name:     "file url is valid",
+			schedule: config.SnapshotSchedule{URL: "file:///snapshots/{{.Name}}/{{.Timestamp}}.tar.gz"},
+		},
+		{
+			name:     "gs url is valid",
+			schedule: config.SnapshotSchedule{URL: "gs://my-bucket/{{.Name}}/{{.Timestamp}}.tar.gz"},
+		},
+		{
+			name:     "azblob url is valid",
+			schedule: config.SnapshotSchedule{URL: "azblob://my-account/my-container/{{.Name}}/{{.Timestamp}}.tar.gz"},
+		},
+		{
+			name:          "unknown url scheme is invalid",
+			schedule:      config.SnapshotSchedule{URL: "ftp://my-server/{{.Timestamp}}.tar.gz"},
+			expectedError: "url needs to start with one of 's3://', 'container://', 'oci://', 'file://', 'gs://', 'azblob://'",
+		},
+		{
+			name:          "missing url is invalid",
+			expectedError: "url is required",
+		},
+		{
+			name: "retention with timestamp file name is valid",
+			schedule: config.SnapshotSchedule{
+				URL:       "container:///data/snapshots/{{.Schedule}}-{{.Timestamp}}.tar.gz",
+				Retention: config.SnapshotRetention{KeepLast: 3},
+			},
+		},
+		{
+			name: "retention without .tar.gz file name is invalid",
+			schedule: config.SnapshotSchedule{
+				URL:       "s3://my-bucket/{{.Name}}/{{.Timestamp}}",
+				Retention: config.SnapshotRetention{KeepDaily: 7},
+			},
+			expectedError: "retention requires {{.Timestamp}} in a file name ending with .tar.gz",
+		},
+		{
+			name: "retention with timestamp in directory is invalid",
+			schedule: config.SnapshotSchedule{
+				URL:       "s3://my-bucket/{{.Timestamp}}/snapshot.tar.gz",
+				Retention: config.SnapshotRetention{KeepLast: 3},
+			},
+			expectedError: "retention requires {{.Timestamp}} in a file name ending with .tar.gz",
+		},
+		{
+			name: "retention without timestamp is invalid",
+			schedule: config.SnapshotSchedule{
+				URL:       "s3://my-bucket/{{.Name}}/snapshot.tar.gz",
+				Retention: config.SnapshotRetention{KeepLast: 3},
+			},
+			expectedError: "retention requires {{.Timestamp}} in a file name ending with .tar.gz",
+		},
+		{
+			name: "retention with oci url is invalid",
+			schedule: config.SnapshotSchedule{
+				URL:       "oci://ghcr.io/my-org/snapshots:{{.Timestamp}}",
+				Retention: config.SnapshotRetention{KeepLast: 3},
+			},
+			expectedError: "retention is not supported for oci snapshots",
+		},
+		{
+			name: "oci url without retention is valid",
+			schedule: config.SnapshotSchedule{
+				URL: "oci://ghcr.io/my-org/snapshots:{{.Timestamp}}",
+			},
+		},
+		{
+			name: "unknown template field is invalid",
+			schedule: config.SnapshotSchedule{
+				URL:       "s3://my-bucket/{{.Cluster}}/{{.Timestamp}}.tar.gz",
+				Retention: config.SnapshotRetention{KeepLast: 3},
+			},
+			expectedError: "Cluster",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.schedule.Name = "nightly"
+			tc.schedule.Schedule = "0 3 * * *"
+			err := validateSnapshotSchedules([]config.SnapshotSchedule{tc.schedule})
+			if tc.expectedError == "" && err != nil {
+				t.Errorf("expected validation to pass, but got error: %v", err)
+			} else if tc.expectedError != "" && (err == nil || !strings.Contains(err.Error(), tc.expectedError)) {
+				t.Errorf("expected validation to fail with %q, but got: %v", tc.expectedError, err)
+			}
+		})
+	}
+}
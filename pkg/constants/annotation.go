@@ -29,6 +29,12 @@ const (
 
 	// SleepModeLastActivityAnnotation tracks the last time a vCluster received an API request
 	SleepModeLastActivityAnnotation = "vcluster.loft.sh/last-activity"
+
+	// VClusterExtraSANsAnnotation is a comma separated list of extra hostnames and ips on the vCluster service
+	// the cert syncer signs the proxy certificate for, e.g. the addresses vcluster connect --expose created. This is a
+	// privileged input: whoever can annotate the vCluster service in the host namespace decides which names the proxy
+	// certificate is valid for, so entries that are neither a DNS-1123 subdomain nor an ip are ignored.
+	VClusterExtraSANsAnnotation = "vcluster.loft.sh/extra-sans"
 )
 
 func PausedAnnotation(isRestore bool) string {
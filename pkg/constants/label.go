@@ -9,4 +9,7 @@ const (
 
 	// RestoreRequestLabel is used to label ConfigMaps as restore requests.
 	RestoreRequestLabel = "vcluster.loft.sh/restore-request"
+
+	// RBACMappingLabel is used to label virtual role bindings that are managed from rbac.identityMappings.
+	RBACMappingLabel = "vcluster.loft.sh/rbac-mapping"
 )
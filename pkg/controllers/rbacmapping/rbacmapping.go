@@ -0,0 +1,277 @@
+package rbacmapping
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+
+	vclusterconfig "github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/pkg/constants"
+	"github.com/loft-sh/vcluster/pkg/util/loghelper"
+	"github.com/loft-sh/vcluster/pkg/util/translate"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Binding is a virtual role binding that results from an identity mapping. If Namespace is empty, the binding is a
+// cluster role binding.
+type Binding struct {
+	// Mapping is the index of the identity mapping the binding results from
+	Mapping int
+
+	Name        string
+	Namespace   string
+	ClusterRole string
+	Subjects    []rbacv1.Subject
+}
+
+// Subjects returns the virtual rbac subjects of the mapping with the prefix applied
+func Subjects(mapping vclusterconfig.RBACIdentityMapping) []rbacv1.Subject {
+	subjects := []rbacv1.Subject{}
+	for _, user := range mapping.Users {
+		subjects = append(subjects, rbacv1.Subject{
+			Kind:     rbacv1.UserKind,
+			APIGroup: rbacv1.GroupName,
+			Name:     mapping.Prefix + user,
+		})
+	}
+	for _, group := range mapping.Groups {
+		subjects = append(subjects, rbacv1.Subject{
+			Kind:     rbacv1.GroupKind,
+			APIGroup: rbacv1.GroupName,
+			Name:     mapping.Prefix + group,
+		})
+	}
+
+	return subjects
+}
+
+// Matches returns true if the host user or one of its groups is part of the mapping. The user and groups are
+// the names the host api server authenticated, so they are compared with the names of the mapping with the
+// prefix applied.
+func Matches(mapping vclusterconfig.RBACIdentityMapping, user string, groups []string) bool {
+	containsName := func(names []string, name string) bool {
+		return slices.ContainsFunc(names, func(n string) bool {
+			return mapping.Prefix+n == name
+		})
+	}
+	if user != "" && containsName(mapping.Users, user) {
+		return true
+	}
+	for _, group := range groups {
+		if containsName(mapping.Groups, group) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DesiredBindings returns all virtual bindings that should exist for the given mappings. Mappings that result in
+// the same binding only return it once.
+func DesiredBindings(mappings []vclusterconfig.RBACIdentityMapping) []Binding {
+	bindings := []Binding{}
+	seen := map[types.NamespacedName]bool{}
+	add := func(binding Binding) {
+		key := types.NamespacedName{Namespace: binding.Namespace, Name: binding.Name}
+		if !seen[key] {
+			seen[key] = true
+			bindings = append(bindings, binding)
+		}
+	}
+	for idx, mapping := range mappings {
+		subjects := Subjects(mapping)
+		if len(subjects) == 0 {
+			continue
+		}
+
+		for _, clusterRole := range mapping.ClusterRoles {
+			add(Binding{
+				Mapping:     idx,
+				Name:        bindingName(subjects, clusterRole),
+				ClusterRole: clusterRole,
+				Subjects:    subjects,
+			})
+		}
+		for _, namespace := range mapping.Namespaces {
+			for _, role := range mapping.Roles {
+				add(Binding{
+					Mapping:     idx,
+					Name:        bindingName(subjects, role),
+					Namespace:   namespace,
+					ClusterRole: role,
+					Subjects:    subjects,
+				})
+			}
+		}
+	}
+
+	return bindings
+}
+
+// bindingName derives the name from the subjects and the role, so that reordering or removing other mappings
+// doesn't rename the bindings of a mapping
+func bindingName(subjects []rbacv1.Subject, role string) string {
+	hash := sha256.New()
+	for _, subject := range subjects {
+		_, _ = fmt.Fprintf(hash, "%s/%s\n", subject.Kind, subject.Name)
+	}
+	_, _ = hash.Write([]byte(role))
+	return translate.SafeConcatName("vcluster", "mapping", role, hex.EncodeToString(hash.Sum(nil))[:10])
+}
+
+type Reconciler struct {
+	client.Client
+	Mappings []vclusterconfig.RBACIdentityMapping
+	Log      loghelper.Logger
+}
+
+func (r *Reconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+	clusterRoleBindings := &rbacv1.ClusterRoleBindingList{}
+	err := r.Client.List(ctx, clusterRoleBindings, client.HasLabels{constants.RBACMappingLabel})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	roleBindings := &rbacv1.RoleBindingList{}
+	err = r.Client.List(ctx, roleBindings, client.HasLabels{constants.RBACMappingLabel})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	existing := map[types.NamespacedName]client.Object{}
+	for i := range clusterRoleBindings.Items {
+		existing[types.NamespacedName{Name: clusterRoleBindings.Items[i].Name}] = &clusterRoleBindings.Items[i]
+	}
+	for i := range roleBindings.Items {
+		existing[client.ObjectKeyFromObject(&roleBindings.Items[i])] = &roleBindings.Items[i]
+	}
+
+	for _, binding := range DesiredBindings(r.Mappings) {
+		key := types.NamespacedName{Namespace: binding.Namespace, Name: binding.Name}
+		current := existing[key]
+		delete(existing, key)
+
+		// role bindings are created once their namespace exists
+		if binding.Namespace != "" && current == nil {
+			err = r.Client.Get(ctx, types.NamespacedName{Name: binding.Namespace}, &corev1.Namespace{})
+			if kerrors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		err = r.apply(ctx, binding, current)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// remove bindings of mappings that were removed
+	for _, obj := range existing {
+		r.Log.Infof("delete %s %s as it is not mapped anymore", kindOf(obj), client.ObjectKeyFromObject(obj).String())
+		err = r.Client.Delete(ctx, obj)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *Reconciler) apply(ctx context.Context, binding Binding, current client.Object) error {
+	roleRef := rbacv1.RoleRef{
+		APIGroup: rbacv1.GroupName,
+		Kind:     "ClusterRole",
+		Name:     binding.ClusterRole,
+	}
+	meta := metav1.ObjectMeta{
+		Name:      binding.Name,
+		Namespace: binding.Namespace,
+		Labels:    map[string]string{constants.RBACMappingLabel: "true"},
+	}
+
+	var desired client.Object
+	if binding.Namespace == "" {
+		desired = &rbacv1.ClusterRoleBinding{ObjectMeta: meta, RoleRef: roleRef, Subjects: binding.Subjects}
+	} else {
+		desired = &rbacv1.RoleBinding{ObjectMeta: meta, RoleRef: roleRef, Subjects: binding.Subjects}
+	}
+
+	if current != nil {
+		currentRoleRef, currentSubjects := roleRefAndSubjects(current)
+		if currentRoleRef == roleRef && equality.Semantic.DeepEqual(currentSubjects, binding.Subjects) {
+			return nil
+		}
+
+		// the role ref is immutable, so we need to recreate the binding
+		if currentRoleRef != roleRef {
+			err := r.Client.Delete(ctx, current)
+			if err != nil && !kerrors.IsNotFound(err) {
+				return err
+			}
+		} else {
+			r.Log.Infof("update subjects of %s %s", kindOf(desired), client.ObjectKeyFromObject(desired).String())
+			desired.SetResourceVersion(current.GetResourceVersion())
+			return r.Client.Update(ctx, desired)
+		}
+	}
+
+	r.Log.Infof("create %s %s", kindOf(desired), client.ObjectKeyFromObject(desired).String())
+	return r.Client.Create(ctx, desired)
+}
+
+func roleRefAndSubjects(obj client.Object) (rbacv1.RoleRef, []rbacv1.Subject) {
+	switch binding := obj.(type) {
+	case *rbacv1.ClusterRoleBinding:
+		return binding.RoleRef, binding.Subjects
+	case *rbacv1.RoleBinding:
+		return binding.RoleRef, binding.Subjects
+	}
+
+	return rbacv1.RoleRef{}, nil
+}
+
+func kindOf(obj client.Object) string {
+	if _, ok := obj.(*rbacv1.ClusterRoleBinding); ok {
+		return "cluster role binding"
+	}
+
+	return "role binding"
+}
+
+// SetupWithManager adds the controller to the manager
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// all events result in a single reconcile of all mappings
+	eventHandler := handler.EnqueueRequestsFromMapFunc(func(_ context.Context, _ client.Object) []reconcile.Request {
+		return []reconcile.Request{{
+			NamespacedName: types.NamespacedName{Name: "rbac-mapping"},
+		}}
+	})
+	managedPredicate := predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return object.GetLabels()[constants.RBACMappingLabel] == "true"
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(controller.Options{
+			CacheSyncTimeout: constants.DefaultCacheSyncTimeout,
+		}).
+		Named("rbac_mapping").
+		Watches(&corev1.Namespace{}, eventHandler).
+		Watches(&rbacv1.ClusterRoleBinding{}, eventHandler, builder.WithPredicates(managedPredicate)).
+		Watches(&rbacv1.RoleBinding{}, eventHandler, builder.WithPredicates(managedPredicate)).
+		Complete(r)
+}
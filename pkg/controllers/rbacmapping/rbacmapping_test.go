@@ -0,0 +1,108 @@
+package rbacmapping
+
+import (
+	"context"
+	"testing"
+
+	vclusterconfig "github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/pkg/scheme"
+	"github.com/loft-sh/vcluster/pkg/util/loghelper"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+	).Build()
+
+	reconciler := &Reconciler{
+		Client: fakeClient,
+		Mappings: []vclusterconfig.RBACIdentityMapping{
+			{
+				Groups:     []string{"team-a-devs"},
+				Prefix:     "oidc:",
+				Roles:      []string{"edit"},
+				Namespaces: []string{"app", "missing"},
+			},
+			{
+				Users:        []string{"alice"},
+				ClusterRoles: []string{"view"},
+			},
+		},
+		Log: loghelper.New("test"),
+	}
+	_, err := reconciler.Reconcile(ctx, ctrl.Request{})
+	assert.NilError(t, err)
+
+	aliceView := bindingName(Subjects(reconciler.Mappings[1]), "view")
+	roleBinding := &rbacv1.RoleBinding{}
+	err = fakeClient.Get(ctx, types.NamespacedName{Namespace: "app", Name: bindingName(Subjects(reconciler.Mappings[0]), "edit")}, roleBinding)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, roleBinding.Subjects, []rbacv1.Subject{{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: "oidc:team-a-devs"}})
+	assert.Equal(t, roleBinding.RoleRef.Name, "edit")
+
+	roleBindings := &rbacv1.RoleBindingList{}
+	assert.NilError(t, fakeClient.List(ctx, roleBindings, client.InNamespace("missing")))
+	assert.Equal(t, len(roleBindings.Items), 0)
+
+	clusterRoleBinding := &rbacv1.ClusterRoleBinding{}
+	err = fakeClient.Get(ctx, types.NamespacedName{Name: aliceView}, clusterRoleBinding)
+	assert.NilError(t, err)
+	assert.Equal(t, clusterRoleBinding.Subjects[0].Name, "alice")
+
+	// removing a mapping deletes its bindings and keeps the names of the others
+	reconciler.Mappings = reconciler.Mappings[1:]
+	_, err = reconciler.Reconcile(ctx, ctrl.Request{})
+	assert.NilError(t, err)
+
+	assert.NilError(t, fakeClient.List(ctx, roleBindings))
+	assert.Equal(t, len(roleBindings.Items), 0)
+
+	clusterRoleBindings := &rbacv1.ClusterRoleBindingList{}
+	assert.NilError(t, fakeClient.List(ctx, clusterRoleBindings))
+	assert.Equal(t, len(clusterRoleBindings.Items), 1)
+	assert.Equal(t, clusterRoleBindings.Items[0].Name, aliceView)
+
+	// changing the role recreates the binding
+	reconciler.Mappings[0].ClusterRoles = []string{"edit"}
+	_, err = reconciler.Reconcile(ctx, ctrl.Request{})
+	assert.NilError(t, err)
+
+	clusterRoleBindings = &rbacv1.ClusterRoleBindingList{}
+	assert.NilError(t, fakeClient.List(ctx, clusterRoleBindings))
+	assert.Equal(t, len(clusterRoleBindings.Items), 1)
+	assert.Equal(t, clusterRoleBindings.Items[0].RoleRef.Name, "edit")
+}
+
+func TestMatches(t *testing.T) {
+	mapping := vclusterconfig.RBACIdentityMapping{
+		Users:  []string{"alice"},
+		Groups: []string{"team-a-devs"},
+	}
+
+	assert.Assert(t, Matches(mapping, "alice", nil))
+	assert.Assert(t, Matches(mapping, "bob", []string{"system:authenticated", "team-a-devs"}))
+	assert.Assert(t, !Matches(mapping, "bob", []string{"system:authenticated"}))
+
+	// the names are compared with the prefix applied
+	mapping.Prefix = "oidc:"
+	assert.Assert(t, Matches(mapping, "oidc:alice", nil))
+	assert.Assert(t, Matches(mapping, "bob", []string{"oidc:team-a-devs"}))
+	assert.Assert(t, !Matches(mapping, "alice", nil))
+	assert.Assert(t, !Matches(mapping, "bob", []string{"team-a-devs"}))
+}
+
+func TestDesiredBindingsDeduplicates(t *testing.T) {
+	mapping := vclusterconfig.RBACIdentityMapping{Users: []string{"alice"}, ClusterRoles: []string{"view"}}
+	bindings := DesiredBindings([]vclusterconfig.RBACIdentityMapping{mapping, mapping})
+	assert.Equal(t, len(bindings), 1)
+	assert.Equal(t, bindings[0].Name, bindingName(Subjects(mapping), "view"))
+}
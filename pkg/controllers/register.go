@@ -22,6 +22,7 @@ import (
 	"github.com/loft-sh/vcluster/pkg/controllers/coredns"
 	"github.com/loft-sh/vcluster/pkg/controllers/k8sdefaultendpoint"
 	"github.com/loft-sh/vcluster/pkg/controllers/podsecurity"
+	"github.com/loft-sh/vcluster/pkg/controllers/rbacmapping"
 	"github.com/loft-sh/vcluster/pkg/controllers/sleepmode"
 	"github.com/loft-sh/vcluster/pkg/snapshot"
 	csiVolumeSnapshots "github.com/loft-sh/vcluster/pkg/snapshot/volumes/csi/deploy"
@@ -47,6 +48,14 @@ func RegisterControllers(ctx *synccontext.ControllerContext, syncers []syncertyp
 		}
 	}
 
+	// register controller that maps host users and groups to virtual role bindings
+	if len(ctx.Config.RBAC.IdentityMappings) > 0 {
+		err := registerRBACMappingController(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	// register sleep mode controller and activity tracking
 	if ctx.Config.SleepMode != nil && ctx.Config.SleepMode.Enabled {
 		err := registerSleepModeController(ctx)
@@ -274,6 +283,19 @@ func registerPodSecurityController(ctx *synccontext.ControllerContext) error {
 	return nil
 }
 
+func registerRBACMappingController(ctx *synccontext.ControllerContext) error {
+	controller := &rbacmapping.Reconciler{
+		Client:   ctx.VirtualManager.GetClient(),
+		Mappings: ctx.Config.RBAC.IdentityMappings,
+		Log:      loghelper.New("rbac-mapping-controller"),
+	}
+	err := controller.SetupWithManager(ctx.VirtualManager)
+	if err != nil {
+		return fmt.Errorf("unable to setup rbac mapping controller: %w", err)
+	}
+	return nil
+}
+
 func registerSleepModeController(ctx *synccontext.ControllerContext) error {
 	logger := loghelper.New("sleepmode-controller")
 	controller := &sleepmode.SleepModeReconciler{
@@ -300,6 +322,26 @@ func registerSnapshotController(registerContext *synccontext.RegisterContext) er
 		return fmt.Errorf("unable to register vcluster snapshot controller: %w", err)
 	}
 
+	// register scheduler that periodically creates snapshot requests
+	if len(registerContext.Config.Snapshots.Schedules) > 0 {
+		scheduler, err := snapshot.NewScheduler(registerContext)
+		if err != nil {
+			return fmt.Errorf("unable to create vcluster snapshot scheduler: %w", err)
+		}
+		err = registerContext.HostManager.Add(scheduler)
+		if err != nil {
+			return fmt.Errorf("unable to register vcluster snapshot scheduler: %w", err)
+		}
+	}
+
+	// register controllers for the VirtualClusterSnapshot and VirtualClusterRestore resources
+	if registerContext.Config.Snapshots.CustomResources.Enabled {
+		err = snapshot.RegisterResourceControllers(registerContext)
+		if err != nil {
+			return fmt.Errorf("unable to register vcluster snapshot resource controllers: %w", err)
+		}
+	}
+
 	config := registerContext.Config
 	if config.PrivateNodes.Enabled && config.Deploy.VolumeSnapshotController.Enabled {
 		err = csiVolumeSnapshots.Deploy(registerContext)
@@ -283,7 +283,7 @@ func (s *persistentVolumeClaimSyncer) isHostVolumeRestoreInProgress(ctx *synccon
 		if err != nil {
 			return false, fmt.Errorf("unmarshal restore request: %w", err)
 		}
-		volumeRestore, ok := restoreRequest.Status.VolumesRestore.PersistentVolumeClaims[pvcName]
+		volumeRestore, ok := restoreRequest.VolumeRestoreStatus(pvcName)
 		if !ok {
 			continue
 		}
@@ -0,0 +1,492 @@
+package credentials
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/pkg/certs"
+	"github.com/loft-sh/vcluster/pkg/util/certhelper"
+	"github.com/loft-sh/vcluster/pkg/util/random"
+	"github.com/loft-sh/vcluster/pkg/util/translate"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// CredentialLabel is set on all objects that belong to an issued credential, the value is the credential id
+	CredentialLabel = "vcluster.loft.sh/credential"
+
+	// RecordKey is the config map key the issuance record is stored under
+	RecordKey = "credential"
+
+	// ServiceAccountNamespace is the virtual namespace the service accounts for token credentials are created in
+	ServiceAccountNamespace = metav1.NamespaceSystem
+
+	// UserPrefix is the user name prefix for certificate credentials
+	UserPrefix = "vcluster:credential:"
+
+	// MinExpiration is the minimum expiration the kube-apiserver accepts for bound service account tokens
+	MinExpiration = 10 * time.Minute
+
+	// DefaultMaxExpiration is the maximum expiration if controlPlane.proxy.credentials.maxExpiration is not set
+	DefaultMaxExpiration = 24 * time.Hour
+)
+
+// GroupVersion is the api group of the credential issuance endpoint of the vCluster proxy
+var GroupVersion = schema.GroupVersion{Group: "credentials.vcluster.loft.sh", Version: "v1alpha1"}
+
+// CredentialRequestsResource is the resource that is created to issue a credential, similar to a TokenRequest
+const CredentialRequestsResource = "credentialrequests"
+
+var ErrNotFound = errors.New("credential not found")
+
+type Type string
+
+const (
+	TypeToken       Type = "token"
+	TypeCertificate Type = "certificate"
+)
+
+// Request describes a scoped, time-bound credential for a virtual cluster, similar to a TokenRequest
+type Request struct {
+	// Type is either token (bound service account token) or certificate (client certificate)
+	Type Type `json:"type"`
+
+	// Namespaces are the virtual namespaces Roles are bound in
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Roles are cluster roles that are bound via role bindings in each of the Namespaces
+	Roles []string `json:"roles,omitempty"`
+
+	// ClusterRoles are cluster roles that are bound cluster wide
+	ClusterRoles []string `json:"clusterRoles,omitempty"`
+
+	// Expiration is the duration the credential is valid for
+	Expiration time.Duration `json:"expiration"`
+
+	// Description is an optional description of the credential
+	Description string `json:"description,omitempty"`
+}
+
+// Record is the issuance record that is stored in the host namespace of the virtual cluster
+type Record struct {
+	Request `json:",inline"`
+
+	// ID is the unique id of the credential
+	ID string `json:"id"`
+
+	// Subject is the virtual cluster user the credential authenticates as
+	Subject string `json:"subject"`
+
+	// IssuedAt is the time the credential was issued
+	IssuedAt metav1.Time `json:"issuedAt"`
+
+	// ExpiresAt is the time the credential expires
+	ExpiresAt metav1.Time `json:"expiresAt"`
+
+	// Serial is the hex encoded serial number of a client certificate, it is added to the revocation list once the
+	// credential is revoked
+	Serial string `json:"serial,omitempty"`
+}
+
+// Expired returns true if the credential is not valid anymore
+func (r *Record) Expired() bool {
+	return time.Now().After(r.ExpiresAt.Time)
+}
+
+// Credential is an issued credential
+type Credential struct {
+	Record *Record
+
+	// Token is set for token credentials
+	Token string
+
+	// ClientCertificate and ClientKey are set for certificate credentials
+	ClientCertificate []byte
+	ClientKey         []byte
+}
+
+// MaxExpiration returns the configured maximum expiration of credentials or DefaultMaxExpiration if none is set
+func MaxExpiration(proxyConfig config.ControlPlaneProxy) (time.Duration, error) {
+	if proxyConfig.Credentials.MaxExpiration == "" {
+		return DefaultMaxExpiration, nil
+	}
+
+	maxExpiration, err := time.ParseDuration(string(proxyConfig.Credentials.MaxExpiration))
+	if err != nil {
+		return 0, fmt.Errorf("parse controlPlane.proxy.credentials.maxExpiration: %w", err)
+	} else if maxExpiration <= 0 {
+		return 0, fmt.Errorf("controlPlane.proxy.credentials.maxExpiration needs to be positive")
+	}
+
+	return maxExpiration, nil
+}
+
+// Validate checks the request for obvious mistakes and that it doesn't expire later than maxExpiration
+func (r *Request) Validate(maxExpiration time.Duration) error {
+	if r.Type != TypeToken && r.Type != TypeCertificate {
+		return fmt.Errorf("unsupported credential type %q, expected %s or %s", r.Type, TypeToken, TypeCertificate)
+	}
+	if len(r.Roles) == 0 && len(r.ClusterRoles) == 0 {
+		return fmt.Errorf("at least one role or cluster role is required")
+	}
+	if len(r.Roles) > 0 && len(r.Namespaces) == 0 {
+		return fmt.Errorf("roles require at least one namespace")
+	}
+	if r.Type == TypeToken && r.Expiration < MinExpiration {
+		return fmt.Errorf("token credentials need to be valid for at least %s", MinExpiration)
+	} else if r.Expiration <= 0 {
+		return fmt.Errorf("expiration is required")
+	} else if r.Expiration > maxExpiration {
+		return fmt.Errorf("credentials can be valid for at most %s, see controlPlane.proxy.credentials.maxExpiration", maxExpiration)
+	}
+
+	return nil
+}
+
+// RecordName returns the name of the config map that holds the issuance record in the host namespace
+func RecordName(vClusterName, id string) string {
+	return translate.SafeConcatName("vc", "credential", vClusterName, id)
+}
+
+// Issue creates the credential subject and its role bindings in the virtual cluster, records the issuance in the host
+// namespace and returns the token or client certificate.
+func Issue(ctx context.Context, hostClient, vClient kubernetes.Interface, vClusterName, vClusterNamespace string, request *Request, maxExpiration time.Duration) (*Credential, error) {
+	if err := request.Validate(maxExpiration); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := &Record{
+		Request:   *request,
+		ID:        random.String(8),
+		IssuedAt:  metav1.NewTime(now),
+		ExpiresAt: metav1.NewTime(now.Add(request.Expiration)),
+	}
+
+	subject := rbacv1.Subject{
+		Kind:     rbacv1.UserKind,
+		APIGroup: rbacv1.GroupName,
+		Name:     UserPrefix + record.ID,
+	}
+	if request.Type == TypeToken {
+		subject = rbacv1.Subject{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      translate.SafeConcatName("vcluster", "credential", record.ID),
+			Namespace: ServiceAccountNamespace,
+		}
+		record.Subject = "system:serviceaccount:" + subject.Namespace + ":" + subject.Name
+	} else {
+		record.Subject = subject.Name
+	}
+
+	// store the record first, so we can always find and revoke partially created credentials
+	err := createRecord(ctx, hostClient, vClusterName, vClusterNamespace, record)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := issue(ctx, hostClient, vClient, vClusterName, vClusterNamespace, record, subject)
+	if err != nil {
+		if revokeErr := Revoke(ctx, hostClient, vClient, vClusterName, vClusterNamespace, record.ID); revokeErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("revoke partially issued credential: %w", revokeErr))
+		}
+
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+func issue(ctx context.Context, hostClient, vClient kubernetes.Interface, vClusterName, vClusterNamespace string, record *Record, subject rbacv1.Subject) (*Credential, error) {
+	labels := map[string]string{CredentialLabel: record.ID}
+	if subject.Kind == rbacv1.ServiceAccountKind {
+		_, err := vClient.CoreV1().ServiceAccounts(subject.Namespace).Create(ctx, &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      subject.Name,
+				Namespace: subject.Namespace,
+				Labels:    labels,
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("create service account: %w", err)
+		}
+	}
+
+	for _, clusterRole := range record.ClusterRoles {
+		_, err := vClient.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   translate.SafeConcatName("vcluster", "credential", record.ID, clusterRole),
+				Labels: labels,
+			},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "ClusterRole",
+				Name:     clusterRole,
+			},
+			Subjects: []rbacv1.Subject{subject},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("create cluster role binding for %s: %w", clusterRole, err)
+		}
+	}
+
+	for _, namespace := range record.Namespaces {
+		for _, role := range record.Roles {
+			_, err := vClient.RbacV1().RoleBindings(namespace).Create(ctx, &rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      translate.SafeConcatName("vcluster", "credential", record.ID, role),
+					Namespace: namespace,
+					Labels:    labels,
+				},
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: rbacv1.GroupName,
+					Kind:     "ClusterRole",
+					Name:     role,
+				},
+				Subjects: []rbacv1.Subject{subject},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("create role binding for %s in namespace %s: %w", role, namespace, err)
+			}
+		}
+	}
+
+	credential := &Credential{Record: record}
+	if subject.Kind == rbacv1.ServiceAccountKind {
+		expirationSeconds := int64(record.Expiration.Seconds())
+		tokenRequest, err := vClient.CoreV1().ServiceAccounts(subject.Namespace).CreateToken(ctx, subject.Name, &authenticationv1.TokenRequest{
+			Spec: authenticationv1.TokenRequestSpec{
+				ExpirationSeconds: &expirationSeconds,
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("create service account token: %w", err)
+		}
+
+		credential.Token = tokenRequest.Status.Token
+		return credential, nil
+	}
+
+	cert, keyBytes, err := signClientCertificate(ctx, hostClient, vClusterName, vClusterNamespace, subject.Name, record.ExpiresAt.Time)
+	if err != nil {
+		return nil, err
+	}
+
+	// the serial is needed to revoke the certificate later on
+	record.Serial = SerialOf(cert)
+	err = updateRecord(ctx, hostClient, vClusterName, vClusterNamespace, record)
+	if err != nil {
+		return nil, err
+	}
+
+	credential.ClientCertificate = certhelper.EncodeCertPEM(cert)
+	credential.ClientKey = keyBytes
+	return credential, nil
+}
+
+// SerialOf returns the serial number of a certificate as it is stored in the issuance record and revocation list
+func SerialOf(cert *x509.Certificate) string {
+	return cert.SerialNumber.Text(16)
+}
+
+func signClientCertificate(ctx context.Context, hostClient kubernetes.Interface, vClusterName, vClusterNamespace, user string, notAfter time.Time) (*x509.Certificate, []byte, error) {
+	secret, err := hostClient.CoreV1().Secrets(vClusterNamespace).Get(ctx, certs.CertSecretName(vClusterName), metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("get certificates secret, client certificates are only supported if vCluster manages its own certificates: %w", err)
+	}
+
+	caCertBytes, caKeyBytes := secret.Data[certs.ClientCACertName], secret.Data[certs.ClientCAKeyName]
+	if len(caCertBytes) == 0 || len(caKeyBytes) == 0 {
+		caCertBytes, caKeyBytes = secret.Data[certs.CACertName], secret.Data[certs.CAKeyName]
+	}
+	if len(caCertBytes) == 0 || len(caKeyBytes) == 0 {
+		return nil, nil, fmt.Errorf("secret %s/%s doesn't contain a client ca", vClusterNamespace, secret.Name)
+	}
+
+	caCerts, err := certhelper.ParseCertsPEM(caCertBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse client ca: %w", err)
+	}
+	caKey, err := certhelper.ParsePrivateKeyPEM(caKeyBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse client ca key: %w", err)
+	}
+
+	keyBytes, err := certhelper.MakeEllipticPrivateKeyPEM()
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate key: %w", err)
+	}
+	key, err := certhelper.ParsePrivateKeyPEM(keyBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := certhelper.NewSignedCert(certhelper.Config{
+		CommonName: user,
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		NotAfter:   notAfter,
+	}, key.(crypto.Signer), caCerts[0], caKey.(crypto.Signer))
+	if err != nil {
+		return nil, nil, fmt.Errorf("sign client certificate: %w", err)
+	}
+
+	return cert, keyBytes, nil
+}
+
+// List returns all issuance records of the virtual cluster sorted by issue time
+func List(ctx context.Context, hostClient kubernetes.Interface, vClusterName, vClusterNamespace string) ([]*Record, error) {
+	configMaps, err := hostClient.CoreV1().ConfigMaps(vClusterNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=vcluster,release=" + vClusterName + "," + CredentialLabel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list credentials: %w", err)
+	}
+
+	records := []*Record{}
+	for _, configMap := range configMaps.Items {
+		record := &Record{}
+		err = json.Unmarshal([]byte(configMap.Data[RecordKey]), record)
+		if err != nil {
+			return nil, fmt.Errorf("parse credential %s: %w", configMap.Name, err)
+		}
+
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].IssuedAt.Before(&records[j].IssuedAt)
+	})
+	return records, nil
+}
+
+// Revoke deletes the subject and role bindings of the credential in the virtual cluster as well as its issuance record.
+// Bound service account tokens become invalid once their service account is gone, the serial of a client certificate
+// is added to the revocation list of the vCluster, which the vCluster proxy checks when authenticating requests.
+func Revoke(ctx context.Context, hostClient, vClient kubernetes.Interface, vClusterName, vClusterNamespace, id string) error {
+	recordName := RecordName(vClusterName, id)
+	configMap, err := hostClient.CoreV1().ConfigMaps(vClusterNamespace).Get(ctx, recordName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+
+		return fmt.Errorf("get credential: %w", err)
+	}
+
+	record := &Record{}
+	err = json.Unmarshal([]byte(configMap.Data[RecordKey]), record)
+	if err != nil {
+		return fmt.Errorf("parse credential %s: %w", configMap.Name, err)
+	}
+	if record.Serial != "" && !record.Expired() {
+		err = addRevokedSerial(ctx, hostClient, vClusterName, vClusterNamespace, record.Serial, record.ExpiresAt.Time)
+		if err != nil {
+			return err
+		}
+	}
+
+	selector := metav1.ListOptions{LabelSelector: CredentialLabel + "=" + id}
+	err = vClient.CoreV1().ServiceAccounts(ServiceAccountNamespace).DeleteCollection(ctx, metav1.DeleteOptions{}, selector)
+	if err != nil {
+		return fmt.Errorf("delete service accounts: %w", err)
+	}
+
+	err = vClient.RbacV1().ClusterRoleBindings().DeleteCollection(ctx, metav1.DeleteOptions{}, selector)
+	if err != nil {
+		return fmt.Errorf("delete cluster role bindings: %w", err)
+	}
+
+	roleBindings, err := vClient.RbacV1().RoleBindings(metav1.NamespaceAll).List(ctx, selector)
+	if err != nil {
+		return fmt.Errorf("list role bindings: %w", err)
+	}
+	for _, roleBinding := range roleBindings.Items {
+		err = vClient.RbacV1().RoleBindings(roleBinding.Namespace).Delete(ctx, roleBinding.Name, metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("delete role binding %s/%s: %w", roleBinding.Namespace, roleBinding.Name, err)
+		}
+	}
+
+	err = hostClient.CoreV1().ConfigMaps(vClusterNamespace).Delete(ctx, recordName, metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("delete credential record: %w", err)
+	}
+
+	return nil
+}
+
+func updateRecord(ctx context.Context, hostClient kubernetes.Interface, vClusterName, vClusterNamespace string, record *Record) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := hostClient.CoreV1().ConfigMaps(vClusterNamespace).Get(ctx, RecordName(vClusterName, record.ID), metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("get credential record: %w", err)
+		}
+
+		configMap.Data = map[string]string{RecordKey: string(raw)}
+		_, err = hostClient.CoreV1().ConfigMaps(vClusterNamespace).Update(ctx, configMap, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func createRecord(ctx context.Context, hostClient kubernetes.Interface, vClusterName, vClusterNamespace string, record *Record) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	_, err = hostClient.CoreV1().ConfigMaps(vClusterNamespace).Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RecordName(vClusterName, record.ID),
+			Namespace: vClusterNamespace,
+			Labels: map[string]string{
+				"app":           "vcluster",
+				"release":       vClusterName,
+				CredentialLabel: record.ID,
+			},
+		},
+		Data: map[string]string{
+			RecordKey: string(raw),
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("record credential: %w", err)
+	}
+
+	return nil
+}
+
+// Scope returns a human-readable description of the permissions of a credential
+func (r *Record) Scope() string {
+	scopes := []string{}
+	for _, clusterRole := range r.ClusterRoles {
+		scopes = append(scopes, clusterRole+" (cluster)")
+	}
+	if len(r.Roles) > 0 {
+		namespaces := slices.Clone(r.Namespaces)
+		sort.Strings(namespaces)
+		scopes = append(scopes, strings.Join(r.Roles, ",")+" ("+strings.Join(namespaces, ",")+")")
+	}
+
+	return strings.Join(scopes, "; ")
+}
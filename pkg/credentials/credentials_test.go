@@ -0,0 +1,114 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/vcluster/config"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		request     Request
+		expectedErr string
+	}{
+		{
+			name:        "unknown type",
+			request:     Request{Type: "password", ClusterRoles: []string{"view"}, Expiration: time.Hour},
+			expectedErr: `unsupported credential type "password", expected token or certificate`,
+		},
+		{
+			name:        "no roles",
+			request:     Request{Type: TypeToken, Expiration: time.Hour},
+			expectedErr: "at least one role or cluster role is required",
+		},
+		{
+			name:        "roles without namespaces",
+			request:     Request{Type: TypeToken, Roles: []string{"edit"}, Expiration: time.Hour},
+			expectedErr: "roles require at least one namespace",
+		},
+		{
+			name:        "token expiration too short",
+			request:     Request{Type: TypeToken, ClusterRoles: []string{"view"}, Expiration: time.Minute},
+			expectedErr: "token credentials need to be valid for at least 10m0s",
+		},
+		{
+			name:    "short lived certificate",
+			request: Request{Type: TypeCertificate, ClusterRoles: []string{"view"}, Expiration: time.Minute},
+		},
+		{
+			name:        "expiration above the maximum",
+			request:     Request{Type: TypeCertificate, ClusterRoles: []string{"view"}, Expiration: DefaultMaxExpiration + time.Second},
+			expectedErr: "credentials can be valid for at most 24h0m0s, see controlPlane.proxy.credentials.maxExpiration",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.Validate(DefaultMaxExpiration)
+			if testCase.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.Error(t, err, testCase.expectedErr)
+			}
+		})
+	}
+}
+
+func TestMaxExpiration(t *testing.T) {
+	maxExpiration, err := MaxExpiration(config.ControlPlaneProxy{})
+	assert.NilError(t, err)
+	assert.Equal(t, maxExpiration, DefaultMaxExpiration)
+
+	maxExpiration, err = MaxExpiration(config.ControlPlaneProxy{Credentials: config.ControlPlaneProxyCredentials{MaxExpiration: "2h"}})
+	assert.NilError(t, err)
+	assert.Equal(t, maxExpiration, 2*time.Hour)
+	assert.Error(t, (&Request{Type: TypeToken, ClusterRoles: []string{"view"}, Expiration: 3 * time.Hour}).Validate(maxExpiration), "credentials can be valid for at most 2h0m0s, see controlPlane.proxy.credentials.maxExpiration")
+
+	_, err = MaxExpiration(config.ControlPlaneProxy{Credentials: config.ControlPlaneProxyCredentials{MaxExpiration: "-1h"}})
+	assert.ErrorContains(t, err, "needs to be positive")
+}
+
+func TestIssueAndRevoke(t *testing.T) {
+	ctx := context.Background()
+	hostClient := fake.NewSimpleClientset()
+	vClient := fake.NewSimpleClientset()
+
+	// the fake clientset doesn't support token requests, so issue fails after the bindings were created and everything
+	// should be cleaned up again
+	_, err := Issue(ctx, hostClient, vClient, "vcluster", "test", &Request{
+		Type:         TypeToken,
+		Namespaces:   []string{"dev", "prod"},
+		Roles:        []string{"edit"},
+		ClusterRoles: []string{"view"},
+		Expiration:   time.Hour,
+	}, DefaultMaxExpiration)
+	assert.ErrorContains(t, err, "create service account token")
+
+	records, err := List(ctx, hostClient, "vcluster", "test")
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 0)
+
+	roleBindings, err := vClient.RbacV1().RoleBindings(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(roleBindings.Items), 0)
+
+	// revoking an unknown credential fails
+	err = Revoke(ctx, hostClient, vClient, "vcluster", "test", "unknown")
+	assert.Assert(t, errors.Is(err, ErrNotFound))
+}
+
+func TestScope(t *testing.T) {
+	record := &Record{Request: Request{
+		Namespaces:   []string{"prod", "dev"},
+		Roles:        []string{"edit"},
+		ClusterRoles: []string{"view"},
+	}}
+	assert.Equal(t, record.Scope(), "view (cluster); edit (dev,prod)")
+}
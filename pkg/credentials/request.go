@@ -0,0 +1,78 @@
+package credentials
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CredentialRequest is created at the credential issuance endpoint of the vCluster proxy to issue a credential, similar
+// to a TokenRequest
+type CredentialRequest struct {
+	metav1.TypeMeta `json:",inline"`
+
+	Spec   CredentialRequestSpec   `json:"spec"`
+	Status CredentialRequestStatus `json:"status,omitempty"`
+}
+
+type CredentialRequestSpec struct {
+	// Type is either token (bound service account token) or certificate (client certificate)
+	Type Type `json:"type"`
+
+	// Namespaces are the virtual namespaces Roles are bound in
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Roles are cluster roles that are bound via role bindings in each of the Namespaces
+	Roles []string `json:"roles,omitempty"`
+
+	// ClusterRoles are cluster roles that are bound cluster wide
+	ClusterRoles []string `json:"clusterRoles,omitempty"`
+
+	// ExpirationSeconds is the duration the credential is valid for
+	ExpirationSeconds int64 `json:"expirationSeconds"`
+
+	// Description is an optional description of the credential
+	Description string `json:"description,omitempty"`
+}
+
+type CredentialRequestStatus struct {
+	// ID is the id of the issued credential that is used to revoke it
+	ID string `json:"id,omitempty"`
+
+	// Subject is the virtual cluster user the credential authenticates as
+	Subject string `json:"subject,omitempty"`
+
+	// ExpirationTimestamp is the time the credential expires
+	ExpirationTimestamp metav1.Time `json:"expirationTimestamp,omitempty"`
+
+	// Token is set for token credentials
+	Token string `json:"token,omitempty"`
+
+	// ClientCertificateData and ClientKeyData are set for certificate credentials
+	ClientCertificateData []byte `json:"clientCertificateData,omitempty"`
+	ClientKeyData         []byte `json:"clientKeyData,omitempty"`
+}
+
+// Request converts the spec into an issue request
+func (s *CredentialRequestSpec) Request() *Request {
+	return &Request{
+		Type:         s.Type,
+		Namespaces:   s.Namespaces,
+		Roles:        s.Roles,
+		ClusterRoles: s.ClusterRoles,
+		Expiration:   time.Duration(s.ExpirationSeconds) * time.Second,
+		Description:  s.Description,
+	}
+}
+
+// NewCredentialRequestStatus returns the status for an issued credential
+func NewCredentialRequestStatus(credential *Credential) CredentialRequestStatus {
+	return CredentialRequestStatus{
+		ID:                    credential.Record.ID,
+		Subject:               credential.Record.Subject,
+		ExpirationTimestamp:   credential.Record.ExpiresAt,
+		Token:                 credential.Token,
+		ClientCertificateData: credential.ClientCertificate,
+		ClientKeyData:         credential.ClientKey,
+	}
+}
@@ -0,0 +1,90 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// RevocationListName returns the name of the config map in the host namespace that holds the serials of revoked client
+// certificates. Each key is a serial and each value the time the certificate expires, after which it can be dropped.
+func RevocationListName(vClusterName string) string {
+	return "vc-revoked-credentials-" + vClusterName
+}
+
+// RevokedSerials returns the serials of all revoked client certificates that are not expired yet
+func RevokedSerials(ctx context.Context, hostClient kubernetes.Interface, vClusterName, vClusterNamespace string) (sets.Set[string], error) {
+	configMap, err := hostClient.CoreV1().ConfigMaps(vClusterNamespace).Get(ctx, RevocationListName(vClusterName), metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return sets.New[string](), nil
+		}
+
+		return nil, fmt.Errorf("get revoked credentials: %w", err)
+	}
+
+	return unexpiredSerials(configMap.Data, time.Now()), nil
+}
+
+func addRevokedSerial(ctx context.Context, hostClient kubernetes.Interface, vClusterName, vClusterNamespace, serial string, expiresAt time.Time) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := hostClient.CoreV1().ConfigMaps(vClusterNamespace).Get(ctx, RevocationListName(vClusterName), metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			_, err = hostClient.CoreV1().ConfigMaps(vClusterNamespace).Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      RevocationListName(vClusterName),
+					Namespace: vClusterNamespace,
+					Labels: map[string]string{
+						"app":     "vcluster",
+						"release": vClusterName,
+					},
+				},
+				Data: map[string]string{serial: expiresAt.UTC().Format(time.RFC3339)},
+			}, metav1.CreateOptions{})
+			if kerrors.IsAlreadyExists(err) {
+				return kerrors.NewConflict(corev1.Resource("configmaps"), RevocationListName(vClusterName), err)
+			}
+
+			return err
+		} else if err != nil {
+			return err
+		}
+
+		// drop serials of certificates that expired in the meantime
+		data := map[string]string{serial: expiresAt.UTC().Format(time.RFC3339)}
+		for revokedSerial := range unexpiredSerials(configMap.Data, time.Now()) {
+			data[revokedSerial] = configMap.Data[revokedSerial]
+		}
+
+		configMap.Data = data
+		_, err = hostClient.CoreV1().ConfigMaps(vClusterNamespace).Update(ctx, configMap, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("add certificate %s to revoked credentials: %w", serial, err)
+	}
+
+	return nil
+}
+
+// unexpiredSerials returns all serials that expire after now, serials with an invalid expiry are kept
+func unexpiredSerials(data map[string]string, now time.Time) sets.Set[string] {
+	serials := sets.New[string]()
+	for serial, expiry := range data {
+		expiresAt, err := time.Parse(time.RFC3339, expiry)
+		if err == nil && expiresAt.Before(now) {
+			continue
+		}
+
+		serials.Insert(serial)
+	}
+
+	return serials
+}
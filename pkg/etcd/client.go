@@ -27,6 +27,7 @@ type Client interface {
 	ListStream(ctx context.Context, key string) <-chan *ValueOrError
 	Watch(ctx context.Context, key string) clientv3.WatchChan
 	Get(ctx context.Context, key string) (Value, error)
+	Revision(ctx context.Context) (int64, error)
 	Put(ctx context.Context, key string, value []byte) (int64, error)
 	Delete(ctx context.Context, key string) error
 	DeletePrefix(ctx context.Context, prefix string) error
@@ -197,6 +198,16 @@ func (c *client) Get(ctx context.Context, key string) (Value, error) {
 	return Value{}, ErrNotFound
 }
 
+// Revision returns the current revision of the store
+func (c *client) Revision(ctx context.Context) (int64, error) {
+	resp, err := c.c.Get(ctx, "/", clientv3.WithLimit(1))
+	if err != nil {
+		return 0, err
+	}
+
+	return resp.Header.Revision, nil
+}
+
 func (c *client) Put(ctx context.Context, key string, value []byte) (int64, error) {
 	val, err := c.Get(ctx, key)
 	if err != nil && !errors.Is(err, ErrNotFound) {
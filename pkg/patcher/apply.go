@@ -6,6 +6,7 @@ import (
 
 	"github.com/loft-sh/vcluster/pkg/scheme"
 	"github.com/loft-sh/vcluster/pkg/syncer/synccontext"
+	"github.com/loft-sh/vcluster/pkg/tracing"
 	"github.com/loft-sh/vcluster/pkg/util/clienthelper"
 	"github.com/loft-sh/vcluster/pkg/util/patch"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
@@ -54,6 +55,9 @@ func CreateHostObject(ctx *synccontext.SyncContext, vObj, pObj client.Object, ev
 		namespaceName = vObj.GetNamespace() + "/" + vObj.GetName()
 	}
 
+	// link the host object to the reconcile that created it
+	tracing.InjectIntoObject(ctx, pObj)
+
 	err = ApplyObject(ctx, nil, pObj, synccontext.SyncVirtualToHost, hasStatus)
 	if err != nil {
 		ctx.Log.Infof("error syncing %s %s to host cluster: %v", gvk.Kind, namespaceName, err)
@@ -6,6 +6,7 @@ import (
 	agentstoragev1 "github.com/loft-sh/agentapi/v4/pkg/apis/loft/storage/v1"
 	managementv1 "github.com/loft-sh/api/v4/pkg/apis/management/v1"
 	"github.com/loft-sh/vcluster/pkg/apis"
+	snapshotv1alpha1 "github.com/loft-sh/vcluster/pkg/apis/snapshot/v1alpha1"
 	apidiscoveryv2 "k8s.io/api/apidiscovery/v2"
 	apidiscoveryv2beta1 "k8s.io/api/apidiscovery/v2beta1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -35,6 +36,9 @@ func init() {
 	// Register VolumeSnapshot CRDs
 	_ = volumesnapshotv1.AddToScheme(Scheme)
 
+	// Register vCluster snapshot CRDs
+	_ = snapshotv1alpha1.AddToScheme(Scheme)
+
 	// Register Loft CRDs
 	_ = agentstoragev1.AddToScheme(Scheme)
 	_ = agentclusterv1.AddToScheme(Scheme)
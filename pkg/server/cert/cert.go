@@ -22,6 +22,7 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -241,6 +242,11 @@ func getExtraSANs(ctx context.Context, workloadNamespaceClient, vClient client.C
 		"*."+translate.VClusterName+"."+vConfig.HostNamespace+"."+constants.NodeSuffix,
 	)
 
+	// add sans requested on the service, e.g. by vcluster connect --expose
+	if svc.Annotations[constants.VClusterExtraSANsAnnotation] != "" {
+		retSANs = append(retSANs, parseExtraSANsAnnotation(ctx, svc.Annotations[constants.VClusterExtraSANsAnnotation])...)
+	}
+
 	// if the service is a node port, we need to add the node ips to the sans
 	if svc.Spec.Type == corev1.ServiceTypeNodePort {
 		pods := &corev1.PodList{}
@@ -313,6 +319,26 @@ func getExtraSANs(ctx context.Context, workloadNamespaceClient, vClient client.C
 	return retSANs, nil
 }
 
+// parseExtraSANsAnnotation returns the valid hostnames and ips of the extra sans annotation. The annotation is a
+// privileged input, as the proxy certificate is signed for whatever it contains, so entries that are neither a
+// DNS-1123 subdomain nor an ip are dropped.
+func parseExtraSANsAnnotation(ctx context.Context, annotation string) []string {
+	sans := []string{}
+	for _, san := range strings.Split(annotation, ",") {
+		san = strings.TrimSpace(san)
+		if san == "" {
+			continue
+		} else if net.ParseIP(san) == nil && len(validation.IsDNS1123Subdomain(san)) > 0 {
+			klog.FromContext(ctx).Info("Ignoring invalid extra san of the vCluster service", "san", san, "annotation", constants.VClusterExtraSANsAnnotation)
+			continue
+		}
+
+		sans = append(sans, san)
+	}
+
+	return sans
+}
+
 func addSANs(altNames *certhelper.AltNames, sans []string) {
 	for _, san := range sans {
 		ip := net.ParseIP(san)
@@ -0,0 +1,38 @@
+package cert
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseExtraSANsAnnotation(t *testing.T) {
+	testCases := []struct {
+		name       string
+		annotation string
+		expected   []string
+	}{
+		{
+			name:       "hostnames and ips",
+			annotation: "vcluster.example.com,10.0.0.1,fd00::1",
+			expected:   []string{"vcluster.example.com", "10.0.0.1", "fd00::1"},
+		},
+		{
+			name:       "empty and whitespace entries",
+			annotation: " vcluster.example.com , ,,\t",
+			expected:   []string{"vcluster.example.com"},
+		},
+		{
+			name:       "invalid entries",
+			annotation: "*.example.com,Vcluster.example.com,vcluster example.com,-vcluster.example.com,vcluster.example.com/path,10.0.0.1",
+			expected:   []string{"10.0.0.1"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.DeepEqual(t, parseExtraSANsAnnotation(context.Background(), testCase.annotation), testCase.expected)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package filters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/loft-sh/vcluster/pkg/credentials"
+	"github.com/loft-sh/vcluster/pkg/util/clienthelper"
+	requestpkg "github.com/loft-sh/vcluster/pkg/util/request"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxCredentialRequestSize limits the size of a credential request body
+const maxCredentialRequestSize = 1024 * 1024
+
+// WithCredentialRequests serves the credential issuance endpoint. Creating a credential request is authorized by the
+// delegating authorizer, but as the role bindings of the credential are created by vCluster itself, the user also needs
+// to be allowed to bind each requested role, the same way the kube-apiserver prevents privilege escalation.
+func WithCredentialRequests(h http.Handler, hostClient, virtualClient kubernetes.Interface, vClusterName, vClusterNamespace string, maxExpiration time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		info, ok := request.RequestInfoFrom(req.Context())
+		if !ok || !info.IsResourceRequest || info.APIGroup != credentials.GroupVersion.Group || info.Resource != credentials.CredentialRequestsResource {
+			h.ServeHTTP(w, req)
+			return
+		} else if info.Verb != "create" {
+			requestpkg.FailWithStatus(w, req, http.StatusMethodNotAllowed, fmt.Errorf("%s only supports create", credentials.CredentialRequestsResource))
+			return
+		}
+
+		userInfo, ok := request.UserFrom(req.Context())
+		if !ok {
+			requestpkg.FailWithStatus(w, req, http.StatusInternalServerError, fmt.Errorf("user info is missing"))
+			return
+		}
+
+		credentialRequest := &credentials.CredentialRequest{}
+		err := json.NewDecoder(io.LimitReader(req.Body, maxCredentialRequestSize)).Decode(credentialRequest)
+		if err != nil {
+			requestpkg.FailWithStatus(w, req, http.StatusBadRequest, fmt.Errorf("decode credential request: %w", err))
+			return
+		}
+
+		issueRequest := credentialRequest.Spec.Request()
+		err = issueRequest.Validate(maxExpiration)
+		if err != nil {
+			requestpkg.FailWithStatus(w, req, http.StatusBadRequest, err)
+			return
+		}
+
+		err = checkBindRoles(req.Context(), virtualClient, userInfo, issueRequest)
+		if err != nil {
+			requestpkg.FailWithStatus(w, req, http.StatusForbidden, err)
+			return
+		}
+
+		credential, err := credentials.Issue(req.Context(), hostClient, virtualClient, vClusterName, vClusterNamespace, issueRequest, maxExpiration)
+		if err != nil {
+			requestpkg.FailWithStatus(w, req, http.StatusInternalServerError, fmt.Errorf("issue credential: %w", err))
+			return
+		}
+
+		credentialRequest.APIVersion = credentials.GroupVersion.String()
+		credentialRequest.Kind = "CredentialRequest"
+		credentialRequest.Status = credentials.NewCredentialRequestStatus(credential)
+		responsewriters.WriteRawJSON(http.StatusCreated, credentialRequest, w)
+	})
+}
+
+// checkBindRoles returns an error if the user is not allowed to bind one of the requested roles
+func checkBindRoles(ctx context.Context, virtualClient kubernetes.Interface, userInfo user.Info, issueRequest *credentials.Request) error {
+	for _, clusterRole := range issueRequest.ClusterRoles {
+		err := checkBindRole(ctx, virtualClient, userInfo, "", clusterRole)
+		if err != nil {
+			return err
+		}
+	}
+	for _, namespace := range issueRequest.Namespaces {
+		for _, role := range issueRequest.Roles {
+			err := checkBindRole(ctx, virtualClient, userInfo, namespace, role)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkBindRole(ctx context.Context, virtualClient kubernetes.Interface, userInfo user.Info, namespace, role string) error {
+	accessReview, err := virtualClient.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   userInfo.GetName(),
+			UID:    userInfo.GetUID(),
+			Groups: userInfo.GetGroups(),
+			Extra:  clienthelper.ConvertExtra(userInfo.GetExtra()),
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "bind",
+				Group:     rbacv1.GroupName,
+				Resource:  "clusterroles",
+				Name:      role,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("check bind permission for cluster role %s: %w", role, err)
+	} else if !accessReview.Status.Allowed || accessReview.Status.Denied {
+		if namespace != "" {
+			return fmt.Errorf("user %s is not allowed to bind cluster role %s in namespace %s", userInfo.GetName(), role, namespace)
+		}
+
+		return fmt.Errorf("user %s is not allowed to bind cluster role %s", userInfo.GetName(), role)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package filters
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/loft-sh/vcluster/pkg/certs"
+	"github.com/loft-sh/vcluster/pkg/credentials"
+	"github.com/loft-sh/vcluster/pkg/util/certhelper"
+	"gotest.tools/v3/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+func TestWithCredentialRequests(t *testing.T) {
+	hostClient := fake.NewSimpleClientset(newClientCASecret(t))
+	vClient := fake.NewSimpleClientset()
+
+	// the user is only allowed to bind the view cluster role
+	vClient.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		accessReview := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		accessReview.Status.Allowed = accessReview.Spec.User == "alice" && accessReview.Spec.ResourceAttributes.Verb == "bind" && accessReview.Spec.ResourceAttributes.Name == "view"
+		return true, accessReview, nil
+	})
+
+	passedThrough := false
+	handler := WithCredentialRequests(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		passedThrough = true
+	}), hostClient, vClient, "vcluster", "test", credentials.DefaultMaxExpiration)
+
+	cases := []struct {
+		name     string
+		verb     string
+		resource string
+		spec     credentials.CredentialRequestSpec
+
+		expectedCode  int
+		expectedError string
+	}{
+		{
+			name:         "issue certificate",
+			verb:         "create",
+			resource:     credentials.CredentialRequestsResource,
+			spec:         credentials.CredentialRequestSpec{Type: credentials.TypeCertificate, ClusterRoles: []string{"view"}, ExpirationSeconds: 3600},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:          "escalation",
+			verb:          "create",
+			resource:      credentials.CredentialRequestsResource,
+			spec:          credentials.CredentialRequestSpec{Type: credentials.TypeCertificate, Namespaces: []string{"default"}, Roles: []string{"admin"}, ExpirationSeconds: 3600},
+			expectedCode:  http.StatusForbidden,
+			expectedError: "user alice is not allowed to bind cluster role admin in namespace default",
+		},
+		{
+			name:          "invalid request",
+			verb:          "create",
+			resource:      credentials.CredentialRequestsResource,
+			spec:          credentials.CredentialRequestSpec{Type: credentials.TypeCertificate, ExpirationSeconds: 3600},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "at least one role or cluster role is required",
+		},
+		{
+			name:         "other verb",
+			verb:         "list",
+			resource:     credentials.CredentialRequestsResource,
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "other resource",
+			verb:     "create",
+			resource: "other",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			passedThrough = false
+			body, err := json.Marshal(&credentials.CredentialRequest{Spec: testCase.spec})
+			assert.NilError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/apis/credentials.vcluster.loft.sh/v1alpha1/"+testCase.resource, strings.NewReader(string(body)))
+			ctx := request.WithRequestInfo(req.Context(), &request.RequestInfo{
+				IsResourceRequest: true,
+				Verb:              testCase.verb,
+				APIGroup:          credentials.GroupVersion.Group,
+				APIVersion:        credentials.GroupVersion.Version,
+				Resource:          testCase.resource,
+			})
+			req = req.WithContext(request.WithUser(ctx, &user.DefaultInfo{Name: "alice"}))
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			if testCase.expectedCode == 0 {
+				assert.Assert(t, passedThrough)
+				return
+			}
+
+			assert.Assert(t, !passedThrough)
+			assert.Equal(t, recorder.Code, testCase.expectedCode, recorder.Body.String())
+			if testCase.expectedError != "" {
+				assert.Assert(t, strings.Contains(recorder.Body.String(), testCase.expectedError), recorder.Body.String())
+			}
+			if testCase.expectedCode != http.StatusCreated {
+				return
+			}
+
+			response := &credentials.CredentialRequest{}
+			assert.NilError(t, json.Unmarshal(recorder.Body.Bytes(), response))
+			assert.Equal(t, response.Kind, "CredentialRequest")
+			assert.Equal(t, response.Status.Subject, credentials.UserPrefix+response.Status.ID)
+			clientCerts, err := certhelper.ParseCertsPEM(response.Status.ClientCertificateData)
+			assert.NilError(t, err)
+			assert.Equal(t, clientCerts[0].Subject.CommonName, response.Status.Subject)
+
+			records, err := credentials.List(context.Background(), hostClient, "vcluster", "test")
+			assert.NilError(t, err)
+			assert.Equal(t, len(records), 1)
+			assert.Equal(t, records[0].Serial, credentials.SerialOf(clientCerts[0]))
+		})
+	}
+}
+
+func newClientCASecret(t *testing.T) *corev1.Secret {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	caCert, err := certutil.NewSelfSignedCACert(certutil.Config{CommonName: "vcluster-client-ca"}, key)
+	assert.NilError(t, err)
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	assert.NilError(t, err)
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      certs.CertSecretName("vcluster"),
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			certs.CACertName: certhelper.EncodeCertPEM(caCert),
+			certs.CAKeyName:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}),
+		},
+	}
+}
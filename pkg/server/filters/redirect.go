@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/gorilla/websocket"
 	"github.com/loft-sh/vcluster/pkg/authorization/delegatingauthorizer"
 	"github.com/loft-sh/vcluster/pkg/mappings"
 	"github.com/loft-sh/vcluster/pkg/scheme"
@@ -67,13 +68,28 @@ func WithRedirect(h http.Handler, registerCtx *synccontext.RegisterContext, unca
 				}
 			}
 
+			// credentials, cookies and forwarded addresses of the vCluster client are not meant for the host
+			req.Header.Del("Authorization")
+			req.Header.Del("Cookie")
+			req.Header.Del("X-Forwarded-For")
+
+			// recorded sessions are proxied message by message, which is only possible for websocket streams
+			if recordSession(registerCtx.Config.ControlPlane.Proxy.SessionRecording, info, req) {
+				if !websocket.IsWebSocketUpgrade(req) {
+					requestpkg.FailWithStatus(w, req, http.StatusForbidden, errRecordingRequiresWebsocket)
+					return
+				}
+
+				serveRecordedSession(w, req, info, registerCtx)
+				return
+			}
+
 			h, err := handler.Handler("", registerCtx.HostManager.GetConfig(), nil)
 			if err != nil {
 				requestpkg.FailWithStatus(w, req, http.StatusInternalServerError, err)
 				return
 			}
 
-			req.Header.Del("Authorization")
 			h.ServeHTTP(w, req)
 			return
 		}
@@ -83,9 +99,7 @@ func WithRedirect(h http.Handler, registerCtx *synccontext.RegisterContext, unca
 }
 
 func callAdmissionWebhooks(req *http.Request, info *request.RequestInfo, parameterCodec runtime.ParameterCodec, admit admission.Interface, uncachedVirtualClient client.Client) error {
-	if info.Resource != "pods" {
-		return nil
-	} else if info.Subresource != "exec" && info.Subresource != "portforward" && info.Subresource != "attach" {
+	if !isStreamingSubresource(info) {
 		return nil
 	}
 
@@ -130,6 +144,10 @@ func callAdmissionWebhooks(req *http.Request, info *request.RequestInfo, paramet
 	return nil
 }
 
+func isStreamingSubresource(info *request.RequestInfo) bool {
+	return info.Resource == "pods" && (info.Subresource == "exec" || info.Subresource == "portforward" || info.Subresource == "attach")
+}
+
 func applies(r *request.RequestInfo, resources []delegatingauthorizer.GroupVersionResourceVerb) bool {
 	if !r.IsResourceRequest {
 		return false
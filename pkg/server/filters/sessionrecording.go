@@ -0,0 +1,85 @@
+package filters
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/pkg/server/handler"
+	"github.com/loft-sh/vcluster/pkg/server/recording"
+	"github.com/loft-sh/vcluster/pkg/syncer/synccontext"
+	requestpkg "github.com/loft-sh/vcluster/pkg/util/request"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+)
+
+// errRecordingRequiresWebsocket is returned for sessions that would be recorded, but don't use websockets. SPDY
+// streams can't be recorded, so allowing them would bypass the recording.
+var errRecordingRequiresWebsocket = errors.New("session recording is enabled, interactive exec and attach sessions require websocket streaming (kubectl v1.30 or newer)")
+
+// recordSession returns true if the request is an interactive exec or attach session that needs to be recorded. Sessions
+// with stdin are interactive as well, even without a tty, e.g. kubectl exec -i or piped shell scripts.
+func recordSession(recordingConfig config.ControlPlaneProxySessionRecording, info *request.RequestInfo, req *http.Request) bool {
+	if !recordingConfig.Enabled || info.Resource != "pods" || (info.Subresource != "exec" && info.Subresource != "attach") {
+		return false
+	}
+
+	query := req.URL.Query()
+	return query.Get("tty") == "true" || query.Get("stdin") == "true"
+}
+
+// serveRecordedSession proxies a websocket exec or attach stream to the host cluster and records it
+func serveRecordedSession(w http.ResponseWriter, req *http.Request, info *request.RequestInfo, registerCtx *synccontext.RegisterContext) {
+	proxy, err := handler.WebsocketHandler(registerCtx.HostManager.GetConfig())
+	if err != nil {
+		requestpkg.FailWithStatus(w, req, http.StatusInternalServerError, err)
+		return
+	}
+
+	recordingConfig := registerCtx.Config.ControlPlane.Proxy.SessionRecording
+	query := req.URL.Query()
+	metadata := &recording.Metadata{
+		Subresource: info.Subresource,
+		Namespace:   info.Namespace,
+		Pod:         info.Name,
+		Container:   query.Get("container"),
+		Command:     query["command"],
+		Start:       time.Now(),
+	}
+	if user, ok := request.UserFrom(req.Context()); ok {
+		metadata.User = user.GetName()
+		metadata.Groups = user.GetGroups()
+	}
+
+	// the path was already rewritten to the host pod
+	splitted := strings.Split(req.URL.Path, "/")
+	if len(splitted) > 6 {
+		metadata.HostNamespace = splitted[4]
+		metadata.HostPod = splitted[6]
+	}
+
+	recorder, err := recording.New(recordingConfig, metadata)
+	if err != nil {
+		klog.Errorf("error starting session recording for %s/%s: %v", info.Namespace, info.Name, err)
+		requestpkg.FailWithStatus(w, req, http.StatusInternalServerError, err)
+		return
+	}
+
+	proxy.OnConnect = recorder.Connected
+	proxy.OnMessage = recorder.Message
+	proxy.ServeHTTP(w, req)
+
+	// the request context is done at this point, so we use a new one for uploading the recording
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	err = recorder.Close(ctx)
+	if err != nil {
+		klog.Errorf("error finishing session recording %s: %v", metadata.ID, err)
+		return
+	}
+
+	klog.Infof("Recorded %s session %s of %s in %s/%s", info.Subresource, metadata.ID, metadata.User, info.Namespace, info.Name)
+}
@@ -0,0 +1,111 @@
+package filters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/pkg/authorization/delegatingauthorizer"
+	"github.com/loft-sh/vcluster/pkg/scheme"
+	syncertesting "github.com/loft-sh/vcluster/pkg/syncer/testing"
+	testingutil "github.com/loft-sh/vcluster/pkg/util/testing"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestRecordSession(t *testing.T) {
+	enabled := config.ControlPlaneProxySessionRecording{Enabled: true}
+	cases := []struct {
+		name            string
+		recordingConfig config.ControlPlaneProxySessionRecording
+		subresource     string
+		query           string
+		expected        bool
+	}{
+		{
+			name:            "interactive exec is recorded",
+			recordingConfig: enabled,
+			subresource:     "exec",
+			query:           "?command=sh&stdin=true&tty=true",
+			expected:        true,
+		},
+		{
+			name:            "interactive attach is recorded",
+			recordingConfig: enabled,
+			subresource:     "attach",
+			query:           "?stdin=true&tty=true",
+			expected:        true,
+		},
+		{
+			name:            "exec with stdin but without tty is recorded",
+			recordingConfig: enabled,
+			subresource:     "exec",
+			query:           "?command=sh&stdin=true",
+			expected:        true,
+		},
+		{
+			name:        "recording disabled",
+			subresource: "exec",
+			query:       "?command=sh&tty=true",
+		},
+		{
+			name:            "non interactive exec is not recorded",
+			recordingConfig: enabled,
+			subresource:     "exec",
+			query:           "?command=ls",
+		},
+		{
+			name:            "portforward is not recorded",
+			recordingConfig: enabled,
+			subresource:     "portforward",
+			query:           "?ports=80",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/namespaces/default/pods/test/"+tc.subresource+tc.query, nil)
+			info := &request.RequestInfo{IsResourceRequest: true, Resource: "pods", Subresource: tc.subresource, Namespace: "default", Name: "test"}
+			assert.Equal(t, recordSession(tc.recordingConfig, info, req), tc.expected)
+		})
+	}
+}
+
+func TestRedirectRejectsUnrecordableSessions(t *testing.T) {
+	vConfig := testingutil.NewFakeConfig()
+	vConfig.ControlPlane.Proxy.SessionRecording.Enabled = true
+	pClient := testingutil.NewFakeClient(scheme.Scheme)
+	vClient := testingutil.NewFakeClient(scheme.Scheme)
+	registerCtx := syncertesting.NewFakeRegisterContext(vConfig, pClient, vClient)
+
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Fatal("request should not reach the next handler")
+	})
+	h := WithRedirect(next, registerCtx, vClient, nil, []delegatingauthorizer.GroupVersionResourceVerb{
+		{GroupVersionResource: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}, SubResource: "exec", Verb: "*"},
+	})
+
+	// interactive SPDY exec sessions can't be recorded, with or without tty
+	for _, query := range []string{"?command=sh&stdin=true&tty=true", "?command=sh&stdin=true"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/namespaces/default/pods/test/exec"+query, nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "SPDY/3.1")
+		req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{
+			IsResourceRequest: true,
+			Verb:              "create",
+			APIVersion:        "v1",
+			Resource:          "pods",
+			Subresource:       "exec",
+			Namespace:         "default",
+			Name:              "test",
+		}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		assert.Equal(t, w.Code, http.StatusForbidden, query)
+		assert.Assert(t, strings.Contains(w.Body.String(), "require websocket streaming"), query)
+	}
+}
@@ -0,0 +1,68 @@
+package filters
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/loft-sh/vcluster/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+)
+
+// WithTraceContext stores the trace context of create requests in the traceparent annotation of the created object,
+// so that syncers can continue the trace when they create the corresponding host object. Only json bodies are
+// annotated, other content types are passed through untouched.
+func WithTraceContext(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		info, ok := request.RequestInfoFrom(req.Context())
+		if !ok || !info.IsResourceRequest || info.Verb != "create" || info.Subresource != "" || req.Body == nil {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		span := oteltrace.SpanFromContext(req.Context())
+		if !span.SpanContext().IsSampled() || !strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+			h.ServeHTTP(w, req)
+			return
+		}
+		span.SetAttributes(
+			attribute.String("k8s.resource", info.Resource),
+			attribute.String("k8s.namespace", info.Namespace),
+		)
+
+		rawObj, err := io.ReadAll(req.Body)
+		if err != nil {
+			klog.FromContext(req.Context()).Error(err, "read request body for trace context")
+			req.Body = io.NopCloser(bytes.NewReader(rawObj))
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(annotateTraceContext(req, rawObj)))
+		h.ServeHTTP(w, req)
+	})
+}
+
+func annotateTraceContext(req *http.Request, rawObj []byte) []byte {
+	obj := &unstructured.Unstructured{}
+	if err := json.Unmarshal(rawObj, &obj.Object); err != nil || obj.GetKind() == "" {
+		return rawObj
+	}
+
+	tracing.InjectIntoObject(req.Context(), obj)
+	out, err := json.Marshal(obj.Object)
+	if err != nil {
+		return rawObj
+	}
+
+	req.ContentLength = int64(len(out))
+	req.Header.Set("Content-Length", strconv.Itoa(len(out)))
+	return out
+}
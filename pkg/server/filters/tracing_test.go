@@ -0,0 +1,151 @@
+package filters
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/loft-sh/vcluster/pkg/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapifilters "k8s.io/apiserver/pkg/endpoints/filters"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// spanRecorder records the spans that have ended
+type spanRecorder struct {
+	m     sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (r *spanRecorder) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (r *spanRecorder) OnEnd(span sdktrace.ReadOnlySpan) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.spans = append(r.spans, span)
+}
+
+func (r *spanRecorder) Shutdown(context.Context) error { return nil }
+
+func (r *spanRecorder) ForceFlush(context.Context) error { return nil }
+
+func (r *spanRecorder) ended() []sdktrace.ReadOnlySpan {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.spans
+}
+
+func TestWithTraceContext(t *testing.T) {
+	recorder := &spanRecorder{}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()), sdktrace.WithSpanProcessor(recorder))
+	tracing.SetProvider(provider)
+	defer tracing.SetProvider(nil)
+
+	// the handler chain of the api server creates the request span before the filter runs
+	var body []byte
+	handler := genericapifilters.WithTracing(WithTraceContext(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		assert.NilError(t, err)
+		assert.Equal(t, req.ContentLength, int64(len(body)))
+	})), provider)
+
+	cases := []struct {
+		name        string
+		verb        string
+		subresource string
+		contentType string
+		traceParent string
+
+		expectedAnnotation bool
+	}{
+		{
+			name:               "create",
+			verb:               "create",
+			contentType:        "application/json",
+			expectedAnnotation: true,
+		},
+		{
+			name:               "continue the trace of the client",
+			verb:               "create",
+			contentType:        "application/json",
+			traceParent:        "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01",
+			expectedAnnotation: true,
+		},
+		{
+			name:        "update",
+			verb:        "update",
+			contentType: "application/json",
+		},
+		{
+			name:        "subresource",
+			verb:        "create",
+			subresource: "eviction",
+			contentType: "application/json",
+		},
+		{
+			name:        "protobuf",
+			verb:        "create",
+			contentType: "application/vnd.kubernetes.protobuf",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			spansBefore := len(recorder.ended())
+			raw := []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","namespace":"default"}}`)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/namespaces/default/configmaps", strings.NewReader(string(raw)))
+			req.Header.Set("Content-Type", testCase.contentType)
+			if testCase.traceParent != "" {
+				req.Header.Set("traceparent", testCase.traceParent)
+			}
+			ctx := request.WithRequestInfo(req.Context(), &request.RequestInfo{
+				IsResourceRequest: true,
+				Verb:              testCase.verb,
+				APIPrefix:         "api",
+				APIVersion:        "v1",
+				Namespace:         "default",
+				Resource:          "configmaps",
+				Subresource:       testCase.subresource,
+			})
+			// only privileged users can continue an incoming trace
+			ctx = request.WithUser(ctx, &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}})
+			handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+			// a span is created for every request
+			spans := recorder.ended()
+			assert.Equal(t, len(spans), spansBefore+1)
+			span := spans[len(spans)-1]
+			if testCase.traceParent != "" {
+				assert.Equal(t, span.SpanContext().TraceID().String(), "0102030405060708090a0b0c0d0e0f10")
+				assert.Equal(t, span.Parent().SpanID().String(), "0102030405060708")
+			}
+
+			if !testCase.expectedAnnotation {
+				assert.Equal(t, string(body), string(raw))
+				return
+			}
+
+			// the span context is propagated to the created object
+			configMap := &corev1.ConfigMap{}
+			assert.NilError(t, json.Unmarshal(body, configMap))
+			assert.Equal(t, configMap.Name, "test")
+			propagated := tracing.SpanContextFromObject(configMap)
+			assert.Equal(t, propagated.TraceID(), span.SpanContext().TraceID())
+			assert.Equal(t, propagated.SpanID(), span.SpanContext().SpanID())
+			assert.Assert(t, propagated.TraceFlags().IsSampled())
+			assert.Equal(t, span.SpanKind(), oteltrace.SpanKindServer)
+		})
+	}
+}
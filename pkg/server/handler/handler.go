@@ -4,9 +4,12 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/gorilla/websocket"
+	"github.com/loft-sh/vcluster/pkg/util/websocketproxy"
 	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apimachinery/pkg/util/proxy"
 	"k8s.io/apiserver/pkg/endpoints/request"
@@ -127,3 +130,55 @@ func makeUpgradeTransport(config *rest.Config) (proxy.UpgradeRequestRoundTripper
 	}
 	return proxy.NewUpgradeRequestRoundTripper(rt, upgrader), nil
 }
+
+// WebsocketHandler returns a websocket proxy to the host api server of cfg. The backend connection is
+// always established via http/1.1, as websocket upgrades are not possible over http/2 connections.
+func WebsocketHandler(cfg *rest.Config) (*websocketproxy.WebsocketProxy, error) {
+	target, err := url.Parse(cfg.Host)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme == "https" {
+		target.Scheme = "wss"
+	} else {
+		target.Scheme = "ws"
+	}
+
+	transportConfig, err := cfg.TransportConfig()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := transport.TLSConfigFor(transportConfig)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		tlsConfig.NextProtos = []string{"http/1.1"}
+	}
+
+	wsProxy := websocketproxy.NewProxy(target)
+	wsProxy.Dialer = &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 45 * time.Second,
+		TLSClientConfig:  tlsConfig,
+	}
+	wsProxy.Director = func(_ *http.Request, out http.Header) {
+		// the host header of the incoming request points to vCluster, cookies and forwarded addresses
+		// of the vCluster client are not meant for the host
+		out.Del("Host")
+		out.Del("Cookie")
+		out.Del("X-Forwarded-For")
+
+		token := transportConfig.BearerToken
+		if transportConfig.BearerTokenFile != "" {
+			if rawToken, err := os.ReadFile(transportConfig.BearerTokenFile); err == nil {
+				token = strings.TrimSpace(string(rawToken))
+			}
+		}
+		if token != "" {
+			out.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	return wsProxy, nil
+}
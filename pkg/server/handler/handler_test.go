@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/client-go/rest"
+)
+
+func TestWebsocketHandlerHeaders(t *testing.T) {
+	wsProxy, err := WebsocketHandler(&rest.Config{Host: "https://127.0.0.1:6443", BearerToken: "host-token"})
+	assert.NilError(t, err)
+	assert.Equal(t, wsProxy.Backend(httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/pods/test/exec", nil)).Scheme, "wss")
+
+	out := http.Header{}
+	out.Set("Host", "vcluster.example.com")
+	out.Set("Cookie", "session=vcluster-client")
+	out.Set("X-Forwarded-For", "10.0.0.1")
+	out.Set("Sec-WebSocket-Protocol", "v5.channel.k8s.io")
+	wsProxy.Director(httptest.NewRequest(http.MethodGet, "/", nil), out)
+
+	assert.Equal(t, out.Get("Authorization"), "Bearer host-token")
+	assert.Equal(t, out.Get("Host"), "")
+	assert.Equal(t, out.Get("Cookie"), "")
+	assert.Equal(t, out.Get("X-Forwarded-For"), "")
+	assert.Equal(t, out.Get("Sec-WebSocket-Protocol"), "v5.channel.k8s.io")
+}
@@ -0,0 +1,251 @@
+package recording
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	vclusterconfig "github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/pkg/snapshot/options"
+	"github.com/loft-sh/vcluster/pkg/snapshot/s3"
+	"github.com/loft-sh/vcluster/pkg/util/random"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultPath is the directory recordings are written to if no path is configured
+	DefaultPath = "/data/session-recordings"
+
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// stream channels of the kubernetes remote command protocol
+const (
+	stdinChannel  = 0
+	stdoutChannel = 1
+	stderrChannel = 2
+	resizeChannel = 4
+)
+
+// Metadata describes a recorded session and is stored next to the recording
+type Metadata struct {
+	ID          string   `json:"id"`
+	User        string   `json:"user"`
+	Groups      []string `json:"groups,omitempty"`
+	Subresource string   `json:"subresource"`
+	Namespace   string   `json:"namespace"`
+	Pod         string   `json:"pod"`
+	Container   string   `json:"container,omitempty"`
+	Command     []string `json:"command,omitempty"`
+
+	HostNamespace string `json:"hostNamespace"`
+	HostPod       string `json:"hostPod"`
+
+	Protocol string    `json:"protocol,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end,omitempty"`
+	Duration string    `json:"duration,omitempty"`
+}
+
+// Recorder writes the stdin, stdout, stderr and resize streams of a websocket exec or attach session as
+// asciicast v2 (https://docs.asciinema.org/manual/asciicast/v2/) file.
+type Recorder struct {
+	config   vclusterconfig.ControlPlaneProxySessionRecording
+	metadata *Metadata
+
+	m      sync.Mutex
+	file   *os.File
+	base64 bool
+}
+
+// New creates a new recording for the session described by metadata
+func New(recordingConfig vclusterconfig.ControlPlaneProxySessionRecording, metadata *Metadata) (*Recorder, error) {
+	if recordingConfig.Path == "" {
+		recordingConfig.Path = DefaultPath
+	}
+	err := os.MkdirAll(recordingConfig.Path, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("create recordings directory: %w", err)
+	}
+
+	metadata.ID = metadata.Start.UTC().Format("20060102T150405Z") + "-" + random.String(6)
+	file, err := os.OpenFile(filepath.Join(recordingConfig.Path, metadata.ID+".cast"), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("create recording: %w", err)
+	}
+
+	recorder := &Recorder{
+		config:   recordingConfig,
+		metadata: metadata,
+		file:     file,
+	}
+	err = recorder.writeLine(map[string]interface{}{
+		"version":   2,
+		"width":     defaultWidth,
+		"height":    defaultHeight,
+		"timestamp": metadata.Start.Unix(),
+		"title":     metadata.Namespace + "/" + metadata.Pod + " (" + metadata.User + ")",
+	})
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return recorder, nil
+}
+
+// Connected stores the negotiated streaming protocol
+func (r *Recorder) Connected(subprotocol string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.metadata.Protocol = subprotocol
+	r.base64 = strings.Contains(subprotocol, "base64.channel.k8s.io")
+}
+
+// Message records a single websocket message of the session
+func (r *Recorder) Message(fromBackend bool, messageType int, data []byte) {
+	if len(data) == 0 || (messageType != websocket.BinaryMessage && messageType != websocket.TextMessage) {
+		return
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	channel, payload := int(data[0]), data[1:]
+	if r.base64 {
+		decoded, err := base64.StdEncoding.DecodeString(string(payload))
+		if err != nil {
+			return
+		}
+
+		channel, payload = int(data[0]-'0'), decoded
+	}
+	if len(payload) == 0 {
+		return
+	}
+
+	elapsed := time.Since(r.metadata.Start).Seconds()
+	var err error
+	switch {
+	case fromBackend && (channel == stdoutChannel || channel == stderrChannel):
+		err = r.writeLine([]interface{}{elapsed, "o", string(payload)})
+	case !fromBackend && channel == stdinChannel:
+		err = r.writeLine([]interface{}{elapsed, "i", string(payload)})
+	case !fromBackend && channel == resizeChannel:
+		size := struct {
+			Width  uint16
+			Height uint16
+		}{}
+		if json.Unmarshal(payload, &size) == nil {
+			err = r.writeLine([]interface{}{elapsed, "r", strconv.Itoa(int(size.Width)) + "x" + strconv.Itoa(int(size.Height))})
+		}
+	}
+	if err != nil {
+		klog.Errorf("error writing session recording %s: %v", r.metadata.ID, err)
+	}
+}
+
+// Close finishes the recording, writes the metadata and uploads both if an url is configured
+func (r *Recorder) Close(ctx context.Context) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.metadata.End = time.Now()
+	r.metadata.Duration = r.metadata.End.Sub(r.metadata.Start).Round(time.Second).String()
+	err := r.file.Close()
+	if err != nil {
+		return err
+	}
+
+	rawMetadata, err := json.MarshalIndent(r.metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	metadataPath := filepath.Join(r.config.Path, r.metadata.ID+".json")
+	err = os.WriteFile(metadataPath, rawMetadata, 0600)
+	if err != nil {
+		return fmt.Errorf("write recording metadata: %w", err)
+	}
+
+	if r.config.URL == "" {
+		return nil
+	}
+
+	for _, file := range []string{r.file.Name(), metadataPath} {
+		err = upload(ctx, r.config.URL, file)
+		if err != nil {
+			return fmt.Errorf("upload %s: %w", filepath.Base(file), err)
+		}
+
+		_ = os.Remove(file)
+	}
+
+	return nil
+}
+
+func (r *Recorder) writeLine(value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.file.Write(append(raw, '\n'))
+	return err
+}
+
+func upload(ctx context.Context, rawURL, file string) error {
+	s3Options, err := ParseURL(rawURL)
+	if err != nil {
+		return err
+	}
+	s3Options.Key = path.Join(s3Options.Key, filepath.Base(file))
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	objectStore := s3.NewStore(klog.FromContext(ctx))
+	err = objectStore.Init(s3Options)
+	if err != nil {
+		return err
+	}
+
+	return objectStore.PutObject(ctx, f)
+}
+
+// ParseURL parses an s3://BUCKET/PREFIX url with optional snapshot url options
+func ParseURL(rawURL string) (*s3.Options, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse recording url: %w", err)
+	} else if parsedURL.Scheme != "s3" {
+		return nil, fmt.Errorf("unsupported recording url %s, expected s3://BUCKET/PREFIX", rawURL)
+	} else if parsedURL.Host == "" {
+		return nil, fmt.Errorf("bucket name is missing from url, expected format: s3://BUCKET/PREFIX")
+	}
+
+	s3Options := &s3.Options{
+		Bucket: parsedURL.Host,
+		Key:    strings.TrimPrefix(parsedURL.Path, "/"),
+	}
+	err = options.PopulateStructFromMap(s3Options, parsedURL.Query(), true)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing options: %w", err)
+	}
+
+	return s3Options, nil
+}
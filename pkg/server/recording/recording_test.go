@@ -0,0 +1,89 @@
+package recording
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	vclusterconfig "github.com/loft-sh/vcluster/config"
+	"gotest.tools/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	dir := t.TempDir()
+	metadata := &Metadata{
+		User:        "alice",
+		Subresource: "exec",
+		Namespace:   "default",
+		Pod:         "nginx",
+		Start:       time.Now(),
+	}
+	recorder, err := New(vclusterconfig.ControlPlaneProxySessionRecording{Enabled: true, Path: dir}, metadata)
+	assert.NilError(t, err)
+
+	recorder.Connected("v4.channel.k8s.io")
+	recorder.Message(false, websocket.BinaryMessage, append([]byte{stdinChannel}, []byte("ls\n")...))
+	recorder.Message(true, websocket.BinaryMessage, append([]byte{stdoutChannel}, []byte("file\n")...))
+	recorder.Message(false, websocket.BinaryMessage, append([]byte{resizeChannel}, []byte(`{"Width":120,"Height":40}`)...))
+	recorder.Message(true, websocket.PingMessage, []byte{stdoutChannel, 'x'})
+	assert.NilError(t, recorder.Close(context.Background()))
+
+	events := readEvents(t, filepath.Join(dir, metadata.ID+".cast"))
+	assert.Equal(t, len(events), 3)
+	assert.DeepEqual(t, events[0][1:], []interface{}{"i", "ls\n"})
+	assert.DeepEqual(t, events[1][1:], []interface{}{"o", "file\n"})
+	assert.DeepEqual(t, events[2][1:], []interface{}{"r", "120x40"})
+
+	_, err = os.Stat(filepath.Join(dir, metadata.ID+".json"))
+	assert.NilError(t, err)
+}
+
+func TestRecorderBase64(t *testing.T) {
+	dir := t.TempDir()
+	metadata := &Metadata{Start: time.Now()}
+	recorder, err := New(vclusterconfig.ControlPlaneProxySessionRecording{Enabled: true, Path: dir}, metadata)
+	assert.NilError(t, err)
+
+	recorder.Connected("base64.channel.k8s.io")
+	recorder.Message(true, websocket.TextMessage, []byte("1"+base64.StdEncoding.EncodeToString([]byte("hello"))))
+	assert.NilError(t, recorder.Close(context.Background()))
+
+	events := readEvents(t, filepath.Join(dir, metadata.ID+".cast"))
+	assert.Equal(t, len(events), 1)
+	assert.DeepEqual(t, events[0][1:], []interface{}{"o", "hello"})
+}
+
+func TestParseURL(t *testing.T) {
+	s3Options, err := ParseURL("s3://recordings/vcluster-a?region=eu-west-1")
+	assert.NilError(t, err)
+	assert.Equal(t, s3Options.Bucket, "recordings")
+	assert.Equal(t, s3Options.Key, "vcluster-a")
+	assert.Equal(t, s3Options.Region, "eu-west-1")
+
+	_, err = ParseURL("oci://registry/recordings")
+	assert.ErrorContains(t, err, "unsupported recording url")
+}
+
+func readEvents(t *testing.T, file string) [][]interface{} {
+	f, err := os.Open(file)
+	assert.NilError(t, err)
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	assert.Assert(t, scanner.Scan(), "header is missing")
+
+	events := [][]interface{}{}
+	for scanner.Scan() {
+		event := []interface{}{}
+		assert.NilError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+
+	return events
+}
@@ -12,10 +12,12 @@ import (
 
 	"github.com/loft-sh/vcluster/pkg/authentication/delegatingauthenticator"
 	"github.com/loft-sh/vcluster/pkg/authentication/platformauthenticator"
+	"github.com/loft-sh/vcluster/pkg/authentication/revocationauthenticator"
 	"github.com/loft-sh/vcluster/pkg/authorization/allowall"
 	"github.com/loft-sh/vcluster/pkg/authorization/delegatingauthorizer"
 	"github.com/loft-sh/vcluster/pkg/authorization/impersonationauthorizer"
 	"github.com/loft-sh/vcluster/pkg/authorization/kubeletauthorizer"
+	"github.com/loft-sh/vcluster/pkg/credentials"
 	"github.com/loft-sh/vcluster/pkg/plugin"
 	"github.com/loft-sh/vcluster/pkg/pro"
 	"github.com/loft-sh/vcluster/pkg/server/cert"
@@ -23,6 +25,7 @@ import (
 	"github.com/loft-sh/vcluster/pkg/server/handler"
 	servertypes "github.com/loft-sh/vcluster/pkg/server/types"
 	"github.com/loft-sh/vcluster/pkg/syncer/synccontext"
+	"github.com/loft-sh/vcluster/pkg/tracing"
 	"github.com/loft-sh/vcluster/pkg/util/pluginhookclient"
 	"github.com/loft-sh/vcluster/pkg/util/serverhelper"
 	"github.com/pkg/errors"
@@ -132,6 +135,9 @@ func NewServer(ctx *synccontext.ControllerContext) (*Server, error) {
 	}
 
 	h := handler.ImpersonatingHandler("", virtualConfig)
+	if tracing.Enabled() {
+		h = filters.WithTraceContext(h)
+	}
 
 	// pre hooks
 	for _, f := range ctx.PreServerHooks {
@@ -150,6 +156,21 @@ func NewServer(ctx *synccontext.ControllerContext) (*Server, error) {
 		}
 		uncachedLocalClient = pluginhookclient.WrapPhysicalClient(uncachedLocalClient)
 
+		hostKubeClient, err := kubernetes.NewForConfig(localConfig)
+		if err != nil {
+			return nil, err
+		}
+		virtualKubeClient, err := kubernetes.NewForConfig(virtualConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		maxExpiration, err := credentials.MaxExpiration(ctx.Config.ControlPlane.Proxy)
+		if err != nil {
+			return nil, err
+		}
+
+		h = filters.WithCredentialRequests(h, hostKubeClient, virtualKubeClient, ctx.Config.Name, ctx.Config.HostNamespace, maxExpiration)
 		h = filters.WithServiceCreateRedirect(h, registerCtx, uncachedLocalClient, uncachedVirtualClient)
 		h = filters.WithRedirect(h, registerCtx, uncachedVirtualClient, admissionHandler, s.redirectResources)
 		h = filters.WithK8sMetrics(h, registerCtx)
@@ -184,6 +205,7 @@ func NewServer(ctx *synccontext.ControllerContext) (*Server, error) {
 func (s *Server) ServeOnListenerTLS(ctx *synccontext.ControllerContext) error {
 	// kubernetes build handler configuration
 	serverConfig := server.NewConfig(serializer.NewCodecFactory(s.uncachedVirtualClient.Scheme()))
+	serverConfig.TracerProvider = tracing.Provider()
 	serverConfig.RequestInfoResolver = &request.RequestInfoFactory{
 		APIPrefixes:          sets.NewString("api", "apis"),
 		GrouplessAPIPrefixes: sets.NewString("api"),
@@ -210,6 +232,15 @@ func (s *Server) ServeOnListenerTLS(ctx *synccontext.ControllerContext) error {
 	}
 	redirectAuthNonResources := []delegatingauthorizer.PathVerb{}
 	redirectAuthResources = append(redirectAuthResources, s.redirectResources...)
+	if !ctx.Config.PrivateNodes.Enabled {
+		redirectAuthResources = append(redirectAuthResources,
+			delegatingauthorizer.GroupVersionResourceVerb{
+				GroupVersionResource: credentials.GroupVersion.WithResource(credentials.CredentialRequestsResource),
+				Verb:                 "*",
+				SubResource:          "",
+			},
+		)
+	}
 	if ctx.Config.Integrations.MetricsServer.Enabled {
 		redirectAuthResources = append(redirectAuthResources,
 			delegatingauthorizer.GroupVersionResourceVerb{
@@ -256,7 +287,7 @@ func (s *Server) ServeOnListenerTLS(ctx *synccontext.ControllerContext) error {
 	serverConfig.Authorization.Authorizer = union.New(
 		kubeletauthorizer.New(s.uncachedVirtualClient),
 		delegatingauthorizer.New(s.uncachedVirtualClient, redirectAuthResources, redirectAuthNonResources),
-		impersonationauthorizer.New(s.uncachedVirtualClient),
+		impersonationauthorizer.New(s.uncachedVirtualClient, ctx.Config.ControlPlane.Proxy.Impersonation, ctx.Config.HostNamespace),
 		allowall.New(),
 	)
 
@@ -283,11 +314,23 @@ func (s *Server) ServeOnListenerTLS(ctx *synccontext.ControllerContext) error {
 	// make sure the tokens are correctly authenticated. We use the following order:
 	// 1. try the service account token one first since it's cheap to check this.
 	// 2. try the extra authenticators like platform that might take longer
-	// 3. last is the certificate authenticator
+	// 3. last is the certificate authenticator, which rejects client certificates of revoked credentials
+	certificateAuthenticator := serverConfig.Authentication.Authenticator
+	if !ctx.Config.PrivateNodes.Enabled {
+		hostKubeClient, err := kubernetes.NewForConfig(ctx.HostManager.GetConfig())
+		if err != nil {
+			return err
+		}
+
+		certificateAuthenticator = revocationauthenticator.New(certificateAuthenticator, func(revokedCtx context.Context) (sets.Set[string], error) {
+			return credentials.RevokedSerials(revokedCtx, hostKubeClient, ctx.Config.Name, ctx.Config.HostNamespace)
+		})
+	}
+
 	authenticators := []authenticator.Request{}
 	authenticators = append(authenticators, delegatingauthenticator.New(s.uncachedVirtualClient))
 	authenticators = append(authenticators, platformauthenticator.Default)
-	authenticators = append(authenticators, serverConfig.Authentication.Authenticator)
+	authenticators = append(authenticators, certificateAuthenticator)
 	serverConfig.Authentication.Authenticator = unionauthentication.NewFailOnError(authenticators...)
 
 	// create server
@@ -18,6 +18,7 @@ import (
 	"github.com/loft-sh/vcluster/pkg/scheme"
 	"github.com/loft-sh/vcluster/pkg/syncer/synccontext"
 	"github.com/loft-sh/vcluster/pkg/telemetry"
+	"github.com/loft-sh/vcluster/pkg/tracing"
 	"github.com/loft-sh/vcluster/pkg/util/blockingcacheclient"
 	"github.com/loft-sh/vcluster/pkg/util/pluginhookclient"
 	"github.com/pkg/errors"
@@ -171,6 +172,7 @@ func LoadVirtualConfig(ctx context.Context, options *config.VirtualClusterConfig
 	virtualClusterConfig.QPS = 1000
 	virtualClusterConfig.Burst = 2000
 	virtualClusterConfig.Timeout = 0
+	tracing.WrapConfig(virtualClusterConfig)
 
 	// start leader election for controllers
 	rawConfig, err := clientConfig.RawConfig()
@@ -0,0 +1,75 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/loft-sh/vcluster/pkg/snapshot/encryption"
+	"github.com/loft-sh/vcluster/pkg/snapshot/types"
+)
+
+// Archive is an opened snapshot that can be read key by key
+type Archive struct {
+	*tar.Reader
+
+	// Encrypted is true if the snapshot was encrypted on the client side
+	Encrypted bool
+
+	reader     io.ReadCloser
+	gzipReader *gzip.Reader
+}
+
+// OpenArchive downloads the snapshot from the object store, decrypts and decompresses it
+func OpenArchive(ctx context.Context, objectStore types.Storage, options *Options, loadSecret encryption.SecretLoader) (*Archive, error) {
+	reader, err := objectStore.GetObject(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup: %w", err)
+	}
+
+	// check if the snapshot is encrypted
+	bufferedReader := bufio.NewReader(reader)
+	prefix, _ := bufferedReader.Peek(len(encryption.Magic))
+
+	// optionally decrypt
+	decryptedReader, err := encryption.NewReader(bufferedReader, &options.Encryption, loadSecret)
+	if err != nil {
+		_ = reader.Close()
+		return nil, fmt.Errorf("failed to decrypt snapshot: %w", err)
+	}
+
+	// optionally decompress
+	gzipReader, err := gzip.NewReader(decryptedReader)
+	if err != nil {
+		_ = reader.Close()
+		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+
+	return &Archive{
+		Reader:     tar.NewReader(gzipReader),
+		Encrypted:  encryption.IsEncrypted(prefix),
+		reader:     reader,
+		gzipReader: gzipReader,
+	}, nil
+}
+
+// Next returns the next key and value of the snapshot or io.EOF if there are no more keys
+func (a *Archive) Next() ([]byte, []byte, error) {
+	key, value, err := readKeyValue(a.Reader)
+	if err != nil {
+		return nil, nil, err
+	} else if len(key) == 0 {
+		return nil, nil, io.EOF
+	}
+
+	return key, value, nil
+}
+
+// Close closes the underlying readers
+func (a *Archive) Close() error {
+	_ = a.gzipReader.Close()
+	return a.reader.Close()
+}
@@ -0,0 +1,198 @@
+package azblob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/loft-sh/vcluster/pkg/snapshot/types"
+	"k8s.io/utils/ptr"
+)
+
+// blockSize is the size of a single uploaded block, a blob can consist of up to 50000 blocks
+var blockSize int64 = 8 * 1024 * 1024
+
+type Options struct {
+	Account   string `json:"account,omitempty" url:"account"`
+	Container string `json:"container,omitempty"`
+	Blob      string `json:"blob,omitempty"`
+
+	SkipClientCredentials bool `json:"skip-client-credentials,omitempty" url:"skip-client-credentials"`
+
+	AccountKey string `json:"account-key,omitempty" url:"account-key,base64"`
+	SASToken   string `json:"sas-token,omitempty" url:"sas-token,base64"`
+	// ClientID selects a user assigned managed identity if neither account key nor sas token are set
+	ClientID string `json:"client-id,omitempty" url:"client-id"`
+
+	// URL overrides the blob service endpoint, e.g. http://127.0.0.1:10000/devstoreaccount1 for azurite
+	URL string `json:"url,omitempty" url:"url,base64"`
+}
+
+func (o *Options) FillCredentials(isClient bool) {
+	if o.Account == "" {
+		o.Account = os.Getenv("AZURE_STORAGE_ACCOUNT")
+	}
+	if (isClient && o.SkipClientCredentials) || o.Container == "" || o.AccountKey != "" || o.SASToken != "" {
+		return
+	}
+
+	o.AccountKey = os.Getenv("AZURE_STORAGE_KEY")
+	o.SASToken = os.Getenv("AZURE_STORAGE_SAS_TOKEN")
+}
+
+type Store struct {
+	client *azblob.Client
+
+	account   string
+	container string
+	blob      string
+}
+
+func NewStore(_ context.Context, options *Options) (*Store, error) {
+	options.FillCredentials(false)
+	if options.Account == "" {
+		return nil, errors.New("storage account must be specified via azblob://CONTAINER/BLOB?account=ACCOUNT")
+	}
+
+	serviceURL := "https://" + options.Account + ".blob.core.windows.net"
+	if options.URL != "" {
+		serviceURL = strings.TrimSuffix(options.URL, "/")
+	}
+
+	client, err := newClient(serviceURL, options)
+	if err != nil {
+		return nil, fmt.Errorf("create blob client: %w", err)
+	}
+
+	return &Store{
+		client:    client,
+		account:   options.Account,
+		container: options.Container,
+		blob:      options.Blob,
+	}, nil
+}
+
+func newClient(serviceURL string, options *Options) (*azblob.Client, error) {
+	switch {
+	case options.AccountKey != "":
+		credential, err := azblob.NewSharedKeyCredential(options.Account, options.AccountKey)
+		if err != nil {
+			return nil, err
+		}
+
+		return azblob.NewClientWithSharedKeyCredential(serviceURL, credential, nil)
+	case options.SASToken != "":
+		return azblob.NewClientWithNoCredential(serviceURL+"?"+strings.TrimPrefix(options.SASToken, "?"), nil)
+	case options.ClientID != "":
+		credential, err := azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{ID: azidentity.ClientID(options.ClientID)})
+		if err != nil {
+			return nil, err
+		}
+
+		return azblob.NewClient(serviceURL, credential, nil)
+	default:
+		// the default credential chain covers environment credentials, workload identity and managed identities
+		credential, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return azblob.NewClient(serviceURL, credential, nil)
+	}
+}
+
+func (s *Store) Target() string {
+	return "azblob://" + s.container + "/" + s.blob + "?account=" + s.account
+}
+
+// PutObject uploads the snapshot block by block and commits the block list afterwards, as the size is not known beforehand
+func (s *Store) PutObject(ctx context.Context, body io.Reader) error {
+	_, err := s.client.UploadStream(ctx, s.container, s.blob, body, &azblob.UploadStreamOptions{
+		BlockSize:   blockSize,
+		HTTPHeaders: &blob.HTTPHeaders{BlobContentType: ptr.To("application/gzip")},
+	})
+	if err != nil {
+		return fmt.Errorf("error putting object %s: %w", s.blob, err)
+	}
+
+	return nil
+}
+
+func (s *Store) GetObject(ctx context.Context) (io.ReadCloser, error) {
+	resp, err := s.client.DownloadStream(ctx, s.container, s.blob, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil, fmt.Errorf("error getting object %s: %w: %w", s.blob, types.ErrObjectNotFound, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting object %s: %w", s.blob, err)
+	}
+
+	return resp.Body, nil
+}
+
+func (s *Store) List(ctx context.Context) ([]types.Snapshot, error) {
+	prefix := s.blob
+	if strings.HasSuffix(prefix, "tar.gz") {
+		// Use the "parent dir" as the prefix if a file was given
+		prefix = filepath.Dir(prefix)
+
+		// Handle if the blob is at the root of the container.
+		if prefix == "." {
+			prefix = ""
+		}
+	}
+
+	snapshots := make([]types.Snapshot, 0)
+	pager := s.client.NewListBlobsFlatPager(s.container, &azblob.ListBlobsFlatOptions{Prefix: &prefix})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list blobs: %w", err)
+		}
+
+		for _, item := range page.Segment.BlobItems {
+			if item.Name == nil {
+				continue
+			}
+
+			// Skip non *.tar.gz blobs
+			name := *item.Name
+			if !strings.HasSuffix(name, "tar.gz") {
+				continue
+			}
+
+			// Skip blobs not in the "current directory"
+			id := strings.TrimPrefix(strings.TrimPrefix(name, prefix), "/")
+			if filepath.Dir(id) != "." {
+				continue
+			}
+
+			snapshot := types.Snapshot{
+				ID:  id,
+				URL: "azblob://" + s.container + "/" + name + "?account=" + s.account,
+			}
+			if item.Properties != nil && item.Properties.LastModified != nil {
+				snapshot.Timestamp = *item.Properties.LastModified
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	return snapshots, nil
+}
+
+func (s *Store) Delete(ctx context.Context) error {
+	_, err := s.client.DeleteBlob(ctx, s.container, s.blob, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting object %s: %w", s.blob, err)
+	}
+
+	return nil
+}
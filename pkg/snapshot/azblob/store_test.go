@@ -0,0 +1,178 @@
+package azblob
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/vcluster/pkg/snapshot/types"
+	"gotest.tools/v3/assert"
+)
+
+var accountKey = base64.StdEncoding.EncodeToString([]byte("my-account-key"))
+
+// fakeServer implements the parts of the blob service rest api used by the blob client with path style urls like azurite
+type fakeServer struct {
+	*httptest.Server
+
+	m      sync.Mutex
+	blobs  map[string][]byte
+	blocks map[string][]byte
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	f := &fakeServer{
+		blobs:  map[string][]byte{},
+		blocks: map[string][]byte{},
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeServer) authorized(r *http.Request) bool {
+	if r.Header.Get("x-ms-version") == "" {
+		return false
+	} else if r.URL.Query().Get("sig") != "" {
+		return r.URL.Query().Get("sig") == "my-signature"
+	}
+
+	return strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey devstoreaccount1:")
+}
+
+func (f *fakeServer) handle(w http.ResponseWriter, r *http.Request) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if !f.authorized(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	query := r.URL.Query()
+	blobName, isBlob := strings.CutPrefix(r.URL.Path, "/devstoreaccount1/my-container/")
+	switch {
+	case r.Method == http.MethodPut && isBlob && query.Get("comp") == "block":
+		f.blocks[blobName+"/"+query.Get("blockid")] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && isBlob && query.Get("comp") == "blocklist":
+		blockList := &struct {
+			Latest []string `xml:"Latest"`
+		}{}
+		if xml.Unmarshal(body, blockList) != nil || r.Header.Get("x-ms-blob-content-type") != "application/gzip" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		blob := []byte{}
+		for _, blockID := range blockList.Latest {
+			blob = append(blob, f.blocks[blobName+"/"+blockID]...)
+		}
+		f.blobs[blobName] = blob
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && isBlob && query.Get("comp") == "":
+		if r.Header.Get("x-ms-blob-content-type") != "application/gzip" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.blobs[blobName] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet && isBlob:
+		blob, ok := f.blobs[blobName]
+		if !ok {
+			w.Header().Set("x-ms-error-code", "BlobNotFound")
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("x-ms-blob-type", "BlockBlob")
+		_, _ = w.Write(blob)
+	case r.Method == http.MethodDelete && isBlob:
+		delete(f.blobs, blobName)
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodGet && r.URL.Path == "/devstoreaccount1/my-container" && query.Get("comp") == "list":
+		names := []string{}
+		for name := range f.blobs {
+			if strings.HasPrefix(name, query.Get("prefix")) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		// return one blob per page to test paging
+		page := 0
+		if query.Get("marker") != "" {
+			page = sort.SearchStrings(names, query.Get("marker"))
+		}
+		response := `<?xml version="1.0" encoding="utf-8"?><EnumerationResults ContainerName="my-container"><Blobs>`
+		if page < len(names) {
+			response += fmt.Sprintf("<Blob><Name>%s</Name><Properties><Last-Modified>%s</Last-Modified></Properties></Blob>", names[page], time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat))
+		}
+		response += "</Blobs><NextMarker>"
+		if page+1 < len(names) {
+			response += names[page+1]
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(response + "</NextMarker></EnumerationResults>"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestStore(t *testing.T) {
+	defer func(size int64) { blockSize = size }(blockSize)
+	blockSize = 1024 * 1024
+
+	ctx := context.Background()
+	server := newFakeServer(t)
+
+	// a small snapshot is uploaded in a single request, a large one is split into several blocks
+	for _, content := range []string{"0123456789abcdefghij", strings.Repeat("0123456789", 250*1024)} {
+		store, err := NewStore(ctx, &Options{Account: "devstoreaccount1", Container: "my-container", Blob: "snapshots/my-snapshot.tar.gz", AccountKey: accountKey, URL: server.URL + "/devstoreaccount1"})
+		assert.NilError(t, err)
+		assert.Equal(t, store.Target(), "azblob://my-container/snapshots/my-snapshot.tar.gz?account=devstoreaccount1")
+		assert.NilError(t, store.PutObject(ctx, strings.NewReader(content)))
+
+		reader, err := store.GetObject(ctx)
+		assert.NilError(t, err)
+		blob, err := io.ReadAll(reader)
+		assert.NilError(t, err)
+		assert.NilError(t, reader.Close())
+		assert.Equal(t, string(blob), content, "content size %d", len(content))
+	}
+
+	// list only snapshots in the same directory with a sas token
+	server.blobs["snapshots/other.tar.gz"] = []byte("other")
+	server.blobs["snapshots/nested/other.tar.gz"] = []byte("nested")
+	server.blobs["snapshots/other.txt"] = []byte("text")
+	store, err := NewStore(ctx, &Options{Account: "devstoreaccount1", Container: "my-container", Blob: "snapshots/my-snapshot.tar.gz", SASToken: "?sv=2021-08-06&sig=my-signature", URL: server.URL + "/devstoreaccount1"})
+	assert.NilError(t, err)
+	snapshots, err := store.List(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(snapshots), 2)
+	assert.Equal(t, snapshots[0].ID, "my-snapshot.tar.gz")
+	assert.Equal(t, snapshots[0].URL, "azblob://my-container/snapshots/my-snapshot.tar.gz?account=devstoreaccount1")
+	assert.Assert(t, snapshots[0].Timestamp.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, snapshots[1].ID, "other.tar.gz")
+
+	assert.NilError(t, store.Delete(ctx))
+	_, err = store.GetObject(ctx)
+	assert.ErrorContains(t, err, "BlobNotFound")
+	assert.Assert(t, errors.Is(err, types.ErrObjectNotFound), err)
+
+	// wrong sas token
+	store, err = NewStore(ctx, &Options{Account: "devstoreaccount1", Container: "my-container", Blob: "snapshots/other.tar.gz", SASToken: "sv=2021-08-06&sig=other", URL: server.URL + "/devstoreaccount1"})
+	assert.NilError(t, err)
+	_, err = store.GetObject(ctx)
+	assert.ErrorContains(t, err, "403")
+}
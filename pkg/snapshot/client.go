@@ -2,17 +2,27 @@ package snapshot
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	vclusterconfig "github.com/loft-sh/vcluster/config"
 	"github.com/loft-sh/vcluster/pkg/config"
 	"github.com/loft-sh/vcluster/pkg/constants"
 	"github.com/loft-sh/vcluster/pkg/etcd"
+	setupconfig "github.com/loft-sh/vcluster/pkg/setup/config"
+	"github.com/loft-sh/vcluster/pkg/snapshot/encryption"
 	"github.com/loft-sh/vcluster/pkg/snapshot/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 )
 
@@ -51,9 +61,18 @@ func (c *Client) Run(ctx context.Context) error {
 		return fmt.Errorf("failed to create store: %w", err)
 	}
 
+	// read the base snapshot of an incremental snapshot
+	manifest := newManifest(ctx, vConfig)
+	if c.Options.IncrementalBase != "" {
+		manifest.Base, err = c.incrementalBase(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	// write the snapshot
 	klog.Infof("Start writing etcd snapshot %s...", objectStore.Target())
-	err = c.writeSnapshot(ctx, etcdClient, objectStore)
+	err = c.writeSnapshot(ctx, etcdClient, objectStore, manifest)
 	if err != nil {
 		return err
 	}
@@ -104,8 +123,16 @@ func (c *Client) Delete(ctx context.Context) error {
 		return fmt.Errorf("failed to create store: %w", err)
 	}
 
+	// incremental snapshots can't be restored without their base snapshot
+	incrementals, err := incrementalSnapshotsOf(ctx, &c.Options, objectStore)
+	if err != nil {
+		klog.Warningf("Could not check if snapshot %s is the base of incremental snapshots: %v", objectStore.Target(), err)
+	} else if len(incrementals) > 0 {
+		return fmt.Errorf("snapshot %s is the base of the incremental snapshots %s, please delete them first", objectStore.Target(), strings.Join(incrementals, ", "))
+	}
+
 	// delete snapshot
-	if err := objectStore.Delete(ctx); err != nil {
+	if err := deleteSnapshot(ctx, &c.Options); err != nil {
 		return err
 	}
 
@@ -113,7 +140,64 @@ func (c *Client) Delete(ctx context.Context) error {
 	return nil
 }
 
-func (c *Client) writeSnapshot(ctx context.Context, etcdClient etcd.Client, objectStore types.Storage) error {
+// incrementalSnapshotsOf returns the ids of the incremental snapshots stored next to the snapshot of options that
+// were created from it. Incremental snapshots are always newer than their base, so only those are checked.
+func incrementalSnapshotsOf(ctx context.Context, options *Options, objectStore types.Storage) ([]string, error) {
+	if _, err := options.withID("id"); err != nil {
+		// snapshots of this storage type can't be told apart by id
+		return nil, nil
+	}
+
+	snapshots, err := objectStore.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots: %w", err)
+	}
+
+	target := options.GetURL()
+	var created time.Time
+	for _, snapshot := range snapshots {
+		snapshotOptions, err := options.withID(snapshot.ID)
+		if err == nil && snapshotOptions.GetURL() == target {
+			created = snapshot.Timestamp
+		}
+	}
+
+	newer := []types.Snapshot{}
+	for _, snapshot := range snapshots {
+		if snapshot.Timestamp.After(created) {
+			newer = append(newer, snapshot)
+		}
+	}
+
+	return baseReferences(ctx, options, newer)[target], nil
+}
+
+// incrementalBase verifies the base snapshot of an incremental snapshot and returns its reference
+func (c *Client) incrementalBase(ctx context.Context) (*BaseSnapshot, error) {
+	baseOptions, err := c.Options.withURL(c.Options.IncrementalBase)
+	if err != nil {
+		return nil, err
+	}
+
+	klog.Infof("Verifying base snapshot %s...", baseOptions.GetURL())
+	manifest, err := VerifySnapshot(ctx, baseOptions, hostSecretLoader(ctx))
+	if errors.Is(err, ErrNoManifest) {
+		return nil, fmt.Errorf("base snapshot %s was created by an older vCluster version, please create a new full snapshot", baseOptions.GetURL())
+	} else if err != nil {
+		return nil, fmt.Errorf("verify base snapshot %s: %w", baseOptions.GetURL(), err)
+	} else if manifest.Revision == 0 {
+		return nil, fmt.Errorf("base snapshot %s has no etcd revision, please create a new full snapshot", baseOptions.GetURL())
+	}
+
+	return &BaseSnapshot{
+		URL:        baseOptions.GetURL(),
+		Revision:   manifest.Revision,
+		Checksum:   manifest.Checksum,
+		Generation: manifest.Generation,
+	}, nil
+}
+
+func (c *Client) writeSnapshot(ctx context.Context, etcdClient etcd.Client, objectStore types.Storage, manifest *Manifest) error {
 	// now stream objects from etcd to object store
 	errChan := make(chan error)
 	reader, writer, err := os.Pipe()
@@ -126,17 +210,57 @@ func (c *Client) writeSnapshot(ctx context.Context, etcdClient etcd.Client, obje
 		errChan <- objectStore.PutObject(ctx, reader)
 	}()
 
+	// optionally encrypt the snapshot
+	var snapshotWriter io.WriteCloser = writer
+	if c.Options.Encryption.Enabled() {
+		snapshotWriter, err = encryption.NewWriter(writer, &c.Options.Encryption, hostSecretLoader(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to create encryption writer: %w", err)
+		}
+		defer snapshotWriter.Close()
+	}
+
+	// identify the backing store, revisions can only be compared between snapshots of the same one
+	manifest.Generation, err = backingStoreGeneration(ctx, etcdClient)
+	if err != nil {
+		return fmt.Errorf("failed to get backing store generation: %w", err)
+	}
+
+	// remember the revision before listing, every key changed afterwards will be part of the next incremental snapshot
+	manifest.Revision, err = etcdClient.Revision(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get etcd revision: %w", err)
+	}
+
+	// fall back to a full snapshot if the backing store was replaced or restored since the base snapshot
+	if manifest.Base != nil {
+		if manifest.Base.Generation != manifest.Generation {
+			klog.Warningf("Backing store has changed since base snapshot %s was taken, creating a full snapshot instead", manifest.Base.URL)
+			manifest.Base = nil
+		} else if manifest.Revision < manifest.Base.Revision {
+			klog.Warningf("etcd revision %d is older than revision %d of base snapshot %s, creating a full snapshot instead", manifest.Revision, manifest.Base.Revision, manifest.Base.URL)
+			manifest.Base = nil
+		}
+	}
+
 	// start listing the keys
 	listChan := etcdClient.ListStream(ctx, "/")
 
 	// don't compress with default level as this can get quite cpu heavy
-	gzipWriter, _ := gzip.NewWriterLevel(writer, 3)
+	gzipWriter, _ := gzip.NewWriterLevel(snapshotWriter, 3)
 	defer gzipWriter.Close()
 
 	// create a new tar write
 	tarWriter := tar.NewWriter(gzipWriter)
 	defer tarWriter.Close()
 
+	// every key is added to the checksum of the manifest
+	checksum := newManifestHash()
+	writeKey := func(key, value []byte) error {
+		checksum.Add(key, value)
+		return writeKeyValue(tarWriter, key, value)
+	}
+
 	// write the vCluster config as first thing
 	if c.Options.Release != nil {
 		releaseBytes, err := json.Marshal(c.Options.Release)
@@ -144,7 +268,7 @@ func (c *Client) writeSnapshot(ctx context.Context, etcdClient etcd.Client, obje
 			return fmt.Errorf("failed to marshal vCluster release: %w", err)
 		}
 
-		err = writeKeyValue(tarWriter, []byte(SnapshotReleaseKey), releaseBytes)
+		err = writeKey([]byte(SnapshotReleaseKey), releaseBytes)
 		if err != nil {
 			return fmt.Errorf("failed to snapshot vCluster release: %w", err)
 		}
@@ -157,7 +281,7 @@ func (c *Client) writeSnapshot(ctx context.Context, etcdClient etcd.Client, obje
 			return fmt.Errorf("failed to marshal snapshot request: %w", err)
 		}
 		key := fmt.Sprintf("%s/%s", RequestStoreKey, APIVersion)
-		err = writeKeyValue(tarWriter, []byte(key), requestBytes)
+		err = writeKey([]byte(key), requestBytes)
 		if err != nil {
 			return fmt.Errorf("failed to snapshot snapshot request: %w", err)
 		}
@@ -165,6 +289,8 @@ func (c *Client) writeSnapshot(ctx context.Context, etcdClient etcd.Client, obje
 
 	// now write the snapshot
 	backedUpKeys := 0
+	skippedKeys := 0
+	keyIndex := &bytes.Buffer{}
 	for {
 		select {
 		case <-ctx.Done():
@@ -182,13 +308,24 @@ func (c *Client) writeSnapshot(ctx context.Context, etcdClient etcd.Client, obje
 				}
 
 				key := string(obj.Value.Key)
-				if _, ok := c.skipKeys[key]; ok {
+				if key == SnapshotGenerationKey {
+					continue
+				} else if _, ok := c.skipKeys[key]; ok {
 					klog.Infof("Skipping key %s", key)
 					continue
 				}
+				// incremental snapshots only contain keys changed since the base snapshot
+				if manifest.Base != nil {
+					keyIndex.WriteString(key + "\n")
+					if obj.Value.Modified <= manifest.Base.Revision {
+						skippedKeys++
+						continue
+					}
+				}
+
 				// write the object into the store
 				klog.V(1).Infof("Snapshot key %s", key)
-				err := writeKeyValue(tarWriter, obj.Value.Key, obj.Value.Data)
+				err := writeKey(obj.Value.Key, obj.Value.Data)
 				if err != nil {
 					return fmt.Errorf("failed to snapshot key %s: %w", key, err)
 				}
@@ -201,16 +338,105 @@ func (c *Client) writeSnapshot(ctx context.Context, etcdClient etcd.Client, obje
 			} else {
 				klog.Infof("Successfully backed up %d etcd keys", backedUpKeys)
 
+				// write all current keys, so keys deleted since the base snapshot can be removed on restore
+				if manifest.Base != nil {
+					klog.Infof("Skipped %d etcd keys unchanged since revision %d of base snapshot %s", skippedKeys, manifest.Base.Revision, manifest.Base.URL)
+					err := writeKey([]byte(SnapshotKeysKey), keyIndex.Bytes())
+					if err != nil {
+						return fmt.Errorf("failed to write snapshot keys: %w", err)
+					}
+				}
+
+				// write the manifest as last key, so readers can verify the snapshot is complete
+				manifest.Keys = checksum.keys
+				manifest.Checksum = checksum.Checksum()
+				manifest.Digests = checksum.digests
+				manifestBytes, err := json.Marshal(manifest)
+				if err != nil {
+					return fmt.Errorf("failed to marshal snapshot manifest: %w", err)
+				}
+				err = writeKeyValue(tarWriter, []byte(SnapshotManifestKey), manifestBytes)
+				if err != nil {
+					return fmt.Errorf("failed to write snapshot manifest: %w", err)
+				}
+
 				// close the writer to signal we are done, but wait until object store has finished writing
 				_ = tarWriter.Close()
 				_ = gzipWriter.Close()
+				err = snapshotWriter.Close()
+				if err != nil {
+					return fmt.Errorf("failed to finish snapshot encryption: %w", err)
+				}
 				_ = writer.Close()
-				return <-errChan
+				err = <-errChan
+				if err != nil {
+					return err
+				}
+
+				// the base reference is written after the snapshot is complete, so every reference points to a snapshot
+				if manifest.Base != nil {
+					err = writeBaseReference(ctx, &c.Options, manifest.Base)
+					if err != nil {
+						return fmt.Errorf("failed to write base reference: %w", err)
+					}
+				}
+
+				return nil
 			}
 		}
 	}
 }
 
+// backingStoreGeneration returns the generation of the backing store and creates it if the backing store has none yet
+func backingStoreGeneration(ctx context.Context, etcdClient etcd.Client) (string, error) {
+	value, err := etcdClient.Get(ctx, SnapshotGenerationKey)
+	if err == nil {
+		return string(value.Data), nil
+	} else if !errors.Is(err, etcd.ErrNotFound) {
+		return "", err
+	}
+
+	return newBackingStoreGeneration(ctx, etcdClient)
+}
+
+// newBackingStoreGeneration replaces the generation of the backing store, so that no incremental snapshot is based on
+// a snapshot taken before
+func newBackingStoreGeneration(ctx context.Context, etcdClient etcd.Client) (string, error) {
+	generation := uuid.NewString()
+	_, err := etcdClient.Put(ctx, SnapshotGenerationKey, []byte(generation))
+	if err != nil {
+		return "", err
+	}
+
+	return generation, nil
+}
+
+// hostSecretLoader loads snapshot encryption keys from Secrets in the vCluster host namespace
+func hostSecretLoader(ctx context.Context) encryption.SecretLoader {
+	return func(name, key string) ([]byte, error) {
+		restConfig, namespace, err := setupconfig.InitClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		kubeClient, err := kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		secret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		value, ok := secret.Data[key]
+		if !ok {
+			return nil, fmt.Errorf("key %s not found in secret %s/%s", key, namespace, name)
+		}
+
+		return value, nil
+	}
+}
+
 func (c *Client) addResourceToSkip(kindPlural, namespacedName string) {
 	if c.skipKeys == nil {
 		c.skipKeys = make(map[string]struct{})
@@ -1,6 +1,9 @@
 package snapshot
 
 import (
+	"strings"
+
+	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -55,3 +58,67 @@ type RequestMetadata struct {
 	Name              string      `json:"name"`
 	CreationTimestamp metav1.Time `json:"creationTimestamp,omitempty"`
 }
+
+// volumesResult is the result of restoring or snapshotting volumes with a single volumes.Snapshotter
+// or volumes.Restorer.
+type volumesResult struct {
+	phase    volumes.SnapshotRequestPhase
+	message  string
+	requests int
+}
+
+// combineVolumesPhases combines the results of the CSI volume snapshots and the file level volume
+// backups into a single phase and error message.
+func combineVolumesPhases(results ...volumesResult) (volumes.SnapshotRequestPhase, string) {
+	var started []volumesResult
+	for _, result := range results {
+		// requests created before file level volume backups were added don't have them initialized
+		if result.phase == volumes.RequestPhaseNotStarted && result.requests == 0 {
+			continue
+		}
+		started = append(started, result)
+	}
+	if len(started) == 0 {
+		return volumes.RequestPhaseNotStarted, ""
+	}
+
+	// still going on
+	for _, result := range started {
+		switch result.phase {
+		case volumes.RequestPhaseNotStarted,
+			volumes.RequestPhaseInProgress,
+			volumes.RequestPhaseCanceling,
+			volumes.RequestPhaseDeleting:
+			return result.phase, ""
+		}
+	}
+
+	// only results with volumes decide if the request has failed
+	var relevant []volumesResult
+	for _, result := range started {
+		if result.requests > 0 {
+			relevant = append(relevant, result)
+		}
+	}
+	if len(relevant) == 0 {
+		relevant = started
+	}
+
+	var messages []string
+	samePhase, completed := true, true
+	for _, result := range relevant {
+		if result.message != "" {
+			messages = append(messages, result.message)
+		}
+		samePhase = samePhase && result.phase == relevant[0].phase
+		completed = completed && (result.phase == volumes.RequestPhaseCompleted || result.phase == volumes.RequestPhaseSkipped)
+	}
+	switch {
+	case samePhase:
+		return relevant[0].phase, strings.Join(messages, "; ")
+	case completed:
+		return volumes.RequestPhaseCompleted, ""
+	default:
+		return volumes.RequestPhasePartiallyFailed, strings.Join(messages, "; ")
+	}
+}
@@ -31,7 +31,12 @@ func (s *Store) Target() string {
 }
 
 func (s *Store) GetObject(_ context.Context) (io.ReadCloser, error) {
-	return os.Open(s.path)
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %w", types.ErrObjectNotFound, err)
+	}
+
+	return f, err
 }
 
 func (s *Store) PutObject(_ context.Context, body io.Reader) error {
@@ -103,7 +108,7 @@ func (s *Store) Delete(_ context.Context) error {
 		return fmt.Errorf("not a snapshot file")
 	}
 
-	if !strings.HasSuffix(s.path, "tar.gz") {
+	if !strings.HasSuffix(s.path, "tar.gz") && !strings.HasSuffix(s.path, "tar.gz"+types.BaseReferenceSuffix) {
 		return fmt.Errorf("not a snapshot file")
 	}
 
@@ -11,6 +11,7 @@ import (
 	"github.com/loft-sh/vcluster/pkg/constants"
 	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
 	csiVolumes "github.com/loft-sh/vcluster/pkg/snapshot/volumes/csi"
+	"github.com/loft-sh/vcluster/pkg/snapshot/volumes/filebackup"
 	"github.com/loft-sh/vcluster/pkg/syncer/synccontext"
 	"github.com/loft-sh/vcluster/pkg/util/loghelper"
 	corev1 "k8s.io/api/core/v1"
@@ -39,6 +40,7 @@ type Reconciler struct {
 	logger                     loghelper.Logger
 	eventRecorder              record.EventRecorder
 	volumeSnapshotter          volumes.Snapshotter
+	volumeBackupSnapshotter    volumes.Snapshotter
 	isHostMode                 bool
 }
 
@@ -94,6 +96,11 @@ func NewController(registerContext *synccontext.RegisterContext) (*Reconciler, e
 		finalizer:          ControllerFinalizer,
 		requestKey:         RequestKey,
 	}
+	podConfig := newVolumeBackupPodConfig(registerContext.Config, snapshotRequestsManager.GetClient(), reconciler.getRequestNamespace(), registerContext.HostManager.GetAPIReader())
+	volumeBackupSnapshotter, err := filebackup.NewVolumeSnapshotter(registerContext.Config, kubeClient, podConfig, eventRecorder, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create volume backup snapshotter: %w", err)
+	}
 	return &Reconciler{
 		reconcilerBase:             reconciler,
 		vConfig:                    registerContext.Config,
@@ -102,6 +109,7 @@ func NewController(registerContext *synccontext.RegisterContext) (*Reconciler, e
 		logger:                     logger,
 		eventRecorder:              snapshotRequestsManager.GetEventRecorderFor(controllerName),
 		volumeSnapshotter:          volumeSnapshotter,
+		volumeBackupSnapshotter:    volumeBackupSnapshotter,
 		isHostMode:                 isHostMode,
 	}, nil
 }
@@ -191,8 +199,10 @@ func (c *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ct
 		}
 		if snapshotRequest.Status.Phase == RequestPhaseCanceling {
 			snapshotRequest.Status.VolumeSnapshots.Phase = volumes.RequestPhaseCanceling
+			snapshotRequest.Status.VolumeBackups.Phase = volumes.RequestPhaseCanceling
 		} else {
 			snapshotRequest.Status.VolumeSnapshots.Phase = volumes.RequestPhaseDeleting
+			snapshotRequest.Status.VolumeBackups.Phase = volumes.RequestPhaseDeleting
 		}
 		fallthrough
 	case RequestPhaseCreatingVolumeSnapshots:
@@ -254,16 +264,19 @@ func (c *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ct
 }
 
 func (c *Reconciler) reconcileVolumeSnapshots(ctx context.Context, snapshotRequestObj runtime.Object, snapshotRequest *Request) (time.Duration, error) {
-	volumeSnapshotsRequest := &snapshotRequest.Spec.VolumeSnapshots
-	volumeSnapshotsStatus := &snapshotRequest.Status.VolumeSnapshots
-	previousVolumeSnapshotsRequestPhase := volumeSnapshotsStatus.Phase
-	err := c.volumeSnapshotter.Reconcile(ctx, snapshotRequestObj, snapshotRequest.Name, volumeSnapshotsRequest, volumeSnapshotsStatus)
+	previousVolumeSnapshotsRequestPhase, _ := snapshotRequest.volumesPhase()
+	err := c.volumeSnapshotter.Reconcile(ctx, snapshotRequestObj, snapshotRequest.Name, &snapshotRequest.Spec.VolumeSnapshots, &snapshotRequest.Status.VolumeSnapshots)
 	if err != nil {
 		return 0, fmt.Errorf("failed to reconcile volume snapshots: %w", err)
 	}
+	err = c.volumeBackupSnapshotter.Reconcile(ctx, snapshotRequestObj, snapshotRequest.Name, &snapshotRequest.Spec.VolumeBackups, &snapshotRequest.Status.VolumeBackups)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reconcile volume backups: %w", err)
+	}
 
-	// check volume snapshots' status
-	switch volumeSnapshotsStatus.Phase {
+	// check volume snapshots' and volume backups' status
+	volumesPhase, volumesErrorMessage := snapshotRequest.volumesPhase()
+	switch volumesPhase {
 	case volumes.RequestPhaseCanceling:
 		fallthrough
 	case volumes.RequestPhaseDeleting:
@@ -282,13 +295,13 @@ func (c *Reconciler) reconcileVolumeSnapshots(ctx context.Context, snapshotReque
 		snapshotRequest.Status.Phase = RequestPhaseCreatingEtcdBackup
 	case volumes.RequestPhaseFailed:
 		snapshotRequest.Status.Phase = RequestPhaseFailed
-		snapshotRequest.Status.Error.Message = volumeSnapshotsStatus.Error.Message
+		snapshotRequest.Status.Error.Message = volumesErrorMessage
 	case volumes.RequestPhaseCanceled:
 		snapshotRequest.Status.Phase = RequestPhaseCanceled
 	case volumes.RequestPhaseDeleted:
 		snapshotRequest.Status.Phase = snapshotRequest.Status.Phase.Next()
 	default:
-		return 0, fmt.Errorf("unexpected volume snapshots request phase %s", volumeSnapshotsStatus.Phase)
+		return 0, fmt.Errorf("unexpected volume snapshots request phase %s", volumesPhase)
 	}
 
 	return 0, nil
@@ -324,6 +337,9 @@ func (c *Reconciler) reconcileNewRequest(_ context.Context, configMap *corev1.Co
 		snapshotRequest.Spec.VolumeSnapshots = volumes.SnapshotsRequest{
 			Requests: []volumes.SnapshotRequest{},
 		}
+		snapshotRequest.Spec.VolumeBackups = volumes.SnapshotsRequest{
+			Requests: []volumes.SnapshotRequest{},
+		}
 		snapshotRequest.Status.Phase = RequestPhaseCreatingVolumeSnapshots
 		c.eventRecorder.Eventf(configMap, corev1.EventTypeNormal, "CreatingVolumeSnapshots", "Started to create volume snapshots for snapshot request %s/%s", configMap.Namespace, configMap.Name)
 	} else {
@@ -387,13 +403,14 @@ func (c *Reconciler) reconcileCreatingEtcdBackup(ctx context.Context, configMap
 
 	// All done, now update the snapshot request phase to "Completed"! ✅
 	if snapshotRequest.Spec.IncludeVolumes {
-		if snapshotRequest.Status.VolumeSnapshots.Phase == volumes.RequestPhaseCompleted {
+		volumesPhase, volumesErrorMessage := snapshotRequest.volumesPhase()
+		if volumesPhase == volumes.RequestPhaseCompleted {
 			snapshotRequest.Status.Phase = RequestPhaseCompleted
-		} else if snapshotRequest.Status.VolumeSnapshots.Phase == volumes.RequestPhasePartiallyFailed {
+		} else if volumesPhase == volumes.RequestPhasePartiallyFailed {
 			snapshotRequest.Status.Phase = RequestPhasePartiallyFailed
-			snapshotRequest.Status.Error.Message = snapshotRequest.Status.VolumeSnapshots.Error.Message
+			snapshotRequest.Status.Error.Message = volumesErrorMessage
 		} else {
-			return false, fmt.Errorf("unexpected volume snapshots request phase %s", snapshotRequest.Status.VolumeSnapshots.Phase)
+			return false, fmt.Errorf("unexpected volume snapshots request phase %s", volumesPhase)
 		}
 	} else {
 		snapshotRequest.Status.Phase = RequestPhaseCompleted
@@ -68,6 +68,12 @@ func (c *Reconciler) reconcileDeletingEtcdBackup(ctx context.Context, configMap
 		return false, fmt.Errorf("failed to delete etcd backup: %w", err)
 	}
 	c.logger.Infof("Deleted vCluster etcd backup at %s for the snapshot deletion request %s/%s", snapshotRequest.Spec.URL, configMap.Namespace, configMap.Name)
+
+	// the file level volume backups are stored next to the etcd backup, so they are deleted together
+	err = DeleteVolumeBackups(ctx, snapshotOptions, snapshotRequest.Status.VolumeBackups)
+	if err != nil {
+		c.logger.Errorf("Failed to delete volume backups for the snapshot deletion request %s/%s: %v", configMap.Namespace, configMap.Name, err)
+	}
 	snapshotRequest.Status.Phase = snapshotRequest.Status.Phase.Next()
 	return false, nil
 }
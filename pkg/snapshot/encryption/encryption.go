@@ -0,0 +1,559 @@
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/loft-sh/vcluster/pkg/snapshot/options"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// Magic is written in front of every encrypted snapshot
+	Magic = "VCLUSTER-ENCRYPTED-SNAPSHOT/v1\n"
+
+	// CipherAES256GCM is the only supported cipher for now
+	CipherAES256GCM = "AES-256-GCM"
+
+	// DefaultSecretKey is the key within the host Secret that holds the encryption key
+	DefaultSecretKey = "key"
+
+	KeyTypePassphrase = "passphrase"
+	KeyTypeAge        = "age"
+	KeyTypeSecret     = "secret"
+
+	chunkSize     = 64 * 1024
+	dataKeySize   = 32
+	noncePrefix   = 7
+	maxHeaderSize = 1024 * 1024
+
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+)
+
+var (
+	// ErrNoKey is returned if a snapshot is encrypted, but none of the given keys can decrypt it
+	ErrNoKey = errors.New("snapshot is encrypted, but no matching key was provided. Please specify encryption-passphrase, age-identity or encryption-key-secret")
+)
+
+// Options holds the key material used to encrypt or decrypt a snapshot. All options can be passed
+// as snapshot url parameters.
+type Options struct {
+	Passphrase    string   `json:"passphrase,omitempty" url:"encryption-passphrase,base64"`
+	AgeRecipients []string `json:"age-recipients,omitempty" url:"age-recipient"`
+	AgeIdentity   string   `json:"age-identity,omitempty" url:"age-identity,base64"`
+
+	// KeySecret is the name of a Secret in the vCluster host namespace that holds the encryption key
+	KeySecret    string `json:"key-secret,omitempty" url:"encryption-key-secret"`
+	KeySecretKey string `json:"key-secret-key,omitempty" url:"encryption-key-secret-key"`
+}
+
+// Enabled returns true if new snapshots should be encrypted
+func (o *Options) Enabled() bool {
+	return o.Passphrase != "" || len(o.AgeRecipients) > 0 || o.KeySecret != ""
+}
+
+// ParseQuery fills the options from the url query and removes the encryption parameters from it, so
+// the remaining parameters can be parsed by the store.
+func (o *Options) ParseQuery(query url.Values) error {
+	err := options.PopulateStructFromMap(o, query, false)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range []string{"encryption-passphrase", "age-recipient", "age-identity", "encryption-key-secret", "encryption-key-secret-key"} {
+		query.Del(key)
+	}
+
+	return nil
+}
+
+// SecretLoader returns the value of key within the Secret name from the vCluster host namespace
+type SecretLoader func(name, key string) ([]byte, error)
+
+// Metadata describes how a snapshot was encrypted and is stored in front of the encrypted archive
+type Metadata struct {
+	Cipher    string       `json:"cipher"`
+	ChunkSize int          `json:"chunkSize"`
+	Nonce     []byte       `json:"nonce"`
+	Keys      []WrappedKey `json:"keys"`
+}
+
+// WrappedKey is the data key of the snapshot encrypted with a passphrase, age recipients or a host Secret
+type WrappedKey struct {
+	Type string `json:"type"`
+
+	Secret    string `json:"secret,omitempty"`
+	SecretKey string `json:"secretKey,omitempty"`
+
+	Salt  []byte `json:"salt,omitempty"`
+	Nonce []byte `json:"nonce,omitempty"`
+	Key   []byte `json:"key"`
+}
+
+// NewWriter writes the encryption metadata to w and returns a writer that encrypts all data written to it. The
+// writer needs to be closed to write the last chunk.
+func NewWriter(w io.Writer, opts *Options, loadSecret SecretLoader) (io.WriteCloser, error) {
+	dataKey := make([]byte, dataKeySize)
+	nonce := make([]byte, noncePrefix)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	metadata := &Metadata{
+		Cipher:    CipherAES256GCM,
+		ChunkSize: chunkSize,
+		Nonce:     nonce,
+	}
+	if opts.Passphrase != "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+
+		wrappedKey, err := wrapKey(passphraseKey(opts.Passphrase, salt), dataKey)
+		if err != nil {
+			return nil, err
+		}
+		wrappedKey.Type = KeyTypePassphrase
+		wrappedKey.Salt = salt
+		metadata.Keys = append(metadata.Keys, *wrappedKey)
+	}
+	if len(opts.AgeRecipients) > 0 {
+		recipients, err := age.ParseRecipients(strings.NewReader(strings.Join(opts.AgeRecipients, "\n")))
+		if err != nil {
+			return nil, fmt.Errorf("parse age recipients: %w", err)
+		}
+
+		buf := &bytes.Buffer{}
+		ageWriter, err := age.Encrypt(buf, recipients...)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt data key for age recipients: %w", err)
+		}
+		if _, err := ageWriter.Write(dataKey); err != nil {
+			return nil, err
+		}
+		if err := ageWriter.Close(); err != nil {
+			return nil, err
+		}
+		metadata.Keys = append(metadata.Keys, WrappedKey{Type: KeyTypeAge, Key: buf.Bytes()})
+	}
+	if opts.KeySecret != "" {
+		secretKey := opts.KeySecretKey
+		if secretKey == "" {
+			secretKey = DefaultSecretKey
+		}
+		keyEncryptionKey, err := secretKeyEncryptionKey(opts.KeySecret, secretKey, loadSecret)
+		if err != nil {
+			return nil, err
+		}
+
+		wrappedKey, err := wrapKey(keyEncryptionKey, dataKey)
+		if err != nil {
+			return nil, err
+		}
+		wrappedKey.Type = KeyTypeSecret
+		wrappedKey.Secret = opts.KeySecret
+		wrappedKey.SecretKey = secretKey
+		metadata.Keys = append(metadata.Keys, *wrappedKey)
+	}
+	if len(metadata.Keys) == 0 {
+		return nil, errors.New("no encryption key specified")
+	}
+
+	header, err := writeMetadata(w, metadata)
+	if err != nil {
+		return nil, fmt.Errorf("write encryption metadata: %w", err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &writer{
+		w:      w,
+		aead:   aead,
+		nonce:  nonce,
+		header: header,
+		buf:    make([]byte, 0, chunkSize),
+	}, nil
+}
+
+// NewReader returns a reader that decrypts r if it is an encrypted snapshot. Unencrypted snapshots are
+// returned as they are.
+func NewReader(r io.Reader, opts *Options, loadSecret SecretLoader) (io.Reader, error) {
+	bufferedReader := bufio.NewReader(r)
+	prefix, err := bufferedReader.Peek(len(Magic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	} else if string(prefix) != Magic {
+		return bufferedReader, nil
+	}
+
+	// the metadata is authenticated together with every chunk, so it is only trusted after the first chunk was
+	// decrypted. Until then the chunk size needs to match, as it determines how much memory is allocated.
+	metadata, header, err := readMetadata(bufferedReader)
+	if err != nil {
+		return nil, fmt.Errorf("read encryption metadata: %w", err)
+	} else if metadata.Cipher != CipherAES256GCM {
+		return nil, fmt.Errorf("unsupported snapshot cipher %s", metadata.Cipher)
+	} else if metadata.ChunkSize != chunkSize {
+		return nil, fmt.Errorf("unsupported encryption chunk size %d", metadata.ChunkSize)
+	} else if len(metadata.Nonce) != noncePrefix {
+		return nil, errors.New("invalid encryption metadata")
+	}
+
+	dataKey, err := unwrapDataKey(metadata, opts, loadSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reader{
+		r:      bufferedReader,
+		aead:   aead,
+		nonce:  metadata.Nonce,
+		header: header,
+		sealed: make([]byte, chunkSize+aead.Overhead()),
+	}, nil
+}
+
+// IsEncrypted checks if the given snapshot prefix belongs to an encrypted snapshot
+func IsEncrypted(prefix []byte) bool {
+	return bytes.HasPrefix(prefix, []byte(Magic))
+}
+
+func unwrapDataKey(metadata *Metadata, opts *Options, loadSecret SecretLoader) ([]byte, error) {
+	var errs []error
+	for _, wrappedKey := range metadata.Keys {
+		var (
+			dataKey []byte
+			err     error
+		)
+		switch wrappedKey.Type {
+		case KeyTypePassphrase:
+			if opts.Passphrase == "" {
+				continue
+			}
+
+			dataKey, err = unwrapKey(passphraseKey(opts.Passphrase, wrappedKey.Salt), &wrappedKey)
+		case KeyTypeAge:
+			if opts.AgeIdentity == "" {
+				continue
+			}
+
+			var identities []age.Identity
+			identities, err = age.ParseIdentities(strings.NewReader(opts.AgeIdentity))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("parse age identity: %w", err))
+				continue
+			}
+
+			var ageReader io.Reader
+			ageReader, err = age.Decrypt(bytes.NewReader(wrappedKey.Key), identities...)
+			if err == nil {
+				dataKey, err = io.ReadAll(ageReader)
+			}
+		case KeyTypeSecret:
+			if loadSecret == nil {
+				continue
+			}
+
+			// the secret from the options takes precedence, e.g. when restoring into another vCluster
+			secretName, secretKey := wrappedKey.Secret, wrappedKey.SecretKey
+			if opts.KeySecret != "" {
+				secretName = opts.KeySecret
+				if opts.KeySecretKey != "" {
+					secretKey = opts.KeySecretKey
+				}
+			}
+
+			var keyEncryptionKey []byte
+			keyEncryptionKey, err = secretKeyEncryptionKey(secretName, secretKey, loadSecret)
+			if err == nil {
+				dataKey, err = unwrapKey(keyEncryptionKey, &wrappedKey)
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("decrypt data key with %s: %w", wrappedKey.Type, err))
+			continue
+		} else if len(dataKey) != dataKeySize {
+			errs = append(errs, fmt.Errorf("decrypt data key with %s: unexpected key size", wrappedKey.Type))
+			continue
+		}
+
+		return dataKey, nil
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return nil, ErrNoKey
+}
+
+func secretKeyEncryptionKey(secretName, secretKey string, loadSecret SecretLoader) ([]byte, error) {
+	if loadSecret == nil {
+		return nil, fmt.Errorf("cannot load encryption key from secret %s", secretName)
+	}
+	if secretKey == "" {
+		secretKey = DefaultSecretKey
+	}
+
+	value, err := loadSecret(secretName, secretKey)
+	if err != nil {
+		return nil, fmt.Errorf("load encryption key from secret %s: %w", secretName, err)
+	}
+
+	// accept raw and base64 encoded keys
+	value = bytes.TrimSpace(value)
+	if decoded, err := base64.StdEncoding.DecodeString(string(value)); err == nil {
+		value = decoded
+	}
+	if len(value) < 16 {
+		return nil, fmt.Errorf("encryption key in secret %s needs to have at least 16 bytes", secretName)
+	}
+
+	return hkdf.Key(sha256.New, value, nil, "vcluster-snapshot-key", dataKeySize)
+}
+
+func passphraseKey(passphrase string, salt []byte) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, argon2Time, argon2Memory, argon2Threads, dataKeySize)
+}
+
+func wrapKey(keyEncryptionKey, dataKey []byte) (*WrappedKey, error) {
+	aead, err := newAEAD(keyEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return &WrappedKey{
+		Nonce: nonce,
+		Key:   aead.Seal(nil, nonce, dataKey, nil),
+	}, nil
+}
+
+func unwrapKey(keyEncryptionKey []byte, wrappedKey *WrappedKey) ([]byte, error) {
+	aead, err := newAEAD(keyEncryptionKey)
+	if err != nil {
+		return nil, err
+	} else if len(wrappedKey.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid nonce")
+	}
+
+	dataKey, err := aead.Open(nil, wrappedKey.Nonce, wrappedKey.Key, nil)
+	if err != nil {
+		return nil, errors.New("wrong key")
+	}
+
+	return dataKey, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// writeMetadata writes the magic and metadata and returns them as header, which is passed as additional data
+// when sealing chunks
+func writeMetadata(w io.Writer, metadata *Metadata) ([]byte, error) {
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(Magic)+4+len(raw))
+	header = append(header, Magic...)
+	header = binary.BigEndian.AppendUint32(header, uint32(len(raw)))
+	header = append(header, raw...)
+	_, err = w.Write(header)
+	if err != nil {
+		return nil, err
+	}
+
+	return header, nil
+}
+
+// readMetadata reads the magic and metadata and returns the header exactly as it was written
+func readMetadata(r io.Reader) (*Metadata, []byte, error) {
+	prefix := make([]byte, len(Magic)+4)
+	_, err := io.ReadFull(r, prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	size := binary.BigEndian.Uint32(prefix[len(Magic):])
+	if size > maxHeaderSize {
+		return nil, nil, fmt.Errorf("encryption metadata too large: %d bytes", size)
+	}
+
+	header := make([]byte, len(prefix)+int(size))
+	copy(header, prefix)
+	_, err = io.ReadFull(r, header[len(prefix):])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metadata := &Metadata{}
+	err = json.Unmarshal(header[len(prefix):], metadata)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return metadata, header, nil
+}
+
+// chunkNonce builds the nonce of a chunk from the random prefix, the chunk counter and a flag for the last chunk,
+// so chunks can neither be reordered nor truncated without failing authentication.
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 0, noncePrefix+5)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	if last {
+		return append(nonce, 1)
+	}
+
+	return append(nonce, 0)
+}
+
+type writer struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	nonce   []byte
+	header  []byte
+	counter uint32
+	buf     []byte
+	closed  bool
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write to closed writer")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// the last chunk is only written on close, so we flush full chunks only once more data arrives
+		if len(w.buf) == chunkSize {
+			err := w.flush(false)
+			if err != nil {
+				return written, err
+			}
+		}
+
+		n := copy(w.buf[len(w.buf):chunkSize], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+	}
+
+	return written, nil
+}
+
+func (w *writer) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+	return w.flush(true)
+}
+
+func (w *writer) flush(last bool) error {
+	sealed := w.aead.Seal(nil, chunkNonce(w.nonce, w.counter, last), w.buf, w.header)
+	_, err := w.w.Write(sealed)
+	if err != nil {
+		return err
+	}
+
+	w.counter++
+	w.buf = w.buf[:0]
+	return nil
+}
+
+type reader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	nonce   []byte
+	header  []byte
+	counter uint32
+
+	sealed []byte
+	buf    []byte
+	done   bool
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+
+		err := r.readChunk()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *reader) readChunk() error {
+	n, err := io.ReadFull(r.r, r.sealed)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	} else if n < r.aead.Overhead() {
+		return errors.New("encrypted snapshot is truncated")
+	}
+	sealed := r.sealed[:n]
+
+	// a full chunk might be the last one as well, so we try both
+	plaintext, openErr := r.aead.Open(nil, chunkNonce(r.nonce, r.counter, false), sealed, r.header)
+	if openErr != nil || n < len(r.sealed) {
+		plaintext, openErr = r.aead.Open(nil, chunkNonce(r.nonce, r.counter, true), sealed, r.header)
+		if openErr != nil {
+			return errors.New("decrypt snapshot: message authentication failed")
+		}
+
+		r.done = true
+	}
+
+	r.counter++
+	r.buf = plaintext
+	return nil
+}
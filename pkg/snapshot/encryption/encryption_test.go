@@ -0,0 +1,182 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"testing"
+
+	"filippo.io/age"
+	"gotest.tools/v3/assert"
+)
+
+func TestRoundTrip(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	assert.NilError(t, err)
+	otherIdentity, err := age.GenerateX25519Identity()
+	assert.NilError(t, err)
+
+	secrets := map[string][]byte{"my-secret/key": []byte("my-secret-value-0123456789")}
+	loadSecret := func(name, key string) ([]byte, error) {
+		value, ok := secrets[name+"/"+key]
+		if !ok {
+			return nil, fmt.Errorf("secret %s not found", name)
+		}
+		return value, nil
+	}
+
+	tests := []struct {
+		name        string
+		encrypt     Options
+		decrypt     Options
+		size        int
+		expectedErr bool
+	}{
+		{
+			name:    "passphrase",
+			encrypt: Options{Passphrase: "my-passphrase"},
+			decrypt: Options{Passphrase: "my-passphrase"},
+			size:    1024,
+		},
+		{
+			name:        "wrong passphrase",
+			encrypt:     Options{Passphrase: "my-passphrase"},
+			decrypt:     Options{Passphrase: "other-passphrase"},
+			size:        1024,
+			expectedErr: true,
+		},
+		{
+			name:    "age",
+			encrypt: Options{AgeRecipients: []string{identity.Recipient().String()}},
+			decrypt: Options{AgeIdentity: identity.String()},
+			size:    3*chunkSize + 17,
+		},
+		{
+			name:        "wrong age identity",
+			encrypt:     Options{AgeRecipients: []string{identity.Recipient().String()}},
+			decrypt:     Options{AgeIdentity: otherIdentity.String()},
+			size:        10,
+			expectedErr: true,
+		},
+		{
+			name:    "secret",
+			encrypt: Options{KeySecret: "my-secret"},
+			decrypt: Options{},
+			size:    2 * chunkSize,
+		},
+		{
+			name:    "multiple keys",
+			encrypt: Options{Passphrase: "my-passphrase", KeySecret: "my-secret"},
+			decrypt: Options{Passphrase: "my-passphrase"},
+			size:    chunkSize,
+		},
+		{
+			name:    "empty",
+			encrypt: Options{Passphrase: "my-passphrase"},
+			decrypt: Options{Passphrase: "my-passphrase"},
+			size:    0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plaintext := make([]byte, test.size)
+			_, err := rand.Read(plaintext)
+			assert.NilError(t, err)
+
+			encrypted := &bytes.Buffer{}
+			writer, err := NewWriter(encrypted, &test.encrypt, loadSecret)
+			assert.NilError(t, err)
+			_, err = writer.Write(plaintext)
+			assert.NilError(t, err)
+			assert.NilError(t, writer.Close())
+			assert.Assert(t, IsEncrypted(encrypted.Bytes()))
+			assert.Assert(t, !bytes.Contains(encrypted.Bytes(), []byte("my-secret-value-0123456789")))
+
+			reader, err := NewReader(encrypted, &test.decrypt, loadSecret)
+			if test.expectedErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+
+			decrypted, err := io.ReadAll(reader)
+			assert.NilError(t, err)
+			assert.Assert(t, bytes.Equal(decrypted, plaintext))
+		})
+	}
+}
+
+func TestNoKey(t *testing.T) {
+	encrypted := &bytes.Buffer{}
+	writer, err := NewWriter(encrypted, &Options{Passphrase: "my-passphrase"}, nil)
+	assert.NilError(t, err)
+	assert.NilError(t, writer.Close())
+
+	_, err = NewReader(encrypted, &Options{}, nil)
+	assert.Assert(t, errors.Is(err, ErrNoKey))
+}
+
+func TestTampering(t *testing.T) {
+	encrypt := func(size int) []byte {
+		encrypted := &bytes.Buffer{}
+		writer, err := NewWriter(encrypted, &Options{Passphrase: "my-passphrase"}, nil)
+		assert.NilError(t, err)
+		_, err = writer.Write(bytes.Repeat([]byte("a"), size))
+		assert.NilError(t, err)
+		assert.NilError(t, writer.Close())
+		return encrypted.Bytes()
+	}
+	decrypt := func(encrypted []byte) error {
+		reader, err := NewReader(bytes.NewReader(encrypted), &Options{Passphrase: "my-passphrase"}, nil)
+		if err != nil {
+			return err
+		}
+		_, err = io.ReadAll(reader)
+		return err
+	}
+
+	// truncated within a chunk
+	encrypted := encrypt(2*chunkSize + 100)
+	assert.Assert(t, decrypt(encrypted[:len(encrypted)-50]) != nil)
+
+	// truncated at a chunk boundary
+	encrypted = encrypt(2 * chunkSize)
+	assert.Assert(t, decrypt(encrypted[:len(encrypted)-16]) != nil)
+
+	// modified ciphertext
+	encrypted = encrypt(100)
+	encrypted[len(encrypted)-20] ^= 0xff
+	assert.Assert(t, decrypt(encrypted) != nil)
+
+	// modified chunk size
+	encrypted = bytes.Replace(encrypt(100), []byte(fmt.Sprintf(`"chunkSize":%d`, chunkSize)), []byte(`"chunkSize":99999`), 1)
+	assert.ErrorContains(t, decrypt(encrypted), "unsupported encryption chunk size 99999")
+
+	// modified metadata that still decodes to the same values, json field names are case insensitive
+	encrypted = bytes.Replace(encrypt(100), []byte(`"chunkSize"`), []byte(`"chunksize"`), 1)
+	assert.ErrorContains(t, decrypt(encrypted), "message authentication failed")
+}
+
+func TestPlaintextPassthrough(t *testing.T) {
+	reader, err := NewReader(bytes.NewReader([]byte("plain snapshot")), &Options{Passphrase: "my-passphrase"}, nil)
+	assert.NilError(t, err)
+	plaintext, err := io.ReadAll(reader)
+	assert.NilError(t, err)
+	assert.Equal(t, string(plaintext), "plain snapshot")
+}
+
+func TestParseQuery(t *testing.T) {
+	query, err := url.ParseQuery("region=eu-west-1&encryption-key-secret=my-secret&age-recipient=age1a&age-recipient=age1b")
+	assert.NilError(t, err)
+
+	options := &Options{}
+	assert.NilError(t, options.ParseQuery(query))
+	assert.Equal(t, options.KeySecret, "my-secret")
+	assert.DeepEqual(t, options.AgeRecipients, []string{"age1a", "age1b"})
+	assert.Assert(t, options.Enabled())
+	assert.DeepEqual(t, query, url.Values{"region": []string{"eu-west-1"}})
+}
@@ -0,0 +1,127 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/loft-sh/vcluster/pkg/snapshot/types"
+)
+
+// Options configure a snapshot stored on a filesystem, e.g. an NFS or hostPath volume shared across vClusters
+type Options struct {
+	Path string `json:"path,omitempty"`
+}
+
+func NewStore(options *Options) *Store {
+	return &Store{
+		path: options.Path,
+	}
+}
+
+type Store struct {
+	path string
+}
+
+func (s *Store) Target() string {
+	return "file://" + s.path
+}
+
+func (s *Store) GetObject(_ context.Context) (io.ReadCloser, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %w", types.ErrObjectNotFound, err)
+	}
+
+	return f, err
+}
+
+// PutObject writes the snapshot to a temporary file next to the target and renames it afterwards,
+// so other vClusters sharing the same volume never see a partially written snapshot.
+func (s *Store) PutObject(_ context.Context, body io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(s.path), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = io.Copy(f, body)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	// make sure the snapshot is persisted before it becomes visible
+	err = f.Sync()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path)
+}
+
+func (s *Store) List(_ context.Context) ([]types.Snapshot, error) {
+	path := s.path
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fileInfo.IsDir() {
+		path = filepath.Dir(path)
+	}
+
+	var snapshots []types.Snapshot
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		eInfo, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		if eInfo.IsDir() || !strings.HasSuffix(entry.Name(), "tar.gz") {
+			continue
+		}
+
+		snapshots = append(snapshots, types.Snapshot{
+			ID:        entry.Name(),
+			URL:       "file://" + filepath.Join(path, entry.Name()),
+			Timestamp: eInfo.ModTime(),
+		})
+	}
+	return snapshots, nil
+}
+
+func (s *Store) Delete(_ context.Context) error {
+	fileInfo, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+
+	if fileInfo.IsDir() || (!strings.HasSuffix(s.path, "tar.gz") && !strings.HasSuffix(s.path, "tar.gz"+types.BaseReferenceSuffix)) {
+		return fmt.Errorf("not a snapshot file")
+	}
+
+	return os.Remove(s.path)
+}
@@ -0,0 +1,186 @@
+package gcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/loft-sh/vcluster/pkg/snapshot/types"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// uploadChunkSize is the size of a single resumable upload request, needs to be a multiple of 256KiB
+var uploadChunkSize = 8 * 1024 * 1024
+
+type Options struct {
+	Bucket string `json:"bucket,omitempty"`
+	Key    string `json:"key,omitempty"`
+
+	SkipClientCredentials bool `json:"skip-client-credentials,omitempty" url:"skip-client-credentials"`
+
+	// Credentials is the content of a service account key or application default credentials file
+	Credentials     string `json:"credentials,omitempty" url:"credentials,base64"`
+	CredentialsFile string `json:"credentials-file,omitempty" url:"credentials-file,base64"`
+	AccessToken     string `json:"access-token,omitempty" url:"access-token,base64"`
+
+	// URL overrides the storage endpoint, e.g. for an emulator. No credentials are used if only the url is set.
+	URL string `json:"url,omitempty" url:"url,base64"`
+}
+
+func (o *Options) FillCredentials(isClient bool) {
+	if (isClient && o.SkipClientCredentials) || o.Bucket == "" || o.Credentials != "" || o.CredentialsFile != "" || o.AccessToken != "" || o.URL != "" {
+		return
+	}
+
+	credentials, err := os.ReadFile(defaultCredentialsFile())
+	if err != nil {
+		return
+	}
+
+	o.Credentials = string(credentials)
+}
+
+// defaultCredentialsFile returns the path of the application default credentials
+func defaultCredentialsFile() string {
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		return path
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(configDir, "gcloud", "application_default_credentials.json")
+}
+
+type Store struct {
+	client *storage.Client
+
+	bucket string
+	key    string
+}
+
+func NewStore(ctx context.Context, options *Options) (*Store, error) {
+	options.FillCredentials(false)
+
+	clientOptions := []option.ClientOption{option.WithScopes(storage.ScopeReadWrite)}
+	if options.URL != "" {
+		clientOptions = append(clientOptions, option.WithEndpoint(strings.TrimSuffix(options.URL, "/")+"/storage/v1/"), storage.WithJSONReads())
+	}
+
+	switch {
+	case options.AccessToken != "":
+		clientOptions = append(clientOptions, option.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: options.AccessToken})))
+	case options.Credentials != "":
+		clientOptions = append(clientOptions, option.WithCredentialsJSON([]byte(options.Credentials)))
+	case options.CredentialsFile != "":
+		clientOptions = append(clientOptions, option.WithCredentialsFile(options.CredentialsFile))
+	case options.URL != "":
+		clientOptions = append(clientOptions, option.WithoutAuthentication())
+	}
+
+	// without explicit credentials the client uses the application default credentials, e.g. workload identity on GKE
+	client, err := storage.NewClient(ctx, clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("create storage client: %w", err)
+	}
+
+	return &Store{
+		client: client,
+		bucket: options.Bucket,
+		key:    options.Key,
+	}, nil
+}
+
+func (s *Store) Target() string {
+	return "gs://" + s.bucket + "/" + s.key
+}
+
+// PutObject streams the snapshot to the bucket with a resumable upload, as the size is not known beforehand
+func (s *Store) PutObject(ctx context.Context, body io.Reader) error {
+	writer := s.client.Bucket(s.bucket).Object(s.key).NewWriter(ctx)
+	writer.ChunkSize = uploadChunkSize
+	writer.ContentType = "application/gzip"
+
+	_, err := io.Copy(writer, body)
+	if err != nil {
+		_ = writer.Close()
+		return fmt.Errorf("error putting object %s: %w", s.key, err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("error putting object %s: %w", s.key, err)
+	}
+
+	return nil
+}
+
+func (s *Store) GetObject(ctx context.Context) (io.ReadCloser, error) {
+	reader, err := s.client.Bucket(s.bucket).Object(s.key).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, fmt.Errorf("error getting object %s: %w: %w", s.key, types.ErrObjectNotFound, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting object %s: %w", s.key, err)
+	}
+
+	return reader, nil
+}
+
+func (s *Store) List(ctx context.Context) ([]types.Snapshot, error) {
+	prefix := s.key
+	if strings.HasSuffix(prefix, "tar.gz") {
+		// Use the "parent dir" as the prefix if a file was given
+		prefix = filepath.Dir(prefix)
+
+		// Handle if the key is at the root of the bucket.
+		if prefix == "." {
+			prefix = ""
+		}
+	}
+
+	snapshots := make([]types.Snapshot, 0)
+	objects := s.client.Bucket(s.bucket).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		obj, err := objects.Next()
+		if errors.Is(err, iterator.Done) {
+			return snapshots, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("list objects: %w", err)
+		}
+
+		// Skip non *.tar.gz objects
+		if !strings.HasSuffix(obj.Name, "tar.gz") {
+			continue
+		}
+
+		// Skip objects not in the "current directory"
+		id := strings.TrimPrefix(strings.TrimPrefix(obj.Name, prefix), "/")
+		if filepath.Dir(id) != "." {
+			continue
+		}
+
+		snapshots = append(snapshots, types.Snapshot{
+			ID:        id,
+			URL:       "gs://" + s.bucket + "/" + obj.Name,
+			Timestamp: obj.Updated,
+		})
+	}
+}
+
+func (s *Store) Delete(ctx context.Context) error {
+	err := s.client.Bucket(s.bucket).Object(s.key).Delete(ctx)
+	if err != nil {
+		return fmt.Errorf("error deleting object %s: %w", s.key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package gcs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/loft-sh/vcluster/pkg/snapshot/types"
+	"gotest.tools/v3/assert"
+)
+
+// fakeServer implements the parts of the cloud storage json api used by the storage client
+type fakeServer struct {
+	*httptest.Server
+
+	m        sync.Mutex
+	objects  map[string][]byte
+	sessions map[string]string
+	uploads  map[string][]byte
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	f := &fakeServer{
+		objects:  map[string][]byte{},
+		sessions: map[string]string{},
+		uploads:  map[string][]byte{},
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeServer) handle(w http.ResponseWriter, r *http.Request) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer my-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	objectName, isObject := strings.CutPrefix(r.URL.Path, "/storage/v1/b/my-bucket/o/")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/my-bucket/o" && r.URL.Query().Get("uploadType") == "multipart":
+		name, content, err := readMultipartUpload(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[name] = content
+		writeObject(w, name)
+	case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/my-bucket/o" && r.URL.Query().Get("uploadType") == "resumable":
+		metadata := &struct {
+			Name string `json:"name"`
+		}{}
+		if json.NewDecoder(r.Body).Decode(metadata) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		session := strconv.Itoa(len(f.sessions))
+		f.sessions[session] = metadata.Name
+		w.Header().Set("Location", f.URL+"/upload/session/"+session)
+	case (r.Method == http.MethodPut || r.Method == http.MethodPost) && strings.HasPrefix(r.URL.Path, "/upload/session/"):
+		session := strings.TrimPrefix(r.URL.Path, "/upload/session/")
+		body, _ := io.ReadAll(r.Body)
+		f.uploads[session] = append(f.uploads[session], body...)
+		contentRange := r.Header.Get("Content-Range")
+		if strings.HasSuffix(contentRange, "/*") {
+			// the client asks for a 200 instead of the upload specific 308 via X-GUploader-No-308
+			w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(f.uploads[session])-1))
+			w.Header().Set("X-Http-Status-Code-Override", "308")
+			return
+		}
+
+		total, _ := strconv.Atoi(contentRange[strings.LastIndex(contentRange, "/")+1:])
+		if total != len(f.uploads[session]) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[f.sessions[session]] = f.uploads[session]
+		writeObject(w, f.sessions[session])
+	case r.Method == http.MethodGet && isObject && r.URL.Query().Get("alt") == "media":
+		object, ok := f.objects[objectName]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(object)
+	case r.Method == http.MethodDelete && isObject:
+		if _, ok := f.objects[objectName]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.objects, objectName)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b/my-bucket/o":
+		// return one object per page to test paging
+		names := []string{}
+		for name := range f.objects {
+			if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+		list := map[string]interface{}{"kind": "storage#objects"}
+		if page < len(names) {
+			list["items"] = []map[string]interface{}{objectMetadata(names[page])}
+		}
+		if page+1 < len(names) {
+			list["nextPageToken"] = strconv.Itoa(page + 1)
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func readMultipartUpload(r *http.Request) (string, []byte, error) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	reader := multipart.NewReader(r.Body, params["boundary"])
+	metadataPart, err := reader.NextPart()
+	if err != nil {
+		return "", nil, err
+	}
+	metadata := &struct {
+		Name string `json:"name"`
+	}{}
+	err = json.NewDecoder(metadataPart).Decode(metadata)
+	if err != nil {
+		return "", nil, err
+	}
+
+	mediaPart, err := reader.NextPart()
+	if err != nil {
+		return "", nil, err
+	}
+	content, err := io.ReadAll(mediaPart)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return metadata.Name, content, nil
+}
+
+func objectMetadata(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"kind":    "storage#object",
+		"bucket":  "my-bucket",
+		"name":    name,
+		"updated": time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339),
+	}
+}
+
+func writeObject(w http.ResponseWriter, name string) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(objectMetadata(name))
+}
+
+func TestStore(t *testing.T) {
+	defer func(chunkSize int) { uploadChunkSize = chunkSize }(uploadChunkSize)
+	uploadChunkSize = 256 * 1024
+
+	ctx := context.Background()
+	server := newFakeServer(t)
+
+	// a small snapshot is uploaded in a single request, a large one with a resumable upload
+	for _, content := range []string{"0123456789abcdefghij", strings.Repeat("0123456789", 60*1024)} {
+		store, err := NewStore(ctx, &Options{Bucket: "my-bucket", Key: "snapshots/my-snapshot.tar.gz", AccessToken: "my-token", URL: server.URL})
+		assert.NilError(t, err)
+		assert.Equal(t, store.Target(), "gs://my-bucket/snapshots/my-snapshot.tar.gz")
+		assert.NilError(t, store.PutObject(ctx, strings.NewReader(content)))
+
+		reader, err := store.GetObject(ctx)
+		assert.NilError(t, err)
+		object, err := io.ReadAll(reader)
+		assert.NilError(t, err)
+		assert.NilError(t, reader.Close())
+		assert.Equal(t, string(object), content, "content size %d", len(content))
+	}
+
+	// list only snapshots in the same directory
+	server.objects["snapshots/other.tar.gz"] = []byte("other")
+	server.objects["snapshots/nested/other.tar.gz"] = []byte("nested")
+	server.objects["snapshots/other.txt"] = []byte("text")
+	store, err := NewStore(ctx, &Options{Bucket: "my-bucket", Key: "snapshots/my-snapshot.tar.gz", AccessToken: "my-token", URL: server.URL})
+	assert.NilError(t, err)
+	snapshots, err := store.List(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(snapshots), 2)
+	assert.Equal(t, snapshots[0].ID, "my-snapshot.tar.gz")
+	assert.Equal(t, snapshots[0].URL, "gs://my-bucket/snapshots/my-snapshot.tar.gz")
+	assert.Equal(t, snapshots[0].Timestamp, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, snapshots[1].ID, "other.tar.gz")
+
+	assert.NilError(t, store.Delete(ctx))
+	_, err = store.GetObject(ctx)
+	assert.Assert(t, errors.Is(err, storage.ErrObjectNotExist), err)
+	assert.Assert(t, errors.Is(err, types.ErrObjectNotFound), err)
+
+	// wrong credentials
+	store, err = NewStore(ctx, &Options{Bucket: "my-bucket", Key: "snapshots/other.tar.gz", AccessToken: "other-token", URL: server.URL})
+	assert.NilError(t, err)
+	_, err = store.GetObject(ctx)
+	assert.ErrorContains(t, err, "401")
+}
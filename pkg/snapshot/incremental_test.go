@@ -0,0 +1,195 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/etcd"
+	"github.com/loft-sh/vcluster/pkg/scheme"
+	"github.com/loft-sh/vcluster/pkg/snapshot/types"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+)
+
+func TestIncrementalSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	source := &fakeEtcdClient{}
+	_, _ = source.Put(ctx, "/registry/configmaps/default/unchanged", []byte("unchanged"))
+	_, _ = source.Put(ctx, "/registry/configmaps/default/changed", []byte("before"))
+	_, _ = source.Put(ctx, "/registry/configmaps/default/deleted", []byte("deleted"))
+
+	// full snapshot
+	fullURL := "container://" + filepath.Join(dir, "full.tar.gz")
+	full := createVerifiedTestSnapshot(t, source, fullURL, "")
+	assert.Equal(t, full.Revision, int64(4))
+	assert.Assert(t, full.Base == nil)
+	assert.Assert(t, full.Generation != "")
+
+	// first incremental snapshot
+	_, _ = source.Put(ctx, "/registry/configmaps/default/changed", []byte("after"))
+	_ = source.Delete(ctx, "/registry/configmaps/default/deleted")
+	firstURL := "container://" + filepath.Join(dir, "first.tar.gz")
+	first := createVerifiedTestSnapshot(t, source, firstURL, fullURL)
+	assert.DeepEqual(t, first.Base, &BaseSnapshot{URL: fullURL, Revision: full.Revision, Checksum: full.Checksum, Generation: full.Generation})
+
+	keys := snapshotKeys(t, firstURL)
+	assert.DeepEqual(t, keys, []string{"/registry/configmaps/default/changed", SnapshotKeysKey})
+
+	// second incremental snapshot
+	_, _ = source.Put(ctx, "/registry/configmaps/default/created", []byte("created"))
+	secondURL := "container://" + filepath.Join(dir, "second.tar.gz")
+	createVerifiedTestSnapshot(t, source, secondURL, firstURL)
+
+	secondOptions := &Options{}
+	assert.NilError(t, Parse(secondURL, secondOptions))
+	chain, err := resolveSnapshotChain(ctx, secondOptions, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(chain), 3)
+	assert.Equal(t, chain[0].GetURL(), fullURL)
+	assert.Equal(t, chain[1].GetURL(), firstURL)
+	assert.Equal(t, chain[2].GetURL(), secondURL)
+
+	// read the chain as a single snapshot, as done for diffs and selective restores
+	values, err := readChainValues(ctx, secondOptions, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, values, map[string][]byte{
+		"/registry/configmaps/default/unchanged": []byte("unchanged"),
+		"/registry/configmaps/default/changed":   []byte("after"),
+		"/registry/configmaps/default/created":   []byte("created"),
+	})
+
+	// restore the chain
+	target := &fakeEtcdClient{}
+	restoreClient := &RestoreClient{vConfig: &config.VirtualClusterConfig{}}
+	state := &restoreState{
+		decoder: serializer.NewCodecFactory(scheme.Scheme).UniversalDeserializer(),
+		encoder: protobuf.NewSerializer(scheme.Scheme, scheme.Scheme),
+		keys:    map[string]struct{}{},
+	}
+	for i, snapshotOptions := range chain {
+		assert.NilError(t, restoreClient.restoreSnapshot(ctx, target, snapshotOptions, state, i == len(chain)-1))
+	}
+	assert.NilError(t, deleteRemovedKeys(ctx, target, state))
+	restored := map[string]string{}
+	for _, value := range target.values {
+		restored[string(value.Key)] = string(value.Data)
+	}
+	assert.DeepEqual(t, restored, map[string]string{
+		"/registry/configmaps/default/unchanged": "unchanged",
+		"/registry/configmaps/default/changed":   "after",
+		"/registry/configmaps/default/created":   "created",
+	})
+
+	// incremental snapshots are rejected before anything is restored if verification is skipped
+	fullOptions := &Options{}
+	assert.NilError(t, Parse(fullURL, fullOptions))
+	fullOptions.SkipVerify = true
+	chain, err = restoreChain(ctx, fullOptions, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(chain), 1)
+	secondOptions.SkipVerify = true
+	_, err = restoreChain(ctx, secondOptions, nil)
+	assert.ErrorContains(t, err, "cannot be restored with --skip-verify")
+	secondOptions.SkipVerify = false
+	chain, err = restoreChain(ctx, secondOptions, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(chain), 3)
+	fullOptions.SkipVerify = false
+
+	// base snapshots of incremental snapshots can't be deleted
+	for i, name := range []string{"full.tar.gz", "first.tar.gz", "second.tar.gz"} {
+		modTime := time.Now().Add(time.Duration(i-3) * time.Hour)
+		assert.NilError(t, os.Chtimes(filepath.Join(dir, name), modTime, modTime))
+	}
+	fullStore, err := CreateStore(ctx, fullOptions)
+	assert.NilError(t, err)
+	incrementals, err := incrementalSnapshotsOf(ctx, fullOptions, fullStore)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, incrementals, []string{"first.tar.gz"})
+	secondStore, err := CreateStore(ctx, secondOptions)
+	assert.NilError(t, err)
+	incrementals, err = incrementalSnapshotsOf(ctx, secondOptions, secondStore)
+	assert.NilError(t, err)
+	assert.Equal(t, len(incrementals), 0)
+
+	// changed base snapshot
+	createVerifiedTestSnapshot(t, source, fullURL, "")
+	_, err = resolveSnapshotChain(ctx, secondOptions, nil)
+	assert.ErrorContains(t, err, "was changed after incremental snapshot")
+
+	// restored backing store falls back to a full snapshot
+	assert.NilError(t, restoreClient.restoreSnapshot(ctx, target, chain[0], state, true))
+	_, err = newBackingStoreGeneration(ctx, target)
+	assert.NilError(t, err)
+	third := createVerifiedTestSnapshot(t, target, "container://"+filepath.Join(dir, "third.tar.gz"), secondURL)
+	assert.Assert(t, third.Base == nil)
+	assert.Assert(t, third.Generation != full.Generation)
+
+	// backing store with an older revision falls back to a full snapshot
+	_, _ = target.Put(ctx, SnapshotGenerationKey, []byte(full.Generation))
+	target.revision = 1
+	fourth := createVerifiedTestSnapshot(t, target, "container://"+filepath.Join(dir, "fourth.tar.gz"), secondURL)
+	assert.Assert(t, fourth.Base == nil)
+
+	// base references are stored next to incremental snapshots only and deleted with them
+	base, err := readBaseReference(ctx, fullOptions)
+	assert.NilError(t, err)
+	assert.Assert(t, base == nil)
+	base, err = readBaseReference(ctx, secondOptions)
+	assert.NilError(t, err)
+	assert.Equal(t, base.URL, firstURL)
+	assert.NilError(t, deleteSnapshot(ctx, secondOptions))
+	_, err = os.Stat(filepath.Join(dir, "second.tar.gz"+types.BaseReferenceSuffix))
+	assert.Assert(t, os.IsNotExist(err))
+
+	// different storage type
+	assert.ErrorContains(t, Validate(&Options{Type: "container", Container: fullOptions.Container, IncrementalBase: "file:///other.tar.gz"}, false), "same storage type")
+}
+
+func createVerifiedTestSnapshot(t *testing.T, etcdClient etcd.Client, snapshotURL, baseURL string) *Manifest {
+	assert.NilError(t, createTestSnapshot(t, etcdClient, snapshotURL, baseURL))
+
+	options := &Options{}
+	assert.NilError(t, Parse(snapshotURL, options))
+	manifest, err := VerifySnapshot(context.Background(), options, nil)
+	assert.NilError(t, err)
+	return manifest
+}
+
+func createTestSnapshot(t *testing.T, etcdClient etcd.Client, snapshotURL, baseURL string) error {
+	ctx := context.Background()
+	snapshotClient := &Client{}
+	assert.NilError(t, Parse(snapshotURL, &snapshotClient.Options))
+	snapshotClient.Options.IncrementalBase = baseURL
+	objectStore, err := CreateStore(ctx, &snapshotClient.Options)
+	assert.NilError(t, err)
+
+	manifest := &Manifest{Version: ManifestVersion}
+	if baseURL != "" {
+		manifest.Base, err = snapshotClient.incrementalBase(ctx)
+		assert.NilError(t, err)
+	}
+
+	return snapshotClient.writeSnapshot(ctx, etcdClient, objectStore, manifest)
+}
+
+func snapshotKeys(t *testing.T, snapshotURL string) []string {
+	options := &Options{}
+	assert.NilError(t, Parse(snapshotURL, options))
+
+	keys := []string{}
+	for _, entry := range readTestArchive(t, options.Container.Path) {
+		if entry.key != SnapshotManifestKey {
+			keys = append(keys, entry.key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,449 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/loft-sh/vcluster/pkg/mappings/store"
+	"github.com/loft-sh/vcluster/pkg/scheme"
+	"github.com/loft-sh/vcluster/pkg/snapshot/encryption"
+	"github.com/pmezard/go-difflib/difflib"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// ObjectChange describes how an object differs between two snapshots
+type ObjectChange string
+
+const (
+	ObjectAdded   ObjectChange = "added"
+	ObjectRemoved ObjectChange = "removed"
+	ObjectChanged ObjectChange = "changed"
+)
+
+// Summary describes the contents of a snapshot
+type Summary struct {
+	Target    string `json:"target"`
+	Encrypted bool   `json:"encrypted"`
+
+	// Keys is the number of keys within the snapshot
+	Keys int `json:"keys"`
+	// Objects is the number of Kubernetes objects within the snapshot
+	Objects int `json:"objects"`
+	// Mappings is the number of vCluster name mappings within the snapshot
+	Mappings int `json:"mappings"`
+
+	Namespaces []string       `json:"namespaces,omitempty"`
+	Kinds      map[string]int `json:"kinds,omitempty"`
+
+	Manifest        *Manifest               `json:"manifest,omitempty"`
+	Release         *HelmRelease            `json:"release,omitempty"`
+	Request         *Request                `json:"request,omitempty"`
+	VolumeSnapshots []VolumeSnapshotSummary `json:"volumeSnapshots,omitempty"`
+}
+
+// VolumeSnapshotSummary describes a volume snapshot that was taken together with the snapshot
+type VolumeSnapshotSummary struct {
+	PersistentVolumeClaim string `json:"persistentVolumeClaim"`
+	CSIDriver             string `json:"csiDriver,omitempty"`
+	Phase                 string `json:"phase,omitempty"`
+	SnapshotHandle        string `json:"snapshotHandle,omitempty"`
+	Error                 string `json:"error,omitempty"`
+}
+
+// ObjectDiff describes the difference of a single object
+type ObjectDiff struct {
+	Object string       `json:"object"`
+	Change ObjectChange `json:"change"`
+	Diff   string       `json:"diff,omitempty"`
+}
+
+// Inspect reads the whole snapshot and summarizes its contents
+func Inspect(ctx context.Context, options *Options, loadSecret encryption.SecretLoader) (*Summary, error) {
+	objectStore, err := CreateStore(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create store: %w", err)
+	}
+
+	archive, err := OpenArchive(ctx, objectStore, options, loadSecret)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	summary := &Summary{
+		Target:    objectStore.Target(),
+		Encrypted: archive.Encrypted,
+		Kinds:     map[string]int{},
+	}
+	namespaces := map[string]bool{}
+	decoder := serializer.NewCodecFactory(scheme.Scheme).UniversalDeserializer()
+	for {
+		key, value, err := archive.Next()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("read etcd key/value: %w", err)
+		} else if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if string(key) == SnapshotManifestKey {
+			summary.Manifest = &Manifest{}
+			err = json.Unmarshal(value, summary.Manifest)
+			if err != nil {
+				return nil, fmt.Errorf("failed to unmarshal snapshot manifest: %w", err)
+			}
+
+			// the per-key digests are only needed for verification and would blow up the summary
+			summary.Manifest.Digests = nil
+			continue
+		}
+
+		summary.Keys++
+		switch {
+		case string(key) == SnapshotReleaseKey:
+			summary.Release = &HelmRelease{}
+			err = json.Unmarshal(value, summary.Release)
+			if err != nil {
+				return nil, fmt.Errorf("failed to unmarshal helm release: %w", err)
+			}
+		case strings.HasPrefix(string(key), RequestStoreKey):
+			summary.Request = &Request{}
+			err = json.Unmarshal(value, summary.Request)
+			if err != nil {
+				return nil, fmt.Errorf("failed to unmarshal snapshot request: %w", err)
+			}
+		case strings.HasPrefix(string(key), store.MappingsPrefix):
+			summary.Mappings++
+		case strings.HasPrefix(string(key), "/registry/"):
+			obj, err := decodeSnapshotObject(decoder, value)
+			if err != nil {
+				continue
+			}
+
+			summary.Objects++
+			summary.Kinds[kindName(obj.GroupVersionKind().GroupKind())]++
+			if obj.GetNamespace() != "" {
+				namespaces[obj.GetNamespace()] = true
+			} else if obj.GetKind() == "Namespace" {
+				namespaces[obj.GetName()] = true
+			}
+		}
+	}
+
+	summary.Namespaces = slices.Sorted(maps.Keys(namespaces))
+	if summary.Request != nil {
+		for _, volumeSnapshot := range summary.Request.volumeRequests() {
+			pvcName := volumeSnapshot.PersistentVolumeClaim.Namespace + "/" + volumeSnapshot.PersistentVolumeClaim.Name
+			volumeSnapshotSummary := VolumeSnapshotSummary{
+				PersistentVolumeClaim: pvcName,
+				CSIDriver:             volumeSnapshot.CSIDriver,
+			}
+			status, ok := summary.Request.volumeStatus(pvcName)
+			if ok {
+				volumeSnapshotSummary.Phase = string(status.Phase)
+				volumeSnapshotSummary.SnapshotHandle = status.SnapshotHandle
+				volumeSnapshotSummary.Error = status.Error.Message
+			}
+
+			summary.VolumeSnapshots = append(summary.VolumeSnapshots, volumeSnapshotSummary)
+		}
+	}
+
+	return summary, nil
+}
+
+// ReadRelease reads the helm release of the vCluster from the snapshot. It returns nil if the snapshot
+// doesn't contain a helm release.
+func ReadRelease(ctx context.Context, options *Options, loadSecret encryption.SecretLoader) (*HelmRelease, error) {
+	objectStore, err := CreateStore(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create store: %w", err)
+	}
+
+	archive, err := OpenArchive(ctx, objectStore, options, loadSecret)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	for {
+		key, value, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("read etcd key/value: %w", err)
+		} else if string(key) != SnapshotReleaseKey {
+			continue
+		}
+
+		release := &HelmRelease{}
+		err = json.Unmarshal(value, release)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal helm release: %w", err)
+		}
+
+		return release, nil
+	}
+}
+
+// ReadObjects reads all objects from the snapshot that are not managed by the control plane, keyed by ObjectID.
+// Incremental snapshots are read together with their base snapshots.
+func ReadObjects(ctx context.Context, options *Options, loadSecret encryption.SecretLoader) (map[string]*unstructured.Unstructured, error) {
+	values, err := readChainValues(ctx, options, loadSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := map[string]*unstructured.Unstructured{}
+	decoder := serializer.NewCodecFactory(scheme.Scheme).UniversalDeserializer()
+	for key, value := range values {
+		if !strings.HasPrefix(key, "/registry/") {
+			continue
+		}
+
+		obj, err := decodeSnapshotObject(decoder, value)
+		if err != nil || isManagedObject(obj) {
+			continue
+		}
+
+		stripServerFields(obj)
+		objects[ObjectID(obj)] = obj
+	}
+
+	return objects, nil
+}
+
+// readChainValues reads all keys of the snapshot. Incremental snapshots are read together with their base snapshots,
+// so the result contains the keys of the vCluster at the time the incremental snapshot was taken. The manifest and
+// the key index are not part of the result. The base snapshots are not verified, restores verify the chain before.
+func readChainValues(ctx context.Context, options *Options, loadSecret encryption.SecretLoader) (map[string][]byte, error) {
+	values := map[string][]byte{}
+	var keyIndex []byte
+	current := options
+	for i := 0; ; i++ {
+		if i == maxSnapshotChain {
+			return nil, fmt.Errorf("snapshot %s has more than %d base snapshots", options.GetURL(), maxSnapshotChain)
+		}
+
+		objectStore, err := CreateStore(ctx, current)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create store: %w", err)
+		}
+		archive, err := OpenArchive(ctx, objectStore, current, loadSecret)
+		if err != nil {
+			return nil, err
+		}
+
+		// snapshots are read from the newest to the full snapshot, so keys that were changed later are kept
+		snapshotValues := map[string][]byte{}
+		manifest, snapshotKeyIndex, err := readArchiveValues(archive, snapshotValues)
+		_ = archive.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read snapshot %s: %w", current.GetURL(), err)
+		}
+		for key, value := range snapshotValues {
+			if _, ok := values[key]; !ok {
+				values[key] = value
+			}
+		}
+		if i == 0 {
+			keyIndex = snapshotKeyIndex
+		}
+
+		if manifest == nil || manifest.Base == nil {
+			break
+		}
+		current, err = current.withURL(manifest.Base.URL)
+		if err != nil {
+			return nil, fmt.Errorf("incremental snapshot %s: %w", current.GetURL(), err)
+		}
+	}
+
+	// remove the keys that were deleted since the base snapshot, the keys describing the snapshot itself are not indexed
+	if keyIndex != nil {
+		existingKeys := map[string]struct{}{}
+		for _, key := range strings.Split(string(keyIndex), "\n") {
+			existingKeys[key] = struct{}{}
+		}
+		for key := range values {
+			if _, ok := existingKeys[key]; !ok && !strings.HasPrefix(key, "/vcluster/snapshot/") {
+				delete(values, key)
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// readArchiveValues reads all keys of a single archive into values and returns its manifest and key index if present
+func readArchiveValues(archive *Archive, values map[string][]byte) (*Manifest, []byte, error) {
+	var manifest *Manifest
+	var keyIndex []byte
+	for {
+		key, value, err := archive.Next()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, nil, fmt.Errorf("read etcd key/value: %w", err)
+		} else if errors.Is(err, io.EOF) {
+			return manifest, keyIndex, nil
+		}
+
+		switch string(key) {
+		case SnapshotManifestKey:
+			manifest = &Manifest{}
+			err = json.Unmarshal(value, manifest)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to unmarshal snapshot manifest: %w", err)
+			}
+		case SnapshotKeysKey:
+			keyIndex = value
+		default:
+			values[string(key)] = value
+		}
+	}
+}
+
+// ReadLiveObjects reads all objects from a running vCluster that are not managed by the control plane, keyed by ObjectID
+func ReadLiveObjects(ctx context.Context, restConfig *rest.Config) (map[string]*unstructured.Unstructured, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// aggregated apis might not be available, so we continue with the resources we got
+	resourceLists, err := discoveryClient.ServerPreferredResources()
+	if err != nil && len(resourceLists) == 0 {
+		return nil, fmt.Errorf("discover api resources: %w", err)
+	}
+
+	objects := map[string]*unstructured.Unstructured{}
+	for _, resourceList := range resourceLists {
+		groupVersion, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			return nil, err
+		} else if groupVersion.Group == "metrics.k8s.io" {
+			continue
+		}
+
+		for _, resource := range resourceList.APIResources {
+			if strings.Contains(resource.Name, "/") || !slices.Contains(resource.Verbs, "list") {
+				continue
+			}
+
+			list, err := dynamicClient.Resource(groupVersion.WithResource(resource.Name)).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("list %s: %w", resource.Name, err)
+			}
+
+			for i := range list.Items {
+				obj := &list.Items[i]
+				obj.SetGroupVersionKind(groupVersion.WithKind(resource.Kind))
+				if isManagedObject(obj) {
+					continue
+				}
+
+				stripServerFields(obj)
+				objects[ObjectID(obj)] = obj
+			}
+		}
+	}
+
+	return objects, nil
+}
+
+// DiffObjects compares two sets of objects and returns the added, removed and changed objects as YAML diffs
+func DiffObjects(from, to map[string]*unstructured.Unstructured) ([]ObjectDiff, error) {
+	ids := map[string]bool{}
+	for id := range from {
+		ids[id] = true
+	}
+	for id := range to {
+		ids[id] = true
+	}
+
+	diffs := []ObjectDiff{}
+	for _, id := range slices.Sorted(maps.Keys(ids)) {
+		fromYAML, err := objectYAML(from[id])
+		if err != nil {
+			return nil, err
+		}
+		toYAML, err := objectYAML(to[id])
+		if err != nil {
+			return nil, err
+		} else if fromYAML == toYAML {
+			continue
+		}
+
+		change := ObjectChanged
+		if from[id] == nil {
+			change = ObjectAdded
+		} else if to[id] == nil {
+			change = ObjectRemoved
+		}
+
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(fromYAML),
+			B:        difflib.SplitLines(toYAML),
+			FromFile: "a/" + id,
+			ToFile:   "b/" + id,
+			Context:  3,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("diff %s: %w", id, err)
+		}
+
+		diffs = append(diffs, ObjectDiff{
+			Object: id,
+			Change: change,
+			Diff:   diff,
+		})
+	}
+
+	return diffs, nil
+}
+
+// ObjectID identifies an object independent of its api version, e.g. Deployment.apps default/my-deployment
+func ObjectID(obj *unstructured.Unstructured) string {
+	name := obj.GetName()
+	if obj.GetNamespace() != "" {
+		name = obj.GetNamespace() + "/" + name
+	}
+
+	return kindName(obj.GroupVersionKind().GroupKind()) + " " + name
+}
+
+func objectYAML(obj *unstructured.Unstructured) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+
+	out, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", fmt.Errorf("marshal %s: %w", ObjectID(obj), err)
+	}
+
+	return string(out), nil
+}
+
+func kindName(groupKind schema.GroupKind) string {
+	if groupKind.Group == "" {
+		return groupKind.Kind
+	}
+
+	return groupKind.Kind + "." + groupKind.Group
+}
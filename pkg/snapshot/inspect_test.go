@@ -0,0 +1,138 @@
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestInspect(t *testing.T) {
+	release, err := json.Marshal(&HelmRelease{ReleaseName: "my-vcluster", ReleaseNamespace: "vcluster-my-vcluster", ChartName: "vcluster", ChartVersion: "0.30.0"})
+	assert.NilError(t, err)
+	request, err := json.Marshal(&Request{
+		RequestMetadata: RequestMetadata{Name: "my-request"},
+		Spec: RequestSpec{
+			IncludeVolumes: true,
+			VolumeSnapshots: volumes.SnapshotsRequest{Requests: []volumes.SnapshotRequest{{
+				PersistentVolumeClaim: corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "team-a"}},
+				CSIDriver:             "ebs.csi.aws.com",
+			}}},
+		},
+		Status: RequestStatus{VolumeSnapshots: volumes.SnapshotsStatus{Snapshots: map[string]volumes.SnapshotStatus{
+			"team-a/data": {Phase: volumes.RequestPhaseCompleted, SnapshotHandle: "snap-123"},
+		}}},
+	})
+	assert.NilError(t, err)
+
+	options := writeTestSnapshotFile(t, "snapshot.tar.gz", map[string]runtime.Object{
+		"/registry/namespaces/team-a": &corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+		},
+		"/registry/configmaps/team-a/app": &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"},
+		},
+		"/registry/configmaps/team-b/app": &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-b"},
+		},
+	}, map[string][]byte{
+		SnapshotReleaseKey:            release,
+		RequestStoreKey + "/v1beta1":  request,
+		"/vcluster/mappings/my-value": []byte("{}"),
+	})
+
+	summary, err := Inspect(context.Background(), options, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, summary.Encrypted, false)
+	assert.Equal(t, summary.Keys, 7)
+	assert.Equal(t, summary.Objects, 4)
+	assert.Equal(t, summary.Mappings, 1)
+	assert.DeepEqual(t, summary.Namespaces, []string{"team-a", "team-b"})
+	assert.DeepEqual(t, summary.Kinds, map[string]int{"ConfigMap": 2, "Namespace": 1, "Widget.example.com": 1})
+	assert.Equal(t, summary.Release.ChartVersion, "0.30.0")
+	assert.Equal(t, summary.Request.Name, "my-request")
+	assert.DeepEqual(t, summary.VolumeSnapshots, []VolumeSnapshotSummary{{
+		PersistentVolumeClaim: "team-a/data",
+		CSIDriver:             "ebs.csi.aws.com",
+		Phase:                 string(volumes.RequestPhaseCompleted),
+		SnapshotHandle:        "snap-123",
+	}})
+
+	snapshotRelease, err := ReadRelease(context.Background(), options, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, snapshotRelease.ReleaseName, "my-vcluster")
+}
+
+func TestDiffObjects(t *testing.T) {
+	fromOptions := writeTestSnapshotFile(t, "from.tar.gz", map[string]runtime.Object{
+		"/registry/configmaps/default/changed": &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "changed", Namespace: "default", UID: "1", ResourceVersion: "1"},
+			Data:       map[string]string{"key": "old"},
+		},
+		"/registry/configmaps/default/removed": &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "removed", Namespace: "default"},
+		},
+		"/registry/configmaps/default/same": &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "same", Namespace: "default", UID: "2", ResourceVersion: "2"},
+		},
+	}, nil)
+	toOptions := writeTestSnapshotFile(t, "to.tar.gz", map[string]runtime.Object{
+		"/registry/configmaps/default/changed": &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "changed", Namespace: "default", UID: "3", ResourceVersion: "5"},
+			Data:       map[string]string{"key": "new"},
+		},
+		"/registry/configmaps/default/same": &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "same", Namespace: "default", UID: "4", ResourceVersion: "6"},
+		},
+	}, nil)
+
+	from, err := ReadObjects(context.Background(), fromOptions, nil)
+	assert.NilError(t, err)
+	to, err := ReadObjects(context.Background(), toOptions, nil)
+	assert.NilError(t, err)
+
+	diffs, err := DiffObjects(from, to)
+	assert.NilError(t, err)
+	assert.Equal(t, len(diffs), 2)
+	assert.Equal(t, diffs[0].Object, "ConfigMap default/changed")
+	assert.Equal(t, diffs[0].Change, ObjectChanged)
+	assert.Assert(t, strings.Contains(diffs[0].Diff, "-  key: old\n+  key: new\n"), diffs[0].Diff)
+	assert.Equal(t, diffs[1].Object, "ConfigMap default/removed")
+	assert.Equal(t, diffs[1].Change, ObjectRemoved)
+}
+
+// writeTestSnapshotFile writes a snapshot with the given objects and raw keys and returns the options to read it
+func writeTestSnapshotFile(t *testing.T, name string, objects map[string]runtime.Object, keys map[string][]byte) *Options {
+	buf := writeTestSnapshot(t, objects, keys)
+
+	compressed := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(compressed)
+	_, err := gzipWriter.Write(buf.Bytes())
+	assert.NilError(t, err)
+	assert.NilError(t, gzipWriter.Close())
+
+	filePath := filepath.Join(t.TempDir(), name)
+	assert.NilError(t, os.WriteFile(filePath, compressed.Bytes(), 0600))
+
+	options := &Options{}
+	assert.NilError(t, Parse("container://"+filePath, options))
+	return options
+}
@@ -0,0 +1,409 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/snapshot/encryption"
+	"github.com/loft-sh/vcluster/pkg/snapshot/types"
+	"github.com/loft-sh/vcluster/pkg/telemetry"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// SnapshotManifestKey stores the manifest of the snapshot, it is always the last key within the archive
+	SnapshotManifestKey = "/vcluster/snapshot/manifest"
+
+	// SnapshotKeysKey stores all etcd keys of the vCluster at the time an incremental snapshot was taken,
+	// so keys deleted since the base snapshot can be removed on restore
+	SnapshotKeysKey = "/vcluster/snapshot/keys"
+
+	// SnapshotGenerationKey stores a random id within the backing store itself. It is created by the first
+	// snapshot and replaced on every restore, so incremental snapshots are never based on a snapshot of
+	// another or a restored backing store. The key is never part of a snapshot.
+	SnapshotGenerationKey = "/vcluster/snapshot/generation"
+
+	// maxSnapshotChain is the maximum number of snapshots followed from an incremental snapshot to its full snapshot
+	maxSnapshotChain = 100
+
+	// ManifestVersion is the version of the manifest format, v2 adds the per-key digests
+	ManifestVersion = "v2"
+
+	// maxReportedKeys is the maximum number of keys listed in a verification error
+	maxReportedKeys = 10
+
+	// maxBaseReferenceSize is the maximum size of the base reference stored next to an incremental snapshot
+	maxBaseReferenceSize = 64 * 1024
+)
+
+// ErrNoManifest is returned if a snapshot was created by a vCluster version that did not write a manifest
+var ErrNoManifest = errors.New("snapshot has no manifest")
+
+// Manifest describes the contents of a snapshot and allows to verify its integrity
+type Manifest struct {
+	Version string `json:"version"`
+
+	// Keys is the number of keys within the snapshot, excluding the manifest itself
+	Keys int `json:"keys"`
+	// Checksum is the sha256 over all keys and values in the order they were written
+	Checksum string `json:"checksum"`
+	// Digests maps every key to the sha256 of its value, so that verification can tell which keys are
+	// missing or have changed. This adds roughly 100 bytes per key to the manifest. Manifests written
+	// before v2 have no digests and are only verified against the key count and checksum.
+	Digests map[string]string `json:"digests,omitempty"`
+
+	VClusterVersion   string `json:"vclusterVersion,omitempty"`
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	BackingStore      string `json:"backingStore,omitempty"`
+
+	// Revision is the etcd revision before the keys were read. An incremental snapshot based on this
+	// snapshot contains every key that was modified after this revision.
+	Revision int64 `json:"revision,omitempty"`
+	// Generation identifies the backing store the snapshot was taken from, see SnapshotGenerationKey
+	Generation string `json:"generation,omitempty"`
+	// Base references the snapshot an incremental snapshot was created from
+	Base *BaseSnapshot `json:"base,omitempty"`
+
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+}
+
+// BaseSnapshot identifies the snapshot an incremental snapshot was created from
+type BaseSnapshot struct {
+	URL        string `json:"url"`
+	Revision   int64  `json:"revision"`
+	Checksum   string `json:"checksum"`
+	Generation string `json:"generation,omitempty"`
+}
+
+// manifestHash calculates the checksum and the per-key digests of a snapshot key by key
+type manifestHash struct {
+	hash    hash.Hash
+	keys    int
+	digests map[string]string
+}
+
+func newManifestHash() *manifestHash {
+	return &manifestHash{hash: sha256.New(), digests: map[string]string{}}
+}
+
+// Add adds a key and value to the checksum. Both are length prefixed, so that moving bytes
+// from a value to the next key changes the checksum.
+func (m *manifestHash) Add(key, value []byte) {
+	length := make([]byte, 8)
+	binary.BigEndian.PutUint64(length, uint64(len(key)))
+	_, _ = m.hash.Write(length)
+	_, _ = m.hash.Write(key)
+	binary.BigEndian.PutUint64(length, uint64(len(value)))
+	_, _ = m.hash.Write(length)
+	_, _ = m.hash.Write(value)
+	m.keys++
+
+	digest := sha256.Sum256(value)
+	m.digests[string(key)] = "sha256:" + hex.EncodeToString(digest[:])
+}
+
+func (m *manifestHash) Checksum() string {
+	return "sha256:" + hex.EncodeToString(m.hash.Sum(nil))
+}
+
+// newManifest creates a new manifest for a snapshot of the given vCluster. The Kubernetes version
+// is only filled if the virtual cluster api server is reachable.
+func newManifest(ctx context.Context, vConfig *config.VirtualClusterConfig) *Manifest {
+	manifest := &Manifest{
+		Version:           ManifestVersion,
+		VClusterVersion:   telemetry.SyncerVersion,
+		BackingStore:      string(vConfig.BackingStoreType()),
+		CreationTimestamp: metav1.Now(),
+	}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", vConfig.VirtualClusterKubeConfig().KubeConfig)
+	if err != nil {
+		klog.FromContext(ctx).V(1).Info("Skip kubernetes version in snapshot manifest", "error", err)
+		return manifest
+	}
+	restConfig.Timeout = 10 * time.Second
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		klog.FromContext(ctx).V(1).Info("Skip kubernetes version in snapshot manifest", "error", err)
+		return manifest
+	}
+	version, err := discoveryClient.ServerVersion()
+	if err != nil {
+		klog.FromContext(ctx).V(1).Info("Skip kubernetes version in snapshot manifest", "error", err)
+		return manifest
+	}
+
+	manifest.KubernetesVersion = version.GitVersion
+	return manifest
+}
+
+// VerifySnapshot reads the whole snapshot and verifies it against its manifest
+func VerifySnapshot(ctx context.Context, options *Options, loadSecret encryption.SecretLoader) (*Manifest, error) {
+	objectStore, err := CreateStore(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create store: %w", err)
+	}
+
+	archive, err := OpenArchive(ctx, objectStore, options, loadSecret)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	return VerifyArchive(archive)
+}
+
+// ReadManifest reads the manifest of a snapshot without verifying the snapshot against it. As the manifest is
+// the last key of the archive, the whole snapshot is read. Returns ErrNoManifest if the snapshot has no manifest.
+func ReadManifest(ctx context.Context, options *Options, loadSecret encryption.SecretLoader) (*Manifest, error) {
+	objectStore, err := CreateStore(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create store: %w", err)
+	}
+
+	archive, err := OpenArchive(ctx, objectStore, options, loadSecret)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	for {
+		key, value, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, ErrNoManifest
+		} else if err != nil {
+			return nil, fmt.Errorf("read etcd key/value: %w", err)
+		}
+
+		if string(key) == SnapshotManifestKey {
+			manifest := &Manifest{}
+			err = json.Unmarshal(value, manifest)
+			if err != nil {
+				return nil, fmt.Errorf("failed to unmarshal snapshot manifest: %w", err)
+			}
+
+			return manifest, nil
+		}
+	}
+}
+
+// baseReferences reads the base references of the given snapshots, which are stored next to the snapshot of options,
+// and returns the urls of the base snapshots they were created from, mapped to the ids of the incremental snapshots.
+// Only the small base reference objects are read, snapshots without one are full snapshots. Snapshots whose base
+// reference can't be read are skipped.
+func baseReferences(ctx context.Context, options *Options, snapshots []types.Snapshot) map[string][]string {
+	references := map[string][]string{}
+	for _, snapshot := range snapshots {
+		snapshotOptions, err := options.withID(snapshot.ID)
+		if err != nil {
+			return references
+		}
+
+		base, err := readBaseReference(ctx, snapshotOptions)
+		if err != nil {
+			klog.Warningf("Could not read base reference of snapshot %s to check if it is incremental: %v", snapshotOptions.GetURL(), err)
+			continue
+		}
+
+		if base != nil {
+			references[base.URL] = append(references[base.URL], snapshot.ID)
+		}
+	}
+
+	return references
+}
+
+// writeBaseReference stores the base of an incremental snapshot in a small object next to the snapshot, so
+// snapshots that are the base of others can be found without downloading and decrypting every snapshot
+func writeBaseReference(ctx context.Context, options *Options, base *BaseSnapshot) error {
+	referenceOptions, err := options.withSuffix(types.BaseReferenceSuffix)
+	if err != nil {
+		// snapshots of this storage type can't be deleted, so there is nothing to protect
+		return nil
+	}
+
+	objectStore, err := CreateStore(ctx, referenceOptions)
+	if err != nil {
+		return fmt.Errorf("failed to create store: %w", err)
+	}
+
+	referenceBytes, err := json.Marshal(base)
+	if err != nil {
+		return err
+	}
+
+	return objectStore.PutObject(ctx, bytes.NewReader(referenceBytes))
+}
+
+// readBaseReference reads the base reference stored next to a snapshot and returns nil if the snapshot is
+// not incremental
+func readBaseReference(ctx context.Context, options *Options) (*BaseSnapshot, error) {
+	referenceOptions, err := options.withSuffix(types.BaseReferenceSuffix)
+	if err != nil {
+		return nil, nil
+	}
+
+	objectStore, err := CreateStore(ctx, referenceOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create store: %w", err)
+	}
+
+	reader, err := objectStore.GetObject(ctx)
+	if errors.Is(err, types.ErrObjectNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	base := &BaseSnapshot{}
+	err = json.NewDecoder(io.LimitReader(reader, maxBaseReferenceSize)).Decode(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal base reference: %w", err)
+	}
+
+	return base, nil
+}
+
+// deleteSnapshot deletes the snapshot of options together with its base reference
+func deleteSnapshot(ctx context.Context, options *Options) error {
+	objectStore, err := CreateStore(ctx, options)
+	if err != nil {
+		return fmt.Errorf("failed to create store: %w", err)
+	}
+
+	base, err := readBaseReference(ctx, options)
+	if err != nil {
+		klog.Warningf("Could not read base reference of snapshot %s: %v", options.GetURL(), err)
+	}
+
+	err = objectStore.Delete(ctx)
+	if err != nil || base == nil {
+		return err
+	}
+
+	referenceOptions, err := options.withSuffix(types.BaseReferenceSuffix)
+	if err != nil {
+		return err
+	}
+	referenceStore, err := CreateStore(ctx, referenceOptions)
+	if err != nil {
+		return fmt.Errorf("failed to create store: %w", err)
+	}
+
+	return referenceStore.Delete(ctx)
+}
+
+// VerifyArchive reads the archive until the end and verifies it against its manifest. Returns
+// ErrNoManifest if the archive is readable but has no manifest.
+func VerifyArchive(archive *Archive) (*Manifest, error) {
+	var manifest *Manifest
+	checksum := newManifestHash()
+	for {
+		key, value, err := archive.Next()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("read etcd key/value: %w", err)
+		} else if errors.Is(err, io.EOF) {
+			break
+		} else if manifest != nil {
+			return nil, fmt.Errorf("unexpected key %s after snapshot manifest", string(key))
+		}
+
+		if string(key) == SnapshotManifestKey {
+			manifest = &Manifest{}
+			err = json.Unmarshal(value, manifest)
+			if err != nil {
+				return nil, fmt.Errorf("failed to unmarshal snapshot manifest: %w", err)
+			}
+			continue
+		}
+
+		checksum.Add(key, value)
+	}
+
+	// read the rest of the stream, so the gzip checksum is verified as well
+	_, err := io.Copy(io.Discard, archive.gzipReader)
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot: %w", err)
+	}
+
+	if manifest == nil {
+		return nil, ErrNoManifest
+	}
+
+	// the key count and the digests are reported separately, so it's clear if keys are missing or have changed
+	errs := []error{}
+	if manifest.Keys != checksum.keys {
+		errs = append(errs, fmt.Errorf("snapshot contains %d keys, but manifest expects %d", checksum.keys, manifest.Keys))
+	}
+	errs = append(errs, verifyDigests(manifest.Digests, checksum.digests)...)
+	if len(errs) > 0 {
+		return manifest, errors.Join(errs...)
+	}
+
+	// the checksum also covers the order of the keys
+	if manifest.Checksum != checksum.Checksum() {
+		return manifest, fmt.Errorf("snapshot checksum %s does not match manifest checksum %s", checksum.Checksum(), manifest.Checksum)
+	}
+
+	return manifest, nil
+}
+
+// verifyDigests compares the digests of the manifest with the digests of the keys read from the snapshot.
+// Manifests without digests are not compared.
+func verifyDigests(expected, actual map[string]string) []error {
+	if expected == nil {
+		return nil
+	}
+
+	missing, unexpected, changed := []string{}, []string{}, []string{}
+	for key, digest := range expected {
+		actualDigest, ok := actual[key]
+		if !ok {
+			missing = append(missing, key)
+		} else if actualDigest != digest {
+			changed = append(changed, key)
+		}
+	}
+	for key := range actual {
+		if _, ok := expected[key]; !ok {
+			unexpected = append(unexpected, key)
+		}
+	}
+
+	errs := []error{}
+	if len(missing) > 0 {
+		errs = append(errs, fmt.Errorf("snapshot is missing %d keys of the manifest: %s", len(missing), formatKeys(missing)))
+	}
+	if len(unexpected) > 0 {
+		errs = append(errs, fmt.Errorf("snapshot contains %d keys that are not in the manifest: %s", len(unexpected), formatKeys(unexpected)))
+	}
+	if len(changed) > 0 {
+		errs = append(errs, fmt.Errorf("%d keys do not match their manifest digest: %s", len(changed), formatKeys(changed)))
+	}
+	return errs
+}
+
+func formatKeys(keys []string) string {
+	slices.Sort(keys)
+	if len(keys) > maxReportedKeys {
+		return fmt.Sprintf("%s and %d more", strings.Join(keys[:maxReportedKeys], ", "), len(keys)-maxReportedKeys)
+	}
+
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,178 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/loft-sh/vcluster/pkg/etcd"
+	"gotest.tools/v3/assert"
+)
+
+type fakeEtcdClient struct {
+	etcd.Client
+
+	values   []etcd.Value
+	revision int64
+}
+
+func (f *fakeEtcdClient) Revision(_ context.Context) (int64, error) {
+	return f.revision, nil
+}
+
+func (f *fakeEtcdClient) Get(_ context.Context, key string) (etcd.Value, error) {
+	for _, value := range f.values {
+		if string(value.Key) == key {
+			return value, nil
+		}
+	}
+
+	return etcd.Value{}, etcd.ErrNotFound
+}
+
+func (f *fakeEtcdClient) Put(_ context.Context, key string, data []byte) (int64, error) {
+	f.revision++
+	for i := range f.values {
+		if string(f.values[i].Key) == key {
+			f.values[i].Data = data
+			f.values[i].Modified = f.revision
+			return f.revision, nil
+		}
+	}
+
+	f.values = append(f.values, etcd.Value{Key: []byte(key), Data: data, Modified: f.revision})
+	return f.revision, nil
+}
+
+func (f *fakeEtcdClient) Delete(_ context.Context, key string) error {
+	f.revision++
+	f.values = slices.DeleteFunc(f.values, func(value etcd.Value) bool {
+		return string(value.Key) == key
+	})
+	return nil
+}
+
+func (f *fakeEtcdClient) ListStream(_ context.Context, _ string) <-chan *etcd.ValueOrError {
+	listChan := make(chan *etcd.ValueOrError, len(f.values))
+	for _, value := range f.values {
+		listChan <- &etcd.ValueOrError{Value: value}
+	}
+	close(listChan)
+	return listChan
+}
+
+func TestVerifySnapshot(t *testing.T) {
+	ctx := context.Background()
+	options := &Options{}
+	assert.NilError(t, Parse("container://"+filepath.Join(t.TempDir(), "snapshot.tar.gz"), options))
+	objectStore, err := CreateStore(ctx, options)
+	assert.NilError(t, err)
+
+	snapshotClient := &Client{
+		Request: &Request{RequestMetadata: RequestMetadata{Name: "my-request"}},
+		Options: Options{Release: &HelmRelease{ReleaseName: "my-vcluster"}},
+	}
+	err = snapshotClient.writeSnapshot(ctx, &fakeEtcdClient{values: []etcd.Value{
+		{Key: []byte("/registry/configmaps/default/first"), Data: []byte("first")},
+		{Key: []byte("/registry/configmaps/default/second"), Data: []byte("second")},
+	}}, objectStore, &Manifest{Version: ManifestVersion, BackingStore: "embedded-database"})
+	assert.NilError(t, err)
+
+	manifest, err := VerifySnapshot(ctx, options, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, manifest.Keys, 4)
+	assert.Equal(t, manifest.BackingStore, "embedded-database")
+	assert.Equal(t, len(manifest.Digests), 4)
+
+	summary, err := Inspect(ctx, options, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, summary.Keys, 4)
+	assert.Equal(t, summary.Manifest.Checksum, manifest.Checksum)
+
+	// changed value
+	entries := readTestArchive(t, options.Container.Path)
+	changed := slices.Clone(entries)
+	changed[2].value = []byte("changed")
+	writeTestArchive(t, options.Container.Path, changed)
+	_, err = VerifySnapshot(ctx, options, nil)
+	assert.Error(t, err, "1 keys do not match their manifest digest: "+entries[2].key)
+
+	// missing key
+	writeTestArchive(t, options.Container.Path, append(entries[:1:1], entries[2:]...))
+	_, err = VerifySnapshot(ctx, options, nil)
+	assert.Error(t, err, "snapshot contains 3 keys, but manifest expects 4\nsnapshot is missing 1 keys of the manifest: "+entries[1].key)
+
+	// reordered keys only change the checksum
+	writeTestArchive(t, options.Container.Path, append([]testArchiveEntry{entries[1], entries[0]}, entries[2:]...))
+	_, err = VerifySnapshot(ctx, options, nil)
+	assert.ErrorContains(t, err, "does not match manifest checksum")
+
+	// manifests without digests are verified against the key count and checksum
+	manifest.Digests = nil
+	manifestBytes, err := json.Marshal(manifest)
+	assert.NilError(t, err)
+	withoutDigests := slices.Clone(entries)
+	withoutDigests[2].value = []byte("changed")
+	withoutDigests[len(withoutDigests)-1].value = manifestBytes
+	writeTestArchive(t, options.Container.Path, withoutDigests)
+	_, err = VerifySnapshot(ctx, options, nil)
+	assert.ErrorContains(t, err, "does not match manifest checksum")
+
+	// no manifest
+	writeTestArchive(t, options.Container.Path, entries[:len(entries)-1])
+	_, err = VerifySnapshot(ctx, options, nil)
+	assert.Assert(t, errors.Is(err, ErrNoManifest))
+
+	// truncated snapshot
+	raw, err := os.ReadFile(options.Container.Path)
+	assert.NilError(t, err)
+	assert.NilError(t, os.WriteFile(options.Container.Path, raw[:len(raw)/2], 0600))
+	_, err = VerifySnapshot(ctx, options, nil)
+	assert.Assert(t, err != nil)
+	assert.Assert(t, !errors.Is(err, ErrNoManifest))
+}
+
+type testArchiveEntry struct {
+	key   string
+	value []byte
+}
+
+func readTestArchive(t *testing.T, path string) []testArchiveEntry {
+	f, err := os.Open(path)
+	assert.NilError(t, err)
+	defer f.Close()
+
+	gzipReader, err := gzip.NewReader(f)
+	assert.NilError(t, err)
+	tarReader := tar.NewReader(gzipReader)
+
+	entries := []testArchiveEntry{}
+	for {
+		key, value, err := readKeyValue(tarReader)
+		if errors.Is(err, io.EOF) {
+			return entries
+		}
+		assert.NilError(t, err)
+		entries = append(entries, testArchiveEntry{key: string(key), value: value})
+	}
+}
+
+func writeTestArchive(t *testing.T, path string, entries []testArchiveEntry) {
+	buf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, entry := range entries {
+		assert.NilError(t, writeKeyValue(tarWriter, []byte(entry.key), entry.value))
+	}
+	assert.NilError(t, tarWriter.Close())
+	assert.NilError(t, gzipWriter.Close())
+	assert.NilError(t, os.WriteFile(path, buf.Bytes(), 0600))
+}
@@ -8,9 +8,14 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 
+	"github.com/loft-sh/vcluster/pkg/snapshot/azblob"
 	"github.com/loft-sh/vcluster/pkg/snapshot/container"
+	"github.com/loft-sh/vcluster/pkg/snapshot/encryption"
+	"github.com/loft-sh/vcluster/pkg/snapshot/file"
+	"github.com/loft-sh/vcluster/pkg/snapshot/gcs"
 	"github.com/loft-sh/vcluster/pkg/snapshot/oci"
 	"github.com/loft-sh/vcluster/pkg/snapshot/options"
 	"github.com/loft-sh/vcluster/pkg/snapshot/s3"
@@ -30,9 +35,83 @@ type Options struct {
 	S3        s3.Options        `json:"s3"`
 	Container container.Options `json:"container"`
 	OCI       oci.Options       `json:"oci"`
+	File      file.Options      `json:"file"`
+	GCS       gcs.Options       `json:"gcs"`
+	AzBlob    azblob.Options    `json:"azblob"`
+
+	Encryption encryption.Options `json:"encryption"`
 
 	Release        *HelmRelease `json:"release,omitempty"`
 	IncludeVolumes bool         `json:"include-volumes,omitempty"`
+
+	// SkipVerify skips verifying the snapshot against its manifest before restoring it
+	SkipVerify bool `json:"skip-verify,omitempty"`
+
+	// IncrementalBase is the url of the snapshot an incremental snapshot is based on. Only keys that
+	// were changed or deleted since the base snapshot are written. If the backing store was replaced
+	// or restored since the base snapshot, a full snapshot is written instead.
+	IncrementalBase string `json:"incremental-base,omitempty"`
+}
+
+// withURL returns a copy of the options that points to another snapshot in the same storage. Credentials
+// and encryption options are kept, so snapshots of a chain can be read with the options of the latest one.
+func (o *Options) withURL(snapshotURL string) (*Options, error) {
+	other := *o
+	other.IncrementalBase = ""
+	err := Parse(snapshotURL, &other)
+	if err != nil {
+		return nil, fmt.Errorf("parse snapshot url %s: %w", snapshotURL, err)
+	} else if other.Type != o.Type {
+		return nil, fmt.Errorf("snapshot %s needs to be stored in the same storage type %s", snapshotURL, o.Type)
+	}
+
+	return &other, nil
+}
+
+// withID returns a copy of the options that points to the snapshot with the given id in the same directory,
+// as returned by types.Storage.List
+func (o *Options) withID(id string) (*Options, error) {
+	other := *o
+	other.IncrementalBase = ""
+	switch other.Type {
+	case "s3":
+		other.S3.Key = path.Join(path.Dir(other.S3.Key), id)
+	case "container":
+		other.Container.Path = filepath.Join(filepath.Dir(other.Container.Path), id)
+	case "file":
+		other.File.Path = filepath.Join(filepath.Dir(other.File.Path), id)
+	case "gs":
+		other.GCS.Key = path.Join(path.Dir(other.GCS.Key), id)
+	case "azblob":
+		other.AzBlob.Blob = path.Join(path.Dir(other.AzBlob.Blob), id)
+	default:
+		return nil, fmt.Errorf("addressing %s snapshots by id is not supported", other.Type)
+	}
+
+	return &other, nil
+}
+
+// withSuffix returns a copy of the options that points to an object next to the snapshot, whose name is the
+// name of the snapshot with the given suffix
+func (o *Options) withSuffix(suffix string) (*Options, error) {
+	other := *o
+	other.IncrementalBase = ""
+	switch other.Type {
+	case "s3":
+		other.S3.Key += suffix
+	case "container":
+		other.Container.Path += suffix
+	case "file":
+		other.File.Path += suffix
+	case "gs":
+		other.GCS.Key += suffix
+	case "azblob":
+		other.AzBlob.Blob += suffix
+	default:
+		return nil, fmt.Errorf("objects next to %s snapshots are not supported", other.Type)
+	}
+
+	return &other, nil
 }
 
 func (o *Options) GetURL() string {
@@ -44,6 +123,12 @@ func (o *Options) GetURL() string {
 		snapshotURL = "container://" + o.Container.Path
 	case "oci":
 		snapshotURL = "oci://" + o.OCI.Repository
+	case "file":
+		snapshotURL = "file://" + o.File.Path
+	case "gs":
+		snapshotURL = "gs://" + o.GCS.Bucket + "/" + o.GCS.Key
+	case "azblob":
+		snapshotURL = "azblob://" + o.AzBlob.Container + "/" + o.AzBlob.Blob
 	}
 
 	return snapshotURL
@@ -77,6 +162,22 @@ func CreateStore(ctx context.Context, options *Options) (types.Storage, error) {
 		return container.NewStore(&options.Container), nil
 	} else if options.Type == "oci" {
 		return oci.NewStore(&options.OCI), nil
+	} else if options.Type == "file" {
+		return file.NewStore(&options.File), nil
+	} else if options.Type == "gs" {
+		objectStore, err := gcs.NewStore(ctx, &options.GCS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init gcs object store: %w", err)
+		}
+
+		return objectStore, nil
+	} else if options.Type == "azblob" {
+		objectStore, err := azblob.NewStore(ctx, &options.AzBlob)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init azure blob object store: %w", err)
+		}
+
+		return objectStore, nil
 	}
 
 	return nil, fmt.Errorf("unknown storage: %s", options.Type)
@@ -88,11 +189,20 @@ func Parse(snapshotURL string, snapshotOptions *Options) error {
 		return fmt.Errorf("error parsing snapshotURL %s: %w", snapshotURL, err)
 	}
 
-	if parsedURL.Scheme != "s3" && parsedURL.Scheme != "container" && parsedURL.Scheme != "oci" {
-		return fmt.Errorf("scheme needs to be 'oci', 's3' or 'container'")
+	switch parsedURL.Scheme {
+	case "s3", "container", "oci", "file", "gs", "azblob":
+	default:
+		return fmt.Errorf("scheme needs to be 'oci', 's3', 'gs', 'azblob', 'file' or 'container'")
 	}
 	snapshotOptions.Type = parsedURL.Scheme
 
+	// encryption options are supported by all storage types
+	query := parsedURL.Query()
+	err = snapshotOptions.Encryption.ParseQuery(query)
+	if err != nil {
+		return fmt.Errorf("error parsing encryption options: %w", err)
+	}
+
 	// depending on the type we parse differently
 	switch snapshotOptions.Type {
 	case "s3":
@@ -107,7 +217,7 @@ func Parse(snapshotURL string, snapshotOptions *Options) error {
 
 		snapshotOptions.S3.Bucket = parsedURL.Host
 		snapshotOptions.S3.Key = strings.TrimPrefix(parsedURL.Path, "/")
-		err = options.PopulateStructFromMap(&snapshotOptions.S3, parsedURL.Query(), true)
+		err = options.PopulateStructFromMap(&snapshotOptions.S3, query, true)
 		if err != nil {
 			return fmt.Errorf("error parsing options: %w", err)
 		}
@@ -118,6 +228,41 @@ func Parse(snapshotURL string, snapshotOptions *Options) error {
 			return fmt.Errorf("couldn't find path for url")
 		}
 		snapshotOptions.Container.Path = parsedURL.Path
+	case "file":
+		if parsedURL.Host != "" {
+			return fmt.Errorf("relative paths are not supported for file snapshots")
+		} else if parsedURL.Path == "" {
+			return fmt.Errorf("couldn't find path for url")
+		}
+		snapshotOptions.File.Path = parsedURL.Path
+	case "gs":
+		// Format: gs://BUCKET/KEY
+		if parsedURL.Host == "" {
+			return fmt.Errorf("bucket name is missing from url, expected format: gs://BUCKET/KEY")
+		} else if parsedURL.Path == "" {
+			return fmt.Errorf("object name is missing from url, expected format: gs://BUCKET/KEY")
+		}
+
+		snapshotOptions.GCS.Bucket = parsedURL.Host
+		snapshotOptions.GCS.Key = strings.TrimPrefix(parsedURL.Path, "/")
+		err = options.PopulateStructFromMap(&snapshotOptions.GCS, query, true)
+		if err != nil {
+			return fmt.Errorf("error parsing options: %w", err)
+		}
+	case "azblob":
+		// Format: azblob://CONTAINER/BLOB?account=ACCOUNT
+		if parsedURL.Host == "" {
+			return fmt.Errorf("container name is missing from url, expected format: azblob://CONTAINER/BLOB")
+		} else if parsedURL.Path == "" {
+			return fmt.Errorf("blob name is missing from url, expected format: azblob://CONTAINER/BLOB")
+		}
+
+		snapshotOptions.AzBlob.Container = parsedURL.Host
+		snapshotOptions.AzBlob.Blob = strings.TrimPrefix(parsedURL.Path, "/")
+		err = options.PopulateStructFromMap(&snapshotOptions.AzBlob, query, true)
+		if err != nil {
+			return fmt.Errorf("error parsing options: %w", err)
+		}
 	case "oci":
 		if parsedURL.Path == "" {
 			return fmt.Errorf("unexpected format, need oci://my-registry.com/my-repo")
@@ -129,7 +274,7 @@ func Parse(snapshotURL string, snapshotOptions *Options) error {
 			snapshotOptions.OCI.Username = parsedURL.User.Username()
 			snapshotOptions.OCI.Password, _ = parsedURL.User.Password()
 		}
-		err = options.PopulateStructFromMap(&snapshotOptions.OCI, parsedURL.Query(), true)
+		err = options.PopulateStructFromMap(&snapshotOptions.OCI, query, true)
 		if err != nil {
 			return fmt.Errorf("error parsing options: %w", err)
 		}
@@ -175,8 +320,35 @@ func Validate(options *Options, isList bool) error {
 		if options.OCI.Repository == "" {
 			return fmt.Errorf("repository must be specified via oci://repository")
 		}
+	} else if options.Type == "file" {
+		if options.File.Path == "" {
+			return fmt.Errorf("path must be specified via file:///PATH")
+		}
+	} else if options.Type == "gs" {
+		if !isList && options.GCS.Key == "" {
+			return fmt.Errorf("key must be specified via gs://BUCKET/KEY")
+		}
+		if options.GCS.Bucket == "" {
+			return fmt.Errorf("bucket must be specified via gs://BUCKET/KEY")
+		}
+	} else if options.Type == "azblob" {
+		if !isList && options.AzBlob.Blob == "" {
+			return fmt.Errorf("blob must be specified via azblob://CONTAINER/BLOB")
+		}
+		if options.AzBlob.Container == "" {
+			return fmt.Errorf("container must be specified via azblob://CONTAINER/BLOB")
+		}
 	} else {
-		return fmt.Errorf("type must be either 'container', 'oci' or 's3'")
+		return fmt.Errorf("type must be either 'container', 'file', 'oci', 's3', 'gs' or 'azblob'")
+	}
+
+	if options.IncrementalBase != "" {
+		baseOptions, err := options.withURL(options.IncrementalBase)
+		if err != nil {
+			return fmt.Errorf("invalid incremental base: %w", err)
+		} else if baseOptions.GetURL() == options.GetURL() {
+			return fmt.Errorf("incremental base needs to be a different snapshot")
+		}
 	}
 
 	return nil
@@ -186,5 +358,18 @@ func AddFlags(flags *pflag.FlagSet, options *Options) {
 	flags.StringVarP(&options.S3.KmsKeyID, "kms-key-id", "", "", "AWS KMS key ID that is configured for given S3 bucket. If set, aws-kms SSE will be used")
 	flags.StringVarP(&options.S3.CustomerKeyEncryptionFile, "customer-key-encryption-file", "", "", "AWS customer key encryption file used for SSE-C. Mutually exclusive with kms-key-id")
 	flags.StringVarP(&options.S3.ServerSideEncryption, "server-side-encryption", "", "", "AWS Server-Side encryption algorithm")
-	flags.BoolVarP(&options.IncludeVolumes, "include-volumes", "", false, "Create CSI volume snapshots (shared and private nodes only)")
+	flags.BoolVarP(&options.IncludeVolumes, "include-volumes", "", false, "Create CSI volume snapshots and file level backups of volumes with storage classes configured in snapshots.volumes.fileBackup.storageClasses (shared and private nodes only)")
+	flags.StringVar(&options.IncrementalBase, "incremental-base", "", "Create an incremental snapshot that only contains the keys changed or deleted since the given snapshot. The base snapshot needs to be in the same storage")
+	AddEncryptionFlags(flags, options, false)
+}
+
+// AddEncryptionFlags adds the flags to encrypt or decrypt snapshots
+func AddEncryptionFlags(flags *pflag.FlagSet, options *Options, isRestore bool) {
+	flags.StringVar(&options.Encryption.Passphrase, "encryption-passphrase", "", "Passphrase the snapshot encryption key is derived from")
+	flags.StringVar(&options.Encryption.KeySecret, "encryption-key-secret", "", "Name of a Secret in the vCluster host namespace that holds the snapshot encryption key in the data key 'key'")
+	if isRestore {
+		flags.StringVar(&options.Encryption.AgeIdentity, "age-identity", "", "Age identity (AGE-SECRET-KEY-1...) used to decrypt the snapshot")
+	} else {
+		flags.StringSliceVar(&options.Encryption.AgeRecipients, "age-recipient", []string{}, "Encrypt the snapshot for the given age recipients (age1...)")
+	}
 }
@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/loft-sh/vcluster/pkg/snapshot/azblob"
 	"github.com/loft-sh/vcluster/pkg/snapshot/container"
+	"github.com/loft-sh/vcluster/pkg/snapshot/file"
+	"github.com/loft-sh/vcluster/pkg/snapshot/gcs"
 	"github.com/loft-sh/vcluster/pkg/snapshot/oci"
 	"github.com/loft-sh/vcluster/pkg/snapshot/s3"
 	"gotest.tools/v3/assert"
@@ -52,6 +55,40 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "file",
+			url:  "file:///mnt/snapshots/my-snapshot.tar.gz",
+			expectedOptions: Options{
+				Type: "file",
+				File: file.Options{Path: "/mnt/snapshots/my-snapshot.tar.gz"},
+			},
+		},
+		{
+			name: "gs",
+			url:  fmt.Sprintf("gs://my-bucket/my-key?access-token=%s&skip-client-credentials=true", base64.StdEncoding.EncodeToString([]byte("my-token"))),
+			expectedOptions: Options{
+				Type: "gs",
+				GCS: gcs.Options{
+					Bucket:                "my-bucket",
+					Key:                   "my-key",
+					AccessToken:           "my-token",
+					SkipClientCredentials: true,
+				},
+			},
+		},
+		{
+			name: "azblob",
+			url:  fmt.Sprintf("azblob://my-container/my-dir/my-blob?account=my-account&account-key=%s", base64.StdEncoding.EncodeToString([]byte("my-account-key"))),
+			expectedOptions: Options{
+				Type: "azblob",
+				AzBlob: azblob.Options{
+					Account:    "my-account",
+					Container:  "my-container",
+					Blob:       "my-dir/my-blob",
+					AccountKey: "my-account-key",
+				},
+			},
+		},
 		{
 			name:          "s3 unexpected option",
 			url:           "s3://my-bucket/my-key?region=eu-west-1&unexpected-option=true",
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/loft-sh/log"
@@ -68,22 +69,55 @@ func (r *Request) ShouldCancel(otherRequest *Request) bool {
 	return shouldCancel
 }
 
+// volumesPhase returns the combined phase of the volume snapshots and the file level volume backups.
+func (r *Request) volumesPhase() (volumes.SnapshotRequestPhase, string) {
+	return combineVolumesPhases(
+		volumesResult{
+			phase:    r.Status.VolumeSnapshots.Phase,
+			message:  r.Status.VolumeSnapshots.Error.Message,
+			requests: len(r.Spec.VolumeSnapshots.Requests),
+		},
+		volumesResult{
+			phase:    r.Status.VolumeBackups.Phase,
+			message:  r.Status.VolumeBackups.Error.Message,
+			requests: len(r.Spec.VolumeBackups.Requests),
+		},
+	)
+}
+
+// volumeRequests returns the volume snapshot requests together with the file level volume backup requests.
+func (r *Request) volumeRequests() []volumes.SnapshotRequest {
+	return append(slices.Clone(r.Spec.VolumeSnapshots.Requests), r.Spec.VolumeBackups.Requests...)
+}
+
+// volumeStatus returns the status of the PVC volume snapshot or file level volume backup.
+func (r *Request) volumeStatus(pvcName string) (volumes.SnapshotStatus, bool) {
+	if status, ok := r.Status.VolumeSnapshots.Snapshots[pvcName]; ok {
+		return status, true
+	}
+
+	status, ok := r.Status.VolumeBackups.Snapshots[pvcName]
+	return status, ok
+}
+
 type RequestSpec struct {
 	URL             string                   `json:"url,omitempty"`
 	IncludeVolumes  bool                     `json:"includeVolumes,omitempty"`
 	VolumeSnapshots volumes.SnapshotsRequest `json:"volumeSnapshots,omitempty"`
+	VolumeBackups   volumes.SnapshotsRequest `json:"volumeBackups,omitempty"`
 	Options         Options                  `json:"-"`
 }
 
 type RequestStatus struct {
 	Phase           RequestPhase                `json:"phase,omitempty"`
 	VolumeSnapshots volumes.SnapshotsStatus     `json:"volumeSnapshots,omitempty"`
+	VolumeBackups   volumes.SnapshotsStatus     `json:"volumeBackups,omitempty"`
 	Error           snapshotTypes.SnapshotError `json:"error,omitempty"`
 }
 
 // CreateSnapshotRequestResources creates snapshot request ConfigMap and Secret in the cluster. It returns the created
 // snapshot request.
-func CreateSnapshotRequestResources(ctx context.Context, vClusterNamespace, vClusterName string, vConfig *config.VirtualClusterConfig, options *Options, kubeClient *kubernetes.Clientset) (*Request, error) {
+func CreateSnapshotRequestResources(ctx context.Context, vClusterNamespace, vClusterName string, vConfig *config.VirtualClusterConfig, options *Options, kubeClient kubernetes.Interface) (*Request, error) {
 	if vConfig == nil {
 		return nil, fmt.Errorf("config is nil")
 	}
@@ -263,7 +297,7 @@ func GetSnapshots(ctx context.Context, vClusterNamespace string, snapshotOpts *O
 		// set to Completed/PartiallyFailed after the upload). Therefore, here
 		// we update the phase to the correct final state.
 		if savedSnapshotRequest.Spec.IncludeVolumes {
-			if savedSnapshotRequest.Status.VolumeSnapshots.Phase == volumes.RequestPhaseCompleted {
+			if volumesPhase, _ := savedSnapshotRequest.volumesPhase(); volumesPhase == volumes.RequestPhaseCompleted {
 				savedSnapshotRequest.Status.Phase = RequestPhaseCompleted
 			} else {
 				savedSnapshotRequest.Status.Phase = RequestPhasePartiallyFailed
@@ -324,16 +358,16 @@ func GetSnapshots(ctx context.Context, vClusterNamespace string, snapshotOpts *O
 	url = snapshotRequestToShow.Spec.URL
 	status = snapshotRequestToShow.Status.Phase
 	age = duration.HumanDuration(time.Since(snapshotRequestToShow.CreationTimestamp.Time))
-	if len(snapshotRequestToShow.Spec.VolumeSnapshots.Requests) > 0 {
+	if volumeRequests := snapshotRequestToShow.volumeRequests(); len(volumeRequests) > 0 {
 		var completedCount int
-		for _, volumeSnapshotRequest := range snapshotRequestToShow.Spec.VolumeSnapshots.Requests {
+		for _, volumeSnapshotRequest := range volumeRequests {
 			pvcName := fmt.Sprintf("%s/%s", volumeSnapshotRequest.PersistentVolumeClaim.Namespace, volumeSnapshotRequest.PersistentVolumeClaim.Name)
-			volumeSnapshotStatus, ok := snapshotRequestToShow.Status.VolumeSnapshots.Snapshots[pvcName]
+			volumeSnapshotStatus, ok := snapshotRequestToShow.volumeStatus(pvcName)
 			if ok && volumeSnapshotStatus.Phase == volumes.RequestPhaseCompleted {
 				completedCount++
 			}
 		}
-		volumesStatus = fmt.Sprintf("%d/%d", completedCount, len(snapshotRequestToShow.Spec.VolumeSnapshots.Requests))
+		volumesStatus = fmt.Sprintf("%d/%d", completedCount, len(volumeRequests))
 	}
 	if savedSnapshotRequest != nil {
 		saved = "Yes"
@@ -355,7 +389,7 @@ func GetSnapshots(ctx context.Context, vClusterNamespace string, snapshotOpts *O
 	return nil
 }
 
-func DeleteSnapshotRequestResources(ctx context.Context, vClusterNamespace, vClusterName string, vConfig *config.VirtualClusterConfig, options *Options, kubeClient *kubernetes.Clientset) error {
+func DeleteSnapshotRequestResources(ctx context.Context, vClusterNamespace, vClusterName string, vConfig *config.VirtualClusterConfig, options *Options, kubeClient kubernetes.Interface) error {
 	// First, try to get saved snapshots
 	restoreClient := RestoreClient{
 		Snapshot: *options,
@@ -0,0 +1,480 @@
+package snapshot
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+
+	snapshotv1alpha1 "github.com/loft-sh/vcluster/pkg/apis/snapshot/v1alpha1"
+	"github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/constants"
+	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
+	"github.com/loft-sh/vcluster/pkg/syncer/synccontext"
+	"github.com/loft-sh/vcluster/pkg/util"
+	"github.com/loft-sh/vcluster/pkg/util/loghelper"
+	corev1 "k8s.io/api/core/v1"
+	equality "k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var (
+	//go:embed crds/snapshot.vcluster.loft.sh_virtualclustersnapshots.yaml
+	virtualClusterSnapshotCRD string
+
+	//go:embed crds/snapshot.vcluster.loft.sh_virtualclusterrestores.yaml
+	virtualClusterRestoreCRD string
+)
+
+// RegisterResourceControllers installs the VirtualClusterSnapshot and VirtualClusterRestore CRDs in the host cluster
+// and registers the controllers that translate between these resources and the snapshot and restore request
+// ConfigMaps. The snapshot and restore controllers keep reconciling the ConfigMaps, so requests that are created
+// by older clients still work.
+func RegisterResourceControllers(registerContext *synccontext.RegisterContext) error {
+	hostConfig := registerContext.HostManager.GetConfig()
+	err := util.EnsureCRD(registerContext, hostConfig, []byte(virtualClusterSnapshotCRD), snapshotv1alpha1.SchemeGroupVersion.WithKind("VirtualClusterSnapshot"))
+	if err != nil {
+		return fmt.Errorf("failed to ensure VirtualClusterSnapshot CRD: %w", err)
+	}
+	err = util.EnsureCRD(registerContext, hostConfig, []byte(virtualClusterRestoreCRD), snapshotv1alpha1.SchemeGroupVersion.WithKind("VirtualClusterRestore"))
+	if err != nil {
+		return fmt.Errorf("failed to ensure VirtualClusterRestore CRD: %w", err)
+	}
+
+	base := resourceReconcilerBase{
+		vConfig:   registerContext.Config,
+		client:    registerContext.HostManager.GetClient(),
+		scheme:    registerContext.HostManager.GetScheme(),
+		namespace: registerContext.Config.HostNamespace,
+		logger:    loghelper.New("vcluster-snapshot-resource-controller"),
+	}
+	err = base.register(registerContext.HostManager, "virtualclustersnapshot-controller", &snapshotv1alpha1.VirtualClusterSnapshot{}, constants.SnapshotRequestLabel, &snapshotResourceReconciler{base})
+	if err != nil {
+		return fmt.Errorf("unable to register VirtualClusterSnapshot controller: %w", err)
+	}
+	err = base.register(registerContext.HostManager, "virtualclusterrestore-controller", &snapshotv1alpha1.VirtualClusterRestore{}, constants.RestoreRequestLabel, &restoreResourceReconciler{base})
+	if err != nil {
+		return fmt.Errorf("unable to register VirtualClusterRestore controller: %w", err)
+	}
+
+	return nil
+}
+
+type resourceReconcilerBase struct {
+	vConfig   *config.VirtualClusterConfig
+	client    client.Client
+	scheme    *runtime.Scheme
+	namespace string
+	logger    loghelper.Logger
+}
+
+// register watches the resource and the request ConfigMaps, which are named like the resource they belong to.
+func (r *resourceReconcilerBase) register(manager ctrl.Manager, name string, resource client.Object, requestLabel string, reconciler reconcile.Reconciler) error {
+	inNamespace := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetNamespace() == r.namespace
+	})
+	isRequest := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		_, ok := obj.GetLabels()[requestLabel]
+		return ok
+	})
+
+	return ctrl.NewControllerManagedBy(manager).
+		WithOptions(controller.Options{
+			CacheSyncTimeout:        constants.DefaultCacheSyncTimeout,
+			MaxConcurrentReconciles: 1,
+		}).
+		Named(name).
+		For(resource, builder.WithPredicates(inNamespace)).
+		Watches(&corev1.ConfigMap{}, &handler.EnqueueRequestForObject{}, builder.WithPredicates(inNamespace, isRequest)).
+		Complete(reconciler)
+}
+
+// getRequestConfigMap returns the request ConfigMap or nil if it doesn't exist.
+func (r *resourceReconcilerBase) getRequestConfigMap(ctx context.Context, req ctrl.Request) (*corev1.ConfigMap, error) {
+	configMap := &corev1.ConfigMap{}
+	err := r.client.Get(ctx, req.NamespacedName, configMap)
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get request ConfigMap %s: %w", req.NamespacedName, err)
+	}
+
+	return configMap, nil
+}
+
+// isOwnedBy checks if the object is owned by a resource of the given kind.
+func isOwnedBy(obj metav1.Object, kind string) bool {
+	return slices.ContainsFunc(obj.GetOwnerReferences(), func(ownerReference metav1.OwnerReference) bool {
+		return ownerReference.Kind == kind && strings.HasPrefix(ownerReference.APIVersion, snapshotv1alpha1.GroupName+"/")
+	})
+}
+
+// snapshotResourceReconciler creates snapshot requests for new VirtualClusterSnapshots and mirrors the status of
+// the snapshot requests into them. Snapshot requests that are created without a VirtualClusterSnapshot, e.g. by
+// vcluster snapshot create or a snapshot schedule, get a VirtualClusterSnapshot that is owned by the request.
+type snapshotResourceReconciler struct {
+	resourceReconcilerBase
+}
+
+func (r *snapshotResourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	configMap, err := r.getRequestConfigMap(ctx, req)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	snapshot := &snapshotv1alpha1.VirtualClusterSnapshot{}
+	err = r.client.Get(ctx, req.NamespacedName, snapshot)
+	if kerrors.IsNotFound(err) {
+		if configMap == nil || !configMap.DeletionTimestamp.IsZero() || isOwnedBy(configMap, "VirtualClusterSnapshot") {
+			return ctrl.Result{}, nil
+		}
+
+		snapshot, err = r.createResource(ctx, configMap)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get VirtualClusterSnapshot %s: %w", req.NamespacedName, err)
+	} else if !snapshot.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	status := snapshot.Status.DeepCopy()
+	if configMap != nil {
+		request, err := UnmarshalSnapshotRequest(configMap)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		snapshotResourceStatus(request, snapshot.Generation, status)
+	} else if snapshot.Status.Phase == "" {
+		// the request ConfigMap is deleted once the request has expired, so only new snapshots need a request
+		phase := RequestPhaseNotStarted
+		err = r.createRequest(ctx, snapshot)
+		if err != nil {
+			r.logger.Errorf("failed to create snapshot request for VirtualClusterSnapshot %s: %v", req.NamespacedName, err)
+			phase = RequestPhaseFailed
+			status.Error = err.Error()
+		}
+		status.Phase = phaseString(phase)
+		setRequestConditions(&status.Conditions, snapshot.Generation, phase, status.Error)
+	}
+
+	return ctrl.Result{}, r.updateStatus(ctx, snapshot, status)
+}
+
+// createResource creates the VirtualClusterSnapshot for a snapshot request that was created without one.
+func (r *snapshotResourceReconciler) createResource(ctx context.Context, configMap *corev1.ConfigMap) (*snapshotv1alpha1.VirtualClusterSnapshot, error) {
+	request, err := UnmarshalSnapshotRequest(configMap)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &snapshotv1alpha1.VirtualClusterSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMap.Name,
+			Namespace: configMap.Namespace,
+			Labels: map[string]string{
+				constants.VClusterNameLabel: r.vConfig.Name,
+			},
+		},
+		Spec: snapshotv1alpha1.VirtualClusterSnapshotSpec{
+			URL:            request.Spec.URL,
+			IncludeVolumes: request.Spec.IncludeVolumes,
+		},
+	}
+	err = controllerutil.SetOwnerReference(configMap, snapshot, r.scheme)
+	if err != nil {
+		return nil, err
+	}
+	err = r.client.Create(ctx, snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create VirtualClusterSnapshot %s/%s: %w", snapshot.Namespace, snapshot.Name, err)
+	}
+
+	r.logger.Infof("Created VirtualClusterSnapshot %s/%s for snapshot request", snapshot.Namespace, snapshot.Name)
+	return snapshot, nil
+}
+
+// createRequest creates the snapshot request ConfigMap and Secret for a new VirtualClusterSnapshot. Both are
+// owned by the VirtualClusterSnapshot and are deleted together with it.
+func (r *snapshotResourceReconciler) createRequest(ctx context.Context, snapshot *snapshotv1alpha1.VirtualClusterSnapshot) error {
+	snapshotURL, err := r.snapshotURL(ctx, snapshot)
+	if err != nil {
+		return err
+	}
+
+	options := &Options{}
+	err = Parse(snapshotURL, options)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot url: %w", err)
+	}
+	options.IncludeVolumes = snapshot.Spec.IncludeVolumes
+
+	secret, err := CreateSnapshotOptionsSecret(constants.SnapshotRequestLabel, r.namespace, r.vConfig.Name, options)
+	if err != nil {
+		return err
+	}
+	secret.Name = snapshot.Name
+	err = controllerutil.SetControllerReference(snapshot, secret, r.scheme)
+	if err != nil {
+		return err
+	}
+	err = r.client.Create(ctx, secret)
+	if err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create snapshot options Secret: %w", err)
+	}
+
+	request := &Request{
+		RequestMetadata: RequestMetadata{
+			Name:              snapshot.Name,
+			CreationTimestamp: metav1.Now(),
+		},
+		Spec: RequestSpec{
+			URL:            options.GetURL(),
+			IncludeVolumes: options.IncludeVolumes,
+		},
+	}
+	configMap, err := CreateSnapshotRequestConfigMap(r.namespace, r.vConfig.Name, request)
+	if err != nil {
+		return err
+	}
+	configMap.Name = snapshot.Name
+	err = controllerutil.SetControllerReference(snapshot, configMap, r.scheme)
+	if err != nil {
+		return err
+	}
+	err = r.client.Create(ctx, configMap)
+	if err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create snapshot request ConfigMap: %w", err)
+	}
+
+	r.logger.Infof("Created snapshot request %s/%s for VirtualClusterSnapshot", configMap.Namespace, configMap.Name)
+	return nil
+}
+
+func (r *snapshotResourceReconciler) snapshotURL(ctx context.Context, snapshot *snapshotv1alpha1.VirtualClusterSnapshot) (string, error) {
+	if snapshot.Spec.URLFrom == nil {
+		return snapshot.Spec.URL, nil
+	}
+
+	secret := &corev1.Secret{}
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: snapshot.Namespace, Name: snapshot.Spec.URLFrom.Name}, secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to get snapshot url Secret %s: %w", snapshot.Spec.URLFrom.Name, err)
+	}
+	snapshotURL, ok := secret.Data[snapshot.Spec.URLFrom.Key]
+	if !ok {
+		return "", fmt.Errorf("snapshot url Secret %s doesn't have the key %s", snapshot.Spec.URLFrom.Name, snapshot.Spec.URLFrom.Key)
+	}
+
+	return strings.TrimSpace(string(snapshotURL)), nil
+}
+
+func (r *snapshotResourceReconciler) updateStatus(ctx context.Context, snapshot *snapshotv1alpha1.VirtualClusterSnapshot, status *snapshotv1alpha1.VirtualClusterSnapshotStatus) error {
+	if equality.Semantic.DeepEqual(&snapshot.Status, status) {
+		return nil
+	}
+
+	snapshot.Status = *status
+	err := r.client.Status().Update(ctx, snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to update status of VirtualClusterSnapshot %s/%s: %w", snapshot.Namespace, snapshot.Name, err)
+	}
+
+	return nil
+}
+
+// restoreResourceReconciler mirrors the restore request ConfigMaps into VirtualClusterRestores. Restores need the
+// vCluster to be paused, so they are still started with vcluster restore.
+type restoreResourceReconciler struct {
+	resourceReconcilerBase
+}
+
+func (r *restoreResourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	configMap, err := r.getRequestConfigMap(ctx, req)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	restore := &snapshotv1alpha1.VirtualClusterRestore{}
+	err = r.client.Get(ctx, req.NamespacedName, restore)
+	if kerrors.IsNotFound(err) {
+		if configMap == nil || !configMap.DeletionTimestamp.IsZero() {
+			return ctrl.Result{}, nil
+		}
+
+		restore, err = r.createResource(ctx, configMap)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get VirtualClusterRestore %s: %w", req.NamespacedName, err)
+	} else if !restore.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	status := restore.Status.DeepCopy()
+	if configMap != nil {
+		request, err := UnmarshalRestoreRequest(configMap)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		restoreResourceStatus(request, restore.Generation, status)
+	} else if restore.Status.Phase == "" {
+		status.Phase = string(RequestPhaseFailed)
+		status.Error = "there is no restore request for this VirtualClusterRestore, please use vcluster restore to restore a virtual cluster"
+		setRequestConditions(&status.Conditions, restore.Generation, RequestPhaseFailed, status.Error)
+	}
+
+	if equality.Semantic.DeepEqual(&restore.Status, status) {
+		return ctrl.Result{}, nil
+	}
+	restore.Status = *status
+	err = r.client.Status().Update(ctx, restore)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update status of VirtualClusterRestore %s/%s: %w", restore.Namespace, restore.Name, err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// createResource creates the VirtualClusterRestore for a restore request, it is deleted together with the request.
+func (r *restoreResourceReconciler) createResource(ctx context.Context, configMap *corev1.ConfigMap) (*snapshotv1alpha1.VirtualClusterRestore, error) {
+	request, err := UnmarshalRestoreRequest(configMap)
+	if err != nil {
+		return nil, err
+	}
+
+	restore := &snapshotv1alpha1.VirtualClusterRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMap.Name,
+			Namespace: configMap.Namespace,
+			Labels: map[string]string{
+				constants.VClusterNameLabel: r.vConfig.Name,
+			},
+		},
+		Spec: snapshotv1alpha1.VirtualClusterRestoreSpec{
+			URL:            request.Spec.URL,
+			IncludeVolumes: request.Spec.IncludeVolumes,
+		},
+	}
+	err = controllerutil.SetOwnerReference(configMap, restore, r.scheme)
+	if err != nil {
+		return nil, err
+	}
+	err = r.client.Create(ctx, restore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create VirtualClusterRestore %s/%s: %w", restore.Namespace, restore.Name, err)
+	}
+
+	r.logger.Infof("Created VirtualClusterRestore %s/%s for restore request", restore.Namespace, restore.Name)
+	return restore, nil
+}
+
+// snapshotResourceStatus sets the status of the VirtualClusterSnapshot from the snapshot request.
+func snapshotResourceStatus(request *Request, generation int64, status *snapshotv1alpha1.VirtualClusterSnapshotStatus) {
+	phase := request.Status.Phase
+	status.Phase = phaseString(phase)
+	status.URL = request.Spec.URL
+	status.Error = request.Status.Error.Message
+	status.Volumes = nil
+	for _, volumeRequest := range request.Spec.VolumeSnapshots.Requests {
+		status.Volumes = append(status.Volumes, snapshotVolumeStatus(volumeRequest, request.Status.VolumeSnapshots, snapshotv1alpha1.VolumeMethodCSISnapshot))
+	}
+	for _, volumeRequest := range request.Spec.VolumeBackups.Requests {
+		status.Volumes = append(status.Volumes, snapshotVolumeStatus(volumeRequest, request.Status.VolumeBackups, snapshotv1alpha1.VolumeMethodFileBackup))
+	}
+	if (request.Done() || phase == RequestPhaseDeleted) && status.CompletionTime == nil {
+		now := metav1.Now()
+		status.CompletionTime = &now
+	}
+
+	setRequestConditions(&status.Conditions, generation, phase, status.Error)
+}
+
+func snapshotVolumeStatus(volumeRequest volumes.SnapshotRequest, snapshotsStatus volumes.SnapshotsStatus, method string) snapshotv1alpha1.VolumeStatus {
+	pvcName := volumeRequest.PersistentVolumeClaim.Namespace + "/" + volumeRequest.PersistentVolumeClaim.Name
+	snapshotStatus := snapshotsStatus.Snapshots[pvcName]
+	return snapshotv1alpha1.VolumeStatus{
+		PersistentVolumeClaim: pvcName,
+		Method:                method,
+		Phase:                 volumePhaseString(snapshotStatus.Phase),
+		Error:                 snapshotStatus.Error.Message,
+	}
+}
+
+// restoreResourceStatus sets the status of the VirtualClusterRestore from the restore request.
+func restoreResourceStatus(request *RestoreRequest, generation int64, status *snapshotv1alpha1.VirtualClusterRestoreStatus) {
+	phase := request.Status.Phase
+	status.Phase = phaseString(phase)
+	status.Error = request.Status.Error.Message
+	status.Volumes = nil
+	for _, volumeRequest := range request.Spec.VolumesRestore.Requests {
+		status.Volumes = append(status.Volumes, restoreVolumeStatus(volumeRequest, request.Status.VolumesRestore, snapshotv1alpha1.VolumeMethodCSISnapshot))
+	}
+	for _, volumeRequest := range request.Spec.VolumeBackupsRestore.Requests {
+		status.Volumes = append(status.Volumes, restoreVolumeStatus(volumeRequest, request.Status.VolumeBackupsRestore, snapshotv1alpha1.VolumeMethodFileBackup))
+	}
+	if request.Done() && status.CompletionTime == nil {
+		now := metav1.Now()
+		status.CompletionTime = &now
+	}
+
+	setRequestConditions(&status.Conditions, generation, phase, status.Error)
+}
+
+func restoreVolumeStatus(volumeRequest volumes.RestoreRequest, restoreStatus volumes.RestoreRequestStatus, method string) snapshotv1alpha1.VolumeStatus {
+	pvcName := volumeRequest.PersistentVolumeClaim.Namespace + "/" + volumeRequest.PersistentVolumeClaim.Name
+	volumeRestoreStatus := restoreStatus.PersistentVolumeClaims[pvcName]
+	return snapshotv1alpha1.VolumeStatus{
+		PersistentVolumeClaim: pvcName,
+		Method:                method,
+		Phase:                 volumePhaseString(volumeRestoreStatus.Phase),
+		Error:                 volumeRestoreStatus.Error.Message,
+	}
+}
+
+// setRequestConditions sets the Ready and Reconciling conditions according to the request phase.
+func setRequestConditions(conditions *[]metav1.Condition, generation int64, phase RequestPhase, message string) {
+	reason := phaseString(phase)
+	ready := metav1.Condition{Type: snapshotv1alpha1.ConditionReady, Status: metav1.ConditionFalse, Reason: reason, Message: message, ObservedGeneration: generation}
+	reconciling := metav1.Condition{Type: snapshotv1alpha1.ConditionReconciling, Status: metav1.ConditionFalse, Reason: reason, ObservedGeneration: generation}
+	switch phase {
+	case RequestPhaseCompleted, RequestPhasePartiallyFailed:
+		ready.Status = metav1.ConditionTrue
+	case RequestPhaseFailed, RequestPhaseCanceled, RequestPhaseDeleted:
+	default:
+		reconciling.Status = metav1.ConditionTrue
+	}
+
+	meta.SetStatusCondition(conditions, ready)
+	meta.SetStatusCondition(conditions, reconciling)
+}
+
+func phaseString(phase RequestPhase) string {
+	if phase == RequestPhaseNotStarted {
+		return "Pending"
+	}
+
+	return string(phase)
+}
+
+func volumePhaseString(phase volumes.SnapshotRequestPhase) string {
+	if phase == volumes.RequestPhaseNotStarted {
+		return "Pending"
+	}
+
+	return string(phase)
+}
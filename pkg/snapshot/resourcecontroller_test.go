@@ -0,0 +1,147 @@
+package snapshot
+
+import (
+	"context"
+	"testing"
+
+	snapshotv1alpha1 "github.com/loft-sh/vcluster/pkg/apis/snapshot/v1alpha1"
+	"github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/constants"
+	"github.com/loft-sh/vcluster/pkg/snapshot/types"
+	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
+	"github.com/loft-sh/vcluster/pkg/util/loghelper"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSnapshotResourceStatus(t *testing.T) {
+	pvc := corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "data"}}
+	request := &Request{
+		Spec: RequestSpec{
+			URL:            "s3://my-bucket/my-snapshot.tar.gz",
+			IncludeVolumes: true,
+			VolumeSnapshots: volumes.SnapshotsRequest{
+				Requests: []volumes.SnapshotRequest{{PersistentVolumeClaim: pvc}},
+			},
+		},
+		Status: RequestStatus{
+			Phase: RequestPhasePartiallyFailed,
+			Error: types.SnapshotError{Message: "volume snapshot failed"},
+			VolumeSnapshots: volumes.SnapshotsStatus{
+				Snapshots: map[string]volumes.SnapshotStatus{
+					"default/data": {Phase: volumes.RequestPhaseFailed, Error: types.SnapshotError{Message: "no csi driver"}},
+				},
+			},
+		},
+	}
+
+	status := &snapshotv1alpha1.VirtualClusterSnapshotStatus{}
+	snapshotResourceStatus(request, 1, status)
+	assert.Equal(t, status.Phase, "PartiallyFailed")
+	assert.Equal(t, status.URL, "s3://my-bucket/my-snapshot.tar.gz")
+	assert.Equal(t, status.Error, "volume snapshot failed")
+	assert.Assert(t, status.CompletionTime != nil)
+	assert.DeepEqual(t, status.Volumes, []snapshotv1alpha1.VolumeStatus{{
+		PersistentVolumeClaim: "default/data",
+		Method:                snapshotv1alpha1.VolumeMethodCSISnapshot,
+		Phase:                 "Failed",
+		Error:                 "no csi driver",
+	}})
+	assert.Assert(t, meta.IsStatusConditionTrue(status.Conditions, snapshotv1alpha1.ConditionReady))
+	assert.Assert(t, meta.IsStatusConditionFalse(status.Conditions, snapshotv1alpha1.ConditionReconciling))
+}
+
+func TestSetRequestConditions(t *testing.T) {
+	tests := []struct {
+		phase       RequestPhase
+		ready       bool
+		reconciling bool
+	}{
+		{phase: RequestPhaseNotStarted, reconciling: true},
+		{phase: RequestPhaseCreatingEtcdBackup, reconciling: true},
+		{phase: RequestPhaseCompleted, ready: true},
+		{phase: RequestPhaseFailed},
+		{phase: RequestPhaseCanceled},
+	}
+
+	for _, test := range tests {
+		t.Run(phaseString(test.phase), func(t *testing.T) {
+			conditions := []metav1.Condition{}
+			setRequestConditions(&conditions, 2, test.phase, "")
+			assert.Equal(t, meta.IsStatusConditionTrue(conditions, snapshotv1alpha1.ConditionReady), test.ready)
+			assert.Equal(t, meta.IsStatusConditionTrue(conditions, snapshotv1alpha1.ConditionReconciling), test.reconciling)
+			assert.Equal(t, meta.FindStatusCondition(conditions, snapshotv1alpha1.ConditionReady).Reason, phaseString(test.phase))
+			assert.Equal(t, meta.FindStatusCondition(conditions, snapshotv1alpha1.ConditionReady).ObservedGeneration, int64(2))
+		})
+	}
+}
+
+func TestSnapshotResourceReconcile(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	assert.NilError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NilError(t, snapshotv1alpha1.AddToScheme(scheme))
+
+	snapshot := &snapshotv1alpha1.VirtualClusterSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "vcluster-ns", Name: "my-snapshot", Generation: 1},
+		Spec: snapshotv1alpha1.VirtualClusterSnapshotSpec{
+			URL: "s3://my-bucket/my-snapshot.tar.gz",
+		},
+	}
+	kubeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(snapshot).
+		WithStatusSubresource(&snapshotv1alpha1.VirtualClusterSnapshot{}).
+		Build()
+	reconciler := &snapshotResourceReconciler{resourceReconcilerBase{
+		vConfig:   &config.VirtualClusterConfig{Name: "my-vcluster"},
+		client:    kubeClient,
+		scheme:    scheme,
+		namespace: "vcluster-ns",
+		logger:    loghelper.New("test"),
+	}}
+
+	// a new VirtualClusterSnapshot creates a snapshot request
+	_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(snapshot)})
+	assert.NilError(t, err)
+
+	configMap := &corev1.ConfigMap{}
+	assert.NilError(t, kubeClient.Get(ctx, client.ObjectKeyFromObject(snapshot), configMap))
+	assert.Equal(t, configMap.Labels[constants.SnapshotRequestLabel], "")
+	assert.Assert(t, isOwnedBy(configMap, "VirtualClusterSnapshot"))
+	request, err := UnmarshalSnapshotRequest(configMap)
+	assert.NilError(t, err)
+	assert.Equal(t, request.Spec.URL, "s3://my-bucket/my-snapshot.tar.gz")
+	assert.NilError(t, kubeClient.Get(ctx, client.ObjectKeyFromObject(snapshot), &corev1.Secret{}))
+
+	assert.NilError(t, kubeClient.Get(ctx, client.ObjectKeyFromObject(snapshot), snapshot))
+	assert.Equal(t, snapshot.Status.Phase, "Pending")
+	assert.Assert(t, meta.IsStatusConditionTrue(snapshot.Status.Conditions, snapshotv1alpha1.ConditionReconciling))
+
+	// a snapshot request that was created without a VirtualClusterSnapshot gets one
+	legacyConfigMap, err := CreateSnapshotRequestConfigMap("vcluster-ns", "my-vcluster", &Request{
+		RequestMetadata: RequestMetadata{Name: "legacy"},
+		Spec:            RequestSpec{URL: "oci://ghcr.io/my-user/my-repo:my-tag"},
+		Status:          RequestStatus{Phase: RequestPhaseCompleted},
+	})
+	assert.NilError(t, err)
+	legacyConfigMap.Name = "legacy"
+	assert.NilError(t, kubeClient.Create(ctx, legacyConfigMap))
+
+	_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(legacyConfigMap)})
+	assert.NilError(t, err)
+
+	legacySnapshot := &snapshotv1alpha1.VirtualClusterSnapshot{}
+	assert.NilError(t, kubeClient.Get(ctx, client.ObjectKeyFromObject(legacyConfigMap), legacySnapshot))
+	assert.Equal(t, legacySnapshot.Spec.URL, "oci://ghcr.io/my-user/my-repo:my-tag")
+	assert.Equal(t, legacySnapshot.Status.Phase, "Completed")
+	assert.Equal(t, legacySnapshot.OwnerReferences[0].Kind, "ConfigMap")
+	assert.Assert(t, meta.IsStatusConditionTrue(legacySnapshot.Status.Conditions, snapshotv1alpha1.ConditionReady))
+}
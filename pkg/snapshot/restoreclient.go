@@ -3,7 +3,6 @@ package snapshot
 import (
 	"archive/tar"
 	"bytes"
-	"compress/gzip"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -23,6 +22,7 @@ import (
 	"github.com/loft-sh/vcluster/pkg/mappings/store"
 	"github.com/loft-sh/vcluster/pkg/scheme"
 	setupconfig "github.com/loft-sh/vcluster/pkg/setup/config"
+	"github.com/loft-sh/vcluster/pkg/snapshot/encryption"
 	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
 	"github.com/loft-sh/vcluster/pkg/util/translate"
 	"go.etcd.io/etcd/server/v3/storage/backend"
@@ -34,7 +34,9 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
@@ -46,6 +48,7 @@ type RestoreClient struct {
 	snapshotRequest Request
 	Snapshot        Options
 	RestoreVolumes  bool
+	Selective       SelectiveRestoreOptions
 
 	NewVCluster bool
 	vConfig     *config.VirtualClusterConfig
@@ -72,29 +75,19 @@ func (o *RestoreClient) GetSnapshotRequest(ctx context.Context) (*Request, error
 		return nil, fmt.Errorf("failed to create store: %w", err)
 	}
 
-	// now stream objects from object store to etcd
-	reader, err := objectStore.GetObject(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get backup: %w", err)
-	}
-	defer reader.Close()
-
-	// optionally decompress
-	gzipReader, err := gzip.NewReader(reader)
+	// open the snapshot
+	archive, err := OpenArchive(ctx, objectStore, &o.Snapshot, hostSecretLoader(ctx))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		return nil, err
 	}
-	defer gzipReader.Close()
-
-	// create a new tar reader
-	tarReader := tar.NewReader(gzipReader)
+	defer archive.Close()
 
 	for {
 		// read from archive
-		key, value, err := readKeyValue(tarReader)
+		key, value, err := archive.Next()
 		if err != nil && !errors.Is(err, io.EOF) {
 			return nil, fmt.Errorf("read etcd key/value: %w", err)
-		} else if errors.Is(err, io.EOF) || len(key) == 0 {
+		} else if errors.Is(err, io.EOF) {
 			break
 		}
 
@@ -129,6 +122,12 @@ func (o *RestoreClient) Run(ctx context.Context) (retErr error) {
 	if err != nil {
 		return err
 	}
+	err = o.Selective.Validate()
+	if err != nil {
+		return err
+	} else if o.Selective.Enabled() && (o.RestoreVolumes || o.NewVCluster) {
+		return errors.New("a selective restore cannot be combined with restoring volumes or a new vCluster")
+	}
 	o.vConfig = vConfig
 
 	// set global vCluster name
@@ -140,22 +139,22 @@ func (o *RestoreClient) Run(ctx context.Context) (retErr error) {
 		return fmt.Errorf("failed to create store: %w", err)
 	}
 
-	// now stream objects from object store to etcd
-	reader, err := objectStore.GetObject(ctx)
+	// verify the snapshot and resolve its base snapshots before we touch the existing backing store
+	chain, err := restoreChain(ctx, &o.Snapshot, hostSecretLoader(ctx))
 	if err != nil {
-		return fmt.Errorf("failed to get backup: %w", err)
+		return err
 	}
-	defer reader.Close()
 
-	// print log message that we start restoring
-	klog.Infof("Start restoring etcd snapshot from %s...", objectStore.Target())
+	// restore only the selected objects through the api server of the running vCluster
+	if o.Selective.Enabled() {
+		klog.Infof("Start restoring etcd snapshot from %s...", objectStore.Target())
+		values, err := readChainValues(ctx, &o.Snapshot, hostSecretLoader(ctx))
+		if err != nil {
+			return err
+		}
 
-	// optionally decompress
-	gzipReader, err := gzip.NewReader(reader)
-	if err != nil {
-		return fmt.Errorf("failed to create gzip reader: %w", err)
+		return o.restoreSelective(ctx, vConfig, values)
 	}
-	defer gzipReader.Close()
 
 	// create new etcd client that will delete the existing data / recreate the database
 	etcdClient, revertBackup, err := newRestoreEtcdClient(ctx, vConfig)
@@ -173,18 +172,92 @@ func (o *RestoreClient) Run(ctx context.Context) (retErr error) {
 		}
 	}()
 
-	// create a new tar reader
-	tarReader := tar.NewReader(gzipReader)
+	// apply the full snapshot and all incremental snapshots on top
+	state := &restoreState{
+		decoder: decoder,
+		encoder: encoder,
+		keys:    map[string]struct{}{},
+	}
+	for i, snapshotOptions := range chain {
+		err = o.restoreSnapshot(ctx, etcdClient, snapshotOptions, state, i == len(chain)-1)
+		if err != nil {
+			return err
+		}
+	}
+
+	// remove the keys that were deleted since the base snapshot
+	if state.manifest != nil && state.manifest.Base != nil {
+		if len(chain) == 1 {
+			return fmt.Errorf("snapshot %s is incremental, but its base snapshots were not restored", objectStore.Target())
+		} else if state.keyIndex == nil {
+			return fmt.Errorf("incremental snapshot %s has no key index", objectStore.Target())
+		}
+
+		err = deleteRemovedKeys(ctx, etcdClient, state)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the restored backing store must not be the base of incremental snapshots of the backing store before
+	_, err = newBackingStoreGeneration(ctx, etcdClient)
+	if err != nil {
+		return fmt.Errorf("write backing store generation: %w", err)
+	}
+
+	// compact the database until that revision
+	klog.Infof("Compact etcd database until revision %d", state.latestRevision)
+	err = etcdClient.Compact(ctx, state.latestRevision)
+	if err != nil {
+		return fmt.Errorf("compact etcd database: %w", err)
+	}
+
+	klog.Infof("Successfully restored %d etcd keys from snapshot", len(state.keys))
+	klog.Infof("Successfully restored snapshot from %s", objectStore.Target())
+	return nil
+}
+
+// restoreState is shared between the snapshots of an incremental snapshot chain
+type restoreState struct {
+	decoder runtime.Decoder
+	encoder runtime.Encoder
+
+	// keys are all keys that were written to etcd
+	keys map[string]struct{}
+	// keyIndex and manifest are the ones of the latest restored snapshot
+	keyIndex []byte
+	manifest *Manifest
+
+	latestRevision int64
+}
+
+// restoreSnapshot writes all keys of a single snapshot to etcd
+func (o *RestoreClient) restoreSnapshot(ctx context.Context, etcdClient etcd.Client, snapshotOptions *Options, state *restoreState, isLast bool) error {
+	objectStore, err := CreateStore(ctx, snapshotOptions)
+	if err != nil {
+		return fmt.Errorf("failed to create store: %w", err)
+	}
+
+	// print log message that we start restoring
+	klog.Infof("Start restoring etcd snapshot from %s...", objectStore.Target())
+
+	// now stream objects from object store to etcd
+	archive, err := OpenArchive(ctx, objectStore, snapshotOptions, hostSecretLoader(ctx))
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
 
 	// now restore each key value
+	state.keyIndex = nil
+	state.manifest = nil
 	restoredKeys := 0
-	latestRevision := int64(0)
 	for {
 		// read from archive
-		key, value, err := readKeyValue(tarReader)
+		key, value, err := archive.Next()
 		if err != nil && !errors.Is(err, io.EOF) {
 			return fmt.Errorf("read etcd key/value: %w", err)
-		} else if errors.Is(err, io.EOF) || len(key) == 0 {
+		} else if errors.Is(err, io.EOF) {
 			break
 		}
 
@@ -200,10 +273,23 @@ func (o *RestoreClient) Run(ctx context.Context) (retErr error) {
 			}
 		}
 
-		// check snapshot request
+		// the manifest and key index are only used to verify and apply incremental snapshots
+		if string(key) == SnapshotManifestKey {
+			state.manifest = &Manifest{}
+			err = json.Unmarshal(value, state.manifest)
+			if err != nil {
+				return fmt.Errorf("failed to unmarshal snapshot manifest: %w", err)
+			}
+			continue
+		} else if string(key) == SnapshotKeysKey {
+			state.keyIndex = value
+			continue
+		}
+
+		// check snapshot request, volumes are only restored from the latest snapshot
 		if strings.HasPrefix(string(key), RequestStoreKey) {
-			if o.RestoreVolumes {
-				err = o.createRestoreRequest(ctx, vConfig, value)
+			if o.RestoreVolumes && isLast {
+				err = o.createRestoreRequest(ctx, o.vConfig, value)
 				if err != nil {
 					return fmt.Errorf("failed to create restore request: %w", err)
 				}
@@ -214,8 +300,8 @@ func (o *RestoreClient) Run(ctx context.Context) (retErr error) {
 		// transform pods to make sure they are not deleted on start
 		if strings.HasPrefix(string(key), "/registry/pods/") {
 			// we need to only do this in shared nodes mode as otherwise kubelet will not update the status correctly
-			if !vConfig.PrivateNodes.Enabled {
-				value, err = transformPod(value, decoder, encoder)
+			if !o.vConfig.PrivateNodes.Enabled {
+				value, err = transformPod(value, state.decoder, state.encoder)
 				if err != nil {
 					return fmt.Errorf("transform value: %w", err)
 				}
@@ -223,7 +309,7 @@ func (o *RestoreClient) Run(ctx context.Context) (retErr error) {
 		}
 
 		if o.isPVCThatShouldBeRestoredInHost(string(key)) {
-			value, err = unsetVolumeName(value, decoder, encoder)
+			value, err = unsetVolumeName(value, state.decoder, state.encoder)
 			if err != nil {
 				return fmt.Errorf("failed to unset volume name: %w", err)
 			}
@@ -237,10 +323,11 @@ func (o *RestoreClient) Run(ctx context.Context) (retErr error) {
 		}
 
 		klog.V(1).Infof("Restore key %s", string(key))
-		latestRevision, err = etcdClient.Put(ctx, string(key), value)
+		state.latestRevision, err = etcdClient.Put(ctx, string(key), value)
 		if err != nil {
 			return fmt.Errorf("restore etcd key %s: %w", string(key), err)
 		}
+		state.keys[string(key)] = struct{}{}
 
 		// print status update
 		restoredKeys++
@@ -249,15 +336,124 @@ func (o *RestoreClient) Run(ctx context.Context) (retErr error) {
 		}
 	}
 
-	// compact the database until that revision
-	klog.Infof("Compact etcd database until revision %d", latestRevision)
-	err = etcdClient.Compact(ctx, latestRevision)
+	klog.Infof("Restored %d etcd keys from %s", restoredKeys, objectStore.Target())
+	return nil
+}
+
+// deleteRemovedKeys deletes all restored keys that are not part of the key index of the latest incremental snapshot
+func deleteRemovedKeys(ctx context.Context, etcdClient etcd.Client, state *restoreState) error {
+	existingKeys := map[string]struct{}{}
+	for _, key := range strings.Split(string(state.keyIndex), "\n") {
+		if key != "" {
+			existingKeys[key] = struct{}{}
+		}
+	}
+
+	deletedKeys := 0
+	for key := range state.keys {
+		if _, ok := existingKeys[key]; ok {
+			continue
+		}
+
+		klog.V(1).Infof("Delete key %s", key)
+		err := etcdClient.Delete(ctx, key)
+		if err != nil {
+			return fmt.Errorf("delete etcd key %s: %w", key, err)
+		}
+		delete(state.keys, key)
+		deletedKeys++
+	}
+
+	klog.Infof("Deleted %d etcd keys that were removed since the base snapshot", deletedKeys)
+	return nil
+}
+
+// restoreChain returns the snapshots that need to be restored in order, starting with the full snapshot. The
+// snapshots are verified against their manifests unless verification is skipped. Incremental snapshots can't be
+// restored without verification, as their base snapshots are only resolved while verifying, so without
+// verification only the manifest is read to reject them before anything is restored.
+func restoreChain(ctx context.Context, options *Options, loadSecret encryption.SecretLoader) ([]*Options, error) {
+	if options.SkipVerify {
+		manifest, err := ReadManifest(ctx, options, loadSecret)
+		if err != nil && !errors.Is(err, ErrNoManifest) {
+			return nil, fmt.Errorf("read manifest of snapshot %s: %w", options.GetURL(), err)
+		} else if manifest != nil && manifest.Base != nil {
+			return nil, fmt.Errorf("snapshot %s is an incremental snapshot of %s and cannot be restored with --skip-verify, as its base snapshots are only resolved while verifying", options.GetURL(), manifest.Base.URL)
+		}
+
+		return []*Options{options}, nil
+	}
+
+	chain, err := resolveSnapshotChain(ctx, options, loadSecret)
+	if errors.Is(err, ErrNoManifest) {
+		klog.Warningf("Snapshot %s has no manifest, skipping verification", options.GetURL())
+		return []*Options{options}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("verify snapshot %s: %w", options.GetURL(), err)
+	}
+
+	return chain, nil
+}
+
+// resolveSnapshotChain verifies the snapshot and follows incremental snapshots back to their full
+// snapshot. Each base snapshot is verified and needs to match the checksum and revision the incremental
+// snapshot was created from. The returned chain starts with the full snapshot.
+func resolveSnapshotChain(ctx context.Context, options *Options, loadSecret encryption.SecretLoader) ([]*Options, error) {
+	chain := []*Options{options}
+	var incremental *Manifest
+	for {
+		current := chain[0]
+		klog.Infof("Verifying etcd snapshot %s...", current.GetURL())
+		manifest, err := VerifySnapshot(ctx, current, loadSecret)
+		if err != nil && incremental == nil {
+			return nil, err
+		} else if errors.Is(err, ErrNoManifest) {
+			return nil, fmt.Errorf("base snapshot %s has no manifest", current.GetURL())
+		} else if err != nil {
+			return nil, fmt.Errorf("verify base snapshot %s: %w", current.GetURL(), err)
+		} else if incremental != nil && (manifest.Checksum != incremental.Base.Checksum || manifest.Revision != incremental.Base.Revision) {
+			return nil, fmt.Errorf("base snapshot %s was changed after incremental snapshot %s was created from it", current.GetURL(), chain[1].GetURL())
+		}
+		klog.Infof("Successfully verified %d keys of snapshot taken with vCluster %s", manifest.Keys, manifest.VClusterVersion)
+
+		// follow incremental snapshots to their base
+		if manifest.Base == nil {
+			return chain, nil
+		} else if len(chain) >= maxSnapshotChain {
+			return nil, fmt.Errorf("snapshot chain is longer than %d snapshots", maxSnapshotChain)
+		}
+
+		baseOptions, err := current.withURL(manifest.Base.URL)
+		if err != nil {
+			return nil, fmt.Errorf("incremental snapshot %s: %w", current.GetURL(), err)
+		}
+		incremental = manifest
+		chain = append([]*Options{baseOptions}, chain...)
+	}
+}
+
+func (o *RestoreClient) restoreSelective(ctx context.Context, vConfig *config.VirtualClusterConfig, values map[string][]byte) error {
+	restConfig, err := clientcmd.BuildConfigFromFlags("", vConfig.VirtualClusterKubeConfig().KubeConfig)
 	if err != nil {
-		return fmt.Errorf("compact etcd database: %w", err)
+		return fmt.Errorf("failed to load virtual cluster kube config: %w", err)
+	}
+	virtualClient, err := client.New(restConfig, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		return fmt.Errorf("failed to create virtual cluster client: %w", err)
+	}
+
+	restorer, err := newSelectiveRestorer(virtualClient, &o.Selective)
+	if err != nil {
+		return err
+	}
+
+	result := restorer.Restore(ctx, values)
+
+	klog.Infof("Selective restore finished: %d created, %d overwritten, %d renamed, %d skipped, %d failed", result.Created, result.Overwritten, result.Renamed, result.Skipped, result.Failed)
+	if result.Failed > 0 {
+		return fmt.Errorf("failed to restore %d objects", result.Failed)
 	}
 
-	klog.Infof("Successfully restored %d etcd keys from snapshot", restoredKeys)
-	klog.Infof("Successfully restored snapshot from %s", objectStore.Target())
 	return nil
 }
 
@@ -284,6 +480,11 @@ func (o *RestoreClient) createRestoreRequest(ctx context.Context, vConfig *confi
 		return fmt.Errorf("failed to unmarshal snapshot request: %w", err)
 	}
 	klog.Infof("Found snapshot request: %s", snapshotRequest.Name)
+	if o.NewVCluster && !vConfig.PrivateNodes.Enabled {
+		// the snapshot was taken from another vCluster, so the host PVCs need to be renamed
+		rewriteVolumeRequests(&snapshotRequest.Spec.VolumeSnapshots, &snapshotRequest.Status.VolumeSnapshots)
+		rewriteVolumeRequests(&snapshotRequest.Spec.VolumeBackups, &snapshotRequest.Status.VolumeBackups)
+	}
 	o.snapshotRequest = snapshotRequest
 
 	// first create the snapshot options Secret
@@ -326,7 +527,7 @@ func (o *RestoreClient) skipKey(key string) bool {
 		// check if the snapshot exists
 		if strings.HasPrefix(key, pvcPrefix) {
 			pvcName := strings.TrimPrefix(key, pvcPrefix)
-			status, ok := o.snapshotRequest.Status.VolumeSnapshots.Snapshots[pvcName]
+			status, ok := o.snapshotRequest.volumeStatus(pvcName)
 			if !ok {
 				return false
 			}
@@ -334,7 +535,7 @@ func (o *RestoreClient) skipKey(key string) bool {
 			return status.Phase == volumes.RequestPhaseCompleted
 		} else if strings.HasPrefix(key, pvPrefix) {
 			volumeName := strings.TrimPrefix(key, pvPrefix)
-			for _, snapshotSpec := range o.snapshotRequest.Spec.VolumeSnapshots.Requests {
+			for _, snapshotSpec := range o.snapshotRequest.volumeRequests() {
 				if snapshotSpec.PersistentVolumeClaim.Spec.VolumeName == volumeName {
 					return true
 				}
@@ -351,7 +552,7 @@ func (o *RestoreClient) skipKey(key string) bool {
 			if translatedPVCName == "" {
 				return true
 			}
-			status, ok := o.snapshotRequest.Status.VolumeSnapshots.Snapshots[translatedPVCName]
+			status, ok := o.snapshotRequest.volumeStatus(translatedPVCName)
 			if !ok {
 				return false
 			}
@@ -382,7 +583,7 @@ func (o *RestoreClient) isPVCThatShouldBeRestoredInHost(key string) bool {
 	if translatedPVCName == "" {
 		return true
 	}
-	status, ok := o.snapshotRequest.Status.VolumeSnapshots.Snapshots[translatedPVCName]
+	status, ok := o.snapshotRequest.volumeStatus(translatedPVCName)
 	if !ok {
 		klog.V(1).Infof("Snapshot not found for PVC %s", strings.TrimPrefix(key, pvcPrefix))
 		return false
@@ -767,6 +968,32 @@ func readKeyValue(tarReader *tar.Reader) ([]byte, []byte, error) {
 	return []byte(header.Name), buf.Bytes(), nil
 }
 
+// rewriteVolumeRequests translates the host PVCs of the volume requests from the vCluster the snapshot
+// was taken from to the vCluster that is restored, so that the volumes are restored into the new host
+// namespace with the new host names.
+func rewriteVolumeRequests(request *volumes.SnapshotsRequest, status *volumes.SnapshotsStatus) {
+	for i := range request.Requests {
+		pvc := &request.Requests[i].PersistentVolumeClaim
+		vName, vNamespace := pvc.Annotations[translate.NameAnnotation], pvc.Annotations[translate.NamespaceAnnotation]
+		if vName == "" || vNamespace == "" {
+			continue
+		}
+
+		oldName := pvc.Namespace + "/" + pvc.Name
+		hostName := translate.Default.HostName(nil, vName, vNamespace)
+		pvc.Namespace, pvc.Name = hostName.Namespace, hostName.Name
+		if pvc.Labels[translate.MarkerLabel] != "" {
+			pvc.Labels[translate.MarkerLabel] = translate.VClusterName
+		}
+
+		newName := pvc.Namespace + "/" + pvc.Name
+		if snapshotStatus, ok := status.Snapshots[oldName]; ok && oldName != newName {
+			delete(status.Snapshots, oldName)
+			status.Snapshots[newName] = snapshotStatus
+		}
+	}
+}
+
 func getTranslatedPVCName(pvcName string) string {
 	// Parse namespace and name from "namespace/name" format
 	parts := strings.SplitN(pvcName, "/", 2)
@@ -0,0 +1,54 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
+	"github.com/loft-sh/vcluster/pkg/util/translate"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRewriteVolumeRequests(t *testing.T) {
+	oldDefault, oldName := translate.Default, translate.VClusterName
+	defer func() {
+		translate.Default, translate.VClusterName = oldDefault, oldName
+	}()
+	translate.Default = translate.NewSingleNamespaceTranslator("vcluster-clone")
+	translate.VClusterName = "clone"
+
+	sourceName := translate.SingleNamespaceHostName("data", "default", "source")
+	request := &volumes.SnapshotsRequest{
+		Requests: []volumes.SnapshotRequest{
+			{
+				PersistentVolumeClaim: corev1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      sourceName,
+						Namespace: "vcluster-source",
+						Labels: map[string]string{
+							translate.MarkerLabel: "source",
+						},
+						Annotations: map[string]string{
+							translate.NameAnnotation:      "data",
+							translate.NamespaceAnnotation: "default",
+						},
+					},
+				},
+			},
+		},
+	}
+	status := &volumes.SnapshotsStatus{
+		Snapshots: map[string]volumes.SnapshotStatus{
+			"vcluster-source/" + sourceName: {Phase: volumes.RequestPhaseCompleted},
+		},
+	}
+	rewriteVolumeRequests(request, status)
+
+	pvc := request.Requests[0].PersistentVolumeClaim
+	assert.Equal(t, pvc.Namespace, "vcluster-clone")
+	assert.Equal(t, pvc.Name, translate.SingleNamespaceHostName("data", "default", "clone"))
+	assert.Equal(t, pvc.Labels[translate.MarkerLabel], "clone")
+	assert.Equal(t, len(status.Snapshots), 1)
+	assert.Equal(t, status.Snapshots[pvc.Namespace+"/"+pvc.Name].Phase, volumes.RequestPhaseCompleted)
+}
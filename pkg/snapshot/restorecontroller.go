@@ -10,6 +10,7 @@ import (
 	"github.com/loft-sh/vcluster/pkg/constants"
 	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
 	csiVolumes "github.com/loft-sh/vcluster/pkg/snapshot/volumes/csi"
+	"github.com/loft-sh/vcluster/pkg/snapshot/volumes/filebackup"
 	"github.com/loft-sh/vcluster/pkg/syncer/synccontext"
 	"github.com/loft-sh/vcluster/pkg/util/loghelper"
 	corev1 "k8s.io/api/core/v1"
@@ -28,7 +29,8 @@ const (
 
 type RestoreReconciler struct {
 	reconcilerBase
-	volumesRestorer volumes.Restorer
+	volumesRestorer       volumes.Restorer
+	volumeBackupsRestorer volumes.Restorer
 }
 
 func NewRestoreController(registerContext *synccontext.RegisterContext) (*RestoreReconciler, error) {
@@ -83,10 +85,16 @@ func NewRestoreController(registerContext *synccontext.RegisterContext) (*Restor
 		finalizer:          RestoreControllerFinalizer,
 		requestKey:         RestoreRequestKey,
 	}
+	podConfig := newVolumeBackupPodConfig(registerContext.Config, requestsManager.GetClient(), reconciler.getRequestNamespace(), registerContext.HostManager.GetAPIReader())
+	volumeBackupsRestorer, err := filebackup.NewRestorer(registerContext.Config, kubeClient, podConfig, eventRecorder, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create volume backups restorer: %w", err)
+	}
 
 	return &RestoreReconciler{
-		reconcilerBase:  reconciler,
-		volumesRestorer: volumesRestorer,
+		reconcilerBase:        reconciler,
+		volumesRestorer:       volumesRestorer,
+		volumeBackupsRestorer: volumeBackupsRestorer,
 	}, nil
 }
 
@@ -156,14 +164,17 @@ func (c *RestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (re
 			return ctrl.Result{}, fmt.Errorf("failed to reconcile new restore request %s/%s: %w", configMap.Namespace, configMap.Name, err)
 		}
 	case RequestPhaseRestoringVolumes:
-		volumesRestoreRequest := &restoreRequest.Spec.VolumesRestore
-		volumesRestoreStatus := &restoreRequest.Status.VolumesRestore
-		previousVolumesRestoreRequestPhase := volumesRestoreStatus.Phase
-		err = c.volumesRestorer.Reconcile(ctx, &configMap, restoreRequest.Name, volumesRestoreRequest, volumesRestoreStatus)
+		previousVolumesRestoreRequestPhase, _ := restoreRequest.volumesPhase()
+		err = c.volumesRestorer.Reconcile(ctx, &configMap, restoreRequest.Name, &restoreRequest.Spec.VolumesRestore, &restoreRequest.Status.VolumesRestore)
 		if err != nil {
 			return ctrl.Result{}, fmt.Errorf("failed to reconcile volume snapshots: %w", err)
 		}
-		switch volumesRestoreStatus.Phase {
+		err = c.volumeBackupsRestorer.Reconcile(ctx, &configMap, restoreRequest.Name, &restoreRequest.Spec.VolumeBackupsRestore, &restoreRequest.Status.VolumeBackupsRestore)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to reconcile volume backups: %w", err)
+		}
+		volumesRestorePhase, volumesRestoreErrorMessage := restoreRequest.volumesPhase()
+		switch volumesRestorePhase {
 		case volumes.RequestPhaseInProgress:
 			if previousVolumesRestoreRequestPhase == volumes.RequestPhaseNotStarted {
 				// volume restore request just got initialized and moved to in-progress
@@ -182,12 +193,12 @@ func (c *RestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (re
 			restoreRequest.Status.Phase = RequestPhaseCompleted
 		case volumes.RequestPhaseFailed:
 			restoreRequest.Status.Phase = RequestPhaseFailed
-			restoreRequest.Status.Error.Message = volumesRestoreStatus.Error.Message
+			restoreRequest.Status.Error.Message = volumesRestoreErrorMessage
 		case volumes.RequestPhasePartiallyFailed:
 			restoreRequest.Status.Phase = RequestPhasePartiallyFailed
-			restoreRequest.Status.Error.Message = volumesRestoreStatus.Error.Message
+			restoreRequest.Status.Error.Message = volumesRestoreErrorMessage
 		default:
-			return ctrl.Result{}, fmt.Errorf("unexpected volume snapshots request phase %s", volumesRestoreStatus.Phase)
+			return ctrl.Result{}, fmt.Errorf("unexpected volume snapshots request phase %s", volumesRestorePhase)
 		}
 	case RequestPhasePartiallyFailed:
 		fallthrough
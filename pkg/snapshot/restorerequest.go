@@ -34,16 +34,45 @@ func (r *RestoreRequest) GetPhase() RequestPhase {
 }
 
 type RestoreRequestSpec struct {
-	URL            string                     `json:"url,omitempty"`
-	IncludeVolumes bool                       `json:"includeVolumes,omitempty"`
-	VolumesRestore volumes.RestoreRequestSpec `json:"volumesRestore,omitempty"`
-	Options        Options                    `json:"-"`
+	URL                  string                     `json:"url,omitempty"`
+	IncludeVolumes       bool                       `json:"includeVolumes,omitempty"`
+	VolumesRestore       volumes.RestoreRequestSpec `json:"volumesRestore,omitempty"`
+	VolumeBackupsRestore volumes.RestoreRequestSpec `json:"volumeBackupsRestore,omitempty"`
+	Options              Options                    `json:"-"`
 }
 
 type RestoreRequestStatus struct {
-	Phase          RequestPhase                 `json:"phase,omitempty"`
-	VolumesRestore volumes.RestoreRequestStatus `json:"volumesRestore,omitempty"`
-	Error          snapshotTypes.SnapshotError  `json:"error,omitempty"`
+	Phase                RequestPhase                 `json:"phase,omitempty"`
+	VolumesRestore       volumes.RestoreRequestStatus `json:"volumesRestore,omitempty"`
+	VolumeBackupsRestore volumes.RestoreRequestStatus `json:"volumeBackupsRestore,omitempty"`
+	Error                snapshotTypes.SnapshotError  `json:"error,omitempty"`
+}
+
+// volumesPhase returns the combined phase of restoring the volume snapshots and the file level volume backups.
+func (r *RestoreRequest) volumesPhase() (volumes.SnapshotRequestPhase, string) {
+	return combineVolumesPhases(
+		volumesResult{
+			phase:    r.Status.VolumesRestore.Phase,
+			message:  r.Status.VolumesRestore.Error.Message,
+			requests: len(r.Spec.VolumesRestore.Requests),
+		},
+		volumesResult{
+			phase:    r.Status.VolumeBackupsRestore.Phase,
+			message:  r.Status.VolumeBackupsRestore.Error.Message,
+			requests: len(r.Spec.VolumeBackupsRestore.Requests),
+		},
+	)
+}
+
+// VolumeRestoreStatus returns the restore status of the PVC, regardless if it is restored from a
+// volume snapshot or from a file level volume backup.
+func (r *RestoreRequest) VolumeRestoreStatus(pvcName string) (volumes.RestoreStatus, bool) {
+	if status, ok := r.Status.VolumesRestore.PersistentVolumeClaims[pvcName]; ok {
+		return status, true
+	}
+
+	status, ok := r.Status.VolumeBackupsRestore.PersistentVolumeClaims[pvcName]
+	return status, ok
 }
 
 func NewRestoreRequest(snapshotRequest Request) (RestoreRequest, error) {
@@ -57,6 +86,9 @@ func NewRestoreRequest(snapshotRequest Request) (RestoreRequest, error) {
 			VolumesRestore: volumes.RestoreRequestSpec{
 				Requests: []volumes.RestoreRequest{},
 			},
+			VolumeBackupsRestore: volumes.RestoreRequestSpec{
+				Requests: []volumes.RestoreRequest{},
+			},
 		},
 		Status: RestoreRequestStatus{
 			Phase: RequestPhaseNotStarted,
@@ -64,21 +96,39 @@ func NewRestoreRequest(snapshotRequest Request) (RestoreRequest, error) {
 				Phase:                  volumes.RequestPhaseNotStarted,
 				PersistentVolumeClaims: map[string]volumes.RestoreStatus{},
 			},
+			VolumeBackupsRestore: volumes.RestoreRequestStatus{
+				Phase:                  volumes.RequestPhaseNotStarted,
+				PersistentVolumeClaims: map[string]volumes.RestoreStatus{},
+			},
 		},
 	}
 
-	for _, volumeSnapshotRequest := range snapshotRequest.Spec.VolumeSnapshots.Requests {
+	err := addVolumeRestoreRequests(snapshotRequest.Spec.VolumeSnapshots, snapshotRequest.Status.VolumeSnapshots, &restoreRequest.Spec.VolumesRestore, &restoreRequest.Status.VolumesRestore)
+	if err != nil {
+		return RestoreRequest{}, err
+	}
+	err = addVolumeRestoreRequests(snapshotRequest.Spec.VolumeBackups, snapshotRequest.Status.VolumeBackups, &restoreRequest.Spec.VolumeBackupsRestore, &restoreRequest.Status.VolumeBackupsRestore)
+	if err != nil {
+		return RestoreRequest{}, err
+	}
+
+	return restoreRequest, nil
+}
+
+// addVolumeRestoreRequests adds a restore request for every volume that was successfully snapshotted or backed up
+func addVolumeRestoreRequests(snapshotsRequest volumes.SnapshotsRequest, snapshotsStatus volumes.SnapshotsStatus, restoreSpec *volumes.RestoreRequestSpec, restoreStatus *volumes.RestoreRequestStatus) error {
+	for _, volumeSnapshotRequest := range snapshotsRequest.Requests {
 		pvcName := fmt.Sprintf("%s/%s", volumeSnapshotRequest.PersistentVolumeClaim.Namespace, volumeSnapshotRequest.PersistentVolumeClaim.Name)
-		snapshotStatus, ok := snapshotRequest.Status.VolumeSnapshots.Snapshots[pvcName]
+		snapshotStatus, ok := snapshotsStatus.Snapshots[pvcName]
 		if !ok {
-			return RestoreRequest{}, fmt.Errorf("volume snapshot status for PVC %s is not set", pvcName)
+			return fmt.Errorf("volume snapshot status for PVC %s is not set", pvcName)
 		}
 		if snapshotStatus.Phase != volumes.RequestPhaseCompleted {
 			// Volume snapshot was not successfully created
 			continue
 		}
 		if snapshotStatus.SnapshotHandle == "" {
-			return RestoreRequest{}, fmt.Errorf("snapshot handle for PVC %s is not set in the snapshot request status", pvcName)
+			return fmt.Errorf("snapshot handle for PVC %s is not set in the snapshot request status", pvcName)
 		}
 
 		// add volume restore request
@@ -88,15 +138,15 @@ func NewRestoreRequest(snapshotRequest Request) (RestoreRequest, error) {
 			VolumeSnapshotClassName: volumeSnapshotRequest.VolumeSnapshotClassName,
 			SnapshotHandle:          snapshotStatus.SnapshotHandle,
 		}
-		restoreRequest.Spec.VolumesRestore.Requests = append(restoreRequest.Spec.VolumesRestore.Requests, volumeRestoreRequest)
+		restoreSpec.Requests = append(restoreSpec.Requests, volumeRestoreRequest)
 
 		// set volume restore status
-		restoreRequest.Status.VolumesRestore.PersistentVolumeClaims[pvcName] = volumes.RestoreStatus{
+		restoreStatus.PersistentVolumeClaims[pvcName] = volumes.RestoreStatus{
 			Phase: volumes.RequestPhaseNotStarted,
 		}
 	}
 
-	return restoreRequest, nil
+	return nil
 }
 
 func UnmarshalRestoreRequest(configMap *corev1.ConfigMap) (*RestoreRequest, error) {
@@ -289,7 +289,10 @@ func (o *ObjectStore) GetObject(ctx context.Context) (io.ReadCloser, error) {
 	}
 
 	output, err := o.s3.GetObject(ctx, input)
-	if err != nil {
+	var noSuchKey *s3types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, fmt.Errorf("error getting object %s: %w: %w", o.key, types.ErrObjectNotFound, err)
+	} else if err != nil {
 		return nil, errors.Wrapf(err, "error getting object %s", o.key)
 	}
 
@@ -0,0 +1,493 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	vclusterconfig "github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/constants"
+	"github.com/loft-sh/vcluster/pkg/snapshot/types"
+	"github.com/loft-sh/vcluster/pkg/syncer/synccontext"
+	"github.com/loft-sh/vcluster/pkg/util/cron"
+	"github.com/loft-sh/vcluster/pkg/util/loghelper"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ScheduleTimestampFormat is the format of the {{.Timestamp}} field in schedule url templates
+	ScheduleTimestampFormat = "20060102-150405"
+
+	scheduleInterval = 30 * time.Second
+)
+
+// ScheduleStatus is the status of a snapshot schedule as stored in the schedule status ConfigMap
+type ScheduleStatus struct {
+	Schedule         string       `json:"schedule"`
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// ActiveRequest is the name of the snapshot request that is currently running
+	ActiveRequest string `json:"activeRequest,omitempty"`
+
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+	LastSuccessfulURL  string       `json:"lastSuccessfulURL,omitempty"`
+	LastFailureTime    *metav1.Time `json:"lastFailureTime,omitempty"`
+	LastError          string       `json:"lastError,omitempty"`
+
+	LastRetentionTime  *metav1.Time `json:"lastRetentionTime,omitempty"`
+	LastRetentionError string       `json:"lastRetentionError,omitempty"`
+	Pruned             int          `json:"pruned,omitempty"`
+}
+
+// ScheduleURLData holds the fields that can be used in schedule url templates
+type ScheduleURLData struct {
+	Name      string
+	Namespace string
+	Schedule  string
+	Timestamp string
+}
+
+// ScheduleStatusConfigMapName returns the name of the ConfigMap that holds the status of all snapshot schedules
+func ScheduleStatusConfigMapName(vClusterName string) string {
+	return "vc-snapshot-schedules-" + vClusterName
+}
+
+// RenderScheduleURL renders the url template of a snapshot schedule
+func RenderScheduleURL(urlTemplate string, data ScheduleURLData) (string, error) {
+	t, err := template.New("url").Option("missingkey=error").Parse(urlTemplate)
+	if err != nil {
+		return "", fmt.Errorf("parse url template: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+	err = t.Execute(buf, data)
+	if err != nil {
+		return "", fmt.Errorf("render url template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// PruneSnapshots splits the snapshots into the ones that are kept and the ones that should be deleted according
+// to the retention. A snapshot is kept if any rule selects it. If no rule is set, all snapshots are kept.
+func PruneSnapshots(snapshots []types.Snapshot, retention vclusterconfig.SnapshotRetention) (keep []types.Snapshot, prune []types.Snapshot) {
+	if retention.KeepLast <= 0 && retention.KeepDaily <= 0 && retention.KeepWeekly <= 0 && retention.KeepMonthly <= 0 {
+		return snapshots, nil
+	}
+
+	sorted := append([]types.Snapshot{}, snapshots...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.After(sorted[j].Timestamp)
+	})
+
+	rules := []struct {
+		count  int
+		bucket func(i int, t time.Time) string
+	}{
+		{count: retention.KeepLast, bucket: func(i int, _ time.Time) string { return strconv.Itoa(i) }},
+		{count: retention.KeepDaily, bucket: func(_ int, t time.Time) string { return t.UTC().Format("2006-01-02") }},
+		{count: retention.KeepWeekly, bucket: func(_ int, t time.Time) string {
+			year, week := t.UTC().ISOWeek()
+			return fmt.Sprintf("%d-%d", year, week)
+		}},
+		{count: retention.KeepMonthly, bucket: func(_ int, t time.Time) string { return t.UTC().Format("2006-01") }},
+	}
+
+	// walk from newest to oldest and keep the newest snapshot of each bucket until enough buckets are found
+	kept := make([]bool, len(sorted))
+	for _, rule := range rules {
+		buckets := map[string]bool{}
+		for i, snapshot := range sorted {
+			if len(buckets) >= rule.count {
+				break
+			}
+
+			bucket := rule.bucket(i, snapshot.Timestamp)
+			if buckets[bucket] {
+				continue
+			}
+
+			buckets[bucket] = true
+			kept[i] = true
+		}
+	}
+
+	for i, snapshot := range sorted {
+		if kept[i] {
+			keep = append(keep, snapshot)
+		} else {
+			prune = append(prune, snapshot)
+		}
+	}
+
+	return keep, prune
+}
+
+type schedule struct {
+	vclusterconfig.SnapshotSchedule
+
+	cron *cron.Schedule
+}
+
+// Scheduler periodically creates snapshot requests for the configured snapshot schedules, applies their
+// retention and reports the result in the schedule status ConfigMap.
+type Scheduler struct {
+	vConfig    *config.VirtualClusterConfig
+	kubeClient kubernetes.Interface
+	namespace  string
+	schedules  []schedule
+	logger     loghelper.Logger
+
+	now func() time.Time
+}
+
+func NewScheduler(registerContext *synccontext.RegisterContext) (*Scheduler, error) {
+	kubeClient, err := kubernetes.NewForConfig(registerContext.HostManager.GetConfig())
+	if err != nil {
+		return nil, fmt.Errorf("could not create kube client: %w", err)
+	}
+
+	schedules := make([]schedule, 0, len(registerContext.Config.Snapshots.Schedules))
+	for _, snapshotSchedule := range registerContext.Config.Snapshots.Schedules {
+		cronSchedule, err := cron.Parse(snapshotSchedule.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot schedule %s: %w", snapshotSchedule.Name, err)
+		}
+
+		schedules = append(schedules, schedule{SnapshotSchedule: snapshotSchedule, cron: cronSchedule})
+	}
+
+	return &Scheduler{
+		vConfig:    registerContext.Config,
+		kubeClient: kubeClient,
+		namespace:  registerContext.Config.HostNamespace,
+		schedules:  schedules,
+		logger:     loghelper.New("snapshot-scheduler"),
+		now:        time.Now,
+	}, nil
+}
+
+// Start implements manager.Runnable
+func (s *Scheduler) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		err := s.run(ctx)
+		if err != nil {
+			s.logger.Errorf("error running snapshot schedules: %v", err)
+		}
+	}, scheduleInterval)
+	return nil
+}
+
+func (s *Scheduler) run(ctx context.Context) error {
+	configMap, err := s.kubeClient.CoreV1().ConfigMaps(s.namespace).Get(ctx, ScheduleStatusConfigMapName(s.vConfig.Name), metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ScheduleStatusConfigMapName(s.vConfig.Name),
+				Namespace: s.namespace,
+				Labels: map[string]string{
+					constants.VClusterNamespaceLabel: s.namespace,
+					constants.VClusterNameLabel:      s.vConfig.Name,
+				},
+			},
+		}
+	} else if err != nil {
+		return fmt.Errorf("get snapshot schedule status: %w", err)
+	}
+
+	data := map[string]string{}
+	for _, snapshotSchedule := range s.schedules {
+		status := ScheduleStatus{}
+		if rawStatus, ok := configMap.Data[snapshotSchedule.Name]; ok {
+			err = json.Unmarshal([]byte(rawStatus), &status)
+			if err != nil {
+				s.logger.Errorf("error parsing status of snapshot schedule %s: %v", snapshotSchedule.Name, err)
+			}
+		}
+
+		s.reconcileSchedule(ctx, snapshotSchedule, &status)
+		rawStatus, err := json.Marshal(status)
+		if err != nil {
+			return fmt.Errorf("marshal status of snapshot schedule %s: %w", snapshotSchedule.Name, err)
+		}
+
+		data[snapshotSchedule.Name] = string(rawStatus)
+	}
+
+	configMap.Data = data
+	if configMap.ResourceVersion == "" {
+		_, err = s.kubeClient.CoreV1().ConfigMaps(s.namespace).Create(ctx, configMap, metav1.CreateOptions{})
+	} else {
+		_, err = s.kubeClient.CoreV1().ConfigMaps(s.namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("update snapshot schedule status: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Scheduler) reconcileSchedule(ctx context.Context, snapshotSchedule schedule, status *ScheduleStatus) {
+	now := s.now()
+
+	// check the result of the last snapshot request
+	if status.ActiveRequest != "" {
+		s.reconcileActiveRequest(ctx, snapshotSchedule, status, now)
+	}
+
+	// schedule changed or first run, so we only compute the next schedule time
+	if status.Schedule != snapshotSchedule.Schedule || status.NextScheduleTime == nil {
+		status.Schedule = snapshotSchedule.Schedule
+		status.NextScheduleTime = nextScheduleTime(snapshotSchedule.cron, now)
+		return
+	} else if now.Before(status.NextScheduleTime.Time) {
+		return
+	}
+
+	status.NextScheduleTime = nextScheduleTime(snapshotSchedule.cron, now)
+	if status.ActiveRequest != "" {
+		s.logger.Infof("Skipping snapshot schedule %s, because snapshot request %s is still running", snapshotSchedule.Name, status.ActiveRequest)
+		return
+	}
+
+	request, err := s.createRequest(ctx, snapshotSchedule, now)
+	if err != nil {
+		s.logger.Errorf("error creating snapshot for schedule %s: %v", snapshotSchedule.Name, err)
+		status.LastFailureTime = &metav1.Time{Time: now}
+		status.LastError = err.Error()
+		return
+	}
+
+	s.logger.Infof("Created snapshot request %s for schedule %s", request.Name, snapshotSchedule.Name)
+	status.LastScheduleTime = &metav1.Time{Time: now}
+	status.ActiveRequest = request.Name
+}
+
+func (s *Scheduler) reconcileActiveRequest(ctx context.Context, snapshotSchedule schedule, status *ScheduleStatus, now time.Time) {
+	configMap, err := s.kubeClient.CoreV1().ConfigMaps(s.namespace).Get(ctx, status.ActiveRequest, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		status.LastFailureTime = &metav1.Time{Time: now}
+		status.LastError = fmt.Sprintf("snapshot request %s not found", status.ActiveRequest)
+		status.ActiveRequest = ""
+		return
+	} else if err != nil {
+		s.logger.Errorf("error getting snapshot request %s of schedule %s: %v", status.ActiveRequest, snapshotSchedule.Name, err)
+		return
+	}
+
+	request, err := UnmarshalSnapshotRequest(configMap)
+	if err != nil {
+		status.LastFailureTime = &metav1.Time{Time: now}
+		status.LastError = err.Error()
+		status.ActiveRequest = ""
+		return
+	} else if !request.Done() {
+		return
+	}
+
+	status.ActiveRequest = ""
+	switch request.Status.Phase {
+	case RequestPhaseCompleted, RequestPhasePartiallyFailed:
+		status.LastSuccessfulTime = &metav1.Time{Time: now}
+		status.LastSuccessfulURL = request.Spec.URL
+		if request.Status.Phase == RequestPhasePartiallyFailed {
+			status.LastError = request.Status.Error.Message
+		}
+
+		pruned, err := s.applyRetention(ctx, snapshotSchedule, now)
+		status.LastRetentionTime = &metav1.Time{Time: now}
+		status.Pruned = pruned
+		status.LastRetentionError = ""
+		if err != nil {
+			s.logger.Errorf("error applying retention of snapshot schedule %s: %v", snapshotSchedule.Name, err)
+			status.LastRetentionError = err.Error()
+		}
+	case RequestPhaseCanceled:
+		status.LastFailureTime = &metav1.Time{Time: now}
+		status.LastError = fmt.Sprintf("snapshot request %s was canceled", request.Name)
+	default:
+		status.LastFailureTime = &metav1.Time{Time: now}
+		status.LastError = request.Status.Error.Message
+	}
+}
+
+func (s *Scheduler) createRequest(ctx context.Context, snapshotSchedule schedule, now time.Time) (*Request, error) {
+	snapshotURL, err := s.renderURL(snapshotSchedule, now.UTC().Format(ScheduleTimestampFormat))
+	if err != nil {
+		return nil, err
+	}
+
+	options := &Options{}
+	err = Parse(snapshotURL, options)
+	if err != nil {
+		return nil, err
+	}
+	options.IncludeVolumes = snapshotSchedule.IncludeVolumes
+
+	return CreateSnapshotRequestResources(ctx, s.namespace, s.vConfig.Name, s.vConfig, options, s.kubeClient)
+}
+
+// applyRetention deletes the snapshots of the schedule that are not selected by its retention and returns
+// the number of deleted snapshots.
+func (s *Scheduler) applyRetention(ctx context.Context, snapshotSchedule schedule, now time.Time) (int, error) {
+	retention := snapshotSchedule.Retention
+	if retention.KeepLast <= 0 && retention.KeepDaily <= 0 && retention.KeepWeekly <= 0 && retention.KeepMonthly <= 0 {
+		return 0, nil
+	}
+
+	// snapshots of this schedule differ only in the timestamp of the file name
+	const marker = "TIMESTAMP"
+	markedURL, err := s.renderURL(snapshotSchedule, marker)
+	if err != nil {
+		return 0, err
+	}
+	parsedURL, err := url.Parse(markedURL)
+	if err != nil {
+		return 0, err
+	}
+	prefix, suffix, ok := strings.Cut(path.Base(parsedURL.Path), marker)
+	if !ok || !strings.HasSuffix(parsedURL.Path, ".tar.gz") || strings.Contains(path.Dir(parsedURL.Path), marker) {
+		return 0, errors.New("retention requires a url with {{.Timestamp}} in a file name ending with .tar.gz")
+	}
+
+	snapshotURL, err := s.renderURL(snapshotSchedule, now.UTC().Format(ScheduleTimestampFormat))
+	if err != nil {
+		return 0, err
+	}
+	options := &Options{}
+	err = Parse(snapshotURL, options)
+	if err != nil {
+		return 0, err
+	}
+	store, err := CreateStore(ctx, options)
+	if err != nil {
+		return 0, err
+	}
+	snapshots, err := store.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list snapshots: %w", err)
+	}
+
+	scheduleSnapshots := []types.Snapshot{}
+	for _, snapshot := range snapshots {
+		timestamp, ok := strings.CutPrefix(snapshot.ID, prefix)
+		if !ok {
+			continue
+		}
+		timestamp, ok = strings.CutSuffix(timestamp, suffix)
+		if !ok {
+			continue
+		}
+		if _, err := time.Parse(ScheduleTimestampFormat, timestamp); err != nil {
+			continue
+		}
+
+		scheduleSnapshots = append(scheduleSnapshots, snapshot)
+	}
+
+	_, prune := PruneSnapshots(scheduleSnapshots, retention)
+	prune = s.keepBaseSnapshots(ctx, snapshotSchedule, options, snapshots, prune)
+	pruned := 0
+	var errs []error
+	for _, snapshot := range prune {
+		err = s.deleteSnapshot(ctx, snapshotSchedule, options, snapshot)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("delete snapshot %s: %w", snapshot.ID, err))
+			continue
+		}
+
+		s.logger.Infof("Deleted snapshot %s of schedule %s", snapshot.ID, snapshotSchedule.Name)
+		pruned++
+	}
+
+	return pruned, errors.Join(errs...)
+}
+
+// keepBaseSnapshots removes the snapshots from prune that remaining incremental snapshots were created from,
+// directly or through other incremental snapshots, as the incremental snapshots can't be restored without them.
+// Incremental snapshots are not necessarily created by the schedule, so all snapshots next to the ones of the
+// schedule are considered.
+func (s *Scheduler) keepBaseSnapshots(ctx context.Context, snapshotSchedule schedule, options *Options, snapshots, prune []types.Snapshot) []types.Snapshot {
+	if len(prune) == 0 {
+		return prune
+	}
+
+	// incremental snapshots are always newer than their base
+	oldest := slices.MinFunc(prune, func(a, b types.Snapshot) int { return a.Timestamp.Compare(b.Timestamp) })
+	keep := slices.DeleteFunc(slices.Clone(snapshots), func(snapshot types.Snapshot) bool {
+		return !snapshot.Timestamp.After(oldest.Timestamp) || slices.ContainsFunc(prune, func(pruned types.Snapshot) bool { return pruned.ID == snapshot.ID })
+	})
+	for len(keep) > 0 && len(prune) > 0 {
+		references := baseReferences(ctx, options, keep)
+		if len(references) == 0 {
+			break
+		}
+
+		keep = nil
+		prune = slices.DeleteFunc(prune, func(snapshot types.Snapshot) bool {
+			snapshotOptions, err := options.withID(snapshot.ID)
+			if err != nil {
+				return false
+			}
+
+			incrementals := references[snapshotOptions.GetURL()]
+			if len(incrementals) == 0 {
+				return false
+			}
+
+			s.logger.Infof("Keeping snapshot %s of schedule %s, as it is the base of the incremental snapshots %s", snapshot.ID, snapshotSchedule.Name, strings.Join(incrementals, ", "))
+			keep = append(keep, snapshot)
+			return true
+		})
+	}
+
+	return prune
+}
+
+func (s *Scheduler) deleteSnapshot(ctx context.Context, snapshotSchedule schedule, scheduleOptions *Options, snapshot types.Snapshot) error {
+	options, err := scheduleOptions.withID(snapshot.ID)
+	if err != nil {
+		return err
+	}
+
+	// volume snapshots are deleted by the snapshot controller together with the etcd backup
+	if snapshotSchedule.IncludeVolumes {
+		return DeleteSnapshotRequestResources(ctx, s.namespace, s.vConfig.Name, s.vConfig, options, s.kubeClient)
+	}
+
+	return deleteSnapshot(ctx, options)
+}
+
+func (s *Scheduler) renderURL(snapshotSchedule schedule, timestamp string) (string, error) {
+	return RenderScheduleURL(snapshotSchedule.URL, ScheduleURLData{
+		Name:      s.vConfig.Name,
+		Namespace: s.namespace,
+		Schedule:  snapshotSchedule.Name,
+		Timestamp: timestamp,
+	})
+}
+
+func nextScheduleTime(cronSchedule *cron.Schedule, now time.Time) *metav1.Time {
+	next := cronSchedule.Next(now.UTC())
+	if next.IsZero() {
+		return nil
+	}
+
+	return &metav1.Time{Time: next}
+}
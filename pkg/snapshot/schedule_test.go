@@ -0,0 +1,250 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	vclusterconfig "github.com/loft-sh/vcluster/config"
+	"github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/snapshot/types"
+	"github.com/loft-sh/vcluster/pkg/util/cron"
+	"github.com/loft-sh/vcluster/pkg/util/loghelper"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestPruneSnapshots(t *testing.T) {
+	now := time.Date(2025, time.March, 31, 12, 0, 0, 0, time.UTC)
+
+	// one snapshot every 12 hours over the last 90 days
+	snapshots := []types.Snapshot{}
+	for i := 0; i < 180; i++ {
+		timestamp := now.Add(-time.Duration(i) * 12 * time.Hour)
+		snapshots = append(snapshots, types.Snapshot{ID: timestamp.Format(ScheduleTimestampFormat), Timestamp: timestamp})
+	}
+
+	tests := []struct {
+		name      string
+		retention vclusterconfig.SnapshotRetention
+		expected  []string
+	}{
+		{
+			name:     "no retention",
+			expected: nil,
+		},
+		{
+			name:      "keep last",
+			retention: vclusterconfig.SnapshotRetention{KeepLast: 3},
+			expected:  []string{"20250331-120000", "20250331-000000", "20250330-120000"},
+		},
+		{
+			name:      "keep daily",
+			retention: vclusterconfig.SnapshotRetention{KeepDaily: 2},
+			expected:  []string{"20250331-120000", "20250330-120000"},
+		},
+		{
+			name:      "keep last and monthly",
+			retention: vclusterconfig.SnapshotRetention{KeepLast: 1, KeepMonthly: 3},
+			expected:  []string{"20250331-120000", "20250228-120000", "20250131-120000"},
+		},
+		{
+			name:      "keep weekly",
+			retention: vclusterconfig.SnapshotRetention{KeepWeekly: 2},
+			expected:  []string{"20250331-120000", "20250330-120000"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keep, prune := PruneSnapshots(snapshots, test.retention)
+			assert.Equal(t, len(keep)+len(prune), len(snapshots))
+			if test.expected == nil {
+				assert.Equal(t, len(prune), 0)
+				return
+			}
+
+			kept := []string{}
+			for _, snapshot := range keep {
+				kept = append(kept, snapshot.ID)
+			}
+			assert.DeepEqual(t, kept, test.expected)
+		})
+	}
+}
+
+func TestSchedulerRetention(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, time.March, 31, 12, 0, 0, 0, time.UTC)
+
+	// snapshots of the schedule and a manually created one
+	files := []string{"manual.tar.gz"}
+	for i := 0; i < 5; i++ {
+		files = append(files, "nightly-"+now.Add(-time.Duration(i)*24*time.Hour).Format(ScheduleTimestampFormat)+".tar.gz")
+	}
+	for i, file := range files {
+		filePath := filepath.Join(dir, file)
+		assert.NilError(t, os.WriteFile(filePath, []byte("snapshot"), 0600))
+		modTime := now.Add(-time.Duration(i) * 24 * time.Hour)
+		assert.NilError(t, os.Chtimes(filePath, modTime, modTime))
+	}
+
+	cronSchedule, err := cron.Parse("0 3 * * *")
+	assert.NilError(t, err)
+	vConfig := &config.VirtualClusterConfig{Name: "my-vcluster"}
+	scheduler := &Scheduler{
+		vConfig:    vConfig,
+		kubeClient: fake.NewSimpleClientset(),
+		namespace:  "vcluster-my-vcluster",
+		logger:     loghelper.New("test"),
+		now:        func() time.Time { return now },
+	}
+	pruned, err := scheduler.applyRetention(context.Background(), schedule{
+		SnapshotSchedule: vclusterconfig.SnapshotSchedule{
+			Name:      "nightly",
+			Schedule:  "0 3 * * *",
+			URL:       "container://" + dir + "/{{.Schedule}}-{{.Timestamp}}.tar.gz",
+			Retention: vclusterconfig.SnapshotRetention{KeepLast: 2},
+		},
+		cron: cronSchedule,
+	}, now)
+	assert.NilError(t, err)
+	assert.Equal(t, pruned, 3)
+
+	entries, err := os.ReadDir(dir)
+	assert.NilError(t, err)
+	remaining := []string{}
+	for _, entry := range entries {
+		remaining = append(remaining, entry.Name())
+	}
+	sort.Strings(remaining)
+	assert.DeepEqual(t, remaining, []string{"manual.tar.gz", "nightly-20250330-120000.tar.gz", "nightly-20250331-120000.tar.gz"})
+}
+
+func TestSchedulerRetentionKeepsBaseSnapshots(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	now := time.Date(2025, time.March, 31, 12, 0, 0, 0, time.UTC)
+	source := &fakeEtcdClient{}
+	_, _ = source.Put(ctx, "/registry/configmaps/default/test", []byte("test"))
+
+	// three nightly snapshots and a manually created incremental snapshot of the oldest one
+	names := []string{}
+	for i := 2; i >= 0; i-- {
+		names = append(names, "nightly-"+now.Add(-time.Duration(i)*24*time.Hour).Format(ScheduleTimestampFormat)+".tar.gz")
+	}
+	names = append(names, "manual.tar.gz")
+	for i, name := range names {
+		baseURL := ""
+		if name == "manual.tar.gz" {
+			baseURL = "container://" + filepath.Join(dir, names[0])
+		}
+		_, _ = source.Put(ctx, "/registry/configmaps/default/test", []byte(name))
+		assert.NilError(t, createTestSnapshot(t, source, "container://"+filepath.Join(dir, name), baseURL))
+
+		modTime := now.Add(time.Duration(i-len(names)) * time.Hour)
+		assert.NilError(t, os.Chtimes(filepath.Join(dir, name), modTime, modTime))
+	}
+
+	cronSchedule, err := cron.Parse("0 3 * * *")
+	assert.NilError(t, err)
+	scheduler := &Scheduler{
+		vConfig:    &config.VirtualClusterConfig{Name: "my-vcluster"},
+		kubeClient: fake.NewSimpleClientset(),
+		namespace:  "vcluster-my-vcluster",
+		logger:     loghelper.New("test"),
+		now:        func() time.Time { return now },
+	}
+	pruned, err := scheduler.applyRetention(ctx, schedule{
+		SnapshotSchedule: vclusterconfig.SnapshotSchedule{
+			Name:      "nightly",
+			Schedule:  "0 3 * * *",
+			URL:       "container://" + dir + "/{{.Schedule}}-{{.Timestamp}}.tar.gz",
+			Retention: vclusterconfig.SnapshotRetention{KeepLast: 1},
+		},
+		cron: cronSchedule,
+	}, now)
+	assert.NilError(t, err)
+	assert.Equal(t, pruned, 1)
+
+	entries, err := os.ReadDir(dir)
+	assert.NilError(t, err)
+	remaining := []string{}
+	for _, entry := range entries {
+		remaining = append(remaining, entry.Name())
+	}
+	sort.Strings(remaining)
+	assert.DeepEqual(t, remaining, []string{"manual.tar.gz", "manual.tar.gz" + types.BaseReferenceSuffix, names[0], names[2]})
+}
+
+func TestSchedulerSchedule(t *testing.T) {
+	now := time.Date(2025, time.March, 31, 2, 59, 50, 0, time.UTC)
+	cronSchedule, err := cron.Parse("0 3 * * *")
+	assert.NilError(t, err)
+	snapshotSchedule := schedule{
+		SnapshotSchedule: vclusterconfig.SnapshotSchedule{
+			Name:     "nightly",
+			Schedule: "0 3 * * *",
+			URL:      "s3://my-bucket/{{.Name}}/{{.Timestamp}}.tar.gz",
+		},
+		cron: cronSchedule,
+	}
+
+	// the fake client does not generate names
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.PrependReactor("create", "secrets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		object := action.(clienttesting.CreateAction).GetObject().(metav1.Object)
+		if object.GetName() == "" {
+			object.SetName(object.GetGenerateName() + "abcde")
+		}
+		return false, nil, nil
+	})
+	scheduler := &Scheduler{
+		vConfig:    &config.VirtualClusterConfig{Name: "my-vcluster"},
+		kubeClient: kubeClient,
+		namespace:  "vcluster-my-vcluster",
+		logger:     loghelper.New("test"),
+		now:        func() time.Time { return now },
+	}
+
+	// the first run only computes the next schedule time
+	status := &ScheduleStatus{}
+	scheduler.reconcileSchedule(context.Background(), snapshotSchedule, status)
+	assert.Equal(t, status.NextScheduleTime.Time, time.Date(2025, time.March, 31, 3, 0, 0, 0, time.UTC))
+	assert.Equal(t, status.ActiveRequest, "")
+
+	// once due, a snapshot request is created
+	now = now.Add(time.Minute)
+	scheduler.reconcileSchedule(context.Background(), snapshotSchedule, status)
+	assert.Equal(t, status.NextScheduleTime.Time, time.Date(2025, time.April, 1, 3, 0, 0, 0, time.UTC))
+	configMaps, err := kubeClient.CoreV1().ConfigMaps(scheduler.namespace).List(context.Background(), metav1.ListOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(configMaps.Items), 1)
+	assert.Equal(t, configMaps.Items[0].Name, "my-vcluster-snapshot-request-abcde")
+	assert.Equal(t, status.ActiveRequest, "my-vcluster-snapshot-request-abcde")
+
+	request, err := UnmarshalSnapshotRequest(&configMaps.Items[0])
+	assert.NilError(t, err)
+	assert.Equal(t, request.Spec.URL, "s3://my-bucket/my-vcluster/20250331-030050.tar.gz")
+
+	// a failed request is reported in the status
+	request.Status.Phase = RequestPhaseFailed
+	request.Status.Error.Message = "upload failed"
+	configMap, err := CreateSnapshotRequestConfigMap(scheduler.namespace, "my-vcluster", request)
+	assert.NilError(t, err)
+	configMap.Name = status.ActiveRequest
+	_, err = kubeClient.CoreV1().ConfigMaps(scheduler.namespace).Update(context.Background(), configMap, metav1.UpdateOptions{})
+	assert.NilError(t, err)
+
+	scheduler.reconcileSchedule(context.Background(), snapshotSchedule, status)
+	assert.Equal(t, status.ActiveRequest, "")
+	assert.Equal(t, status.LastError, "upload failed")
+	assert.Assert(t, status.LastFailureTime != nil)
+	assert.Assert(t, status.LastSuccessfulTime == nil)
+}
@@ -0,0 +1,480 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/loft-sh/vcluster/pkg/mappings/store"
+	"github.com/loft-sh/vcluster/pkg/scheme"
+	"github.com/spf13/pflag"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ConflictSkip keeps objects that already exist in the vCluster
+	ConflictSkip = "skip"
+	// ConflictOverwrite replaces objects that already exist in the vCluster with the snapshot version
+	ConflictOverwrite = "overwrite"
+	// ConflictRename restores objects that already exist in the vCluster under a new name
+	ConflictRename = "rename"
+
+	renameSuffix = "-restored"
+)
+
+// skippedRestoreKinds are kinds that are managed by the control plane and never restored selectively
+var skippedRestoreKinds = map[schema.GroupKind]bool{
+	{Kind: "Event"}:                                       true,
+	{Kind: "Event", Group: "events.k8s.io"}:               true,
+	{Kind: "Endpoints"}:                                   true,
+	{Kind: "EndpointSlice", Group: "discovery.k8s.io"}:    true,
+	{Kind: "Lease", Group: "coordination.k8s.io"}:         true,
+	{Kind: "Node"}:                                        true,
+	{Kind: "RangeAllocation"}:                             true,
+	{Kind: "APIService", Group: "apiregistration.k8s.io"}: true,
+	{Kind: "IPAddress", Group: "networking.k8s.io"}:       true,
+	{Kind: "ServiceCIDR", Group: "networking.k8s.io"}:     true,
+}
+
+// restorePriority defines the order objects are restored in, lower values are restored first
+var restorePriority = map[string]int{
+	"Namespace":                0,
+	"CustomResourceDefinition": 1,
+	"StorageClass":             2,
+	"PriorityClass":            2,
+	"ServiceAccount":           3,
+	"Secret":                   3,
+	"ConfigMap":                3,
+	"Role":                     3,
+	"ClusterRole":              3,
+	"RoleBinding":              4,
+	"ClusterRoleBinding":       4,
+	"PersistentVolume":         4,
+	"PersistentVolumeClaim":    4,
+	"Service":                  4,
+}
+
+// SelectiveRestoreOptions restore only a part of a snapshot through the vCluster api server
+type SelectiveRestoreOptions struct {
+	Namespaces []string
+	Kinds      []string
+	Selector   string
+	Conflict   string
+	DryRun     bool
+}
+
+// AddSelectiveRestoreFlags adds the flags for a selective restore
+func AddSelectiveRestoreFlags(flags *pflag.FlagSet, options *SelectiveRestoreOptions) {
+	flags.StringSliceVar(&options.Namespaces, "include-namespaces", nil, "Only restore objects within these namespaces into the running vCluster")
+	flags.StringSliceVar(&options.Kinds, "include-kinds", nil, "Only restore objects of these kinds into the running vCluster. E.g. configmaps, Deployment or deployments.apps")
+	flags.StringVar(&options.Selector, "selector", "", "Only restore objects matching this label selector into the running vCluster")
+	flags.StringVar(&options.Conflict, "conflict", ConflictSkip, "How to handle objects that already exist during a selective restore. One of skip, overwrite or rename. Renamed objects get the suffix '-restored', references to them are not rewritten")
+	flags.BoolVar(&options.DryRun, "dry-run", false, "Only print what a selective restore would change")
+}
+
+// Enabled returns true if only a part of the snapshot should be restored
+func (o *SelectiveRestoreOptions) Enabled() bool {
+	return len(o.Namespaces) > 0 || len(o.Kinds) > 0 || o.Selector != ""
+}
+
+// Validate checks the selective restore options
+func (o *SelectiveRestoreOptions) Validate() error {
+	if !o.Enabled() {
+		if o.DryRun {
+			return errors.New("--dry-run requires at least one of --include-namespaces, --include-kinds or --selector")
+		} else if o.Conflict != "" && o.Conflict != ConflictSkip {
+			return errors.New("--conflict requires at least one of --include-namespaces, --include-kinds or --selector")
+		}
+
+		return nil
+	}
+
+	switch o.Conflict {
+	case "", ConflictSkip, ConflictOverwrite, ConflictRename:
+	default:
+		return fmt.Errorf("unsupported conflict mode %q, must be one of %s, %s or %s", o.Conflict, ConflictSkip, ConflictOverwrite, ConflictRename)
+	}
+
+	_, err := labels.Parse(o.Selector)
+	if err != nil {
+		return fmt.Errorf("parse selector: %w", err)
+	}
+
+	return nil
+}
+
+// Args returns the options as command line flags
+func (o *SelectiveRestoreOptions) Args() []string {
+	args := []string{}
+	if len(o.Namespaces) > 0 {
+		args = append(args, "--include-namespaces="+strings.Join(o.Namespaces, ","))
+	}
+	if len(o.Kinds) > 0 {
+		args = append(args, "--include-kinds="+strings.Join(o.Kinds, ","))
+	}
+	if o.Selector != "" {
+		args = append(args, "--selector="+o.Selector)
+	}
+	if o.Conflict != "" {
+		args = append(args, "--conflict="+o.Conflict)
+	}
+	if o.DryRun {
+		args = append(args, "--dry-run")
+	}
+	return args
+}
+
+// SelectiveRestoreResult summarizes a selective restore
+type SelectiveRestoreResult struct {
+	Created     int
+	Overwritten int
+	Renamed     int
+	Skipped     int
+	Failed      int
+}
+
+type selectiveRestorer struct {
+	client   client.Client
+	options  *SelectiveRestoreOptions
+	selector labels.Selector
+	decoder  runtime.Decoder
+}
+
+func newSelectiveRestorer(kubeClient client.Client, options *SelectiveRestoreOptions) (*selectiveRestorer, error) {
+	selector, err := labels.Parse(options.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("parse selector: %w", err)
+	}
+
+	return &selectiveRestorer{
+		client:   kubeClient,
+		options:  options,
+		selector: selector,
+		decoder:  serializer.NewCodecFactory(scheme.Scheme).UniversalDeserializer(),
+	}, nil
+}
+
+// Restore restores all matching objects of the snapshot keys into the vCluster
+func (s *selectiveRestorer) Restore(ctx context.Context, values map[string][]byte) *SelectiveRestoreResult {
+	objects := s.collect(values)
+
+	result := &SelectiveRestoreResult{}
+	for _, obj := range objects {
+		err := s.restoreObject(ctx, obj, result)
+		if err != nil {
+			klog.Errorf("Error restoring %s: %v", objectRef(obj), err)
+			result.Failed++
+		}
+	}
+
+	return result
+}
+
+// collect decodes all objects from the snapshot keys that match the filters, including the namespaces they live in
+func (s *selectiveRestorer) collect(values map[string][]byte) []*unstructured.Unstructured {
+	namespaces := map[string]*unstructured.Unstructured{}
+	objects := []*unstructured.Unstructured{}
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		if !strings.HasPrefix(key, "/registry/") || strings.HasPrefix(key, store.MappingsPrefix) || strings.HasPrefix(key, RequestStoreKey) {
+			continue
+		}
+
+		obj, err := decodeSnapshotObject(s.decoder, values[key])
+		if err != nil {
+			klog.V(1).Infof("Skip key %s that could not be decoded: %v", key, err)
+			continue
+		} else if obj.GetKind() == "Namespace" {
+			namespaces[obj.GetName()] = obj
+		}
+
+		if !s.matches(obj) {
+			continue
+		}
+
+		objects = append(objects, obj)
+	}
+
+	// make sure the namespaces of the restored objects are restored as well
+	for _, obj := range objects {
+		if obj.GetNamespace() == "" {
+			continue
+		}
+
+		namespace, ok := namespaces[obj.GetNamespace()]
+		if ok && !slices.Contains(objects, namespace) {
+			objects = append(objects, namespace)
+		}
+	}
+
+	sort.SliceStable(objects, func(i, j int) bool {
+		return priority(objects[i]) < priority(objects[j])
+	})
+	return objects
+}
+
+func (s *selectiveRestorer) matches(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	if isManagedObject(obj) {
+		return false
+	}
+
+	if len(s.options.Namespaces) > 0 {
+		namespace := obj.GetNamespace()
+		if gvk.Kind == "Namespace" && gvk.Group == "" {
+			namespace = obj.GetName()
+		}
+		if !slices.Contains(s.options.Namespaces, namespace) {
+			return false
+		}
+	}
+
+	if len(s.options.Kinds) > 0 && !slices.ContainsFunc(s.options.Kinds, func(kind string) bool {
+		return s.matchesKind(kind, gvk)
+	}) {
+		return false
+	}
+
+	return s.selector.Matches(labels.Set(obj.GetLabels()))
+}
+
+// matchesKind checks if the filter matches the kind or resource, optionally qualified by the group, e.g. deployments.apps
+func (s *selectiveRestorer) matchesKind(filter string, gvk schema.GroupVersionKind) bool {
+	name, group, hasGroup := strings.Cut(strings.ToLower(filter), ".")
+	if hasGroup && group != strings.ToLower(gvk.Group) {
+		return false
+	} else if name == strings.ToLower(gvk.Kind) {
+		return true
+	}
+
+	// fall back to the guessed resource if the kind is not known to the api server, e.g. for custom resources
+	resource, _ := meta.UnsafeGuessKindToResource(gvk)
+	mapping, err := s.client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err == nil {
+		resource = mapping.Resource
+	}
+
+	return name == resource.Resource
+}
+
+func (s *selectiveRestorer) restoreObject(ctx context.Context, obj *unstructured.Unstructured, result *SelectiveRestoreResult) error {
+	sanitizeRestoreObject(obj)
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(obj.GroupVersionKind())
+	err := s.client.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	} else if kerrors.IsNotFound(err) {
+		s.log("Create", obj, "")
+		err = s.create(ctx, obj)
+		if err != nil {
+			return err
+		}
+
+		result.Created++
+		return nil
+	}
+
+	switch s.options.Conflict {
+	case ConflictOverwrite:
+		s.log("Overwrite", obj, "")
+		if !s.options.DryRun {
+			keepImmutableFields(obj, existing)
+			obj.SetResourceVersion(existing.GetResourceVersion())
+			err = s.client.Update(ctx, obj)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Overwritten++
+		return nil
+	case ConflictRename:
+		// namespaces are reused as renaming them would require renaming all the objects within
+		if obj.GetNamespace() != "" {
+			newName, err := s.findFreeName(ctx, obj)
+			if err != nil {
+				return err
+			}
+
+			s.log("Rename", obj, newName)
+			obj.SetName(newName)
+			err = s.create(ctx, obj)
+			if err != nil {
+				return err
+			}
+
+			result.Renamed++
+			return nil
+		}
+	}
+
+	s.log("Skip existing", obj, "")
+	result.Skipped++
+	return nil
+}
+
+func (s *selectiveRestorer) findFreeName(ctx context.Context, obj *unstructured.Unstructured) (string, error) {
+	for i := 1; ; i++ {
+		name := obj.GetName() + renameSuffix
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d", name, i)
+		}
+
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(obj.GroupVersionKind())
+		err := s.client.Get(ctx, client.ObjectKey{Namespace: obj.GetNamespace(), Name: name}, existing)
+		if kerrors.IsNotFound(err) {
+			return name, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+}
+
+func (s *selectiveRestorer) create(ctx context.Context, obj *unstructured.Unstructured) error {
+	if s.options.DryRun {
+		return nil
+	}
+
+	return s.client.Create(ctx, obj)
+}
+
+func (s *selectiveRestorer) log(action string, obj *unstructured.Unstructured, newName string) {
+	message := action + " " + objectRef(obj)
+	if newName != "" {
+		message += " as " + newName
+	}
+	if s.options.DryRun {
+		message = "[dry-run] " + message
+	}
+
+	klog.Info(message)
+}
+
+// isManagedObject checks if the object is managed by the control plane or a controller and therefore
+// shouldn't be restored or compared
+func isManagedObject(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	if skippedRestoreKinds[gvk.GroupKind()] || obj.GetDeletionTimestamp() != nil {
+		return true
+	}
+
+	// owned objects are recreated by their controllers
+	if len(obj.GetOwnerReferences()) > 0 {
+		return true
+	}
+
+	// kube-root-ca.crt is managed by the control plane
+	return gvk.Kind == "ConfigMap" && obj.GetName() == "kube-root-ca.crt"
+}
+
+// stripServerFields removes the fields that are set by the api server
+func stripServerFields(obj *unstructured.Unstructured) {
+	obj.SetUID("")
+	obj.SetResourceVersion("")
+	obj.SetGeneration(0)
+	obj.SetSelfLink("")
+	obj.SetManagedFields(nil)
+	unstructured.RemoveNestedField(obj.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(obj.Object, "status")
+}
+
+// sanitizeRestoreObject removes the fields that are set by the api server or bound to the previous object
+func sanitizeRestoreObject(obj *unstructured.Unstructured) {
+	stripServerFields(obj)
+
+	switch obj.GroupVersionKind().GroupKind() {
+	case schema.GroupKind{Kind: "Service"}:
+		clusterIP, _, _ := unstructured.NestedString(obj.Object, "spec", "clusterIP")
+		if clusterIP != "None" {
+			unstructured.RemoveNestedField(obj.Object, "spec", "clusterIP")
+			unstructured.RemoveNestedField(obj.Object, "spec", "clusterIPs")
+		}
+	case schema.GroupKind{Kind: "PersistentVolumeClaim"}:
+		// the previously bound volume is most likely gone
+		unstructured.RemoveNestedField(obj.Object, "spec", "volumeName")
+	case schema.GroupKind{Kind: "Pod"}:
+		unstructured.RemoveNestedField(obj.Object, "spec", "nodeName")
+	case schema.GroupKind{Kind: "Job", Group: "batch"}:
+		// the selector is generated from the job uid
+		unstructured.RemoveNestedField(obj.Object, "spec", "selector")
+		for _, label := range []string{"controller-uid", "batch.kubernetes.io/controller-uid", "job-name", "batch.kubernetes.io/job-name"} {
+			unstructured.RemoveNestedField(obj.Object, "spec", "template", "metadata", "labels", label)
+		}
+	}
+}
+
+// keepImmutableFields copies the fields that were removed by sanitizeRestoreObject, but can't be changed
+// on an existing object, from the live object, e.g. the volume a PVC is already bound to
+func keepImmutableFields(obj, existing *unstructured.Unstructured) {
+	var fields [][]string
+	switch obj.GroupVersionKind().GroupKind() {
+	case schema.GroupKind{Kind: "Service"}:
+		fields = [][]string{{"spec", "clusterIP"}, {"spec", "clusterIPs"}}
+	case schema.GroupKind{Kind: "PersistentVolumeClaim"}:
+		fields = [][]string{{"spec", "volumeName"}}
+	}
+
+	for _, field := range fields {
+		value, found, err := unstructured.NestedFieldCopy(existing.Object, field...)
+		if err != nil || !found {
+			continue
+		}
+
+		_ = unstructured.SetNestedField(obj.Object, value, field...)
+	}
+}
+
+func decodeSnapshotObject(decoder runtime.Decoder, value []byte) (*unstructured.Unstructured, error) {
+	obj, gvk, err := decoder.Decode(value, nil, nil)
+	if err == nil {
+		raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		unstructuredObj := &unstructured.Unstructured{Object: raw}
+		unstructuredObj.SetGroupVersionKind(*gvk)
+		return unstructuredObj, nil
+	}
+
+	// custom resources are stored as json
+	unstructuredObj := &unstructured.Unstructured{}
+	jsonErr := unstructuredObj.UnmarshalJSON(value)
+	if jsonErr != nil {
+		return nil, err
+	}
+
+	return unstructuredObj, nil
+}
+
+func priority(obj *unstructured.Unstructured) int {
+	value, ok := restorePriority[obj.GetKind()]
+	if !ok {
+		return 5
+	}
+
+	return value
+}
+
+func objectRef(obj client.Object) string {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if obj.GetNamespace() == "" {
+		return kind + " " + obj.GetName()
+	}
+
+	return kind + " " + obj.GetNamespace() + "/" + obj.GetName()
+}
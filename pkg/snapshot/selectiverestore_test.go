@@ -0,0 +1,258 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/loft-sh/vcluster/pkg/scheme"
+	"gotest.tools/v3/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSelectiveRestore(t *testing.T) {
+	snapshotObjects := map[string]runtime.Object{
+		"/registry/namespaces/team-a": &corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a", UID: "1"},
+		},
+		"/registry/namespaces/team-b": &corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: "team-b", UID: "2"},
+		},
+		"/registry/configmaps/team-a/app": &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a", ResourceVersion: "10", Labels: map[string]string{"app": "web"}},
+			Data:       map[string]string{"version": "snapshot"},
+		},
+		"/registry/configmaps/team-a/kube-root-ca.crt": &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-root-ca.crt", Namespace: "team-a"},
+		},
+		"/registry/configmaps/team-b/other": &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-b"},
+		},
+		"/registry/deployments/team-a/web": &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a", Labels: map[string]string{"app": "web"}},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: ptr.To(int32(1)),
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			},
+		},
+		"/registry/replicasets/team-a/web-123": &appsv1.ReplicaSet{
+			TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "ReplicaSet"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web-123", Namespace: "team-a", Labels: map[string]string{"app": "web"}, OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "3"},
+			}},
+		},
+		"/registry/services/specs/team-a/web": &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a", Labels: map[string]string{"app": "web"}},
+			Spec:       corev1.ServiceSpec{ClusterIP: "10.96.0.15", ClusterIPs: []string{"10.96.0.15"}},
+		},
+	}
+
+	existingConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"},
+		Data:       map[string]string{"version": "current"},
+	}
+
+	tests := []struct {
+		name     string
+		options  SelectiveRestoreOptions
+		existing []client.Object
+		expected SelectiveRestoreResult
+
+		// expectedVersion is the version of the app config map after the restore
+		expectedVersion string
+		// expectedRenamed is the name of the renamed app config map
+		expectedRenamed string
+	}{
+		{
+			name:            "namespace",
+			options:         SelectiveRestoreOptions{Namespaces: []string{"team-a"}},
+			expected:        SelectiveRestoreResult{Created: 4},
+			expectedVersion: "snapshot",
+		},
+		{
+			name:            "kind with namespace",
+			options:         SelectiveRestoreOptions{Kinds: []string{"configmaps"}, Namespaces: []string{"team-a"}},
+			expected:        SelectiveRestoreResult{Created: 2},
+			expectedVersion: "snapshot",
+		},
+		{
+			name:     "qualified kind and selector",
+			options:  SelectiveRestoreOptions{Kinds: []string{"deployments.apps", "Service"}, Selector: "app=web"},
+			expected: SelectiveRestoreResult{Created: 3},
+		},
+		{
+			name:            "skip existing",
+			options:         SelectiveRestoreOptions{Namespaces: []string{"team-a"}, Kinds: []string{"ConfigMap"}, Conflict: ConflictSkip},
+			existing:        []client.Object{existingConfigMap.DeepCopy()},
+			expected:        SelectiveRestoreResult{Created: 1, Skipped: 1},
+			expectedVersion: "current",
+		},
+		{
+			name:            "overwrite existing",
+			options:         SelectiveRestoreOptions{Namespaces: []string{"team-a"}, Kinds: []string{"ConfigMap"}, Conflict: ConflictOverwrite},
+			existing:        []client.Object{existingConfigMap.DeepCopy()},
+			expected:        SelectiveRestoreResult{Created: 1, Overwritten: 1},
+			expectedVersion: "snapshot",
+		},
+		{
+			name:    "rename existing",
+			options: SelectiveRestoreOptions{Namespaces: []string{"team-a"}, Kinds: []string{"ConfigMap"}, Conflict: ConflictRename},
+			existing: []client.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+				existingConfigMap.DeepCopy(),
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app-restored", Namespace: "team-a"}},
+			},
+			expected:        SelectiveRestoreResult{Renamed: 1, Skipped: 1},
+			expectedVersion: "current",
+			expectedRenamed: "app-restored-2",
+		},
+		{
+			name:     "dry run",
+			options:  SelectiveRestoreOptions{Namespaces: []string{"team-a"}, Conflict: ConflictOverwrite, DryRun: true},
+			existing: []client.Object{existingConfigMap.DeepCopy()},
+			expected: SelectiveRestoreResult{Created: 3, Overwritten: 1},
+			// nothing changed
+			expectedVersion: "current",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(test.existing...).Build()
+			restorer, err := newSelectiveRestorer(kubeClient, &test.options)
+			assert.NilError(t, err)
+
+			result := restorer.Restore(ctx, readTestSnapshotValues(t, snapshotObjects))
+			assert.DeepEqual(t, *result, test.expected)
+
+			configMap := &corev1.ConfigMap{}
+			err = kubeClient.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app"}, configMap)
+			if test.expectedVersion == "" {
+				assert.Assert(t, err != nil)
+			} else {
+				assert.NilError(t, err)
+				assert.Equal(t, configMap.Data["version"], test.expectedVersion)
+			}
+
+			if test.expectedRenamed != "" {
+				err = kubeClient.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: test.expectedRenamed}, configMap)
+				assert.NilError(t, err)
+				assert.Equal(t, configMap.Data["version"], "snapshot")
+			}
+
+			// owned objects and other namespaces are never restored
+			err = kubeClient.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "web-123"}, &appsv1.ReplicaSet{})
+			assert.Assert(t, err != nil)
+			err = kubeClient.Get(ctx, client.ObjectKey{Namespace: "team-b", Name: "other"}, &corev1.ConfigMap{})
+			assert.Assert(t, err != nil)
+
+			// services get a new cluster ip
+			service := &corev1.Service{}
+			err = kubeClient.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "web"}, service)
+			if err == nil {
+				assert.Equal(t, service.Spec.ClusterIP, "")
+			}
+		})
+	}
+}
+
+func TestSelectiveRestoreOverwriteKeepsImmutableFields(t *testing.T) {
+	ctx := context.Background()
+	snapshotObjects := map[string]runtime.Object{
+		"/registry/persistentvolumeclaims/team-a/data": &corev1.PersistentVolumeClaim{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "team-a", Labels: map[string]string{"version": "snapshot"}},
+			Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-snapshot"},
+		},
+		"/registry/services/specs/team-a/web": &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a", Labels: map[string]string{"version": "snapshot"}},
+			Spec:       corev1.ServiceSpec{ClusterIP: "10.96.0.15", ClusterIPs: []string{"10.96.0.15"}},
+		},
+	}
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "team-a"},
+			Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-live"},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a"},
+			Spec:       corev1.ServiceSpec{ClusterIP: "10.96.0.20", ClusterIPs: []string{"10.96.0.20"}},
+		},
+	).Build()
+	restorer, err := newSelectiveRestorer(kubeClient, &SelectiveRestoreOptions{Kinds: []string{"PersistentVolumeClaim", "Service"}, Conflict: ConflictOverwrite})
+	assert.NilError(t, err)
+
+	result := restorer.Restore(ctx, readTestSnapshotValues(t, snapshotObjects))
+	assert.DeepEqual(t, *result, SelectiveRestoreResult{Overwritten: 2})
+
+	// the bound volume and cluster ip of the live objects are kept
+	pvc := &corev1.PersistentVolumeClaim{}
+	assert.NilError(t, kubeClient.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "data"}, pvc))
+	assert.Equal(t, pvc.Labels["version"], "snapshot")
+	assert.Equal(t, pvc.Spec.VolumeName, "pv-live")
+	service := &corev1.Service{}
+	assert.NilError(t, kubeClient.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "web"}, service))
+	assert.Equal(t, service.Labels["version"], "snapshot")
+	assert.Equal(t, service.Spec.ClusterIP, "10.96.0.20")
+	assert.DeepEqual(t, service.Spec.ClusterIPs, []string{"10.96.0.20"})
+}
+
+func TestSelectiveRestoreOptionsValidate(t *testing.T) {
+	assert.NilError(t, (&SelectiveRestoreOptions{Conflict: ConflictSkip}).Validate())
+	assert.NilError(t, (&SelectiveRestoreOptions{Namespaces: []string{"default"}, Conflict: ConflictRename, DryRun: true}).Validate())
+	assert.ErrorContains(t, (&SelectiveRestoreOptions{DryRun: true}).Validate(), "--dry-run requires")
+	assert.ErrorContains(t, (&SelectiveRestoreOptions{Conflict: ConflictOverwrite}).Validate(), "--conflict requires")
+	assert.ErrorContains(t, (&SelectiveRestoreOptions{Namespaces: []string{"default"}, Conflict: "merge"}).Validate(), "unsupported conflict mode")
+	assert.ErrorContains(t, (&SelectiveRestoreOptions{Selector: "app in (web"}).Validate(), "parse selector")
+}
+
+// readTestSnapshotValues writes a snapshot with the given objects and returns its keys
+func readTestSnapshotValues(t *testing.T, objects map[string]runtime.Object) map[string][]byte {
+	values := map[string][]byte{}
+	_, _, err := readArchiveValues(&Archive{Reader: tar.NewReader(writeTestSnapshot(t, objects, nil))}, values)
+	assert.NilError(t, err)
+	return values
+}
+
+func writeTestSnapshot(t *testing.T, objects map[string]runtime.Object, keys map[string][]byte) *bytes.Buffer {
+	encoder := protobuf.NewSerializer(scheme.Scheme, scheme.Scheme)
+	buf := &bytes.Buffer{}
+	tarWriter := tar.NewWriter(buf)
+	for key, obj := range objects {
+		value := &bytes.Buffer{}
+		assert.NilError(t, encoder.Encode(obj, value))
+		assert.NilError(t, tarWriter.WriteHeader(&tar.Header{Name: key, Size: int64(value.Len()), Mode: 0666}))
+		_, err := tarWriter.Write(value.Bytes())
+		assert.NilError(t, err)
+	}
+
+	// custom resources are stored as json
+	customResource := []byte(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"widget","namespace":"team-b"}}`)
+	assert.NilError(t, tarWriter.WriteHeader(&tar.Header{Name: "/registry/example.com/widgets/team-b/widget", Size: int64(len(customResource)), Mode: 0666}))
+	_, err := tarWriter.Write(customResource)
+	assert.NilError(t, err)
+
+	for key, value := range keys {
+		assert.NilError(t, writeKeyValue(tarWriter, []byte(key), value))
+	}
+
+	assert.NilError(t, tarWriter.Close())
+	return buf
+}
@@ -2,10 +2,18 @@ package types
 
 import (
 	"context"
+	"errors"
 	"io"
 	"time"
 )
 
+// BaseReferenceSuffix is appended to the name of an incremental snapshot to store its base reference
+// in a small object next to it. These objects are not listed as snapshots.
+const BaseReferenceSuffix = ".base"
+
+// ErrObjectNotFound is returned by Storage.GetObject if the object does not exist
+var ErrObjectNotFound = errors.New("object not found")
+
 type Snapshot struct {
 	ID        string    `json:"id"`
 	URL       string    `json:"url"`
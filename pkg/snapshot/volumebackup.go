@@ -0,0 +1,446 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/snapshot/encryption"
+	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// errKeySecretNotSupported is returned by the secret loader of the volume backup helper pods, as they
+// run without access to the vCluster host namespace
+var errKeySecretNotSupported = errors.New("encryption key secrets are not supported for file level volume backups, please use a passphrase or age recipients")
+
+// VolumeBackupURL returns the url the file level backup of a PersistentVolumeClaim is stored at. Volume
+// backups are stored next to the etcd snapshot, e.g. the backups of s3://my-bucket/my-snapshot.tar.gz
+// are stored at s3://my-bucket/my-snapshot-volumes/NAMESPACE/NAME.tar.gz.
+func VolumeBackupURL(options *Options, pvc types.NamespacedName) (string, error) {
+	switch options.Type {
+	case "s3", "gs", "azblob":
+	default:
+		return "", fmt.Errorf("file level volume backups are not supported for %s snapshots, please use s3, gs or azblob", options.Type)
+	}
+
+	return strings.TrimSuffix(options.GetURL(), ".tar.gz") + "-volumes/" + pvc.Namespace + "/" + pvc.Name + ".tar.gz", nil
+}
+
+// EncodeVolumeBackupOptions encodes the snapshot options for the VCLUSTER_STORAGE_OPTIONS environment
+// variable of a volume backup helper pod.
+func EncodeVolumeBackupOptions(options *Options) (string, error) {
+	if options.Encryption.KeySecret != "" {
+		return "", errKeySecretNotSupported
+	}
+
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return "", fmt.Errorf("marshal snapshot options: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(optionsJSON), nil
+}
+
+// BackupVolume writes the files below dir as tar archive to the backup url
+func BackupVolume(ctx context.Context, options *Options, backupURL, dir string) error {
+	volumeOptions, err := options.withURL(backupURL)
+	if err != nil {
+		return err
+	}
+	objectStore, err := CreateStore(ctx, volumeOptions)
+	if err != nil {
+		return fmt.Errorf("failed to create store: %w", err)
+	}
+
+	// stream the archive to the object store
+	errChan := make(chan error)
+	reader, writer := io.Pipe()
+	go func() {
+		err := objectStore.PutObject(ctx, reader)
+		_ = reader.CloseWithError(err)
+		errChan <- err
+	}()
+
+	err = writeVolumeArchive(writer, volumeOptions, dir)
+	_ = writer.CloseWithError(err)
+	putErr := <-errChan
+	if err != nil {
+		return err
+	} else if putErr != nil {
+		return fmt.Errorf("failed to upload volume backup: %w", putErr)
+	}
+
+	klog.Infof("Successfully backed up volume %s to %s", dir, objectStore.Target())
+	return nil
+}
+
+func writeVolumeArchive(writer io.Writer, options *Options, dir string) error {
+	// optionally encrypt the backup
+	var encryptedWriter io.WriteCloser = nopWriteCloser{Writer: writer}
+	if options.Encryption.Enabled() {
+		var err error
+		encryptedWriter, err = encryption.NewWriter(writer, &options.Encryption, volumeBackupSecretLoader)
+		if err != nil {
+			return fmt.Errorf("failed to create encryption writer: %w", err)
+		}
+	}
+
+	gzipWriter, _ := gzip.NewWriterLevel(encryptedWriter, 3)
+	tarWriter := tar.NewWriter(gzipWriter)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if path == dir {
+			return nil
+		}
+
+		return writeVolumeFile(tarWriter, dir, path, entry)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive volume %s: %w", dir, err)
+	}
+
+	// close the writers in order to flush all data
+	err = tarWriter.Close()
+	if err != nil {
+		return err
+	}
+	err = gzipWriter.Close()
+	if err != nil {
+		return err
+	}
+	err = encryptedWriter.Close()
+	if err != nil {
+		return fmt.Errorf("failed to finish volume backup encryption: %w", err)
+	}
+
+	return nil
+}
+
+func writeVolumeFile(tarWriter *tar.Writer, dir, path string, entry fs.DirEntry) error {
+	info, err := entry.Info()
+	if err != nil {
+		return err
+	}
+
+	// only directories, regular files and symlinks are backed up
+	linkTarget := ""
+	switch {
+	case info.Mode().IsDir(), info.Mode().IsRegular():
+	case info.Mode()&fs.ModeSymlink != 0:
+		linkTarget, err = os.Readlink(path)
+		if err != nil {
+			return err
+		}
+	default:
+		klog.Infof("Skip backing up %s with unsupported file mode %s", path, info.Mode())
+		return nil
+	}
+
+	header, err := tar.FileInfoHeader(info, linkTarget)
+	if err != nil {
+		return err
+	}
+	relativePath, err := filepath.Rel(dir, path)
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(relativePath)
+	err = tarWriter.WriteHeader(header)
+	if err != nil {
+		return err
+	} else if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(tarWriter, file)
+	return err
+}
+
+// RestoreVolume extracts the volume backup at the backup url into dir
+func RestoreVolume(ctx context.Context, options *Options, backupURL, dir string) error {
+	volumeOptions, err := options.withURL(backupURL)
+	if err != nil {
+		return err
+	}
+	objectStore, err := CreateStore(ctx, volumeOptions)
+	if err != nil {
+		return fmt.Errorf("failed to create store: %w", err)
+	}
+
+	archive, err := OpenArchive(ctx, objectStore, volumeOptions, volumeBackupSecretLoader)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	restoreOwnership := os.Geteuid() == 0
+	for {
+		header, err := archive.Reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read volume backup: %w", err)
+		}
+
+		err = restoreVolumeFile(archive.Reader, dir, header, restoreOwnership)
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %w", header.Name, err)
+		}
+	}
+
+	klog.Infof("Successfully restored volume %s from %s", dir, objectStore.Target())
+	return nil
+}
+
+func restoreVolumeFile(reader io.Reader, dir string, header *tar.Header, restoreOwnership bool) error {
+	// make sure we never write outside of the volume
+	target := filepath.Join(dir, filepath.FromSlash(header.Name))
+	if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+		return fmt.Errorf("path is outside of the volume")
+	}
+	err := checkNoSymlinkParents(dir, target)
+	if err != nil {
+		return err
+	}
+
+	// existing symlinks are replaced instead of followed, existing files are replaced by symlinks
+	info, err := os.Lstat(target)
+	if err == nil && (info.Mode()&fs.ModeSymlink != 0 || (header.Typeflag == tar.TypeSymlink && !info.IsDir())) {
+		err = os.Remove(target)
+		if err != nil {
+			return err
+		}
+	}
+
+	mode := header.FileInfo().Mode()
+	switch header.Typeflag {
+	case tar.TypeDir:
+		err = os.MkdirAll(target, 0755)
+		if err != nil {
+			return err
+		}
+		err = os.Chmod(target, mode.Perm())
+	case tar.TypeReg:
+		err = restoreRegularFile(reader, target, mode)
+	case tar.TypeSymlink:
+		err = os.MkdirAll(filepath.Dir(target), 0755)
+		if err != nil {
+			return err
+		}
+		err = os.Symlink(header.Linkname, target)
+	default:
+		klog.Infof("Skip restoring %s with unsupported type %c", header.Name, header.Typeflag)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if restoreOwnership {
+		err = os.Lchown(target, header.Uid, header.Gid)
+		if err != nil {
+			return err
+		}
+	}
+	if header.Typeflag == tar.TypeReg {
+		return os.Chtimes(target, header.AccessTime, header.ModTime)
+	}
+
+	return nil
+}
+
+func restoreRegularFile(reader io.Reader, target string, mode fs.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, reader)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	// the mode of an existing file is not changed by open
+	return os.Chmod(target, mode.Perm())
+}
+
+// checkNoSymlinkParents makes sure a previously restored symlink can't be used to write outside of the volume
+func checkNoSymlinkParents(dir, target string) error {
+	relativePath, err := filepath.Rel(dir, filepath.Dir(target))
+	if err != nil || relativePath == "." {
+		return err
+	}
+
+	current := dir
+	for _, segment := range strings.Split(relativePath, string(os.PathSeparator)) {
+		current = filepath.Join(current, segment)
+		info, err := os.Lstat(current)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		} else if info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("parent directory %s is a symlink", current)
+		}
+	}
+
+	return nil
+}
+
+// DeleteVolumeBackups deletes the file level volume backups of a snapshot request
+func DeleteVolumeBackups(ctx context.Context, options *Options, status volumes.SnapshotsStatus) error {
+	var errs []error
+	for pvcName, volumeBackupStatus := range status.Snapshots {
+		if volumeBackupStatus.SnapshotHandle == "" {
+			continue
+		}
+
+		volumeOptions, err := options.withURL(volumeBackupStatus.SnapshotHandle)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		objectStore, err := CreateStore(ctx, volumeOptions)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to create store: %w", err))
+			continue
+		}
+		err = objectStore.Delete(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete volume backup of PVC %s: %w", pvcName, err))
+			continue
+		}
+
+		klog.Infof("Deleted volume backup of PVC %s at %s", pvcName, objectStore.Target())
+	}
+
+	return errors.Join(errs...)
+}
+
+func volumeBackupSecretLoader(_, _ string) ([]byte, error) {
+	return nil, errKeySecretNotSupported
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// volumeBackupPodConfig implements the filebackup.PodConfig interface for the snapshot and restore requests.
+type volumeBackupPodConfig struct {
+	vConfig          *config.VirtualClusterConfig
+	requestsClient   client.Client
+	requestNamespace string
+	hostReader       client.Reader
+}
+
+func newVolumeBackupPodConfig(vConfig *config.VirtualClusterConfig, requestsClient client.Client, requestNamespace string, hostReader client.Reader) *volumeBackupPodConfig {
+	return &volumeBackupPodConfig{
+		vConfig:          vConfig,
+		requestsClient:   requestsClient,
+		requestNamespace: requestNamespace,
+		hostReader:       hostReader,
+	}
+}
+
+// Image returns the configured helper pod image, or the image of the vCluster syncer container.
+func (p *volumeBackupPodConfig) Image(ctx context.Context) (string, error) {
+	if p.vConfig.Snapshots.Volumes.FileBackup.Image != "" {
+		return p.vConfig.Snapshots.Volumes.FileBackup.Image, nil
+	}
+
+	podName := os.Getenv("POD_NAME")
+	if podName == "" {
+		return "", errors.New("cannot determine the vCluster image, please set snapshots.volumes.fileBackup.image")
+	}
+	var pod corev1.Pod
+	err := p.hostReader.Get(ctx, types.NamespacedName{Namespace: p.vConfig.HostNamespace, Name: podName}, &pod)
+	if err != nil {
+		return "", fmt.Errorf("failed to get vCluster pod to determine the vCluster image, please set snapshots.volumes.fileBackup.image: %w", err)
+	}
+	for _, container := range pod.Spec.Containers {
+		if container.Name == "syncer" {
+			return container.Image, nil
+		}
+	}
+
+	return "", fmt.Errorf("vCluster pod %s/%s has no syncer container, please set snapshots.volumes.fileBackup.image", pod.Namespace, pod.Name)
+}
+
+// StorageOptions returns the encoded snapshot options from the Secret of the snapshot or restore request.
+func (p *volumeBackupPodConfig) StorageOptions(ctx context.Context, requestName string) (string, error) {
+	var secret corev1.Secret
+	err := p.requestsClient.Get(ctx, types.NamespacedName{Namespace: p.requestNamespace, Name: requestName}, &secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to get request Secret %s: %w", requestName, err)
+	}
+	options, err := unmarshalRequestOptions(&secret)
+	if err != nil {
+		return "", err
+	}
+
+	return EncodeVolumeBackupOptions(options)
+}
+
+// BackupURL returns the url of the PVC volume backup, which is stored next to the etcd snapshot.
+func (p *volumeBackupPodConfig) BackupURL(ctx context.Context, requestName string, pvc types.NamespacedName) (string, error) {
+	var secret corev1.Secret
+	err := p.requestsClient.Get(ctx, types.NamespacedName{Namespace: p.requestNamespace, Name: requestName}, &secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to get snapshot request Secret %s: %w", requestName, err)
+	}
+	options, err := unmarshalRequestOptions(&secret)
+	if err != nil {
+		return "", err
+	}
+
+	return VolumeBackupURL(options, pvc)
+}
+
+// unmarshalRequestOptions reads the snapshot options from the Secret of a snapshot or a restore request
+func unmarshalRequestOptions(secret *corev1.Secret) (*Options, error) {
+	optionsJSON, ok := secret.Data[OptionsKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s does not have the snapshot options", secret.Namespace, secret.Name)
+	}
+	var options Options
+	err := json.Unmarshal(optionsJSON, &options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot options: %w", err)
+	}
+
+	return &options, nil
+}
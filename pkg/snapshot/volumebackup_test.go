@@ -0,0 +1,162 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loft-sh/vcluster/pkg/snapshot/encryption"
+	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestVolumeBackupRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source")
+	assert.NilError(t, os.MkdirAll(filepath.Join(source, "nested", "empty"), 0755))
+	assert.NilError(t, os.WriteFile(filepath.Join(source, "file.txt"), []byte("hello"), 0644))
+	assert.NilError(t, os.WriteFile(filepath.Join(source, "nested", "script.sh"), []byte("#!/bin/sh"), 0755))
+	assert.NilError(t, os.Symlink("nested/script.sh", filepath.Join(source, "link")))
+
+	options := &Options{}
+	assert.NilError(t, Parse("container://"+filepath.Join(dir, "snapshot.tar.gz"), options))
+	options.Encryption = encryption.Options{Passphrase: "secret"}
+	backupURL := "container://" + filepath.Join(dir, "snapshot-volumes", "default", "data.tar.gz")
+	assert.NilError(t, BackupVolume(ctx, options, backupURL, source))
+
+	// restore into a volume with an existing file that is overwritten
+	target := filepath.Join(dir, "target")
+	assert.NilError(t, os.MkdirAll(target, 0755))
+	assert.NilError(t, os.WriteFile(filepath.Join(target, "file.txt"), []byte("outdated content"), 0600))
+	assert.NilError(t, RestoreVolume(ctx, options, backupURL, target))
+
+	content, err := os.ReadFile(filepath.Join(target, "file.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "hello")
+	info, err := os.Stat(filepath.Join(target, "file.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0644))
+	info, err = os.Stat(filepath.Join(target, "nested", "script.sh"))
+	assert.NilError(t, err)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0755))
+	info, err = os.Stat(filepath.Join(target, "nested", "empty"))
+	assert.NilError(t, err)
+	assert.Assert(t, info.IsDir())
+	linkTarget, err := os.Readlink(filepath.Join(target, "link"))
+	assert.NilError(t, err)
+	assert.Equal(t, linkTarget, "nested/script.sh")
+
+	// the backup can't be restored without the passphrase
+	options.Encryption = encryption.Options{}
+	assert.Assert(t, RestoreVolume(ctx, options, backupURL, filepath.Join(dir, "other")) != nil)
+}
+
+func TestRestoreVolumeFileOutsideOfVolume(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(dir, "outside")
+	volume := filepath.Join(dir, "volume")
+	assert.NilError(t, os.MkdirAll(outside, 0755))
+	assert.NilError(t, os.MkdirAll(volume, 0755))
+
+	err := restoreVolumeFile(strings.NewReader("x"), volume, &tar.Header{Name: "../outside/file", Typeflag: tar.TypeReg, Mode: 0644, Size: 1}, false)
+	assert.ErrorContains(t, err, "outside of the volume")
+
+	// a restored symlink can't be used to write outside of the volume
+	err = restoreVolumeFile(nil, volume, &tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: outside, Mode: 0777}, false)
+	assert.NilError(t, err)
+	err = restoreVolumeFile(strings.NewReader("x"), volume, &tar.Header{Name: "link/file", Typeflag: tar.TypeReg, Mode: 0644, Size: 1}, false)
+	assert.ErrorContains(t, err, "is a symlink")
+	_, err = os.Stat(filepath.Join(outside, "file"))
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestVolumeBackupURL(t *testing.T) {
+	pvc := types.NamespacedName{Namespace: "default", Name: "data"}
+
+	options := &Options{}
+	assert.NilError(t, Parse("s3://my-bucket/snapshots/snap-1.tar.gz", options))
+	backupURL, err := VolumeBackupURL(options, pvc)
+	assert.NilError(t, err)
+	assert.Equal(t, backupURL, "s3://my-bucket/snapshots/snap-1-volumes/default/data.tar.gz")
+
+	options = &Options{}
+	assert.NilError(t, Parse("oci://ghcr.io/my-user/my-repo:snap-1", options))
+	_, err = VolumeBackupURL(options, pvc)
+	assert.ErrorContains(t, err, "not supported for oci snapshots")
+
+	_, err = EncodeVolumeBackupOptions(&Options{Encryption: encryption.Options{KeySecret: "my-key"}})
+	assert.ErrorIs(t, err, errKeySecretNotSupported)
+}
+
+func TestCombineVolumesPhases(t *testing.T) {
+	testCases := []struct {
+		name            string
+		results         []volumesResult
+		expectedPhase   volumes.SnapshotRequestPhase
+		expectedMessage string
+	}{
+		{
+			name: "request without volume backups",
+			results: []volumesResult{
+				{phase: volumes.RequestPhaseCompleted, requests: 2},
+				{phase: volumes.RequestPhaseNotStarted},
+			},
+			expectedPhase: volumes.RequestPhaseCompleted,
+		},
+		{
+			name: "volume backups still in progress",
+			results: []volumesResult{
+				{phase: volumes.RequestPhaseCompleted, requests: 2},
+				{phase: volumes.RequestPhaseInProgress, requests: 1},
+			},
+			expectedPhase: volumes.RequestPhaseInProgress,
+		},
+		{
+			name: "no volumes at all",
+			results: []volumesResult{
+				{phase: volumes.RequestPhaseCompleted},
+				{phase: volumes.RequestPhaseCompleted},
+			},
+			expectedPhase: volumes.RequestPhaseCompleted,
+		},
+		{
+			name: "only volume snapshots failed",
+			results: []volumesResult{
+				{phase: volumes.RequestPhaseFailed, message: "snapshots failed", requests: 1},
+				{phase: volumes.RequestPhaseCompleted},
+			},
+			expectedPhase:   volumes.RequestPhaseFailed,
+			expectedMessage: "snapshots failed",
+		},
+		{
+			name: "volume backups failed",
+			results: []volumesResult{
+				{phase: volumes.RequestPhaseCompleted, requests: 1},
+				{phase: volumes.RequestPhaseFailed, message: "backups failed", requests: 1},
+			},
+			expectedPhase:   volumes.RequestPhasePartiallyFailed,
+			expectedMessage: "backups failed",
+		},
+		{
+			name: "restore skipped",
+			results: []volumesResult{
+				{phase: volumes.RequestPhaseCompleted, requests: 1},
+				{phase: volumes.RequestPhaseSkipped, requests: 1},
+			},
+			expectedPhase: volumes.RequestPhaseCompleted,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			phase, message := combineVolumesPhases(testCase.results...)
+			assert.Equal(t, phase, testCase.expectedPhase)
+			assert.Equal(t, message, testCase.expectedMessage)
+		})
+	}
+}
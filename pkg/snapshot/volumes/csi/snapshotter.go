@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	snapshotsv1 "github.com/kubernetes-csi/external-snapshotter/client/v8/clientset/versioned"
 	"github.com/loft-sh/vcluster/pkg/config"
@@ -79,6 +80,9 @@ func (s *VolumeSnapshotter) CheckIfPersistentVolumeIsSupported(pv *corev1.Persis
 	if !managedByCSIDriver {
 		return fmt.Errorf("specified PersistentVolume is not managed by the CSI driver: %w", volumes.ErrPersistentVolumeNotSupported)
 	}
+	if slices.Contains(s.vConfig.Snapshots.Volumes.FileBackup.StorageClasses, pv.Spec.StorageClassName) {
+		return fmt.Errorf("storage class %q of the specified PersistentVolume is backed up on file level: %w", pv.Spec.StorageClassName, volumes.ErrPersistentVolumeNotSupported)
+	}
 
 	return nil
 }
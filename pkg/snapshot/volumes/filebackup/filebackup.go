@@ -0,0 +1,305 @@
+package filebackup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
+	"github.com/loft-sh/vcluster/pkg/util/loghelper"
+	"github.com/loft-sh/vcluster/pkg/util/translate"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	persistentVolumeClaimNameLabel = "vcluster.loft.sh/persistentvolumeclaim"
+	optionsKey                     = "options"
+	dataPath                       = "/data"
+	tmpPath                        = "/tmp"
+)
+
+// PodConfig provides the parts of the helper pods that depend on the snapshot or restore request.
+type PodConfig interface {
+	// Image returns the image of the helper pods.
+	Image(ctx context.Context) (string, error)
+
+	// StorageOptions returns the snapshot options of the request, encoded for the VCLUSTER_STORAGE_OPTIONS
+	// environment variable.
+	StorageOptions(ctx context.Context, requestName string) (string, error)
+
+	// BackupURL returns the url the backup of the PersistentVolumeClaim is stored at.
+	BackupURL(ctx context.Context, requestName string, pvc types.NamespacedName) (string, error)
+}
+
+// podHandler runs the helper pods that back up and restore the volume files.
+type podHandler struct {
+	kubeClient     kubernetes.Interface
+	eventRecorder  record.EventRecorder
+	logger         loghelper.Logger
+	podConfig      PodConfig
+	storageClasses []string
+	runAsUser      int64
+}
+
+func newPodHandler(vConfig *config.VirtualClusterConfig, kubeClient kubernetes.Interface, podConfig PodConfig, eventRecorder record.EventRecorder, logger loghelper.Logger) (podHandler, error) {
+	if vConfig == nil {
+		return podHandler{}, errors.New("virtual cluster config is required")
+	}
+	if kubeClient == nil {
+		return podHandler{}, errors.New("kubernetes client is required")
+	}
+	if podConfig == nil {
+		return podHandler{}, errors.New("pod config is required")
+	}
+	if eventRecorder == nil {
+		return podHandler{}, errors.New("event recorder is required")
+	}
+	if logger == nil {
+		return podHandler{}, errors.New("logger is required")
+	}
+
+	return podHandler{
+		kubeClient:     kubeClient,
+		eventRecorder:  eventRecorder,
+		logger:         logger,
+		podConfig:      podConfig,
+		storageClasses: vConfig.Snapshots.Volumes.FileBackup.StorageClasses,
+		runAsUser:      vConfig.Snapshots.Volumes.FileBackup.RunAsUser,
+	}, nil
+}
+
+// CheckIfPersistentVolumeIsSupported checks if the volume belongs to a storage class that is backed up
+// on file level.
+func (h *podHandler) CheckIfPersistentVolumeIsSupported(pv *corev1.PersistentVolume) error {
+	hasPersistentVolumeClaim := pv.Spec.ClaimRef != nil &&
+		pv.Spec.ClaimRef.Name != "" &&
+		pv.Spec.ClaimRef.Namespace != ""
+	if !hasPersistentVolumeClaim {
+		return fmt.Errorf("specified PersistentVolume does not have a PersistentVolumeClaim set: %w", volumes.ErrPersistentVolumeNotSupported)
+	}
+	if !slices.Contains(h.storageClasses, pv.Spec.StorageClassName) {
+		return fmt.Errorf("storage class %q of the specified PersistentVolume is not backed up on file level: %w", pv.Spec.StorageClassName, volumes.ErrPersistentVolumeNotSupported)
+	}
+
+	return nil
+}
+
+// runHelperPod creates the helper pod and its Secret if they don't exist yet, and returns the helper pod.
+func (h *podHandler) runHelperPod(ctx context.Context, requestLabel, requestName, command, backupURL string, pvcName types.NamespacedName) (*corev1.Pod, error) {
+	podName := helperPodName(command, pvcName.Name, requestName)
+	pod, err := h.kubeClient.CoreV1().Pods(pvcName.Namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err == nil {
+		return pod, nil
+	} else if !kerrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get helper pod %s/%s: %w", pvcName.Namespace, podName, err)
+	}
+
+	image, err := h.podConfig.Image(ctx)
+	if err != nil {
+		return nil, err
+	}
+	storageOptions, err := h.podConfig.StorageOptions(ctx, requestName)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{
+		requestLabel:                   requestName,
+		persistentVolumeClaimNameLabel: pvcName.Name,
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: pvcName.Namespace,
+			Name:      podName,
+			Labels:    labels,
+		},
+		StringData: map[string]string{
+			optionsKey: storageOptions,
+		},
+	}
+	_, err = h.kubeClient.CoreV1().Secrets(pvcName.Namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if err != nil && !kerrors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create helper pod Secret %s/%s: %w", pvcName.Namespace, podName, err)
+	}
+
+	readOnly := command == "backup"
+	pod = &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: pvcName.Namespace,
+			Name:      podName,
+			Labels:    labels,
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                corev1.RestartPolicyNever,
+			AutomountServiceAccountToken: ptr.To(false),
+			Containers: []corev1.Container{
+				{
+					Name:    "volume-" + command,
+					Image:   image,
+					Command: []string{"/vcluster", "snapshot", "volume", command, "--url", backupURL, "--path", dataPath},
+					// the last log lines contain the error in case the backup or restore fails
+					TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+					SecurityContext:          h.helperSecurityContext(command),
+					Env: []corev1.EnvVar{
+						{
+							Name: "VCLUSTER_STORAGE_OPTIONS",
+							ValueFrom: &corev1.EnvVarSource{
+								SecretKeyRef: &corev1.SecretKeySelector{
+									LocalObjectReference: corev1.LocalObjectReference{Name: podName},
+									Key:                  optionsKey,
+								},
+							},
+						},
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "data",
+							MountPath: dataPath,
+							ReadOnly:  readOnly,
+						},
+						{
+							Name:      "tmp",
+							MountPath: tmpPath,
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "data",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: pvcName.Name,
+							ReadOnly:  readOnly,
+						},
+					},
+				},
+				{
+					Name: "tmp",
+					VolumeSource: corev1.VolumeSource{
+						EmptyDir: &corev1.EmptyDirVolumeSource{},
+					},
+				},
+			},
+		},
+	}
+	pod, err = h.kubeClient.CoreV1().Pods(pvcName.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create helper pod for PersistentVolumeClaim %s: %w", pvcName, err)
+	}
+	h.logger.Infof("Created helper pod %s/%s to %s PersistentVolumeClaim %s", pod.Namespace, pod.Name, command, pvcName)
+
+	return pod, nil
+}
+
+// helperSecurityContext returns the security context of the helper pod container. The helper pods run as root by
+// default, as the files of a volume can be owned by any user, but only keep the capabilities to read all files for
+// a backup and to restore the file ownership and modes for a restore.
+func (h *podHandler) helperSecurityContext(command string) *corev1.SecurityContext {
+	securityContext := &corev1.SecurityContext{
+		AllowPrivilegeEscalation: ptr.To(false),
+		ReadOnlyRootFilesystem:   ptr.To(true),
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+	if h.runAsUser != 0 {
+		securityContext.RunAsNonRoot = ptr.To(true)
+		securityContext.RunAsUser = ptr.To(h.runAsUser)
+		return securityContext
+	}
+
+	securityContext.RunAsUser = ptr.To(int64(0))
+	if command == "backup" {
+		securityContext.Capabilities.Add = []corev1.Capability{"DAC_READ_SEARCH"}
+	} else {
+		securityContext.Capabilities.Add = []corev1.Capability{"CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID"}
+	}
+
+	return securityContext
+}
+
+// deleteHelperPod deletes the helper pod and its Secret, it returns true once both are gone.
+func (h *podHandler) deleteHelperPod(ctx context.Context, requestName, command string, pvcName types.NamespacedName) (bool, error) {
+	podName := helperPodName(command, pvcName.Name, requestName)
+	err := h.kubeClient.CoreV1().Secrets(pvcName.Namespace).Delete(ctx, podName, metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to delete helper pod Secret %s/%s: %w", pvcName.Namespace, podName, err)
+	}
+
+	err = h.kubeClient.CoreV1().Pods(pvcName.Namespace).Delete(ctx, podName, metav1.DeleteOptions{})
+	if kerrors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to delete helper pod %s/%s: %w", pvcName.Namespace, podName, err)
+	}
+
+	// wait until the pod is gone, so the volume is not mounted anymore
+	return false, nil
+}
+
+// helperPodError returns the reason why the helper pod has failed.
+func helperPodError(pod *corev1.Pod) error {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.State.Terminated != nil && containerStatus.State.Terminated.Message != "" {
+			return fmt.Errorf("helper pod %s/%s has failed: %s", pod.Namespace, pod.Name, containerStatus.State.Terminated.Message)
+		} else if containerStatus.State.Terminated != nil {
+			return fmt.Errorf("helper pod %s/%s has failed with exit code %d, please check its logs", pod.Namespace, pod.Name, containerStatus.State.Terminated.ExitCode)
+		}
+	}
+
+	return fmt.Errorf("helper pod %s/%s has failed: %s", pod.Namespace, pod.Name, pod.Status.Message)
+}
+
+func helperPodName(command, pvcName, requestName string) string {
+	return translate.SafeConcatName("volume", command, pvcName, requestName)
+}
+
+// volumesPhase collects the phases of the single volumes to determine the phase of the whole request.
+type volumesPhase struct {
+	inProgress bool
+	completed  bool
+	skipped    bool
+	failed     int
+}
+
+func (p *volumesPhase) add(phase volumes.SnapshotRequestPhase) {
+	switch phase {
+	case volumes.RequestPhaseCompleted:
+		p.completed = true
+	case volumes.RequestPhaseSkipped:
+		p.skipped = true
+	case volumes.RequestPhaseFailed:
+		p.failed++
+	default:
+		p.inProgress = true
+	}
+}
+
+func (p *volumesPhase) result(total int) (volumes.SnapshotRequestPhase, string) {
+	switch {
+	case p.inProgress:
+		return volumes.RequestPhaseInProgress, ""
+	case p.completed && p.failed > 0:
+		return volumes.RequestPhasePartiallyFailed, fmt.Sprintf("%d out of %d volumes have failed", p.failed, total)
+	case p.completed:
+		return volumes.RequestPhaseCompleted, ""
+	case p.failed > 0:
+		return volumes.RequestPhaseFailed, "all volumes have failed"
+	case p.skipped:
+		return volumes.RequestPhaseSkipped, ""
+	default:
+		return volumes.RequestPhaseCompleted, ""
+	}
+}
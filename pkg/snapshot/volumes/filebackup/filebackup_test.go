@@ -0,0 +1,236 @@
+package filebackup
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
+	"github.com/loft-sh/vcluster/pkg/util/loghelper"
+	testingutil "github.com/loft-sh/vcluster/pkg/util/testing"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+)
+
+const testStorageClass = "local-path"
+
+type fakePodConfig struct {
+	err error
+}
+
+func (f *fakePodConfig) Image(context.Context) (string, error) {
+	return "vcluster:test", f.err
+}
+
+func (f *fakePodConfig) StorageOptions(context.Context, string) (string, error) {
+	return "options", f.err
+}
+
+func (f *fakePodConfig) BackupURL(_ context.Context, requestName string, pvc types.NamespacedName) (string, error) {
+	return "s3://bucket/" + requestName + "/" + pvc.Namespace + "/" + pvc.Name + ".tar.gz", f.err
+}
+
+func newTestConfig(runAsUser int64) *config.VirtualClusterConfig {
+	vConfig := testingutil.NewFakeConfig()
+	vConfig.Snapshots.Volumes.FileBackup.StorageClasses = []string{testStorageClass}
+	vConfig.Snapshots.Volumes.FileBackup.RunAsUser = runAsUser
+	return vConfig
+}
+
+func newTestPodHandler(t *testing.T, kubeClient kubernetes.Interface, runAsUser int64) podHandler {
+	t.Helper()
+
+	handler, err := newPodHandler(newTestConfig(runAsUser), kubeClient, &fakePodConfig{}, record.NewFakeRecorder(100), loghelper.New("filebackup-test"))
+	assert.NilError(t, err)
+	return handler
+}
+
+// setHelperPodPhase sets the phase of the helper pod, like the kubelet would once the pod has finished.
+func setHelperPodPhase(t *testing.T, kubeClient kubernetes.Interface, command, requestName string, pvcName types.NamespacedName, phase corev1.PodPhase, message string) {
+	t.Helper()
+
+	pod, err := kubeClient.CoreV1().Pods(pvcName.Namespace).Get(context.Background(), helperPodName(command, pvcName.Name, requestName), metav1.GetOptions{})
+	assert.NilError(t, err)
+	pod.Status.Phase = phase
+	if message != "" {
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: message}},
+		}}
+	}
+	_, err = kubeClient.CoreV1().Pods(pvcName.Namespace).UpdateStatus(context.Background(), pod, metav1.UpdateOptions{})
+	assert.NilError(t, err)
+}
+
+// assertHelperPodDeleted checks that the helper pod and its Secret are gone.
+func assertHelperPodDeleted(t *testing.T, kubeClient kubernetes.Interface, command, requestName string, pvcName types.NamespacedName) {
+	t.Helper()
+
+	podName := helperPodName(command, pvcName.Name, requestName)
+	pods, err := kubeClient.CoreV1().Pods(pvcName.Namespace).List(context.Background(), metav1.ListOptions{})
+	assert.NilError(t, err)
+	for _, pod := range pods.Items {
+		assert.Assert(t, pod.Name != podName, "helper pod %s was not deleted", podName)
+	}
+	secrets, err := kubeClient.CoreV1().Secrets(pvcName.Namespace).List(context.Background(), metav1.ListOptions{})
+	assert.NilError(t, err)
+	for _, secret := range secrets.Items {
+		assert.Assert(t, secret.Name != podName, "helper pod Secret %s was not deleted", podName)
+	}
+}
+
+func TestRunHelperPod(t *testing.T) {
+	pvcName := types.NamespacedName{Namespace: "test", Name: "data"}
+
+	t.Run("backup as root", func(t *testing.T) {
+		kubeClient := fake.NewSimpleClientset()
+		handler := newTestPodHandler(t, kubeClient, 0)
+		pod, err := handler.runHelperPod(context.Background(), "request", "snapshot-1", "backup", "s3://bucket/data.tar.gz", pvcName)
+		assert.NilError(t, err)
+
+		container := pod.Spec.Containers[0]
+		assert.DeepEqual(t, container.Command, []string{"/vcluster", "snapshot", "volume", "backup", "--url", "s3://bucket/data.tar.gz", "--path", dataPath})
+		assert.Assert(t, container.VolumeMounts[0].ReadOnly)
+		assert.Assert(t, pod.Spec.Volumes[0].PersistentVolumeClaim.ReadOnly)
+		assert.DeepEqual(t, container.SecurityContext, &corev1.SecurityContext{
+			RunAsUser:                ptr.To(int64(0)),
+			AllowPrivilegeEscalation: ptr.To(false),
+			ReadOnlyRootFilesystem:   ptr.To(true),
+			Capabilities: &corev1.Capabilities{
+				Drop: []corev1.Capability{"ALL"},
+				Add:  []corev1.Capability{"DAC_READ_SEARCH"},
+			},
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		})
+		assert.Equal(t, *pod.Spec.AutomountServiceAccountToken, false)
+
+		secret, err := kubeClient.CoreV1().Secrets(pvcName.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, secret.StringData[optionsKey], "options")
+
+		// an existing helper pod is reused
+		existing, err := handler.runHelperPod(context.Background(), "request", "snapshot-1", "backup", "s3://bucket/other.tar.gz", pvcName)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, existing.Spec.Containers[0].Command, container.Command)
+	})
+
+	t.Run("restore as non-root user", func(t *testing.T) {
+		handler := newTestPodHandler(t, fake.NewSimpleClientset(), 1000)
+		pod, err := handler.runHelperPod(context.Background(), "request", "restore-1", "restore", "s3://bucket/data.tar.gz", pvcName)
+		assert.NilError(t, err)
+
+		container := pod.Spec.Containers[0]
+		assert.Assert(t, !container.VolumeMounts[0].ReadOnly)
+		assert.Equal(t, *container.SecurityContext.RunAsNonRoot, true)
+		assert.Equal(t, *container.SecurityContext.RunAsUser, int64(1000))
+		assert.DeepEqual(t, container.SecurityContext.Capabilities, &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}})
+	})
+
+	t.Run("restore as root", func(t *testing.T) {
+		handler := newTestPodHandler(t, fake.NewSimpleClientset(), 0)
+		pod, err := handler.runHelperPod(context.Background(), "request", "restore-1", "restore", "s3://bucket/data.tar.gz", pvcName)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, pod.Spec.Containers[0].SecurityContext.Capabilities.Add, []corev1.Capability{"CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID"})
+	})
+
+	t.Run("pod config error", func(t *testing.T) {
+		kubeClient := fake.NewSimpleClientset()
+		handler := newTestPodHandler(t, kubeClient, 0)
+		handler.podConfig = &fakePodConfig{err: errors.New("no image")}
+		_, err := handler.runHelperPod(context.Background(), "request", "snapshot-1", "backup", "s3://bucket/data.tar.gz", pvcName)
+		assert.ErrorContains(t, err, "no image")
+		assertHelperPodDeleted(t, kubeClient, "backup", "snapshot-1", pvcName)
+	})
+}
+
+func TestDeleteHelperPod(t *testing.T) {
+	pvcName := types.NamespacedName{Namespace: "test", Name: "data"}
+	kubeClient := fake.NewSimpleClientset()
+	handler := newTestPodHandler(t, kubeClient, 0)
+	_, err := handler.runHelperPod(context.Background(), "request", "snapshot-1", "backup", "s3://bucket/data.tar.gz", pvcName)
+	assert.NilError(t, err)
+
+	// the first call deletes the pod and waits until it's gone
+	deleted, err := handler.deleteHelperPod(context.Background(), "snapshot-1", "backup", pvcName)
+	assert.NilError(t, err)
+	assert.Assert(t, !deleted)
+	assertHelperPodDeleted(t, kubeClient, "backup", "snapshot-1", pvcName)
+
+	deleted, err = handler.deleteHelperPod(context.Background(), "snapshot-1", "backup", pvcName)
+	assert.NilError(t, err)
+	assert.Assert(t, deleted)
+}
+
+func TestHelperPodError(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "helper"}}
+	pod.Status.Message = "evicted"
+	assert.Error(t, helperPodError(pod), "helper pod test/helper has failed: evicted")
+
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 2}},
+	}}
+	assert.Error(t, helperPodError(pod), "helper pod test/helper has failed with exit code 2, please check its logs")
+
+	pod.Status.ContainerStatuses[0].State.Terminated.Message = "access denied"
+	assert.Error(t, helperPodError(pod), "helper pod test/helper has failed: access denied")
+}
+
+func TestVolumesPhase(t *testing.T) {
+	testCases := []struct {
+		name            string
+		phases          []volumes.SnapshotRequestPhase
+		expectedPhase   volumes.SnapshotRequestPhase
+		expectedMessage string
+	}{
+		{
+			name:          "no volumes",
+			expectedPhase: volumes.RequestPhaseCompleted,
+		},
+		{
+			name:          "in progress",
+			phases:        []volumes.SnapshotRequestPhase{volumes.RequestPhaseCompleted, volumes.RequestPhaseCompletedCleaningUp},
+			expectedPhase: volumes.RequestPhaseInProgress,
+		},
+		{
+			name:          "completed",
+			phases:        []volumes.SnapshotRequestPhase{volumes.RequestPhaseCompleted, volumes.RequestPhaseSkipped},
+			expectedPhase: volumes.RequestPhaseCompleted,
+		},
+		{
+			name:            "partially failed",
+			phases:          []volumes.SnapshotRequestPhase{volumes.RequestPhaseCompleted, volumes.RequestPhaseFailed},
+			expectedPhase:   volumes.RequestPhasePartiallyFailed,
+			expectedMessage: "1 out of 2 volumes have failed",
+		},
+		{
+			name:            "failed",
+			phases:          []volumes.SnapshotRequestPhase{volumes.RequestPhaseFailed, volumes.RequestPhaseSkipped},
+			expectedPhase:   volumes.RequestPhaseFailed,
+			expectedMessage: "all volumes have failed",
+		},
+		{
+			name:          "skipped",
+			phases:        []volumes.SnapshotRequestPhase{volumes.RequestPhaseSkipped},
+			expectedPhase: volumes.RequestPhaseSkipped,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			phase := volumesPhase{}
+			for _, p := range testCase.phases {
+				phase.add(p)
+			}
+
+			resultPhase, message := phase.result(len(testCase.phases))
+			assert.Equal(t, resultPhase, testCase.expectedPhase)
+			assert.Equal(t, message, testCase.expectedMessage)
+		})
+	}
+}
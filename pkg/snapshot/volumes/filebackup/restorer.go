@@ -0,0 +1,201 @@
+package filebackup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/constants"
+	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
+	"github.com/loft-sh/vcluster/pkg/util/loghelper"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+)
+
+// Restorer is a volumes.Restorer interface implementation that recreates PersistentVolumeClaims and
+// repopulates them from their file level backups with helper pods.
+type Restorer struct {
+	podHandler
+}
+
+// NewRestorer creates a new instance of the file level volume restorer.
+func NewRestorer(vConfig *config.VirtualClusterConfig, kubeClient kubernetes.Interface, podConfig PodConfig, eventRecorder record.EventRecorder, logger loghelper.Logger) (*Restorer, error) {
+	handler, err := newPodHandler(vConfig, kubeClient, podConfig, eventRecorder, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Restorer{
+		podHandler: handler,
+	}, nil
+}
+
+// Reconcile volume backups restore request.
+func (r *Restorer) Reconcile(ctx context.Context, requestObj runtime.Object, requestName string, request *volumes.RestoreRequestSpec, status *volumes.RestoreRequestStatus) error {
+	r.logger.Debugf("Restore volume backups for restore request %s", requestName)
+
+	switch status.Phase {
+	case volumes.RequestPhaseNotStarted:
+		status.Phase = volumes.RequestPhaseInProgress
+		fallthrough
+	case volumes.RequestPhaseInProgress:
+		r.reconcileInProgress(ctx, requestObj, requestName, request, status)
+	case volumes.RequestPhaseCompleted,
+		volumes.RequestPhasePartiallyFailed,
+		volumes.RequestPhaseFailed,
+		volumes.RequestPhaseSkipped:
+		r.logger.Debugf("Finished restoring volume backups for restore request %s, final status is %s", requestName, status.Phase)
+	default:
+		return fmt.Errorf("invalid restore request phase: %s", status.Phase)
+	}
+
+	return nil
+}
+
+func (r *Restorer) reconcileInProgress(ctx context.Context, requestObj runtime.Object, requestName string, request *volumes.RestoreRequestSpec, status *volumes.RestoreRequestStatus) {
+	if status.PersistentVolumeClaims == nil {
+		status.PersistentVolumeClaims = map[string]volumes.RestoreStatus{}
+	}
+
+	phase := volumesPhase{}
+	for _, volumeRestoreRequest := range request.Requests {
+		pvcName := types.NamespacedName{
+			Namespace: volumeRestoreRequest.PersistentVolumeClaim.Namespace,
+			Name:      volumeRestoreRequest.PersistentVolumeClaim.Name,
+		}
+		volumeRestoreStatus := status.PersistentVolumeClaims[pvcName.String()]
+
+		switch volumeRestoreStatus.Phase {
+		case volumes.RequestPhaseNotStarted:
+			volumeRestoreStatus.Phase = volumes.RequestPhaseInProgress
+			fallthrough
+		case volumes.RequestPhaseInProgress:
+			volumeRestoreStatus = r.reconcileInProgressPVC(ctx, requestName, volumeRestoreRequest, volumeRestoreStatus)
+		case volumes.RequestPhaseCompletedCleaningUp,
+			volumes.RequestPhaseFailedCleaningUp:
+			deleted, err := r.deleteHelperPod(ctx, requestName, "restore", pvcName)
+			if err == nil && deleted && volumeRestoreStatus.Phase == volumes.RequestPhaseCompletedCleaningUp {
+				err = r.removeRestoreRequestLabel(ctx, pvcName)
+			}
+			if err != nil {
+				volumeRestoreStatus.Phase = volumes.RequestPhaseFailed
+				volumeRestoreStatus.Error.Message = err.Error()
+			} else if deleted {
+				volumeRestoreStatus.Phase = volumeRestoreStatus.Phase.Next()
+			}
+			r.volumeRestoreFinished(requestObj, pvcName, volumeRestoreStatus)
+		}
+
+		status.PersistentVolumeClaims[pvcName.String()] = volumeRestoreStatus
+		phase.add(volumeRestoreStatus.Phase)
+	}
+
+	status.Phase, status.Error.Message = phase.result(len(request.Requests))
+}
+
+func (r *Restorer) reconcileInProgressPVC(ctx context.Context, requestName string, volumeRestoreRequest volumes.RestoreRequest, volumeRestoreStatus volumes.RestoreStatus) volumes.RestoreStatus {
+	failed := func(err error) volumes.RestoreStatus {
+		volumeRestoreStatus.Phase = volumes.RequestPhaseFailedCleaningUp
+		volumeRestoreStatus.Error.Message = err.Error()
+		return volumeRestoreStatus
+	}
+
+	// First, check if the PVC already exists. PVCs that were created by this restore request are
+	// still being populated.
+	originalPVC := volumeRestoreRequest.PersistentVolumeClaim
+	pvcName := types.NamespacedName{
+		Namespace: originalPVC.Namespace,
+		Name:      originalPVC.Name,
+	}
+	pvc, err := r.kubeClient.CoreV1().PersistentVolumeClaims(pvcName.Namespace).Get(ctx, pvcName.Name, metav1.GetOptions{})
+	if err == nil && pvc.Labels[constants.RestoreRequestLabel] != requestName {
+		volumeRestoreStatus.Phase = volumes.RequestPhaseSkipped
+		r.eventRecorder.Eventf(pvc, corev1.EventTypeNormal, "VolumeRestoreSkipped", "Skipped restoring PersistentVolumeClaim %s, since it already exists", pvcName)
+		return volumeRestoreStatus
+	} else if kerrors.IsNotFound(err) {
+		err = r.createPersistentVolumeClaim(ctx, requestName, originalPVC)
+		if err != nil {
+			return failed(err)
+		}
+	} else if err != nil {
+		return failed(fmt.Errorf("failed to get PVC %s: %w", pvcName, err))
+	}
+
+	// the helper pod mounts the new PVC, so it also gets bound in case of WaitForFirstConsumer
+	pod, err := r.runHelperPod(ctx, constants.RestoreRequestLabel, requestName, "restore", volumeRestoreRequest.SnapshotHandle, pvcName)
+	if err != nil {
+		return failed(err)
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		volumeRestoreStatus.Phase = volumes.RequestPhaseCompletedCleaningUp
+	case corev1.PodFailed:
+		return failed(helperPodError(pod))
+	}
+
+	return volumeRestoreStatus
+}
+
+func (r *Restorer) createPersistentVolumeClaim(ctx context.Context, requestName string, originalPVC corev1.PersistentVolumeClaim) error {
+	labels := map[string]string{}
+	for key, value := range originalPVC.Labels {
+		labels[key] = value
+	}
+	labels[constants.RestoreRequestLabel] = requestName
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        originalPVC.Name,
+			Namespace:   originalPVC.Namespace,
+			Annotations: originalPVC.Annotations,
+			Labels:      labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      originalPVC.Spec.AccessModes,
+			Selector:         originalPVC.Spec.Selector,
+			Resources:        originalPVC.Spec.Resources,
+			StorageClassName: originalPVC.Spec.StorageClassName,
+			VolumeMode:       originalPVC.Spec.VolumeMode,
+		},
+	}
+	pvc, err := r.kubeClient.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(ctx, pvc, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create PersistentVolumeClaim %s/%s: %w", originalPVC.Namespace, originalPVC.Name, err)
+	}
+
+	r.logger.Infof("Created PersistentVolumeClaim %s/%s to restore its volume backup", pvc.Namespace, pvc.Name)
+	return nil
+}
+
+// removeRestoreRequestLabel removes the label that marks the PVC as being populated by the restore request.
+func (r *Restorer) removeRestoreRequestLabel(ctx context.Context, pvcName types.NamespacedName) error {
+	pvc, err := r.kubeClient.CoreV1().PersistentVolumeClaims(pvcName.Namespace).Get(ctx, pvcName.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get PVC %s: %w", pvcName, err)
+	} else if _, ok := pvc.Labels[constants.RestoreRequestLabel]; !ok {
+		return nil
+	}
+
+	delete(pvc.Labels, constants.RestoreRequestLabel)
+	_, err = r.kubeClient.CoreV1().PersistentVolumeClaims(pvcName.Namespace).Update(ctx, pvc, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update PVC %s: %w", pvcName, err)
+	}
+
+	return nil
+}
+
+func (r *Restorer) volumeRestoreFinished(requestObj runtime.Object, pvcName types.NamespacedName, volumeRestoreStatus volumes.RestoreStatus) {
+	switch volumeRestoreStatus.Phase {
+	case volumes.RequestPhaseCompleted:
+		r.eventRecorder.Eventf(requestObj, corev1.EventTypeNormal, "VolumeRestored", "Restored files of PersistentVolumeClaim %s from its volume backup", pvcName)
+	case volumes.RequestPhaseFailed:
+		r.eventRecorder.Eventf(requestObj, corev1.EventTypeWarning, "VolumeRestoreFailed", "Failed to restore files of PersistentVolumeClaim %s: %s", pvcName, volumeRestoreStatus.Error.Message)
+	}
+}
@@ -0,0 +1,122 @@
+package filebackup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/loft-sh/vcluster/pkg/constants"
+	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
+	"github.com/loft-sh/vcluster/pkg/util/loghelper"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func newTestRestorer(t *testing.T, objects ...*corev1.PersistentVolumeClaim) (*Restorer, *fake.Clientset) {
+	t.Helper()
+
+	kubeClient := fake.NewSimpleClientset()
+	for _, pvc := range objects {
+		_, err := kubeClient.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(context.Background(), pvc, metav1.CreateOptions{})
+		assert.NilError(t, err)
+	}
+
+	restorer, err := NewRestorer(newTestConfig(0), kubeClient, &fakePodConfig{}, record.NewFakeRecorder(100), loghelper.New("filebackup-test"))
+	assert.NilError(t, err)
+	return restorer, kubeClient
+}
+
+func newTestRestoreRequest(pvc *corev1.PersistentVolumeClaim) *volumes.RestoreRequestSpec {
+	return &volumes.RestoreRequestSpec{
+		Requests: []volumes.RestoreRequest{
+			{
+				PersistentVolumeClaim: *pvc,
+				SnapshotHandle:        "s3://bucket/snapshot-1/test/" + pvc.Name + ".tar.gz",
+			},
+		},
+	}
+}
+
+func TestRestorer(t *testing.T) {
+	ctx := context.Background()
+	requestObj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "restore-1"}}
+	pvcName := types.NamespacedName{Namespace: "test", Name: "data"}
+
+	t.Run("restore succeeds", func(t *testing.T) {
+		restorer, kubeClient := newTestRestorer(t)
+		request := newTestRestoreRequest(newTestPVC("data", "pv-data", testStorageClass))
+		status := &volumes.RestoreRequestStatus{}
+
+		// the PVC is recreated without its volume and populated by the helper pod
+		assert.NilError(t, restorer.Reconcile(ctx, requestObj, "restore-1", request, status))
+		assert.Equal(t, status.Phase, volumes.RequestPhaseInProgress)
+		assert.Equal(t, status.PersistentVolumeClaims[pvcName.String()].Phase, volumes.RequestPhaseInProgress)
+		pvc, err := kubeClient.CoreV1().PersistentVolumeClaims("test").Get(ctx, "data", metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, pvc.Spec.VolumeName, "")
+		assert.Equal(t, pvc.Labels[constants.RestoreRequestLabel], "restore-1")
+		pod, err := kubeClient.CoreV1().Pods("test").Get(ctx, helperPodName("restore", "data", "restore-1"), metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, pod.Spec.Volumes[0].PersistentVolumeClaim.ClaimName, "data")
+
+		// the PVC created by the restore request is still being populated
+		assert.NilError(t, restorer.Reconcile(ctx, requestObj, "restore-1", request, status))
+		assert.Equal(t, status.PersistentVolumeClaims[pvcName.String()].Phase, volumes.RequestPhaseInProgress)
+
+		// the helper pod has finished and is cleaned up
+		setHelperPodPhase(t, kubeClient, "restore", "restore-1", pvcName, corev1.PodSucceeded, "")
+		assert.NilError(t, restorer.Reconcile(ctx, requestObj, "restore-1", request, status))
+		assert.Equal(t, status.PersistentVolumeClaims[pvcName.String()].Phase, volumes.RequestPhaseCompletedCleaningUp)
+		assert.NilError(t, restorer.Reconcile(ctx, requestObj, "restore-1", request, status))
+		assertHelperPodDeleted(t, kubeClient, "restore", "restore-1", pvcName)
+		assert.Equal(t, status.Phase, volumes.RequestPhaseInProgress)
+		assert.NilError(t, restorer.Reconcile(ctx, requestObj, "restore-1", request, status))
+		assert.Equal(t, status.PersistentVolumeClaims[pvcName.String()].Phase, volumes.RequestPhaseCompleted)
+		assert.Equal(t, status.Phase, volumes.RequestPhaseCompleted)
+		assertEvent(t, restorer.eventRecorder, "VolumeRestored")
+
+		// the PVC is not marked as being restored anymore
+		pvc, err = kubeClient.CoreV1().PersistentVolumeClaims("test").Get(ctx, "data", metav1.GetOptions{})
+		assert.NilError(t, err)
+		_, ok := pvc.Labels[constants.RestoreRequestLabel]
+		assert.Assert(t, !ok)
+	})
+
+	t.Run("restore fails", func(t *testing.T) {
+		restorer, kubeClient := newTestRestorer(t)
+		request := newTestRestoreRequest(newTestPVC("data", "pv-data", testStorageClass))
+		status := &volumes.RestoreRequestStatus{}
+		assert.NilError(t, restorer.Reconcile(ctx, requestObj, "restore-1", request, status))
+
+		// the failed helper pod is cleaned up as well
+		setHelperPodPhase(t, kubeClient, "restore", "restore-1", pvcName, corev1.PodFailed, "snapshot not found")
+		assert.NilError(t, restorer.Reconcile(ctx, requestObj, "restore-1", request, status))
+		assert.Equal(t, status.PersistentVolumeClaims[pvcName.String()].Phase, volumes.RequestPhaseFailedCleaningUp)
+		assert.NilError(t, restorer.Reconcile(ctx, requestObj, "restore-1", request, status))
+		assert.NilError(t, restorer.Reconcile(ctx, requestObj, "restore-1", request, status))
+		assertHelperPodDeleted(t, kubeClient, "restore", "restore-1", pvcName)
+		assert.Equal(t, status.PersistentVolumeClaims[pvcName.String()].Phase, volumes.RequestPhaseFailed)
+		assert.Equal(t, status.PersistentVolumeClaims[pvcName.String()].Error.Message, "helper pod test/"+helperPodName("restore", "data", "restore-1")+" has failed: snapshot not found")
+		assert.Equal(t, status.Phase, volumes.RequestPhaseFailed)
+		assertEvent(t, restorer.eventRecorder, "VolumeRestoreFailed")
+	})
+
+	t.Run("existing PVC is skipped", func(t *testing.T) {
+		restorer, kubeClient := newTestRestorer(t, newTestPVC("data", "pv-data", testStorageClass))
+		request := newTestRestoreRequest(newTestPVC("data", "pv-data", testStorageClass))
+		status := &volumes.RestoreRequestStatus{}
+		assert.NilError(t, restorer.Reconcile(ctx, requestObj, "restore-1", request, status))
+		assert.Equal(t, status.PersistentVolumeClaims[pvcName.String()].Phase, volumes.RequestPhaseSkipped)
+		assert.Equal(t, status.Phase, volumes.RequestPhaseSkipped)
+		assertHelperPodDeleted(t, kubeClient, "restore", "restore-1", pvcName)
+	})
+
+	t.Run("invalid phase", func(t *testing.T) {
+		restorer, _ := newTestRestorer(t)
+		err := restorer.Reconcile(ctx, requestObj, "restore-1", &volumes.RestoreRequestSpec{}, &volumes.RestoreRequestStatus{Phase: volumes.RequestPhaseCanceling})
+		assert.ErrorContains(t, err, "invalid restore request phase")
+	})
+}
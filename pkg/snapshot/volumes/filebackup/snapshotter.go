@@ -0,0 +1,254 @@
+package filebackup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/loft-sh/vcluster/pkg/config"
+	"github.com/loft-sh/vcluster/pkg/constants"
+	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
+	"github.com/loft-sh/vcluster/pkg/util/loghelper"
+	"github.com/loft-sh/vcluster/pkg/util/translate"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+)
+
+// VolumeSnapshotter is a volumes.Snapshotter interface implementation that backs up the files of
+// volumes without CSI snapshot support with helper pods.
+type VolumeSnapshotter struct {
+	podHandler
+	vConfig *config.VirtualClusterConfig
+}
+
+// NewVolumeSnapshotter creates a new instance of the file level volume snapshotter.
+func NewVolumeSnapshotter(vConfig *config.VirtualClusterConfig, kubeClient kubernetes.Interface, podConfig PodConfig, eventRecorder record.EventRecorder, logger loghelper.Logger) (*VolumeSnapshotter, error) {
+	handler, err := newPodHandler(vConfig, kubeClient, podConfig, eventRecorder, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VolumeSnapshotter{
+		podHandler: handler,
+		vConfig:    vConfig,
+	}, nil
+}
+
+func (s *VolumeSnapshotter) Reconcile(ctx context.Context, requestObj runtime.Object, requestName string, request *volumes.SnapshotsRequest, status *volumes.SnapshotsStatus) error {
+	s.logger.Debugf("Reconcile volume backups for snapshot request %s", requestName)
+	defer s.logger.Debugf("Reconciled volume backups for snapshot request %s", requestName)
+	var err error
+
+	switch status.Phase {
+	case volumes.RequestPhaseNotStarted:
+		err = s.reconcileNotStarted(ctx, requestName, request, status)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile new volume backups request %s: %w", requestName, err)
+		}
+	case volumes.RequestPhaseInProgress:
+		err = s.reconcileInProgress(ctx, requestObj, requestName, request, status)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile volume backups request %s: %w", requestName, err)
+		}
+	case volumes.RequestPhaseCompleted,
+		volumes.RequestPhasePartiallyFailed,
+		volumes.RequestPhaseFailed,
+		volumes.RequestPhaseCanceled,
+		volumes.RequestPhaseDeleted,
+		volumes.RequestPhaseSkipped:
+		s.logger.Debugf("Finished reconciling volume backups request %s, final status is %s", requestName, status.Phase)
+	case volumes.RequestPhaseDeleting,
+		volumes.RequestPhaseCanceling:
+		err = s.reconcileDeleting(ctx, requestName, request, status)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile %s volume backups request %s: %w", status.Phase, requestName, err)
+		}
+	default:
+		return fmt.Errorf("invalid snapshot request phase: %s", status.Phase)
+	}
+
+	return nil
+}
+
+// Cleanup does nothing, as the helper pods are deleted as soon as the volume backup has finished.
+func (s *VolumeSnapshotter) Cleanup(_ context.Context) error {
+	return nil
+}
+
+func (s *VolumeSnapshotter) reconcileNotStarted(ctx context.Context, requestName string, request *volumes.SnapshotsRequest, status *volumes.SnapshotsStatus) error {
+	if len(s.storageClasses) == 0 {
+		request.Requests = nil
+		status.Snapshots = map[string]volumes.SnapshotStatus{}
+		status.Phase = volumes.RequestPhaseInProgress
+		return nil
+	}
+
+	// for private nodes, we need to get all PVCs in the virtual cluster
+	// for share nodes, we need to get only PVCs in the vCluster host namespace
+	var pvcsNamespace string
+	var listOptions metav1.ListOptions
+	if !s.vConfig.PrivateNodes.Enabled {
+		pvcsNamespace = s.vConfig.HostNamespace
+		listOptions = metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s", translate.MarkerLabel, s.vConfig.Name),
+		}
+	}
+	pvcs, err := s.kubeClient.CoreV1().PersistentVolumeClaims(pvcsNamespace).List(ctx, listOptions)
+	if err != nil {
+		return fmt.Errorf("failed to list PersistentVolumeClaims: %w", err)
+	}
+
+	var volumeBackupRequests []volumes.SnapshotRequest
+	for _, pvc := range pvcs.Items {
+		if pvc.Spec.VolumeName == "" {
+			// PVC is not bound to a PV, skip it
+			continue
+		}
+		pv, err := s.kubeClient.CoreV1().PersistentVolumes().Get(ctx, pvc.Spec.VolumeName, metav1.GetOptions{})
+		if err != nil {
+			s.logger.Errorf("failed to get PersistentVolume %s for PersistentVolumeClaim %s/%s: %v", pvc.Spec.VolumeName, pvc.Namespace, pvc.Name, err)
+			continue
+		}
+		if s.CheckIfPersistentVolumeIsSupported(pv) != nil {
+			continue
+		}
+
+		pvcCopy := pvc.DeepCopy()
+		delete(pvcCopy.Annotations, "kubectl.kubernetes.io/last-applied-configuration")
+		delete(pvcCopy.Annotations, "pv.kubernetes.io/bind-completed")
+		delete(pvcCopy.Annotations, "pv.kubernetes.io/bound-by-controller")
+		delete(pvcCopy.Annotations, "volume.beta.kubernetes.io/storage-provisioner")
+		delete(pvcCopy.Annotations, "volume.kubernetes.io/storage-provisioner")
+		pvcCopy.ManagedFields = nil
+		pvcCopy.Status = corev1.PersistentVolumeClaimStatus{}
+		volumeBackupRequests = append(volumeBackupRequests, volumes.SnapshotRequest{
+			PersistentVolumeClaim: *pvcCopy,
+		})
+	}
+
+	request.Requests = volumeBackupRequests
+	status.Snapshots = map[string]volumes.SnapshotStatus{}
+	status.Phase = volumes.RequestPhaseInProgress
+	return nil
+}
+
+func (s *VolumeSnapshotter) reconcileInProgress(ctx context.Context, requestObj runtime.Object, requestName string, request *volumes.SnapshotsRequest, status *volumes.SnapshotsStatus) error {
+	if len(request.Requests) == 0 {
+		status.Phase = volumes.RequestPhaseCompleted
+		return nil
+	}
+	if status.Snapshots == nil {
+		status.Snapshots = map[string]volumes.SnapshotStatus{}
+	}
+
+	phase := volumesPhase{}
+	for _, volumeBackupRequest := range request.Requests {
+		pvcName := types.NamespacedName{
+			Namespace: volumeBackupRequest.PersistentVolumeClaim.Namespace,
+			Name:      volumeBackupRequest.PersistentVolumeClaim.Name,
+		}
+		volumeBackupStatus := status.Snapshots[pvcName.String()]
+
+		switch volumeBackupStatus.Phase {
+		case volumes.RequestPhaseNotStarted:
+			volumeBackupStatus.Phase = volumes.RequestPhaseInProgress
+			fallthrough
+		case volumes.RequestPhaseInProgress:
+			volumeBackupStatus = s.reconcileInProgressPVC(ctx, requestName, pvcName, volumeBackupStatus)
+		case volumes.RequestPhaseCompletedCleaningUp,
+			volumes.RequestPhaseFailedCleaningUp:
+			deleted, err := s.deleteHelperPod(ctx, requestName, "backup", pvcName)
+			if err != nil {
+				volumeBackupStatus.Phase = volumes.RequestPhaseFailed
+				volumeBackupStatus.Error.Message = err.Error()
+			} else if deleted {
+				volumeBackupStatus.Phase = volumeBackupStatus.Phase.Next()
+			}
+			s.volumeBackupFinished(requestObj, pvcName, volumeBackupStatus)
+		}
+
+		status.Snapshots[pvcName.String()] = volumeBackupStatus
+		phase.add(volumeBackupStatus.Phase)
+	}
+
+	status.Phase, status.Error.Message = phase.result(len(request.Requests))
+	if status.Phase == volumes.RequestPhaseFailed || status.Phase == volumes.RequestPhasePartiallyFailed {
+		s.eventRecorder.Event(requestObj, corev1.EventTypeWarning, "VolumeBackups"+string(status.Phase), status.Error.Message)
+	}
+	return nil
+}
+
+func (s *VolumeSnapshotter) reconcileInProgressPVC(ctx context.Context, requestName string, pvcName types.NamespacedName, volumeBackupStatus volumes.SnapshotStatus) volumes.SnapshotStatus {
+	failed := func(err error) volumes.SnapshotStatus {
+		volumeBackupStatus.Phase = volumes.RequestPhaseFailedCleaningUp
+		volumeBackupStatus.Error.Message = err.Error()
+		return volumeBackupStatus
+	}
+
+	// remember where the backup is stored, so it can be restored and deleted later on
+	if volumeBackupStatus.SnapshotHandle == "" {
+		backupURL, err := s.podConfig.BackupURL(ctx, requestName, pvcName)
+		if err != nil {
+			return failed(err)
+		}
+		volumeBackupStatus.SnapshotHandle = backupURL
+	}
+
+	pod, err := s.runHelperPod(ctx, constants.SnapshotRequestLabel, requestName, "backup", volumeBackupStatus.SnapshotHandle, pvcName)
+	if err != nil {
+		return failed(err)
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		volumeBackupStatus.Phase = volumes.RequestPhaseCompletedCleaningUp
+	case corev1.PodFailed:
+		return failed(helperPodError(pod))
+	}
+
+	return volumeBackupStatus
+}
+
+func (s *VolumeSnapshotter) volumeBackupFinished(requestObj runtime.Object, pvcName types.NamespacedName, volumeBackupStatus volumes.SnapshotStatus) {
+	switch volumeBackupStatus.Phase {
+	case volumes.RequestPhaseCompleted:
+		s.eventRecorder.Eventf(requestObj, corev1.EventTypeNormal, "VolumeBackupCreated", "Backed up files of PVC %s to %s", pvcName, volumeBackupStatus.SnapshotHandle)
+	case volumes.RequestPhaseFailed:
+		s.eventRecorder.Eventf(requestObj, corev1.EventTypeWarning, "VolumeBackupFailed", "Failed to back up files of PVC %s: %s", pvcName, volumeBackupStatus.Error.Message)
+	}
+}
+
+// reconcileDeleting deletes the helper pods of a canceled or deleted snapshot request. The backups in the
+// storage are deleted together with the etcd backup.
+func (s *VolumeSnapshotter) reconcileDeleting(ctx context.Context, requestName string, request *volumes.SnapshotsRequest, status *volumes.SnapshotsStatus) error {
+	stillDeleting := false
+	for _, volumeBackupRequest := range request.Requests {
+		pvcName := types.NamespacedName{
+			Namespace: volumeBackupRequest.PersistentVolumeClaim.Namespace,
+			Name:      volumeBackupRequest.PersistentVolumeClaim.Name,
+		}
+		volumeBackupStatus, ok := status.Snapshots[pvcName.String()]
+		if !ok || volumeBackupStatus.Phase == status.Phase.Next() {
+			continue
+		}
+
+		deleted, err := s.deleteHelperPod(ctx, requestName, "backup", pvcName)
+		if err != nil {
+			return err
+		} else if !deleted {
+			stillDeleting = true
+			continue
+		}
+
+		volumeBackupStatus.Phase = status.Phase.Next()
+		status.Snapshots[pvcName.String()] = volumeBackupStatus
+	}
+
+	if !stillDeleting {
+		status.Phase = status.Phase.Next()
+	}
+	return nil
+}
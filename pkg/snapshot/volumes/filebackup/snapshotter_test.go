@@ -0,0 +1,182 @@
+package filebackup
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/loft-sh/vcluster/pkg/snapshot/volumes"
+	"github.com/loft-sh/vcluster/pkg/util/loghelper"
+	"github.com/loft-sh/vcluster/pkg/util/translate"
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func newTestVolumeSnapshotter(t *testing.T, objects ...*corev1.PersistentVolumeClaim) (*VolumeSnapshotter, *fake.Clientset) {
+	t.Helper()
+
+	kubeClient := fake.NewSimpleClientset()
+	for _, pvc := range objects {
+		_, err := kubeClient.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(context.Background(), pvc, metav1.CreateOptions{})
+		assert.NilError(t, err)
+		if pvc.Spec.VolumeName == "" {
+			continue
+		}
+
+		storageClassName := ""
+		if pvc.Spec.StorageClassName != nil {
+			storageClassName = *pvc.Spec.StorageClassName
+		}
+		_, err = kubeClient.CoreV1().PersistentVolumes().Create(context.Background(), &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: pvc.Spec.VolumeName},
+			Spec: corev1.PersistentVolumeSpec{
+				StorageClassName: storageClassName,
+				ClaimRef:         &corev1.ObjectReference{Namespace: pvc.Namespace, Name: pvc.Name},
+			},
+		}, metav1.CreateOptions{})
+		assert.NilError(t, err)
+	}
+
+	snapshotter, err := NewVolumeSnapshotter(newTestConfig(0), kubeClient, &fakePodConfig{}, record.NewFakeRecorder(100), loghelper.New("filebackup-test"))
+	assert.NilError(t, err)
+	return snapshotter, kubeClient
+}
+
+func newTestPVC(name, volumeName, storageClassName string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      name,
+			Labels:    map[string]string{translate.MarkerLabel: "vcluster"},
+			Annotations: map[string]string{
+				"pv.kubernetes.io/bind-completed": "yes",
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			VolumeName:       volumeName,
+			StorageClassName: &storageClassName,
+		},
+	}
+}
+
+func TestVolumeSnapshotter(t *testing.T) {
+	ctx := context.Background()
+	requestObj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "snapshot-1"}}
+	pvcName := types.NamespacedName{Namespace: "test", Name: "data"}
+
+	t.Run("backup succeeds", func(t *testing.T) {
+		snapshotter, kubeClient := newTestVolumeSnapshotter(t,
+			newTestPVC("data", "pv-data", testStorageClass),
+			newTestPVC("csi", "pv-csi", "csi-hostpath"),
+			newTestPVC("unbound", "", testStorageClass),
+		)
+		request := &volumes.SnapshotsRequest{}
+		status := &volumes.SnapshotsStatus{}
+
+		// only the bound PVC of a file backup storage class is backed up
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+		assert.Equal(t, status.Phase, volumes.RequestPhaseInProgress)
+		assert.Equal(t, len(request.Requests), 1)
+		assert.Equal(t, request.Requests[0].PersistentVolumeClaim.Name, "data")
+		assert.Equal(t, len(request.Requests[0].PersistentVolumeClaim.Annotations), 0)
+
+		// the helper pod is created
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+		assert.Equal(t, status.Phase, volumes.RequestPhaseInProgress)
+		assert.Equal(t, status.Snapshots[pvcName.String()].Phase, volumes.RequestPhaseInProgress)
+		assert.Equal(t, status.Snapshots[pvcName.String()].SnapshotHandle, "s3://bucket/snapshot-1/test/data.tar.gz")
+
+		// the helper pod has finished and is cleaned up
+		setHelperPodPhase(t, kubeClient, "backup", "snapshot-1", pvcName, corev1.PodSucceeded, "")
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+		assert.Equal(t, status.Snapshots[pvcName.String()].Phase, volumes.RequestPhaseCompletedCleaningUp)
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+		assert.Equal(t, status.Phase, volumes.RequestPhaseInProgress)
+		assertHelperPodDeleted(t, kubeClient, "backup", "snapshot-1", pvcName)
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+		assert.Equal(t, status.Snapshots[pvcName.String()].Phase, volumes.RequestPhaseCompleted)
+		assert.Equal(t, status.Phase, volumes.RequestPhaseCompleted)
+		assertEvent(t, snapshotter.eventRecorder, "VolumeBackupCreated")
+
+		// the request is done
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+		assert.Equal(t, status.Phase, volumes.RequestPhaseCompleted)
+	})
+
+	t.Run("backup fails", func(t *testing.T) {
+		snapshotter, kubeClient := newTestVolumeSnapshotter(t, newTestPVC("data", "pv-data", testStorageClass))
+		request := &volumes.SnapshotsRequest{}
+		status := &volumes.SnapshotsStatus{}
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+
+		// the failed helper pod is cleaned up as well
+		setHelperPodPhase(t, kubeClient, "backup", "snapshot-1", pvcName, corev1.PodFailed, "bucket not found")
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+		assert.Equal(t, status.Snapshots[pvcName.String()].Phase, volumes.RequestPhaseFailedCleaningUp)
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+		assertHelperPodDeleted(t, kubeClient, "backup", "snapshot-1", pvcName)
+		assert.Equal(t, status.Snapshots[pvcName.String()].Phase, volumes.RequestPhaseFailed)
+		assert.Equal(t, status.Snapshots[pvcName.String()].Error.Message, "helper pod test/"+helperPodName("backup", "data", "snapshot-1")+" has failed: bucket not found")
+		assert.Equal(t, status.Phase, volumes.RequestPhaseFailed)
+		assert.Equal(t, status.Error.Message, "all volumes have failed")
+		assertEvent(t, snapshotter.eventRecorder, "VolumeBackupFailed")
+		assertEvent(t, snapshotter.eventRecorder, "VolumeBackupsFailed")
+	})
+
+	t.Run("request is canceled", func(t *testing.T) {
+		snapshotter, kubeClient := newTestVolumeSnapshotter(t, newTestPVC("data", "pv-data", testStorageClass))
+		request := &volumes.SnapshotsRequest{}
+		status := &volumes.SnapshotsStatus{}
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+
+		// the running helper pod is deleted
+		status.Phase = volumes.RequestPhaseCanceling
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+		assert.Equal(t, status.Phase, volumes.RequestPhaseCanceling)
+		assertHelperPodDeleted(t, kubeClient, "backup", "snapshot-1", pvcName)
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+		assert.Equal(t, status.Phase, volumes.RequestPhaseCanceled)
+		assert.Equal(t, status.Snapshots[pvcName.String()].Phase, volumes.RequestPhaseCanceled)
+	})
+
+	t.Run("no file backup storage classes", func(t *testing.T) {
+		snapshotter, _ := newTestVolumeSnapshotter(t, newTestPVC("data", "pv-data", testStorageClass))
+		snapshotter.storageClasses = nil
+		request := &volumes.SnapshotsRequest{}
+		status := &volumes.SnapshotsStatus{}
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+		assert.NilError(t, snapshotter.Reconcile(ctx, requestObj, "snapshot-1", request, status))
+		assert.Equal(t, len(request.Requests), 0)
+		assert.Equal(t, status.Phase, volumes.RequestPhaseCompleted)
+	})
+
+	t.Run("invalid phase", func(t *testing.T) {
+		snapshotter, _ := newTestVolumeSnapshotter(t)
+		err := snapshotter.Reconcile(ctx, requestObj, "snapshot-1", &volumes.SnapshotsRequest{}, &volumes.SnapshotsStatus{Phase: volumes.RequestPhaseUndefined})
+		assert.ErrorContains(t, err, "invalid snapshot request phase")
+	})
+}
+
+// assertEvent checks that the next recorded event has the given reason.
+func assertEvent(t *testing.T, eventRecorder record.EventRecorder, reason string) {
+	t.Helper()
+
+	events := eventRecorder.(*record.FakeRecorder).Events
+	for {
+		select {
+		case event := <-events:
+			if strings.Contains(event, " "+reason+" ") {
+				return
+			}
+		default:
+			t.Fatalf("event %s was not recorded", reason)
+		}
+	}
+}
@@ -13,7 +13,11 @@ import (
 	"github.com/loft-sh/vcluster/pkg/patcher"
 	"github.com/loft-sh/vcluster/pkg/syncer/synccontext"
 	syncertypes "github.com/loft-sh/vcluster/pkg/syncer/types"
+	"github.com/loft-sh/vcluster/pkg/tracing"
 	"github.com/loft-sh/vcluster/pkg/util/translate"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
@@ -211,6 +215,7 @@ func (r *SyncController) Reconcile(ctx context.Context, vReq reconcile.Request)
 			}
 		}
 
+		span := r.startSpan(syncContext, "Sync", vObj, pObj)
 		result, err := r.genericSyncer.Sync(syncContext, &synccontext.SyncEvent[client.Object]{
 			VirtualOld: vObjOld,
 			Virtual:    vObj,
@@ -218,17 +223,20 @@ func (r *SyncController) Reconcile(ctx context.Context, vReq reconcile.Request)
 			HostOld: pObjOld,
 			Host:    pObj,
 		})
+		endSpan(span, err)
 		if err != nil {
 			return ctrl.Result{}, fmt.Errorf("sync: %w", err)
 		}
 
 		return result, nil
 	} else if vObj != nil {
+		span := r.startSpan(syncContext, "SyncToHost", vObj, nil)
 		result, err := r.genericSyncer.SyncToHost(syncContext, &synccontext.SyncToHostEvent[client.Object]{
 			HostOld: pObjOld,
 
 			Virtual: vObj,
 		})
+		endSpan(span, err)
 		if err != nil {
 			return ctrl.Result{}, fmt.Errorf("sync to host: %w", err)
 		}
@@ -242,11 +250,13 @@ func (r *SyncController) Reconcile(ctx context.Context, vReq reconcile.Request)
 			}
 		}
 
+		span := r.startSpan(syncContext, "SyncToVirtual", pObj, nil)
 		result, err := r.genericSyncer.SyncToVirtual(syncContext, &synccontext.SyncToVirtualEvent[client.Object]{
 			VirtualOld: vObjOld,
 
 			Host: pObj,
 		})
+		endSpan(span, err)
 		if err != nil {
 			return ctrl.Result{}, fmt.Errorf("sync to virtual: %w", err)
 		}
@@ -257,6 +267,40 @@ func (r *SyncController) Reconcile(ctx context.Context, vReq reconcile.Request)
 	return ctrl.Result{}, nil
 }
 
+// startSpan starts a span for a single sync call and stores it in the sync context. If the source object
+// carries a trace context and the target object doesn't exist yet, the span continues the trace of the request
+// that created the source object, otherwise the trace is only linked.
+func (r *SyncController) startSpan(ctx *synccontext.SyncContext, name string, source, target client.Object) oteltrace.Span {
+	opts := []oteltrace.SpanStartOption{
+		oteltrace.WithAttributes(
+			attribute.String("vcluster.syncer", r.syncer.Name()),
+			attribute.String("k8s.object.namespace", source.GetNamespace()),
+			attribute.String("k8s.object.name", source.GetName()),
+		),
+	}
+
+	parent := ctx.Context
+	if remote := tracing.SpanContextFromObject(source); remote.IsValid() {
+		if target == nil {
+			parent = oteltrace.ContextWithRemoteSpanContext(parent, remote)
+		} else {
+			opts = append(opts, oteltrace.WithLinks(oteltrace.Link{SpanContext: remote}))
+		}
+	}
+
+	var span oteltrace.Span
+	ctx.Context, span = tracing.Tracer().Start(parent, r.syncer.Name()+"."+name, opts...)
+	return span
+}
+
+func endSpan(span oteltrace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
 func (r *SyncController) getObjects(ctx *synccontext.SyncContext, vReq, pReq ctrl.Request) (vObjOld, vObj, pObjOld, pObj client.Object, err error) {
 	// get virtual object
 	exclude, vObj, err := r.getVirtualObject(ctx, vReq.NamespacedName)
@@ -0,0 +1,148 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	vclusterconfig "github.com/loft-sh/vcluster/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"k8s.io/client-go/rest"
+	"k8s.io/component-base/tracing"
+	tracingapi "k8s.io/component-base/tracing/api/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// TraceParentAnnotation holds the W3C trace context of the request or reconcile that created an object
+	TraceParentAnnotation = "vcluster.loft.sh/traceparent"
+
+	// ServiceName is the service name reported to the collector
+	ServiceName = "vcluster"
+
+	// DefaultEndpoint is the OTLP gRPC endpoint used if none is configured
+	DefaultEndpoint = "localhost:4317"
+
+	instrumentationName = "github.com/loft-sh/vcluster"
+)
+
+var (
+	providerMu sync.RWMutex
+	provider   = tracing.NewNoopTracerProvider()
+	enabled    bool
+)
+
+// Init creates the OTLP exporter and registers the resulting tracer provider globally. If tracing
+// is disabled in the config, a noop provider is kept and the returned shutdown func does nothing.
+func Init(ctx context.Context, vClusterName string, tracingConfig vclusterconfig.Tracing) (func(context.Context) error, error) {
+	if !tracingConfig.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	endpoint := tracingConfig.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	samplingRate := tracingConfig.SamplingRatePerMillion
+
+	tp, err := tracing.NewProvider(ctx, &tracingapi.TracingConfiguration{
+		Endpoint:               &endpoint,
+		SamplingRatePerMillion: &samplingRate,
+	}, nil, []resource.Option{
+		resource.WithAttributes(
+			semconv.ServiceName(ServiceName),
+			attribute.String("vcluster.name", vClusterName),
+		),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create tracer provider: %w", err)
+	}
+
+	SetProvider(tp)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(tracing.Propagators())
+	klog.FromContext(ctx).Info("Exporting traces", "endpoint", endpoint, "samplingRatePerMillion", samplingRate)
+	return tp.Shutdown, nil
+}
+
+// SetProvider replaces the tracer provider and enables tracing, a nil provider disables tracing again
+func SetProvider(tp tracing.TracerProvider) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+
+	if tp == nil {
+		provider = tracing.NewNoopTracerProvider()
+		enabled = false
+		return
+	}
+
+	provider = tp
+	enabled = true
+}
+
+// Enabled returns true if an exporter was configured via Init
+func Enabled() bool {
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+
+	return enabled
+}
+
+// Provider returns the configured tracer provider or a noop provider if tracing is disabled
+func Provider() tracing.TracerProvider {
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+
+	return provider
+}
+
+// Tracer returns the vCluster tracer
+func Tracer() oteltrace.Tracer {
+	return Provider().Tracer(instrumentationName)
+}
+
+// WrapConfig makes sure requests made with the given rest config are traced and carry the trace context
+func WrapConfig(restConfig *rest.Config) {
+	if restConfig == nil || !Enabled() {
+		return
+	}
+
+	restConfig.Wrap(tracing.WrapperFor(Provider()))
+}
+
+// InjectIntoObject stores the span context of ctx in the traceparent annotation of obj
+func InjectIntoObject(ctx context.Context, obj client.Object) {
+	if obj == nil || !Enabled() || !oteltrace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+
+	carrier := propagation.MapCarrier{}
+	tracing.Propagators().Inject(ctx, carrier)
+	traceParent := carrier.Get("traceparent")
+	if traceParent == "" {
+		return
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[TraceParentAnnotation] = traceParent
+	obj.SetAnnotations(annotations)
+}
+
+// SpanContextFromObject returns the remote span context stored in the traceparent annotation of obj
+func SpanContextFromObject(obj client.Object) oteltrace.SpanContext {
+	if obj == nil || obj.GetAnnotations()[TraceParentAnnotation] == "" {
+		return oteltrace.SpanContext{}
+	}
+
+	carrier := propagation.MapCarrier{"traceparent": obj.GetAnnotations()[TraceParentAnnotation]}
+	return oteltrace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), carrier))
+}
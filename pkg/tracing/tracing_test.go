@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInjectIntoObject(t *testing.T) {
+	spanContext := oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    oteltrace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:     oteltrace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceFlags: oteltrace.FlagsSampled,
+	})
+	ctx := oteltrace.ContextWithSpanContext(context.Background(), spanContext)
+
+	// disabled tracing shouldn't touch the object
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+	InjectIntoObject(ctx, pod)
+	assert.Equal(t, len(pod.Annotations), 0)
+
+	SetProvider(Provider())
+	defer SetProvider(nil)
+
+	InjectIntoObject(ctx, pod)
+	assert.Equal(t, pod.Annotations[TraceParentAnnotation], "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01")
+
+	extracted := SpanContextFromObject(pod)
+	assert.Assert(t, extracted.IsRemote())
+	assert.Equal(t, extracted.TraceID(), spanContext.TraceID())
+	assert.Equal(t, extracted.SpanID(), spanContext.SpanID())
+
+	// invalid annotations are ignored
+	pod.Annotations[TraceParentAnnotation] = "invalid"
+	assert.Assert(t, !SpanContextFromObject(pod).IsValid())
+}
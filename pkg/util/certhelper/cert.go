@@ -38,6 +38,9 @@ type Config struct {
 	Organization []string
 	AltNames     AltNames
 	Usages       []x509.ExtKeyUsage
+
+	// NotAfter is the time the certificate expires. Defaults to one year from now.
+	NotAfter time.Time
 }
 
 // AltNames contains the domain names and IP addresses that will be added
@@ -60,6 +63,10 @@ func NewSignedCert(cfg Config, key crypto.Signer, caCert *x509.Certificate, caKe
 	if len(cfg.Usages) == 0 {
 		return nil, errors.New("must specify at least one ExtKeyUsage")
 	}
+	notAfter := cfg.NotAfter
+	if notAfter.IsZero() {
+		notAfter = time.Now().Add(duration365d)
+	}
 
 	certTmpl := x509.Certificate{
 		Subject: pkix.Name{
@@ -70,7 +77,7 @@ func NewSignedCert(cfg Config, key crypto.Signer, caCert *x509.Certificate, caKe
 		IPAddresses:  cfg.AltNames.IPs,
 		SerialNumber: serial,
 		NotBefore:    caCert.NotBefore,
-		NotAfter:     time.Now().Add(duration365d).UTC(),
+		NotAfter:     notAfter.UTC(),
 		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
 		ExtKeyUsage:  cfg.Usages,
 	}
@@ -0,0 +1,175 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLookahead limits how far Next searches for a matching time, a schedule like "0 0 30 2 *" never matches
+const maxLookahead = 5 * 366 * 24 * time.Hour
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7},
+}
+
+// Schedule is a parsed standard 5 field cron expression
+type Schedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek map[int]bool
+
+	// restricted day fields are combined with OR as in the cron man page
+	dayOfMonthStar, dayOfWeekStar bool
+}
+
+// Parse parses a standard cron expression with the fields minute, hour, day of month, month and
+// day of week. Lists, ranges, steps and the macros @yearly, @monthly, @weekly, @daily and @hourly are supported.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if macro, ok := macros[spec]; ok {
+		spec = macro
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("expected %d fields in cron expression %q, got %d", len(fields), spec, len(parts))
+	}
+
+	values := make([]map[int]bool, len(fields))
+	for i, part := range parts {
+		value, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("parse %s of cron expression %q: %w", fields[i].name, spec, err)
+		}
+
+		values[i] = value
+	}
+
+	// sunday can be either 0 or 7
+	if values[4][7] {
+		values[4][0] = true
+	}
+
+	return &Schedule{
+		minute:         values[0],
+		hour:           values[1],
+		dayOfMonth:     values[2],
+		month:          values[3],
+		dayOfWeek:      values[4],
+		dayOfMonthStar: strings.HasPrefix(parts[2], "*"),
+		dayOfWeekStar:  strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// Next returns the first time after t that matches the schedule or the zero time if there is none
+func (s *Schedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	end := t.Add(maxLookahead)
+	for next.Before(end) {
+		switch {
+		case !s.month[int(next.Month())]:
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+		case !s.matchesDay(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+		case !s.hour[next.Hour()]:
+			next = next.Truncate(time.Hour).Add(time.Hour)
+		case !s.minute[next.Minute()]:
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dayOfMonth := s.dayOfMonth[t.Day()]
+	dayOfWeek := s.dayOfWeek[int(t.Weekday())]
+	if s.dayOfMonthStar || s.dayOfWeekStar {
+		return dayOfMonth && dayOfWeek
+	}
+
+	return dayOfMonth || dayOfWeek
+}
+
+func parseField(expression string, f field) (map[int]bool, error) {
+	values := map[int]bool{}
+	for _, item := range strings.Split(expression, ",") {
+		rangeExpression, step := item, 1
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			var err error
+			step, err = strconv.Atoi(item[idx+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %q", item)
+			}
+
+			rangeExpression = item[:idx]
+		}
+
+		start, end := f.min, f.max
+		switch {
+		case rangeExpression == "*":
+		case strings.Contains(rangeExpression, "-"):
+			bounds := strings.SplitN(rangeExpression, "-", 2)
+			var err error
+			start, err = parseValue(bounds[0], f)
+			if err != nil {
+				return nil, err
+			}
+			end, err = parseValue(bounds[1], f)
+			if err != nil {
+				return nil, err
+			}
+			if start > end {
+				return nil, fmt.Errorf("invalid range %q", rangeExpression)
+			}
+		default:
+			value, err := parseValue(rangeExpression, f)
+			if err != nil {
+				return nil, err
+			}
+
+			start = value
+			if step == 1 {
+				end = value
+			}
+		}
+
+		for i := start; i <= end; i += step {
+			values[i] = true
+		}
+	}
+
+	return values, nil
+}
+
+func parseValue(value string, f field) (int, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	} else if number < f.min || number > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d-%d]", number, f.min, f.max)
+	}
+
+	return number, nil
+}
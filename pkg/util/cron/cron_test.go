@@ -0,0 +1,42 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestNext(t *testing.T) {
+	from := time.Date(2025, time.January, 31, 10, 17, 30, 0, time.UTC)
+	testCases := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{spec: "* * * * *", expected: time.Date(2025, time.January, 31, 10, 18, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", expected: time.Date(2025, time.January, 31, 10, 30, 0, 0, time.UTC)},
+		{spec: "0 3 * * *", expected: time.Date(2025, time.February, 1, 3, 0, 0, 0, time.UTC)},
+		{spec: "@hourly", expected: time.Date(2025, time.January, 31, 11, 0, 0, 0, time.UTC)},
+		{spec: "@weekly", expected: time.Date(2025, time.February, 2, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * 7", expected: time.Date(2025, time.February, 2, 0, 0, 0, 0, time.UTC)},
+		{spec: "30 9-17/4 * * 1-5", expected: time.Date(2025, time.January, 31, 13, 30, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", expected: time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 15 * 1", expected: time.Date(2025, time.February, 3, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 30 2 *", expected: time.Time{}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.spec, func(t *testing.T) {
+			schedule, err := Parse(testCase.spec)
+			assert.NilError(t, err)
+			assert.Equal(t, schedule.Next(from), testCase.expected)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		_, err := Parse(spec)
+		assert.Assert(t, err != nil, spec)
+	}
+}
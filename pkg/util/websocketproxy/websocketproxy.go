@@ -1,6 +1,7 @@
 // Package websocketproxy is a reverse proxy for WebSocket connections.
 // Originally from https://github.com/koding/websocketproxy
-// Changes made: added Ping handler to connPub, which sends ping to connBackend
+// Changes made: added Ping handler to connPub, which sends ping to connBackend,
+// added OnConnect and OnMessage hooks to observe the proxied messages
 package websocketproxy
 
 import (
@@ -50,6 +51,14 @@ type WebsocketProxy struct {
 	//  Dialer contains options for connecting to the backend WebSocket server.
 	//  If nil, DefaultDialer is used.
 	Dialer *websocket.Dialer
+
+	// OnConnect, if non-nil, is called with the negotiated subprotocol once
+	// both connections are established.
+	OnConnect func(subprotocol string)
+
+	// OnMessage, if non-nil, is called for every proxied data message before
+	// it is forwarded. It is called concurrently for both directions.
+	OnMessage func(fromBackend bool, messageType int, data []byte)
 }
 
 // ProxyHandler returns a new http.Handler interface that reverse proxies the
@@ -187,6 +196,7 @@ func (w *WebsocketProxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	errClient := make(chan error, 1)
 	errBackend := make(chan error, 1)
 	replicateWebsocketConn := func(dst, src *websocket.Conn, errc chan error) {
+		fromBackend := src == connBackend
 		for {
 			msgType, msg, err := src.ReadMessage()
 			if err != nil {
@@ -200,6 +210,9 @@ func (w *WebsocketProxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 				_ = dst.WriteMessage(websocket.CloseMessage, m)
 				break
 			}
+			if w.OnMessage != nil {
+				w.OnMessage(fromBackend, msgType, msg)
+			}
 			err = dst.WriteMessage(msgType, msg)
 			if err != nil {
 				errc <- err
@@ -224,6 +237,10 @@ func (w *WebsocketProxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		return err
 	})
 
+	if w.OnConnect != nil {
+		w.OnConnect(connBackend.Subprotocol())
+	}
+
 	go replicateWebsocketConn(connPub, connBackend, errClient)
 	go replicateWebsocketConn(connBackend, connPub, errBackend)
 